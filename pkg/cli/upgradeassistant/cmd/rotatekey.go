@@ -0,0 +1,292 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	systemmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/system/repository/models"
+	usermodels "github.com/koderover/zadig/pkg/microservice/user/core/repository/models"
+	"github.com/koderover/zadig/pkg/tool/crypto"
+	"github.com/koderover/zadig/pkg/tool/log"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+func init() {
+	rootCmd.AddCommand(rotateKeyCmd)
+
+	rotateKeyCmd.Flags().Bool("dry-run", false, "only report the records to be re-encrypted")
+	rotateKeyCmd.Flags().Bool("skip-mysql", false, "skip the tables in the user database")
+	_ = viper.BindPFlag("dryRun", rotateKeyCmd.Flags().Lookup("dry-run"))
+	_ = viper.BindPFlag("skipMysql", rotateKeyCmd.Flags().Lookup("skip-mysql"))
+}
+
+var rotateKeyCmd = &cobra.Command{
+	Use:   "rotate-key",
+	Short: "re-encrypt stored credentials with the primary aes key",
+	Long: `re-encrypt stored credentials with the primary aes key in the keyring.
+
+Add the new key to the keyring, make it the primary one and restart the services before running this command.
+Records already encrypted with the primary key are skipped, so the command can be run again to resume an
+interrupted rotation.
+
+The tokens of the attached clusters are encrypted with the key used when the agents were installed and are kept
+in the agents, they are not re-encrypted by this command. Keep the old keys in the keyring, otherwise all attached
+clusters are disconnected until their agents are installed again with new tokens.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return preRun()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := rotateKey(viper.GetBool("dryRun"), viper.GetBool("skipMysql")); err != nil {
+			log.Fatal(err)
+		}
+	},
+	PostRun: func(cmd *cobra.Command, args []string) {
+		if err := postRun(); err != nil {
+			fmt.Println(err)
+		}
+	},
+}
+
+// encryptedField is a field encrypted by crypto.AesEncrypt, nested fields are separated by dots.
+// If array is set, the field is looked up in each element of the array.
+type encryptedField struct {
+	collection string
+	array      string
+	field      string
+}
+
+func (f encryptedField) path() string {
+	if f.array == "" {
+		return f.field
+	}
+	return f.array + "." + f.field
+}
+
+// update returns the filter and the update which replace one occurrence of value, the positional
+// operator is used to update the element holding the value if the field is in an array.
+func (f encryptedField) update(id interface{}, value, encrypted string) (bson.M, bson.M) {
+	target := f.field
+	if f.array != "" {
+		target = f.array + ".$." + f.field
+	}
+	return bson.M{"_id": id, f.path(): value}, bson.M{"$set": bson.M{target: encrypted}}
+}
+
+// values returns the values of the field in the document.
+func (f encryptedField) values(doc bson.M) []string {
+	if f.array == "" {
+		if value, ok := lookupField(doc, f.field); ok {
+			return []string{value}
+		}
+		return nil
+	}
+
+	var elements []interface{}
+	switch a := lookupValue(doc, f.array).(type) {
+	case bson.A:
+		elements = a
+	case []interface{}:
+		elements = a
+	}
+	var values []string
+	for _, element := range elements {
+		if value, ok := lookupField(element, f.field); ok && value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+var mongoEncryptedFields = []encryptedField{
+	{collection: commonmodels.S3Storage{}.TableName(), field: "encryptedSk"},
+	{collection: commonmodels.SecretProvider{}.TableName(), field: "vault.token"},
+	{collection: systemmodels.AuditLogConfig{}.TableName(), field: "webhook.token"},
+	{collection: commonmodels.WorkflowV4{}.TableName(), array: "notify_ctls", field: "webhook_secret"},
+	{collection: commonmodels.EventSubscription{}.TableName(), field: "secret"},
+}
+
+type rotateKeyResult struct {
+	total, reEncrypted, failed int
+}
+
+func (r *rotateKeyResult) String() string {
+	return fmt.Sprintf("total: %d, re-encrypted: %d, failed: %d", r.total, r.reEncrypted, r.failed)
+}
+
+func rotateKey(dryRun, skipMysql bool) error {
+	keyring := crypto.GetKeyring()
+	log.Infof("Re-encrypting credentials with key version %d, dry run: %v", keyring.Primary, dryRun)
+
+	failed := false
+	for _, f := range mongoEncryptedFields {
+		res, err := reEncryptMongoField(keyring, f, dryRun)
+		if err != nil {
+			return fmt.Errorf("failed to re-encrypt %s.%s: %s", f.collection, f.path(), err)
+		}
+		log.Infof("%s.%s: %s", f.collection, f.path(), res)
+		failed = failed || res.failed > 0
+	}
+
+	if !skipMysql {
+		res, err := reEncryptUserMFA(keyring, dryRun)
+		if err != nil {
+			return fmt.Errorf("failed to re-encrypt %s.secret: %s", usermodels.UserMFA{}.TableName(), err)
+		}
+		log.Infof("%s.secret: %s", usermodels.UserMFA{}.TableName(), res)
+		failed = failed || res.failed > 0
+	}
+
+	if failed {
+		return fmt.Errorf("some records can't be re-encrypted, keep the old keys in the keyring and check the logs above")
+	}
+	log.Info("Key rotation finished, keep the old keys in the keyring as long as the attached cluster agents use tokens issued with them")
+	return nil
+}
+
+// reEncryptMongoField re-encrypts the field document by document, each update is conditioned on the old value
+// so that the records changed by the services during the rotation are left untouched.
+func reEncryptMongoField(keyring *crypto.Keyring, f encryptedField, dryRun bool) (*rotateKeyResult, error) {
+	ctx := context.Background()
+	coll := mongotool.Database(config.MongoDatabase()).Collection(f.collection)
+
+	query := bson.M{f.path(): bson.M{"$type": "string", "$ne": ""}}
+	cursor, err := coll.Find(ctx, query, options.Find().SetProjection(bson.M{f.path(): 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	res := &rotateKeyResult{}
+	for cursor.Next(ctx) {
+		doc := bson.M{}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		for _, value := range f.values(doc) {
+			res.total++
+
+			encrypted, changed, err := keyring.ReEncrypt(value)
+			if err != nil {
+				log.Errorf("Failed to re-encrypt %s.%s of %v: %s", f.collection, f.path(), doc["_id"], err)
+				res.failed++
+				continue
+			}
+			if !changed {
+				continue
+			}
+			res.reEncrypted++
+			if dryRun {
+				continue
+			}
+
+			filter, update := f.update(doc["_id"], value, encrypted)
+			if _, err = coll.UpdateOne(ctx, filter, update); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, cursor.Err()
+}
+
+func lookupField(doc interface{}, field string) (string, bool) {
+	value, ok := lookupValue(doc, field).(string)
+	return value, ok
+}
+
+func lookupValue(doc interface{}, field string) interface{} {
+	cur := doc
+	for _, p := range strings.Split(field, ".") {
+		switch m := cur.(type) {
+		case bson.M:
+			cur = m[p]
+		case bson.D:
+			cur = m.Map()[p]
+		default:
+			return nil
+		}
+	}
+	return cur
+}
+
+func reEncryptUserMFA(keyring *crypto.Keyring, dryRun bool) (*rotateKeyResult, error) {
+	db, err := sql.Open("mysql", fmt.Sprintf(
+		"%s:%s@tcp(%s)/%s?charset=utf8",
+		config.MysqlUser(), config.MysqlPassword(), config.MysqlHost(), config.MysqlUserDB(),
+	))
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	table := usermodels.UserMFA{}.TableName()
+	rows, err := db.Query(fmt.Sprintf("SELECT `uid`, `secret` FROM `%s`", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type record struct {
+		uid, secret string
+	}
+	var records []record
+	for rows.Next() {
+		r := record{}
+		if err := rows.Scan(&r.uid, &r.secret); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	res := &rotateKeyResult{}
+	for _, r := range records {
+		res.total++
+		encrypted, changed, err := keyring.ReEncrypt(r.secret)
+		if err != nil {
+			log.Errorf("Failed to re-encrypt %s.secret of %s: %s", table, r.uid, err)
+			res.failed++
+			continue
+		}
+		if !changed {
+			continue
+		}
+		res.reEncrypted++
+		if dryRun {
+			continue
+		}
+
+		_, err = db.Exec(fmt.Sprintf("UPDATE `%s` SET `secret` = ? WHERE `uid` = ? AND `secret` = ?", table), encrypted, r.uid, r.secret)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestEncryptedFieldValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		field encryptedField
+		doc   bson.M
+		want  []string
+	}{
+		{
+			name:  "top level field",
+			field: encryptedField{field: "encryptedSk"},
+			doc:   bson.M{"encryptedSk": "sk"},
+			want:  []string{"sk"},
+		},
+		{
+			name:  "nested field",
+			field: encryptedField{field: "webhook.token"},
+			doc:   bson.M{"webhook": bson.M{"token": "token"}},
+			want:  []string{"token"},
+		},
+		{
+			name:  "nested field in a bson.D",
+			field: encryptedField{field: "vault.token"},
+			doc:   bson.M{"vault": bson.D{{Key: "token", Value: "token"}}},
+			want:  []string{"token"},
+		},
+		{
+			name:  "missing field",
+			field: encryptedField{field: "webhook.token"},
+			doc:   bson.M{"webhook": bson.M{}},
+		},
+		{
+			name:  "field in the elements of an array",
+			field: encryptedField{array: "notify_ctls", field: "webhook_secret"},
+			doc: bson.M{"notify_ctls": bson.A{
+				bson.M{"webhook_type": "webhook", "webhook_secret": "s1"},
+				bson.M{"webhook_type": "dingding"},
+				bson.M{"webhook_type": "webhook", "webhook_secret": ""},
+				bson.D{{Key: "webhook_type", Value: "webhook"}, {Key: "webhook_secret", Value: "s2"}},
+			}},
+			want: []string{"s1", "s2"},
+		},
+		{
+			name:  "null array",
+			field: encryptedField{array: "notify_ctls", field: "webhook_secret"},
+			doc:   bson.M{"notify_ctls": nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.field.values(tt.doc))
+		})
+	}
+}
+
+func TestEncryptedFieldUpdate(t *testing.T) {
+	filter, update := encryptedField{field: "webhook.token"}.update("id", "old", "new")
+	assert.Equal(t, bson.M{"_id": "id", "webhook.token": "old"}, filter)
+	assert.Equal(t, bson.M{"$set": bson.M{"webhook.token": "new"}}, update)
+
+	filter, update = encryptedField{array: "notify_ctls", field: "webhook_secret"}.update("id", "old", "new")
+	assert.Equal(t, bson.M{"_id": "id", "notify_ctls.webhook_secret": "old"}, filter)
+	assert.Equal(t, bson.M{"$set": bson.M{"notify_ctls.$.webhook_secret": "new"}}, update)
+}
+
+func TestMongoEncryptedFields(t *testing.T) {
+	paths := make(map[string]bool)
+	for _, f := range mongoEncryptedFields {
+		paths[f.collection+"."+f.path()] = true
+	}
+	assert.True(t, paths["workflow_v4.notify_ctls.webhook_secret"])
+	assert.True(t, paths["audit_log_config.webhook.token"])
+	assert.True(t, paths["event_subscription.secret"])
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package config
 
 import (
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"strconv"
@@ -90,6 +91,24 @@ func NsqLookupAddrs() []string {
 	return strings.Split(viper.GetString(setting.ENVNsqLookupAddrs), ",")
 }
 
+// VerificationMetricEndpoints returns the Prometheus endpoints which deploy verifications are allowed to query.
+func VerificationMetricEndpoints() []string {
+	endpoints := make([]string, 0)
+	for _, endpoint := range strings.Split(viper.GetString(setting.ENVVerificationMetricEndpoints), ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// AuditLogHashKey returns the key of the operation log hash chain, it is derived from the secret key of the
+// deployment which is not stored in mongodb.
+func AuditLogHashKey() []byte {
+	sum := sha256.Sum256([]byte("audit-log." + configbase.SecretKey()))
+	return sum[:]
+}
+
 func HubServerAddress() string {
 	return configbase.HubServerServiceAddress()
 }
@@ -212,8 +212,17 @@ const (
 type ApprovalType string
 
 const (
-	NativeApproval ApprovalType = "native"
-	LarkApproval   ApprovalType = "lark"
+	NativeApproval   ApprovalType = "native"
+	LarkApproval     ApprovalType = "lark"
+	DingTalkApproval ApprovalType = "dingtalk"
+	WeComApproval    ApprovalType = "wecom"
+)
+
+type JiraLinkMode string
+
+const (
+	JiraLinkModeComment    JiraLinkMode = "comment"
+	JiraLinkModeRemoteLink JiraLinkMode = "remote_link"
 )
 
 type ApproveOrReject string
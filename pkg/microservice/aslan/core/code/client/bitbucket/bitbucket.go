@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitbucket
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/code/client"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/bitbucket"
+)
+
+type Config struct {
+	Address     string `json:"address"`
+	Username    string `json:"username"`
+	AccessToken string `json:"access_token"`
+	EnableProxy bool   `json:"enable_proxy"`
+}
+
+type Client struct {
+	Client *bitbucket.Client
+}
+
+func (c *Config) Open(id int, logger *zap.SugaredLogger) (client.CodeHostClient, error) {
+	cli, err := bitbucket.NewClient(id, c.Address, c.Username, c.AccessToken, config.ProxyHTTPSAddr(), c.EnableProxy)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{Client: cli}, nil
+}
+
+func (c *Client) ListBranches(opt client.ListOpt) ([]*client.Branch, error) {
+	branches, err := c.Client.ListBranches(opt.Namespace, opt.ProjectName)
+	if err != nil {
+		return nil, err
+	}
+	var res []*client.Branch
+	for _, b := range branches {
+		res = append(res, &client.Branch{
+			Name: b.Name,
+		})
+	}
+	return res, nil
+}
+
+func (c *Client) ListTags(opt client.ListOpt) ([]*client.Tag, error) {
+	tags, err := c.Client.ListTags(opt.Namespace, opt.ProjectName)
+	if err != nil {
+		return nil, err
+	}
+	var res []*client.Tag
+	for _, t := range tags {
+		res = append(res, &client.Tag{
+			Name:    t.Name,
+			Message: t.Message,
+		})
+	}
+	return res, nil
+}
+
+func (c *Client) ListPrs(opt client.ListOpt) ([]*client.PullRequest, error) {
+	prs, err := c.Client.ListOpenPullRequests(opt.Namespace, opt.ProjectName, opt.TargeBr)
+	if err != nil {
+		return nil, err
+	}
+	var res []*client.PullRequest
+	for _, pr := range prs {
+		res = append(res, &client.PullRequest{
+			ID:             pr.ID,
+			Number:         pr.ID,
+			Title:          pr.Title,
+			State:          pr.State,
+			User:           pr.Author,
+			AuthorUsername: pr.Author,
+			SourceBranch:   pr.SourceBranch,
+			TargetBranch:   pr.TargetBranch,
+			CreatedAt:      pr.CreatedAt,
+			UpdatedAt:      pr.UpdatedAt,
+		})
+	}
+	return res, nil
+}
+
+func (c *Client) ListNamespaces(keyword string) ([]*client.Namespace, error) {
+	namespaces, err := c.Client.ListNamespaces()
+	if err != nil {
+		return nil, err
+	}
+	var res []*client.Namespace
+	for _, ns := range namespaces {
+		kind := client.OrgKind
+		if ns.Personal {
+			kind = client.UserKind
+		}
+		res = append(res, &client.Namespace{
+			Name: ns.Name,
+			Path: ns.Key,
+			Kind: kind,
+		})
+	}
+	return res, nil
+}
+
+func (c *Client) ListProjects(opt client.ListOpt) ([]*client.Project, error) {
+	repos, err := c.Client.ListRepositories(opt.Namespace, opt.Key, opt.Page, opt.PerPage)
+	if err != nil {
+		return nil, err
+	}
+	var res []*client.Project
+	for _, repo := range repos {
+		res = append(res, &client.Project{
+			ID:            repo.ID,
+			Name:          repo.Slug,
+			Description:   repo.Description,
+			DefaultBranch: repo.DefaultBranch,
+			Namespace:     repo.Namespace,
+		})
+	}
+	return res, nil
+}
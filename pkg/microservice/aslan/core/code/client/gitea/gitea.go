@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitea
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/code/client"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/gitea"
+	giteatool "github.com/koderover/zadig/pkg/tool/gitea"
+)
+
+type Config struct {
+	Address     string `json:"address"`
+	AccessToken string `json:"access_token"`
+	EnableProxy bool   `json:"enable_proxy"`
+}
+
+type Client struct {
+	Client *gitea.Client
+}
+
+func (c *Config) Open(id int, logger *zap.SugaredLogger) (client.CodeHostClient, error) {
+	cli, err := gitea.NewClient(id, c.Address, c.AccessToken, config.ProxyHTTPSAddr(), c.EnableProxy)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{Client: cli}, nil
+}
+
+func (c *Client) ListBranches(opt client.ListOpt) ([]*client.Branch, error) {
+	branches, err := c.Client.ListBranches(opt.Namespace, opt.ProjectName)
+	if err != nil {
+		return nil, err
+	}
+	var res []*client.Branch
+	for _, b := range branches {
+		res = append(res, &client.Branch{
+			Name: b.Name,
+		})
+	}
+	return res, nil
+}
+
+func (c *Client) ListTags(opt client.ListOpt) ([]*client.Tag, error) {
+	tags, err := c.Client.ListTags(opt.Namespace, opt.ProjectName)
+	if err != nil {
+		return nil, err
+	}
+	var res []*client.Tag
+	for _, t := range tags {
+		res = append(res, &client.Tag{
+			Name:    t.Name,
+			Message: t.Message,
+		})
+	}
+	return res, nil
+}
+
+func (c *Client) ListPrs(opt client.ListOpt) ([]*client.PullRequest, error) {
+	prs, err := c.Client.ListOpenPullRequests(opt.Namespace, opt.ProjectName, opt.TargeBr)
+	if err != nil {
+		return nil, err
+	}
+	var res []*client.PullRequest
+	for _, pr := range prs {
+		item := &client.PullRequest{
+			ID:     int(pr.ID),
+			Number: pr.Number,
+			Title:  pr.Title,
+			State:  pr.State,
+		}
+		if pr.User != nil {
+			item.User = pr.User.Login
+			item.AuthorUsername = pr.User.Login
+		}
+		if pr.Head != nil {
+			item.SourceBranch = pr.Head.Ref
+		}
+		if pr.Base != nil {
+			item.TargetBranch = pr.Base.Ref
+		}
+		if pr.CreatedAt != nil {
+			item.CreatedAt = pr.CreatedAt.Unix()
+		}
+		if pr.UpdatedAt != nil {
+			item.UpdatedAt = pr.UpdatedAt.Unix()
+		}
+		res = append(res, item)
+	}
+	return res, nil
+}
+
+func (c *Client) ListNamespaces(keyword string) ([]*client.Namespace, error) {
+	namespaces, err := c.Client.ListNamespaces()
+	if err != nil {
+		return nil, err
+	}
+	var res []*client.Namespace
+	for _, ns := range namespaces {
+		kind := client.OrgKind
+		if ns.Kind == giteatool.NamespaceKindUser {
+			kind = client.UserKind
+		}
+		res = append(res, &client.Namespace{
+			Name: ns.Name,
+			Path: ns.Name,
+			Kind: kind,
+		})
+	}
+	return res, nil
+}
+
+func (c *Client) ListProjects(opt client.ListOpt) ([]*client.Project, error) {
+	repos, err := c.Client.ListRepositories(opt.Namespace, opt.Key, opt.Page, opt.PerPage)
+	if err != nil {
+		return nil, err
+	}
+	var res []*client.Project
+	for _, repo := range repos {
+		res = append(res, &client.Project{
+			ID:            int(repo.ID),
+			Name:          repo.Name,
+			Description:   repo.Description,
+			DefaultBranch: repo.DefaultBranch,
+			Namespace:     opt.Namespace,
+		})
+	}
+	return res, nil
+}
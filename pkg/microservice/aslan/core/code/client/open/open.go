@@ -23,8 +23,10 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/code/client"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/code/client/bitbucket"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/code/client/codehub"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/code/client/gerrit"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/code/client/gitea"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/code/client/gitee"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/code/client/github"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/code/client/gitlab"
@@ -37,12 +39,14 @@ type ClientConfig interface {
 }
 
 var ClientsConfig = map[string]func() ClientConfig{
-	setting.SourceFromGitlab:  func() ClientConfig { return new(gitlab.Config) },
-	setting.SourceFromGithub:  func() ClientConfig { return new(github.Config) },
-	setting.SourceFromGerrit:  func() ClientConfig { return new(gerrit.Config) },
-	setting.SourceFromCodeHub: func() ClientConfig { return new(codehub.Config) },
-	setting.SourceFromGitee:   func() ClientConfig { return new(gitee.Config) },
-	setting.SourceFromGiteeEE: func() ClientConfig { return new(gitee.EEConfig) },
+	setting.SourceFromGitlab:    func() ClientConfig { return new(gitlab.Config) },
+	setting.SourceFromGithub:    func() ClientConfig { return new(github.Config) },
+	setting.SourceFromGerrit:    func() ClientConfig { return new(gerrit.Config) },
+	setting.SourceFromCodeHub:   func() ClientConfig { return new(codehub.Config) },
+	setting.SourceFromGitee:     func() ClientConfig { return new(gitee.Config) },
+	setting.SourceFromGiteeEE:   func() ClientConfig { return new(gitee.EEConfig) },
+	setting.SourceFromBitbucket: func() ClientConfig { return new(bitbucket.Config) },
+	setting.SourceFromGitea:     func() ClientConfig { return new(gitea.Config) },
 }
 
 func OpenClient(ch *systemconfig.CodeHost, log *zap.SugaredLogger) (client.CodeHostClient, error) {
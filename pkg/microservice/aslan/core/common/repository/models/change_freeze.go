@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+const (
+	ChangeFreezeTypeOnce   = "once"
+	ChangeFreezeTypeWeekly = "weekly"
+
+	ChangeFreezeOverridePending  = "pending"
+	ChangeFreezeOverrideApproved = "approved"
+	ChangeFreezeOverrideRejected = "rejected"
+)
+
+// ChangeFreezeWindow blocks deployments to production environments during the given period.
+// An empty ProjectName means the window applies to all projects.
+type ChangeFreezeWindow struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"          json:"id,omitempty"`
+	Name        string             `bson:"name"                   json:"name"`
+	ProjectName string             `bson:"project_name"           json:"project_name"`
+	Description string             `bson:"description"            json:"description"`
+	Enabled     bool               `bson:"enabled"                json:"enabled"`
+	Type        string             `bson:"type"                   json:"type"`
+	// StartAt and EndAt are used by windows of type once, e.g. holiday freezes
+	StartAt int64 `bson:"start_at"               json:"start_at"`
+	EndAt   int64 `bson:"end_at"                 json:"end_at"`
+	// Weekly is used by windows of type weekly
+	Weekly *WeeklyFreezeRange `bson:"weekly,omitempty"       json:"weekly,omitempty"`
+	// Approvers are the uids of the users who are able to approve emergency overrides
+	Approvers  []string `bson:"approvers"              json:"approvers"`
+	CreatedBy  string   `bson:"created_by"             json:"created_by"`
+	CreateTime int64    `bson:"create_time"            json:"create_time"`
+	UpdateBy   string   `bson:"update_by"              json:"update_by"`
+	UpdateTime int64    `bson:"update_time"            json:"update_time"`
+}
+
+// WeeklyFreezeRange is a recurring range in a week, e.g. from Friday 18:00 to Monday 08:00.
+type WeeklyFreezeRange struct {
+	// StartDay and EndDay are the days of week, 0 is Sunday
+	StartDay int `bson:"start_day"  json:"start_day"`
+	// StartTime and EndTime are in the format of 15:04
+	StartTime string `bson:"start_time" json:"start_time"`
+	EndDay    int    `bson:"end_day"    json:"end_day"`
+	EndTime   string `bson:"end_time"   json:"end_time"`
+	// Timezone is an IANA time zone name, the local time zone is used if it is empty
+	Timezone string `bson:"timezone"   json:"timezone"`
+}
+
+func (ChangeFreezeWindow) TableName() string {
+	return "change_freeze_window"
+}
+
+// ChangeFreezeOverride is an emergency override which allows a workflow to deploy during a freeze window.
+type ChangeFreezeOverride struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"     json:"id,omitempty"`
+	ProjectName   string             `bson:"project_name"      json:"project_name"`
+	WorkflowName  string             `bson:"workflow_name"     json:"workflow_name"`
+	Reason        string             `bson:"reason"            json:"reason"`
+	Duration      int64              `bson:"duration"          json:"duration"`
+	Status        string             `bson:"status"            json:"status"`
+	RequestedBy   string             `bson:"requested_by"      json:"requested_by"`
+	RequestedUID  string             `bson:"requested_uid"     json:"requested_uid"`
+	ReviewedBy    string             `bson:"reviewed_by"       json:"reviewed_by"`
+	ReviewedUID   string             `bson:"reviewed_uid"      json:"reviewed_uid"`
+	ReviewComment string             `bson:"review_comment"    json:"review_comment"`
+	CreateTime    int64              `bson:"create_time"       json:"create_time"`
+	ReviewTime    int64              `bson:"review_time"       json:"review_time"`
+	// ExpireAt is set when the override is approved, the override is valid until then
+	ExpireAt int64 `bson:"expire_at"         json:"expire_at"`
+	// Approvals records the windows approved so far, the override is approved after all active windows are approved
+	Approvals []*ChangeFreezeApproval `bson:"approvals"         json:"approvals"`
+}
+
+type ChangeFreezeApproval struct {
+	WindowID    string `bson:"window_id"    json:"window_id"`
+	WindowName  string `bson:"window_name"  json:"window_name"`
+	ApprovedBy  string `bson:"approved_by"  json:"approved_by"`
+	ApprovedUID string `bson:"approved_uid" json:"approved_uid"`
+	ApproveTime int64  `bson:"approve_time" json:"approve_time"`
+}
+
+func (ChangeFreezeOverride) TableName() string {
+	return "change_freeze_override"
+}
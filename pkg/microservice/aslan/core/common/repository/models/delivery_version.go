@@ -45,6 +45,54 @@ type DeliveryVersion struct {
 	CreatedBy           string                   `bson:"created_by"              json:"createdBy"`
 	CreatedAt           int64                    `bson:"created_at"              json:"created_at"`
 	DeletedAt           int64                    `bson:"deleted_at"              json:"deleted_at"`
+	ReleaseNotes        *DeliveryReleaseNotes    `bson:"release_notes,omitempty" json:"releaseNotes,omitempty"`
+}
+
+// DeliveryReleaseNotes describes what changed in a delivery version compared with BaseVersion.
+type DeliveryReleaseNotes struct {
+	BaseVersion string                `bson:"base_version" json:"baseVersion"`
+	Services    []*ReleaseNoteService `bson:"services"     json:"services"`
+	Markdown    string                `bson:"markdown"     json:"markdown"`
+	CreatedAt   int64                 `bson:"created_at"   json:"createdAt"`
+}
+
+type ReleaseNoteService struct {
+	ServiceName   string               `bson:"service_name"   json:"serviceName"`
+	ContainerName string               `bson:"container_name" json:"containerName"`
+	PreviousImage string               `bson:"previous_image" json:"previousImage"`
+	Image         string               `bson:"image"          json:"image"`
+	WorkflowName  string               `bson:"workflow_name"  json:"workflowName"`
+	TaskID        int64                `bson:"task_id"        json:"taskId"`
+	TaskURL       string               `bson:"task_url"       json:"taskUrl"`
+	Commits       []*ReleaseNoteCommit `bson:"commits"        json:"commits"`
+	PullRequests  []*ReleaseNotePR     `bson:"pull_requests"  json:"pullRequests"`
+	Issues        []*ReleaseNoteIssue  `bson:"issues"         json:"issues"`
+}
+
+type ReleaseNoteCommit struct {
+	RepoOwner string `bson:"repo_owner" json:"repoOwner"`
+	RepoName  string `bson:"repo_name"  json:"repoName"`
+	Branch    string `bson:"branch"     json:"branch"`
+	CommitID  string `bson:"commit_id"  json:"commitId"`
+	Message   string `bson:"message"    json:"message"`
+	Author    string `bson:"author"     json:"author"`
+	URL       string `bson:"url"        json:"url"`
+}
+
+type ReleaseNotePR struct {
+	RepoName string `bson:"repo_name" json:"repoName"`
+	Number   int    `bson:"number"    json:"number"`
+	Title    string `bson:"title"     json:"title"`
+	URL      string `bson:"url"       json:"url"`
+}
+
+type ReleaseNoteIssue struct {
+	// Source is either "jira" or "meego"
+	Source string `bson:"source" json:"source"`
+	Key    string `bson:"key"    json:"key"`
+	Title  string `bson:"title"  json:"title"`
+	Status string `bson:"status" json:"status"`
+	URL    string `bson:"url"    json:"url"`
 }
 
 func (DeliveryVersion) TableName() string {
@@ -0,0 +1,68 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+const (
+	EventDeliveryStatusPending   = "pending"
+	EventDeliveryStatusSucceeded = "succeeded"
+	EventDeliveryStatusFailed    = "failed"
+	// EventDeliveryStatusDead marks a delivery which ran out of attempts, it's kept as a dead letter until redelivered
+	EventDeliveryStatusDead = "dead"
+)
+
+// EventSubscription is a system-wide receiver of the CloudEvents emitted by zadig,
+// an empty EventTypes or Projects matches all of them.
+type EventSubscription struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"   json:"id,omitempty"`
+	Name       string             `bson:"name"            json:"name"`
+	URL        string             `bson:"url"             json:"url"`
+	Secret     string             `bson:"secret"          json:"secret,omitempty"`
+	EventTypes []string           `bson:"event_types"     json:"event_types"`
+	Projects   []string           `bson:"projects"        json:"projects"`
+	Enabled    bool               `bson:"enabled"         json:"enabled"`
+	CreatedBy  string             `bson:"created_by"      json:"created_by"`
+	CreateTime int64              `bson:"create_time"     json:"create_time"`
+	UpdateBy   string             `bson:"update_by"       json:"update_by"`
+	UpdateTime int64              `bson:"update_time"     json:"update_time"`
+}
+
+func (EventSubscription) TableName() string {
+	return "event_subscription"
+}
+
+// EventDelivery is the delivery of an event to a subscription, Payload is the CloudEvent in JSON.
+type EventDelivery struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"     json:"id,omitempty"`
+	SubscriptionID string             `bson:"subscription_id"   json:"subscription_id"`
+	EventID        string             `bson:"event_id"          json:"event_id"`
+	EventType      string             `bson:"event_type"        json:"event_type"`
+	ProjectName    string             `bson:"project_name"      json:"project_name"`
+	URL            string             `bson:"url"               json:"url"`
+	Payload        string             `bson:"payload"           json:"payload"`
+	Status         string             `bson:"status"            json:"status"`
+	Attempts       int                `bson:"attempts"          json:"attempts"`
+	ResponseCode   int                `bson:"response_code"     json:"response_code"`
+	Error          string             `bson:"error"             json:"error"`
+	CreateTime     int64              `bson:"create_time"       json:"create_time"`
+	UpdateTime     int64              `bson:"update_time"       json:"update_time"`
+}
+
+func (EventDelivery) TableName() string {
+	return "event_delivery"
+}
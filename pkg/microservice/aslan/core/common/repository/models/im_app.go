@@ -27,6 +27,17 @@ type IMApp struct {
 	AppSecret               string `json:"app_secret" bson:"app_secret"`
 	EncryptKey              string `json:"encrypt_key" bson:"encrypt_key"`
 	LarkDefaultApprovalCode string `json:"-" bson:"lark_default_approval_code"`
+	// DingTalk fields
+	DingTalkAppKey                  string `json:"dingtalk_app_key" bson:"dingtalk_app_key"`
+	DingTalkAppSecret               string `json:"dingtalk_app_secret" bson:"dingtalk_app_secret"`
+	DingTalkDefaultApprovalFormCode string `json:"-" bson:"dingtalk_default_approval_form_code"`
+	// WeCom fields
+	WeComCorpID             string `json:"wecom_corp_id" bson:"wecom_corp_id"`
+	WeComSecret             string `json:"wecom_secret" bson:"wecom_secret"`
+	WeComApprovalTemplateID string `json:"wecom_approval_template_id" bson:"wecom_approval_template_id"`
+	// Slack fields
+	SlackBotToken      string `json:"slack_bot_token" bson:"slack_bot_token"`
+	SlackSigningSecret string `json:"slack_signing_secret" bson:"slack_signing_secret"`
 
 	UpdateTime int64 `json:"update_time" bson:"update_time"`
 }
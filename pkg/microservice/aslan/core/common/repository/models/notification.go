@@ -42,6 +42,7 @@ type Notification struct {
 	IsWorkflowV4 bool                `bson:"is_workflowv4"                json:"is_workflowv4"`
 	ErrInfo      string              `bson:"err_info"                     json:"err_info"`
 	PrTask       *PrTaskInfo         `bson:"pr_task_info,omitempty"       json:"pr_task_info,omitempty"`
+	PreviewEnv   *PreviewEnvInfo     `bson:"preview_env,omitempty"        json:"preview_env,omitempty"`
 	Label        string              `bson:"label"                        json:"label"  `
 	Revision     string              `bson:"revision"                     json:"revision"`
 	RepoOwner    string              `bson:"repo_owner"                   json:"repo_owner"`
@@ -55,6 +56,14 @@ type PrTaskInfo struct {
 	ProductName      string `bson:"product_name,omitempty"              json:"product_name,omitempty"`
 }
 
+type PreviewEnvInfo struct {
+	ProductName string `bson:"product_name" json:"product_name"`
+	EnvName     string `bson:"env_name"     json:"env_name"`
+	BaseEnv     string `bson:"base_env"     json:"base_env"`
+	URL         string `bson:"url"          json:"url"`
+	Deleted     bool   `bson:"deleted"      json:"deleted"`
+}
+
 type NotificationTask struct {
 	ProductName         string            `bson:"product_name"            json:"product_name"`
 	WorkflowName        string            `bson:"workflow_name"           json:"workflow_name"`
@@ -171,6 +180,18 @@ func (n *Notification) CreateCommentBody() (comment string, err error) {
 		}
 	}
 
+	// preview environment comments only carry the routing info of the environment
+	if n.PreviewEnv != nil {
+		content := fmt.Sprintf("预览环境：[%s]({{$.BaseURI}}/v1/projects/detail/%s/envs/detail?envName=%s) 基准环境：%s \n\n 访问时添加请求头 `x-env: %s`", n.PreviewEnv.EnvName, n.PreviewEnv.ProductName, n.PreviewEnv.EnvName, n.PreviewEnv.BaseEnv, n.PreviewEnv.EnvName)
+		if n.PreviewEnv.URL != "" {
+			content = fmt.Sprintf("%s 访问地址：%s", content, n.PreviewEnv.URL)
+		}
+		if n.PreviewEnv.Deleted {
+			content = fmt.Sprintf("预览环境 %s 已销毁", n.PreviewEnv.EnvName)
+		}
+		tmplSource = content
+	}
+
 	tmpl := template.Must(template.New("comment").Parse(tmplSource))
 	buffer := bytes.NewBufferString("")
 
@@ -71,6 +71,13 @@ type Product struct {
 	// For production environment
 	Production bool   `json:"production" bson:"production"`
 	Alias      string `json:"alias" bson:"alias"`
+
+	// PreviewEnv is set only when the environment is created for a pull request
+	PreviewEnv *ProductPreviewEnv `bson:"preview_env,omitempty" json:"preview_env,omitempty"`
+
+	// GitOps is set when the rendered manifests are committed to a git repository instead of being applied
+	GitOps       *ProductGitOps       `bson:"gitops,omitempty"        json:"gitops,omitempty"`
+	GitOpsStatus *ProductGitOpsStatus `bson:"gitops_status,omitempty" json:"gitops_status,omitempty"`
 }
 
 type CreateUpdateCommonEnvCfgArgs struct {
@@ -124,6 +131,50 @@ type ProductShareEnv struct {
 	BaseEnv string `bson:"base_env" json:"base_env"`
 }
 
+type ProductPreviewEnv struct {
+	CodehostID    int    `bson:"codehost_id"    json:"codehost_id"`
+	RepoOwner     string `bson:"repo_owner"     json:"repo_owner"`
+	RepoNamespace string `bson:"repo_namespace" json:"repo_namespace"`
+	RepoName      string `bson:"repo_name"      json:"repo_name"`
+	PR            int    `bson:"pr"             json:"pr"`
+	CommitID      string `bson:"commit_id"      json:"commit_id"`
+	ActiveTime    int64  `bson:"active_time"    json:"active_time"`
+}
+
+type ProductGitOps struct {
+	Enabled       bool   `bson:"enabled"        json:"enabled"`
+	CodehostID    int    `bson:"codehost_id"    json:"codehost_id"`
+	RepoOwner     string `bson:"repo_owner"     json:"repo_owner"`
+	RepoNamespace string `bson:"repo_namespace" json:"repo_namespace"`
+	RepoName      string `bson:"repo_name"      json:"repo_name"`
+	Branch        string `bson:"branch"         json:"branch"`
+	// Path is the directory where the manifests are written to, one file per service
+	Path string `bson:"path" json:"path"`
+	// MergeRequest commits to a new branch and opens a merge request against Branch instead of committing to it directly
+	MergeRequest bool `bson:"merge_request" json:"merge_request"`
+}
+
+func (g *ProductGitOps) GetRepoNamespace() string {
+	if g.RepoNamespace != "" {
+		return g.RepoNamespace
+	}
+	return g.RepoOwner
+}
+
+type ProductGitOpsStatus struct {
+	// CommitSHA is the latest commit of the manifest path in the target branch
+	CommitSHA       string `bson:"commit_sha"        json:"commit_sha"`
+	ExportCommitSHA string `bson:"export_commit_sha" json:"export_commit_sha"`
+	ExportBranch    string `bson:"export_branch"     json:"export_branch"`
+	MergeRequestURL string `bson:"merge_request_url" json:"merge_request_url"`
+	// ManifestDigest is the digest of the exported manifests, used to check whether the target branch is in sync
+	ManifestDigest string `bson:"manifest_digest" json:"manifest_digest"`
+	SyncStatus     string `bson:"sync_status"     json:"sync_status"`
+	Error          string `bson:"error"           json:"error"`
+	ExportTime     int64  `bson:"export_time"     json:"export_time"`
+	CheckTime      int64  `bson:"check_time"      json:"check_time"`
+}
+
 func (Product) TableName() string {
 	return "product"
 }
@@ -133,6 +184,11 @@ func (p *Product) GetNamespace() string {
 	return p.ProductName + "-env-" + p.EnvName
 }
 
+// IsGitOps returns true if the manifests of the product are committed to git instead of being applied to the cluster
+func (p *Product) IsGitOps() bool {
+	return p.GitOps != nil && p.GitOps.Enabled
+}
+
 func (p *Product) GetGroupServiceNames() [][]string {
 	var resp [][]string
 	for _, group := range p.Services {
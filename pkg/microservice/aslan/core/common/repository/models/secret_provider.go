@@ -0,0 +1,52 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+const (
+	SecretProviderVault      = "vault"
+	SecretProviderKubernetes = "kubernetes"
+)
+
+// SecretProvider is the external secret store which secret://path#key references in params and envs are resolved from.
+type SecretProvider struct {
+	ID         primitive.ObjectID        `bson:"_id,omitempty"          json:"id,omitempty"`
+	Type       string                    `bson:"type"                   json:"type"`
+	Vault      *VaultSecretProvider      `bson:"vault,omitempty"        json:"vault,omitempty"`
+	Kubernetes *KubernetesSecretProvider `bson:"kubernetes,omitempty"   json:"kubernetes,omitempty"`
+	UpdateBy   string                    `bson:"update_by"              json:"update_by"`
+	UpdateTime int64                     `bson:"update_time"            json:"update_time"`
+}
+
+type VaultSecretProvider struct {
+	Address string `bson:"address"    json:"address"`
+	// Token is encrypted before being saved
+	Token     string `bson:"token"      json:"token"`
+	Mount     string `bson:"mount"      json:"mount"`
+	Namespace string `bson:"namespace"  json:"namespace"`
+}
+
+type KubernetesSecretProvider struct {
+	ClusterID string `bson:"cluster_id" json:"cluster_id"`
+	// Namespace is the only namespace secrets are read from
+	Namespace string `bson:"namespace"  json:"namespace"`
+}
+
+func (SecretProvider) TableName() string {
+	return "secret_provider"
+}
@@ -23,7 +23,9 @@ type SystemSetting struct {
 	WorkflowConcurrency int64              `bson:"workflow_concurrency" json:"workflow_concurrency"`
 	BuildConcurrency    int64              `bson:"build_concurrency" json:"build_concurrency"`
 	DefaultLogin        string             `bson:"default_login" json:"default_login"`
-	UpdateTime          int64              `bson:"update_time" json:"update_time"`
+	// RestrictProductionExec blocks terminals into production environments unless the user holds the exec_production verb
+	RestrictProductionExec bool  `bson:"restrict_production_exec" json:"restrict_production_exec"`
+	UpdateTime             int64 `bson:"update_time" json:"update_time"`
 }
 
 func (SystemSetting) TableName() string {
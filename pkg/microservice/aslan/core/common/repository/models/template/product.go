@@ -70,6 +70,7 @@ type Product struct {
 	CustomTarRule              *CustomRule          `bson:"custom_tar_rule,omitempty"           json:"custom_tar_rule,omitempty"`
 	DeliveryVersionHook        *DeliveryVersionHook `bson:"delivery_version_hook"               json:"delivery_version_hook"`
 	Public                     bool                 `bson:"public,omitempty"                    json:"public"`
+	PreviewEnv                 *PreviewEnvSetting   `bson:"preview_env,omitempty"               json:"preview_env,omitempty"`
 }
 
 type ServiceInfo struct {
@@ -172,6 +173,27 @@ type DeliveryVersionHook struct {
 	Path     string `bson:"path"       json:"path"`
 }
 
+// PreviewEnvSetting describes how pull requests on the tracked repos get a share-env sub environment
+// of BaseEnv, which is built and deployed by the given WorkflowV4 and recycled after TTL hours without a push.
+type PreviewEnvSetting struct {
+	Enabled      bool              `bson:"enabled"       json:"enabled"`
+	BaseEnv      string            `bson:"base_env"      json:"base_env"`
+	WorkflowName string            `bson:"workflow_name" json:"workflow_name"`
+	Repos        []*PreviewEnvRepo `bson:"repos"         json:"repos"`
+	// Services to be created in the preview environment, all services of the base environment are used if it is empty
+	Services []string `bson:"services"      json:"services"`
+	TTL      int      `bson:"ttl"           json:"ttl"`
+	URL      string   `bson:"url"           json:"url"`
+}
+
+type PreviewEnvRepo struct {
+	CodehostID    int    `bson:"codehost_id"    json:"codehost_id"`
+	RepoOwner     string `bson:"repo_owner"     json:"repo_owner"`
+	RepoNamespace string `bson:"repo_namespace" json:"repo_namespace"`
+	RepoName      string `bson:"repo_name"      json:"repo_name"`
+	Branch        string `bson:"branch"         json:"branch"`
+}
+
 type AutoDeployPolicy struct {
 	Enable bool `bson:"enable" json:"enable"`
 }
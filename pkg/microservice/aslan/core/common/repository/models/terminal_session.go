@@ -0,0 +1,59 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+const (
+	TerminalSessionTypePod  = "pod"
+	TerminalSessionTypeHost = "host"
+
+	TerminalSessionStatusActive   = "active"
+	TerminalSessionStatusFinished = "finished"
+	TerminalSessionStatusFailed   = "failed"
+)
+
+// TerminalSession is an interactive shell opened into a pod or a host, its asciicast recording
+// is stored in the object storage identified by StorageID.
+type TerminalSession struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"  json:"id,omitempty"`
+	Type        string             `bson:"type"           json:"type"`
+	UserName    string             `bson:"user_name"      json:"user_name"`
+	UID         string             `bson:"uid"            json:"uid"`
+	ProjectName string             `bson:"project_name"   json:"project_name"`
+	EnvName     string             `bson:"env_name"       json:"env_name"`
+	Production  bool               `bson:"production"     json:"production"`
+	// ClusterID, Namespace, PodName and ContainerName are set for sessions of type pod
+	ClusterID     string `bson:"cluster_id,omitempty"     json:"cluster_id,omitempty"`
+	Namespace     string `bson:"namespace,omitempty"      json:"namespace,omitempty"`
+	PodName       string `bson:"pod_name,omitempty"       json:"pod_name,omitempty"`
+	ContainerName string `bson:"container_name,omitempty" json:"container_name,omitempty"`
+	// HostID and HostIP are set for sessions of type host
+	HostID    string `bson:"host_id,omitempty"        json:"host_id,omitempty"`
+	HostIP    string `bson:"host_ip,omitempty"        json:"host_ip,omitempty"`
+	Status    string `bson:"status"                   json:"status"`
+	Error     string `bson:"error,omitempty"          json:"error,omitempty"`
+	StorageID string `bson:"storage_id"               json:"storage_id"`
+	ObjectKey string `bson:"object_key"               json:"object_key"`
+	Size      int64  `bson:"size"                     json:"size"`
+	StartTime int64  `bson:"start_time"               json:"start_time"`
+	EndTime   int64  `bson:"end_time"                 json:"end_time"`
+}
+
+func (TerminalSession) TableName() string {
+	return "terminal_session"
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	"github.com/koderover/zadig/pkg/types"
+	"github.com/koderover/zadig/pkg/types/step"
 )
 
 type WorkflowTask struct {
@@ -58,6 +59,50 @@ func (WorkflowTask) TableName() string {
 	return "workflow_task"
 }
 
+// BuildRepos returns the repos checked out by the git step of the zadig-build jobs in the task,
+// only the job which built the image is considered if image is not empty.
+func (t *WorkflowTask) BuildRepos(image string) []*types.Repository {
+	repos := make([]*types.Repository, 0)
+	for _, stage := range t.Stages {
+		for _, job := range stage.Jobs {
+			if job.JobType != string(config.JobZadigBuild) {
+				continue
+			}
+			jobSpec := &JobTaskFreestyleSpec{}
+			if err := IToi(job.Spec, jobSpec); err != nil {
+				continue
+			}
+			if image != "" {
+				matched := false
+				for _, env := range jobSpec.Properties.Envs {
+					if env.Key == "IMAGE" && env.Value == image {
+						matched = true
+						break
+					}
+				}
+				if !matched {
+					continue
+				}
+			}
+			for _, stepTask := range jobSpec.Steps {
+				if stepTask.StepType != config.StepGit {
+					continue
+				}
+				stepSpec := &step.StepGitSpec{}
+				if err := IToi(stepTask.Spec, stepSpec); err != nil {
+					continue
+				}
+				repos = append(repos, stepSpec.Repos...)
+				break
+			}
+			if image != "" {
+				return repos
+			}
+		}
+	}
+	return repos
+}
+
 type StageTask struct {
 	Name      string        `bson:"name"          json:"name"`
 	Status    config.Status `bson:"status"        json:"status"`
@@ -108,6 +153,7 @@ type JobTaskDeploySpec struct {
 	Timeout            int                 `bson:"timeout"                          json:"timeout"                             yaml:"timeout"`
 	ReplaceResources   []Resource          `bson:"replace_resources"                json:"replace_resources"                   yaml:"replace_resources"`
 	RelatedPodLabels   []map[string]string `bson:"-"                                json:"-"                                   yaml:"-"`
+	Verification       *DeployVerification `bson:"verification,omitempty"           json:"verification,omitempty"              yaml:"verification,omitempty"`
 }
 
 type Resource struct {
@@ -127,6 +173,7 @@ type JobTaskHelmDeploySpec struct {
 	ReleaseName        string                   `bson:"release_name"                     json:"release_name"                        yaml:"release_name"`
 	Timeout            int                      `bson:"timeout"                          json:"timeout"                             yaml:"timeout"`
 	ReplaceResources   []Resource               `bson:"replace_resources"                json:"replace_resources"                   yaml:"replace_resources"`
+	Verification       *DeployVerification      `bson:"verification,omitempty"           json:"verification,omitempty"              yaml:"verification,omitempty"`
 }
 
 type ImageAndServiceModule struct {
@@ -326,11 +326,18 @@ type NotifyCtl struct {
 	WeChatWebHook   string   `bson:"weChat_webHook,omitempty"      yaml:"weChat_webHook,omitempty"      json:"weChat_webHook,omitempty"`
 	DingDingWebHook string   `bson:"dingding_webhook,omitempty"    yaml:"dingding_webhook,omitempty"    json:"dingding_webhook,omitempty"`
 	FeiShuWebHook   string   `bson:"feishu_webhook,omitempty"      yaml:"feishu_webhook,omitempty"      json:"feishu_webhook,omitempty"`
+	SlackWebHook    string   `bson:"slack_webhook,omitempty"       yaml:"slack_webhook,omitempty"       json:"slack_webhook,omitempty"`
+	TeamsWebHook    string   `bson:"teams_webhook,omitempty"       yaml:"teams_webhook,omitempty"       json:"teams_webhook,omitempty"`
+	MailReceivers   []string `bson:"mail_receivers,omitempty"      yaml:"mail_receivers,omitempty"      json:"mail_receivers,omitempty"`
+	WebHookURL      string   `bson:"webhook_url,omitempty"         yaml:"webhook_url,omitempty"         json:"webhook_url,omitempty"`
+	WebHookSecret   string   `bson:"webhook_secret,omitempty"      yaml:"webhook_secret,omitempty"      json:"webhook_secret,omitempty"`
 	AtMobiles       []string `bson:"at_mobiles,omitempty"          yaml:"at_mobiles,omitempty"          json:"at_mobiles,omitempty"`
 	WechatUserIDs   []string `bson:"wechat_user_ids,omitempty"     yaml:"wechat_user_ids,omitempty"     json:"wechat_user_ids,omitempty"`
 	LarkUserIDs     []string `bson:"lark_user_ids,omitempty"       yaml:"lark_user_ids,omitempty"       json:"lark_user_ids,omitempty"`
 	IsAtAll         bool     `bson:"is_at_all,omitempty"           yaml:"is_at_all,omitempty"           json:"is_at_all,omitempty"`
 	NotifyTypes     []string `bson:"notify_type"                   yaml:"notify_type"                   json:"notify_type"`
+	TitleTemplate   string   `bson:"title_template,omitempty"      yaml:"title_template,omitempty"      json:"title_template,omitempty"`
+	ContentTemplate string   `bson:"content_template,omitempty"    yaml:"content_template,omitempty"    json:"content_template,omitempty"`
 }
 
 type TaskInfo struct {
@@ -362,17 +369,18 @@ type TestArgs struct {
 }
 
 type HookPayload struct {
-	Owner          string `bson:"owner"            json:"owner,omitempty"`
-	Repo           string `bson:"repo"             json:"repo,omitempty"`
-	Branch         string `bson:"branch"           json:"branch,omitempty"`
-	Ref            string `bson:"ref"              json:"ref,omitempty"`
-	IsPr           bool   `bson:"is_pr"            json:"is_pr,omitempty"`
-	CheckRunID     int64  `bson:"check_run_id"     json:"check_run_id,omitempty"`
-	MergeRequestID string `bson:"merge_request_id" json:"merge_request_id,omitempty"`
-	CommitID       string `bson:"commit_id"        json:"commit_id,omitempty"`
-	DeliveryID     string `bson:"delivery_id"      json:"delivery_id,omitempty"`
-	CodehostID     int    `bson:"codehost_id"      json:"codehost_id"`
-	EventType      string `bson:"event_type"       json:"event_type"`
+	Owner          string              `bson:"owner"                   json:"owner,omitempty"`
+	Repo           string              `bson:"repo"                    json:"repo,omitempty"`
+	Branch         string              `bson:"branch"                  json:"branch,omitempty"`
+	Ref            string              `bson:"ref"                     json:"ref,omitempty"`
+	IsPr           bool                `bson:"is_pr"                   json:"is_pr,omitempty"`
+	CheckRunID     int64               `bson:"check_run_id"            json:"check_run_id,omitempty"`
+	MergeRequestID string              `bson:"merge_request_id"        json:"merge_request_id,omitempty"`
+	CommitID       string              `bson:"commit_id"               json:"commit_id,omitempty"`
+	DeliveryID     string              `bson:"delivery_id"             json:"delivery_id,omitempty"`
+	CodehostID     int                 `bson:"codehost_id"             json:"codehost_id"`
+	EventType      string              `bson:"event_type"              json:"event_type"`
+	CommitStatus   *CommitStatusConfig `bson:"commit_status,omitempty" json:"commit_status,omitempty"`
 }
 
 type TargetArgs struct {
@@ -52,6 +52,7 @@ type WorkflowV4 struct {
 	HookPayload     *HookPayload             `bson:"hook_payload"        yaml:"-"                   json:"hook_payload,omitempty"`
 	BaseName        string                   `bson:"base_name"           yaml:"-"                   json:"base_name"`
 	ShareStorages   []*ShareStorage          `bson:"share_storages"      yaml:"share_storages"      json:"share_storages"`
+	JiraIntegration *JiraIntegration         `bson:"jira_integration"    yaml:"jira_integration"    json:"jira_integration,omitempty"`
 }
 
 type WorkflowStage struct {
@@ -62,11 +63,13 @@ type WorkflowStage struct {
 }
 
 type Approval struct {
-	Enabled        bool                `bson:"enabled"                     yaml:"enabled"                       json:"enabled"`
-	Type           config.ApprovalType `bson:"type"                        yaml:"type"                          json:"type"`
-	Description    string              `bson:"description"                 yaml:"description"                   json:"description"`
-	NativeApproval *NativeApproval     `bson:"native_approval"             yaml:"native_approval,omitempty"     json:"native_approval,omitempty"`
-	LarkApproval   *LarkApproval       `bson:"lark_approval"               yaml:"lark_approval,omitempty"       json:"lark_approval,omitempty"`
+	Enabled          bool                `bson:"enabled"                     yaml:"enabled"                       json:"enabled"`
+	Type             config.ApprovalType `bson:"type"                        yaml:"type"                          json:"type"`
+	Description      string              `bson:"description"                 yaml:"description"                   json:"description"`
+	NativeApproval   *NativeApproval     `bson:"native_approval"             yaml:"native_approval,omitempty"     json:"native_approval,omitempty"`
+	LarkApproval     *LarkApproval       `bson:"lark_approval"               yaml:"lark_approval,omitempty"       json:"lark_approval,omitempty"`
+	DingTalkApproval *DingTalkApproval   `bson:"dingtalk_approval"           yaml:"dingtalk_approval,omitempty"   json:"dingtalk_approval,omitempty"`
+	WeComApproval    *WeComApproval      `bson:"wecom_approval"              yaml:"wecom_approval,omitempty"      json:"wecom_approval,omitempty"`
 }
 
 type NativeApproval struct {
@@ -89,6 +92,24 @@ type LarkApprovalUser struct {
 	OperationTime   int64                  `bson:"operation_time"              yaml:"-"                          json:"operation_time"`
 }
 
+// DingTalkApproval creates an approval instance in dingtalk, ApproveUsers are zadig users which are mapped
+// to dingtalk users by their mobiles.
+type DingTalkApproval struct {
+	Timeout      int     `bson:"timeout"                     yaml:"timeout"                    json:"timeout"`
+	ApprovalID   string  `bson:"approval_id"                 yaml:"approval_id"                json:"approval_id"`
+	ApproveUsers []*User `bson:"approve_users"               yaml:"approve_users"              json:"approve_users"`
+	InstanceCode string  `bson:"instance_code"               yaml:"-"                          json:"instance_code"`
+}
+
+// WeComApproval creates an approval in wecom, ApproveUsers are zadig users which are mapped to wecom users
+// by their mobiles or emails.
+type WeComApproval struct {
+	Timeout      int     `bson:"timeout"                     yaml:"timeout"                    json:"timeout"`
+	ApprovalID   string  `bson:"approval_id"                 yaml:"approval_id"                json:"approval_id"`
+	ApproveUsers []*User `bson:"approve_users"               yaml:"approve_users"              json:"approve_users"`
+	InstanceCode string  `bson:"instance_code"               yaml:"-"                          json:"instance_code"`
+}
+
 type User struct {
 	UserID          string                 `bson:"user_id"                     yaml:"user_id"                    json:"user_id"`
 	UserName        string                 `bson:"user_name"                   yaml:"user_name"                  json:"user_name"`
@@ -160,6 +181,47 @@ type ZadigDeployJobSpec struct {
 	// 当 source 为 fromjob 时需要，指定部署镜像来源是上游哪一个构建任务
 	JobName          string             `bson:"job_name"             yaml:"job_name"             json:"job_name"`
 	ServiceAndImages []*ServiceAndImage `bson:"service_and_images"   yaml:"service_and_images"   json:"service_and_images"`
+	// Verification runs after the rollout is ready, or right after the apply when SkipCheckRunStatus is set
+	Verification *DeployVerification `bson:"verification,omitempty" yaml:"verification,omitempty" json:"verification,omitempty"`
+}
+
+type DeployVerification struct {
+	Enabled bool `bson:"enabled"                 yaml:"enabled"                 json:"enabled"`
+	// unit is minute, the probes and metrics are checked repeatedly during this period
+	Duration int `bson:"duration"                yaml:"duration"                json:"duration"`
+	// unit is second
+	Interval int                   `bson:"interval"                yaml:"interval"                json:"interval"`
+	Probes   []*VerificationProbe  `bson:"probes"                  yaml:"probes"                  json:"probes"`
+	Metrics  []*VerificationMetric `bson:"metrics"                 yaml:"metrics"                 json:"metrics"`
+	// restore the images before the deployment if the verification fails
+	RollbackOnFailure bool `bson:"rollback_on_failure"     yaml:"rollback_on_failure"     json:"rollback_on_failure"`
+}
+
+type VerificationProbe struct {
+	// http/https/tcp
+	Protocol string `bson:"protocol"                yaml:"protocol"                json:"protocol"`
+	// the probe is sent from the aslan pod, so only the services in the namespace of the environment are allowed,
+	// the address is either a bare service name or `<service>.<namespace>[.svc[.cluster.local]]`
+	Address             string              `bson:"address"                 yaml:"address"                 json:"address"`
+	Port                int                 `bson:"port"                    yaml:"port"                    json:"port"`
+	Path                string              `bson:"path"                    yaml:"path"                    json:"path"`
+	HTTPHeaders         []*types.HTTPHeader `bson:"http_headers"            yaml:"http_headers"            json:"http_headers"`
+	ResponseSuccessFlag string              `bson:"response_success_flag"   yaml:"response_success_flag"   json:"response_success_flag"`
+	// unit is second
+	Timeout int `bson:"timeout"                 yaml:"timeout"                 json:"timeout"`
+}
+
+type VerificationMetric struct {
+	Name string `bson:"name"                    yaml:"name"                    json:"name"`
+	// address of the Prometheus compatible http api, it is queried from the aslan pod and must be one of
+	// the endpoints allowed by the VERIFICATION_METRIC_ENDPOINTS environment variable of aslan
+	Endpoint string `bson:"endpoint"                yaml:"endpoint"                json:"endpoint"`
+	Token    string `bson:"token"                   yaml:"token"                   json:"token"`
+	// the query must return a scalar or a vector with exactly one sample
+	Query string `bson:"query"                   yaml:"query"                   json:"query"`
+	// the metric is healthy when `value <Operator> Threshold` holds, one of >, >=, <, <=
+	Operator  string  `bson:"operator"                yaml:"operator"                json:"operator"`
+	Threshold float64 `bson:"threshold"               yaml:"threshold"               json:"threshold"`
 }
 
 type ServiceAndImage struct {
@@ -439,6 +501,45 @@ type WorkflowV4Hook struct {
 	Description         string              `bson:"description,omitempty"     json:"description,omitempty"`
 	Repos               []*types.Repository `bson:"-"                         json:"repos,omitempty"`
 	WorkflowArg         *WorkflowV4         `bson:"workflow_arg"              json:"workflow_arg"`
+	CommitStatus        *CommitStatusConfig `bson:"commit_status,omitempty"   json:"commit_status,omitempty"`
+}
+
+// CommitStatusConfig decides whether the status of tasks triggered by a hook is reported to the commit,
+// as commit statuses for gitlab, check runs for gitee and label votes for gerrit.
+// Name is the status name shown in the code host, workflow name is used if it is empty.
+type CommitStatusConfig struct {
+	Enabled     bool   `bson:"enabled"      json:"enabled"`
+	Name        string `bson:"name"         json:"name"`
+	GerritLabel string `bson:"gerrit_label" json:"gerrit_label"`
+}
+
+func (c *CommitStatusConfig) GetGerritLabel() string {
+	if c.GerritLabel == "" {
+		return "Verified"
+	}
+	return c.GerritLabel
+}
+
+type JiraIntegration struct {
+	FailureIssue   *JiraFailureIssue   `bson:"failure_issue"   yaml:"failure_issue"   json:"failure_issue"`
+	DeploymentLink *JiraDeploymentLink `bson:"deployment_link" yaml:"deployment_link" json:"deployment_link"`
+}
+
+// JiraFailureIssue creates a jira issue for every failed job of the task, an open issue of the same
+// workflow and job is commented instead of creating a new one.
+type JiraFailureIssue struct {
+	Enabled    bool   `bson:"enabled"     yaml:"enabled"     json:"enabled"`
+	ProjectKey string `bson:"project_key" yaml:"project_key" json:"project_key"`
+	IssueType  string `bson:"issue_type"  yaml:"issue_type"  json:"issue_type"`
+	Assignee   string `bson:"assignee"    yaml:"assignee"    json:"assignee"`
+	// ProtectedBranches are regular expressions of the branches, task built from any branch is handled if it is empty
+	ProtectedBranches []string `bson:"protected_branches" yaml:"protected_branches" json:"protected_branches"`
+}
+
+// JiraDeploymentLink posts the deployment info to the jira issues found in the commits deployed by the task
+type JiraDeploymentLink struct {
+	Enabled bool                `bson:"enabled" yaml:"enabled" json:"enabled"`
+	Mode    config.JiraLinkMode `bson:"mode"    yaml:"mode"    json:"mode"`
 }
 
 type JiraHook struct {
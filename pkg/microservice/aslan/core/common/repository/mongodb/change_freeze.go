@@ -0,0 +1,286 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type ChangeFreezeWindowColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewChangeFreezeWindowColl() *ChangeFreezeWindowColl {
+	name := models.ChangeFreezeWindow{}.TableName()
+	return &ChangeFreezeWindowColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *ChangeFreezeWindowColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *ChangeFreezeWindowColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "project_name", Value: 1},
+			bson.E{Key: "name", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+	return err
+}
+
+// List returns the windows of the given project together with the org-wide windows,
+// all windows are returned if projectName is empty and all is true.
+func (c *ChangeFreezeWindowColl) List(projectName string, all bool) ([]*models.ChangeFreezeWindow, error) {
+	query := bson.M{"project_name": bson.M{"$in": bson.A{"", projectName}}}
+	if projectName == "" && all {
+		query = bson.M{}
+	}
+	resp := make([]*models.ChangeFreezeWindow, 0)
+	ctx := context.Background()
+
+	opts := options.Find().SetSort(bson.D{{"project_name", 1}, {"name", 1}})
+	cursor, err := c.Collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, err
+}
+
+func (c *ChangeFreezeWindowColl) Find(id string) (*models.ChangeFreezeWindow, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(models.ChangeFreezeWindow)
+	err = c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(resp)
+	return resp, err
+}
+
+func (c *ChangeFreezeWindowColl) Create(args *models.ChangeFreezeWindow) error {
+	if args == nil {
+		return errors.New("nil change freeze window")
+	}
+
+	args.CreateTime = time.Now().Unix()
+	args.UpdateTime = time.Now().Unix()
+
+	_, err := c.InsertOne(context.TODO(), args)
+
+	return err
+}
+
+func (c *ChangeFreezeWindowColl) Update(id string, args *models.ChangeFreezeWindow) error {
+	if args == nil {
+		return errors.New("nil change freeze window")
+	}
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	query := bson.M{"_id": oid}
+	change := bson.M{"$set": bson.M{
+		"name":        args.Name,
+		"description": args.Description,
+		"enabled":     args.Enabled,
+		"type":        args.Type,
+		"start_at":    args.StartAt,
+		"end_at":      args.EndAt,
+		"weekly":      args.Weekly,
+		"approvers":   args.Approvers,
+		"update_by":   args.UpdateBy,
+		"update_time": time.Now().Unix(),
+	}}
+
+	_, err = c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+func (c *ChangeFreezeWindowColl) Delete(id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	query := bson.M{"_id": oid}
+
+	_, err = c.DeleteOne(context.TODO(), query)
+	return err
+}
+
+type ChangeFreezeOverrideColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewChangeFreezeOverrideColl() *ChangeFreezeOverrideColl {
+	name := models.ChangeFreezeOverride{}.TableName()
+	return &ChangeFreezeOverrideColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *ChangeFreezeOverrideColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *ChangeFreezeOverrideColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "project_name", Value: 1},
+			bson.E{Key: "workflow_name", Value: 1},
+		},
+		Options: options.Index().SetUnique(false),
+	}
+	_, err := c.Indexes().CreateOne(ctx, mod)
+	return err
+}
+
+type ListChangeFreezeOverrideOption struct {
+	ProjectName  string
+	WorkflowName string
+	Status       string
+}
+
+func (c *ChangeFreezeOverrideColl) List(opt *ListChangeFreezeOverrideOption) ([]*models.ChangeFreezeOverride, error) {
+	query := bson.M{}
+	if opt.ProjectName != "" {
+		query["project_name"] = opt.ProjectName
+	}
+	if opt.WorkflowName != "" {
+		query["workflow_name"] = opt.WorkflowName
+	}
+	if opt.Status != "" {
+		query["status"] = opt.Status
+	}
+	resp := make([]*models.ChangeFreezeOverride, 0)
+	ctx := context.Background()
+
+	opts := options.Find().SetSort(bson.D{{"create_time", -1}})
+	cursor, err := c.Collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, err
+}
+
+func (c *ChangeFreezeOverrideColl) Find(id string) (*models.ChangeFreezeOverride, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(models.ChangeFreezeOverride)
+	err = c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(resp)
+	return resp, err
+}
+
+// FindApproved returns an approved override of the workflow which is still valid at the given time.
+func (c *ChangeFreezeOverrideColl) FindApproved(projectName, workflowName string, at int64) (*models.ChangeFreezeOverride, error) {
+	query := bson.M{
+		"project_name":  projectName,
+		"workflow_name": workflowName,
+		"status":        models.ChangeFreezeOverrideApproved,
+		"expire_at":     bson.M{"$gt": at},
+	}
+
+	resp := new(models.ChangeFreezeOverride)
+	err := c.FindOne(context.TODO(), query).Decode(resp)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return resp, err
+}
+
+func (c *ChangeFreezeOverrideColl) Create(args *models.ChangeFreezeOverride) error {
+	if args == nil {
+		return errors.New("nil change freeze override")
+	}
+
+	args.CreateTime = time.Now().Unix()
+	res, err := c.InsertOne(context.TODO(), args)
+	if err != nil {
+		return err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		args.ID = oid
+	}
+	return nil
+}
+
+// Review updates a pending override to the reviewed status.
+func (c *ChangeFreezeOverrideColl) Review(id string, args *models.ChangeFreezeOverride) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	query := bson.M{"_id": oid, "status": models.ChangeFreezeOverridePending}
+	change := bson.M{"$set": bson.M{
+		"status":         args.Status,
+		"reviewed_by":    args.ReviewedBy,
+		"reviewed_uid":   args.ReviewedUID,
+		"review_comment": args.ReviewComment,
+		"review_time":    args.ReviewTime,
+		"expire_at":      args.ExpireAt,
+		"approvals":      args.Approvals,
+	}}
+
+	res, err := c.UpdateOne(context.TODO(), query, change)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("override is not pending")
+	}
+	return nil
+}
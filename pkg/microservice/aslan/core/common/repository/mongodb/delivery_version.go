@@ -28,6 +28,7 @@ import (
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/setting"
 	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
 )
 
@@ -239,6 +240,34 @@ func (c *DeliveryVersionColl) Update(args *models.DeliveryVersion) error {
 	return err
 }
 
+func (c *DeliveryVersionColl) UpdateReleaseNotes(id primitive.ObjectID, notes *models.DeliveryReleaseNotes) error {
+	query := bson.M{"_id": id, "deleted_at": 0}
+	change := bson.M{"$set": bson.M{
+		"release_notes": notes,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+// GetPrevious returns the latest finished version of the project created before createdAt
+func (c *DeliveryVersionColl) GetPrevious(productName string, createdAt int64) (*models.DeliveryVersion, error) {
+	query := bson.M{
+		"product_name": productName,
+		"deleted_at":   0,
+		"created_at":   bson.M{"$lt": createdAt},
+		"status": bson.M{"$nin": []string{
+			setting.DeliveryVersionStatusFailed,
+			setting.DeliveryVersionStatusCreating,
+			setting.DeliveryVersionStatusRetrying,
+		}},
+	}
+	opts := options.FindOne().SetSort(bson.D{{"created_at", -1}})
+
+	resp := new(models.DeliveryVersion)
+	err := c.FindOne(context.TODO(), query, opts).Decode(resp)
+	return resp, err
+}
+
 func (c *DeliveryVersionColl) FindProducts() ([]string, error) {
 	resp := make([]string, 0)
 	query := bson.M{"deleted_at": 0}
@@ -0,0 +1,314 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type EventSubscriptionColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewEventSubscriptionColl() *EventSubscriptionColl {
+	name := models.EventSubscription{}.TableName()
+	return &EventSubscriptionColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *EventSubscriptionColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *EventSubscriptionColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys:    bson.M{"name": 1},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err := c.Indexes().CreateOne(ctx, mod)
+	return err
+}
+
+func (c *EventSubscriptionColl) List() ([]*models.EventSubscription, error) {
+	ctx := context.Background()
+	cursor, err := c.Collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{"create_time", -1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*models.EventSubscription, 0)
+	if err := cursor.All(ctx, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListEnabled returns the enabled subscriptions which are interested in the event type of the project.
+func (c *EventSubscriptionColl) ListEnabled(eventType, projectName string) ([]*models.EventSubscription, error) {
+	query := bson.M{
+		"enabled": true,
+		"$and": bson.A{
+			bson.M{"$or": bson.A{
+				bson.M{"event_types": bson.M{"$size": 0}},
+				bson.M{"event_types": nil},
+				bson.M{"event_types": eventType},
+			}},
+			bson.M{"$or": bson.A{
+				bson.M{"projects": bson.M{"$size": 0}},
+				bson.M{"projects": nil},
+				bson.M{"projects": projectName},
+			}},
+		},
+	}
+
+	ctx := context.Background()
+	cursor, err := c.Collection.Find(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*models.EventSubscription, 0)
+	if err := cursor.All(ctx, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *EventSubscriptionColl) Find(id string) (*models.EventSubscription, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(models.EventSubscription)
+	err = c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(resp)
+	return resp, err
+}
+
+func (c *EventSubscriptionColl) Create(args *models.EventSubscription) error {
+	if args == nil {
+		return errors.New("nil event subscription")
+	}
+
+	res, err := c.InsertOne(context.TODO(), args)
+	if err != nil {
+		return err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		args.ID = oid
+	}
+	return nil
+}
+
+func (c *EventSubscriptionColl) Update(id string, args *models.EventSubscription) error {
+	if args == nil {
+		return errors.New("nil event subscription")
+	}
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	change := bson.M{"$set": bson.M{
+		"name":        args.Name,
+		"url":         args.URL,
+		"secret":      args.Secret,
+		"event_types": args.EventTypes,
+		"projects":    args.Projects,
+		"enabled":     args.Enabled,
+		"update_by":   args.UpdateBy,
+		"update_time": args.UpdateTime,
+	}}
+	_, err = c.UpdateOne(context.TODO(), bson.M{"_id": oid}, change)
+	return err
+}
+
+func (c *EventSubscriptionColl) Delete(id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DeleteOne(context.TODO(), bson.M{"_id": oid})
+	return err
+}
+
+type EventDeliveryColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+type EventDeliveryListOption struct {
+	SubscriptionID string
+	EventType      string
+	ProjectName    string
+	Status         string
+	PageNum        int64
+	PageSize       int64
+}
+
+func NewEventDeliveryColl() *EventDeliveryColl {
+	name := models.EventDelivery{}.TableName()
+	return &EventDeliveryColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *EventDeliveryColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *EventDeliveryColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "subscription_id", Value: 1},
+				bson.E{Key: "create_time", Value: -1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "status", Value: 1},
+				bson.E{Key: "create_time", Value: -1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+
+	_, err := c.Indexes().CreateMany(ctx, mod)
+	return err
+}
+
+func (c *EventDeliveryColl) Create(args *models.EventDelivery) error {
+	if args == nil {
+		return errors.New("nil event delivery")
+	}
+
+	res, err := c.InsertOne(context.TODO(), args)
+	if err != nil {
+		return err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		args.ID = oid
+	}
+	return nil
+}
+
+func (c *EventDeliveryColl) Find(id string) (*models.EventDelivery, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(models.EventDelivery)
+	err = c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(resp)
+	return resp, err
+}
+
+// UpdateResult records the result of an attempt of the delivery.
+func (c *EventDeliveryColl) UpdateResult(args *models.EventDelivery) error {
+	if args == nil {
+		return errors.New("nil event delivery")
+	}
+
+	change := bson.M{"$set": bson.M{
+		"status":        args.Status,
+		"attempts":      args.Attempts,
+		"response_code": args.ResponseCode,
+		"error":         args.Error,
+		"update_time":   args.UpdateTime,
+	}}
+	_, err := c.UpdateOne(context.TODO(), bson.M{"_id": args.ID}, change)
+	return err
+}
+
+// ResetForRedelivery resets the attempts of a delivery in one of the given statuses to pending,
+// false is returned if the delivery is in another status.
+func (c *EventDeliveryColl) ResetForRedelivery(id primitive.ObjectID, statuses []string, updateTime int64) (bool, error) {
+	query := bson.M{"_id": id, "status": bson.M{"$in": statuses}}
+	change := bson.M{"$set": bson.M{
+		"status":        models.EventDeliveryStatusPending,
+		"attempts":      0,
+		"response_code": 0,
+		"error":         "",
+		"update_time":   updateTime,
+	}}
+	res, err := c.UpdateOne(context.TODO(), query, change)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+func (c *EventDeliveryColl) List(opt *EventDeliveryListOption) ([]*models.EventDelivery, int64, error) {
+	if opt == nil {
+		return nil, 0, errors.New("nil ListOption")
+	}
+
+	query := bson.M{}
+	if opt.SubscriptionID != "" {
+		query["subscription_id"] = opt.SubscriptionID
+	}
+	if opt.EventType != "" {
+		query["event_type"] = opt.EventType
+	}
+	if opt.ProjectName != "" {
+		query["project_name"] = opt.ProjectName
+	}
+	if opt.Status != "" {
+		query["status"] = opt.Status
+	}
+
+	ctx := context.Background()
+	total, err := c.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{"create_time", -1}})
+	if opt.PageNum > 0 && opt.PageSize > 0 {
+		opts.SetSkip((opt.PageNum - 1) * opt.PageSize).SetLimit(opt.PageSize)
+	}
+	cursor, err := c.Collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp := make([]*models.EventDelivery, 0)
+	if err := cursor.All(ctx, &resp); err != nil {
+		return nil, 0, err
+	}
+	return resp, total, nil
+}
@@ -448,6 +448,41 @@ func (c *ProductColl) UpdateProductRecycleDay(envName, productName string, recyc
 	return err
 }
 
+func (c *ProductColl) UpdatePreviewEnv(envName, productName string, previewEnv *models.ProductPreviewEnv) error {
+	query := bson.M{"env_name": envName, "product_name": productName}
+
+	change := bson.M{"$set": bson.M{
+		"update_time": time.Now().Unix(),
+		"preview_env": previewEnv,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+
+	return err
+}
+
+func (c *ProductColl) UpdateGitOps(envName, productName string, gitOps *models.ProductGitOps) error {
+	query := bson.M{"env_name": envName, "product_name": productName}
+
+	change := bson.M{"$set": bson.M{
+		"update_time": time.Now().Unix(),
+		"gitops":      gitOps,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+
+	return err
+}
+
+func (c *ProductColl) UpdateGitOpsStatus(envName, productName string, status *models.ProductGitOpsStatus) error {
+	query := bson.M{"env_name": envName, "product_name": productName}
+
+	change := bson.M{"$set": bson.M{
+		"gitops_status": status,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+
+	return err
+}
+
 func (c *ProductColl) UpdateProductAlias(envName, productName, alias string) error {
 	query := bson.M{"env_name": envName, "product_name": productName}
 
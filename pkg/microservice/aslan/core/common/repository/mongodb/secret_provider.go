@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type SecretProviderColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewSecretProviderColl() *SecretProviderColl {
+	name := models.SecretProvider{}.TableName()
+	return &SecretProviderColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *SecretProviderColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *SecretProviderColl) EnsureIndex(_ context.Context) error {
+	return nil
+}
+
+// Get returns the secret provider, nil is returned if it is not configured.
+func (c *SecretProviderColl) Get() (*models.SecretProvider, error) {
+	resp := new(models.SecretProvider)
+	err := c.FindOne(context.TODO(), bson.M{}).Decode(resp)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *SecretProviderColl) Upsert(args *models.SecretProvider) error {
+	change := bson.M{"$set": bson.M{
+		"type":        args.Type,
+		"vault":       args.Vault,
+		"kubernetes":  args.Kubernetes,
+		"update_by":   args.UpdateBy,
+		"update_time": time.Now().Unix(),
+	}}
+	_, err := c.UpdateOne(context.TODO(), bson.M{}, change, options.Update().SetUpsert(true))
+	return err
+}
+
+func (c *SecretProviderColl) Delete() error {
+	_, err := c.DeleteMany(context.TODO(), bson.M{})
+	return err
+}
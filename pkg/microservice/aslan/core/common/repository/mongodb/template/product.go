@@ -175,6 +175,7 @@ type ProductListOpt struct {
 	ContainSharedServices []*template.ServiceInfo
 	BasicFacility         string
 	DeployType            string
+	PreviewEnvEnabled     bool
 }
 
 // ListWithOption ...
@@ -194,6 +195,9 @@ func (c *ProductColl) ListWithOption(opt *ProductListOpt) ([]*template.Product,
 	if opt.DeployType != "" {
 		query["product_feature.deploy_type"] = bson.M{"$in": strings.Split(opt.DeployType, ",")}
 	}
+	if opt.PreviewEnvEnabled {
+		query["preview_env.enabled"] = true
+	}
 
 	cursor, err := c.Collection.Find(context.TODO(), query)
 	if err != nil {
@@ -279,6 +283,17 @@ func (c *ProductColl) UpdateProductFeature(productName string, productFeature *t
 	return err
 }
 
+func (c *ProductColl) UpdatePreviewEnv(productName string, previewEnv *template.PreviewEnvSetting, updateBy string) error {
+	query := bson.M{"product_name": productName}
+	change := bson.M{"$set": bson.M{
+		"update_time": time.Now().Unix(),
+		"update_by":   updateBy,
+		"preview_env": previewEnv,
+	}}
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
 // Update existing ProductTmpl
 func (c *ProductColl) Update(productName string, args *template.Product) error {
 	// avoid panic issue
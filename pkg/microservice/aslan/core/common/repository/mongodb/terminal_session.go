@@ -0,0 +1,185 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type TerminalSessionColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+type TerminalSessionListOption struct {
+	ProjectName string
+	EnvName     string
+	UserName    string
+	Type        string
+	StartTime   int64
+	EndTime     int64
+	PageNum     int64
+	PageSize    int64
+}
+
+func NewTerminalSessionColl() *TerminalSessionColl {
+	name := models.TerminalSession{}.TableName()
+	return &TerminalSessionColl{
+		Collection: mongotool.Database(config.MongoDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *TerminalSessionColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *TerminalSessionColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "start_time", Value: -1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "project_name", Value: 1},
+				bson.E{Key: "env_name", Value: 1},
+				bson.E{Key: "start_time", Value: -1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "user_name", Value: 1},
+				bson.E{Key: "start_time", Value: -1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+
+	_, err := c.Indexes().CreateMany(ctx, mod)
+	return err
+}
+
+func (c *TerminalSessionColl) Create(args *models.TerminalSession) error {
+	if args == nil {
+		return errors.New("nil terminal session")
+	}
+
+	res, err := c.InsertOne(context.TODO(), args)
+	if err != nil {
+		return err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		args.ID = oid
+	}
+	return nil
+}
+
+// Finish records the result of the session once the shell is closed.
+func (c *TerminalSessionColl) Finish(args *models.TerminalSession) error {
+	if args == nil {
+		return errors.New("nil terminal session")
+	}
+
+	query := bson.M{"_id": args.ID}
+	change := bson.M{"$set": bson.M{
+		"status":     args.Status,
+		"error":      args.Error,
+		"object_key": args.ObjectKey,
+		"size":       args.Size,
+		"end_time":   args.EndTime,
+	}}
+
+	_, err := c.UpdateOne(context.TODO(), query, change)
+	return err
+}
+
+func (c *TerminalSessionColl) Find(id string) (*models.TerminalSession, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(models.TerminalSession)
+	err = c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(resp)
+	return resp, err
+}
+
+func (c *TerminalSessionColl) List(opt *TerminalSessionListOption) ([]*models.TerminalSession, int64, error) {
+	if opt == nil {
+		return nil, 0, errors.New("nil ListOption")
+	}
+
+	query := bson.M{}
+	if opt.ProjectName != "" {
+		query["project_name"] = opt.ProjectName
+	}
+	if opt.EnvName != "" {
+		query["env_name"] = opt.EnvName
+	}
+	if opt.UserName != "" {
+		query["user_name"] = opt.UserName
+	}
+	if opt.Type != "" {
+		query["type"] = opt.Type
+	}
+	timeRange := bson.M{}
+	if opt.StartTime > 0 {
+		timeRange["$gte"] = opt.StartTime
+	}
+	if opt.EndTime > 0 {
+		timeRange["$lte"] = opt.EndTime
+	}
+	if len(timeRange) > 0 {
+		query["start_time"] = timeRange
+	}
+
+	ctx := context.Background()
+	total, err := c.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{"start_time", -1}})
+	if opt.PageNum > 0 && opt.PageSize > 0 {
+		opts.SetSkip((opt.PageNum - 1) * opt.PageSize).SetLimit(opt.PageSize)
+	}
+	cursor, err := c.Collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp := make([]*models.TerminalSession, 0)
+	if err := cursor.All(ctx, &resp); err != nil {
+		return nil, 0, err
+	}
+	return resp, total, nil
+}
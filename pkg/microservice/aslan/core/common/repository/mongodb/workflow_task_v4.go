@@ -81,6 +81,14 @@ func (c *WorkflowTaskv4Coll) EnsureIndex(ctx context.Context) error {
 			},
 			Options: options.Index().SetUnique(false),
 		},
+		// used to find the build task of an image
+		{
+			Keys: bson.D{
+				bson.E{Key: "project_name", Value: 1},
+				bson.E{Key: "stages.jobs.spec.properties.envs.value", Value: 1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
 	}
 
 	_, err := c.Indexes().CreateMany(ctx, mod)
@@ -163,6 +171,31 @@ func (c *WorkflowTaskv4Coll) GetLatest(workflowName string) (*models.WorkflowTas
 	return resp, nil
 }
 
+// FindLatestBuildTaskByImage returns the latest task in the project which built the given image
+func (c *WorkflowTaskv4Coll) FindLatestBuildTaskByImage(projectName, image string) (*models.WorkflowTask, error) {
+	resp := new(models.WorkflowTask)
+	query := bson.M{
+		"project_name": projectName,
+		"is_deleted":   false,
+		"stages.jobs": bson.M{"$elemMatch": bson.M{
+			"type": string(config.JobZadigBuild),
+			"spec.properties.envs": bson.M{"$elemMatch": bson.M{
+				"key":   "IMAGE",
+				"value": image,
+			}},
+		}},
+	}
+
+	findOption := options.FindOne()
+	findOption.SetSort(bson.D{{"create_time", -1}})
+
+	err := c.FindOne(context.TODO(), query, findOption).Decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 func (c *WorkflowTaskv4Coll) FindTodoTasksByWorkflowName(workflowName string) ([]*models.WorkflowTask, error) {
 	ret := make([]*models.WorkflowTask, 0)
 	query := bson.M{"status": bson.M{"$in": []string{"waiting", "queued", "created", "running", "blocked"}}}
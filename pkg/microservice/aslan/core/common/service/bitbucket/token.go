@@ -0,0 +1,40 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitbucket
+
+import (
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/git"
+	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
+	"github.com/koderover/zadig/pkg/tool/bitbucket"
+)
+
+// getAccessToken returns a valid access token of the codehost, OAuth tokens are refreshed
+// and saved back when they are about to expire.
+func getAccessToken(id int, username, accessToken string) (string, error) {
+	// app passwords and personal access tokens do not expire
+	if id == 0 || username != "" || accessToken == "" {
+		return accessToken, nil
+	}
+
+	return git.GetOAuthAccessToken(id, func(ch *systemconfig.CodeHost) (string, string, error) {
+		token, err := bitbucket.RefreshAccessToken(ch.Address, ch.AccessKey, ch.SecretKey, ch.RefreshToken)
+		if err != nil {
+			return "", "", err
+		}
+		return token.AccessToken, token.RefreshToken, nil
+	})
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package changefreeze
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+)
+
+const minutesPerWeek = 7 * 24 * 60
+
+// FrozenError is returned when a deployment to a production environment is blocked by freeze windows.
+type FrozenError struct {
+	EnvName string
+	Windows []*models.ChangeFreezeWindow
+}
+
+func (e *FrozenError) Error() string {
+	names := make([]string, 0, len(e.Windows))
+	for _, w := range e.Windows {
+		names = append(names, w.Name)
+	}
+	return fmt.Sprintf("deployment to production environment %s is blocked by change freeze window %s, an emergency override approved by a designated approver is required", e.EnvName, strings.Join(names, ","))
+}
+
+// Validate checks if the window is well defined.
+func Validate(w *models.ChangeFreezeWindow) error {
+	if w.Name == "" {
+		return fmt.Errorf("name can't be empty")
+	}
+	switch w.Type {
+	case models.ChangeFreezeTypeOnce:
+		if w.StartAt <= 0 || w.EndAt <= w.StartAt {
+			return fmt.Errorf("end_at must be later than start_at")
+		}
+	case models.ChangeFreezeTypeWeekly:
+		if w.Weekly == nil {
+			return fmt.Errorf("weekly range can't be empty")
+		}
+		if _, err := weeklyRange(w.Weekly); err != nil {
+			return err
+		}
+		if _, err := loadLocation(w.Weekly.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %s: %s", w.Weekly.Timezone, err)
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", w.Type)
+	}
+	return nil
+}
+
+// IsActive returns whether the window is in effect at the given time.
+func IsActive(w *models.ChangeFreezeWindow, t time.Time) bool {
+	if !w.Enabled {
+		return false
+	}
+
+	switch w.Type {
+	case models.ChangeFreezeTypeOnce:
+		return t.Unix() >= w.StartAt && t.Unix() < w.EndAt
+	case models.ChangeFreezeTypeWeekly:
+		if w.Weekly == nil {
+			return false
+		}
+		r, err := weeklyRange(w.Weekly)
+		if err != nil {
+			return false
+		}
+		loc, err := loadLocation(w.Weekly.Timezone)
+		if err != nil {
+			return false
+		}
+		t = t.In(loc)
+		now := int(t.Weekday())*24*60 + t.Hour()*60 + t.Minute()
+		// the range wraps around the end of the week, e.g. from Friday to Monday
+		if r[0] > r[1] {
+			return now >= r[0] || now < r[1]
+		}
+		return now >= r[0] && now < r[1]
+	}
+	return false
+}
+
+// ActiveWindows returns the org-wide and project windows which are in effect at the given time.
+func ActiveWindows(projectName string, t time.Time) ([]*models.ChangeFreezeWindow, error) {
+	windows, err := commonrepo.NewChangeFreezeWindowColl().List(projectName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*models.ChangeFreezeWindow, 0)
+	for _, w := range windows {
+		if IsActive(w, t) {
+			resp = append(resp, w)
+		}
+	}
+	return resp, nil
+}
+
+// CheckDeploy returns a FrozenError if the workflow is not allowed to deploy to the environment right now.
+// Only production environments are checked, and an approved override of the workflow lifts the freeze.
+func CheckDeploy(env *models.Product, workflowName string) error {
+	if env == nil || !env.Production {
+		return nil
+	}
+
+	now := time.Now()
+	windows, err := ActiveWindows(env.ProductName, now)
+	if err != nil {
+		return fmt.Errorf("failed to list change freeze windows: %s", err)
+	}
+	if len(windows) == 0 {
+		return nil
+	}
+
+	override, err := commonrepo.NewChangeFreezeOverrideColl().FindApproved(env.ProductName, workflowName, now.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to find change freeze override: %s", err)
+	}
+	// windows activated after the approval are not lifted by the override
+	if override != nil && len(UnapprovedWindows(windows, override.Approvals)) == 0 {
+		return nil
+	}
+	return &FrozenError{EnvName: env.EnvName, Windows: windows}
+}
+
+// UnapprovedWindows returns the windows which have no approval yet.
+func UnapprovedWindows(windows []*models.ChangeFreezeWindow, approvals []*models.ChangeFreezeApproval) []*models.ChangeFreezeWindow {
+	approved := make(map[string]bool)
+	for _, a := range approvals {
+		approved[a.WindowID] = true
+	}
+
+	resp := make([]*models.ChangeFreezeWindow, 0)
+	for _, w := range windows {
+		if !approved[w.ID.Hex()] {
+			resp = append(resp, w)
+		}
+	}
+	return resp
+}
+
+// CheckWorkflowTask checks all deploy jobs of the task against the freeze windows.
+func CheckWorkflowTask(task *models.WorkflowTask) error {
+	checked := make(map[string]bool)
+	for _, stage := range task.Stages {
+		for _, job := range stage.Jobs {
+			var envName string
+			switch spec := job.Spec.(type) {
+			case *models.JobTaskDeploySpec:
+				envName = spec.Env
+			case *models.JobTaskHelmDeploySpec:
+				envName = spec.Env
+			default:
+				continue
+			}
+			if envName == "" || checked[envName] {
+				continue
+			}
+			checked[envName] = true
+
+			env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{
+				Name:    task.ProjectName,
+				EnvName: envName,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to find env %s: %s", envName, err)
+			}
+			if err := CheckDeploy(env, task.WorkflowName); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// weeklyRange converts the range to the minutes since Sunday 00:00.
+func weeklyRange(r *models.WeeklyFreezeRange) ([2]int, error) {
+	var resp [2]int
+	for i, p := range []struct {
+		day   int
+		clock string
+	}{{r.StartDay, r.StartTime}, {r.EndDay, r.EndTime}} {
+		if p.day < 0 || p.day > 6 {
+			return resp, fmt.Errorf("day of week must be between 0 and 6")
+		}
+		c, err := time.Parse("15:04", p.clock)
+		if err != nil {
+			return resp, fmt.Errorf("invalid time %s, the format is 15:04", p.clock)
+		}
+		resp[i] = (p.day*24*60 + c.Hour()*60 + c.Minute()) % minutesPerWeek
+	}
+	if resp[0] == resp[1] {
+		return resp, fmt.Errorf("start and end of the weekly range can't be the same")
+	}
+	return resp, nil
+}
+
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package changefreeze
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+func TestWeeklyRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       *models.WeeklyFreezeRange
+		want    [2]int
+		wantErr bool
+	}{
+		{
+			name: "friday evening to monday morning",
+			r:    &models.WeeklyFreezeRange{StartDay: 5, StartTime: "18:00", EndDay: 1, EndTime: "08:00"},
+			want: [2]int{5*24*60 + 18*60, 1*24*60 + 8*60},
+		},
+		{
+			name: "sunday midnight",
+			r:    &models.WeeklyFreezeRange{StartDay: 0, StartTime: "00:00", EndDay: 0, EndTime: "12:30"},
+			want: [2]int{0, 12*60 + 30},
+		},
+		{
+			name:    "invalid day",
+			r:       &models.WeeklyFreezeRange{StartDay: 7, StartTime: "00:00", EndDay: 1, EndTime: "00:00"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid time",
+			r:       &models.WeeklyFreezeRange{StartDay: 1, StartTime: "25:00", EndDay: 2, EndTime: "00:00"},
+			wantErr: true,
+		},
+		{
+			name:    "empty range",
+			r:       &models.WeeklyFreezeRange{StartDay: 3, StartTime: "10:00", EndDay: 3, EndTime: "10:00"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := weeklyRange(tt.r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("weeklyRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("weeklyRange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsActive(t *testing.T) {
+	// 2022-10-14 is a Friday
+	friday := func(clock string) time.Time {
+		ts, err := time.ParseInLocation("2006-01-02 15:04", "2022-10-14 "+clock, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+	weekend := &models.WeeklyFreezeRange{StartDay: 5, StartTime: "18:00", EndDay: 1, EndTime: "08:00", Timezone: "UTC"}
+	daytime := &models.WeeklyFreezeRange{StartDay: 5, StartTime: "09:00", EndDay: 5, EndTime: "17:00", Timezone: "UTC"}
+
+	tests := []struct {
+		name string
+		w    *models.ChangeFreezeWindow
+		t    time.Time
+		want bool
+	}{
+		{
+			name: "disabled",
+			w:    &models.ChangeFreezeWindow{Type: models.ChangeFreezeTypeOnce, StartAt: friday("00:00").Unix(), EndAt: friday("23:00").Unix()},
+			t:    friday("12:00"),
+		},
+		{
+			name: "once in range",
+			w:    &models.ChangeFreezeWindow{Enabled: true, Type: models.ChangeFreezeTypeOnce, StartAt: friday("00:00").Unix(), EndAt: friday("23:00").Unix()},
+			t:    friday("12:00"),
+			want: true,
+		},
+		{
+			name: "once at the end",
+			w:    &models.ChangeFreezeWindow{Enabled: true, Type: models.ChangeFreezeTypeOnce, StartAt: friday("00:00").Unix(), EndAt: friday("12:00").Unix()},
+			t:    friday("12:00"),
+		},
+		{
+			name: "weekly in range",
+			w:    &models.ChangeFreezeWindow{Enabled: true, Type: models.ChangeFreezeTypeWeekly, Weekly: daytime},
+			t:    friday("09:00"),
+			want: true,
+		},
+		{
+			name: "weekly out of range",
+			w:    &models.ChangeFreezeWindow{Enabled: true, Type: models.ChangeFreezeTypeWeekly, Weekly: daytime},
+			t:    friday("17:00"),
+		},
+		{
+			name: "weekly wrapping the week, before the end of week",
+			w:    &models.ChangeFreezeWindow{Enabled: true, Type: models.ChangeFreezeTypeWeekly, Weekly: weekend},
+			t:    friday("20:00"),
+			want: true,
+		},
+		{
+			name: "weekly wrapping the week, after the start of week",
+			w:    &models.ChangeFreezeWindow{Enabled: true, Type: models.ChangeFreezeTypeWeekly, Weekly: weekend},
+			t:    friday("20:00").AddDate(0, 0, 3).Add(-13 * time.Hour),
+			want: true,
+		},
+		{
+			name: "weekly wrapping the week, out of range",
+			w:    &models.ChangeFreezeWindow{Enabled: true, Type: models.ChangeFreezeTypeWeekly, Weekly: weekend},
+			t:    friday("17:59"),
+		},
+		{
+			name: "weekly in another timezone",
+			w: &models.ChangeFreezeWindow{Enabled: true, Type: models.ChangeFreezeTypeWeekly, Weekly: &models.WeeklyFreezeRange{
+				StartDay: 5, StartTime: "09:00", EndDay: 5, EndTime: "17:00", Timezone: "Asia/Shanghai",
+			}},
+			// 02:00 UTC is 10:00 in Shanghai
+			t:    friday("02:00"),
+			want: true,
+		},
+		{
+			name: "weekly without range",
+			w:    &models.ChangeFreezeWindow{Enabled: true, Type: models.ChangeFreezeTypeWeekly},
+			t:    friday("12:00"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsActive(tt.w, tt.t); got != tt.want {
+				t.Errorf("IsActive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnapprovedWindows(t *testing.T) {
+	org := &models.ChangeFreezeWindow{ID: primitive.NewObjectID(), Name: "org"}
+	project := &models.ChangeFreezeWindow{ID: primitive.NewObjectID(), Name: "project", ProjectName: "demo"}
+	windows := []*models.ChangeFreezeWindow{org, project}
+
+	got := UnapprovedWindows(windows, []*models.ChangeFreezeApproval{{WindowID: project.ID.Hex()}})
+	if len(got) != 1 || got[0] != org {
+		t.Errorf("expected the org-wide window to be unapproved, got %v", got)
+	}
+	got = UnapprovedWindows(windows, []*models.ChangeFreezeApproval{{WindowID: project.ID.Hex()}, {WindowID: org.ID.Hex()}})
+	if len(got) != 0 {
+		t.Errorf("expected all windows to be approved, got %v", got)
+	}
+}
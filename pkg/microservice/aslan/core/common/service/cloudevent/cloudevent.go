@@ -0,0 +1,197 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudevent
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/nsq"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/log"
+)
+
+const (
+	TypeWorkflowTaskCreated          = "workflow.task.created"
+	TypeWorkflowTaskStarted          = "workflow.task.started"
+	TypeWorkflowTaskFinished         = "workflow.task.finished"
+	TypeWorkflowStageWaitingApproval = "workflow.stage.waiting_approval"
+	TypeEnvironmentCreated           = "environment.created"
+	TypeEnvironmentUpdated           = "environment.updated"
+	TypeEnvironmentDeleted           = "environment.deleted"
+	TypeDeliveryVersionCreated       = "delivery.version.created"
+
+	specVersion = "1.0"
+	typePrefix  = "io.koderover.zadig."
+)
+
+var EventTypes = []string{
+	TypeWorkflowTaskCreated,
+	TypeWorkflowTaskStarted,
+	TypeWorkflowTaskFinished,
+	TypeWorkflowStageWaitingApproval,
+	TypeEnvironmentCreated,
+	TypeEnvironmentUpdated,
+	TypeEnvironmentDeleted,
+	TypeDeliveryVersionCreated,
+}
+
+// CloudEvent is an event in the structured content mode of CloudEvents v1.0 in JSON.
+type CloudEvent struct {
+	SpecVersion     string      `json:"specversion"`
+	ID              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Subject         string      `json:"subject,omitempty"`
+	Time            string      `json:"time"`
+	DataContentType string      `json:"datacontenttype"`
+	Data            interface{} `json:"data"`
+}
+
+type WorkflowTaskEventData struct {
+	ProjectName         string `json:"project_name"`
+	WorkflowName        string `json:"workflow_name"`
+	WorkflowDisplayName string `json:"workflow_display_name"`
+	TaskID              int64  `json:"task_id"`
+	Status              string `json:"status"`
+	Creator             string `json:"creator"`
+	StageName           string `json:"stage_name,omitempty"`
+	CreateTime          int64  `json:"create_time"`
+	StartTime           int64  `json:"start_time"`
+	EndTime             int64  `json:"end_time"`
+	URL                 string `json:"url"`
+}
+
+type EnvironmentEventData struct {
+	ProjectName string `json:"project_name"`
+	EnvName     string `json:"env_name"`
+	Production  bool   `json:"production"`
+	ClusterID   string `json:"cluster_id"`
+	Namespace   string `json:"namespace"`
+	Operator    string `json:"operator"`
+}
+
+type DeliveryVersionEventData struct {
+	ProjectName string   `json:"project_name"`
+	Version     string   `json:"version"`
+	Type        string   `json:"type"`
+	Desc        string   `json:"desc"`
+	Labels      []string `json:"labels"`
+	CreatedBy   string   `json:"created_by"`
+	CreateTime  int64    `json:"create_time"`
+}
+
+func PublishWorkflowTaskEvent(eventType string, task *commonmodels.WorkflowTask, stageName string) {
+	data := &WorkflowTaskEventData{
+		ProjectName:         task.ProjectName,
+		WorkflowName:        task.WorkflowName,
+		WorkflowDisplayName: task.WorkflowDisplayName,
+		TaskID:              task.TaskID,
+		Status:              string(task.Status),
+		Creator:             task.TaskCreator,
+		StageName:           stageName,
+		CreateTime:          task.CreateTime,
+		StartTime:           task.StartTime,
+		EndTime:             task.EndTime,
+		URL: fmt.Sprintf("%s/v1/projects/detail/%s/pipelines/custom/%s/%d",
+			configbase.SystemAddress(), task.ProjectName, task.WorkflowName, task.TaskID),
+	}
+	Publish(eventType, task.ProjectName, fmt.Sprintf("workflows/%s/tasks/%d", task.WorkflowName, task.TaskID), data)
+}
+
+func PublishEnvironmentEvent(eventType string, env *commonmodels.Product, operator string) {
+	data := &EnvironmentEventData{
+		ProjectName: env.ProductName,
+		EnvName:     env.EnvName,
+		Production:  env.Production,
+		ClusterID:   env.ClusterID,
+		Namespace:   env.Namespace,
+		Operator:    operator,
+	}
+	Publish(eventType, env.ProductName, fmt.Sprintf("environments/%s", env.EnvName), data)
+}
+
+func PublishDeliveryVersionEvent(eventType string, version *commonmodels.DeliveryVersion) {
+	data := &DeliveryVersionEventData{
+		ProjectName: version.ProductName,
+		Version:     version.Version,
+		Type:        version.Type,
+		Desc:        version.Desc,
+		Labels:      version.Labels,
+		CreatedBy:   version.CreatedBy,
+		CreateTime:  version.CreatedAt,
+	}
+	Publish(eventType, version.ProductName, fmt.Sprintf("versions/%s", version.Version), data)
+}
+
+// Publish sends the event to all the subscriptions interested in it asynchronously,
+// every delivery is recorded and queued in nsq so it can be retried.
+func Publish(eventType, projectName, subject string, data interface{}) {
+	event := &CloudEvent{
+		SpecVersion:     specVersion,
+		ID:              uuid.NewV4().String(),
+		Source:          fmt.Sprintf("/projects/%s", projectName),
+		Type:            typePrefix + eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC().Format(time.RFC3339),
+		DataContentType: "application/json",
+		Data:            data,
+	}
+
+	go func() {
+		subscriptions, err := commonrepo.NewEventSubscriptionColl().ListEnabled(eventType, projectName)
+		if err != nil {
+			log.Errorf("failed to list event subscriptions of %s, error: %s", eventType, err)
+			return
+		}
+		if len(subscriptions) == 0 {
+			return
+		}
+		payload, err := json.Marshal(event)
+		if err != nil {
+			log.Errorf("failed to marshal event %s, error: %s", event.ID, err)
+			return
+		}
+
+		for _, subscription := range subscriptions {
+			delivery := &commonmodels.EventDelivery{
+				SubscriptionID: subscription.ID.Hex(),
+				EventID:        event.ID,
+				EventType:      eventType,
+				ProjectName:    projectName,
+				URL:            subscription.URL,
+				Payload:        string(payload),
+				Status:         commonmodels.EventDeliveryStatusPending,
+				CreateTime:     time.Now().Unix(),
+				UpdateTime:     time.Now().Unix(),
+			}
+			if err := commonrepo.NewEventDeliveryColl().Create(delivery); err != nil {
+				log.Errorf("failed to create delivery of event %s to subscription %s, error: %s", event.ID, subscription.Name, err)
+				continue
+			}
+			if err := nsq.Publish(setting.TopicEventDelivery, []byte(delivery.ID.Hex())); err != nil {
+				log.Errorf("failed to queue delivery %s, error: %s", delivery.ID.Hex(), err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudevent
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nsqio/go-nsq"
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	nsqservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/nsq"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/crypto"
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+	"github.com/koderover/zadig/pkg/tool/log"
+)
+
+const (
+	// MaxDeliveryAttempts is the number of attempts before a delivery is moved to the dead letters
+	MaxDeliveryAttempts = 5
+
+	contentType = "application/cloudevents+json; charset=utf-8"
+	// signatureHeader carries the hex encoded HMAC-SHA256 of "<timestamp>.<body>", e.g. sha256=xxx,
+	// subscribers should reject requests whose timestamp is too old.
+	signatureHeader = "X-Zadig-Signature-256"
+	timestampHeader = "X-Zadig-Timestamp"
+	retryBaseDelay  = 10 * time.Second
+	// deliveries are sent concurrently so that a slow subscriber doesn't hold up the others
+	deliveryConcurrency = 10
+	deliveryTimeout     = 10 * time.Second
+)
+
+// ErrDeliveryInProgress is returned when redelivering a delivery which is still being sent or retried.
+var ErrDeliveryInProgress = errors.New("the delivery is still in progress")
+
+// SubscribeNSQ starts consuming the queued deliveries.
+func SubscribeNSQ() error {
+	cfg := nsqservice.Config()
+	cfg.MaxInFlight = 50
+	handler := &DeliveryHandler{log: log.SugaredLogger()}
+	if err := nsqservice.SubScribe(setting.TopicEventDelivery, "event.delivery", deliveryConcurrency, cfg, handler); err != nil {
+		log.Errorf("event delivery subscription failed, the error is: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Redeliver queues a finished delivery again with a fresh set of attempts, it's mostly used for the dead letters.
+// ErrDeliveryInProgress is returned if the delivery is still pending or waiting for a retry.
+func Redeliver(delivery *commonmodels.EventDelivery) error {
+	finished := []string{commonmodels.EventDeliveryStatusSucceeded, commonmodels.EventDeliveryStatusDead}
+	ok, err := commonrepo.NewEventDeliveryColl().ResetForRedelivery(delivery.ID, finished, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrDeliveryInProgress
+	}
+	return nsqservice.Publish(setting.TopicEventDelivery, []byte(delivery.ID.Hex()))
+}
+
+type DeliveryHandler struct {
+	log *zap.SugaredLogger
+}
+
+func (h *DeliveryHandler) HandleMessage(message *nsq.Message) error {
+	id := string(message.Body)
+	delivery, err := commonrepo.NewEventDeliveryColl().Find(id)
+	if err != nil {
+		h.log.Errorf("failed to find event delivery %s, error: %s", id, err)
+		return nil
+	}
+	if delivery.Status != commonmodels.EventDeliveryStatusPending && delivery.Status != commonmodels.EventDeliveryStatusFailed {
+		return nil
+	}
+
+	delivery.Attempts++
+	delivery.UpdateTime = time.Now().Unix()
+	subscription, err := commonrepo.NewEventSubscriptionColl().Find(delivery.SubscriptionID)
+	if err != nil {
+		delivery.Status = commonmodels.EventDeliveryStatusDead
+		delivery.Error = fmt.Sprintf("subscription not found: %s", err)
+	} else {
+		delivery.ResponseCode, err = deliver(subscription, []byte(delivery.Payload))
+		if delay, retry := setDeliveryResult(delivery, err); retry {
+			// the delay is per delivery, backing off would slow down the deliveries of other subscribers
+			message.RequeueWithoutBackoff(delay)
+		}
+	}
+
+	if err := commonrepo.NewEventDeliveryColl().UpdateResult(delivery); err != nil {
+		h.log.Errorf("failed to update event delivery %s, error: %s", id, err)
+	}
+	return nil
+}
+
+// setDeliveryResult updates the status of the delivery with the result of the last attempt,
+// it returns the delay before the next attempt if the delivery should be retried.
+func setDeliveryResult(delivery *commonmodels.EventDelivery, err error) (time.Duration, bool) {
+	switch {
+	case err == nil:
+		delivery.Status = commonmodels.EventDeliveryStatusSucceeded
+		delivery.Error = ""
+		return 0, false
+	case delivery.Attempts >= MaxDeliveryAttempts:
+		delivery.Status = commonmodels.EventDeliveryStatusDead
+		delivery.Error = err.Error()
+		return 0, false
+	default:
+		delivery.Status = commonmodels.EventDeliveryStatusFailed
+		delivery.Error = err.Error()
+		// 10s, 20s, 40s, 80s ...
+		return retryBaseDelay << (delivery.Attempts - 1), true
+	}
+}
+
+func deliver(subscription *commonmodels.EventSubscription, payload []byte) (int, error) {
+	secret := subscription.Secret
+	if secret != "" {
+		var err error
+		if secret, err = crypto.AesDecrypt(secret); err != nil {
+			return 0, fmt.Errorf("failed to decrypt the subscription secret: %s", err)
+		}
+	}
+	return send(subscription.URL, secret, payload)
+}
+
+func send(url, secret string, payload []byte) (int, error) {
+	timestamp := fmt.Sprintf("%d", time.Now().Unix())
+	headers := map[string]string{
+		"Content-Type":  contentType,
+		timestampHeader: timestamp,
+	}
+	if secret != "" {
+		headers[signatureHeader] = "sha256=" + sign(secret, timestamp, payload)
+	}
+
+	res, err := httpclient.New(httpclient.SetTimeout(deliveryTimeout)).Post(url, httpclient.SetHeaders(headers), httpclient.SetBody(payload))
+	code := 0
+	if res != nil {
+		code = res.StatusCode()
+	}
+	return code, err
+}
+
+// sign returns the hex encoded HMAC-SHA256 of "<timestamp>.<payload>", the timestamp is signed as well
+// so that a captured delivery can't be replayed with a fresh timestamp.
+func sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudevent
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	_ "github.com/koderover/zadig/pkg/util/testing"
+)
+
+func TestSetDeliveryResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		err      error
+		status   string
+		delay    time.Duration
+		retry    bool
+	}{
+		{name: "succeeded", attempts: 1, status: commonmodels.EventDeliveryStatusSucceeded},
+		{name: "first failure", attempts: 1, err: errors.New("503"), status: commonmodels.EventDeliveryStatusFailed, delay: 10 * time.Second, retry: true},
+		{name: "third failure", attempts: 3, err: errors.New("503"), status: commonmodels.EventDeliveryStatusFailed, delay: 40 * time.Second, retry: true},
+		{name: "out of attempts", attempts: MaxDeliveryAttempts, err: errors.New("503"), status: commonmodels.EventDeliveryStatusDead},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delivery := &commonmodels.EventDelivery{Attempts: tt.attempts, Error: "previous error"}
+			delay, retry := setDeliveryResult(delivery, tt.err)
+			if delivery.Status != tt.status {
+				t.Errorf("status = %s, want %s", delivery.Status, tt.status)
+			}
+			if delay != tt.delay || retry != tt.retry {
+				t.Errorf("setDeliveryResult() = (%s, %v), want (%s, %v)", delay, retry, tt.delay, tt.retry)
+			}
+			if tt.err == nil && delivery.Error != "" {
+				t.Errorf("error should be cleared, got %s", delivery.Error)
+			}
+		})
+	}
+}
+
+func TestSend(t *testing.T) {
+	payload := []byte(`{"specversion":"1.0"}`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != string(payload) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		timestamp, err := strconv.ParseInt(r.Header.Get(timestampHeader), 10, 64)
+		if err != nil || time.Since(time.Unix(timestamp, 0)) > time.Minute {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(r.Header.Get(timestampHeader) + "."))
+		mac.Write(body)
+		if r.Header.Get(signatureHeader) != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	if code, err := send(server.URL, "secret", payload); err != nil || code != http.StatusNoContent {
+		t.Errorf("send() = (%d, %v), want (%d, nil)", code, err, http.StatusNoContent)
+	}
+	if code, err := send(server.URL, "other", payload); err == nil || code != http.StatusUnauthorized {
+		t.Errorf("send() with a wrong secret = (%d, %v), want (%d, error)", code, err, http.StatusUnauthorized)
+	}
+}
+
+func TestSign(t *testing.T) {
+	payload := []byte(`{"specversion":"1.0"}`)
+	signature := sign("secret", "1700000000", payload)
+	if signature != sign("secret", "1700000000", payload) {
+		t.Errorf("sign() should be deterministic")
+	}
+	if signature == sign("secret", "1700000001", payload) {
+		t.Errorf("sign() should cover the timestamp")
+	}
+	if signature == sign("other", "1700000000", payload) {
+		t.Errorf("sign() should depend on the secret")
+	}
+}
@@ -35,6 +35,8 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/jobexecutor/core/service/step"
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
+	"github.com/koderover/zadig/pkg/tool/bitbucket"
+	"github.com/koderover/zadig/pkg/tool/gitea"
 	"github.com/koderover/zadig/pkg/tool/log"
 	"github.com/koderover/zadig/pkg/types"
 )
@@ -104,6 +106,10 @@ func RunGitCmds(codehostDetail *systemconfig.CodeHost, repoOwner, repoNamespace,
 	}
 	user = userpassPair[0]
 	repo.User = user
+	if repo.Source == setting.SourceFromBitbucket {
+		// bitbucket keeps the plain username, the access token is an app password or a bearer token
+		repo.User = codehostDetail.Username
+	}
 	if password != "" {
 		repo.Password = password
 		tokens = append(tokens, repo.Password)
@@ -229,6 +235,10 @@ func buildGitCommands(repo *Repo, hostNames sets.String) []*Command {
 	} else if repo.Source == setting.SourceFromGiteeEE || repo.Source == setting.SourceFromGitee {
 		giteeURL := step.HTTPSCloneURL(repo.Source, repo.OauthToken, repo.Owner, repo.Name, repo.Address)
 		cmds = append(cmds, &Command{Cmd: RemoteAdd(repo.RemoteName, giteeURL), DisableTrace: true})
+	} else if repo.Source == setting.SourceFromBitbucket {
+		cmds = append(cmds, &Command{Cmd: RemoteAdd(repo.RemoteName, bitbucket.CloneURL(repo.Address, repo.User, repo.OauthToken, owner, repo.Name)), DisableTrace: true})
+	} else if repo.Source == setting.SourceFromGitea {
+		cmds = append(cmds, &Command{Cmd: RemoteAdd(repo.RemoteName, gitea.CloneURL(repo.Address, repo.OauthToken, owner, repo.Name)), DisableTrace: true})
 	} else if repo.Source == setting.SourceFromOther {
 		if repo.AuthType == types.SSHAuthType {
 			host := getHost(repo.Address)
@@ -0,0 +1,47 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dingtalk
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/dingtalk"
+)
+
+// GetDingTalkClientByIMAppID returns the client and the im app of the given id.
+func GetDingTalkClientByIMAppID(id string) (*dingtalk.Client, *models.IMApp, error) {
+	app, err := mongodb.NewIMAppColl().GetByID(context.Background(), id)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "get external approval data")
+	}
+	if app.Type != setting.IMDingding {
+		return nil, nil, errors.Errorf("unexpected approval type %s", app.Type)
+	}
+	return dingtalk.NewClient(app.DingTalkAppKey, app.DingTalkAppSecret), app, nil
+}
+
+// CreateDefaultApprovalForm creates the approval form used by zadig workflows, the form is updated
+// if it has been created before.
+func CreateDefaultApprovalForm(app *models.IMApp) (string, error) {
+	return dingtalk.NewClient(app.DingTalkAppKey, app.DingTalkAppSecret).
+		CreateApprovalForm("Zadig 工作流", "Zadig 工作流", app.DingTalkDefaultApprovalFormCode)
+}
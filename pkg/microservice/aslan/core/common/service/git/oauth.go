@@ -0,0 +1,69 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
+	"github.com/koderover/zadig/pkg/tool/log"
+)
+
+// OAuthTokenExpirationThreshold is the age in seconds after which an OAuth token is refreshed,
+// tokens issued by Bitbucket and Gitea are valid for at least an hour.
+const OAuthTokenExpirationThreshold int64 = 3000
+
+// RefreshFunc exchanges the refresh token of the codehost for a new access token and refresh token,
+// the refresh token is kept if the returned one is empty.
+type RefreshFunc func(ch *systemconfig.CodeHost) (string, string, error)
+
+var oauthMu = &sync.Mutex{}
+
+// GetOAuthAccessToken returns a valid access token of the codehost, the token is refreshed and saved back
+// when it is about to expire. Tokens without a refresh token, e.g. personal access tokens, never expire.
+func GetOAuthAccessToken(id int, refresh RefreshFunc) (string, error) {
+	oauthMu.Lock()
+	defer oauthMu.Unlock()
+
+	ch, err := systemconfig.New().GetRawCodeHost(id)
+	if err != nil {
+		return "", fmt.Errorf("get codehost info error: [%s]", err)
+	}
+	if ch.RefreshToken == "" || time.Now().Unix()-ch.UpdatedAt <= OAuthTokenExpirationThreshold {
+		return ch.AccessToken, nil
+	}
+
+	log.Infof("Starting to refresh %s token of codehost %d, old token issued time: %d", ch.Type, id, ch.UpdatedAt)
+	accessToken, refreshToken, err := refresh(ch)
+	if err != nil {
+		return "", err
+	}
+
+	ch.AccessToken = accessToken
+	if refreshToken != "" {
+		ch.RefreshToken = refreshToken
+	}
+	ch.UpdatedAt = time.Now().Unix()
+	// Since the new token is valid, we simply log the error
+	if err = systemconfig.New().UpdateCodeHost(ch.ID, ch); err != nil {
+		log.Errorf("failed to update codehost, err: %s", err)
+	}
+
+	return accessToken, nil
+}
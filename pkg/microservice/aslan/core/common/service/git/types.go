@@ -33,6 +33,13 @@ type RepositoryCommit struct {
 	Message string `json:"message"`
 }
 
+// FileChanges are the file changes committed at once, keyed by the full path of the files.
+type FileChanges struct {
+	Created map[string]string
+	Updated map[string]string
+	Deleted []string
+}
+
 func ToRepositoryCommit(obj interface{}) *RepositoryCommit {
 	switch o := obj.(type) {
 	case *github.RepositoryCommit:
@@ -0,0 +1,56 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitea
+
+import (
+	"strconv"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	gitservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/git"
+	"github.com/koderover/zadig/pkg/tool/gitea"
+)
+
+type Client struct {
+	*gitea.Client
+}
+
+// NewClient creates a gitea client of the codehost, an expiring OAuth token is refreshed first.
+func NewClient(id int, address, accessToken, proxyAddress string, enableProxy bool) (*Client, error) {
+	token, err := getAccessToken(id, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		Client: gitea.NewClient(address, token, proxyAddress, enableProxy),
+	}, nil
+}
+
+func (c *Client) CreateWebHook(owner, repo string) (string, error) {
+	hook, err := c.CreateHook(owner, repo, config.WebHookURL(), gitservice.GetHookSecret())
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(hook.ID, 10), nil
+}
+
+func (c *Client) DeleteWebHook(owner, repo, hookID string) error {
+	id, err := strconv.ParseInt(hookID, 10, 64)
+	if err != nil {
+		return err
+	}
+	return c.DeleteHook(owner, repo, id)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitea
+
+import (
+	"path"
+
+	"github.com/27149chen/afero"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/git"
+)
+
+func (c *Client) GetTree(owner, repo, filePath, branch string) ([]*git.TreeNode, error) {
+	var treeNodes []*git.TreeNode
+
+	_, dirContent, err := c.GetContents(owner, repo, filePath, branch)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range dirContent {
+		treeNodes = append(treeNodes, &git.TreeNode{
+			Name:     entry.Name,
+			Size:     int(entry.Size),
+			IsDir:    entry.Type == "dir",
+			FullPath: path.Join(filePath, entry.Name),
+		})
+	}
+	return treeNodes, nil
+}
+
+func (c *Client) GetTreeContents(owner, repo, path, branch string) (afero.Fs, error) {
+	return c.Client.GetTreeContents(owner, repo, path, branch)
+}
+
+func (c *Client) GetYAMLContents(owner, repo, path, branch string, isDir, split bool) ([]string, error) {
+	return c.Client.GetYAMLContents(owner, repo, path, branch, split)
+}
+
+func (c *Client) GetLatestRepositoryCommit(owner, repo, path, branch string) (*git.RepositoryCommit, error) {
+	commit, err := c.GetLatestCommit(owner, repo, path, branch)
+	if err != nil {
+		return nil, err
+	}
+	return &git.RepositoryCommit{SHA: commit.SHA, Message: commit.Commit.Message}, nil
+}
+
+func (c *Client) GetFileContent(owner, repo, path, branch string) ([]byte, error) {
+	return c.GetRawFile(owner, repo, path, branch)
+}
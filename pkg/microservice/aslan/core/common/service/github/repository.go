@@ -64,3 +64,33 @@ func (c *Client) GetFileContent(owner, repo, path, branch string) ([]byte, error
 	res, err := fileContent.GetContent()
 	return []byte(res), err
 }
+
+// CommitFiles commits the changes to the branch, which is created from baseBranch if they are different.
+func (c *Client) CommitFiles(owner, repo, branch, baseBranch, message string, changes *git.FileChanges) (string, error) {
+	if baseBranch != "" && baseBranch != branch {
+		if err := c.Client.CreateBranch(context.TODO(), owner, repo, branch, baseBranch); err != nil {
+			return "", err
+		}
+	}
+
+	files := make(map[string]string, len(changes.Created)+len(changes.Updated))
+	for path, content := range changes.Created {
+		files[path] = content
+	}
+	for path, content := range changes.Updated {
+		files[path] = content
+	}
+	commit, err := c.Client.CommitFiles(context.TODO(), owner, repo, branch, message, files, changes.Deleted)
+	if err != nil {
+		return "", err
+	}
+	return commit.GetSHA(), nil
+}
+
+func (c *Client) CreateMergeRequest(owner, repo, sourceBranch, targetBranch, title string) (string, error) {
+	pr, err := c.Client.CreatePullRequest(context.TODO(), owner, repo, title, sourceBranch, targetBranch)
+	if err != nil {
+		return "", err
+	}
+	return pr.GetHTMLURL(), nil
+}
@@ -18,6 +18,7 @@ package gitlab
 
 import (
 	"github.com/27149chen/afero"
+	"github.com/xanzy/go-gitlab"
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/git"
 )
@@ -43,3 +44,42 @@ func (c *Client) GetLatestRepositoryCommit(owner, repo, path, branch string) (*g
 	res, err := c.Client.GetLatestRepositoryCommit(owner, repo, path, branch)
 	return git.ToRepositoryCommit(res), err
 }
+
+// CommitFiles commits the changes to the branch, which is created from baseBranch if they are different.
+func (c *Client) CommitFiles(owner, repo, branch, baseBranch, message string, changes *git.FileChanges) (string, error) {
+	actions := make([]*gitlab.CommitActionOptions, 0, len(changes.Created)+len(changes.Updated)+len(changes.Deleted))
+	for path, content := range changes.Created {
+		actions = append(actions, newCommitAction(gitlab.FileCreate, path, content))
+	}
+	for path, content := range changes.Updated {
+		actions = append(actions, newCommitAction(gitlab.FileUpdate, path, content))
+	}
+	for _, path := range changes.Deleted {
+		actions = append(actions, newCommitAction(gitlab.FileDelete, path, ""))
+	}
+
+	commit, err := c.Client.CommitFiles(owner, repo, branch, baseBranch, message, actions)
+	if err != nil {
+		return "", err
+	}
+	return commit.ID, nil
+}
+
+func newCommitAction(action gitlab.FileActionValue, path, content string) *gitlab.CommitActionOptions {
+	opt := &gitlab.CommitActionOptions{
+		Action:   gitlab.FileAction(action),
+		FilePath: gitlab.String(path),
+	}
+	if action != gitlab.FileDelete {
+		opt.Content = gitlab.String(content)
+	}
+	return opt
+}
+
+func (c *Client) CreateMergeRequest(owner, repo, sourceBranch, targetBranch, title string) (string, error) {
+	mr, err := c.Client.CreateMergeRequest(owner, repo, sourceBranch, targetBranch, title)
+	if err != nil {
+		return "", err
+	}
+	return mr.WebURL, nil
+}
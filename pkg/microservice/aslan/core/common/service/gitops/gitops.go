@@ -0,0 +1,286 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitops
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/git"
+	githubservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/github"
+	gitlabservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/gitlab"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/kube"
+	commonutil "github.com/koderover/zadig/pkg/microservice/aslan/core/common/util"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
+	"github.com/koderover/zadig/pkg/tool/kube/serializer"
+)
+
+// Repo is the git repository the manifests of gitops environments are committed to.
+type Repo interface {
+	GetTree(owner, repo, path, branch string) ([]*git.TreeNode, error)
+	GetFileContent(owner, repo, path, branch string) ([]byte, error)
+	GetLatestRepositoryCommit(owner, repo, path, branch string) (*git.RepositoryCommit, error)
+	CommitFiles(owner, repo, branch, baseBranch, message string, changes *git.FileChanges) (string, error)
+	CreateMergeRequest(owner, repo, sourceBranch, targetBranch, title string) (string, error)
+}
+
+func GetRepo(codehostID int) (Repo, error) {
+	ch, err := systemconfig.New().GetCodeHost(codehostID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get codehost %d, err: %s", codehostID, err)
+	}
+
+	switch ch.Type {
+	case setting.SourceFromGithub:
+		return githubservice.NewClient(ch.AccessToken, config.ProxyHTTPSAddr(), ch.EnableProxy), nil
+	case setting.SourceFromGitlab:
+		return gitlabservice.NewClient(ch.ID, ch.Address, ch.AccessToken, config.ProxyHTTPSAddr(), ch.EnableProxy)
+	default:
+		return nil, fmt.Errorf("codehost type %s is not supported by gitops", ch.Type)
+	}
+}
+
+// ExportEnv renders the manifests of all the deployed services in the environment and commits them to
+// the configured git repository, one file per service. Files of services which no longer exist are removed.
+// The sync status of the environment is updated whether the export succeeds or not.
+func ExportEnv(env *commonmodels.Product, username string, log *zap.SugaredLogger) (*commonmodels.ProductGitOpsStatus, error) {
+	status, err := exportEnv(env, username, log)
+	if err != nil {
+		log.Errorf("failed to export env %s/%s to git, err: %s", env.ProductName, env.EnvName, err)
+		status = &commonmodels.ProductGitOpsStatus{SyncStatus: setting.GitOpsStatusFailed, Error: err.Error(), ExportTime: time.Now().Unix()}
+	}
+	if errUpdate := commonrepo.NewProductColl().UpdateGitOpsStatus(env.EnvName, env.ProductName, status); errUpdate != nil {
+		log.Errorf("failed to update gitops status of env %s/%s, err: %s", env.ProductName, env.EnvName, errUpdate)
+	}
+	return status, err
+}
+
+func exportEnv(env *commonmodels.Product, username string, log *zap.SugaredLogger) (*commonmodels.ProductGitOpsStatus, error) {
+	repo, err := GetRepo(env.GitOps.CodehostID)
+	if err != nil {
+		return nil, err
+	}
+
+	env.EnsureRenderInfo()
+	renderSet, err := commonrepo.NewRenderSetColl().Find(&commonrepo.RenderSetFindOption{
+		ProductTmpl: env.ProductName,
+		EnvName:     env.EnvName,
+		Name:        env.Render.Name,
+		Revision:    env.Render.Revision,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find renderset, err: %s", err)
+	}
+
+	manifests, err := renderEnvManifests(env, renderSet, log)
+	if err != nil {
+		return nil, err
+	}
+	return commitManifests(env, manifests, username, repo, log)
+}
+
+// commitManifests writes the manifests keyed by service name to the path of the environment in one commit.
+func commitManifests(env *commonmodels.Product, manifests map[string]string, username string, repo Repo, log *zap.SugaredLogger) (*commonmodels.ProductGitOpsStatus, error) {
+	cfg := env.GitOps
+	files := make(map[string]string, len(manifests))
+	for svcName, manifest := range manifests {
+		files[path.Join(cfg.Path, svcName+".yaml")] = manifest
+	}
+
+	// the path does not exist before the first export
+	nodes, err := repo.GetTree(cfg.GetRepoNamespace(), cfg.RepoName, cfg.Path, cfg.Branch)
+	if err != nil {
+		log.Infof("failed to get tree of %s in branch %s, treat it as empty, err: %s", cfg.Path, cfg.Branch, err)
+	}
+	changes := fileChanges(nodes, files)
+
+	status := &commonmodels.ProductGitOpsStatus{
+		ManifestDigest: manifestDigest(files),
+		ExportTime:     time.Now().Unix(),
+	}
+	if len(files) == 0 && len(changes.Deleted) == 0 {
+		status.SyncStatus = setting.GitOpsStatusSynced
+		return status, nil
+	}
+
+	branch := cfg.Branch
+	if cfg.MergeRequest {
+		branch = fmt.Sprintf("zadig/%s-%s-%d", env.ProductName, env.EnvName, status.ExportTime)
+	}
+	message := fmt.Sprintf("Update manifests of environment %s/%s by %s", env.ProductName, env.EnvName, username)
+	status.ExportBranch = branch
+	status.ExportCommitSHA, err = repo.CommitFiles(cfg.GetRepoNamespace(), cfg.RepoName, branch, cfg.Branch, message, changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit manifests to %s/%s:%s, err: %s", cfg.GetRepoNamespace(), cfg.RepoName, branch, err)
+	}
+
+	if cfg.MergeRequest {
+		status.MergeRequestURL, err = repo.CreateMergeRequest(cfg.GetRepoNamespace(), cfg.RepoName, branch, cfg.Branch, message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create merge request from %s to %s, err: %s", branch, cfg.Branch, err)
+		}
+	}
+
+	if err = CheckStatus(cfg, status, repo); err != nil {
+		log.Warnf("failed to check gitops status of env %s/%s, err: %s", env.ProductName, env.EnvName, err)
+		status.Error = err.Error()
+	}
+	return status, nil
+}
+
+// fileChanges compares the rendered files with the yaml files in the repository, the yaml files which are not
+// rendered any more are deleted.
+func fileChanges(nodes []*git.TreeNode, files map[string]string) *git.FileChanges {
+	changes := &git.FileChanges{Created: make(map[string]string), Updated: make(map[string]string)}
+	existed := make(map[string]bool)
+	for _, node := range nodes {
+		if node.IsDir || !strings.HasSuffix(node.Name, ".yaml") {
+			continue
+		}
+		existed[node.FullPath] = true
+		if _, ok := files[node.FullPath]; !ok {
+			changes.Deleted = append(changes.Deleted, node.FullPath)
+		}
+	}
+	for file, content := range files {
+		if existed[file] {
+			changes.Updated[file] = content
+		} else {
+			changes.Created[file] = content
+		}
+	}
+	return changes
+}
+
+// CheckStatus compares the manifests in the target branch with the exported ones.
+func CheckStatus(cfg *commonmodels.ProductGitOps, status *commonmodels.ProductGitOpsStatus, repo Repo) error {
+	status.CheckTime = time.Now().Unix()
+	status.Error = ""
+
+	commit, err := repo.GetLatestRepositoryCommit(cfg.GetRepoNamespace(), cfg.RepoName, cfg.Path, cfg.Branch)
+	if err != nil {
+		return fmt.Errorf("failed to get latest commit of %s, err: %s", cfg.Path, err)
+	}
+	if commit != nil {
+		status.CommitSHA = commit.SHA
+	}
+
+	files := make(map[string]string)
+	nodes, err := repo.GetTree(cfg.GetRepoNamespace(), cfg.RepoName, cfg.Path, cfg.Branch)
+	if err != nil {
+		return fmt.Errorf("failed to get tree of %s, err: %s", cfg.Path, err)
+	}
+	for _, node := range nodes {
+		if node.IsDir || !strings.HasSuffix(node.Name, ".yaml") {
+			continue
+		}
+		content, err := repo.GetFileContent(cfg.GetRepoNamespace(), cfg.RepoName, node.FullPath, cfg.Branch)
+		if err != nil {
+			return fmt.Errorf("failed to get content of %s, err: %s", node.FullPath, err)
+		}
+		files[node.FullPath] = string(content)
+	}
+
+	switch {
+	case manifestDigest(files) == status.ManifestDigest:
+		status.SyncStatus = setting.GitOpsStatusSynced
+	case status.MergeRequestURL != "":
+		status.SyncStatus = setting.GitOpsStatusPending
+	default:
+		status.SyncStatus = setting.GitOpsStatusOutOfSync
+	}
+	return nil
+}
+
+// renderEnvManifests renders the manifests of the deployed k8s services in the environment the same way as they
+// are applied to the cluster, the returned map is keyed by service name.
+func renderEnvManifests(env *commonmodels.Product, renderSet *commonmodels.RenderSet, log *zap.SugaredLogger) (map[string]string, error) {
+	ret := make(map[string]string)
+	for _, svc := range env.GetServiceMap() {
+		if svc.Type != setting.K8SDeployType || !commonutil.ServiceDeployed(svc.ServiceName, env.ServiceDeployStrategy) {
+			continue
+		}
+
+		parsedYaml, err := kube.RenderEnvService(env, renderSet, svc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render service %s, err: %s", svc.ServiceName, err)
+		}
+
+		labels := map[string]string{setting.ProductLabel: env.ProductName, setting.ServiceLabel: svc.ServiceName}
+		var docs []string
+		for _, item := range releaseutil.SplitManifests(parsedYaml) {
+			u, err := serializer.NewDecoder().YamlToUnstructured([]byte(item))
+			if err != nil {
+				log.Errorf("Failed to convert yaml to Unstructured, manifest is\n%s\n, error: %v", item, err)
+				return nil, fmt.Errorf("invalid manifest of service %s, err: %s", svc.ServiceName, err)
+			}
+
+			u.SetNamespace(env.Namespace)
+			u.SetLabels(kube.MergeLabels(labels, u.GetLabels()))
+			switch u.GetKind() {
+			case setting.Deployment, setting.StatefulSet, setting.DaemonSet, setting.Job:
+				podLabels, _, err := unstructured.NestedStringMap(u.Object, "spec", "template", "metadata", "labels")
+				if err != nil {
+					podLabels = nil
+				}
+				err = unstructured.SetNestedStringMap(u.Object, kube.MergeLabels(labels, podLabels), "spec", "template", "metadata", "labels")
+				if err != nil {
+					return nil, fmt.Errorf("invalid pod template of %s/%s in service %s, err: %s", u.GetKind(), u.GetName(), svc.ServiceName, err)
+				}
+			}
+
+			content, err := yaml.Marshal(u.Object)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal manifest of service %s, err: %s", svc.ServiceName, err)
+			}
+			docs = append(docs, string(content))
+		}
+		ret[svc.ServiceName] = strings.Join(docs, "---\n")
+	}
+	return ret, nil
+}
+
+func manifestDigest(files map[string]string) string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write([]byte(files[name]))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,300 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitops
+
+import (
+	"errors"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/git"
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+// fakeRepo keeps the files of one branch in memory, committed files are written to it directly.
+type fakeRepo struct {
+	files     map[string]string
+	commitSHA string
+	treeErr   error
+	commitErr error
+
+	committedBranch string
+	committed       *git.FileChanges
+	mergeRequests   int
+}
+
+func (r *fakeRepo) GetTree(owner, repo, dir, branch string) ([]*git.TreeNode, error) {
+	if r.treeErr != nil {
+		return nil, r.treeErr
+	}
+	var nodes []*git.TreeNode
+	for name := range r.files {
+		if path.Dir(name) == dir {
+			nodes = append(nodes, &git.TreeNode{Name: path.Base(name), FullPath: name})
+		}
+	}
+	nodes = append(nodes, &git.TreeNode{Name: "sub", FullPath: path.Join(dir, "sub"), IsDir: true})
+	return nodes, nil
+}
+
+func (r *fakeRepo) GetFileContent(owner, repo, file, branch string) ([]byte, error) {
+	content, ok := r.files[file]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return []byte(content), nil
+}
+
+func (r *fakeRepo) GetLatestRepositoryCommit(owner, repo, dir, branch string) (*git.RepositoryCommit, error) {
+	return &git.RepositoryCommit{SHA: r.commitSHA}, nil
+}
+
+func (r *fakeRepo) CommitFiles(owner, repo, branch, baseBranch, message string, changes *git.FileChanges) (string, error) {
+	if r.commitErr != nil {
+		return "", r.commitErr
+	}
+	r.committedBranch = branch
+	r.committed = changes
+	// commits to another branch are not visible in the base branch until the merge request is merged
+	if branch == baseBranch {
+		for name, content := range changes.Created {
+			r.files[name] = content
+		}
+		for name, content := range changes.Updated {
+			r.files[name] = content
+		}
+		for _, name := range changes.Deleted {
+			delete(r.files, name)
+		}
+	}
+	return "sha-export", nil
+}
+
+func (r *fakeRepo) CreateMergeRequest(owner, repo, sourceBranch, targetBranch, title string) (string, error) {
+	r.mergeRequests++
+	return "https://git.example.com/mr/1", nil
+}
+
+func TestManifestDigest(t *testing.T) {
+	base := manifestDigest(map[string]string{"env/a.yaml": "a", "env/b.yaml": "b"})
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		same  bool
+	}{
+		{name: "same files", files: map[string]string{"env/b.yaml": "b", "env/a.yaml": "a"}, same: true},
+		{name: "changed content", files: map[string]string{"env/a.yaml": "a", "env/b.yaml": "c"}},
+		{name: "renamed file", files: map[string]string{"env/a.yaml": "a", "env/c.yaml": "b"}},
+		{name: "removed file", files: map[string]string{"env/a.yaml": "a"}},
+		{name: "content moved across the name boundary", files: map[string]string{"env/a.yamla": "", "env/b.yaml": "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := manifestDigest(tt.files) == base; got != tt.same {
+				t.Errorf("digest equal = %v, want %v", got, tt.same)
+			}
+		})
+	}
+}
+
+func TestFileChanges(t *testing.T) {
+	nodes := []*git.TreeNode{
+		{Name: "a.yaml", FullPath: "env/a.yaml"},
+		{Name: "b.yaml", FullPath: "env/b.yaml"},
+		{Name: "README.md", FullPath: "env/README.md"},
+		{Name: "sub", FullPath: "env/sub", IsDir: true},
+	}
+
+	tests := []struct {
+		name        string
+		nodes       []*git.TreeNode
+		files       map[string]string
+		wantCreated []string
+		wantUpdated []string
+		wantDeleted []string
+	}{
+		{
+			name:        "first export",
+			files:       map[string]string{"env/a.yaml": "a"},
+			wantCreated: []string{"env/a.yaml"},
+		},
+		{
+			name:        "create, update and delete",
+			nodes:       nodes,
+			files:       map[string]string{"env/a.yaml": "a", "env/c.yaml": "c"},
+			wantCreated: []string{"env/c.yaml"},
+			wantUpdated: []string{"env/a.yaml"},
+			wantDeleted: []string{"env/b.yaml"},
+		},
+		{
+			name:        "all services removed",
+			nodes:       nodes,
+			files:       map[string]string{},
+			wantDeleted: []string{"env/a.yaml", "env/b.yaml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := fileChanges(tt.nodes, tt.files)
+			if got := sortedKeys(changes.Created); !reflect.DeepEqual(got, tt.wantCreated) {
+				t.Errorf("created = %v, want %v", got, tt.wantCreated)
+			}
+			if got := sortedKeys(changes.Updated); !reflect.DeepEqual(got, tt.wantUpdated) {
+				t.Errorf("updated = %v, want %v", got, tt.wantUpdated)
+			}
+			sort.Strings(changes.Deleted)
+			if !reflect.DeepEqual(changes.Deleted, tt.wantDeleted) {
+				t.Errorf("deleted = %v, want %v", changes.Deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestCheckStatus(t *testing.T) {
+	cfg := &commonmodels.ProductGitOps{RepoOwner: "koderover", RepoName: "manifests", Branch: "main", Path: "env"}
+	files := map[string]string{"env/a.yaml": "a", "env/b.yaml": "b"}
+	digest := manifestDigest(files)
+
+	tests := []struct {
+		name       string
+		repo       *fakeRepo
+		status     *commonmodels.ProductGitOpsStatus
+		wantStatus string
+		wantErr    bool
+	}{
+		{
+			name:       "synced",
+			repo:       &fakeRepo{files: map[string]string{"env/a.yaml": "a", "env/b.yaml": "b", "env/notes.txt": "x"}, commitSHA: "sha-1"},
+			status:     &commonmodels.ProductGitOpsStatus{ManifestDigest: digest},
+			wantStatus: setting.GitOpsStatusSynced,
+		},
+		{
+			name:       "changed in git",
+			repo:       &fakeRepo{files: map[string]string{"env/a.yaml": "a", "env/b.yaml": "changed"}, commitSHA: "sha-2"},
+			status:     &commonmodels.ProductGitOpsStatus{ManifestDigest: digest},
+			wantStatus: setting.GitOpsStatusOutOfSync,
+		},
+		{
+			name:       "merge request not merged",
+			repo:       &fakeRepo{files: map[string]string{"env/a.yaml": "a"}, commitSHA: "sha-3"},
+			status:     &commonmodels.ProductGitOpsStatus{ManifestDigest: digest, MergeRequestURL: "https://git.example.com/mr/1"},
+			wantStatus: setting.GitOpsStatusPending,
+		},
+		{
+			name:    "tree error",
+			repo:    &fakeRepo{treeErr: errors.New("forbidden")},
+			status:  &commonmodels.ProductGitOpsStatus{ManifestDigest: digest, Error: "previous error"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckStatus(cfg, tt.status, tt.repo)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.status.SyncStatus != tt.wantStatus {
+				t.Errorf("sync status = %s, want %s", tt.status.SyncStatus, tt.wantStatus)
+			}
+			if tt.status.CommitSHA != tt.repo.commitSHA || tt.status.CheckTime == 0 || tt.status.Error != "" {
+				t.Errorf("unexpected status %+v", tt.status)
+			}
+		})
+	}
+}
+
+func TestCommitManifests(t *testing.T) {
+	manifests := map[string]string{"a": "kind: Deployment", "c": "kind: Service"}
+	logger := zap.NewNop().Sugar()
+
+	tests := []struct {
+		name              string
+		mergeRequest      bool
+		repo              *fakeRepo
+		wantBranchPrefix  string
+		wantSyncStatus    string
+		wantMergeRequests int
+		wantErr           bool
+	}{
+		{
+			name:             "commit to the branch",
+			repo:             &fakeRepo{files: map[string]string{"env/a.yaml": "old", "env/b.yaml": "b"}},
+			wantBranchPrefix: "main",
+			wantSyncStatus:   setting.GitOpsStatusSynced,
+		},
+		{
+			name:              "open a merge request",
+			mergeRequest:      true,
+			repo:              &fakeRepo{files: map[string]string{"env/a.yaml": "old"}},
+			wantBranchPrefix:  "zadig/project-dev-",
+			wantSyncStatus:    setting.GitOpsStatusPending,
+			wantMergeRequests: 1,
+		},
+		{
+			name:    "commit failure",
+			repo:    &fakeRepo{files: map[string]string{}, commitErr: errors.New("protected branch")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := &commonmodels.Product{
+				ProductName: "project",
+				EnvName:     "dev",
+				GitOps:      &commonmodels.ProductGitOps{Enabled: true, RepoOwner: "koderover", RepoName: "manifests", Branch: "main", Path: "env", MergeRequest: tt.mergeRequest},
+			}
+			status, err := commitManifests(env, manifests, "admin", tt.repo, logger)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("commitManifests() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !strings.HasPrefix(tt.repo.committedBranch, tt.wantBranchPrefix) || status.ExportBranch != tt.repo.committedBranch {
+				t.Errorf("committed to %s, status branch %s, want prefix %s", tt.repo.committedBranch, status.ExportBranch, tt.wantBranchPrefix)
+			}
+			if status.ExportCommitSHA != "sha-export" || status.SyncStatus != tt.wantSyncStatus || tt.repo.mergeRequests != tt.wantMergeRequests {
+				t.Errorf("unexpected status %+v, merge requests %d", status, tt.repo.mergeRequests)
+			}
+			if got := sortedKeys(tt.repo.committed.Created); !reflect.DeepEqual(got, []string{"env/c.yaml"}) {
+				t.Errorf("created = %v", got)
+			}
+			if got := sortedKeys(tt.repo.committed.Updated); !reflect.DeepEqual(got, []string{"env/a.yaml"}) {
+				t.Errorf("updated = %v", got)
+			}
+		})
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
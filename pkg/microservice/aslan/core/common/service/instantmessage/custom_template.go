@@ -0,0 +1,388 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instantmessage
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/types"
+	"github.com/koderover/zadig/pkg/types/job"
+	"github.com/koderover/zadig/pkg/types/step"
+)
+
+const credentialMask = "******"
+
+// NotificationTemplateData is the data a user-defined notification template is rendered with.
+// It only carries flattened fields of the task, credential params are masked.
+type NotificationTemplateData struct {
+	ProjectName         string                   `json:"project_name"`
+	WorkflowName        string                   `json:"workflow_name"`
+	WorkflowDisplayName string                   `json:"workflow_display_name"`
+	TaskID              int64                    `json:"task_id"`
+	Status              string                   `json:"status"`
+	Creator             string                   `json:"creator"`
+	CreatorEmail        string                   `json:"creator_email"`
+	StartTime           int64                    `json:"start_time"`
+	EndTime             int64                    `json:"end_time"`
+	Duration            int64                    `json:"duration"`
+	Error               string                   `json:"error"`
+	URL                 string                   `json:"url"`
+	WebHookType         string                   `json:"webhook_type"`
+	Stages              []*NotificationStage     `json:"stages"`
+	Jobs                []*NotificationJob       `json:"jobs"`
+	Params              map[string]string        `json:"params"`
+	Outputs             map[string]string        `json:"outputs"`
+	Commits             []*NotificationCommit    `json:"commits"`
+	TestSummary         *NotificationTestSummary `json:"test_summary"`
+}
+
+type NotificationStage struct {
+	Name      string             `json:"name"`
+	Status    string             `json:"status"`
+	StartTime int64              `json:"start_time"`
+	EndTime   int64              `json:"end_time"`
+	Error     string             `json:"error"`
+	Jobs      []*NotificationJob `json:"jobs"`
+}
+
+type NotificationJob struct {
+	Name      string                `json:"name"`
+	Key       string                `json:"key"`
+	Type      string                `json:"type"`
+	Stage     string                `json:"stage"`
+	Status    string                `json:"status"`
+	StartTime int64                 `json:"start_time"`
+	EndTime   int64                 `json:"end_time"`
+	Error     string                `json:"error"`
+	Image     string                `json:"image"`
+	Env       string                `json:"env"`
+	Commits   []*NotificationCommit `json:"commits"`
+	Outputs   map[string]string     `json:"outputs"`
+}
+
+type NotificationCommit struct {
+	Source        string `json:"source"`
+	RepoOwner     string `json:"repo_owner"`
+	RepoName      string `json:"repo_name"`
+	Branch        string `json:"branch"`
+	Tag           string `json:"tag"`
+	PRs           []int  `json:"prs"`
+	CommitID      string `json:"commit_id"`
+	CommitMessage string `json:"commit_message"`
+	CommitURL     string `json:"commit_url"`
+}
+
+// NotificationTestSummary counts the testing jobs of the task by their status.
+type NotificationTestSummary struct {
+	Total  int                `json:"total"`
+	Passed int                `json:"passed"`
+	Failed int                `json:"failed"`
+	Jobs   []*NotificationJob `json:"jobs"`
+}
+
+// NotificationPreview is a rendered notification, Content is empty for feishu which uses LarkCard instead.
+type NotificationPreview struct {
+	Title    string    `json:"title"`
+	Content  string    `json:"content"`
+	LarkCard *LarkCard `json:"lark_card,omitempty"`
+}
+
+// ValidateNotificationTemplate checks that the user-defined templates of notify can be parsed.
+func ValidateNotificationTemplate(notify *models.NotifyCtl) error {
+	if notify.TitleTemplate != "" {
+		if _, err := parseNotificationTemplate(notify.TitleTemplate); err != nil {
+			return fmt.Errorf("invalid title template: %s", err)
+		}
+	}
+	if notify.ContentTemplate != "" {
+		if _, err := parseNotificationTemplate(notify.ContentTemplate); err != nil {
+			return fmt.Errorf("invalid content template: %s", err)
+		}
+	}
+	return nil
+}
+
+// PreviewWorkflowTaskNotification renders the notification of notify against a workflow task without sending it.
+func (w *Service) PreviewWorkflowTaskNotification(notify *models.NotifyCtl, task *models.WorkflowTask) (*NotificationPreview, error) {
+	var (
+		title, content string
+		larkCard       *LarkCard
+		err            error
+	)
+	switch {
+	case notify.ContentTemplate != "":
+		title, content, larkCard, err = w.getCustomNotificationContent(notify, task, task.Status == config.StatusWaitingApprove)
+	case task.Status == config.StatusWaitingApprove:
+		title, content, larkCard, err = w.getApproveNotificationContent(notify, task)
+	default:
+		title, content, larkCard, err = w.getNotificationContent(notify, task)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &NotificationPreview{Title: title, Content: content, LarkCard: larkCard}, nil
+}
+
+// getCustomNotificationContent renders the user-defined templates of notify, it returns empty content if there is no template.
+func (w *Service) getCustomNotificationContent(notify *models.NotifyCtl, task *models.WorkflowTask, isApprove bool) (string, string, *LarkCard, error) {
+	if notify.ContentTemplate == "" {
+		return "", "", nil, nil
+	}
+	data := newNotificationTemplateData(notify, task)
+
+	titleTemplate := notify.TitleTemplate
+	if titleTemplate == "" {
+		titleTemplate = "{{getIcon .Status}}工作流 {{.WorkflowDisplayName}} #{{.TaskID}} {{taskStatus .Status}}"
+		if isApprove {
+			titleTemplate = "{{getIcon .Status}}工作流 {{.WorkflowDisplayName}} #{{.TaskID}} 等待审批"
+		}
+	}
+	title, err := renderNotificationTemplate(titleTemplate, data)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to render title template: %s", err)
+	}
+	title = strings.TrimSpace(title)
+	body, err := renderNotificationTemplate(notify.ContentTemplate, data)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to render content template: %s", err)
+	}
+	body = strings.TrimSpace(body)
+
+	buttonContent := "点击查看更多信息"
+	if notify.WebHookType == feiShuType {
+		headerTemplate := getColorTemplateWithStatus(task.Status)
+		if isApprove {
+			headerTemplate = feishuHeaderTemplateGreen
+		}
+		lc := NewLarkCard()
+		lc.SetConfig(true)
+		lc.SetHeader(headerTemplate, title, feiShuTagText)
+		lc.AddI18NElementsZhcnFeild(body, true)
+		lc.AddI18NElementsZhcnAction(buttonContent, data.URL)
+		return "", "", lc, nil
+	}
+
+	title = fmt.Sprintf("#### %s \n", title)
+	content := fmt.Sprintf("%s%s \n%s\n\n[%s](%s)", title, body, getNotifyAtContent(notify), buttonContent, data.URL)
+	return title, content, nil, nil
+}
+
+func newNotificationTemplateData(notify *models.NotifyCtl, task *models.WorkflowTask) *NotificationTemplateData {
+	data := &NotificationTemplateData{
+		ProjectName:         task.ProjectName,
+		WorkflowName:        task.WorkflowName,
+		WorkflowDisplayName: task.WorkflowDisplayName,
+		TaskID:              task.TaskID,
+		Status:              string(task.Status),
+		Creator:             task.TaskCreator,
+		CreatorEmail:        task.TaskCreatorEmail,
+		StartTime:           task.StartTime,
+		EndTime:             task.EndTime,
+		Error:               task.Error,
+		WebHookType:         notify.WebHookType,
+		Params:              map[string]string{},
+		Outputs:             map[string]string{},
+		TestSummary:         &NotificationTestSummary{},
+		URL: fmt.Sprintf("%s/v1/projects/detail/%s/pipelines/custom/%s/%d?display_name=%s",
+			configbase.SystemAddress(), task.ProjectName, task.WorkflowName, task.TaskID, url.PathEscape(task.WorkflowDisplayName)),
+	}
+	if task.StartTime > 0 {
+		endTime := task.EndTime
+		if endTime == 0 {
+			endTime = time.Now().Unix()
+		}
+		data.Duration = endTime - task.StartTime
+	}
+	for _, param := range task.Params {
+		if param.IsCredential {
+			data.Params[param.Name] = credentialMask
+			continue
+		}
+		data.Params[param.Name] = param.Value
+	}
+
+	for _, stage := range task.Stages {
+		notificationStage := &NotificationStage{
+			Name:      stage.Name,
+			Status:    string(stage.Status),
+			StartTime: stage.StartTime,
+			EndTime:   stage.EndTime,
+			Error:     stage.Error,
+		}
+		data.Stages = append(data.Stages, notificationStage)
+		for _, jobTask := range stage.Jobs {
+			notificationJob := &NotificationJob{
+				Name:      jobTask.Name,
+				Key:       jobTask.Key,
+				Type:      jobTask.JobType,
+				Stage:     stage.Name,
+				Status:    string(jobTask.Status),
+				StartTime: jobTask.StartTime,
+				EndTime:   jobTask.EndTime,
+				Error:     jobTask.Error,
+				Outputs:   map[string]string{},
+			}
+			for _, output := range jobTask.Outputs {
+				value := task.GlobalContext[job.GetJobOutputKey(jobTask.Key, output.Name)]
+				notificationJob.Outputs[output.Name] = value
+				data.Outputs[jobTask.Key+"."+output.Name] = value
+			}
+
+			switch jobTask.JobType {
+			case string(config.JobZadigBuild), string(config.JobFreestyle), string(config.JobZadigTesting), string(config.JobZadigScanning):
+				jobSpec := &models.JobTaskFreestyleSpec{}
+				models.IToi(jobTask.Spec, jobSpec)
+				for _, repo := range getJobTaskRepos(jobSpec) {
+					notificationJob.Commits = append(notificationJob.Commits, newNotificationCommit(repo))
+				}
+				for _, env := range jobSpec.Properties.Envs {
+					if env.Key == "IMAGE" {
+						notificationJob.Image = env.Value
+					}
+				}
+			case string(config.JobZadigDeploy):
+				jobSpec := &models.JobTaskDeploySpec{}
+				models.IToi(jobTask.Spec, jobSpec)
+				notificationJob.Env = jobSpec.Env
+			case string(config.JobZadigHelmDeploy):
+				jobSpec := &models.JobTaskHelmDeploySpec{}
+				models.IToi(jobTask.Spec, jobSpec)
+				notificationJob.Env = jobSpec.Env
+			}
+			data.Commits = append(data.Commits, notificationJob.Commits...)
+
+			if jobTask.JobType == string(config.JobZadigTesting) {
+				data.TestSummary.Total++
+				switch jobTask.Status {
+				case config.StatusPassed:
+					data.TestSummary.Passed++
+				case config.StatusFailed, config.StatusTimeout:
+					data.TestSummary.Failed++
+				}
+				data.TestSummary.Jobs = append(data.TestSummary.Jobs, notificationJob)
+			}
+			notificationStage.Jobs = append(notificationStage.Jobs, notificationJob)
+			data.Jobs = append(data.Jobs, notificationJob)
+		}
+	}
+	return data
+}
+
+func newNotificationCommit(repo *types.Repository) *NotificationCommit {
+	commit := &NotificationCommit{
+		Source:        repo.Source,
+		RepoOwner:     repo.RepoOwner,
+		RepoName:      repo.RepoName,
+		Branch:        repo.Branch,
+		Tag:           repo.Tag,
+		PRs:           repo.PRs,
+		CommitID:      repo.CommitID,
+		CommitMessage: strings.Trim(repo.CommitMessage, "\n"),
+	}
+	if repo.CommitID != "" {
+		commit.CommitURL = fmt.Sprintf("%s/%s/%s/commit/%s", repo.Address, repo.RepoOwner, repo.RepoName, repo.CommitID)
+	}
+	return commit
+}
+
+func getJobTaskRepos(jobSpec *models.JobTaskFreestyleSpec) []*types.Repository {
+	repos := []*types.Repository{}
+	for _, stepTask := range jobSpec.Steps {
+		if stepTask.StepType == config.StepGit {
+			stepSpec := &step.StepGitSpec{}
+			models.IToi(stepTask.Spec, stepSpec)
+			repos = stepSpec.Repos
+		}
+	}
+	return repos
+}
+
+func parseNotificationTemplate(tplcontent string) (*template.Template, error) {
+	return template.New("custom-notify").Option("missingkey=zero").Funcs(template.FuncMap{
+		"taskStatus": func(status interface{}) string {
+			switch config.Status(fmt.Sprint(status)) {
+			case config.StatusPassed:
+				return "执行成功"
+			case config.StatusCancelled:
+				return "执行取消"
+			case config.StatusTimeout:
+				return "执行超时"
+			case config.StatusReject:
+				return "执行被拒绝"
+			case config.StatusCreated:
+				return "开始执行"
+			case config.StatusRunning:
+				return "执行中"
+			case config.StatusWaitingApprove:
+				return "等待审批"
+			case "":
+				return "未执行"
+			}
+			return "执行失败"
+		},
+		"getIcon": func(status interface{}) string {
+			s := config.Status(fmt.Sprint(status))
+			if s == config.StatusPassed || s == config.StatusCreated || s == config.StatusWaitingApprove {
+				return "👍"
+			}
+			return "⚠️"
+		},
+		"jobType": getJobTypeName,
+		"formatTime": func(t int64) string {
+			if t <= 0 {
+				return ""
+			}
+			return time.Unix(t, 0).Format("2006-01-02 15:04:05")
+		},
+		"formatDuration": func(seconds int64) string {
+			return (time.Duration(seconds) * time.Second).String()
+		},
+		"shortCommit": func(commitID string) string {
+			if len(commitID) > 8 {
+				return commitID[:8]
+			}
+			return commitID
+		},
+		"join":      strings.Join,
+		"contains":  strings.Contains,
+		"hasPrefix": strings.HasPrefix,
+		"hasSuffix": strings.HasSuffix,
+		"lower":     strings.ToLower,
+		"upper":     strings.ToUpper,
+		"trim":      strings.TrimSpace,
+	}).Parse(tplcontent)
+}
+
+func renderNotificationTemplate(tplcontent string, data *NotificationTemplateData) (string, error) {
+	tmpl, err := parseNotificationTemplate(tplcontent)
+	if err != nil {
+		return "", err
+	}
+	buffer := bytes.NewBufferString("")
+	if err := tmpl.Execute(buffer, data); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instantmessage
+
+import (
+	"testing"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/types"
+	"github.com/koderover/zadig/pkg/types/job"
+	"github.com/koderover/zadig/pkg/types/step"
+)
+
+func newTestWorkflowTask() *models.WorkflowTask {
+	buildSpec := &models.JobTaskFreestyleSpec{
+		Properties: models.JobProperties{Envs: []*models.KeyVal{{Key: "IMAGE", Value: "koderover/app:v1"}}},
+		Steps: []*models.StepTask{{
+			StepType: config.StepGit,
+			Spec: &step.StepGitSpec{Repos: []*types.Repository{{
+				Source:        "gitlab",
+				Address:       "https://gitlab.com",
+				RepoOwner:     "koderover",
+				RepoName:      "app",
+				Branch:        "main",
+				CommitID:      "0123456789abcdef",
+				CommitMessage: "fix bug\n",
+			}}},
+		}},
+	}
+	return &models.WorkflowTask{
+		ProjectName:         "demo",
+		WorkflowName:        "release",
+		WorkflowDisplayName: "Release",
+		TaskID:              7,
+		Status:              config.StatusFailed,
+		TaskCreator:         "alice",
+		StartTime:           100,
+		EndTime:             160,
+		Params: []*models.Param{
+			{Name: "version", Value: "v1"},
+			{Name: "token", Value: "s3cr3t", IsCredential: true},
+		},
+		GlobalContext: map[string]string{
+			job.GetJobOutputKey("build", "TAG"): "v1-abc",
+		},
+		Stages: []*models.StageTask{
+			{
+				Name:   "build",
+				Status: config.StatusPassed,
+				Jobs: []*models.JobTask{{
+					Name:    "build",
+					Key:     "build",
+					JobType: string(config.JobZadigBuild),
+					Status:  config.StatusPassed,
+					Spec:    buildSpec,
+					Outputs: []*models.Output{{Name: "TAG"}},
+				}},
+			},
+			{
+				Name:   "test",
+				Status: config.StatusFailed,
+				Jobs: []*models.JobTask{
+					{Name: "unit", Key: "unit", JobType: string(config.JobZadigTesting), Status: config.StatusPassed, Spec: &models.JobTaskFreestyleSpec{}},
+					{Name: "e2e", Key: "e2e", JobType: string(config.JobZadigTesting), Status: config.StatusTimeout, Spec: &models.JobTaskFreestyleSpec{}},
+				},
+			},
+			{
+				Name:   "deploy",
+				Status: config.StatusCancelled,
+				Jobs: []*models.JobTask{{
+					Name:    "deploy",
+					Key:     "deploy",
+					JobType: string(config.JobZadigDeploy),
+					Status:  config.StatusCancelled,
+					Spec:    &models.JobTaskDeploySpec{Env: "prod"},
+				}},
+			},
+		},
+	}
+}
+
+func TestNewNotificationTemplateData(t *testing.T) {
+	data := newNotificationTemplateData(&models.NotifyCtl{WebHookType: "webhook"}, newTestWorkflowTask())
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{name: "status", got: data.Status, want: string(config.StatusFailed)},
+		{name: "webhook type", got: data.WebHookType, want: "webhook"},
+		{name: "duration", got: data.Duration, want: int64(60)},
+		{name: "plain param", got: data.Params["version"], want: "v1"},
+		{name: "credential param is masked", got: data.Params["token"], want: credentialMask},
+		{name: "stages", got: len(data.Stages), want: 3},
+		{name: "jobs", got: len(data.Jobs), want: 4},
+		{name: "jobs of a stage", got: len(data.Stages[1].Jobs), want: 2},
+		{name: "job stage", got: data.Jobs[1].Stage, want: "test"},
+		{name: "task output", got: data.Outputs["build.TAG"], want: "v1-abc"},
+		{name: "job output", got: data.Jobs[0].Outputs["TAG"], want: "v1-abc"},
+		{name: "build image", got: data.Jobs[0].Image, want: "koderover/app:v1"},
+		{name: "deploy env", got: data.Jobs[3].Env, want: "prod"},
+		{name: "commits", got: len(data.Commits), want: 1},
+		{name: "commit message", got: data.Commits[0].CommitMessage, want: "fix bug"},
+		{name: "commit url", got: data.Commits[0].CommitURL, want: "https://gitlab.com/koderover/app/commit/0123456789abcdef"},
+		{name: "tests", got: data.TestSummary.Total, want: 2},
+		{name: "passed tests", got: data.TestSummary.Passed, want: 1},
+		{name: "failed tests", got: data.TestSummary.Failed, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderNotificationTemplate(t *testing.T) {
+	data := newNotificationTemplateData(&models.NotifyCtl{WebHookType: "webhook"}, newTestWorkflowTask())
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "task fields",
+			template: "{{.WorkflowDisplayName}} #{{.TaskID}} by {{.Creator}}",
+			want:     "Release #7 by alice",
+		},
+		{
+			name:     "status functions",
+			template: "{{getIcon .Status}}{{taskStatus .Status}}",
+			want:     "⚠️执行失败",
+		},
+		{
+			name:     "masked credential param",
+			template: "{{.Params.version}} {{.Params.token}}",
+			want:     "v1 " + credentialMask,
+		},
+		{
+			name:     "missing param",
+			template: "[{{.Params.missing}}]",
+			want:     "[]",
+		},
+		{
+			name:     "jobs and commits",
+			template: "{{range .Jobs}}{{.Name}}:{{.Status}};{{end}}{{range .Commits}}{{shortCommit .CommitID}}{{end}}",
+			want:     "build:passed;unit:passed;e2e:timeout;deploy:cancelled;01234567",
+		},
+		{
+			name:     "test summary",
+			template: "{{.TestSummary.Passed}}/{{.TestSummary.Total}}",
+			want:     "1/2",
+		},
+		{
+			name:     "string functions",
+			template: `{{upper .ProjectName}} {{formatDuration .Duration}} {{formatTime 0}}`,
+			want:     "DEMO 1m0s ",
+		},
+		{
+			name:     "invalid template",
+			template: "{{.TaskID",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown field",
+			template: "{{.Unknown}}",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderNotificationTemplate(tt.template, data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderNotificationTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("renderNotificationTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
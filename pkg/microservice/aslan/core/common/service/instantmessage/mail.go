@@ -0,0 +1,75 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instantmessage
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
+	"github.com/koderover/zadig/pkg/tool/mail"
+)
+
+const mailType = "mail"
+
+func (w *Service) sendMailMessage(receivers []string, title, content string) error {
+	if len(receivers) == 0 {
+		return fmt.Errorf("no mail receivers")
+	}
+	email, err := systemconfig.New().GetEmailHost()
+	if err != nil {
+		return fmt.Errorf("failed to get email host: %s", err)
+	}
+
+	return mail.SendEmail(&mail.EmailParams{
+		From:     email.UserName,
+		To:       strings.Join(receivers, ","),
+		Subject:  plainMarkdownTitle(title),
+		Host:     email.Name,
+		UserName: email.UserName,
+		Password: email.Password,
+		Port:     email.Port,
+		Body:     markdownToHTML(content),
+	})
+}
+
+// markdownToHTML converts the markdown used by notifications into HTML.
+func markdownToHTML(s string) string {
+	s = htmlFontRegex.ReplaceAllString(s, "")
+	s = html.EscapeString(s)
+
+	lines := []string{}
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case line == "---":
+			lines = append(lines, "<hr/>")
+			continue
+		case strings.HasPrefix(line, "#"):
+			line = "<h3>" + markdownHeadingRegex.ReplaceAllString(line, "") + "</h3>"
+		default:
+			line = "<p>" + line + "</p>"
+		}
+		line = markdownBoldRegex.ReplaceAllString(line, "<b>$1</b>")
+		line = markdownLinkRegex.ReplaceAllString(line, `<a href="$2">$1</a>`)
+		lines = append(lines, line)
+	}
+	return "<html><body>" + strings.Join(lines, "\n") + "</body></html>"
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instantmessage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+// SendTextNotification sends a notification which is made of a title, a list of "key：value" fields and
+// an optional link through the webhook configured in notify.
+func (w *Service) SendTextNotification(notify *models.NotifyCtl, title string, fields []string, linkText, link string) error {
+	if notify.WebHookType == feiShuType {
+		lc := NewLarkCard()
+		lc.SetConfig(true)
+		lc.SetHeader(feishuHeaderTemplateTurquoise, title, feiShuTagText)
+		for idx, field := range fields {
+			lc.AddI18NElementsZhcnFeild(field+" \n", idx == 0)
+		}
+		if link != "" {
+			lc.AddI18NElementsZhcnAction(linkText, link)
+		}
+		return w.sendNotification("", "", notify, lc, nil)
+	}
+
+	prefix := ""
+	if notify.WebHookType == dingDingType {
+		prefix = "##### "
+	}
+	content := fmt.Sprintf("#### %s \n", title)
+	for _, field := range fields {
+		content += fmt.Sprintf("%s%s \n", prefix, field)
+	}
+	content += getNotifyAtContent(notify)
+	if link != "" {
+		content += fmt.Sprintf("[%s](%s)", linkText, link)
+	}
+	return w.sendNotification(title, strings.TrimSpace(content), notify, nil, nil)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instantmessage
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	slackType = "slack"
+	// slackMaxTextLength is the max length of the text in a Block Kit section
+	slackMaxTextLength = 3000
+)
+
+var (
+	markdownLinkRegex    = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
+	markdownBoldRegex    = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	markdownHeadingRegex = regexp.MustCompile(`(?m)^#{1,6} +`)
+	htmlFontRegex        = regexp.MustCompile(`</?font[^>]*>`)
+)
+
+type SlackMessage struct {
+	Text   string        `json:"text"`
+	Blocks []*SlackBlock `json:"blocks"`
+}
+
+type SlackBlock struct {
+	Type string     `json:"type"`
+	Text *SlackText `json:"text,omitempty"`
+}
+
+type SlackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func (w *Service) sendSlackMessage(uri, title, content string) error {
+	plainTitle := plainMarkdownTitle(title)
+	message := &SlackMessage{
+		Text: plainTitle,
+		Blocks: []*SlackBlock{
+			{Type: "header", Text: &SlackText{Type: "plain_text", Text: plainTitle}},
+		},
+	}
+
+	body := markdownToSlack(markdownBody(title, content))
+	for len(body) > 0 {
+		text := body
+		if len(text) > slackMaxTextLength {
+			text = text[:slackMaxTextLength]
+			if idx := strings.LastIndex(text, "\n"); idx > 0 {
+				text = text[:idx]
+			}
+		}
+		body = strings.TrimLeft(body[len(text):], "\n")
+		message.Blocks = append(message.Blocks, &SlackBlock{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: text}})
+	}
+
+	_, err := w.SendMessageRequest(uri, message)
+	return err
+}
+
+// markdownToSlack converts the markdown used by notifications into Slack mrkdwn.
+func markdownToSlack(s string) string {
+	s = htmlFontRegex.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, "\n---\n", "\n")
+	s = markdownHeadingRegex.ReplaceAllString(s, "")
+	s = markdownBoldRegex.ReplaceAllString(s, "*$1*")
+	s = markdownLinkRegex.ReplaceAllString(s, "<$2|$1>")
+	return strings.TrimSpace(s)
+}
+
+// plainMarkdownTitle removes the markdown syntax from a notification title.
+func plainMarkdownTitle(title string) string {
+	title = htmlFontRegex.ReplaceAllString(title, "")
+	title = markdownHeadingRegex.ReplaceAllString(strings.TrimSpace(title), "")
+	return strings.TrimSpace(strings.ReplaceAll(title, "**", ""))
+}
+
+// markdownBody returns the content without the leading title.
+func markdownBody(title, content string) string {
+	return strings.TrimPrefix(content, title)
+}
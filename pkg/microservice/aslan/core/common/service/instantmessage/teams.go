@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instantmessage
+
+import (
+	"strings"
+)
+
+const (
+	teamsType             = "teams"
+	adaptiveCardType      = "application/vnd.microsoft.card.adaptive"
+	adaptiveCardSchema    = "http://adaptivecards.io/schemas/adaptive-card.json"
+	adaptiveCardVersion   = "1.4"
+	adaptiveCardTextBlock = "TextBlock"
+)
+
+type TeamsMessage struct {
+	Type        string             `json:"type"`
+	Attachments []*TeamsAttachment `json:"attachments"`
+}
+
+type TeamsAttachment struct {
+	ContentType string        `json:"contentType"`
+	Content     *AdaptiveCard `json:"content"`
+}
+
+type AdaptiveCard struct {
+	Schema  string               `json:"$schema"`
+	Type    string               `json:"type"`
+	Version string               `json:"version"`
+	Body    []*AdaptiveTextBlock `json:"body"`
+	MSTeams map[string]string    `json:"msteams,omitempty"`
+}
+
+type AdaptiveTextBlock struct {
+	Type    string `json:"type"`
+	Text    string `json:"text"`
+	Wrap    bool   `json:"wrap"`
+	Size    string `json:"size,omitempty"`
+	Weight  string `json:"weight,omitempty"`
+	Spacing string `json:"spacing,omitempty"`
+}
+
+func (w *Service) sendTeamsMessage(uri, title, content string) error {
+	card := &AdaptiveCard{
+		Schema:  adaptiveCardSchema,
+		Type:    "AdaptiveCard",
+		Version: adaptiveCardVersion,
+		Body: []*AdaptiveTextBlock{
+			{Type: adaptiveCardTextBlock, Text: plainMarkdownTitle(title), Wrap: true, Size: "Medium", Weight: "Bolder"},
+		},
+		MSTeams: map[string]string{"width": "Full"},
+	}
+
+	// adaptive cards render a subset of markdown, every line is put in its own block to keep the line breaks
+	body := markdownBody(title, content)
+	body = htmlFontRegex.ReplaceAllString(body, "")
+	body = markdownHeadingRegex.ReplaceAllString(body, "")
+	spacing := "Small"
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line == "---" {
+			spacing = "Medium"
+			continue
+		}
+		card.Body = append(card.Body, &AdaptiveTextBlock{Type: adaptiveCardTextBlock, Text: line, Wrap: true, Spacing: spacing})
+		spacing = "None"
+	}
+
+	message := &TeamsMessage{
+		Type: "message",
+		Attachments: []*TeamsAttachment{
+			{ContentType: adaptiveCardType, Content: card},
+		},
+	}
+	_, err := w.SendMessageRequest(uri, message)
+	return err
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instantmessage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/crypto"
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+const (
+	webhookType = "webhook"
+	// WebhookSignatureHeader carries the hex encoded HMAC-SHA256 of "<timestamp>.<body>", e.g. sha256=xxx,
+	// receivers should reject requests whose timestamp is too old.
+	WebhookSignatureHeader = "X-Zadig-Signature-256"
+	WebhookTimestampHeader = "X-Zadig-Timestamp"
+)
+
+// WebhookNotification is the payload of the generic webhook.
+type WebhookNotification struct {
+	Title   string               `json:"title"`
+	Content string               `json:"content"`
+	Task    *WebhookWorkflowTask `json:"task,omitempty"`
+}
+
+type WebhookWorkflowTask struct {
+	ProjectName         string `json:"project_name"`
+	WorkflowName        string `json:"workflow_name"`
+	WorkflowDisplayName string `json:"workflow_display_name"`
+	TaskID              int64  `json:"task_id"`
+	Status              string `json:"status"`
+	Creator             string `json:"creator"`
+	StartTime           int64  `json:"start_time"`
+	EndTime             int64  `json:"end_time"`
+	URL                 string `json:"url"`
+}
+
+func (w *Service) sendWebhookMessage(notify *models.NotifyCtl, title, content string, task *models.WorkflowTask) error {
+	message := &WebhookNotification{
+		Title:   plainMarkdownTitle(title),
+		Content: content,
+	}
+	if task != nil {
+		message.Task = &WebhookWorkflowTask{
+			ProjectName:         task.ProjectName,
+			WorkflowName:        task.WorkflowName,
+			WorkflowDisplayName: task.WorkflowDisplayName,
+			TaskID:              task.TaskID,
+			Status:              string(task.Status),
+			Creator:             task.TaskCreator,
+			StartTime:           task.StartTime,
+			EndTime:             task.EndTime,
+			URL: fmt.Sprintf("%s/v1/projects/detail/%s/pipelines/custom/%s/%d?display_name=%s",
+				configbase.SystemAddress(), task.ProjectName, task.WorkflowName, task.TaskID, url.PathEscape(task.WorkflowDisplayName)),
+		}
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	timestamp := fmt.Sprintf("%d", time.Now().Unix())
+	headers := map[string]string{
+		"Content-Type":         "application/json",
+		WebhookTimestampHeader: timestamp,
+	}
+	if notify.WebHookSecret != "" {
+		secret, err := crypto.AesDecrypt(notify.WebHookSecret)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt webhook secret: %s", err)
+		}
+		headers[WebhookSignatureHeader] = "sha256=" + SignWebhookPayload(secret, timestamp, body)
+	}
+
+	_, err = w.SendMessageRequest(notify.WebHookURL, body, httpclient.SetHeaders(headers))
+	return err
+}
+
+// SignWebhookPayload returns the hex encoded HMAC-SHA256 of "<timestamp>.<payload>", the timestamp is
+// signed as well so that a captured request can't be replayed with a fresh timestamp.
+func SignWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// EncryptWebhookSecrets encrypts the webhook secrets before they are saved, a masked secret is
+// replaced by the saved one of the webhook with the same url.
+func EncryptWebhookSecrets(notifies, existing []*models.NotifyCtl) error {
+	saved := make(map[string]string)
+	for _, notify := range existing {
+		if notify.WebHookType == webhookType && notify.WebHookSecret != "" {
+			saved[notify.WebHookURL] = notify.WebHookSecret
+		}
+	}
+	for _, notify := range notifies {
+		if notify.WebHookType != webhookType || notify.WebHookSecret == "" {
+			continue
+		}
+		if notify.WebHookSecret == setting.MaskValue {
+			secret, ok := saved[notify.WebHookURL]
+			if !ok {
+				return fmt.Errorf("webhook secret of %s is required", notify.WebHookURL)
+			}
+			notify.WebHookSecret = secret
+			continue
+		}
+		secret, err := crypto.AesEncrypt(notify.WebHookSecret)
+		if err != nil {
+			return err
+		}
+		notify.WebHookSecret = secret
+	}
+	return nil
+}
+
+// MaskWebhookSecrets hides the webhook secrets in responses.
+func MaskWebhookSecrets(notifies []*models.NotifyCtl) {
+	for _, notify := range notifies {
+		if notify.WebHookSecret != "" {
+			notify.WebHookSecret = setting.MaskValue
+		}
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instantmessage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	body := []byte(`{"title":"test"}`)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1700000000." + string(body)))
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if got := SignWebhookPayload("secret", "1700000000", body); got != expected {
+		t.Errorf("SignWebhookPayload() = %s, want %s", got, expected)
+	}
+	if SignWebhookPayload("secret", "1700000001", body) == expected {
+		t.Errorf("signature should change with the timestamp")
+	}
+	if SignWebhookPayload("other", "1700000000", body) == expected {
+		t.Errorf("signature should change with the secret")
+	}
+}
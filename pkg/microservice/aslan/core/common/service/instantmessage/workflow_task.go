@@ -34,7 +34,6 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/pkg/tool/log"
 	"github.com/koderover/zadig/pkg/types"
-	"github.com/koderover/zadig/pkg/types/step"
 )
 
 func (w *Service) SendWorkflowTaskAproveNotifications(workflowName string, taskID int64) error {
@@ -58,13 +57,19 @@ func (w *Service) SendWorkflowTaskAproveNotifications(workflowName string, taskI
 		if !notify.Enabled {
 			continue
 		}
-		title, content, larkCard, err := w.getApproveNotificationContent(notify, task)
+		title, content, larkCard, err := w.getCustomNotificationContent(notify, task, true)
+		if err != nil {
+			log.Warnf("failed to render notification template, use the default one, err: %s", err)
+		}
+		if notify.ContentTemplate == "" || err != nil {
+			title, content, larkCard, err = w.getApproveNotificationContent(notify, task)
+		}
 		if err != nil {
 			errMsg := fmt.Sprintf("failed to get notification content, err: %s", err)
 			log.Error(errMsg)
 			return errors.New(errMsg)
 		}
-		if err := w.sendNotification(title, content, notify, larkCard); err != nil {
+		if err := w.sendNotification(title, content, notify, larkCard, task); err != nil {
 			log.Errorf("failed to send notification, err: %s", err)
 		}
 	}
@@ -103,13 +108,19 @@ func (w *Service) SendWorkflowTaskNotifications(task *models.WorkflowTask) error
 		}
 		statusSets := sets.NewString(notify.NotifyTypes...)
 		if statusSets.Has(string(task.Status)) || (statusChanged && statusSets.Has(string(config.StatusChanged))) {
-			title, content, larkCard, err := w.getNotificationContent(notify, task)
+			title, content, larkCard, err := w.getCustomNotificationContent(notify, task, false)
+			if err != nil {
+				log.Warnf("failed to render notification template, use the default one, err: %s", err)
+			}
+			if notify.ContentTemplate == "" || err != nil {
+				title, content, larkCard, err = w.getNotificationContent(notify, task)
+			}
 			if err != nil {
 				errMsg := fmt.Sprintf("failed to get notification content, err: %s", err)
 				log.Error(errMsg)
 				return errors.New(errMsg)
 			}
-			if err := w.sendNotification(title, content, notify, larkCard); err != nil {
+			if err := w.sendNotification(title, content, notify, larkCard, task); err != nil {
 				log.Errorf("failed to send notification, err: %s", err)
 			}
 		}
@@ -186,14 +197,7 @@ func (w *Service) getNotificationContent(notify *models.NotifyCtl, task *models.
 			case string(config.JobFreestyle):
 				jobSpec := &models.JobTaskFreestyleSpec{}
 				models.IToi(job.Spec, jobSpec)
-				repos := []*types.Repository{}
-				for _, stepTask := range jobSpec.Steps {
-					if stepTask.StepType == config.StepGit {
-						stepSpec := &step.StepGitSpec{}
-						models.IToi(stepTask.Spec, stepSpec)
-						repos = stepSpec.Repos
-					}
-				}
+				repos := getJobTaskRepos(jobSpec)
 				branchTag, commitID, gitCommitURL := "", "", ""
 				commitMsgs := []string{}
 				var prInfoList []string
@@ -401,56 +405,7 @@ func getJobTaskTplExec(tplcontent string, args *jobTaskNotification) (string, er
 			}
 			return "执行失败"
 		},
-		"jobType": func(jobType string) string {
-			switch jobType {
-			case string(config.JobZadigBuild):
-				return "构建"
-			case string(config.JobZadigDeploy):
-				return "部署"
-			case string(config.JobZadigHelmDeploy):
-				return "helm部署"
-			case string(config.JobCustomDeploy):
-				return "自定义部署"
-			case string(config.JobFreestyle):
-				return "通用任务"
-			case string(config.JobPlugin):
-				return "自定义任务"
-			case string(config.JobZadigTesting):
-				return "测试"
-			case string(config.JobZadigScanning):
-				return "代码扫描"
-			case string(config.JobZadigDistributeImage):
-				return "镜像分发"
-			case string(config.JobK8sBlueGreenDeploy):
-				return "蓝绿部署"
-			case string(config.JobK8sBlueGreenRelease):
-				return "蓝绿发布"
-			case string(config.JobK8sCanaryDeploy):
-				return "金丝雀部署"
-			case string(config.JobK8sCanaryRelease):
-				return "金丝雀发布"
-			case string(config.JobK8sGrayRelease):
-				return "灰度发布"
-			case string(config.JobK8sGrayRollback):
-				return "灰度回滚"
-			case string(config.JobK8sPatch):
-				return "更新 k8s YAML"
-			case string(config.JobIstioRelease):
-				return "istio 发布"
-			case string(config.JobIstioRollback):
-				return "istio 回滚"
-			case string(config.JobJira):
-				return "jira 问题状态变更"
-			case string(config.JobNacos):
-				return "Nacos 配置变更"
-			case string(config.JobApollo):
-				return "Apollo 配置变更"
-			case string(config.JobMeegoTransition):
-				return "飞书工作项状态变更"
-			default:
-				return string(jobType)
-			}
-		},
+		"jobType": getJobTypeName,
 	}).Parse(tplcontent))
 
 	buffer := bytes.NewBufferString("")
@@ -462,7 +417,60 @@ func getJobTaskTplExec(tplcontent string, args *jobTaskNotification) (string, er
 	return buffer.String(), nil
 }
 
-func (w *Service) sendNotification(title, content string, notify *models.NotifyCtl, card *LarkCard) error {
+func getJobTypeName(jobType string) string {
+	switch jobType {
+	case string(config.JobZadigBuild):
+		return "构建"
+	case string(config.JobZadigDeploy):
+		return "部署"
+	case string(config.JobZadigHelmDeploy):
+		return "helm部署"
+	case string(config.JobCustomDeploy):
+		return "自定义部署"
+	case string(config.JobFreestyle):
+		return "通用任务"
+	case string(config.JobPlugin):
+		return "自定义任务"
+	case string(config.JobZadigTesting):
+		return "测试"
+	case string(config.JobZadigScanning):
+		return "代码扫描"
+	case string(config.JobZadigDistributeImage):
+		return "镜像分发"
+	case string(config.JobK8sBlueGreenDeploy):
+		return "蓝绿部署"
+	case string(config.JobK8sBlueGreenRelease):
+		return "蓝绿发布"
+	case string(config.JobK8sCanaryDeploy):
+		return "金丝雀部署"
+	case string(config.JobK8sCanaryRelease):
+		return "金丝雀发布"
+	case string(config.JobK8sGrayRelease):
+		return "灰度发布"
+	case string(config.JobK8sGrayRollback):
+		return "灰度回滚"
+	case string(config.JobK8sPatch):
+		return "更新 k8s YAML"
+	case string(config.JobIstioRelease):
+		return "istio 发布"
+	case string(config.JobIstioRollback):
+		return "istio 回滚"
+	case string(config.JobJira):
+		return "jira 问题状态变更"
+	case string(config.JobNacos):
+		return "Nacos 配置变更"
+	case string(config.JobApollo):
+		return "Apollo 配置变更"
+	case string(config.JobMeegoTransition):
+		return "飞书工作项状态变更"
+	default:
+		return string(jobType)
+	}
+}
+
+// sendNotification sends the notification through the channel of notify. title and content are in markdown,
+// card is used by feishu only and task is used by the generic webhook only, both of them can be nil.
+func (w *Service) sendNotification(title, content string, notify *models.NotifyCtl, card *LarkCard, task *models.WorkflowTask) error {
 	switch notify.WebHookType {
 	case dingDingType:
 		if err := w.sendDingDingMessage(notify.DingDingWebHook, title, content, notify.AtMobiles); err != nil {
@@ -475,6 +483,22 @@ func (w *Service) sendNotification(title, content string, notify *models.NotifyC
 		if err := w.sendFeishuMessageOfSingleType("", notify.FeiShuWebHook, getNotifyAtContent(notify)); err != nil {
 			return err
 		}
+	case slackType:
+		if err := w.sendSlackMessage(notify.SlackWebHook, title, content); err != nil {
+			return err
+		}
+	case teamsType:
+		if err := w.sendTeamsMessage(notify.TeamsWebHook, title, content); err != nil {
+			return err
+		}
+	case mailType:
+		if err := w.sendMailMessage(notify.MailReceivers, title, content); err != nil {
+			return err
+		}
+	case webhookType:
+		if err := w.sendWebhookMessage(notify, title, content, task); err != nil {
+			return err
+		}
 	default:
 		if err := w.SendWeChatWorkMessage(weChatTextTypeMarkdown, notify.WeChatWebHook, content); err != nil {
 			return err
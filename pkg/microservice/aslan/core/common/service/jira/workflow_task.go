@@ -0,0 +1,324 @@
+/*
+ * Copyright 2022 The KodeRover Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jira
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
+	githubtool "github.com/koderover/zadig/pkg/tool/git/github"
+	gitlabtool "github.com/koderover/zadig/pkg/tool/git/gitlab"
+	"github.com/koderover/zadig/pkg/tool/jira"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+var labelInvalidCharRegexp = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+type deployment struct {
+	env     string
+	service string
+	image   string
+	// previousImage is the image replaced by the deployment, it is empty if unknown
+	previousImage string
+}
+
+// SyncWorkflowTask creates jira issues for the failed jobs and posts the deployments to the issues mentioned
+// in the deployed commits, according to the jira integration of the workflow.
+func SyncWorkflowTask(task *commonmodels.WorkflowTask, logger *zap.SugaredLogger) error {
+	if task.WorkflowArgs == nil || task.WorkflowArgs.JiraIntegration == nil {
+		return nil
+	}
+	integration := task.WorkflowArgs.JiraIntegration
+	createIssue := integration.FailureIssue != nil && integration.FailureIssue.Enabled &&
+		(task.Status == config.StatusFailed || task.Status == config.StatusTimeout)
+	linkDeployment := integration.DeploymentLink != nil && integration.DeploymentLink.Enabled
+	if !createIssue && !linkDeployment {
+		return nil
+	}
+
+	info, err := commonrepo.NewProjectManagementColl().GetJira()
+	if err != nil {
+		return fmt.Errorf("failed to get jira info: %s", err)
+	}
+	client := jira.NewJiraClient(info.JiraUser, info.JiraToken, info.JiraHost)
+
+	if createIssue {
+		createFailureIssues(client, task, integration.FailureIssue, logger)
+	}
+	if linkDeployment {
+		linkDeployments(client, task, integration.DeploymentLink, logger)
+	}
+	return nil
+}
+
+func workflowTaskURL(task *commonmodels.WorkflowTask) string {
+	return fmt.Sprintf("%s/v1/projects/detail/%s/pipelines/custom/%s/%d?display_name=%s",
+		configbase.SystemAddress(), task.ProjectName, task.WorkflowName, task.TaskID, url.QueryEscape(task.WorkflowDisplayName))
+}
+
+func onProtectedBranch(task *commonmodels.WorkflowTask, branches []string, logger *zap.SugaredLogger) bool {
+	if len(branches) == 0 {
+		return true
+	}
+	for _, repo := range task.BuildRepos("") {
+		for _, branch := range branches {
+			matched, err := regexp.MatchString("^(?:"+branch+")$", repo.Branch)
+			if err != nil {
+				logger.Warnf("invalid protected branch %s: %s", branch, err)
+				continue
+			}
+			if matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func createFailureIssues(client *jira.Client, task *commonmodels.WorkflowTask, cfg *commonmodels.JiraFailureIssue, logger *zap.SugaredLogger) {
+	if !onProtectedBranch(task, cfg.ProtectedBranches, logger) {
+		return
+	}
+
+	taskURL := workflowTaskURL(task)
+	for _, stage := range task.Stages {
+		for _, job := range stage.Jobs {
+			if job.Status != config.StatusFailed && job.Status != config.StatusTimeout {
+				continue
+			}
+
+			// the label identifies the issue of the workflow and job, which is used to avoid duplicated issues
+			label := labelInvalidCharRegexp.ReplaceAllString(fmt.Sprintf("zadig-%s-%s", task.WorkflowName, job.Name), "-")
+			jql := fmt.Sprintf(`project = "%s" AND labels = "%s" AND statusCategory != Done`, cfg.ProjectKey, label)
+			issues, err := client.Issue.SearchByJQL(jql, false)
+			if err != nil {
+				logger.Errorf("failed to search jira issues by %s: %s", jql, err)
+				continue
+			}
+			if len(issues) > 0 {
+				comment := fmt.Sprintf("Job %s failed again in task #%d: %s\n%s", job.Name, task.TaskID, job.Error, taskURL)
+				if err := client.Issue.AddCommentV2(issues[0].Key, comment); err != nil {
+					logger.Errorf("failed to comment jira issue %s: %s", issues[0].Key, err)
+				}
+				continue
+			}
+
+			description := []string{
+				fmt.Sprintf("Workflow: %s", task.WorkflowDisplayName),
+				fmt.Sprintf("Task: #%d %s", task.TaskID, taskURL),
+				fmt.Sprintf("Job: %s", job.Name),
+				fmt.Sprintf("Status: %s", job.Status),
+				fmt.Sprintf("Triggered by: %s", task.TaskCreator),
+			}
+			for _, repo := range task.BuildRepos("") {
+				description = append(description, fmt.Sprintf("Repository: %s/%s branch %s commit %s", repo.RepoOwner, repo.RepoName, repo.Branch, repo.CommitID))
+			}
+			if job.Error != "" {
+				description = append(description, fmt.Sprintf("Error: %s", job.Error))
+			}
+
+			key, err := client.Issue.Create(&jira.CreateIssueArgs{
+				ProjectKey:  cfg.ProjectKey,
+				IssueType:   cfg.IssueType,
+				Summary:     fmt.Sprintf("[Zadig] %s: job %s failed", task.WorkflowDisplayName, job.Name),
+				Description: strings.Join(description, "\n"),
+				Labels:      []string{"zadig", label},
+				Assignee:    cfg.Assignee,
+			})
+			if err != nil {
+				logger.Errorf("failed to create jira issue for job %s of %s:%d: %s", job.Name, task.WorkflowName, task.TaskID, err)
+				continue
+			}
+			logger.Infof("jira issue %s created for job %s of %s:%d", key, job.Name, task.WorkflowName, task.TaskID)
+		}
+	}
+}
+
+func taskDeployments(task *commonmodels.WorkflowTask) []*deployment {
+	ret := make([]*deployment, 0)
+	for _, stage := range task.Stages {
+		for _, job := range stage.Jobs {
+			if job.Status != config.StatusPassed {
+				continue
+			}
+			switch job.JobType {
+			case string(config.JobZadigDeploy):
+				jobSpec := &commonmodels.JobTaskDeploySpec{}
+				if err := commonmodels.IToi(job.Spec, jobSpec); err != nil {
+					continue
+				}
+				deploy := &deployment{env: jobSpec.Env, service: jobSpec.ServiceName, image: jobSpec.Image}
+				for _, resource := range jobSpec.ReplaceResources {
+					if resource.Container == jobSpec.ServiceModule {
+						deploy.previousImage = resource.Origin
+						break
+					}
+				}
+				ret = append(ret, deploy)
+			case string(config.JobZadigHelmDeploy):
+				jobSpec := &commonmodels.JobTaskHelmDeploySpec{}
+				if err := commonmodels.IToi(job.Spec, jobSpec); err != nil {
+					continue
+				}
+				for _, module := range jobSpec.ImageAndModules {
+					ret = append(ret, &deployment{env: jobSpec.Env, service: jobSpec.ServiceName, image: module.Image})
+				}
+			}
+		}
+	}
+	return ret
+}
+
+func linkDeployments(client *jira.Client, task *commonmodels.WorkflowTask, cfg *commonmodels.JiraDeploymentLink, logger *zap.SugaredLogger) {
+	deployments := taskDeployments(task)
+	if len(deployments) == 0 {
+		return
+	}
+
+	// issue key => env => deployments
+	issueDeployments := make(map[string]map[string][]*deployment)
+	var issueKeys []string
+	codehosts := make(map[int]*systemconfig.CodeHost)
+	for _, deploy := range deployments {
+		if deploy.image == "" {
+			continue
+		}
+		buildTask, err := commonrepo.NewworkflowTaskv4Coll().FindLatestBuildTaskByImage(task.ProjectName, deploy.image)
+		if err != nil {
+			logger.Infof("no build task found for image %s: %s", deploy.image, err)
+			continue
+		}
+		previousRepos := make(map[string]*types.Repository)
+		if deploy.previousImage != "" && deploy.previousImage != deploy.image {
+			if previousTask, err := commonrepo.NewworkflowTaskv4Coll().FindLatestBuildTaskByImage(task.ProjectName, deploy.previousImage); err == nil {
+				for _, repo := range previousTask.BuildRepos(deploy.previousImage) {
+					previousRepos[repoKey(repo)] = repo
+				}
+			}
+		}
+
+		deployKeys := make(map[string]bool)
+		for _, repo := range buildTask.BuildRepos(deploy.image) {
+			for _, message := range deployedCommitMessages(codehosts, repo, previousRepos[repoKey(repo)], logger) {
+				for _, key := range jira.FindIssueKeys(message) {
+					if deployKeys[key] {
+						continue
+					}
+					deployKeys[key] = true
+					if _, ok := issueDeployments[key]; !ok {
+						issueDeployments[key] = make(map[string][]*deployment)
+						issueKeys = append(issueKeys, key)
+					}
+					issueDeployments[key][deploy.env] = append(issueDeployments[key][deploy.env], deploy)
+				}
+			}
+		}
+	}
+
+	taskURL := workflowTaskURL(task)
+	for _, key := range issueKeys {
+		for env, deploys := range issueDeployments[key] {
+			versions := make([]string, 0, len(deploys))
+			for _, deploy := range deploys {
+				versions = append(versions, fmt.Sprintf("%s: %s", deploy.service, deploy.image))
+			}
+			title := fmt.Sprintf("Deployed to %s by %s #%d", env, task.WorkflowDisplayName, task.TaskID)
+
+			var err error
+			if cfg.Mode == config.JiraLinkModeRemoteLink {
+				globalID := fmt.Sprintf("zadig-%s-%d-%s", task.WorkflowName, task.TaskID, env)
+				err = client.Issue.AddRemoteLink(key, globalID, taskURL, title, strings.Join(versions, ", "))
+			} else {
+				err = client.Issue.AddCommentV2(key, fmt.Sprintf("%s\n%s\n%s", title, strings.Join(versions, "\n"), taskURL))
+			}
+			if err != nil {
+				logger.Warnf("failed to post deployment of %s:%d to jira issue %s: %s", task.WorkflowName, task.TaskID, key, err)
+			}
+		}
+	}
+}
+
+func repoKey(repo *types.Repository) string {
+	return fmt.Sprintf("%d/%s/%s", repo.CodehostID, repo.RepoOwner, repo.RepoName)
+}
+
+// deployedCommitMessages returns the messages of the commits between the previously deployed build and the current one,
+// only the message of the head commit is returned if the previous build is unknown or the code host can not be queried.
+func deployedCommitMessages(codehosts map[int]*systemconfig.CodeHost, repo, previous *types.Repository, logger *zap.SugaredLogger) []string {
+	headMessage := []string{repo.CommitMessage}
+	if previous == nil || previous.CommitID == "" || repo.CommitID == "" {
+		return headMessage
+	}
+	if previous.CommitID == repo.CommitID {
+		return nil
+	}
+
+	ch, ok := codehosts[repo.CodehostID]
+	if !ok {
+		var err error
+		ch, err = systemconfig.New().GetCodeHost(repo.CodehostID)
+		if err != nil {
+			logger.Warnf("failed to get codehost %d: %s", repo.CodehostID, err)
+		}
+		codehosts[repo.CodehostID] = ch
+	}
+	if ch == nil {
+		return headMessage
+	}
+
+	messages := make([]string, 0)
+	switch strings.ToLower(ch.Type) {
+	case setting.SourceFromGithub:
+		client := githubtool.NewClient(&githubtool.Config{AccessToken: ch.AccessToken, Proxy: config.ProxyHTTPSAddr()})
+		commits, err := client.CompareCommits(context.TODO(), repo.RepoOwner, repo.RepoName, previous.CommitID, repo.CommitID)
+		if err != nil {
+			logger.Warnf("failed to compare commits of %s/%s: %s", repo.RepoOwner, repo.RepoName, err)
+			return headMessage
+		}
+		for _, commit := range commits {
+			messages = append(messages, commit.GetCommit().GetMessage())
+		}
+	case setting.SourceFromGitlab:
+		client, err := gitlabtool.NewClient(ch.ID, ch.Address, ch.AccessToken, config.ProxyHTTPSAddr(), ch.EnableProxy)
+		if err != nil {
+			logger.Warnf("failed to create gitlab client: %s", err)
+			return headMessage
+		}
+		commits, err := client.CompareCommits(repo.RepoOwner, repo.RepoName, previous.CommitID, repo.CommitID)
+		if err != nil {
+			logger.Warnf("failed to compare commits of %s/%s: %s", repo.RepoOwner, repo.RepoName, err)
+			return headMessage
+		}
+		for _, commit := range commits {
+			messages = append(messages, commit.Message)
+		}
+	default:
+		return headMessage
+	}
+	return messages
+}
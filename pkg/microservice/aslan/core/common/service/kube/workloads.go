@@ -20,6 +20,8 @@ import (
 	"fmt"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	"k8s.io/apimachinery/pkg/labels"
 	crClient "sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -54,29 +56,12 @@ func FetchRelatedWorkloads(namespace, serviceName string,
 		return deployments, statefulSets, nil
 	}
 
-	productService := productInfo.GetServiceMap()[serviceName]
-	if productService == nil {
-		return nil, nil, nil
-	}
-	opt := &commonrepo.RenderSetFindOption{
-		Name:        productInfo.Render.Name,
-		Revision:    productInfo.Render.Revision,
-		EnvName:     productInfo.EnvName,
-		ProductTmpl: productInfo.ProductName,
-	}
-	renderset, exists, err := commonrepo.NewRenderSetColl().FindRenderSet(opt)
-	if err != nil || !exists {
-		log.Errorf("failed to find renderset for env: %s, err: %v", productInfo.EnvName, err)
-		return nil, nil, fmt.Errorf("failed to find renderset for env: %s/%s, err: %v", productInfo.ProductName, productInfo.EnvName, err)
-	}
-	rederedYaml, err := RenderEnvService(productInfo, renderset, productService)
+	manifests, err := renderRelatedManifests(serviceName, productInfo)
 	if err != nil {
-		log.Errorf("failed to render service yaml, err: %s", err)
-		return nil, nil, fmt.Errorf("failed to render service yaml, err: %s", err)
+		return nil, nil, err
 	}
 
 	deploys, stss := make([]*appsv1.Deployment, 0), make([]*appsv1.StatefulSet, 0)
-	manifests := util.SplitManifests(rederedYaml)
 	for _, item := range manifests {
 		u, err := serializer.NewDecoder().YamlToUnstructured([]byte(item))
 		if err != nil {
@@ -98,3 +83,102 @@ func FetchRelatedWorkloads(namespace, serviceName string,
 	}
 	return deploys, stss, nil
 }
+
+// FetchRelatedDaemonSets returns the DaemonSets of the service in the same way as FetchRelatedWorkloads.
+func FetchRelatedDaemonSets(namespace, serviceName string, productInfo *commonmodels.Product, kubeclient crClient.Client) ([]*appsv1.DaemonSet, error) {
+	selector := labels.Set{setting.ProductLabel: productInfo.ProductName, setting.ServiceLabel: serviceName}.AsSelector()
+	daemonSets, err := getter.ListDaemonsets(namespace, selector, kubeclient)
+	if err != nil {
+		return nil, err
+	}
+	if len(daemonSets) > 0 {
+		return daemonSets, nil
+	}
+
+	manifests, err := renderRelatedManifests(serviceName, productInfo)
+	if err != nil {
+		return nil, err
+	}
+	dss := make([]*appsv1.DaemonSet, 0)
+	for _, item := range manifests {
+		u, err := serializer.NewDecoder().YamlToUnstructured([]byte(item))
+		if err != nil || u.GetKind() != setting.DaemonSet {
+			continue
+		}
+		ds, exists, err := getter.GetDaemonSet(namespace, u.GetName(), kubeclient)
+		if exists && err == nil {
+			dss = append(dss, ds)
+		}
+	}
+	return dss, nil
+}
+
+// FetchRelatedCronJobs returns the CronJobs of the service in the same way as FetchRelatedWorkloads.
+// Only the batch/v1beta1 CronJobs are returned if the cluster is older than 1.21, otherwise only the batch/v1 ones.
+func FetchRelatedCronJobs(namespace, serviceName string, productInfo *commonmodels.Product, kubeclient crClient.Client, versionLessThan121 bool) ([]*batchv1.CronJob, []*batchv1beta1.CronJob, error) {
+	selector := labels.Set{setting.ProductLabel: productInfo.ProductName, setting.ServiceLabel: serviceName}.AsSelector()
+	var (
+		cronJobs     []*batchv1.CronJob
+		betaCronJobs []*batchv1beta1.CronJob
+		err          error
+	)
+	if versionLessThan121 {
+		betaCronJobs, err = getter.ListCronJobsV1Beta(namespace, selector, kubeclient)
+	} else {
+		cronJobs, err = getter.ListCronJobs(namespace, selector, kubeclient)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(cronJobs) > 0 || len(betaCronJobs) > 0 {
+		return cronJobs, betaCronJobs, nil
+	}
+
+	manifests, err := renderRelatedManifests(serviceName, productInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, item := range manifests {
+		u, err := serializer.NewDecoder().YamlToUnstructured([]byte(item))
+		if err != nil || u.GetKind() != setting.CronJob {
+			continue
+		}
+		if versionLessThan121 {
+			cj, exists, err := getter.GetCronJobV1Beta(namespace, u.GetName(), kubeclient)
+			if exists && err == nil {
+				betaCronJobs = append(betaCronJobs, cj)
+			}
+		} else {
+			cj, exists, err := getter.GetCronJob(namespace, u.GetName(), kubeclient)
+			if exists && err == nil {
+				cronJobs = append(cronJobs, cj)
+			}
+		}
+	}
+	return cronJobs, betaCronJobs, nil
+}
+
+// renderRelatedManifests renders the yaml of the service in the environment and splits it into manifests.
+func renderRelatedManifests(serviceName string, productInfo *commonmodels.Product) ([]string, error) {
+	productService := productInfo.GetServiceMap()[serviceName]
+	if productService == nil {
+		return nil, nil
+	}
+	opt := &commonrepo.RenderSetFindOption{
+		Name:        productInfo.Render.Name,
+		Revision:    productInfo.Render.Revision,
+		EnvName:     productInfo.EnvName,
+		ProductTmpl: productInfo.ProductName,
+	}
+	renderset, exists, err := commonrepo.NewRenderSetColl().FindRenderSet(opt)
+	if err != nil || !exists {
+		log.Errorf("failed to find renderset for env: %s, err: %v", productInfo.EnvName, err)
+		return nil, fmt.Errorf("failed to find renderset for env: %s/%s, err: %v", productInfo.ProductName, productInfo.EnvName, err)
+	}
+	rederedYaml, err := RenderEnvService(productInfo, renderset, productService)
+	if err != nil {
+		log.Errorf("failed to render service yaml, err: %s", err)
+		return nil, fmt.Errorf("failed to render service yaml, err: %s", err)
+	}
+	return util.SplitManifests(rederedYaml), nil
+}
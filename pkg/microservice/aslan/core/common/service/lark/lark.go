@@ -286,10 +286,44 @@ type EventHandlerResponse struct {
 	Challenge string `json:"challenge"`
 }
 
-func EventHandler(appID, sign, ts, nonce, body string) (*EventHandlerResponse, error) {
+// EventTypeMessageReceive is the event type of the messages sent to the bot, it uses the 2.0 event schema.
+const EventTypeMessageReceive = "im.message.receive_v1"
+
+// MessageEvent is a text message sent to the bot of the im app, in a p2p chat or by mentioning the bot in a group.
+type MessageEvent struct {
+	IMAppID   string
+	OpenID    string
+	MessageID string
+	ChatID    string
+	Text      string
+}
+
+type messageReceiveData struct {
+	Header struct {
+		EventID   string `json:"event_id"`
+		EventType string `json:"event_type"`
+	} `json:"header"`
+	Event struct {
+		Sender struct {
+			SenderID struct {
+				OpenID string `json:"open_id"`
+			} `json:"sender_id"`
+		} `json:"sender"`
+		Message struct {
+			MessageID   string `json:"message_id"`
+			ChatID      string `json:"chat_id"`
+			MessageType string `json:"message_type"`
+			Content     string `json:"content"`
+		} `json:"message"`
+	} `json:"event"`
+}
+
+// EventHandler handles the events of the lark app, the approval events are consumed here and the text messages
+// sent to the bot are returned to the caller.
+func EventHandler(appID, sign, ts, nonce, body string) (*EventHandlerResponse, *MessageEvent, error) {
 	approval, err := mongodb.NewIMAppColl().GetByAppID(context.Background(), appID)
 	if err != nil {
-		return nil, errors.Wrap(err, "get approval by appID")
+		return nil, nil, errors.Wrap(err, "get approval by appID")
 	}
 	key := approval.EncryptKey
 	approvalID := approval.ID.Hex()
@@ -297,38 +331,66 @@ func EventHandler(appID, sign, ts, nonce, body string) (*EventHandlerResponse, e
 
 	raw, err := larkDecrypt(gjson.Get(body, "encrypt").String(), key)
 	if err != nil {
-		return nil, errors.Wrap(err, "decrypt body")
+		return nil, nil, errors.Wrap(err, "decrypt body")
 	}
 
 	// handle lark open platform webhook URL check request, which only need reply the challenge field.
 	if sign == "" {
-		return &EventHandlerResponse{Challenge: gjson.Get(raw, "challenge").String()}, nil
+		return &EventHandlerResponse{Challenge: gjson.Get(raw, "challenge").String()}, nil, nil
 	}
 
 	if sign != larkCalculateSignature(ts, nonce, key, body) {
-		return nil, errors.New("check sign failed")
+		return nil, nil, errors.New("check sign failed")
+	}
+
+	if gjson.Get(raw, "header.event_type").String() == EventTypeMessageReceive {
+		return nil, parseMessageEvent(approvalID, raw), nil
 	}
 
 	callback := &CallbackData{}
 	err = json.Unmarshal([]byte(raw), callback)
 	if err != nil {
 		log.Errorf("unmarshal callback data failed: %v", err)
-		return nil, errors.Wrap(err, "unmarshal")
+		return nil, nil, errors.Wrap(err, "unmarshal")
 	}
 
 	if callback.Event.Type != "approval_instance" {
 		log.Infof("get unknown callback event type %s, ignored", callback.Event.Type)
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	manager := GetLarkApprovalManager(approvalID)
 	if !manager.CheckAndUpdateUUID(callback.UUID) {
 		log.Infof("check existed request uuid %s, ignored", callback.UUID)
-		return nil, nil
+		return nil, nil, nil
 	}
 	manager.UpdateInstanceStatus(callback.Event.InstanceCode, callback.Event.Status)
 	log.Infof("update approval id: %s, instance code: %s, status: %s", approvalID, callback.Event.InstanceCode, callback.Event.Status)
-	return nil, nil
+	return nil, nil, nil
+}
+
+func parseMessageEvent(approvalID, raw string) *MessageEvent {
+	data := &messageReceiveData{}
+	if err := json.Unmarshal([]byte(raw), data); err != nil {
+		log.Errorf("unmarshal message event failed: %v", err)
+		return nil
+	}
+	if data.Event.Message.MessageType != "text" {
+		log.Infof("get message type %s, ignored", data.Event.Message.MessageType)
+		return nil
+	}
+	// lark retries the event if it is not replied in 3 seconds, so the same event may be received more than once
+	if !GetLarkApprovalManager(approvalID).CheckAndUpdateUUID(data.Header.EventID) {
+		log.Infof("check existed event id %s, ignored", data.Header.EventID)
+		return nil
+	}
+	return &MessageEvent{
+		IMAppID:   approvalID,
+		OpenID:    data.Event.Sender.SenderID.OpenID,
+		MessageID: data.Event.Message.MessageID,
+		ChatID:    data.Event.Message.ChatID,
+		Text:      gjson.Get(data.Event.Message.Content, "text").String(),
+	}
 }
 
 func larkDecrypt(encrypt string, key string) (string, error) {
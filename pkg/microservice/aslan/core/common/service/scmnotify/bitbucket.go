@@ -0,0 +1,67 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scmnotify
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/bitbucket"
+	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
+	bitbuckettool "github.com/koderover/zadig/pkg/tool/bitbucket"
+)
+
+// setBitbucketBuildStatus reports the workflow task as a build status of the commit which triggered it,
+// both pushes and pull requests are reported since Bitbucket shows build statuses on commits.
+func setBitbucketBuildStatus(ch *systemconfig.CodeHost, workflowArgs *models.WorkflowV4, taskID int64, state bitbuckettool.BuildState, log *zap.SugaredLogger) error {
+	hook := workflowArgs.HookPayload
+	cli, err := bitbucket.NewClient(ch.ID, ch.Address, ch.Username, ch.AccessToken, config.ProxyHTTPSAddr(), ch.EnableProxy)
+	if err != nil {
+		log.Errorf("Failed to create bitbucket client, err: %s", err)
+		return err
+	}
+
+	displayName := getDisplayName(workflowArgs)
+	status := &bitbuckettool.BuildStatus{
+		Key:         getCommitStatusName(workflowArgs),
+		State:       state,
+		Name:        fmt.Sprintf("%s #%d", displayName, taskID),
+		URL:         getWorkflowV4TaskURL(workflowArgs, taskID),
+		Description: fmt.Sprintf("Workflow %s is %s", displayName, state),
+	}
+	if err := cli.SetBuildStatus(hook.Owner, hook.Repo, hook.CommitID, status); err != nil {
+		log.Errorf("Failed to set bitbucket build status of %s/%s@%s, err: %s", hook.Owner, hook.Repo, hook.CommitID, err)
+		return err
+	}
+	return nil
+}
+
+func getBitbucketBuildState(status config.Status) bitbuckettool.BuildState {
+	switch status {
+	case config.StatusCreated, config.StatusRunning:
+		return bitbuckettool.BuildStateInProgress
+	case config.StatusPassed:
+		return bitbuckettool.BuildStateSuccessful
+	case config.StatusCancelled, config.StatusSkipped, config.StatusReject:
+		return bitbuckettool.BuildStateStopped
+	default:
+		return bitbuckettool.BuildStateFailed
+	}
+}
@@ -30,7 +30,10 @@ import (
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/bitbucket"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/gitea"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/gitee"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/github"
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
 	"github.com/koderover/zadig/pkg/tool/gerrit"
@@ -46,7 +49,7 @@ func NewClient() *Client {
 	return &Client{logger: log.SugaredLogger()}
 }
 
-// Comment send comment to gitlab and set comment id in notify
+// Comment send comment to gitlab, gerrit, gitee, bitbucket, gitea or github and set comment id in notify
 func (c *Client) Comment(notify *models.Notification) error {
 	if notify.PrID == 0 {
 		return fmt.Errorf("non pr notification not supported yet")
@@ -187,6 +190,69 @@ func (c *Client) Comment(notify *models.Notification) error {
 		if err != nil {
 			return fmt.Errorf("failed to comment gitee due to %s/%d %v", notify.ProjectID, notify.PrID, err)
 		}
+	} else if strings.ToLower(codeHostDetail.Type) == setting.SourceFromBitbucket {
+		cli, err := bitbucket.NewClient(codeHostDetail.ID, codeHostDetail.Address, codeHostDetail.Username, codeHostDetail.AccessToken, config.ProxyHTTPSAddr(), codeHostDetail.EnableProxy)
+		if err != nil {
+			return fmt.Errorf("create bitbucket client failed err: %v", err)
+		}
+		if notify.CommentID == "" {
+			// create comment
+			commentID, err := cli.CreatePullRequestComment(notify.RepoOwner, notify.RepoName, notify.PrID, comment)
+			if err != nil {
+				return fmt.Errorf("failed to comment bitbucket due to %s/%s/%d %v", notify.RepoOwner, notify.RepoName, notify.PrID, err)
+			}
+			notify.CommentID = strconv.Itoa(commentID)
+		} else {
+			// update comment
+			commentID, err := strconv.Atoi(notify.CommentID)
+			if err != nil {
+				return fmt.Errorf("failed to atoi commentID %v,err: %s", notify.CommentID, err)
+			}
+			if err = cli.UpdatePullRequestComment(notify.RepoOwner, notify.RepoName, notify.PrID, commentID, comment); err != nil {
+				return fmt.Errorf("failed to comment bitbucket due to %s/%s/%d %v", notify.RepoOwner, notify.RepoName, notify.PrID, err)
+			}
+		}
+	} else if strings.ToLower(codeHostDetail.Type) == setting.SourceFromGitea {
+		cli, err := gitea.NewClient(codeHostDetail.ID, codeHostDetail.Address, codeHostDetail.AccessToken, config.ProxyHTTPSAddr(), codeHostDetail.EnableProxy)
+		if err != nil {
+			return fmt.Errorf("create gitea client failed err: %v", err)
+		}
+		if notify.CommentID == "" {
+			// create comment
+			issueComment, err := cli.CreateIssueComment(notify.RepoOwner, notify.RepoName, notify.PrID, comment)
+			if err != nil {
+				return fmt.Errorf("failed to comment gitea due to %s/%s/%d %v", notify.RepoOwner, notify.RepoName, notify.PrID, err)
+			}
+			notify.CommentID = strconv.FormatInt(issueComment.ID, 10)
+		} else {
+			// update comment
+			commentID, err := strconv.ParseInt(notify.CommentID, 10, 64)
+			if err != nil {
+				return fmt.Errorf("failed to parse commentID %v,err: %s", notify.CommentID, err)
+			}
+			if err = cli.EditIssueComment(notify.RepoOwner, notify.RepoName, commentID, comment); err != nil {
+				return fmt.Errorf("failed to comment gitea due to %s/%s/%d %v", notify.RepoOwner, notify.RepoName, notify.PrID, err)
+			}
+		}
+	} else if strings.ToLower(codeHostDetail.Type) == setting.SourceFromGithub {
+		cli := github.NewClient(codeHostDetail.AccessToken, config.ProxyHTTPSAddr(), codeHostDetail.EnableProxy)
+		if notify.CommentID == "" {
+			// create comment
+			issueComment, err := cli.CreateIssueComment(context.Background(), notify.RepoOwner, notify.RepoName, notify.PrID, comment)
+			if err != nil {
+				return fmt.Errorf("failed to comment github due to %s/%d %v", notify.ProjectID, notify.PrID, err)
+			}
+			notify.CommentID = strconv.FormatInt(issueComment.GetID(), 10)
+		} else {
+			// update comment
+			commentID, err := strconv.ParseInt(notify.CommentID, 10, 64)
+			if err != nil {
+				return fmt.Errorf("failed to parse commentID %v,err: %s", notify.CommentID, err)
+			}
+			if _, err = cli.EditIssueComment(context.Background(), notify.RepoOwner, notify.RepoName, commentID, comment); err != nil {
+				return fmt.Errorf("failed to comment github due to %s/%d %v", notify.ProjectID, notify.PrID, err)
+			}
+		}
 	} else {
 		return fmt.Errorf("non gitlab source not supported to comment")
 	}
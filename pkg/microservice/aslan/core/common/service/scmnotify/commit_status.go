@@ -0,0 +1,221 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scmnotify
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/xanzy/go-gitlab"
+	"go.uber.org/zap"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/gitee"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
+	"github.com/koderover/zadig/pkg/tool/gerrit"
+	gitlabtool "github.com/koderover/zadig/pkg/tool/git/gitlab"
+	giteetool "github.com/koderover/zadig/pkg/tool/gitee"
+)
+
+// getCommitStatusCodeHost returns the codehost of the hook if workflow tasks are reported to it as commit statuses.
+// Bitbucket and gitea always report, gitlab, gitee and gerrit report when it is enabled in the hook.
+func getCommitStatusCodeHost(hook *models.HookPayload) (*systemconfig.CodeHost, bool) {
+	if hook.CodehostID == 0 || hook.CommitID == "" {
+		return nil, false
+	}
+	ch, err := systemconfig.New().GetCodeHost(hook.CodehostID)
+	if err != nil {
+		return nil, false
+	}
+	if !commitStatusEnabled(ch.Type, hook) {
+		return nil, false
+	}
+	return ch, true
+}
+
+func commitStatusEnabled(codehostType string, hook *models.HookPayload) bool {
+	switch codehostType {
+	case setting.SourceFromBitbucket, setting.SourceFromGitea:
+		return true
+	case setting.SourceFromGitlab, setting.SourceFromGitee, setting.SourceFromGiteeEE, setting.SourceFromGerrit:
+		return hook.CommitStatus != nil && hook.CommitStatus.Enabled
+	default:
+		return false
+	}
+}
+
+// setCommitStatus reports the status of a workflow task to the commit which triggered it.
+func setCommitStatus(ch *systemconfig.CodeHost, workflowArgs *models.WorkflowV4, taskID int64, status config.Status, log *zap.SugaredLogger) error {
+	switch ch.Type {
+	case setting.SourceFromBitbucket:
+		return setBitbucketBuildStatus(ch, workflowArgs, taskID, getBitbucketBuildState(status), log)
+	case setting.SourceFromGitea:
+		return setGiteaCommitStatus(ch, workflowArgs, taskID, getGiteaStatusState(status), log)
+	case setting.SourceFromGitlab:
+		return setGitlabCommitStatus(ch, workflowArgs, taskID, status, log)
+	case setting.SourceFromGitee, setting.SourceFromGiteeEE:
+		return setGiteeCheckRun(ch, workflowArgs, taskID, status, log)
+	case setting.SourceFromGerrit:
+		return setGerritVote(ch, workflowArgs, taskID, status, log)
+	}
+	return nil
+}
+
+func getCommitStatusName(workflowArgs *models.WorkflowV4) string {
+	if cs := workflowArgs.HookPayload.CommitStatus; cs != nil && cs.Name != "" {
+		return cs.Name
+	}
+	return workflowArgs.Name
+}
+
+func getWorkflowV4TaskURL(workflowArgs *models.WorkflowV4, taskID int64) string {
+	return fmt.Sprintf("%s/v1/projects/detail/%s/pipelines/custom/%s/%d?display_name=%s",
+		configbase.SystemAddress(), workflowArgs.Project, workflowArgs.Name, taskID, url.QueryEscape(getDisplayName(workflowArgs)))
+}
+
+func setGitlabCommitStatus(ch *systemconfig.CodeHost, workflowArgs *models.WorkflowV4, taskID int64, status config.Status, log *zap.SugaredLogger) error {
+	hook := workflowArgs.HookPayload
+	cli, err := gitlabtool.NewClient(ch.ID, ch.Address, ch.AccessToken, config.ProxyHTTPSAddr(), ch.EnableProxy)
+	if err != nil {
+		log.Errorf("Failed to create gitlab client, err: %s", err)
+		return err
+	}
+
+	opts := &gitlab.SetCommitStatusOptions{
+		State:       getGitlabBuildState(status),
+		Name:        gitlab.String(getCommitStatusName(workflowArgs)),
+		TargetURL:   gitlab.String(getWorkflowV4TaskURL(workflowArgs, taskID)),
+		Description: gitlab.String(fmt.Sprintf("Workflow %s #%d is %s", getDisplayName(workflowArgs), taskID, status)),
+	}
+	if !hook.IsPr && hook.Branch != "" {
+		opts.Ref = gitlab.String(hook.Branch)
+	}
+	if err := cli.SetCommitStatus(hook.Owner, hook.Repo, hook.CommitID, opts); err != nil {
+		// gitlab refuses to set a status to the state it is already in, which happens since running is reported more than once
+		if strings.Contains(err.Error(), "Cannot transition status") {
+			return nil
+		}
+		log.Errorf("Failed to set gitlab commit status of %s/%s@%s, err: %s", hook.Owner, hook.Repo, hook.CommitID, err)
+		return err
+	}
+	return nil
+}
+
+func getGitlabBuildState(status config.Status) gitlab.BuildStateValue {
+	switch status {
+	case config.StatusCreated:
+		return gitlab.Pending
+	case config.StatusRunning:
+		return gitlab.Running
+	case config.StatusPassed:
+		return gitlab.Success
+	case config.StatusCancelled, config.StatusSkipped, config.StatusReject:
+		return gitlab.Canceled
+	default:
+		return gitlab.Failed
+	}
+}
+
+// setGiteeCheckRun reports the workflow task as a check run, the check run of the same name on the commit
+// is updated so that re-running a workflow does not leave stale results behind.
+func setGiteeCheckRun(ch *systemconfig.CodeHost, workflowArgs *models.WorkflowV4, taskID int64, status config.Status, log *zap.SugaredLogger) error {
+	hook := workflowArgs.HookPayload
+	cli := gitee.NewClient(ch.ID, ch.AccessToken, config.ProxyHTTPSAddr(), ch.EnableProxy, ch.Address)
+
+	name := getCommitStatusName(workflowArgs)
+	opts := &giteetool.CheckRunOptions{
+		Name:       name,
+		HeadSha:    hook.CommitID,
+		DetailsURL: getWorkflowV4TaskURL(workflowArgs, taskID),
+		Output: &giteetool.CheckRunOutput{
+			Title:   fmt.Sprintf("%s #%d", getDisplayName(workflowArgs), taskID),
+			Summary: fmt.Sprintf("Workflow %s #%d is %s", getDisplayName(workflowArgs), taskID, status),
+		},
+	}
+	opts.Status, opts.Conclusion = getGiteeCheckRunState(status)
+
+	checkRuns, err := cli.ListCheckRunsForRef(ch.Address, ch.AccessToken, hook.Owner, hook.Repo, hook.CommitID, name)
+	if err != nil {
+		log.Errorf("Failed to list gitee check runs of %s/%s@%s, err: %s", hook.Owner, hook.Repo, hook.CommitID, err)
+		return err
+	}
+	for _, checkRun := range checkRuns {
+		if checkRun.Name == name {
+			if err := cli.UpdateCheckRun(ch.Address, ch.AccessToken, hook.Owner, hook.Repo, checkRun.ID, opts); err != nil {
+				log.Errorf("Failed to update gitee check run %d of %s/%s, err: %s", checkRun.ID, hook.Owner, hook.Repo, err)
+				return err
+			}
+			return nil
+		}
+	}
+	if _, err := cli.CreateCheckRun(ch.Address, ch.AccessToken, hook.Owner, hook.Repo, opts); err != nil {
+		log.Errorf("Failed to create gitee check run of %s/%s@%s, err: %s", hook.Owner, hook.Repo, hook.CommitID, err)
+		return err
+	}
+	return nil
+}
+
+func getGiteeCheckRunState(status config.Status) (string, string) {
+	switch status {
+	case config.StatusCreated:
+		return giteetool.CheckRunStatusQueued, ""
+	case config.StatusRunning:
+		return giteetool.CheckRunStatusInProgress, ""
+	case config.StatusPassed:
+		return giteetool.CheckRunStatusCompleted, giteetool.CheckRunConclusionSuccess
+	case config.StatusTimeout:
+		return giteetool.CheckRunStatusCompleted, giteetool.CheckRunConclusionTimedOut
+	case config.StatusCancelled, config.StatusSkipped, config.StatusReject:
+		return giteetool.CheckRunStatusCompleted, giteetool.CheckRunConclusionCancelled
+	default:
+		return giteetool.CheckRunStatusCompleted, giteetool.CheckRunConclusionFailure
+	}
+}
+
+// setGerritVote votes the configured label of the change when the task is completed, the running state is
+// already shown by the webhook comment.
+func setGerritVote(ch *systemconfig.CodeHost, workflowArgs *models.WorkflowV4, taskID int64, status config.Status, log *zap.SugaredLogger) error {
+	var score string
+	switch status {
+	case config.StatusCreated, config.StatusRunning:
+		return nil
+	case config.StatusPassed:
+		score = "+1"
+	case config.StatusCancelled, config.StatusSkipped, config.StatusReject:
+		score = "0"
+	default:
+		score = "-1"
+	}
+
+	hook := workflowArgs.HookPayload
+	changeID, err := strconv.Atoi(hook.MergeRequestID)
+	if err != nil {
+		return fmt.Errorf("invalid gerrit change number %s: %v", hook.MergeRequestID, err)
+	}
+	cli := gerrit.NewClient(ch.Address, ch.AccessToken, config.ProxyHTTPSAddr(), ch.EnableProxy)
+	message := fmt.Sprintf("%s #%d %s %s", getCommitStatusName(workflowArgs), taskID, strings.ToUpper(string(status)), getWorkflowV4TaskURL(workflowArgs, taskID))
+	if err := cli.SetReview(hook.Repo, changeID, message, hook.CommitStatus.GetGerritLabel(), score, hook.CommitID); err != nil {
+		log.Errorf("Failed to vote gerrit change %s/%d, err: %s", hook.Repo, changeID, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scmnotify
+
+import (
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/setting"
+	giteetool "github.com/koderover/zadig/pkg/tool/gitee"
+)
+
+func TestGetGitlabBuildState(t *testing.T) {
+	tests := []struct {
+		status config.Status
+		want   gitlab.BuildStateValue
+	}{
+		{status: config.StatusCreated, want: gitlab.Pending},
+		{status: config.StatusRunning, want: gitlab.Running},
+		{status: config.StatusPassed, want: gitlab.Success},
+		{status: config.StatusCancelled, want: gitlab.Canceled},
+		{status: config.StatusSkipped, want: gitlab.Canceled},
+		{status: config.StatusReject, want: gitlab.Canceled},
+		{status: config.StatusFailed, want: gitlab.Failed},
+		{status: config.StatusTimeout, want: gitlab.Failed},
+	}
+	for _, tt := range tests {
+		if got := getGitlabBuildState(tt.status); got != tt.want {
+			t.Errorf("getGitlabBuildState(%s) = %s, want %s", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestGetGiteeCheckRunState(t *testing.T) {
+	tests := []struct {
+		status         config.Status
+		wantStatus     string
+		wantConclusion string
+	}{
+		{status: config.StatusCreated, wantStatus: giteetool.CheckRunStatusQueued},
+		{status: config.StatusRunning, wantStatus: giteetool.CheckRunStatusInProgress},
+		{status: config.StatusPassed, wantStatus: giteetool.CheckRunStatusCompleted, wantConclusion: giteetool.CheckRunConclusionSuccess},
+		{status: config.StatusTimeout, wantStatus: giteetool.CheckRunStatusCompleted, wantConclusion: giteetool.CheckRunConclusionTimedOut},
+		{status: config.StatusCancelled, wantStatus: giteetool.CheckRunStatusCompleted, wantConclusion: giteetool.CheckRunConclusionCancelled},
+		{status: config.StatusReject, wantStatus: giteetool.CheckRunStatusCompleted, wantConclusion: giteetool.CheckRunConclusionCancelled},
+		{status: config.StatusFailed, wantStatus: giteetool.CheckRunStatusCompleted, wantConclusion: giteetool.CheckRunConclusionFailure},
+	}
+	for _, tt := range tests {
+		status, conclusion := getGiteeCheckRunState(tt.status)
+		if status != tt.wantStatus || conclusion != tt.wantConclusion {
+			t.Errorf("getGiteeCheckRunState(%s) = (%s, %s), want (%s, %s)", tt.status, status, conclusion, tt.wantStatus, tt.wantConclusion)
+		}
+	}
+}
+
+func TestCommitStatusEnabled(t *testing.T) {
+	enabled := &models.HookPayload{CommitStatus: &models.CommitStatusConfig{Enabled: true}}
+	disabled := &models.HookPayload{CommitStatus: &models.CommitStatusConfig{}}
+	unset := &models.HookPayload{}
+
+	tests := []struct {
+		name         string
+		codehostType string
+		hook         *models.HookPayload
+		want         bool
+	}{
+		{name: "bitbucket always reports", codehostType: setting.SourceFromBitbucket, hook: unset, want: true},
+		{name: "gitea always reports", codehostType: setting.SourceFromGitea, hook: disabled, want: true},
+		{name: "gitlab enabled", codehostType: setting.SourceFromGitlab, hook: enabled, want: true},
+		{name: "gitlab disabled", codehostType: setting.SourceFromGitlab, hook: disabled},
+		{name: "gitlab unset", codehostType: setting.SourceFromGitlab, hook: unset},
+		{name: "gitee enabled", codehostType: setting.SourceFromGitee, hook: enabled, want: true},
+		{name: "gitee enterprise enabled", codehostType: setting.SourceFromGiteeEE, hook: enabled, want: true},
+		{name: "gitee disabled", codehostType: setting.SourceFromGitee, hook: disabled},
+		{name: "gerrit enabled", codehostType: setting.SourceFromGerrit, hook: enabled, want: true},
+		{name: "gerrit unset", codehostType: setting.SourceFromGerrit, hook: unset},
+		{name: "github is not supported", codehostType: setting.SourceFromGithub, hook: enabled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commitStatusEnabled(tt.codehostType, tt.hook); got != tt.want {
+				t.Errorf("commitStatusEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCommitStatusCodeHostWithoutCommit(t *testing.T) {
+	for _, hook := range []*models.HookPayload{
+		{CommitID: "abc", CommitStatus: &models.CommitStatusConfig{Enabled: true}},
+		{CodehostID: 1, CommitStatus: &models.CommitStatusConfig{Enabled: true}},
+	} {
+		if _, ok := getCommitStatusCodeHost(hook); ok {
+			t.Errorf("getCommitStatusCodeHost(%+v) should not report without a codehost and a commit", hook)
+		}
+	}
+}
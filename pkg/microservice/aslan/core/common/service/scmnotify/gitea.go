@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scmnotify
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/gitea"
+	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
+	giteatool "github.com/koderover/zadig/pkg/tool/gitea"
+)
+
+// setGiteaCommitStatus reports the workflow task as a commit status, the status name is used as the
+// context so that each workflow keeps a single status on the commit.
+func setGiteaCommitStatus(ch *systemconfig.CodeHost, workflowArgs *models.WorkflowV4, taskID int64, state giteatool.StatusState, log *zap.SugaredLogger) error {
+	hook := workflowArgs.HookPayload
+	cli, err := gitea.NewClient(ch.ID, ch.Address, ch.AccessToken, config.ProxyHTTPSAddr(), ch.EnableProxy)
+	if err != nil {
+		log.Errorf("Failed to create gitea client, err: %s", err)
+		return err
+	}
+
+	displayName := getDisplayName(workflowArgs)
+	status := &giteatool.CommitStatus{
+		State:       state,
+		TargetURL:   getWorkflowV4TaskURL(workflowArgs, taskID),
+		Description: fmt.Sprintf("Workflow %s #%d is %s", displayName, taskID, state),
+		Context:     getCommitStatusName(workflowArgs),
+	}
+	if err := cli.CreateCommitStatus(hook.Owner, hook.Repo, hook.CommitID, status); err != nil {
+		log.Errorf("Failed to set gitea commit status of %s/%s@%s, err: %s", hook.Owner, hook.Repo, hook.CommitID, err)
+		return err
+	}
+	return nil
+}
+
+func getGiteaStatusState(status config.Status) giteatool.StatusState {
+	switch status {
+	case config.StatusCreated, config.StatusRunning:
+		return giteatool.StatusPending
+	case config.StatusPassed:
+		return giteatool.StatusSuccess
+	case config.StatusCancelled, config.StatusSkipped, config.StatusReject:
+		return giteatool.StatusWarning
+	default:
+		return giteatool.StatusFailure
+	}
+}
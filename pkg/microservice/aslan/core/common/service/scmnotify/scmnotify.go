@@ -36,6 +36,7 @@ import (
 	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
 	e "github.com/koderover/zadig/pkg/tool/errors"
 	s3tool "github.com/koderover/zadig/pkg/tool/s3"
+	"github.com/koderover/zadig/pkg/types"
 	"github.com/koderover/zadig/pkg/util"
 )
 
@@ -121,6 +122,31 @@ func (s *Service) SendErrWebhookComment(
 	return notification, nil
 }
 
+// SendPreviewEnvComment comments the routing info of the preview environment to the pull request
+func (s *Service) SendPreviewEnvComment(
+	repo *types.Repository, baseURI string, previewEnv *models.PreviewEnvInfo, logger *zap.SugaredLogger,
+) error {
+	notification := &models.Notification{
+		CodehostID: repo.CodehostID,
+		PrID:       repo.PR,
+		ProjectID:  strings.TrimLeft(repo.RepoNamespace+"/"+repo.RepoName, "/"),
+		BaseURI:    baseURI,
+		PreviewEnv: previewEnv,
+		Revision:   repo.CommitID,
+		RepoOwner:  repo.RepoOwner,
+		RepoName:   repo.RepoName,
+	}
+
+	if err := s.Client.Comment(notification); err != nil {
+		logger.Errorf("failed to comment to %s %v", notification.ToString(), err)
+		return err
+	} else if err := s.Coll.Create(notification); err != nil {
+		logger.Errorf("failed to save %s %v", notification.ToString(), err)
+		return err
+	}
+	return nil
+}
+
 func convertTaskStatusToNotificationTaskStatus(status config.Status) config.TaskStatus {
 	switch status {
 	case config.StatusWaiting:
@@ -654,7 +680,13 @@ func (s *Service) UpdateEnvAndTaskWebhookComment(workflowArgs *models.WorkflowTa
 func (s *Service) CreateGitCheckForWorkflowV4(workflowArgs *models.WorkflowV4, taskID int64, log *zap.SugaredLogger) error {
 	hook := workflowArgs.HookPayload
 
-	if hook == nil || !hook.IsPr {
+	if hook == nil {
+		return nil
+	}
+	if ch, ok := getCommitStatusCodeHost(hook); ok {
+		return setCommitStatus(ch, workflowArgs, taskID, config.StatusCreated, log)
+	}
+	if !hook.IsPr {
 		return nil
 	}
 
@@ -713,7 +745,13 @@ func (s *Service) CreateGitCheckForWorkflowV4(workflowArgs *models.WorkflowV4, t
 func (s *Service) UpdateGitCheckForWorkflowV4(workflowArgs *models.WorkflowV4, taskID int64, log *zap.SugaredLogger) error {
 	hook := workflowArgs.HookPayload
 
-	if hook == nil || !hook.IsPr {
+	if hook == nil {
+		return nil
+	}
+	if ch, ok := getCommitStatusCodeHost(hook); ok {
+		return setCommitStatus(ch, workflowArgs, taskID, config.StatusRunning, log)
+	}
+	if !hook.IsPr {
 		return nil
 	}
 
@@ -773,7 +811,13 @@ func (s *Service) UpdateGitCheckForWorkflowV4(workflowArgs *models.WorkflowV4, t
 func (s *Service) CompleteGitCheckForWorkflowV4(workflowArgs *models.WorkflowV4, taskID int64, status config.Status, log *zap.SugaredLogger) error {
 	hook := workflowArgs.HookPayload
 
-	if hook == nil || !hook.IsPr {
+	if hook == nil {
+		return nil
+	}
+	if ch, ok := getCommitStatusCodeHost(hook); ok {
+		return setCommitStatus(ch, workflowArgs, taskID, status, log)
+	}
+	if !hook.IsPr {
 		return nil
 	}
 
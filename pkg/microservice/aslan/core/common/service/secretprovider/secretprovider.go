@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretprovider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/setting"
+	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
+	"github.com/koderover/zadig/pkg/tool/crypto"
+	"github.com/koderover/zadig/pkg/tool/secret"
+)
+
+// GetProvider builds the configured secret provider, nil is returned if no provider is configured.
+func GetProvider() (secret.Provider, error) {
+	conf, err := commonrepo.NewSecretProviderColl().Get()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret provider: %s", err)
+	}
+	if conf == nil {
+		return nil, nil
+	}
+	return NewProvider(conf)
+}
+
+// NewProvider builds the secret provider from the config, the vault token in the config is encrypted.
+func NewProvider(conf *models.SecretProvider) (secret.Provider, error) {
+	switch conf.Type {
+	case models.SecretProviderVault:
+		if conf.Vault == nil {
+			return nil, fmt.Errorf("vault config is missing")
+		}
+		token, err := crypto.AesDecrypt(conf.Vault.Token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt vault token: %s", err)
+		}
+		return secret.NewVaultProvider(conf.Vault.Address, token, conf.Vault.Mount, conf.Vault.Namespace), nil
+	case models.SecretProviderKubernetes:
+		if conf.Kubernetes == nil {
+			return nil, fmt.Errorf("kubernetes config is missing")
+		}
+		clusterID := conf.Kubernetes.ClusterID
+		if clusterID == "" {
+			clusterID = setting.LocalClusterID
+		}
+		clientset, err := kubeclient.GetClientset(config.HubServerAddress(), clusterID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get kube client of cluster %s: %s", clusterID, err)
+		}
+		return secret.NewKubernetesProvider(clientset, conf.Kubernetes.Namespace), nil
+	default:
+		return nil, fmt.Errorf("unsupported secret provider type %s", conf.Type)
+	}
+}
+
+// ResolveKeyVals returns a copy of the key values with all secret references resolved by the provider,
+// the resolved ones are marked as credentials so that they are masked in the job logs.
+// Only the secrets of the project can be resolved. The provider is built from the config lazily if p is nil.
+func ResolveKeyVals(ctx context.Context, p secret.Provider, project string, kvs []*models.KeyVal) ([]*models.KeyVal, error) {
+	resp := make([]*models.KeyVal, 0, len(kvs))
+	for _, kv := range kvs {
+		if kv == nil {
+			continue
+		}
+		item := *kv
+		if secret.IsReference(kv.Value) {
+			if p == nil {
+				var err error
+				if p, err = GetProvider(); err != nil {
+					return nil, err
+				}
+			}
+			value, err := secret.Resolve(ctx, p, project, kv.Value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve %s: %s", kv.Key, err)
+			}
+			item.Value = value
+			item.IsCredential = true
+		}
+		resp = append(resp, &item)
+	}
+	return resp, nil
+}
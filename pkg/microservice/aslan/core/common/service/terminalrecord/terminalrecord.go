@@ -0,0 +1,179 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terminalrecord
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	s3service "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/s3"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/client/policy"
+	"github.com/koderover/zadig/pkg/tool/asciicast"
+	"github.com/koderover/zadig/pkg/tool/log"
+	s3tool "github.com/koderover/zadig/pkg/tool/s3"
+)
+
+// VerbExecProduction is the environment verb required to open a terminal into a production environment
+// when RestrictProductionExec is turned on.
+const VerbExecProduction = "exec_production"
+
+var ErrProductionExecForbidden = errors.New("opening a terminal into a production environment requires the exec_production permission")
+
+// CheckExecPermission returns ErrProductionExecForbidden if the user is not allowed to open a terminal into env.
+func CheckExecPermission(uid string, env *models.Product) error {
+	if !env.Production {
+		return nil
+	}
+
+	systemSetting, err := commonrepo.NewSystemSettingColl().Get()
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		return err
+	}
+	if !systemSetting.RestrictProductionExec {
+		return nil
+	}
+
+	rules, err := policy.NewDefault().GetUserRulesByProject(uid, env.ProductName)
+	if err != nil {
+		return err
+	}
+	if rules.IsSystemAdmin || rules.IsProjectAdmin {
+		return nil
+	}
+	for _, verb := range rules.ProjectVerbs {
+		if verb == "production:"+VerbExecProduction {
+			return nil
+		}
+	}
+	for _, verb := range rules.EnvironmentVerbsMap[env.EnvName] {
+		if verb == VerbExecProduction {
+			return nil
+		}
+	}
+	return ErrProductionExecForbidden
+}
+
+// Recording records a terminal session into a local asciicast file, the file is uploaded to the
+// default object storage when the session is closed.
+type Recording struct {
+	*asciicast.Writer
+
+	session *models.TerminalSession
+	file    *os.File
+	once    sync.Once
+}
+
+// Start indexes the session in mongo and starts recording it.
+func Start(session *models.TerminalSession, cols, rows int) (*Recording, error) {
+	file, err := ioutil.TempFile("", "terminal-*.cast")
+	if err != nil {
+		return nil, err
+	}
+
+	session.Status = models.TerminalSessionStatusActive
+	session.StartTime = time.Now().Unix()
+	writer, err := asciicast.NewWriter(file, &asciicast.Header{
+		Width:     cols,
+		Height:    rows,
+		Timestamp: session.StartTime,
+		Title:     title(session),
+		Env:       map[string]string{"TERM": "xterm"},
+	})
+	if err == nil {
+		err = commonrepo.NewTerminalSessionColl().Create(session)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	return &Recording{Writer: writer, session: session, file: file}, nil
+}
+
+// Close uploads the recording and marks the session as finished, it is safe to call it more than once.
+func (r *Recording) Close() {
+	r.once.Do(func() {
+		r.session.EndTime = time.Now().Unix()
+		r.session.Status = models.TerminalSessionStatusFinished
+		if err := r.upload(); err != nil {
+			log.Errorf("failed to upload recording of terminal session %s: %s", r.session.ID.Hex(), err)
+			r.session.Status = models.TerminalSessionStatusFailed
+			r.session.Error = err.Error()
+		}
+		os.Remove(r.file.Name())
+
+		if err := commonrepo.NewTerminalSessionColl().Finish(r.session); err != nil {
+			log.Errorf("failed to update terminal session %s: %s", r.session.ID.Hex(), err)
+		}
+	})
+}
+
+func (r *Recording) upload() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	if err := r.Err(); err != nil {
+		return fmt.Errorf("failed to write recording: %s", err)
+	}
+	info, err := os.Stat(r.file.Name())
+	if err != nil {
+		return err
+	}
+	r.session.Size = info.Size()
+
+	storage, err := s3service.FindDefaultS3()
+	if err != nil {
+		return fmt.Errorf("failed to find default object storage: %s", err)
+	}
+	forcedPathStyle := true
+	if storage.Provider == setting.ProviderSourceAli {
+		forcedPathStyle = false
+	}
+	client, err := s3tool.NewClient(storage.Endpoint, storage.Ak, storage.Sk, storage.Region, storage.Insecure, forcedPathStyle)
+	if err != nil {
+		return err
+	}
+
+	key := storage.GetObjectPath(path.Join("terminal-sessions", time.Unix(r.session.StartTime, 0).UTC().Format("2006/01/02"), r.session.ID.Hex()+".cast"))
+	if err := client.Upload(storage.Bucket, r.file.Name(), key); err != nil {
+		return err
+	}
+	r.session.StorageID = storage.ID.Hex()
+	r.session.ObjectKey = key
+	return nil
+}
+
+func title(session *models.TerminalSession) string {
+	if session.Type == models.TerminalSessionTypeHost {
+		return fmt.Sprintf("%s@%s %s/%s", session.UserName, session.HostIP, session.ProjectName, session.EnvName)
+	}
+	return fmt.Sprintf("%s@%s/%s %s/%s", session.UserName, session.PodName, session.ContainerName, session.ProjectName, session.EnvName)
+}
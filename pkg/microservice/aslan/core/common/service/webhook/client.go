@@ -46,6 +46,7 @@ func NewClient() *client {
 type task struct {
 	ID                                                          int
 	owner, namespace, repo, address, token, ref, ak, sk, region string
+	username                                                    string
 	from                                                        string
 	add, enableProxy                                            bool
 	err                                                         error
@@ -60,6 +61,7 @@ type TaskOption struct {
 	Repo        string
 	Address     string
 	Token       string
+	Username    string
 	Ref         string
 	From        string
 	AK          string
@@ -80,6 +82,7 @@ func (c *client) AddWebHook(taskOption *TaskOption) error {
 		repo:        taskOption.Repo,
 		address:     taskOption.Address,
 		token:       taskOption.Token,
+		username:    taskOption.Username,
 		ref:         getFullReference(taskOption.Name, taskOption.Ref),
 		from:        taskOption.From,
 		add:         true,
@@ -117,6 +120,7 @@ func (c *client) RemoveWebHook(taskOption *TaskOption) error {
 		repo:        taskOption.Repo,
 		address:     taskOption.Address,
 		token:       taskOption.Token,
+		username:    taskOption.Username,
 		ref:         getFullReference(taskOption.Name, taskOption.Ref),
 		from:        taskOption.From,
 		add:         false,
@@ -27,7 +27,9 @@ import (
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/bitbucket"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/codehub"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/gitea"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/gitee"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/github"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/gitlab"
@@ -169,6 +171,20 @@ func removeWebhook(t *task, logger *zap.Logger) {
 		cl = codehub.NewClient(t.ak, t.sk, t.region, config.ProxyHTTPSAddr(), t.enableProxy)
 	case setting.SourceFromGitee, setting.SourceFromGiteeEE:
 		cl = gitee.NewClient(t.ID, t.token, config.ProxyHTTPSAddr(), t.enableProxy, t.address)
+	case setting.SourceFromBitbucket:
+		cl, err = bitbucket.NewClient(t.ID, t.address, t.username, t.token, config.ProxyHTTPSAddr(), t.enableProxy)
+		if err != nil {
+			t.err = err
+			t.doneCh <- struct{}{}
+			return
+		}
+	case setting.SourceFromGitea:
+		cl, err = gitea.NewClient(t.ID, t.address, t.token, config.ProxyHTTPSAddr(), t.enableProxy)
+		if err != nil {
+			t.err = err
+			t.doneCh <- struct{}{}
+			return
+		}
 	default:
 		t.err = fmt.Errorf("invaild source: %s", t.from)
 		t.doneCh <- struct{}{}
@@ -248,6 +264,20 @@ func addWebhook(t *task, logger *zap.Logger) {
 		cl = codehub.NewClient(t.ak, t.sk, t.region, config.ProxyHTTPSAddr(), t.enableProxy)
 	case setting.SourceFromGitee, setting.SourceFromGiteeEE:
 		cl = gitee.NewClient(t.ID, t.token, config.ProxyHTTPSAddr(), t.enableProxy, t.address)
+	case setting.SourceFromBitbucket:
+		cl, err = bitbucket.NewClient(t.ID, t.address, t.username, t.token, config.ProxyHTTPSAddr(), t.enableProxy)
+		if err != nil {
+			t.err = err
+			t.doneCh <- struct{}{}
+			return
+		}
+	case setting.SourceFromGitea:
+		cl, err = gitea.NewClient(t.ID, t.address, t.token, config.ProxyHTTPSAddr(), t.enableProxy)
+		if err != nil {
+			t.err = err
+			t.doneCh <- struct{}{}
+			return
+		}
 	default:
 		t.err = fmt.Errorf("invaild source: %s", t.from)
 		t.doneCh <- struct{}{}
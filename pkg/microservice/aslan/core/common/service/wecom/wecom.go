@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wecom
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/wecom"
+)
+
+// GetWeComClientByIMAppID returns the client and the im app of the given id.
+func GetWeComClientByIMAppID(id string) (*wecom.Client, *models.IMApp, error) {
+	app, err := mongodb.NewIMAppColl().GetByID(context.Background(), id)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "get external approval data")
+	}
+	if app.Type != setting.IMWeCom {
+		return nil, nil, errors.Errorf("unexpected approval type %s", app.Type)
+	}
+	return wecom.NewClient(app.WeComCorpID, app.WeComSecret), app, nil
+}
+
+// GetUserID looks up the wecom user by the mobile first and then by the email.
+func GetUserID(client *wecom.Client, mobile, email string) (string, error) {
+	err := errors.New("neither mobile nor email is set")
+	if mobile != "" {
+		var id string
+		if id, err = client.GetUserIDByMobile(mobile); err == nil {
+			return id, nil
+		}
+	}
+	if email != "" {
+		var id string
+		if id, err = client.GetUserIDByEmail(email); err == nil {
+			return id, nil
+		}
+	}
+	return "", errors.Wrapf(err, "find wecom user by mobile %q or email %q", mobile, email)
+}
@@ -216,7 +216,7 @@ func ProcessWebhook(updatedHooks, currentHooks interface{}, name string, logger
 			}
 
 			switch ch.Type {
-			case setting.SourceFromGithub, setting.SourceFromGitlab, setting.SourceFromCodeHub, setting.SourceFromGitee, setting.SourceFromGiteeEE:
+			case setting.SourceFromGithub, setting.SourceFromGitlab, setting.SourceFromCodeHub, setting.SourceFromGitee, setting.SourceFromGiteeEE, setting.SourceFromBitbucket, setting.SourceFromGitea:
 				err = webhook.NewClient().RemoveWebHook(&webhook.TaskOption{
 					ID:          ch.ID,
 					Name:        wh.name,
@@ -225,6 +225,7 @@ func ProcessWebhook(updatedHooks, currentHooks interface{}, name string, logger
 					Repo:        wh.repo,
 					Address:     ch.Address,
 					Token:       ch.AccessToken,
+					Username:    ch.Username,
 					AK:          ch.AccessKey,
 					SK:          ch.SecretKey,
 					Region:      ch.Region,
@@ -253,7 +254,7 @@ func ProcessWebhook(updatedHooks, currentHooks interface{}, name string, logger
 			}
 
 			switch ch.Type {
-			case setting.SourceFromGithub, setting.SourceFromGitlab, setting.SourceFromCodeHub, setting.SourceFromGitee, setting.SourceFromGiteeEE:
+			case setting.SourceFromGithub, setting.SourceFromGitlab, setting.SourceFromCodeHub, setting.SourceFromGitee, setting.SourceFromGiteeEE, setting.SourceFromBitbucket, setting.SourceFromGitea:
 				err = webhook.NewClient().AddWebHook(&webhook.TaskOption{
 					ID:        ch.ID,
 					Name:      wh.name,
@@ -262,6 +263,7 @@ func ProcessWebhook(updatedHooks, currentHooks interface{}, name string, logger
 					Repo:      wh.repo,
 					Address:   ch.Address,
 					Token:     ch.AccessToken,
+					Username:  ch.Username,
 					Ref:       name,
 					AK:        ch.AccessKey,
 					SK:        ch.SecretKey,
@@ -0,0 +1,355 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflowcontroller
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	dingtalkservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/dingtalk"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/instantmessage"
+	wecomservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/wecom"
+	"github.com/koderover/zadig/pkg/shared/client/user"
+	"github.com/koderover/zadig/pkg/tool/dingtalk"
+	"github.com/koderover/zadig/pkg/tool/log"
+	"github.com/koderover/zadig/pkg/tool/wecom"
+)
+
+// dingtalk and wecom approvals are polled since their callbacks can not reach zadig in many private deployments
+const imApprovalPollInterval = 10 * time.Second
+
+func getApprovalFormContent(stage *commonmodels.StageTask, workflowCtx *commonmodels.WorkflowTaskCtx) string {
+	detailURL := fmt.Sprintf("%s/v1/projects/detail/%s/pipelines/custom/%s/%d?display_name=%s",
+		configbase.SystemAddress(),
+		workflowCtx.ProjectName,
+		workflowCtx.WorkflowName,
+		workflowCtx.TaskID,
+		url.QueryEscape(workflowCtx.WorkflowDisplayName),
+	)
+	descForm := ""
+	if stage.Approval.Description != "" {
+		descForm = fmt.Sprintf("\n描述: %s", stage.Approval.Description)
+	}
+	return fmt.Sprintf("项目名称: %s\n工作流名称: %s\n阶段名称: %s%s\n\n更多详见: %s",
+		workflowCtx.ProjectName, workflowCtx.WorkflowDisplayName, stage.Name, descForm, detailURL)
+}
+
+// getApproveUsersInIM maps the zadig approvers to the users of the im app, the key of the result is the user id in the im app.
+func getApproveUsersInIM(approveUsers []*commonmodels.User, getUserID func(mobile, email string) (string, error)) (map[string]*commonmodels.User, error) {
+	var uids []string
+	for _, u := range approveUsers {
+		uids = append(uids, u.UserID)
+	}
+	users, err := user.New().ListUsers(&user.SearchArgs{UIDs: uids})
+	if err != nil {
+		return nil, errors.Wrap(err, "list approve users")
+	}
+	userInfos := make(map[string]*user.User, len(users))
+	for _, u := range users {
+		userInfos[u.UID] = u
+	}
+
+	resp := make(map[string]*commonmodels.User, len(approveUsers))
+	for _, u := range approveUsers {
+		info, ok := userInfos[u.UserID]
+		if !ok {
+			return nil, errors.Errorf("approve user %s not found", u.UserName)
+		}
+		id, err := getUserID(info.Phone, info.Email)
+		if err != nil {
+			return nil, errors.Wrapf(err, "find approve user %s", u.UserName)
+		}
+		resp[id] = u
+	}
+	return resp, nil
+}
+
+func waitForDingTalkApprove(ctx context.Context, stage *commonmodels.StageTask, workflowCtx *commonmodels.WorkflowTaskCtx, logger *zap.SugaredLogger, ack func()) error {
+	log.Infof("waitForDingTalkApprove start")
+	approval := stage.Approval.DingTalkApproval
+	if approval == nil {
+		stage.Status = config.StatusFailed
+		return errors.New("waitForApprove: dingtalk approval data not found")
+	}
+	if approval.Timeout == 0 {
+		approval.Timeout = 60
+	}
+
+	client, app, err := dingtalkservice.GetDingTalkClientByIMAppID(approval.ApprovalID)
+	if err != nil {
+		stage.Status = config.StatusFailed
+		return errors.Wrap(err, "get dingtalk client")
+	}
+	originatorID, err := client.GetUserIDByMobile(workflowCtx.WorkflowTaskCreatorMobile)
+	if err != nil {
+		stage.Status = config.StatusFailed
+		return errors.Wrapf(err, "get user dingtalk id by mobile-%s", workflowCtx.WorkflowTaskCreatorMobile)
+	}
+	approveUsers, err := getApproveUsersInIM(approval.ApproveUsers, func(mobile, _ string) (string, error) {
+		return client.GetUserIDByMobile(mobile)
+	})
+	if err != nil {
+		stage.Status = config.StatusFailed
+		return err
+	}
+
+	var approverIDs []string
+	for id := range approveUsers {
+		approverIDs = append(approverIDs, id)
+	}
+	instance, err := client.CreateApprovalInstance(&dingtalk.CreateApprovalInstanceArgs{
+		ProcessCode:      app.DingTalkDefaultApprovalFormCode,
+		OriginatorUserID: originatorID,
+		Approvers: []*dingtalk.Approver{{
+			ActionType: dingtalk.ActionTypeOr,
+			UserIDs:    approverIDs,
+		}},
+		FormComponentValues: []*dingtalk.FormComponentValue{{
+			Name:  dingtalk.DefaultFormComponentName,
+			Value: getApprovalFormContent(stage, workflowCtx),
+		}},
+	})
+	if err != nil {
+		log.Errorf("waitForDingTalkApprove: create instance failed: %v", err)
+		stage.Status = config.StatusFailed
+		return errors.Wrap(err, "create approval instance")
+	}
+	log.Infof("waitForDingTalkApprove: create instance success, id %s", instance)
+	approval.InstanceCode = instance
+	ack()
+
+	if err := instantmessage.NewWeChatClient().SendWorkflowTaskAproveNotifications(workflowCtx.WorkflowName, workflowCtx.TaskID); err != nil {
+		logger.Errorf("send approve notification failed, error: %v", err)
+	}
+
+	cancelApproval := func() {
+		if err := client.TerminateApprovalInstance(instance, "workflow task is canceled"); err != nil {
+			log.Errorf("terminate dingtalk approval %s error: %v", instance, err)
+		}
+	}
+	timeout := time.After(time.Duration(approval.Timeout) * time.Minute)
+	ticker := time.NewTicker(imApprovalPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			stage.Status = config.StatusCancelled
+			cancelApproval()
+			return fmt.Errorf("workflow was canceled")
+		case <-timeout:
+			stage.Status = config.StatusCancelled
+			cancelApproval()
+			return fmt.Errorf("workflow timeout")
+		case <-ticker.C:
+			info, err := client.GetApprovalInstance(instance)
+			if err != nil {
+				log.Errorf("waitForDingTalkApprove: get instance %s error: %v", instance, err)
+				continue
+			}
+			if updateDingTalkApprovers(approveUsers, info.OperationRecords) {
+				ack()
+			}
+			if done, err := checkDingTalkApproval(stage, info); done {
+				return err
+			}
+		}
+	}
+}
+
+// updateDingTalkApprovers records the results of the approvers, it returns true if any approver is updated.
+func updateDingTalkApprovers(approveUsers map[string]*commonmodels.User, records []*dingtalk.OperationRecord) bool {
+	updated := false
+	for _, record := range records {
+		u, ok := approveUsers[record.UserID]
+		if !ok || u.RejectOrApprove != "" {
+			continue
+		}
+		switch record.Result {
+		case dingtalk.OperationResultAgree:
+			u.RejectOrApprove = config.Approve
+		case dingtalk.OperationResultRefuse:
+			u.RejectOrApprove = config.Reject
+		default:
+			continue
+		}
+		u.Comment = record.Remark
+		u.OperationTime = time.Now().Unix()
+		if t, err := time.Parse("2006-01-02T15:04Z", record.Date); err == nil {
+			u.OperationTime = t.Unix()
+		}
+		updated = true
+	}
+	return updated
+}
+
+// checkDingTalkApproval returns true if the approval is finished, the stage status is set if it is not approved.
+func checkDingTalkApproval(stage *commonmodels.StageTask, info *dingtalk.ApprovalInstance) (bool, error) {
+	switch info.Status {
+	case dingtalk.ApprovalStatusCompleted:
+		if info.Result == dingtalk.ApprovalResultAgree {
+			return true, nil
+		}
+		stage.Status = config.StatusReject
+		return true, errors.New("Approval has been rejected")
+	case dingtalk.ApprovalStatusTerminated, dingtalk.ApprovalStatusCanceled:
+		stage.Status = config.StatusFailed
+		return true, errors.New("Approval has been canceled")
+	}
+	return false, nil
+}
+
+func waitForWeComApprove(ctx context.Context, stage *commonmodels.StageTask, workflowCtx *commonmodels.WorkflowTaskCtx, logger *zap.SugaredLogger, ack func()) error {
+	log.Infof("waitForWeComApprove start")
+	approval := stage.Approval.WeComApproval
+	if approval == nil {
+		stage.Status = config.StatusFailed
+		return errors.New("waitForApprove: wecom approval data not found")
+	}
+	if approval.Timeout == 0 {
+		approval.Timeout = 60
+	}
+
+	client, app, err := wecomservice.GetWeComClientByIMAppID(approval.ApprovalID)
+	if err != nil {
+		stage.Status = config.StatusFailed
+		return errors.Wrap(err, "get wecom client")
+	}
+	creatorID, err := wecomservice.GetUserID(client, workflowCtx.WorkflowTaskCreatorMobile, workflowCtx.WorkflowTaskCreatorEmail)
+	if err != nil {
+		stage.Status = config.StatusFailed
+		return errors.Wrap(err, "get task creator in wecom")
+	}
+	approveUsers, err := getApproveUsersInIM(approval.ApproveUsers, func(mobile, email string) (string, error) {
+		return wecomservice.GetUserID(client, mobile, email)
+	})
+	if err != nil {
+		stage.Status = config.StatusFailed
+		return err
+	}
+	control, controlID, err := client.GetTemplateTextControl(app.WeComApprovalTemplateID)
+	if err != nil {
+		stage.Status = config.StatusFailed
+		return errors.Wrap(err, "get approval template")
+	}
+
+	var approverIDs []string
+	for id := range approveUsers {
+		approverIDs = append(approverIDs, id)
+	}
+	spNo, err := client.CreateApproval(&wecom.CreateApprovalArgs{
+		CreatorUserID: creatorID,
+		TemplateID:    app.WeComApprovalTemplateID,
+		Approvers: []*wecom.Approver{{
+			Attr:    wecom.ApproverAttrOr,
+			UserIDs: approverIDs,
+		}},
+		Control:   control,
+		ControlID: controlID,
+		Content:   getApprovalFormContent(stage, workflowCtx),
+		Summary:   fmt.Sprintf("Zadig 工作流 %s 阶段 %s", workflowCtx.WorkflowDisplayName, stage.Name),
+	})
+	if err != nil {
+		log.Errorf("waitForWeComApprove: create approval failed: %v", err)
+		stage.Status = config.StatusFailed
+		return errors.Wrap(err, "create approval")
+	}
+	log.Infof("waitForWeComApprove: create approval success, sp_no %s", spNo)
+	approval.InstanceCode = spNo
+	ack()
+
+	if err := instantmessage.NewWeChatClient().SendWorkflowTaskAproveNotifications(workflowCtx.WorkflowName, workflowCtx.TaskID); err != nil {
+		logger.Errorf("send approve notification failed, error: %v", err)
+	}
+
+	// wecom does not provide an api to revoke an approval, it is left to be handled by the approvers
+	timeout := time.After(time.Duration(approval.Timeout) * time.Minute)
+	ticker := time.NewTicker(imApprovalPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			stage.Status = config.StatusCancelled
+			return fmt.Errorf("workflow was canceled")
+		case <-timeout:
+			stage.Status = config.StatusCancelled
+			return fmt.Errorf("workflow timeout")
+		case <-ticker.C:
+			info, err := client.GetApprovalDetail(spNo)
+			if err != nil {
+				log.Errorf("waitForWeComApprove: get approval %s error: %v", spNo, err)
+				continue
+			}
+			if updateWeComApprovers(approveUsers, info.SpRecord) {
+				ack()
+			}
+			if done, err := checkWeComApproval(stage, info); done {
+				return err
+			}
+		}
+	}
+}
+
+// updateWeComApprovers records the results of the approvers, it returns true if any approver is updated.
+func updateWeComApprovers(approveUsers map[string]*commonmodels.User, records []*wecom.ApprovalRecord) bool {
+	updated := false
+	for _, record := range records {
+		for _, detail := range record.Details {
+			u, ok := approveUsers[detail.Approver.UserID]
+			if !ok || u.RejectOrApprove != "" {
+				continue
+			}
+			switch detail.SpStatus {
+			case wecom.ApproverStatusApproved:
+				u.RejectOrApprove = config.Approve
+			case wecom.ApproverStatusRejected:
+				u.RejectOrApprove = config.Reject
+			default:
+				continue
+			}
+			u.Comment = detail.Speech
+			u.OperationTime = detail.SpTime
+			updated = true
+		}
+	}
+	return updated
+}
+
+// checkWeComApproval returns true if the approval is finished, the stage status is set if it is not approved.
+func checkWeComApproval(stage *commonmodels.StageTask, info *wecom.ApprovalDetail) (bool, error) {
+	switch info.SpStatus {
+	case wecom.ApprovalStatusApproved:
+		return true, nil
+	case wecom.ApprovalStatusRejected:
+		stage.Status = config.StatusReject
+		return true, errors.New("Approval has been rejected")
+	case wecom.ApprovalStatusRevoked:
+		stage.Status = config.StatusFailed
+		return true, errors.New("Approval has been canceled")
+	case wecom.ApprovalStatusDeleted:
+		stage.Status = config.StatusFailed
+		return true, errors.New("Approval has been deleted")
+	}
+	return false, nil
+}
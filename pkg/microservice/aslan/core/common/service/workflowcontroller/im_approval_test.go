@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflowcontroller
+
+import (
+	"testing"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/tool/dingtalk"
+	"github.com/koderover/zadig/pkg/tool/wecom"
+)
+
+func TestCheckWeComApproval(t *testing.T) {
+	tests := []struct {
+		spStatus int
+		done     bool
+		failed   bool
+		status   config.Status
+	}{
+		{spStatus: wecom.ApprovalStatusPending},
+		{spStatus: wecom.ApprovalStatusApproved, done: true},
+		{spStatus: wecom.ApprovalStatusRejected, done: true, failed: true, status: config.StatusReject},
+		{spStatus: wecom.ApprovalStatusRevoked, done: true, failed: true, status: config.StatusFailed},
+		{spStatus: wecom.ApprovalStatusDeleted, done: true, failed: true, status: config.StatusFailed},
+	}
+	for _, tt := range tests {
+		stage := &commonmodels.StageTask{}
+		done, err := checkWeComApproval(stage, &wecom.ApprovalDetail{SpStatus: tt.spStatus})
+		if done != tt.done || (err != nil) != tt.failed || stage.Status != tt.status {
+			t.Errorf("sp_status %d: got (%v, %v) with stage status %q, want done %v, failed %v, stage status %q",
+				tt.spStatus, done, err, stage.Status, tt.done, tt.failed, tt.status)
+		}
+	}
+}
+
+func TestCheckDingTalkApproval(t *testing.T) {
+	tests := []struct {
+		name   string
+		info   *dingtalk.ApprovalInstance
+		done   bool
+		failed bool
+		status config.Status
+	}{
+		{name: "running", info: &dingtalk.ApprovalInstance{Status: dingtalk.ApprovalStatusRunning}},
+		{name: "agreed", info: &dingtalk.ApprovalInstance{Status: dingtalk.ApprovalStatusCompleted, Result: dingtalk.ApprovalResultAgree}, done: true},
+		{name: "refused", info: &dingtalk.ApprovalInstance{Status: dingtalk.ApprovalStatusCompleted, Result: dingtalk.ApprovalResultRefuse}, done: true, failed: true, status: config.StatusReject},
+		{name: "terminated", info: &dingtalk.ApprovalInstance{Status: dingtalk.ApprovalStatusTerminated}, done: true, failed: true, status: config.StatusFailed},
+	}
+	for _, tt := range tests {
+		stage := &commonmodels.StageTask{}
+		done, err := checkDingTalkApproval(stage, tt.info)
+		if done != tt.done || (err != nil) != tt.failed || stage.Status != tt.status {
+			t.Errorf("%s: got (%v, %v) with stage status %q, want done %v, failed %v, stage status %q",
+				tt.name, done, err, stage.Status, tt.done, tt.failed, tt.status)
+		}
+	}
+}
+
+func TestUpdateWeComApprovers(t *testing.T) {
+	approveUsers := map[string]*commonmodels.User{
+		"u1": {UserName: "alice"},
+		"u2": {UserName: "bob", RejectOrApprove: config.Reject},
+	}
+	records := []*wecom.ApprovalRecord{{Details: []*wecom.ApproverDetail{
+		{SpStatus: wecom.ApproverStatusApproved, Speech: "lgtm", SpTime: 100},
+		{SpStatus: wecom.ApproverStatusApproved, SpTime: 200},
+		{SpStatus: wecom.ApproverStatusApproved, SpTime: 300},
+	}}}
+	records[0].Details[0].Approver.UserID = "u1"
+	records[0].Details[1].Approver.UserID = "u2"
+	records[0].Details[2].Approver.UserID = "unknown"
+
+	if !updateWeComApprovers(approveUsers, records) {
+		t.Fatalf("approvers should be updated")
+	}
+	if u := approveUsers["u1"]; u.RejectOrApprove != config.Approve || u.Comment != "lgtm" || u.OperationTime != 100 {
+		t.Errorf("unexpected approver %+v", u)
+	}
+	if u := approveUsers["u2"]; u.RejectOrApprove != config.Reject || u.OperationTime != 0 {
+		t.Errorf("the result of approver %s should not be overwritten", u.UserName)
+	}
+	if updateWeComApprovers(approveUsers, records) {
+		t.Errorf("approvers should not be updated twice")
+	}
+}
@@ -34,6 +34,8 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/changefreeze"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/gitops"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/kube"
 	"github.com/koderover/zadig/pkg/setting"
 	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
@@ -51,6 +53,8 @@ type DeployJobCtl struct {
 	restConfig  *rest.Config
 	jobTaskSpec *commonmodels.JobTaskDeploySpec
 	ack         func()
+	// gitOps is set when the image is committed to git instead of being applied to the cluster
+	gitOps bool
 }
 
 func NewDeployJobCtl(job *commonmodels.JobTask, workflowCtx *commonmodels.WorkflowTaskCtx, ack func(), logger *zap.SugaredLogger) *DeployJobCtl {
@@ -76,11 +80,75 @@ func (c *DeployJobCtl) Run(ctx context.Context) {
 	if err := c.run(ctx); err != nil {
 		return
 	}
+	// the rollout is done by the gitops tool, there is nothing to wait for or verify
+	if c.gitOps {
+		c.job.Status = config.StatusPassed
+		return
+	}
 	if c.jobTaskSpec.SkipCheckRunStatus {
 		c.job.Status = config.StatusPassed
+	} else {
+		c.wait(ctx)
+	}
+	// verification does not depend on the rollout status check, it starts right after the apply if the check is skipped
+	if c.job.Status == config.StatusPassed && verificationEnabled(c.jobTaskSpec.Verification) {
+		c.verify(ctx)
+	}
+}
+
+// verify runs the post-deploy verification, the images replaced by this job are restored on failure if configured.
+func (c *DeployJobCtl) verify(ctx context.Context) {
+	c.job.Status = config.StatusRunning
+	c.ack()
+
+	err := runDeployVerification(ctx, c.jobTaskSpec.Verification, c.namespace, c.logger)
+	if err == nil {
+		c.job.Status = config.StatusPassed
+		return
+	}
+	if ctx.Err() != nil {
+		c.job.Status = config.StatusCancelled
 		return
 	}
-	c.wait(ctx)
+
+	msg := fmt.Sprintf("post-deploy verification failed: %v", err)
+	if c.jobTaskSpec.Verification.RollbackOnFailure {
+		if rollbackErr := c.rollback(); rollbackErr != nil {
+			msg = fmt.Sprintf("%s, failed to restore images: %v", msg, rollbackErr)
+		} else {
+			msg = fmt.Sprintf("%s, images are restored", msg)
+		}
+	}
+	logError(c.job, msg, c.logger)
+}
+
+// rollback restores the images recorded in ReplaceResources.
+func (c *DeployJobCtl) rollback() error {
+	for _, resource := range c.jobTaskSpec.ReplaceResources {
+		var err error
+		switch resource.Kind {
+		case setting.Deployment:
+			err = updater.UpdateDeploymentImage(c.namespace, resource.Name, resource.Container, resource.Origin, c.kubeClient)
+		case setting.StatefulSet:
+			err = updater.UpdateStatefulSetImage(c.namespace, resource.Name, resource.Container, resource.Origin, c.kubeClient)
+		case setting.DaemonSet:
+			err = updater.UpdateDaemonSetImage(c.namespace, resource.Name, resource.Container, resource.Origin, c.kubeClient)
+		case setting.CronJob:
+			var versionLessThan121 bool
+			if versionLessThan121, err = clusterVersionLessThan121(c.jobTaskSpec.ClusterID); err == nil {
+				err = updater.UpdateCronJobImage(c.namespace, resource.Name, resource.Container, resource.Origin, c.kubeClient, versionLessThan121)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to restore image of %s/%s/%s: %v", resource.Kind, resource.Name, resource.Container, err)
+		}
+	}
+
+	origins := make(map[string]string)
+	for _, resource := range c.jobTaskSpec.ReplaceResources {
+		origins[resource.Container] = resource.Origin
+	}
+	return updateProductImageByNs(c.namespace, c.workflowCtx.ProjectName, c.jobTaskSpec.ServiceName, origins, c.logger)
 }
 
 func (c *DeployJobCtl) run(ctx context.Context) error {
@@ -97,8 +165,15 @@ func (c *DeployJobCtl) run(ctx context.Context) error {
 		logError(c.job, msg, c.logger)
 		return errors.New(msg)
 	}
+	if err := changefreeze.CheckDeploy(env, c.workflowCtx.WorkflowName); err != nil {
+		logError(c.job, err.Error(), c.logger)
+		return err
+	}
 	c.namespace = env.Namespace
 	c.jobTaskSpec.ClusterID = env.ClusterID
+	if env.IsGitOps() {
+		return c.deployGitOps(env)
+	}
 
 	c.restConfig, err = kubeclient.GetRESTConfig(config.HubServerAddress(), c.jobTaskSpec.ClusterID)
 	if err != nil {
@@ -193,6 +268,43 @@ func (c *DeployJobCtl) run(ctx context.Context) error {
 				}
 			}
 		}
+
+		var daemonSets []*appsv1.DaemonSet
+		daemonSets, err = kube.FetchRelatedDaemonSets(env.Namespace, c.jobTaskSpec.ServiceName, env, c.kubeClient)
+		if err != nil {
+			logError(c.job, err.Error(), c.logger)
+			return err
+		}
+	DsLoop:
+		for _, ds := range daemonSets {
+			for _, container := range ds.Spec.Template.Spec.Containers {
+				if container.Name == c.jobTaskSpec.ServiceModule {
+					err = updater.UpdateDaemonSetImage(ds.Namespace, ds.Name, c.jobTaskSpec.ServiceModule, c.jobTaskSpec.Image, c.kubeClient)
+					if err != nil {
+						msg := fmt.Sprintf("failed to update container image in %s/daemonsets/%s/%s: %v", env.Namespace, ds.Name, container.Name, err)
+						logError(c.job, msg, c.logger)
+						return errors.New(msg)
+					}
+					c.jobTaskSpec.ReplaceResources = append(c.jobTaskSpec.ReplaceResources, commonmodels.Resource{
+						Kind:      setting.DaemonSet,
+						Container: container.Name,
+						Origin:    container.Image,
+						Name:      ds.Name,
+					})
+					replaced = true
+					c.jobTaskSpec.RelatedPodLabels = append(c.jobTaskSpec.RelatedPodLabels, ds.Spec.Template.Labels)
+					break DsLoop
+				}
+			}
+		}
+
+		var cronJobReplaced bool
+		cronJobReplaced, err = c.updateCronJobImage(env)
+		if err != nil {
+			logError(c.job, err.Error(), c.logger)
+			return err
+		}
+		replaced = replaced || cronJobReplaced
 	} else {
 		switch serviceInfo.WorkloadType {
 		case setting.StatefulSet:
@@ -271,6 +383,81 @@ func (c *DeployJobCtl) run(ctx context.Context) error {
 	return nil
 }
 
+// deployGitOps records the image in the environment and exports the manifests to git, the cluster of gitops
+// environments is only changed by the gitops tool syncing from git.
+func (c *DeployJobCtl) deployGitOps(env *commonmodels.Product) error {
+	c.gitOps = true
+	if err := updateProductImageByNs(env.Namespace, c.workflowCtx.ProjectName, c.jobTaskSpec.ServiceName, map[string]string{c.jobTaskSpec.ServiceModule: c.jobTaskSpec.Image}, c.logger); err != nil {
+		logError(c.job, err.Error(), c.logger)
+		return err
+	}
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: env.ProductName, EnvName: env.EnvName})
+	if err != nil {
+		msg := fmt.Sprintf("find project error: %v", err)
+		logError(c.job, msg, c.logger)
+		return errors.New(msg)
+	}
+	if _, err := gitops.ExportEnv(env, fmt.Sprintf("workflow %s", c.workflowCtx.WorkflowName), c.logger); err != nil {
+		msg := fmt.Sprintf("failed to export manifests of env %s to git: %v", env.EnvName, err)
+		logError(c.job, msg, c.logger)
+		return errors.New(msg)
+	}
+	c.job.Spec = c.jobTaskSpec
+	return nil
+}
+
+// updateCronJobImage replaces the container image in the CronJobs of the service, the jobs which are already
+// running are left untouched and the new image takes effect from the next schedule.
+func (c *DeployJobCtl) updateCronJobImage(env *commonmodels.Product) (bool, error) {
+	versionLessThan121, err := clusterVersionLessThan121(c.jobTaskSpec.ClusterID)
+	if err != nil {
+		return false, err
+	}
+
+	cronJobs, betaCronJobs, err := kube.FetchRelatedCronJobs(env.Namespace, c.jobTaskSpec.ServiceName, env, c.kubeClient, versionLessThan121)
+	if err != nil {
+		return false, err
+	}
+	type cronJobContainers struct {
+		name       string
+		containers []corev1.Container
+	}
+	targets := make([]cronJobContainers, 0, len(cronJobs)+len(betaCronJobs))
+	for _, cj := range cronJobs {
+		targets = append(targets, cronJobContainers{name: cj.Name, containers: cj.Spec.JobTemplate.Spec.Template.Spec.Containers})
+	}
+	for _, cj := range betaCronJobs {
+		targets = append(targets, cronJobContainers{name: cj.Name, containers: cj.Spec.JobTemplate.Spec.Template.Spec.Containers})
+	}
+
+	for _, target := range targets {
+		for _, container := range target.containers {
+			if container.Name != c.jobTaskSpec.ServiceModule {
+				continue
+			}
+			if err := updater.UpdateCronJobImage(env.Namespace, target.name, c.jobTaskSpec.ServiceModule, c.jobTaskSpec.Image, c.kubeClient, versionLessThan121); err != nil {
+				return false, fmt.Errorf("failed to update container image in %s/cronjobs/%s/%s: %v", env.Namespace, target.name, container.Name, err)
+			}
+			c.jobTaskSpec.ReplaceResources = append(c.jobTaskSpec.ReplaceResources, commonmodels.Resource{
+				Kind:      setting.CronJob,
+				Container: container.Name,
+				Origin:    container.Image,
+				Name:      target.name,
+			})
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func clusterVersionLessThan121(clusterID string) (bool, error) {
+	versionLessThan121, err := kubeclient.ClusterVersionLessThan121(config.HubServerAddress(), clusterID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get k8s server version: %v", err)
+	}
+	return versionLessThan121, nil
+}
+
 func workLoadDeployStat(kubeClient client.Client, namespace string, labelMaps []map[string]string) error {
 	for _, label := range labelMaps {
 		selector := labels.Set(label).AsSelector()
@@ -385,6 +572,27 @@ func (c *DeployJobCtl) wait(ctx context.Context) {
 						ready = wrapper.StatefulSet(st).Ready()
 					}
 
+					if !ready {
+						break L
+					}
+				case setting.DaemonSet:
+					ds, found, e := getter.GetDaemonSet(c.namespace, resource.Name, c.kubeClient)
+					if e != nil {
+						err = e
+					}
+					if e != nil || !found {
+						c.logger.Errorf(
+							"failed to check daemonSet ready status %s/%s/%s - %v",
+							c.namespace,
+							resource.Kind,
+							resource.Name,
+							e,
+						)
+						ready = false
+					} else {
+						ready = wrapper.Daemenset(ds).Ready()
+					}
+
 					if !ready {
 						break L
 					}
@@ -32,6 +32,7 @@ import (
 	zadigconfig "github.com/koderover/zadig/pkg/config"
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/secretprovider"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/workflowcontroller/stepcontroller"
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/tool/dockerhost"
@@ -161,7 +162,16 @@ func (c *FreestyleJobCtl) run(ctx context.Context) error {
 
 	c.jobTaskSpec.Properties.DockerHost = dockerHost
 
-	jobCtxBytes, err := yaml.Marshal(BuildJobExcutorContext(c.jobTaskSpec, c.job, c.workflowCtx, c.logger))
+	// secret references are resolved on a copy of the spec so that the secrets are never saved with the task
+	execSpec := *c.jobTaskSpec
+	envs, err := secretprovider.ResolveKeyVals(ctx, nil, c.workflowCtx.ProjectName, c.jobTaskSpec.Properties.Envs)
+	if err != nil {
+		logError(c.job, err.Error(), c.logger)
+		return err
+	}
+	execSpec.Properties.Envs = envs
+
+	jobCtxBytes, err := yaml.Marshal(BuildJobExcutorContext(&execSpec, c.job, c.workflowCtx, c.logger))
 	if err != nil {
 		msg := fmt.Sprintf("cannot Jobexcutor.Context data: %v", err)
 		logError(c.job, msg, c.logger)
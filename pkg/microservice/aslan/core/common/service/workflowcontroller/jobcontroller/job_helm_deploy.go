@@ -45,6 +45,7 @@ import (
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	templatemodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/template"
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/changefreeze"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/s3"
 	"github.com/koderover/zadig/pkg/setting"
 )
@@ -98,6 +99,10 @@ func (c *HelmDeployJobCtl) Run(ctx context.Context) {
 		logError(c.job, msg, c.logger)
 		return
 	}
+	if err := changefreeze.CheckDeploy(env, c.workflowCtx.WorkflowName); err != nil {
+		logError(c.job, err.Error(), c.logger)
+		return
+	}
 	c.namespace = env.Namespace
 	c.jobTaskSpec.ClusterID = env.ClusterID
 
@@ -160,6 +165,7 @@ func (c *HelmDeployJobCtl) Run(ctx context.Context) {
 
 	serviceRevisionInProduct := int64(0)
 	involvedImagePaths := make(map[string]*commonmodels.ImagePathSpec)
+	originImages := make(map[string]string)
 	for _, service := range productInfo.GetServiceMap() {
 		if service.ServiceName != c.jobTaskSpec.ServiceName {
 			continue
@@ -175,6 +181,7 @@ func (c *HelmDeployJobCtl) Run(ctx context.Context) {
 				return
 			}
 			involvedImagePaths[container.Name] = container.ImagePath
+			originImages[container.Name] = container.Image
 		}
 		break
 	}
@@ -418,7 +425,64 @@ func (c *HelmDeployJobCtl) Run(ctx context.Context) {
 		logError(c.job, err.Error(), c.logger)
 		return
 	}
-	c.job.Status = config.StatusPassed
+
+	// verification does not depend on the rollout status check, it starts right after the upgrade if the check is skipped
+	if !verificationEnabled(c.jobTaskSpec.Verification) {
+		c.job.Status = config.StatusPassed
+		return
+	}
+	err = runDeployVerification(ctx, c.jobTaskSpec.Verification, c.namespace, c.logger)
+	if err == nil {
+		c.job.Status = config.StatusPassed
+		return
+	}
+	if ctx.Err() != nil {
+		c.job.Status = config.StatusCancelled
+		return
+	}
+	msg := fmt.Sprintf("post-deploy verification failed: %v", err)
+	if c.jobTaskSpec.Verification.RollbackOnFailure {
+		if rollbackErr := c.rollback(helmClient, releaseName, renderInfo, serviceValuesYaml); rollbackErr != nil {
+			msg = fmt.Sprintf("%s, failed to rollback release: %v", msg, rollbackErr)
+		} else {
+			msg = fmt.Sprintf("%s, release is rolled back", msg)
+			// the deferred product update should keep the images before the deployment
+			for serviceModule := range deploytargets {
+				containerName := strings.TrimSuffix(serviceModule, "_"+c.jobTaskSpec.ServiceName)
+				if image, ok := originImages[containerName]; ok {
+					deploytargets[serviceModule] = image
+				}
+			}
+		}
+	}
+	logError(c.job, msg, c.logger)
+}
+
+// rollback rolls the release back to the previous revision and restores the values.yaml of the service in renderset.
+func (c *HelmDeployJobCtl) rollback(helmClient helmclient.Client, releaseName string, renderInfo *commonmodels.RenderSet, valuesYaml string) error {
+	err := helmClient.RollbackRelease(&helmclient.ChartSpec{
+		ReleaseName: releaseName,
+		Namespace:   c.namespace,
+		Timeout:     time.Second * time.Duration(c.timeout()),
+		Wait:        true,
+		MaxHistory:  10,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, chartInfo := range renderInfo.ChartInfos {
+		if chartInfo.ServiceName == c.jobTaskSpec.ServiceName {
+			chartInfo.ValuesYaml = valuesYaml
+			break
+		}
+	}
+	return commonrepo.NewRenderSetColl().Update(&commonmodels.RenderSet{
+		Name:          renderInfo.Name,
+		Revision:      renderInfo.Revision,
+		DefaultValues: renderInfo.DefaultValues,
+		ChartInfos:    renderInfo.ChartInfos,
+	})
 }
 
 func (c *HelmDeployJobCtl) timeout() int {
@@ -0,0 +1,181 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobcontroller
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/probe"
+	"github.com/koderover/zadig/pkg/tool/prometheus"
+)
+
+const (
+	defaultVerificationInterval = 10
+	defaultProbeTimeout         = 3
+	maxProbeRetries             = 3
+)
+
+// the probes and metric queries are sent from the aslan pod, they are variables so that tests can replace them.
+var (
+	doHTTPProbe = probe.DoHTTPProbe
+	doTCPProbe  = probe.DoTCPProbe
+	queryMetric = func(m *commonmodels.VerificationMetric) (float64, error) {
+		return prometheus.NewClient(m.Endpoint, m.Token).QueryScalar(m.Query)
+	}
+	verificationMetricEndpoints = config.VerificationMetricEndpoints
+)
+
+func verificationEnabled(verification *commonmodels.DeployVerification) bool {
+	return verification != nil && verification.Enabled && (len(verification.Probes) > 0 || len(verification.Metrics) > 0)
+}
+
+// runDeployVerification checks the probes and metrics repeatedly until the duration elapses,
+// it returns on the first failed round or when the context is done.
+func runDeployVerification(ctx context.Context, verification *commonmodels.DeployVerification, namespace string, logger *zap.SugaredLogger) error {
+	interval := verification.Interval
+	if interval <= 0 {
+		interval = defaultVerificationInterval
+	}
+	deadline := time.After(time.Duration(verification.Duration) * time.Minute)
+
+	for {
+		for _, p := range verification.Probes {
+			if err := checkVerificationProbe(p, namespace, logger); err != nil {
+				return err
+			}
+		}
+		for _, m := range verification.Metrics {
+			if err := checkVerificationMetric(m); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return nil
+		case <-time.After(time.Duration(interval) * time.Second):
+		}
+	}
+}
+
+// verificationProbeHost returns the in-cluster host of the probe address. Since the probes are sent from the aslan pod,
+// only the services in the namespace of the environment are allowed to be probed.
+func verificationProbeHost(address, namespace string) (string, error) {
+	host := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(address)), ".")
+	name, domain := host, ""
+	if i := strings.Index(host, "."); i >= 0 {
+		name, domain = host[:i], host[i+1:]
+	}
+	if len(validation.IsDNS1035Label(name)) == 0 {
+		switch domain {
+		case "", namespace, namespace + ".svc", namespace + ".svc.cluster.local":
+			return fmt.Sprintf("%s.%s.svc", name, namespace), nil
+		}
+	}
+	return "", fmt.Errorf("probe address %s is not a service in namespace %s", address, namespace)
+}
+
+// checkVerificationMetricEndpoint checks that the endpoint is one of the Prometheus endpoints allowed by the administrator,
+// a path under the allowed endpoint is also accepted.
+func checkVerificationMetricEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid metric endpoint %s", endpoint)
+	}
+	for _, allowed := range verificationMetricEndpoints() {
+		au, err := url.Parse(allowed)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(au.Scheme, u.Scheme) && strings.EqualFold(au.Host, u.Host) &&
+			strings.HasPrefix(strings.TrimSuffix(u.Path, "/")+"/", strings.TrimSuffix(au.Path, "/")+"/") {
+			return nil
+		}
+	}
+	return fmt.Errorf("metric endpoint %s is not allowed, the allowed endpoints are configured by %s", endpoint, setting.ENVVerificationMetricEndpoints)
+}
+
+func checkVerificationProbe(p *commonmodels.VerificationProbe, namespace string, logger *zap.SugaredLogger) error {
+	address, err := verificationProbeHost(p.Address, namespace)
+	if err != nil {
+		return err
+	}
+	timeoutSecond := p.Timeout
+	if timeoutSecond <= 0 {
+		timeoutSecond = defaultProbeTimeout
+	}
+	timeout := time.Duration(timeoutSecond) * time.Second
+
+	var message string
+	for i := 0; i < maxProbeRetries; i++ {
+		switch p.Protocol {
+		case setting.ProtocolHTTP, setting.ProtocolHTTPS:
+			message, err = doHTTPProbe(p.Protocol, address, p.Path, p.Port, p.HTTPHeaders, timeout, p.ResponseSuccessFlag, logger)
+		case setting.ProtocolTCP:
+			message, err = doTCPProbe(address, p.Port, timeout, logger)
+		default:
+			return fmt.Errorf("unsupported probe protocol: %s", p.Protocol)
+		}
+		if err == nil && message == probe.Success {
+			return nil
+		}
+	}
+	if err == nil {
+		err = fmt.Errorf("probe result is %s", message)
+	}
+	return fmt.Errorf("%s probe of %s:%d%s failed: %v", p.Protocol, address, p.Port, p.Path, err)
+}
+
+func checkVerificationMetric(m *commonmodels.VerificationMetric) error {
+	if err := checkVerificationMetricEndpoint(m.Endpoint); err != nil {
+		return err
+	}
+	value, err := queryMetric(m)
+	if err != nil {
+		return fmt.Errorf("failed to query metric %s: %v", m.Name, err)
+	}
+
+	var healthy bool
+	switch m.Operator {
+	case ">":
+		healthy = value > m.Threshold
+	case ">=":
+		healthy = value >= m.Threshold
+	case "<":
+		healthy = value < m.Threshold
+	case "<=":
+		healthy = value <= m.Threshold
+	default:
+		return fmt.Errorf("unsupported operator %s of metric %s", m.Operator, m.Name)
+	}
+	if !healthy {
+		return fmt.Errorf("metric %s regressed, value %v is not %s %v", m.Name, value, m.Operator, m.Threshold)
+	}
+	return nil
+}
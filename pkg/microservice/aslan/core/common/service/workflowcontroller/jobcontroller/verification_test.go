@@ -0,0 +1,232 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobcontroller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/probe"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+func TestVerificationProbeHost(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+		wantErr bool
+	}{
+		{address: "svc", want: "svc.ns.svc"},
+		{address: "svc.ns", want: "svc.ns.svc"},
+		{address: "svc.ns.svc", want: "svc.ns.svc"},
+		{address: "SVC.ns.svc.cluster.local.", want: "svc.ns.svc"},
+		{address: "svc.other", wantErr: true},
+		{address: "svc.other.svc.cluster.local", wantErr: true},
+		{address: "kubernetes.default.svc", wantErr: true},
+		{address: "db.example.com", wantErr: true},
+		{address: "10.0.0.1", wantErr: true},
+		{address: "169.254.169.254", wantErr: true},
+		{address: "svc:8080", wantErr: true},
+		{address: "http://svc", wantErr: true},
+		{address: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			got, err := verificationProbeHost(tt.address, "ns")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verificationProbeHost(%q) error = %v, wantErr %v", tt.address, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("verificationProbeHost(%q) = %q, want %q", tt.address, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckVerificationMetricEndpoint(t *testing.T) {
+	origin := verificationMetricEndpoints
+	defer func() { verificationMetricEndpoints = origin }()
+	verificationMetricEndpoints = func() []string {
+		return []string{"http://prometheus.monitoring:9090", "https://thanos.example.com/prometheus/"}
+	}
+
+	tests := []struct {
+		endpoint string
+		wantErr  bool
+	}{
+		{endpoint: "http://prometheus.monitoring:9090"},
+		{endpoint: "http://prometheus.monitoring:9090/"},
+		{endpoint: "HTTP://Prometheus.Monitoring:9090"},
+		{endpoint: "https://thanos.example.com/prometheus"},
+		{endpoint: "https://thanos.example.com/prometheus/tenant-a"},
+		{endpoint: "https://thanos.example.com/prometheus-other", wantErr: true},
+		{endpoint: "https://thanos.example.com", wantErr: true},
+		{endpoint: "https://prometheus.monitoring:9090", wantErr: true},
+		{endpoint: "http://prometheus.monitoring:9091", wantErr: true},
+		{endpoint: "http://169.254.169.254", wantErr: true},
+		{endpoint: "prometheus.monitoring:9090", wantErr: true},
+		{endpoint: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			if err := checkVerificationMetricEndpoint(tt.endpoint); (err != nil) != tt.wantErr {
+				t.Errorf("checkVerificationMetricEndpoint(%q) error = %v, wantErr %v", tt.endpoint, err, tt.wantErr)
+			}
+		})
+	}
+
+	verificationMetricEndpoints = func() []string { return []string{} }
+	if err := checkVerificationMetricEndpoint("http://prometheus.monitoring:9090"); err == nil {
+		t.Error("expected an error when no endpoint is allowed")
+	}
+}
+
+func TestCheckVerificationMetric(t *testing.T) {
+	origin, originEndpoints := queryMetric, verificationMetricEndpoints
+	defer func() { queryMetric, verificationMetricEndpoints = origin, originEndpoints }()
+	verificationMetricEndpoints = func() []string { return []string{"http://prometheus:9090"} }
+
+	tests := []struct {
+		name      string
+		value     float64
+		queryErr  error
+		operator  string
+		threshold float64
+		wantErr   bool
+	}{
+		{name: "greater than", value: 2, operator: ">", threshold: 1},
+		{name: "not greater than", value: 1, operator: ">", threshold: 1, wantErr: true},
+		{name: "greater or equal", value: 1, operator: ">=", threshold: 1},
+		{name: "not greater or equal", value: 0.99, operator: ">=", threshold: 1, wantErr: true},
+		{name: "less than", value: 0.01, operator: "<", threshold: 0.05},
+		{name: "not less than", value: 0.05, operator: "<", threshold: 0.05, wantErr: true},
+		{name: "less or equal", value: 0.05, operator: "<=", threshold: 0.05},
+		{name: "not less or equal", value: 0.06, operator: "<=", threshold: 0.05, wantErr: true},
+		{name: "unsupported operator", value: 1, operator: "==", threshold: 1, wantErr: true},
+		{name: "query failure", queryErr: errors.New("no sample"), operator: "<", threshold: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queryMetric = func(m *commonmodels.VerificationMetric) (float64, error) {
+				return tt.value, tt.queryErr
+			}
+			err := checkVerificationMetric(&commonmodels.VerificationMetric{Name: "error-rate", Endpoint: "http://prometheus:9090", Operator: tt.operator, Threshold: tt.threshold})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkVerificationMetric() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunDeployVerification(t *testing.T) {
+	originHTTP, originTCP, originQuery, originEndpoints := doHTTPProbe, doTCPProbe, queryMetric, verificationMetricEndpoints
+	defer func() {
+		doHTTPProbe, doTCPProbe, queryMetric, verificationMetricEndpoints = originHTTP, originTCP, originQuery, originEndpoints
+	}()
+	verificationMetricEndpoints = func() []string { return []string{"http://prometheus:9090"} }
+
+	var (
+		addresses []string
+		healthy   bool
+		probeErr  error
+	)
+	doHTTPProbe = func(scheme, host, path string, port int, headers []*types.HTTPHeader, timeout time.Duration, responseSuccessFlag string, log *zap.SugaredLogger) (string, error) {
+		addresses = append(addresses, host)
+		if healthy {
+			return probe.Success, nil
+		}
+		return probe.Failure, probeErr
+	}
+	doTCPProbe = func(host string, port int, timeout time.Duration, log *zap.SugaredLogger) (string, error) {
+		addresses = append(addresses, host)
+		return probe.Success, nil
+	}
+	queryMetric = func(m *commonmodels.VerificationMetric) (float64, error) {
+		return 0, nil
+	}
+
+	verification := &commonmodels.DeployVerification{
+		Enabled: true,
+		Probes: []*commonmodels.VerificationProbe{
+			{Protocol: setting.ProtocolHTTP, Address: "svc", Port: 80, Path: "/healthz"},
+			{Protocol: setting.ProtocolTCP, Address: "db.ns", Port: 3306},
+		},
+		Metrics: []*commonmodels.VerificationMetric{
+			{Name: "error-rate", Endpoint: "http://prometheus:9090", Operator: "<=", Threshold: 0},
+		},
+	}
+	logger := zap.NewNop().Sugar()
+
+	t.Run("passes when every check is healthy", func(t *testing.T) {
+		addresses, healthy = nil, true
+		if err := runDeployVerification(context.Background(), verification, "ns", logger); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(addresses) < 2 || addresses[0] != "svc.ns.svc" || addresses[1] != "db.ns.svc" {
+			t.Errorf("unexpected probe addresses %v", addresses)
+		}
+	})
+
+	t.Run("fails after retrying an unhealthy probe", func(t *testing.T) {
+		addresses, healthy, probeErr = nil, false, errors.New("connection refused")
+		err := runDeployVerification(context.Background(), verification, "ns", logger)
+		if err == nil || !strings.Contains(err.Error(), "connection refused") {
+			t.Fatalf("got error %v, want the probe error", err)
+		}
+		if len(addresses) != maxProbeRetries {
+			t.Errorf("probe is sent %d times, want %d", len(addresses), maxProbeRetries)
+		}
+	})
+
+	t.Run("reports the probe result when the probe fails without an error", func(t *testing.T) {
+		addresses, healthy, probeErr = nil, false, nil
+		err := runDeployVerification(context.Background(), verification, "ns", logger)
+		if err == nil || strings.Contains(err.Error(), "<nil>") || !strings.Contains(err.Error(), probe.Failure) {
+			t.Errorf("got error %v, want the probe result", err)
+		}
+	})
+
+	t.Run("rejects probes outside the namespace of the environment", func(t *testing.T) {
+		addresses, healthy = nil, true
+		outside := *verification
+		outside.Probes = []*commonmodels.VerificationProbe{{Protocol: setting.ProtocolTCP, Address: "db.example.com", Port: 3306}}
+		if err := runDeployVerification(context.Background(), &outside, "ns", logger); err == nil {
+			t.Fatal("expected an error")
+		}
+		if len(addresses) != 0 {
+			t.Errorf("probe is sent to %v", addresses)
+		}
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		addresses, healthy = nil, true
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		long := *verification
+		long.Duration = 10
+		if err := runDeployVerification(ctx, &long, "ns", logger); !errors.Is(err, context.Canceled) {
+			t.Errorf("got error %v, want %v", err, context.Canceled)
+		}
+	})
+}
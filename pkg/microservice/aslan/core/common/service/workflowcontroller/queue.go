@@ -26,6 +26,7 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/cloudevent"
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/tool/log"
 )
@@ -75,6 +76,7 @@ func CreateTask(t *commonmodels.WorkflowTask) error {
 		log.Errorf("create workflow task v4 error: %v", err)
 		return err
 	}
+	cloudevent.PublishWorkflowTaskEvent(cloudevent.TypeWorkflowTaskCreated, t, "")
 	return Push(t)
 }
 
@@ -31,6 +31,7 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/cloudevent"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/instantmessage"
 	larkservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/lark"
 	"github.com/koderover/zadig/pkg/tool/lark"
@@ -108,12 +109,19 @@ func waitForApprove(ctx context.Context, stage *commonmodels.StageTask, workflow
 	}
 	workflowCtx.SetStatus(config.StatusWaitingApprove)
 	defer workflowCtx.SetStatus(config.StatusRunning)
+	if task, err := mongodb.NewworkflowTaskv4Coll().Find(workflowCtx.WorkflowName, workflowCtx.TaskID); err == nil {
+		cloudevent.PublishWorkflowTaskEvent(cloudevent.TypeWorkflowStageWaitingApproval, task, stage.Name)
+	}
 
 	switch stage.Approval.Type {
 	case config.NativeApproval:
 		return waitForNativeApprove(ctx, stage, workflowCtx, logger, ack)
 	case config.LarkApproval:
 		return waitForLarkApprove(ctx, stage, workflowCtx, logger, ack)
+	case config.DingTalkApproval:
+		return waitForDingTalkApprove(ctx, stage, workflowCtx, logger, ack)
+	case config.WeComApproval:
+		return waitForWeComApprove(ctx, stage, workflowCtx, logger, ack)
 	default:
 		return errors.New("invalid approval type")
 	}
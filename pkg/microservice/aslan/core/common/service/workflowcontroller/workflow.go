@@ -31,7 +31,9 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/cloudevent"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/instantmessage"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/jira"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/scmnotify"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/workflowcontroller/jobcontroller"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/workflowstat"
@@ -123,6 +125,7 @@ func (c *workflowCtl) Run(ctx context.Context, concurrency int) {
 	c.workflowTask.Status = config.StatusRunning
 	c.workflowTask.StartTime = time.Now().Unix()
 	c.ack()
+	cloudevent.PublishWorkflowTaskEvent(cloudevent.TypeWorkflowTaskStarted, c.workflowTask, "")
 	c.logger.Infof("start workflow: %s,status: %s", c.workflowTask.WorkflowName, c.workflowTask.Status)
 	defer func() {
 		c.workflowTask.EndTime = time.Now().Unix()
@@ -237,6 +240,13 @@ func (c *workflowCtl) updateWorkflowTask() {
 		if err := instantmessage.NewWeChatClient().SendWorkflowTaskNotifications(c.workflowTask); err != nil {
 			c.logger.Errorf("send workflow task notification failed, error: %v", err)
 		}
+		cloudevent.PublishWorkflowTaskEvent(cloudevent.TypeWorkflowTaskFinished, c.workflowTask, "")
+		// syncing to jira calls the jira api for every failed job and deployment, do not block the queue on it
+		go func(task *commonmodels.WorkflowTask, logger *zap.SugaredLogger) {
+			if err := jira.SyncWorkflowTask(task, logger); err != nil {
+				logger.Errorf("sync workflow task to jira failed, error: %v", err)
+			}
+		}(c.workflowTask, c.logger)
 		q := ConvertTaskToQueue(c.workflowTask)
 		if err := Remove(q); err != nil {
 			c.logger.Errorf("remove queue task: %s:%d error: %v", c.workflowTask.WorkflowName, c.workflowTask.TaskID, err)
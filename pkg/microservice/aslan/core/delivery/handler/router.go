@@ -42,6 +42,8 @@ func (*Router) Inject(router *gin.RouterGroup) {
 	deliveryRelease := router.Group("releases")
 	{
 		deliveryRelease.GET("/:id", GetDeliveryVersion)
+		deliveryRelease.GET("/:id/notes", GetDeliveryReleaseNotes)
+		deliveryRelease.POST("/:id/notes", GenerateDeliveryReleaseNotes)
 		deliveryRelease.GET("", ListDeliveryVersion)
 		deliveryRelease.DELETE("/:id", GetProductNameByDelivery, DeleteDeliveryVersion)
 		deliveryRelease.POST("/helm", CreateHelmDeliveryVersion)
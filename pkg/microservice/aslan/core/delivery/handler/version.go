@@ -65,6 +65,59 @@ func GetDeliveryVersion(c *gin.Context) {
 	ctx.Resp, ctx.Err = deliveryservice.GetDetailReleaseData(version, ctx.Logger)
 }
 
+func GetDeliveryReleaseNotes(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ID := c.Param("id")
+	if ID == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("id can't be empty!")
+		return
+	}
+
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can't be empty!")
+		return
+	}
+
+	notes, err := deliveryservice.GetDeliveryReleaseNotes(projectName, ID, ctx.Logger)
+	if err != nil {
+		ctx.Err = err
+		return
+	}
+	if c.Query("format") == "markdown" {
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(notes.Markdown))
+		return
+	}
+	ctx.Resp = notes
+}
+
+func GenerateDeliveryReleaseNotes(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ID := c.Param("id")
+	if ID == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("id can't be empty!")
+		return
+	}
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can't be empty!")
+		return
+	}
+	args := new(deliveryservice.GenerateReleaseNotesArgs)
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(args); err != nil {
+			ctx.Err = e.ErrInvalidParam.AddErr(err)
+			return
+		}
+	}
+
+	ctx.Resp, ctx.Err = deliveryservice.GenerateDeliveryReleaseNotes(projectName, ID, args, ctx.Logger)
+}
+
 func ListDeliveryVersion(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
@@ -0,0 +1,577 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	githubtool "github.com/koderover/zadig/pkg/tool/git/github"
+	gitlabtool "github.com/koderover/zadig/pkg/tool/git/gitlab"
+	"github.com/koderover/zadig/pkg/tool/jira"
+	"github.com/koderover/zadig/pkg/tool/meego"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+const (
+	ReleaseNoteSourceJira  = "jira"
+	ReleaseNoteSourceMeego = "meego"
+)
+
+type GenerateReleaseNotesArgs struct {
+	// BaseVersion is the version to compare with, the previous version of the project is used if it is empty
+	BaseVersion string `json:"baseVersion"`
+}
+
+// GetDeliveryReleaseNotes returns the saved release notes of the version, the notes are generated without being saved
+// if they have not been generated yet.
+func GetDeliveryReleaseNotes(projectName, id string, log *zap.SugaredLogger) (*commonmodels.DeliveryReleaseNotes, error) {
+	version, err := getProjectDeliveryVersion(projectName, id, log)
+	if err != nil {
+		return nil, err
+	}
+	if version.ReleaseNotes != nil {
+		return version.ReleaseNotes, nil
+	}
+
+	notes, err := buildReleaseNotes(version, "", log)
+	if err != nil {
+		log.Errorf("failed to generate release notes of version %s, err: %s", version.Version, err)
+		return nil, e.ErrGetDeliveryVersion.AddErr(err)
+	}
+	return notes, nil
+}
+
+func GenerateDeliveryReleaseNotes(projectName, id string, args *GenerateReleaseNotesArgs, log *zap.SugaredLogger) (*commonmodels.DeliveryReleaseNotes, error) {
+	version, err := getProjectDeliveryVersion(projectName, id, log)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := generateReleaseNotes(version, args.BaseVersion, log)
+	if err != nil {
+		log.Errorf("failed to generate release notes of version %s, err: %s", version.Version, err)
+		return nil, e.ErrUpdateDeliveryVersion.AddErr(err)
+	}
+	return notes, nil
+}
+
+// getProjectDeliveryVersion returns the version only if it belongs to the project the request is authorized for.
+func getProjectDeliveryVersion(projectName, id string, log *zap.SugaredLogger) (*commonmodels.DeliveryVersion, error) {
+	version, err := commonrepo.NewDeliveryVersionColl().Get(&commonrepo.DeliveryVersionArgs{ID: id})
+	if err != nil {
+		log.Errorf("get deliveryVersion error: %v", err)
+		return nil, e.ErrGetDeliveryVersion
+	}
+	if version.ProductName != projectName {
+		log.Warnf("delivery version %s does not belong to project %s", id, projectName)
+		return nil, e.ErrGetDeliveryVersion.AddDesc(fmt.Sprintf("version not found in project %s", projectName))
+	}
+	return version, nil
+}
+
+// generateReleaseNotes builds the release notes of the version and saves them to the version.
+func generateReleaseNotes(version *commonmodels.DeliveryVersion, baseVersionName string, log *zap.SugaredLogger) (*commonmodels.DeliveryReleaseNotes, error) {
+	notes, err := buildReleaseNotes(version, baseVersionName, log)
+	if err != nil {
+		return nil, err
+	}
+	if err := commonrepo.NewDeliveryVersionColl().UpdateReleaseNotes(version.ID, notes); err != nil {
+		return nil, errors.Wrap(err, "failed to save release notes")
+	}
+	version.ReleaseNotes = notes
+	return notes, nil
+}
+
+// buildReleaseNotes compares the images of the version with the base version, traces every changed image back to
+// the task which built it and collects the commits, pull requests and issues.
+func buildReleaseNotes(version *commonmodels.DeliveryVersion, baseVersionName string, log *zap.SugaredLogger) (*commonmodels.DeliveryReleaseNotes, error) {
+	var baseVersion *commonmodels.DeliveryVersion
+	var err error
+	if baseVersionName != "" {
+		baseVersion, err = commonrepo.NewDeliveryVersionColl().Get(&commonrepo.DeliveryVersionArgs{
+			ProductName: version.ProductName,
+			Version:     baseVersionName,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find base version %s", baseVersionName)
+		}
+	} else {
+		baseVersion, err = commonrepo.NewDeliveryVersionColl().GetPrevious(version.ProductName, version.CreatedAt)
+		if err != nil {
+			if err != mongo.ErrNoDocuments {
+				return nil, errors.Wrap(err, "failed to find previous version")
+			}
+			baseVersion = nil
+		}
+	}
+
+	builder := &releaseNotesBuilder{
+		projectName: version.ProductName,
+		codehosts:   make(map[int]*systemconfig.CodeHost),
+		log:         log,
+	}
+	return builder.build(version, baseVersion), nil
+}
+
+type releaseNotesBuilder struct {
+	projectName string
+	codehosts   map[int]*systemconfig.CodeHost
+	jiraClient  *jira.Client
+	jiraHost    string
+	meegoClient *meego.Client
+	pmLoaded    bool
+	log         *zap.SugaredLogger
+}
+
+func versionImages(version *commonmodels.DeliveryVersion) map[string]*commonmodels.ReleaseNoteService {
+	ret := make(map[string]*commonmodels.ReleaseNoteService)
+	if version == nil || version.ProductEnvInfo == nil {
+		return ret
+	}
+	for _, group := range version.ProductEnvInfo.Services {
+		for _, svc := range group {
+			for _, container := range svc.Containers {
+				ret[svc.ServiceName+"/"+container.Name] = &commonmodels.ReleaseNoteService{
+					ServiceName:   svc.ServiceName,
+					ContainerName: container.Name,
+					Image:         container.Image,
+				}
+			}
+		}
+	}
+	return ret
+}
+
+func (b *releaseNotesBuilder) build(version, baseVersion *commonmodels.DeliveryVersion) *commonmodels.DeliveryReleaseNotes {
+	notes := &commonmodels.DeliveryReleaseNotes{
+		Services:  make([]*commonmodels.ReleaseNoteService, 0),
+		CreatedAt: time.Now().Unix(),
+	}
+	if baseVersion != nil {
+		notes.BaseVersion = baseVersion.Version
+	}
+
+	for _, svc := range changedServices(version, baseVersion) {
+		b.fillService(svc)
+		notes.Services = append(notes.Services, svc)
+	}
+
+	notes.Markdown = renderReleaseNotesMarkdown(version.Version, notes)
+	return notes
+}
+
+// changedServices returns the containers whose images differ from the base version, sorted by service and container.
+func changedServices(version, baseVersion *commonmodels.DeliveryVersion) []*commonmodels.ReleaseNoteService {
+	baseImages := versionImages(baseVersion)
+	images := versionImages(version)
+	keys := make([]string, 0, len(images))
+	for key := range images {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	ret := make([]*commonmodels.ReleaseNoteService, 0)
+	for _, key := range keys {
+		svc := images[key]
+		if base, ok := baseImages[key]; ok {
+			if base.Image == svc.Image {
+				continue
+			}
+			svc.PreviousImage = base.Image
+		}
+		ret = append(ret, svc)
+	}
+	return ret
+}
+
+func (b *releaseNotesBuilder) fillService(svc *commonmodels.ReleaseNoteService) {
+	svc.Commits = make([]*commonmodels.ReleaseNoteCommit, 0)
+	svc.PullRequests = make([]*commonmodels.ReleaseNotePR, 0)
+	svc.Issues = make([]*commonmodels.ReleaseNoteIssue, 0)
+
+	task, err := commonrepo.NewworkflowTaskv4Coll().FindLatestBuildTaskByImage(b.projectName, svc.Image)
+	if err != nil {
+		b.log.Infof("no build task found for image %s, err: %s", svc.Image, err)
+		return
+	}
+	svc.WorkflowName = task.WorkflowName
+	svc.TaskID = task.TaskID
+	svc.TaskURL = fmt.Sprintf("%s/v1/projects/detail/%s/pipelines/custom/%s/%d", configbase.SystemAddress(), task.ProjectName, task.WorkflowName, task.TaskID)
+
+	previousRepos := make(map[string]*types.Repository)
+	if svc.PreviousImage != "" {
+		previousTask, err := commonrepo.NewworkflowTaskv4Coll().FindLatestBuildTaskByImage(b.projectName, svc.PreviousImage)
+		if err == nil {
+			for _, repo := range previousTask.BuildRepos(svc.PreviousImage) {
+				previousRepos[repoKey(repo)] = repo
+			}
+		}
+	}
+
+	issues := make(map[string]*commonmodels.ReleaseNoteIssue)
+	var issueKeys []string
+	addIssue := func(issue *commonmodels.ReleaseNoteIssue) {
+		if _, ok := issues[issue.Source+issue.Key]; ok {
+			return
+		}
+		issues[issue.Source+issue.Key] = issue
+		issueKeys = append(issueKeys, issue.Source+issue.Key)
+	}
+
+	var texts []string
+	for _, repo := range task.BuildRepos(svc.Image) {
+		commits, prs := b.repoChanges(repo, previousRepos[repoKey(repo)])
+		svc.Commits = append(svc.Commits, commits...)
+		svc.PullRequests = append(svc.PullRequests, prs...)
+		for _, commit := range commits {
+			texts = append(texts, commit.Message)
+		}
+		for _, pr := range prs {
+			texts = append(texts, pr.Title)
+		}
+	}
+
+	for _, issue := range b.taskIssues(task) {
+		addIssue(issue)
+	}
+	for _, text := range texts {
+		for _, key := range jira.FindIssueKeys(text) {
+			if _, ok := issues[ReleaseNoteSourceJira+key]; ok {
+				continue
+			}
+			if issue := b.jiraIssue(key); issue != nil {
+				addIssue(issue)
+			}
+		}
+	}
+	for _, key := range issueKeys {
+		svc.Issues = append(svc.Issues, issues[key])
+	}
+}
+
+func repoKey(repo *types.Repository) string {
+	return fmt.Sprintf("%d/%s/%s", repo.CodehostID, repo.RepoOwner, repo.RepoName)
+}
+
+func (b *releaseNotesBuilder) codehost(id int) *systemconfig.CodeHost {
+	if ch, ok := b.codehosts[id]; ok {
+		return ch
+	}
+	ch, err := systemconfig.New().GetCodeHost(id)
+	if err != nil {
+		b.log.Warnf("failed to get codehost %d, err: %s", id, err)
+	}
+	b.codehosts[id] = ch
+	return ch
+}
+
+// repoChanges returns the commits between the previous build and the current one, only the head commit is returned
+// if the code host can not be queried.
+func (b *releaseNotesBuilder) repoChanges(repo, previous *types.Repository) ([]*commonmodels.ReleaseNoteCommit, []*commonmodels.ReleaseNotePR) {
+	if repo.CommitID == "" {
+		return nil, nil
+	}
+	if previous != nil && previous.CommitID == repo.CommitID {
+		return nil, nil
+	}
+
+	commitURL := func(commitID string) string {
+		return fmt.Sprintf("%s/%s/%s/commit/%s", repo.Address, repo.RepoOwner, repo.RepoName, commitID)
+	}
+	headCommit := []*commonmodels.ReleaseNoteCommit{{
+		RepoOwner: repo.RepoOwner,
+		RepoName:  repo.RepoName,
+		Branch:    repo.Branch,
+		CommitID:  repo.CommitID,
+		Message:   repo.CommitMessage,
+		Author:    repo.AuthorName,
+		URL:       commitURL(repo.CommitID),
+	}}
+
+	prIDs := repo.PRs
+	if len(prIDs) == 0 && repo.PR > 0 {
+		prIDs = []int{repo.PR}
+	}
+
+	ch := b.codehost(repo.CodehostID)
+	if ch == nil {
+		return headCommit, nil
+	}
+
+	commits := headCommit
+	prs := make([]*commonmodels.ReleaseNotePR, 0)
+	switch strings.ToLower(ch.Type) {
+	case setting.SourceFromGithub:
+		client := githubtool.NewClient(&githubtool.Config{AccessToken: ch.AccessToken, Proxy: config.ProxyHTTPSAddr()})
+		if previous != nil && previous.CommitID != "" {
+			compared, err := client.CompareCommits(context.TODO(), repo.RepoOwner, repo.RepoName, previous.CommitID, repo.CommitID)
+			if err != nil {
+				b.log.Warnf("failed to compare commits of %s/%s, err: %s", repo.RepoOwner, repo.RepoName, err)
+			} else {
+				commits = make([]*commonmodels.ReleaseNoteCommit, 0, len(compared))
+				for _, c := range compared {
+					commits = append(commits, &commonmodels.ReleaseNoteCommit{
+						RepoOwner: repo.RepoOwner,
+						RepoName:  repo.RepoName,
+						Branch:    repo.Branch,
+						CommitID:  c.GetSHA(),
+						Message:   c.GetCommit().GetMessage(),
+						Author:    c.GetCommit().GetAuthor().GetName(),
+						URL:       c.GetHTMLURL(),
+					})
+				}
+			}
+		}
+		for _, id := range prIDs {
+			pr, err := client.GetPullRequest(context.TODO(), repo.RepoOwner, repo.RepoName, id)
+			if err != nil {
+				b.log.Warnf("failed to get pull request %d of %s/%s, err: %s", id, repo.RepoOwner, repo.RepoName, err)
+				continue
+			}
+			prs = append(prs, &commonmodels.ReleaseNotePR{
+				RepoName: repo.RepoName,
+				Number:   id,
+				Title:    pr.GetTitle(),
+				URL:      pr.GetHTMLURL(),
+			})
+		}
+	case setting.SourceFromGitlab:
+		client, err := gitlabtool.NewClient(ch.ID, ch.Address, ch.AccessToken, config.ProxyHTTPSAddr(), ch.EnableProxy)
+		if err != nil {
+			b.log.Warnf("failed to create gitlab client, err: %s", err)
+			return headCommit, nil
+		}
+		if previous != nil && previous.CommitID != "" {
+			compared, err := client.CompareCommits(repo.RepoOwner, repo.RepoName, previous.CommitID, repo.CommitID)
+			if err != nil {
+				b.log.Warnf("failed to compare commits of %s/%s, err: %s", repo.RepoOwner, repo.RepoName, err)
+			} else {
+				commits = make([]*commonmodels.ReleaseNoteCommit, 0, len(compared))
+				for _, c := range compared {
+					commits = append(commits, &commonmodels.ReleaseNoteCommit{
+						RepoOwner: repo.RepoOwner,
+						RepoName:  repo.RepoName,
+						Branch:    repo.Branch,
+						CommitID:  c.ID,
+						Message:   c.Message,
+						Author:    c.AuthorName,
+						URL:       c.WebURL,
+					})
+				}
+			}
+		}
+		for _, id := range prIDs {
+			mr, err := client.GetMergeRequest(repo.RepoOwner, repo.RepoName, id)
+			if err != nil {
+				b.log.Warnf("failed to get merge request %d of %s/%s, err: %s", id, repo.RepoOwner, repo.RepoName, err)
+				continue
+			}
+			prs = append(prs, &commonmodels.ReleaseNotePR{
+				RepoName: repo.RepoName,
+				Number:   id,
+				Title:    mr.Title,
+				URL:      mr.WebURL,
+			})
+		}
+	default:
+		for _, id := range prIDs {
+			prs = append(prs, &commonmodels.ReleaseNotePR{
+				RepoName: repo.RepoName,
+				Number:   id,
+			})
+		}
+	}
+
+	return commits, prs
+}
+
+func (b *releaseNotesBuilder) loadProjectManagement() {
+	if b.pmLoaded {
+		return
+	}
+	b.pmLoaded = true
+
+	if info, err := commonrepo.NewProjectManagementColl().GetJira(); err == nil {
+		b.jiraClient = jira.NewJiraClient(info.JiraUser, info.JiraToken, info.JiraHost)
+		b.jiraHost = info.JiraHost
+	}
+	if info, err := commonrepo.NewProjectManagementColl().GetMeego(); err == nil {
+		client, err := meego.NewClient(info.MeegoHost, info.MeegoPluginID, info.MeegoPluginSecret, info.MeegoUserKey)
+		if err != nil {
+			b.log.Warnf("failed to create meego client, err: %s", err)
+		} else {
+			b.meegoClient = client
+		}
+	}
+}
+
+// jiraIssue looks up the issue in jira, nil is returned if jira is not integrated or the issue does not exist.
+func (b *releaseNotesBuilder) jiraIssue(key string) *commonmodels.ReleaseNoteIssue {
+	b.loadProjectManagement()
+	if b.jiraClient == nil {
+		return nil
+	}
+	issue, err := b.jiraClient.Issue.GetByKeyOrID(key, "summary,status")
+	if err != nil || issue == nil || issue.Key == "" {
+		return nil
+	}
+	ret := &commonmodels.ReleaseNoteIssue{
+		Source: ReleaseNoteSourceJira,
+		Key:    issue.Key,
+		URL:    fmt.Sprintf("%s/browse/%s", strings.TrimSuffix(b.jiraHost, "/"), issue.Key),
+	}
+	if issue.Fields != nil {
+		ret.Title = issue.Fields.Summary
+		if issue.Fields.Status != nil {
+			ret.Status = issue.Fields.Status.Name
+		}
+	}
+	return ret
+}
+
+// taskIssues returns the jira issues and meego work items handled by the jobs of the task
+func (b *releaseNotesBuilder) taskIssues(task *commonmodels.WorkflowTask) []*commonmodels.ReleaseNoteIssue {
+	ret := make([]*commonmodels.ReleaseNoteIssue, 0)
+	for _, stage := range task.Stages {
+		for _, job := range stage.Jobs {
+			switch job.JobType {
+			case string(config.JobJira):
+				jobSpec := &commonmodels.JobTaskJiraSpec{}
+				if err := commonmodels.IToi(job.Spec, jobSpec); err != nil {
+					continue
+				}
+				for _, issue := range jobSpec.Issues {
+					if detail := b.jiraIssue(issue.Key); detail != nil {
+						ret = append(ret, detail)
+						continue
+					}
+					ret = append(ret, &commonmodels.ReleaseNoteIssue{
+						Source: ReleaseNoteSourceJira,
+						Key:    issue.Key,
+						Title:  issue.Name,
+						Status: issue.Status,
+						URL:    issue.Link,
+					})
+				}
+			case string(config.JobMeegoTransition):
+				jobSpec := &commonmodels.MeegoTransitionSpec{}
+				if err := commonmodels.IToi(job.Spec, jobSpec); err != nil {
+					continue
+				}
+				b.loadProjectManagement()
+				for _, item := range jobSpec.WorkItems {
+					issue := &commonmodels.ReleaseNoteIssue{
+						Source: ReleaseNoteSourceMeego,
+						Key:    fmt.Sprintf("%d", item.ID),
+						Title:  item.Name,
+						Status: item.Status,
+						URL:    fmt.Sprintf("%s/%s/%s/detail/%d", strings.TrimSuffix(jobSpec.Link, "/"), jobSpec.ProjectKey, jobSpec.WorkItemTypeKey, item.ID),
+					}
+					if b.meegoClient != nil {
+						detail, err := b.meegoClient.GetWorkItem(jobSpec.ProjectKey, jobSpec.WorkItemTypeKey, item.ID)
+						if err == nil && detail != nil {
+							issue.Title = detail.Name
+							if detail.WorkItemStatus != nil {
+								issue.Status = detail.WorkItemStatus.StateKey
+							}
+						}
+					}
+					ret = append(ret, issue)
+				}
+			}
+		}
+	}
+	return ret
+}
+
+func renderReleaseNotesMarkdown(version string, notes *commonmodels.DeliveryReleaseNotes) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Release Notes: %s\n\n", version)
+	if notes.BaseVersion != "" {
+		fmt.Fprintf(&sb, "Compared with version %s.\n", notes.BaseVersion)
+	} else {
+		sb.WriteString("No previous version to compare with.\n")
+	}
+	if len(notes.Services) == 0 {
+		sb.WriteString("\nNo image changed.\n")
+		return sb.String()
+	}
+
+	for _, svc := range notes.Services {
+		fmt.Fprintf(&sb, "\n## %s/%s\n\n", svc.ServiceName, svc.ContainerName)
+		if svc.PreviousImage != "" {
+			fmt.Fprintf(&sb, "- Image: `%s` -> `%s`\n", svc.PreviousImage, svc.Image)
+		} else {
+			fmt.Fprintf(&sb, "- Image: `%s`\n", svc.Image)
+		}
+		if svc.TaskURL != "" {
+			fmt.Fprintf(&sb, "- Build: [%s #%d](%s)\n", svc.WorkflowName, svc.TaskID, svc.TaskURL)
+		}
+
+		if len(svc.Commits) > 0 {
+			sb.WriteString("\n### Commits\n\n")
+			for _, commit := range svc.Commits {
+				shortID := commit.CommitID
+				if len(shortID) > 8 {
+					shortID = shortID[:8]
+				}
+				message := strings.TrimSpace(strings.SplitN(commit.Message, "\n", 2)[0])
+				fmt.Fprintf(&sb, "- [%s](%s) %s", shortID, commit.URL, message)
+				if commit.Author != "" {
+					fmt.Fprintf(&sb, " (%s)", commit.Author)
+				}
+				sb.WriteString("\n")
+			}
+		}
+		if len(svc.PullRequests) > 0 {
+			sb.WriteString("\n### Pull Requests\n\n")
+			for _, pr := range svc.PullRequests {
+				if pr.URL != "" {
+					fmt.Fprintf(&sb, "- [%s#%d](%s) %s\n", pr.RepoName, pr.Number, pr.URL, pr.Title)
+				} else {
+					fmt.Fprintf(&sb, "- %s#%d %s\n", pr.RepoName, pr.Number, pr.Title)
+				}
+			}
+		}
+		if len(svc.Issues) > 0 {
+			sb.WriteString("\n### Issues\n\n")
+			for _, issue := range svc.Issues {
+				fmt.Fprintf(&sb, "- [%s](%s) %s", issue.Key, issue.URL, issue.Title)
+				if issue.Status != "" {
+					fmt.Fprintf(&sb, " [%s]", issue.Status)
+				}
+				sb.WriteString("\n")
+			}
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"strings"
+	"testing"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+func newTestVersion(name string, images map[string]string) *commonmodels.DeliveryVersion {
+	svc := &commonmodels.ProductService{ServiceName: "api"}
+	for container, image := range images {
+		svc.Containers = append(svc.Containers, &commonmodels.Container{Name: container, Image: image})
+	}
+	return &commonmodels.DeliveryVersion{
+		Version:        name,
+		ProductEnvInfo: &commonmodels.Product{Services: [][]*commonmodels.ProductService{{svc}}},
+	}
+}
+
+func TestChangedServices(t *testing.T) {
+	base := newTestVersion("v1", map[string]string{"server": "api:1", "worker": "worker:1"})
+	version := newTestVersion("v2", map[string]string{"server": "api:2", "worker": "worker:1", "migrate": "migrate:2"})
+
+	changed := changedServices(version, base)
+	if len(changed) != 2 {
+		t.Fatalf("expected 2 changed containers, got %d", len(changed))
+	}
+	if svc := changed[0]; svc.ContainerName != "migrate" || svc.Image != "migrate:2" || svc.PreviousImage != "" {
+		t.Errorf("unexpected new container %+v", svc)
+	}
+	if svc := changed[1]; svc.ContainerName != "server" || svc.Image != "api:2" || svc.PreviousImage != "api:1" {
+		t.Errorf("unexpected changed container %+v", svc)
+	}
+
+	if changed := changedServices(version, nil); len(changed) != 3 {
+		t.Errorf("all containers should be changed without a base version, got %d", len(changed))
+	}
+}
+
+func TestRenderReleaseNotesMarkdown(t *testing.T) {
+	notes := &commonmodels.DeliveryReleaseNotes{
+		BaseVersion: "v1",
+		Services: []*commonmodels.ReleaseNoteService{{
+			ServiceName:   "api",
+			ContainerName: "server",
+			PreviousImage: "api:1",
+			Image:         "api:2",
+			Commits: []*commonmodels.ReleaseNoteCommit{{
+				CommitID: "0123456789abcdef",
+				Message:  "fix login\n\nlong description",
+				Author:   "jane",
+				URL:      "https://example.com/commit/0123456789abcdef",
+			}},
+			PullRequests: []*commonmodels.ReleaseNotePR{{RepoName: "api", Number: 7, Title: "Fix login"}},
+			Issues:       []*commonmodels.ReleaseNoteIssue{{Key: "ZAD-1", URL: "https://jira/browse/ZAD-1", Title: "login fails", Status: "Done"}},
+		}},
+	}
+
+	markdown := renderReleaseNotesMarkdown("v2", notes)
+	for _, expected := range []string{
+		"# Release Notes: v2",
+		"Compared with version v1.",
+		"- Image: `api:1` -> `api:2`",
+		"- [01234567](https://example.com/commit/0123456789abcdef) fix login (jane)",
+		"- api#7 Fix login",
+		"- [ZAD-1](https://jira/browse/ZAD-1) login fails [Done]",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("markdown does not contain %q:\n%s", expected, markdown)
+		}
+	}
+	if strings.Contains(markdown, "long description") {
+		t.Errorf("only the first line of the commit message should be rendered")
+	}
+
+	empty := renderReleaseNotesMarkdown("v1", &commonmodels.DeliveryReleaseNotes{})
+	if !strings.Contains(empty, "No previous version to compare with.") || !strings.Contains(empty, "No image changed.") {
+		t.Errorf("unexpected markdown of empty notes:\n%s", empty)
+	}
+}
@@ -49,6 +49,7 @@ import (
 	templaterepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb/template"
 	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/base"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/cloudevent"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/kube"
 	workflowservice "github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow"
 	"github.com/koderover/zadig/pkg/setting"
@@ -211,13 +212,14 @@ type DeliveryVersionPayloadChart struct {
 }
 
 type DeliveryVersionHookPayload struct {
-	ProjectName string                         `json:"project_name"`
-	Version     string                         `json:"version"`
-	Status      string                         `json:"status"`
-	Error       string                         `json:"error"`
-	StartTime   int64                          `json:"start_time"`
-	EndTime     int64                          `json:"end_time"`
-	Charts      []*DeliveryVersionPayloadChart `json:"charts"`
+	ProjectName  string                             `json:"project_name"`
+	Version      string                             `json:"version"`
+	Status       string                             `json:"status"`
+	Error        string                             `json:"error"`
+	StartTime    int64                              `json:"start_time"`
+	EndTime      int64                              `json:"end_time"`
+	Charts       []*DeliveryVersionPayloadChart     `json:"charts"`
+	ReleaseNotes *commonmodels.DeliveryReleaseNotes `json:"release_notes,omitempty"`
 }
 
 func GetDeliveryVersion(args *commonrepo.DeliveryVersionArgs, log *zap.SugaredLogger) (*commonmodels.DeliveryVersion, error) {
@@ -1028,13 +1030,14 @@ func buildDeliveryCharts(chartDataMap map[string]*DeliveryChartData, deliveryVer
 func sendVersionDeliveryHook(deliveryVersion *commonmodels.DeliveryVersion, host, urlPath string) error {
 	projectName, version := deliveryVersion.ProductName, deliveryVersion.Version
 	ret := &DeliveryVersionHookPayload{
-		ProjectName: projectName,
-		Version:     version,
-		Status:      setting.DeliveryVersionStatusSuccess,
-		Error:       "",
-		StartTime:   deliveryVersion.CreatedAt,
-		EndTime:     time.Now().Unix(),
-		Charts:      make([]*DeliveryVersionPayloadChart, 0),
+		ProjectName:  projectName,
+		Version:      version,
+		Status:       setting.DeliveryVersionStatusSuccess,
+		Error:        "",
+		StartTime:    deliveryVersion.CreatedAt,
+		EndTime:      time.Now().Unix(),
+		Charts:       make([]*DeliveryVersionPayloadChart, 0),
+		ReleaseNotes: deliveryVersion.ReleaseNotes,
 	}
 
 	//distributes image + chart
@@ -1124,6 +1127,10 @@ func updateVersionStatus(versionName, projectName, status, errStr string) {
 			return
 		}
 
+		if _, err := generateReleaseNotes(versionInfo, "", log.SugaredLogger()); err != nil {
+			log.Errorf("updateVersionStatus failed to generate release notes, version: %s, projectName: %s, err: %s", versionName, projectName, err)
+		}
+
 		templateProduct, err := templaterepo.NewProductColl().Find(projectName)
 		if err != nil {
 			log.Errorf("updateVersionStatus failed to find template product: %s, err: %s", projectName, err)
@@ -1136,6 +1143,7 @@ func updateVersionStatus(versionName, projectName, status, errStr string) {
 				}
 			}
 		}
+		cloudevent.PublishDeliveryVersionEvent(cloudevent.TypeDeliveryVersionCreated, versionInfo)
 	}
 
 	err := commonrepo.NewDeliveryVersionColl().UpdateStatusByName(versionName, projectName, status, errStr)
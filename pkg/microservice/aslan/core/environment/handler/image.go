@@ -61,6 +61,7 @@ func UpdateStatefulSetContainerImage(c *gin.Context) {
 		return
 	}
 
+	args.UpdateBy = ctx.UserName
 	ctx.Err = service.UpdateContainerImage(ctx.RequestID, args, ctx.Logger)
 }
 
@@ -92,5 +93,74 @@ func UpdateDeploymentContainerImage(c *gin.Context) {
 		return
 	}
 
+	args.UpdateBy = ctx.UserName
+	ctx.Err = service.UpdateContainerImage(ctx.RequestID, args, ctx.Logger)
+}
+
+func UpdateDaemonSetContainerImage(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(service.UpdateContainerImageArgs)
+	args.Type = setting.DaemonSet
+
+	data, err := c.GetRawData()
+	if err != nil {
+		log.Errorf("UpdateDaemonSetContainerImage c.GetRawData() err : %v", err)
+		return
+	}
+	if err = json.Unmarshal(data, args); err != nil {
+		log.Errorf("UpdateDaemonSetContainerImage json.Unmarshal err : %v", err)
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(
+		c, ctx.UserName, args.ProductName, setting.OperationSceneEnv,
+		"更新", "环境-服务镜像",
+		fmt.Sprintf("环境名称:%s,服务名称:%s,DaemonSet:%s", args.EnvName, args.ServiceName, args.Name),
+		string(data), ctx.Logger, args.EnvName)
+
+	c.Request.Body = io.NopCloser(bytes.NewBuffer(data))
+
+	if err := c.BindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+
+	args.UpdateBy = ctx.UserName
+	ctx.Err = service.UpdateContainerImage(ctx.RequestID, args, ctx.Logger)
+}
+
+func UpdateCronJobContainerImage(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(service.UpdateContainerImageArgs)
+	args.Type = setting.CronJob
+
+	data, err := c.GetRawData()
+	if err != nil {
+		log.Errorf("UpdateCronJobContainerImage c.GetRawData() err : %v", err)
+		return
+	}
+	if err = json.Unmarshal(data, args); err != nil {
+		log.Errorf("UpdateCronJobContainerImage json.Unmarshal err : %v", err)
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(
+		c, ctx.UserName, args.ProductName, setting.OperationSceneEnv,
+		"更新", "环境-服务镜像",
+		fmt.Sprintf("环境名称:%s,服务名称:%s,CronJob:%s", args.EnvName, args.ServiceName, args.Name),
+		string(data), ctx.Logger, args.EnvName)
+
+	c.Request.Body = io.NopCloser(bytes.NewBuffer(data))
+
+	if err := c.BindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+
+	args.UpdateBy = ctx.UserName
 	ctx.Err = service.UpdateContainerImage(ctx.RequestID, args, ctx.Logger)
 }
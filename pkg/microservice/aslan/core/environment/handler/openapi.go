@@ -0,0 +1,229 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/environment/service"
+	"github.com/koderover/zadig/pkg/setting"
+	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+// OpenAPIListEnvs
+// @Router /openapi/v1/environments [GET]
+// @Summary List the environments of a project
+// @Param projectName query string true "Name of the project"
+// @Produce json
+// @Success 200 {array} service.OpenAPIEnvBrief
+func OpenAPIListEnvs(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+
+	ctx.Resp, ctx.Err = service.OpenAPIListEnvs(projectName, ctx.Logger)
+}
+
+// OpenAPIGetEnv
+// @Router /openapi/v1/environments/{name} [GET]
+// @Summary Get the environment with its services and containers
+// @Param name path string true "Name of the environment"
+// @Param projectName query string true "Name of the project"
+// @Produce json
+// @Success 200 {object} service.OpenAPIEnvDetail
+func OpenAPIGetEnv(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+
+	ctx.Resp, ctx.Err = service.OpenAPIGetEnv(ctx.UserName, projectName, c.Param("name"), ctx.Logger)
+}
+
+// OpenAPICreateEnv
+// @Router /openapi/v1/environments [POST]
+// @Summary Create an environment from the latest service templates or from a base environment
+// @Accept json
+// @Param projectName query string true "Name of the project"
+// @Param body body service.OpenAPICreateEnvReq true "Environment to create"
+// @Produce json
+// @Success 200
+func OpenAPICreateEnv(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(service.OpenAPICreateEnvReq)
+	if err := c.BindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+	args.ProjectName = c.Query("projectName")
+
+	isValid, err := args.Validate()
+	if !isValid {
+		ctx.Err = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName+"(openAPI)", args.ProjectName, setting.OperationSceneEnv, "新增", "环境", args.EnvName, "", ctx.Logger, args.EnvName)
+	ctx.Err = service.OpenAPICreateEnv(ctx.UserName, ctx.RequestID, args, ctx.Logger)
+}
+
+// OpenAPIDeleteEnv
+// @Router /openapi/v1/environments/{name} [DELETE]
+// @Summary Delete the environment
+// @Param name path string true "Name of the environment"
+// @Param projectName query string true "Name of the project"
+// @Param isDelete query bool false "Whether to delete the namespace of the environment"
+// @Produce json
+// @Success 200
+func OpenAPIDeleteEnv(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	envName := c.Param("name")
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+	isDelete := false
+	if c.Query("isDelete") != "" {
+		var err error
+		if isDelete, err = strconv.ParseBool(c.Query("isDelete")); err != nil {
+			ctx.Err = e.ErrInvalidParam.AddDesc("invalidParam isDelete")
+			return
+		}
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName+"(openAPI)", projectName, setting.OperationSceneEnv, "删除", "环境", envName, "", ctx.Logger, envName)
+	ctx.Err = service.DeleteProduct(ctx.UserName, envName, projectName, ctx.RequestID, isDelete, ctx.Logger)
+}
+
+// OpenAPIUpdateServiceImages
+// @Router /openapi/v1/environments/{name}/images [POST]
+// @Summary Update the images of service containers in the environment
+// @Accept json
+// @Param name path string true "Name of the environment"
+// @Param projectName query string true "Name of the project"
+// @Param body body service.OpenAPIUpdateServiceImagesReq true "Images to update"
+// @Produce json
+// @Success 200
+func OpenAPIUpdateServiceImages(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(service.OpenAPIUpdateServiceImagesReq)
+	if err := c.BindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+	args.ProjectName = c.Query("projectName")
+	args.UpdateBy = ctx.UserName + "(openAPI)"
+
+	isValid, err := args.Validate()
+	if !isValid {
+		ctx.Err = e.ErrInvalidParam.AddDesc(err.Error())
+		return
+	}
+
+	envName := c.Param("name")
+	for _, image := range args.Images {
+		internalhandler.InsertDetailedOperationLog(c, ctx.UserName+"(openAPI)", args.ProjectName, setting.OperationSceneEnv, "更新", "环境-服务镜像",
+			fmt.Sprintf("环境名称:%s,服务名称:%s,容器名称:%s,镜像:%s", envName, image.ServiceName, image.ContainerName, image.Image), "", ctx.Logger, envName)
+	}
+	ctx.Err = service.OpenAPIUpdateServiceImages(ctx.RequestID, envName, args, ctx.Logger)
+}
+
+// OpenAPIRestartService
+// @Router /openapi/v1/environments/{name}/services/{serviceName}/restart [POST]
+// @Summary Restart the service in the environment
+// @Param name path string true "Name of the environment"
+// @Param serviceName path string true "Name of the service"
+// @Param projectName query string true "Name of the project"
+// @Produce json
+// @Success 200
+func OpenAPIRestartService(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	envName := c.Param("name")
+	serviceName := c.Param("serviceName")
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName+"(openAPI)", projectName, setting.OperationSceneEnv,
+		"重启", "环境-服务", fmt.Sprintf("环境名称:%s,服务名称:%s", envName, serviceName), "", ctx.Logger, envName)
+	ctx.Err = service.OpenAPIRestartService(ctx.UserName, projectName, envName, serviceName, ctx.Logger)
+}
+
+// OpenAPIGetEnvVariables
+// @Router /openapi/v1/environments/{name}/variables [GET]
+// @Summary Get the global variables and the service variables of the environment
+// @Param name path string true "Name of the environment"
+// @Param projectName query string true "Name of the project"
+// @Produce json
+// @Success 200 {object} service.OpenAPIEnvVariables
+func OpenAPIGetEnvVariables(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+
+	ctx.Resp, ctx.Err = service.OpenAPIGetEnvVariables(projectName, c.Param("name"), ctx.Logger)
+}
+
+// OpenAPIGetServiceStatus
+// @Router /openapi/v1/environments/{name}/services/{serviceName} [GET]
+// @Summary Get the status and the workloads of the service in the environment
+// @Param name path string true "Name of the environment"
+// @Param serviceName path string true "Name of the service"
+// @Param projectName query string true "Name of the project"
+// @Produce json
+// @Success 200 {object} service.OpenAPIServiceStatus
+func OpenAPIGetServiceStatus(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectName := c.Query("projectName")
+	if projectName == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("projectName can not be empty")
+		return
+	}
+
+	ctx.Resp, ctx.Err = service.OpenAPIGetServiceStatus(projectName, c.Param("name"), c.Param("serviceName"), ctx.Logger)
+}
@@ -47,5 +47,5 @@ func ConnectSshPmExec(c *gin.Context) {
 		ctx.Err = e.ErrInvalidParam.AddErr(err)
 	}
 
-	ctx.Err = service.ConnectSshPmExec(c, ctx.UserName, name, projectName, ip, hostId, cols, rows, ctx.Logger)
+	ctx.Err = service.ConnectSshPmExec(c, ctx.UserName, ctx.UserID, name, projectName, ip, hostId, cols, rows, ctx.Logger)
 }
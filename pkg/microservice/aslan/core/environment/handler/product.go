@@ -34,6 +34,13 @@ func CleanProductCronJob(c *gin.Context) {
 	service.CleanProductCronJob(ctx.RequestID, ctx.Logger)
 }
 
+func CleanPreviewEnvCronJob(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	service.CleanPreviewEnvCronJob(ctx.RequestID, ctx.Logger)
+}
+
 func GetInitProduct(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
@@ -62,6 +62,7 @@ func (*Router) Inject(router *gin.RouterGroup) {
 	cron := router.Group("cron")
 	{
 		cron.GET("/cleanproduct", CleanProductCronJob)
+		cron.GET("/cleanpreviewenv", CleanPreviewEnvCronJob)
 	}
 
 	// ---------------------------------------------------------------------------------------
@@ -88,6 +89,8 @@ func (*Router) Inject(router *gin.RouterGroup) {
 	{
 		image.POST("/deployment/:envName", UpdateDeploymentContainerImage)
 		image.POST("/statefulset/:envName", UpdateStatefulSetContainerImage)
+		image.POST("/daemonset/:envName", UpdateDaemonSetContainerImage)
+		image.POST("/cronjob/:envName", UpdateCronJobContainerImage)
 	}
 
 	// 查询环境创建时的服务和变量信息
@@ -170,6 +173,8 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		environments.POST("/:name/services/:serviceName/restart", RestartService)
 		environments.POST("/:name/services/:serviceName/restartNew", RestartWorkload)
 		environments.POST("/:name/services/:serviceName/scaleNew", ScaleNewService)
+		environments.POST("/:name/services/:serviceName/suspendCronJob", SuspendCronJob)
+		environments.GET("/:name/services/:serviceName/cronJobHistory", ListCronJobHistory)
 		environments.GET("/:name/services/:serviceName/containers/:container", GetServiceContainer)
 
 		environments.GET("/:name/estimated-renderchart", GetEstimatedRenderCharts)
@@ -179,6 +184,10 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		environments.DELETE("/:name/share/enable", DisableBaseEnv)
 		environments.GET("/:name/check/sharenv/:op/ready", CheckShareEnvReady)
 
+		environments.GET("/:name/gitops", GetEnvGitOpsStatus)
+		environments.PUT("/:name/gitops", UpdateEnvGitOps)
+		environments.POST("/:name/gitops/sync", SyncEnvToGit)
+
 		environments.GET("/:name/services/:serviceName/pmexec", ConnectSshPmExec)
 
 		environments.POST("/:name/services/:serviceName/devmode/patch", PatchWorkload)
@@ -210,3 +219,16 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		bundles.GET("", GetBundleResources)
 	}
 }
+
+type OpenAPIRouter struct{}
+
+func (*OpenAPIRouter) Inject(router *gin.RouterGroup) {
+	router.GET("", OpenAPIListEnvs)
+	router.POST("", OpenAPICreateEnv)
+	router.GET("/:name", OpenAPIGetEnv)
+	router.DELETE("/:name", OpenAPIDeleteEnv)
+	router.GET("/:name/variables", OpenAPIGetEnvVariables)
+	router.POST("/:name/images", OpenAPIUpdateServiceImages)
+	router.GET("/:name/services/:serviceName", OpenAPIGetServiceStatus)
+	router.POST("/:name/services/:serviceName/restart", OpenAPIRestartService)
+}
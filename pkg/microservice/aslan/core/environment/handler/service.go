@@ -153,6 +153,57 @@ func ScaleNewService(c *gin.Context) {
 	}, ctx.Logger)
 }
 
+func SuspendCronJob(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectName := c.Query("projectName")
+	envName := c.Param("name")
+	name := c.Query("name")
+
+	suspend, err := strconv.ParseBool(c.Query("suspend"))
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc("invalid suspend format")
+		return
+	}
+
+	internalhandler.InsertDetailedOperationLog(
+		c, ctx.UserName,
+		projectName, setting.OperationSceneEnv,
+		suspendOperation(suspend),
+		"环境-服务",
+		fmt.Sprintf("环境名称:%s,%s:%s", envName, setting.CronJob, name),
+		"", ctx.Logger, envName)
+
+	ctx.Err = service.SuspendCronJob(&service.SuspendCronJobArgs{
+		ProductName: projectName,
+		EnvName:     envName,
+		ServiceName: c.Param("serviceName"),
+		Name:        name,
+		Suspend:     suspend,
+	}, ctx.Logger)
+}
+
+func suspendOperation(suspend bool) string {
+	if suspend {
+		return "暂停"
+	}
+	return "恢复"
+}
+
+func ListCronJobHistory(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	name := c.Query("name")
+	if name == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("name can't be empty")
+		return
+	}
+
+	ctx.Resp, ctx.Err = service.ListCronJobHistory(c.Param("name"), c.Query("projectName"), c.Param("serviceName"), name, ctx.Logger)
+}
+
 func GetServiceContainer(c *gin.Context) {
 	ctx := internalhandler.NewContext(c)
 	defer func() { internalhandler.JSONResponse(c, ctx) }()
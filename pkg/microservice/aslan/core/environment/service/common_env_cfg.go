@@ -92,6 +92,9 @@ func DeleteCommonEnvCfg(envName, productName, objectName string, commonEnvCfgTyp
 	if err != nil {
 		return e.ErrDeleteResource.AddErr(err)
 	}
+	if err := checkNotGitOps(product); err != nil {
+		return e.ErrDeleteResource.AddErr(err)
+	}
 
 	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), product.ClusterID)
 	if err != nil {
@@ -240,6 +243,9 @@ func CreateCommonEnvCfg(args *models.CreateUpdateCommonEnvCfgArgs, userName stri
 	if err != nil {
 		return e.ErrUpdateResource.AddErr(err)
 	}
+	if err := checkNotGitOps(product); err != nil {
+		return e.ErrUpdateResource.AddErr(err)
+	}
 
 	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), product.ClusterID)
 	if err != nil {
@@ -557,6 +563,9 @@ func SyncEnvResource(args *SyncEnvResourceArg, log *zap.SugaredLogger) error {
 	if err != nil {
 		return e.ErrUpdateResource.AddErr(fmt.Errorf("failed to find product: %s:%s, err: %s", args.ProductName, args.EnvName, err))
 	}
+	if err := checkNotGitOps(product); err != nil {
+		return e.ErrUpdateResource.AddErr(err)
+	}
 	envResource, err := commonrepo.NewEnvResourceColl().Find(&commonrepo.QueryEnvResourceOption{
 		ProductName: args.ProductName,
 		EnvName:     args.EnvName,
@@ -197,6 +197,9 @@ func UpdateConfigMap(args *models.CreateUpdateCommonEnvCfgArgs, userName string,
 	if err != nil {
 		return e.ErrUpdateConfigMap.AddErr(err)
 	}
+	if err := checkNotGitOps(product); err != nil {
+		return e.ErrUpdateConfigMap.AddErr(err)
+	}
 
 	namespace := product.Namespace
 
@@ -264,6 +267,9 @@ func RollBackConfigMap(envName string, args *RollBackConfigMapArgs, userName, us
 	if err != nil {
 		return e.ErrUpdateConfigMap.AddErr(err)
 	}
+	if err := checkNotGitOps(product); err != nil {
+		return e.ErrUpdateConfigMap.AddErr(err)
+	}
 	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), product.ClusterID)
 	if err != nil {
 		return e.ErrUpdateConfigMap.AddErr(err)
@@ -54,6 +54,7 @@ import (
 	mongotemplate "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb/template"
 	templaterepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb/template"
 	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/cloudevent"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/collaboration"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/kube"
 	commonutil "github.com/koderover/zadig/pkg/microservice/aslan/core/common/util"
@@ -377,6 +378,11 @@ func getServicesWithMaxRevision(projectName string) ([]*commonmodels.Service, er
 // TODO need optimize
 // cvm and k8s yaml projects should not be handled together
 func updateProductImpl(updateRevisionSvcs []string, deployStrategy map[string]string, existedProd, updateProd *commonmodels.Product, renderSet *commonmodels.RenderSet, filter svcUpgradeFilter, log *zap.SugaredLogger) (err error) {
+	defer func() {
+		if err == nil {
+			cloudevent.PublishEnvironmentEvent(cloudevent.TypeEnvironmentUpdated, updateProd, updateProd.UpdateBy)
+		}
+	}()
 	oldProductRender := existedProd.Render
 	updateProd.Render = &commonmodels.RenderInfo{
 		Name:        renderSet.Name,
@@ -447,6 +453,10 @@ func updateProductImpl(updateRevisionSvcs []string, deployStrategy map[string]st
 	// 1. 如果服务待删除：将产品模板中已经不存在，产品环境中待删除的服务进行删除。
 	for _, serviceRev := range prodRevs.ServiceRevisions {
 		if serviceRev.Updatable && serviceRev.Deleted && util.InStringArray(serviceRev.ServiceName, updateRevisionSvcs) {
+			if existedProd.IsGitOps() {
+				deletedServices = append(deletedServices, serviceRev.ServiceName)
+				continue
+			}
 			log.Infof("[%s][P:%s][S:%s] start to delete service", envName, productName, serviceRev.ServiceName)
 			//根据namespace: EnvName, selector: productName + serviceName来删除属于该服务的所有资源
 			selector := labels.Set{setting.ProductLabel: productName, setting.ServiceLabel: serviceRev.ServiceName}.AsSelector()
@@ -476,6 +486,7 @@ func updateProductImpl(updateRevisionSvcs []string, deployStrategy map[string]st
 	updateProd.Status = setting.ProductStatusUpdating
 	//updateProd.Services = updatedServices
 	updateProd.ShareEnv = existedProd.ShareEnv
+	updateProd.GitOps = existedProd.GitOps
 
 	if err := commonrepo.NewProductColl().UpdateStatus(envName, productName, setting.ProductStatusUpdating); err != nil {
 		log.Errorf("[%s][P:%s] Product.UpdateStatus error: %v", envName, productName, err)
@@ -708,7 +719,11 @@ func CreateProduct(user, requestID string, args *commonmodels.Product, log *zap.
 	log.Infof("[%s][P:%s] CreateProduct", args.EnvName, args.ProductName)
 	creator := getCreatorBySource(args.Source)
 	args.UpdateBy = user
-	return creator.Create(user, requestID, args, log)
+	if err = creator.Create(user, requestID, args, log); err != nil {
+		return err
+	}
+	cloudevent.PublishEnvironmentEvent(cloudevent.TypeEnvironmentCreated, args, user)
+	return nil
 }
 
 func UpdateProductRecycleDay(envName, productName string, recycleDay int) error {
@@ -1473,6 +1488,8 @@ func updateHelmProductVariable(productResp *commonmodels.Product, renderset *com
 			// 发送更新产品失败消息给用户
 			title := fmt.Sprintf("更新 [%s] 的 [%s] 环境失败", productName, envName)
 			commonservice.SendErrorMessage(userName, title, requestID, err, log)
+		} else {
+			cloudevent.PublishEnvironmentEvent(cloudevent.TypeEnvironmentUpdated, productResp, userName)
 		}
 		productResp.Status = setting.ProductStatusSuccess
 		if err = commonrepo.NewProductColl().UpdateStatusAndError(envName, productName, productResp.Status, ""); err != nil {
@@ -1691,6 +1708,10 @@ func DeleteProduct(username, envName, productName, requestID string, isDelete bo
 		log.Errorf("find product error: %v", err)
 		return err
 	}
+	// the resources would be recreated by the gitops tool, the gitops mode must be disabled first
+	if err := checkNotGitOps(productInfo); err != nil {
+		return e.ErrDeleteEnv.AddErr(err)
+	}
 
 	// delete informer's cache
 	informer.DeleteInformer(productInfo.ClusterID, productInfo.Namespace)
@@ -1747,6 +1768,7 @@ func DeleteProduct(username, envName, productName, requestID string, isDelete bo
 					title := fmt.Sprintf("删除项目:[%s] 环境:[%s] 成功!", productName, envName)
 					content := fmt.Sprintf("namespace:%s", productInfo.Namespace)
 					commonservice.SendMessage(username, title, content, requestID, log)
+					cloudevent.PublishEnvironmentEvent(cloudevent.TypeEnvironmentDeleted, productInfo, username)
 				}
 			}()
 
@@ -1778,6 +1800,8 @@ func DeleteProduct(username, envName, productName, requestID string, isDelete bo
 		err = commonrepo.NewProductColl().Delete(envName, productName)
 		if err != nil {
 			log.Errorf("Product.Delete error: %v", err)
+		} else {
+			cloudevent.PublishEnvironmentEvent(cloudevent.TypeEnvironmentDeleted, productInfo, username)
 		}
 
 		tempProduct, err := mongotemplate.NewProductColl().Find(productName)
@@ -1837,6 +1861,8 @@ func DeleteProduct(username, envName, productName, requestID string, isDelete bo
 		err = commonrepo.NewProductColl().Delete(envName, productName)
 		if err != nil {
 			log.Errorf("Product.Delete error: %v", err)
+		} else {
+			cloudevent.PublishEnvironmentEvent(cloudevent.TypeEnvironmentDeleted, productInfo, username)
 		}
 	default:
 		go func() {
@@ -1854,6 +1880,7 @@ func DeleteProduct(username, envName, productName, requestID string, isDelete bo
 					title := fmt.Sprintf("删除项目:[%s] 环境:[%s] 成功!", productName, envName)
 					content := fmt.Sprintf("namespace:%s", productInfo.Namespace)
 					commonservice.SendMessage(username, title, content, requestID, log)
+					cloudevent.PublishEnvironmentEvent(cloudevent.TypeEnvironmentDeleted, productInfo, username)
 				}
 			}()
 			if isDelete && !productInfo.Production {
@@ -1900,6 +1927,9 @@ func DeleteProductServices(userName, requestID, envName, productName string, ser
 	if getProjectType(productName) == setting.HelmDeployType {
 		return deleteHelmProductServices(userName, requestID, productInfo, serviceNames, log)
 	}
+	if err := checkNotGitOps(productInfo); err != nil {
+		return e.ErrUpdateEnv.AddErr(err)
+	}
 	return deleteK8sProductServices(productInfo, serviceNames, log)
 }
 
@@ -2323,6 +2353,11 @@ func upsertService(env *commonmodels.Product, service *commonmodels.ProductServi
 		resources = append(resources, u)
 	}
 
+	// manifests of gitops environments are committed to git after the update, see ExportEnvToGit
+	if env.IsGitOps() {
+		return resources, errList.ErrorOrNil()
+	}
+
 	// compatibility: prevSvc.Render could be null when prev update failed
 	if prevSvc != nil && preRenderInfo != nil {
 		err = removeOldResources(resources, env, prevSvc, preRenderInfo, kubeClient, log)
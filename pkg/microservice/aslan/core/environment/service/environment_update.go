@@ -365,11 +365,13 @@ func updateK8sProduct(exitedProd *commonmodels.Product, user, requestID string,
 		}
 	}
 
-	err = ensureKubeEnv(exitedProd.Namespace, exitedProd.RegistryID, map[string]string{setting.ProductLabel: productName}, exitedProd.ShareEnv.Enable, kubeClient, log)
-
-	if err != nil {
-		log.Errorf("[%s][P:%s] service.UpdateProductV2 create kubeEnv error: %v", envName, productName, err)
-		return err
+	// the cluster of gitops environments is only changed by the gitops tools
+	if !exitedProd.IsGitOps() {
+		err = ensureKubeEnv(exitedProd.Namespace, exitedProd.RegistryID, map[string]string{setting.ProductLabel: productName}, exitedProd.ShareEnv.Enable, kubeClient, log)
+		if err != nil {
+			log.Errorf("[%s][P:%s] service.UpdateProductV2 create kubeEnv error: %v", envName, productName, err)
+			return err
+		}
 	}
 
 	renderSet, err := commonservice.CreateRenderSetByMerge(
@@ -466,6 +468,11 @@ func updateK8sProduct(exitedProd *commonmodels.Product, user, requestID string,
 			log.Errorf("[%s][%s] Product.Update set product status error: %v", envName, productName, err)
 			return
 		}
+		if updateProd.Status == setting.ProductStatusSuccess && exitedProd.IsGitOps() {
+			if _, err = ExportEnvToGit(productName, envName, user, log); err != nil {
+				log.Errorf("[%s][%s] failed to export manifests to git: %v", envName, productName, err)
+			}
+		}
 	}()
 
 	return nil
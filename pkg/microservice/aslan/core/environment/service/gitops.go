@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/gitops"
+	"github.com/koderover/zadig/pkg/setting"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+// UpdateEnvGitOps enables or disables the gitops mode of the environment.
+// In gitops mode the rendered manifests are committed to the git repository instead of being applied to the cluster.
+func UpdateEnvGitOps(productName, envName string, args *commonmodels.ProductGitOps, log *zap.SugaredLogger) error {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		return e.ErrUpdateEnv.AddDesc(fmt.Sprintf("failed to find env %s/%s, err: %s", productName, envName, err))
+	}
+	if env.Source != setting.SourceFromZadig && env.Source != "" {
+		return e.ErrUpdateEnv.AddDesc("gitops mode is only supported by k8s yaml environments")
+	}
+
+	if args.Enabled {
+		if args.GetRepoNamespace() == "" || args.RepoName == "" || args.Branch == "" {
+			return e.ErrInvalidParam.AddDesc("repo and branch can not be empty")
+		}
+		if _, err := gitops.GetRepo(args.CodehostID); err != nil {
+			return e.ErrInvalidParam.AddErr(err)
+		}
+		args.Path = strings.Trim(args.Path, "/")
+		if args.Path == "" {
+			args.Path = path.Join(productName, envName)
+		}
+	}
+
+	if err := commonrepo.NewProductColl().UpdateGitOps(envName, productName, args); err != nil {
+		log.Errorf("failed to update gitops config of env %s/%s, err: %s", productName, envName, err)
+		return e.ErrUpdateEnv.AddErr(err)
+	}
+	return nil
+}
+
+// ExportEnvToGit renders the manifests of all the deployed services in the environment and commits them to
+// the configured git repository, see gitops.ExportEnv.
+func ExportEnvToGit(productName, envName, username string, log *zap.SugaredLogger) (*commonmodels.ProductGitOpsStatus, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		return nil, e.ErrUpdateEnv.AddDesc(fmt.Sprintf("failed to find env %s/%s, err: %s", productName, envName, err))
+	}
+	if !env.IsGitOps() {
+		return nil, e.ErrUpdateEnv.AddDesc("gitops mode is not enabled for this environment")
+	}
+
+	status, err := gitops.ExportEnv(env, username, log)
+	if err != nil {
+		return nil, e.ErrUpdateEnv.AddErr(err)
+	}
+	return status, nil
+}
+
+// GetEnvGitOpsStatus reads the manifests back from the target branch and refreshes the sync status of the environment.
+func GetEnvGitOpsStatus(productName, envName string, log *zap.SugaredLogger) (*commonmodels.ProductGitOpsStatus, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		return nil, e.ErrGetEnv.AddDesc(fmt.Sprintf("failed to find env %s/%s, err: %s", productName, envName, err))
+	}
+	if !env.IsGitOps() {
+		return nil, e.ErrGetEnv.AddDesc("gitops mode is not enabled for this environment")
+	}
+	if env.GitOpsStatus == nil {
+		return &commonmodels.ProductGitOpsStatus{}, nil
+	}
+
+	status := env.GitOpsStatus
+	if status.ManifestDigest == "" {
+		return status, nil
+	}
+	repo, err := gitops.GetRepo(env.GitOps.CodehostID)
+	if err != nil {
+		return nil, e.ErrGetEnv.AddErr(err)
+	}
+	if err = gitops.CheckStatus(env.GitOps, status, repo); err != nil {
+		log.Warnf("failed to check gitops status of env %s/%s, err: %s", productName, envName, err)
+		status.Error = err.Error()
+	}
+	if err = commonrepo.NewProductColl().UpdateGitOpsStatus(envName, productName, status); err != nil {
+		log.Errorf("failed to update gitops status of env %s/%s, err: %s", productName, envName, err)
+	}
+	return status, nil
+}
+
+// checkNotGitOps rejects the operations which change the cluster directly, the resources of gitops environments
+// can only be changed by the gitops tool syncing from git.
+func checkNotGitOps(env *commonmodels.Product) error {
+	if env.IsGitOps() {
+		return fmt.Errorf("environment %s is in gitops mode, its resources can only be changed through git", env.EnvName)
+	}
+	return nil
+}
@@ -28,6 +28,7 @@ import (
 	templatemodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/template"
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
 	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/gitops"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/kube"
 	"github.com/koderover/zadig/pkg/setting"
 	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
@@ -46,6 +47,7 @@ type UpdateContainerImageArgs struct {
 	Name          string `json:"name"`
 	ContainerName string `json:"container_name"`
 	Image         string `json:"image"`
+	UpdateBy      string `json:"-"`
 }
 
 func getValidMatchData(spec *models.ImagePathSpec) map[string]string {
@@ -213,6 +215,10 @@ func UpdateContainerImage(requestID string, args *UpdateContainerImageArgs, log
 	if err != nil {
 		return e.ErrUpdateConainterImage.AddErr(err)
 	}
+	// the image of gitops environments is changed in git, the gitops tool syncs it to the cluster
+	if product.IsGitOps() {
+		return updateGitOpsContainerImage(product, args, log)
+	}
 
 	namespace := product.Namespace
 	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), product.ClusterID)
@@ -263,6 +269,20 @@ func UpdateContainerImage(requestID string, args *UpdateContainerImageArgs, log
 				log.Errorf("[%s] UpdateStatefulsetImageByName error: %s", namespace, err.Error())
 				return e.ErrUpdateConainterImage.AddDesc("更新 StatefulSet 容器镜像失败")
 			}
+		case setting.DaemonSet:
+			if err := updater.UpdateDaemonSetImage(namespace, args.Name, args.ContainerName, args.Image, kubeClient); err != nil {
+				log.Errorf("[%s] UpdateDaemonSetImageByName error: %s", namespace, err.Error())
+				return e.ErrUpdateConainterImage.AddDesc("更新 DaemonSet 容器镜像失败")
+			}
+		case setting.CronJob:
+			versionLessThan121, err := clusterVersionLessThan121(product.ClusterID)
+			if err != nil {
+				return e.ErrUpdateConainterImage.AddErr(err)
+			}
+			if err := updater.UpdateCronJobImage(namespace, args.Name, args.ContainerName, args.Image, kubeClient, versionLessThan121); err != nil {
+				log.Errorf("[%s] UpdateCronJobImageByName error: %s", namespace, err.Error())
+				return e.ErrUpdateConainterImage.AddDesc("更新 CronJob 容器镜像失败")
+			}
 		default:
 			return e.ErrUpdateConainterImage.AddDesc(fmt.Sprintf("不支持的资源类型: %s", args.Type))
 		}
@@ -286,3 +306,31 @@ func UpdateContainerImage(requestID string, args *UpdateContainerImageArgs, log
 	}
 	return nil
 }
+
+func updateGitOpsContainerImage(product *models.Product, args *UpdateContainerImageArgs, log *zap.SugaredLogger) error {
+	found := false
+	for _, service := range product.GetServiceMap() {
+		if service.ServiceName != args.ServiceName {
+			continue
+		}
+		for _, container := range service.Containers {
+			if container.Name == args.ContainerName {
+				container.Image = args.Image
+				found = true
+				break
+			}
+		}
+		break
+	}
+	if !found {
+		return e.ErrUpdateConainterImage.AddDesc(fmt.Sprintf("container %s of service %s is not found", args.ContainerName, args.ServiceName))
+	}
+	if err := commonrepo.NewProductColl().Update(product); err != nil {
+		log.Errorf("[%s] update product %s error: %s", product.Namespace, args.ProductName, err.Error())
+		return e.ErrUpdateConainterImage.AddDesc("更新环境信息失败")
+	}
+	if _, err := gitops.ExportEnv(product, args.UpdateBy, log); err != nil {
+		return e.ErrUpdateConainterImage.AddErr(err)
+	}
+	return nil
+}
@@ -215,6 +215,9 @@ func UpdateOrCreateIngress(args *models.CreateUpdateCommonEnvCfgArgs, userName s
 	if err != nil {
 		return e.ErrUpdateResource.AddErr(err)
 	}
+	if err := checkNotGitOps(product); err != nil {
+		return e.ErrUpdateResource.AddErr(err)
+	}
 
 	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), product.ClusterID)
 	if err != nil {
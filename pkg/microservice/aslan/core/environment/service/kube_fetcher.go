@@ -31,7 +31,6 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/duration"
-	"k8s.io/apimachinery/pkg/util/version"
 	k8sversion "k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
@@ -141,9 +140,11 @@ type ResourceSecret struct {
 }
 
 func VersionLessThan121(ver *k8sversion.Info) bool {
-	v121, _ := version.ParseGeneric("v1.21.0")
-	currVersion, _ := version.ParseGeneric(ver.String())
-	return currVersion.LessThan(v121)
+	return kubeclient.VersionLessThan121(ver)
+}
+
+func clusterVersionLessThan121(clusterID string) (bool, error) {
+	return kubeclient.ClusterVersionLessThan121(config.HubServerAddress(), clusterID)
 }
 
 func (resp *K8sResourceResp) handlePageFilter(page, pageSize int) *K8sResourceResp {
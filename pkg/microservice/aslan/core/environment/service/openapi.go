@@ -0,0 +1,332 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
+	"github.com/koderover/zadig/pkg/setting"
+	kubeclient "github.com/koderover/zadig/pkg/shared/kube/client"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/tool/kube/informer"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+func OpenAPIListEnvs(projectName string, log *zap.SugaredLogger) ([]*OpenAPIEnvBrief, error) {
+	envs, err := ListProducts(projectName, nil, log)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*OpenAPIEnvBrief, 0, len(envs))
+	for _, env := range envs {
+		resp = append(resp, &OpenAPIEnvBrief{
+			ProjectName: env.ProjectName,
+			EnvName:     env.Name,
+			Alias:       env.Alias,
+			Production:  env.Production,
+			ClusterID:   env.ClusterID,
+			ClusterName: env.ClusterName,
+			Namespace:   env.Namespace,
+			RegistryID:  env.RegistryID,
+			Source:      env.Source,
+			Status:      env.Status,
+			Error:       env.Error,
+			UpdateBy:    env.UpdateBy,
+			UpdateTime:  env.UpdateTime,
+		})
+	}
+	return resp, nil
+}
+
+func OpenAPIGetEnv(username, projectName, envName string, log *zap.SugaredLogger) (*OpenAPIEnvDetail, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: projectName, EnvName: envName})
+	if err != nil {
+		return nil, e.ErrGetEnv.AddErr(err)
+	}
+	prodResp, err := GetProduct(username, envName, projectName, log)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &OpenAPIEnvDetail{
+		OpenAPIEnvBrief: &OpenAPIEnvBrief{
+			ProjectName: env.ProductName,
+			EnvName:     env.EnvName,
+			Alias:       env.Alias,
+			Production:  env.Production,
+			ClusterID:   env.ClusterID,
+			ClusterName: prodResp.ClusterName,
+			Namespace:   env.Namespace,
+			RegistryID:  prodResp.RegisterID,
+			Source:      env.Source,
+			Status:      prodResp.Status,
+			Error:       prodResp.Error,
+			UpdateBy:    env.UpdateBy,
+			UpdateTime:  env.UpdateTime,
+		},
+		Services: make([]*OpenAPIEnvService, 0),
+	}
+	for _, svc := range env.GetServiceMap() {
+		service := &OpenAPIEnvService{
+			ServiceName: svc.ServiceName,
+			Type:        svc.Type,
+			Revision:    svc.Revision,
+			Containers:  make([]*OpenAPIContainer, 0, len(svc.Containers)),
+		}
+		for _, c := range svc.Containers {
+			service.Containers = append(service.Containers, &OpenAPIContainer{Name: c.Name, Image: c.Image})
+		}
+		resp.Services = append(resp.Services, service)
+	}
+	return resp, nil
+}
+
+// OpenAPICreateEnv creates a k8s yaml or helm environment. The environment is created from the latest service templates,
+// or copied from the base environment if it is specified, which is only supported in k8s yaml projects.
+func OpenAPICreateEnv(username, requestID string, req *OpenAPICreateEnvReq, log *zap.SugaredLogger) error {
+	deployType, err := GetProductDeployType(req.ProjectName)
+	if err != nil {
+		return e.ErrCreateEnv.AddDesc(fmt.Sprintf("failed to query project %s: %s", req.ProjectName, err))
+	}
+	if deployType != setting.K8SDeployType && deployType != setting.HelmDeployType {
+		return e.ErrCreateEnv.AddDesc(fmt.Sprintf("environment creation is not supported in %s projects", deployType))
+	}
+
+	if req.BaseEnvName != "" {
+		if deployType != setting.K8SDeployType {
+			return e.ErrCreateEnv.AddDesc("copying environment is only supported in k8s yaml projects")
+		}
+		baseEnv, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: req.ProjectName, EnvName: req.BaseEnvName})
+		if err != nil {
+			return e.ErrCreateEnv.AddErr(fmt.Errorf("failed to find base environment: %s, err: %s", req.BaseEnvName, err))
+		}
+		arg, err := buildPreviewEnvCreationArg(baseEnv, req.EnvName, req.Services)
+		if err != nil {
+			return e.ErrCreateEnv.AddErr(err)
+		}
+		fillOpenAPICreationArg(arg, req)
+		arg.ShareEnv = commonmodels.ProductShareEnv{}
+		return CopyYamlProduct(username, requestID, req.ProjectName, []*CreateSingleProductArg{arg}, log)
+	}
+
+	initProduct, err := GetInitProduct(req.ProjectName, types.GeneralEnv, false, "", log)
+	if err != nil {
+		return e.ErrCreateEnv.AddErr(err)
+	}
+	arg := &CreateSingleProductArg{ProductName: req.ProjectName, EnvName: req.EnvName}
+	fillOpenAPICreationArg(arg, req)
+
+	svcSet := sets.NewString(req.Services...)
+	for _, group := range initProduct.Services {
+		k8sGroup := make([]*ProductK8sServiceCreationInfo, 0, len(group))
+		for _, svc := range group {
+			if svcSet.Len() > 0 && !svcSet.Has(svc.ServiceName) {
+				continue
+			}
+			if deployType == setting.HelmDeployType {
+				arg.ChartValues = append(arg.ChartValues, &ProductHelmServiceCreationInfo{
+					HelmSvcRenderArg: &commonservice.HelmSvcRenderArg{EnvName: req.EnvName, ServiceName: svc.ServiceName},
+					DeployStrategy:   setting.ServiceDeployStrategyDeploy,
+				})
+				continue
+			}
+			k8sGroup = append(k8sGroup, &ProductK8sServiceCreationInfo{
+				ProductService: svc,
+				DeployStrategy: setting.ServiceDeployStrategyDeploy,
+			})
+		}
+		if deployType == setting.K8SDeployType {
+			arg.Services = append(arg.Services, k8sGroup)
+		}
+	}
+
+	if deployType == setting.HelmDeployType {
+		return CreateHelmProduct(req.ProjectName, username, requestID, []*CreateSingleProductArg{arg}, log)
+	}
+	return CreateYamlProduct(req.ProjectName, username, requestID, []*CreateSingleProductArg{arg}, log)
+}
+
+func fillOpenAPICreationArg(arg *CreateSingleProductArg, req *OpenAPICreateEnvReq) {
+	arg.ClusterID = req.ClusterID
+	arg.Namespace = req.Namespace
+	arg.Production = req.Production
+	arg.Alias = req.Alias
+	if req.RegistryID != "" {
+		arg.RegistryID = req.RegistryID
+	}
+	if req.DefaultValues != "" {
+		arg.DefaultValues = req.DefaultValues
+	}
+}
+
+// OpenAPIUpdateServiceImages updates the image of the containers in all workloads of the services.
+func OpenAPIUpdateServiceImages(requestID, envName string, req *OpenAPIUpdateServiceImagesReq, log *zap.SugaredLogger) error {
+	for _, image := range req.Images {
+		svcResp, err := GetService(envName, req.ProjectName, image.ServiceName, "", log)
+		if err != nil {
+			return e.ErrUpdateConainterImage.AddErr(err)
+		}
+
+		found := false
+		for _, workload := range svcResp.Scales {
+			// the containers of a job can not be updated once it is created
+			if workload.Type == setting.Job {
+				continue
+			}
+			for _, container := range workload.Images {
+				if container.Name != image.ContainerName {
+					continue
+				}
+				found = true
+				err = UpdateContainerImage(requestID, &UpdateContainerImageArgs{
+					Type:          workload.Type,
+					ProductName:   req.ProjectName,
+					EnvName:       envName,
+					ServiceName:   image.ServiceName,
+					Name:          workload.Name,
+					ContainerName: image.ContainerName,
+					Image:         image.Image,
+					UpdateBy:      req.UpdateBy,
+				}, log)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if !found {
+			return e.ErrUpdateConainterImage.AddDesc(fmt.Sprintf("container %s not found in service %s", image.ContainerName, image.ServiceName))
+		}
+	}
+	return nil
+}
+
+func OpenAPIRestartService(username, projectName, envName, serviceName string, log *zap.SugaredLogger) error {
+	err := RestartService(envName, &SvcOptArgs{
+		EnvName:     envName,
+		ProductName: projectName,
+		ServiceName: serviceName,
+		UpdateBy:    username,
+	}, log)
+	if err != nil {
+		return e.ErrRestartService.AddErr(err)
+	}
+	return nil
+}
+
+func OpenAPIGetEnvVariables(projectName, envName string, log *zap.SugaredLogger) (*OpenAPIEnvVariables, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: projectName, EnvName: envName})
+	if err != nil {
+		return nil, e.ErrGetEnv.AddErr(err)
+	}
+	if env.Render == nil {
+		return nil, e.ErrGetRenderSet.AddDesc("invalid environment, nil render data")
+	}
+
+	renderset, err := commonrepo.NewRenderSetColl().Find(&commonrepo.RenderSetFindOption{
+		ProductTmpl: env.ProductName,
+		EnvName:     env.EnvName,
+		Name:        env.Render.Name,
+		Revision:    env.Render.Revision,
+	})
+	if err != nil {
+		log.Errorf("failed to find renderset of env %s/%s, err: %s", projectName, envName, err)
+		return nil, e.ErrGetRenderSet.AddErr(err)
+	}
+
+	serviceRenders := renderset.ServiceVariables
+	if env.Source == setting.SourceFromHelm {
+		serviceRenders = renderset.ChartInfos
+	}
+	resp := &OpenAPIEnvVariables{
+		DefaultValues: renderset.DefaultValues,
+		Services:      make([]*OpenAPIServiceVariable, 0, len(serviceRenders)),
+	}
+	for _, sr := range serviceRenders {
+		variable := &OpenAPIServiceVariable{
+			ServiceName:    sr.ServiceName,
+			OverrideValues: sr.OverrideValues,
+		}
+		if sr.OverrideYaml != nil {
+			variable.VariableYaml = sr.OverrideYaml.YamlContent
+		}
+		resp.Services = append(resp.Services, variable)
+	}
+	return resp, nil
+}
+
+func OpenAPIGetServiceStatus(projectName, envName, serviceName string, log *zap.SugaredLogger) (*OpenAPIServiceStatus, error) {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: projectName, EnvName: envName})
+	if err != nil {
+		return nil, e.ErrGetService.AddErr(err)
+	}
+	if _, ok := env.GetServiceMap()[serviceName]; !ok {
+		return nil, e.ErrGetService.AddDesc(fmt.Sprintf("service %s not found in environment %s", serviceName, envName))
+	}
+
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), env.ClusterID)
+	if err != nil {
+		return nil, e.ErrGetService.AddErr(err)
+	}
+	clientset, err := kubeclient.GetKubeClientSet(config.HubServerAddress(), env.ClusterID)
+	if err != nil {
+		return nil, e.ErrGetService.AddErr(err)
+	}
+	inf, err := informer.NewInformer(env.ClusterID, env.Namespace, clientset)
+	if err != nil {
+		return nil, e.ErrGetService.AddErr(err)
+	}
+
+	svcResp, err := GetServiceImpl(serviceName, "", env, kubeClient, clientset, inf, log)
+	if err != nil {
+		return nil, e.ErrGetService.AddErr(err)
+	}
+	status, ready, _ := queryPodsStatus(env, serviceName, kubeClient, clientset, inf, log)
+
+	resp := &OpenAPIServiceStatus{
+		ServiceName: serviceName,
+		EnvName:     envName,
+		Status:      status,
+		Ready:       ready,
+		Workloads:   make([]*OpenAPIWorkload, 0, len(svcResp.Scales)),
+	}
+	for _, scale := range svcResp.Scales {
+		workload := &OpenAPIWorkload{
+			Name:     scale.Name,
+			Type:     scale.Type,
+			Replicas: scale.Replicas,
+			Images:   make([]*OpenAPIContainer, 0, len(scale.Images)),
+		}
+		for _, pod := range scale.Pods {
+			if pod.PodReady {
+				workload.ReadyReplicas++
+			}
+		}
+		for _, image := range scale.Images {
+			workload.Images = append(workload.Images, &OpenAPIContainer{Name: image.Name, Image: image.Image})
+		}
+		resp.Workloads = append(resp.Workloads, workload)
+	}
+	return resp, nil
+}
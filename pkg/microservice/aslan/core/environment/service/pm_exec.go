@@ -25,7 +25,9 @@ import (
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
 
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/terminalrecord"
 	"github.com/koderover/zadig/pkg/setting"
 	e "github.com/koderover/zadig/pkg/tool/errors"
 	toolssh "github.com/koderover/zadig/pkg/tool/ssh"
@@ -40,7 +42,19 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-func ConnectSshPmExec(c *gin.Context, username, envName, productName, ip, hostId string, cols, rows int, log *zap.SugaredLogger) error {
+func ConnectSshPmExec(c *gin.Context, username, uid, envName, productName, ip, hostId string, cols, rows int, log *zap.SugaredLogger) error {
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		log.Errorf("failed to find env %s of project %s, error: %s", envName, productName, err)
+		return e.ErrLoginPm.AddErr(err)
+	}
+	if err := terminalrecord.CheckExecPermission(uid, env); err != nil {
+		if err == terminalrecord.ErrProductionExecForbidden {
+			return e.ErrForbidden.AddErr(err)
+		}
+		return e.ErrLoginPm.AddErr(err)
+	}
+
 	resp, err := commonrepo.NewPrivateKeyColl().Find(commonrepo.FindPrivateKeyOption{
 		ID: hostId,
 	})
@@ -74,6 +88,23 @@ func ConnectSshPmExec(c *gin.Context, username, envName, productName, ip, hostId
 	}
 	defer sshConn.Close()
 
+	recording, err := terminalrecord.Start(&models.TerminalSession{
+		Type:        models.TerminalSessionTypeHost,
+		UserName:    username,
+		UID:         uid,
+		ProjectName: productName,
+		EnvName:     envName,
+		Production:  env.Production,
+		HostID:      hostId,
+		HostIP:      resp.IP,
+	}, cols, rows)
+	if err != nil {
+		log.Errorf("failed to start session recording, error: %s", err)
+		return e.ErrLoginPm.AddErr(err)
+	}
+	defer recording.Close()
+	sshConn.SetRecorder(recording)
+
 	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Errorf("ws upgrade err:%s", err)
@@ -0,0 +1,260 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	templaterepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	"github.com/koderover/zadig/pkg/setting"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/util"
+)
+
+const (
+	previewEnvPrefix       = "pr"
+	previewEnvNameMaxLen   = 32
+	defaultPreviewEnvTTLHr = 72
+
+	previewEnvReadyTimeout  = 15 * time.Minute
+	previewEnvReadyInterval = 5 * time.Second
+)
+
+var invalidEnvNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// GetPreviewEnvName returns a stable environment name for a pull request of a repo, a short hash of the full repo path
+// is included so that repos with the same name under different namespaces or long names sharing a prefix do not collide.
+func GetPreviewEnvName(repoNamespace, repoName string, pr int) string {
+	hash := sha1.Sum([]byte(repoNamespace + "/" + repoName))
+	suffix := fmt.Sprintf("-%s-%d", hex.EncodeToString(hash[:])[:6], pr)
+	name := invalidEnvNameChars.ReplaceAllString(strings.ToLower(repoName), "-")
+	name = fmt.Sprintf("%s-%s", previewEnvPrefix, strings.Trim(name, "-"))
+	if len(name)+len(suffix) > previewEnvNameMaxLen {
+		name = strings.TrimRight(name[:previewEnvNameMaxLen-len(suffix)], "-")
+	}
+	return name + suffix
+}
+
+// EnsurePreviewEnv creates the share-env sub environment of the configured base environment for the pull request,
+// the environment is only marked as active if it already exists. The returned bool reports whether it is newly created.
+func EnsurePreviewEnv(productName, requestID string, previewEnv *commonmodels.ProductPreviewEnv, log *zap.SugaredLogger) (*commonmodels.Product, bool, error) {
+	templateProduct, err := templaterepo.NewProductColl().Find(productName)
+	if err != nil {
+		return nil, false, e.ErrCreateEnv.AddDesc(fmt.Sprintf("failed to query product %s", productName))
+	}
+	envSetting := templateProduct.PreviewEnv
+	if envSetting == nil || !envSetting.Enabled {
+		return nil, false, e.ErrCreateEnv.AddDesc(fmt.Sprintf("preview environment is not enabled in product %s", productName))
+	}
+	if !templateProduct.IsK8sYamlProduct() {
+		return nil, false, e.ErrCreateEnv.AddDesc("preview environment is only supported in k8s yaml projects")
+	}
+
+	previewEnv.ActiveTime = time.Now().Unix()
+	repoNamespace := previewEnv.RepoNamespace
+	if repoNamespace == "" {
+		repoNamespace = previewEnv.RepoOwner
+	}
+	envName := GetPreviewEnvName(repoNamespace, previewEnv.RepoName, previewEnv.PR)
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err == nil {
+		if env.PreviewEnv == nil {
+			return nil, false, e.ErrCreateEnv.AddDesc(fmt.Sprintf("environment %s already exists and is not a preview environment", envName))
+		}
+		if err := commonrepo.NewProductColl().UpdatePreviewEnv(envName, productName, previewEnv); err != nil {
+			return nil, false, e.ErrUpdateEnv.AddErr(err)
+		}
+		env.PreviewEnv = previewEnv
+		return env, false, nil
+	}
+
+	baseEnv, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envSetting.BaseEnv})
+	if err != nil {
+		return nil, false, e.ErrCreateEnv.AddErr(fmt.Errorf("failed to find base environment: %s, err: %s", envSetting.BaseEnv, err))
+	}
+	if !baseEnv.ShareEnv.Enable || !baseEnv.ShareEnv.IsBase {
+		return nil, false, e.ErrCreateEnv.AddDesc(fmt.Sprintf("environment %s is not a base environment", envSetting.BaseEnv))
+	}
+
+	arg, err := buildPreviewEnvCreationArg(baseEnv, envName, envSetting.Services)
+	if err != nil {
+		return nil, false, e.ErrCreateEnv.AddErr(err)
+	}
+	if err := CopyYamlProduct(setting.PreviewEnvCreator, requestID, productName, []*CreateSingleProductArg{arg}, log); err != nil {
+		return nil, false, err
+	}
+	if err := commonrepo.NewProductColl().UpdatePreviewEnv(envName, productName, previewEnv); err != nil {
+		return nil, false, e.ErrCreateEnv.AddErr(err)
+	}
+
+	env, err = commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		return nil, false, e.ErrCreateEnv.AddErr(err)
+	}
+	return env, true, nil
+}
+
+func buildPreviewEnvCreationArg(baseEnv *commonmodels.Product, envName string, services []string) (*CreateSingleProductArg, error) {
+	renderset, err := commonrepo.NewRenderSetColl().Find(&commonrepo.RenderSetFindOption{
+		ProductTmpl: baseEnv.ProductName,
+		EnvName:     baseEnv.EnvName,
+		Name:        baseEnv.Render.Name,
+		Revision:    baseEnv.Render.Revision,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find renderset of base environment %s, err: %s", baseEnv.EnvName, err)
+	}
+	variableYamls := make(map[string]string)
+	for _, sv := range renderset.ServiceVariables {
+		if sv.OverrideYaml != nil {
+			variableYamls[sv.ServiceName] = sv.OverrideYaml.YamlContent
+		}
+	}
+
+	svcSet := sets.NewString(services...)
+	svcGroups := make([][]*ProductK8sServiceCreationInfo, 0, len(baseEnv.Services))
+	for _, baseGroup := range baseEnv.Services {
+		group := make([]*ProductK8sServiceCreationInfo, 0, len(baseGroup))
+		for _, baseSvc := range baseGroup {
+			if svcSet.Len() > 0 && !svcSet.Has(baseSvc.ServiceName) {
+				continue
+			}
+			svc := &commonmodels.ProductService{
+				ServiceName:  baseSvc.ServiceName,
+				ProductName:  baseSvc.ProductName,
+				Type:         baseSvc.Type,
+				Revision:     baseSvc.Revision,
+				VariableYaml: variableYamls[baseSvc.ServiceName],
+			}
+			for _, c := range baseSvc.Containers {
+				container := *c
+				svc.Containers = append(svc.Containers, &container)
+			}
+			group = append(group, &ProductK8sServiceCreationInfo{
+				ProductService: svc,
+				DeployStrategy: baseEnv.ServiceDeployStrategy[baseSvc.ServiceName],
+			})
+		}
+		svcGroups = append(svcGroups, group)
+	}
+
+	return &CreateSingleProductArg{
+		ProductName:   baseEnv.ProductName,
+		EnvName:       envName,
+		ClusterID:     baseEnv.ClusterID,
+		RegistryID:    baseEnv.RegistryID,
+		BaseEnvName:   baseEnv.EnvName,
+		DefaultValues: renderset.DefaultValues,
+		Services:      svcGroups,
+		ShareEnv: commonmodels.ProductShareEnv{
+			Enable:  true,
+			IsBase:  false,
+			BaseEnv: baseEnv.EnvName,
+		},
+	}, nil
+}
+
+// DeletePreviewEnv deletes the preview environment of the pull request, nothing happens if it does not exist.
+// The returned bool reports whether the environment is deleted.
+func DeletePreviewEnv(productName, repoNamespace, repoName string, pr int, requestID string, log *zap.SugaredLogger) (string, bool, error) {
+	envName := GetPreviewEnvName(repoNamespace, repoName, pr)
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil || env.PreviewEnv == nil {
+		return envName, false, nil
+	}
+	if err := DeleteProduct(setting.PreviewEnvCreator, envName, productName, requestID, true, log); err != nil {
+		return envName, false, err
+	}
+	return envName, true, nil
+}
+
+// WaitPreviewEnvReady waits until the services of the newly created preview environment are deployed.
+func WaitPreviewEnvReady(productName, envName string) error {
+	timeout := time.After(previewEnvReadyTimeout)
+	ticker := time.NewTicker(previewEnvReadyInterval)
+	defer ticker.Stop()
+
+	for {
+		env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+		if err != nil {
+			return fmt.Errorf("failed to find preview environment %s, err: %s", envName, err)
+		}
+		switch env.Status {
+		case setting.ProductStatusFailed:
+			return fmt.Errorf("failed to create preview environment %s: %s", envName, env.Error)
+		case setting.ProductStatusCreating:
+		default:
+			return nil
+		}
+
+		select {
+		case <-timeout:
+			return fmt.Errorf("timed out waiting for preview environment %s to be ready", envName)
+		case <-ticker.C:
+		}
+	}
+}
+
+// CleanPreviewEnvCronJob deletes the preview environments which have not been active for longer than the TTL.
+func CleanPreviewEnvCronJob(requestID string, log *zap.SugaredLogger) {
+	log.Info("[CleanPreviewEnvCronJob] started ...")
+	defer log.Info("[CleanPreviewEnvCronJob] end")
+
+	envs, err := commonrepo.NewProductColl().List(&commonrepo.ProductListOptions{
+		ShareEnvEnable: util.GetBoolPointer(true),
+		ShareEnvIsBase: util.GetBoolPointer(false),
+	})
+	if err != nil {
+		log.Errorf("[Product.List] error: %v", err)
+		return
+	}
+
+	ttlMap := make(map[string]int)
+	for _, env := range envs {
+		if env.PreviewEnv == nil {
+			continue
+		}
+		ttl, ok := ttlMap[env.ProductName]
+		if !ok {
+			ttl = defaultPreviewEnvTTLHr
+			templateProduct, err := templaterepo.NewProductColl().Find(env.ProductName)
+			if err == nil && templateProduct.PreviewEnv != nil && templateProduct.PreviewEnv.TTL > 0 {
+				ttl = templateProduct.PreviewEnv.TTL
+			}
+			ttlMap[env.ProductName] = ttl
+		}
+
+		if time.Now().Unix()-env.PreviewEnv.ActiveTime <= int64(60*60*ttl) {
+			continue
+		}
+		if err := DeleteProduct(setting.PreviewEnvCreator, env.EnvName, env.ProductName, requestID, true, log); err != nil {
+			log.Errorf("[%s][P:%s] delete preview environment error: %v", env.EnvName, env.ProductName, err)
+			continue
+		}
+		log.Infof("[%s][P:%s] idle preview environment deleted", env.EnvName, env.ProductName)
+	}
+}
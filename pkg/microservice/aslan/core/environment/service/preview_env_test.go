@@ -0,0 +1,58 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Testing preview env", func() {
+
+	Describe("test GetPreviewEnvName", func() {
+
+		It("should be stable for the same pull request", func() {
+			name := GetPreviewEnvName("koderover", "zadig", 12)
+			Expect(name).To(Equal(GetPreviewEnvName("koderover", "zadig", 12)))
+			Expect(name).To(HavePrefix("pr-zadig-"))
+			Expect(name).To(HaveSuffix("-12"))
+		})
+
+		It("should differ between pull requests and repo namespaces", func() {
+			name := GetPreviewEnvName("koderover", "zadig", 12)
+			Expect(GetPreviewEnvName("koderover", "zadig", 13)).NotTo(Equal(name))
+			Expect(GetPreviewEnvName("someone", "zadig", 12)).NotTo(Equal(name))
+		})
+
+		It("should replace the invalid chars of the repo name", func() {
+			name := GetPreviewEnvName("koderover", "My_Repo.Web", 1)
+			Expect(name).To(HavePrefix("pr-my-repo-web-"))
+			Expect(name).To(MatchRegexp(`^[a-z0-9-]+$`))
+		})
+
+		It("should truncate long repo names", func() {
+			repoName := strings.Repeat("service-", 10)
+			name := GetPreviewEnvName("koderover", repoName, 12345)
+			Expect(len(name)).To(BeNumerically("<=", previewEnvNameMaxLen))
+			Expect(name).To(HaveSuffix("-12345"))
+			Expect(name).NotTo(ContainSubstring("--"))
+			Expect(GetPreviewEnvName("koderover", repoName+"a", 12345)).NotTo(Equal(name))
+		})
+	})
+})
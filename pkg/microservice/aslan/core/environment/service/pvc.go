@@ -178,6 +178,9 @@ func UpdatePvc(args *models.CreateUpdateCommonEnvCfgArgs, userName string, log *
 	if err != nil {
 		return e.ErrUpdateResource.AddErr(err)
 	}
+	if err := checkNotGitOps(product); err != nil {
+		return e.ErrUpdateResource.AddErr(err)
+	}
 
 	clientset, err := kubeclient.GetKubeClientSet(config.HubServerAddress(), product.ClusterID)
 	if err != nil {
@@ -145,6 +145,9 @@ func UpdateSecret(args *models.CreateUpdateCommonEnvCfgArgs, userName string, lo
 	if err != nil {
 		return e.ErrUpdateResource.AddErr(err)
 	}
+	if err := checkNotGitOps(product); err != nil {
+		return e.ErrUpdateResource.AddErr(err)
+	}
 
 	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), product.ClusterID)
 	if err != nil {
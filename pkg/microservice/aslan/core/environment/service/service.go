@@ -18,6 +18,7 @@ package service
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/go-multierror"
@@ -25,7 +26,9 @@ import (
 	"helm.sh/helm/v3/pkg/releaseutil"
 	versionedclient "istio.io/client-go/pkg/clientset/versioned"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/informers"
@@ -68,6 +71,9 @@ func Scale(args *ScaleArgs, logger *zap.SugaredLogger) error {
 	if err != nil {
 		return e.ErrScaleService.AddErr(err)
 	}
+	if err := checkNotGitOps(prod); err != nil {
+		return e.ErrScaleService.AddErr(err)
+	}
 
 	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), prod.ClusterID)
 	if err != nil {
@@ -98,6 +104,9 @@ func RestartScale(args *RestartScaleArgs, _ *zap.SugaredLogger) error {
 	if err != nil {
 		return err
 	}
+	if err := checkNotGitOps(prod); err != nil {
+		return e.ErrRestartService.AddErr(err)
+	}
 
 	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), prod.ClusterID)
 	if err != nil {
@@ -125,6 +134,8 @@ func RestartScale(args *RestartScaleArgs, _ *zap.SugaredLogger) error {
 		err = updater.RestartDeployment(prod.Namespace, args.Name, kubeClient)
 	case setting.StatefulSet:
 		err = updater.RestartStatefulSet(prod.Namespace, args.Name, kubeClient)
+	case setting.DaemonSet:
+		err = updater.RestartDaemonSet(prod.Namespace, args.Name, kubeClient)
 	}
 
 	if err != nil {
@@ -135,6 +146,146 @@ func RestartScale(args *RestartScaleArgs, _ *zap.SugaredLogger) error {
 	return nil
 }
 
+// SuspendCronJob suspends or resumes the CronJob in the environment, it is the counterpart of Scale for CronJobs.
+func SuspendCronJob(args *SuspendCronJobArgs, logger *zap.SugaredLogger) error {
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: args.ProductName, EnvName: args.EnvName})
+	if err != nil {
+		return e.ErrScaleService.AddErr(err)
+	}
+	if err := checkNotGitOps(prod); err != nil {
+		return e.ErrScaleService.AddErr(err)
+	}
+
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return e.ErrScaleService.AddErr(err)
+	}
+	versionLessThan121, err := clusterVersionLessThan121(prod.ClusterID)
+	if err != nil {
+		return e.ErrScaleService.AddErr(err)
+	}
+	if _, err := getServiceCronJob(prod, args.ServiceName, args.Name, kubeClient, versionLessThan121); err != nil {
+		return e.ErrScaleService.AddErr(err)
+	}
+
+	if err := updater.SuspendCronJob(prod.Namespace, args.Name, args.Suspend, kubeClient, versionLessThan121); err != nil {
+		logger.Errorf("failed to set suspend of %s/cronjob/%s to %t, err: %s", prod.Namespace, args.Name, args.Suspend, err)
+		return e.ErrScaleService.AddErr(err)
+	}
+	return nil
+}
+
+// getServiceCronJob returns the CronJob if it is deployed by the service in the environment
+func getServiceCronJob(prod *commonmodels.Product, serviceName, name string, kubeClient client.Client, versionLessThan121 bool) (metav1.Object, error) {
+	var cronJob metav1.Object
+	if versionLessThan121 {
+		cj, found, err := getter.GetCronJobV1Beta(prod.Namespace, name, kubeClient)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			cronJob = cj
+		}
+	} else {
+		cj, found, err := getter.GetCronJob(prod.Namespace, name, kubeClient)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			cronJob = cj
+		}
+	}
+	if cronJob == nil {
+		return nil, fmt.Errorf("cronjob %s not found in namespace %s", name, prod.Namespace)
+	}
+
+	ls := cronJob.GetLabels()
+	if ls[setting.ProductLabel] != prod.ProductName || ls[setting.ServiceLabel] != serviceName {
+		return nil, fmt.Errorf("cronjob %s does not belong to service %s", name, serviceName)
+	}
+	return cronJob, nil
+}
+
+// ListCronJobHistory lists the jobs created by the CronJob of the service in the environment, the latest one comes first.
+func ListCronJobHistory(envName, productName, serviceName, name string, log *zap.SugaredLogger) ([]*CronJobHistory, error) {
+	prod, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		return nil, e.ErrGetService.AddErr(err)
+	}
+
+	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), prod.ClusterID)
+	if err != nil {
+		return nil, e.ErrGetService.AddErr(err)
+	}
+
+	versionLessThan121, err := clusterVersionLessThan121(prod.ClusterID)
+	if err != nil {
+		return nil, e.ErrGetService.AddErr(err)
+	}
+	cronJob, err := getServiceCronJob(prod, serviceName, name, kubeClient, versionLessThan121)
+	if err != nil {
+		return nil, e.ErrGetService.AddErr(err)
+	}
+
+	selector := labels.SelectorFromSet(getPredefinedLabels(productName, serviceName))
+	jobs, err := getter.ListJobs(prod.Namespace, selector, kubeClient)
+	if err != nil {
+		log.Errorf("failed to list jobs in namespace %s, err: %s", prod.Namespace, err)
+		return nil, e.ErrGetService.AddErr(err)
+	}
+
+	ret := make([]*CronJobHistory, 0)
+	for _, job := range jobs {
+		owned := false
+		for _, owner := range job.OwnerReferences {
+			if owner.Kind == setting.CronJob && owner.UID == cronJob.GetUID() {
+				owned = true
+				break
+			}
+		}
+		if !owned {
+			continue
+		}
+		ret = append(ret, newCronJobHistory(job))
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].CreateTime > ret[j].CreateTime
+	})
+	return ret, nil
+}
+
+func newCronJobHistory(job *batchv1.Job) *CronJobHistory {
+	wrapped := wrapper.Job(job)
+	history := &CronJobHistory{
+		Name:       job.Name,
+		CreateTime: job.CreationTimestamp.Unix(),
+		Duration:   wrapped.GetDuration(),
+		Active:     job.Status.Active,
+		Succeeded:  job.Status.Succeeded,
+		Failed:     job.Status.Failed,
+		Status:     string(config.StatusRunning),
+	}
+	if job.Status.StartTime != nil {
+		history.StartTime = job.Status.StartTime.Unix()
+	}
+	if job.Status.CompletionTime != nil {
+		history.CompletionTime = job.Status.CompletionTime.Unix()
+	}
+
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			history.Status = string(config.StatusPassed)
+		case batchv1.JobFailed:
+			history.Status = string(config.StatusFailed)
+		}
+	}
+	return history
+}
+
 func GetService(envName, productName, serviceName string, workLoadType string, log *zap.SugaredLogger) (ret *SvcResp, err error) {
 	opt := &commonrepo.ProductFindOptions{Name: productName, EnvName: envName}
 	env, err := commonrepo.NewProductColl().Find(opt)
@@ -298,6 +449,30 @@ func GetServiceImpl(serviceName string, workLoadType string, env *commonmodels.P
 
 				ret.Scales = append(ret.Scales, getStatefulSetWorkloadResource(sts, inf, log))
 
+			case setting.DaemonSet:
+				ds, found, err := getter.GetDaemonSet(namespace, u.GetName(), kubeClient)
+				if err != nil || !found {
+					continue
+				}
+
+				ret.Scales = append(ret.Scales, getDaemonSetWorkloadResourceWithCache(ds, inf, log))
+
+			case setting.Job:
+				job, found, err := getter.GetJob(namespace, u.GetName(), kubeClient)
+				if err != nil || !found {
+					continue
+				}
+
+				ret.Scales = append(ret.Scales, getJobWorkloadResourceWithCache(job, inf, log))
+
+			case setting.CronJob:
+				scale, err := getCronJobWorkloadResource(namespace, u.GetName(), clientset, kubeClient, inf, log)
+				if err != nil {
+					continue
+				}
+
+				ret.Scales = append(ret.Scales, scale)
+
 			case setting.Ingress:
 
 				version, err := clientset.Discovery().ServerVersion()
@@ -354,6 +529,9 @@ func RestartService(envName string, args *SvcOptArgs, log *zap.SugaredLogger) (e
 	if err != nil {
 		return err
 	}
+	if err := checkNotGitOps(productObj); err != nil {
+		return e.ErrRestartService.AddErr(err)
+	}
 	kubeClient, err := kubeclient.GetKubeClient(config.HubServerAddress(), productObj.ClusterID)
 	if err != nil {
 		return err
@@ -513,6 +691,10 @@ func queryPodsStatus(productInfo *commonmodels.Product, serviceName string, kube
 	}
 
 	if len(pods) == 0 {
+		// CronJobs have no pods between two schedules, which is the normal state
+		if len(svcResp.Scales) > 0 && onlyCronJobs(svcResp.Scales) {
+			return string(corev1.PodSucceeded), setting.JobReady, nil
+		}
 		return setting.PodNonStarted, setting.PodNotReady, nil
 	}
 
@@ -602,6 +784,67 @@ func getDeploymentWorkloadResource(d *appsv1.Deployment, informer informers.Shar
 	return wrapper.Deployment(d).WorkloadResource(pods)
 }
 
+func getDaemonSetWorkloadResourceWithCache(ds *appsv1.DaemonSet, informer informers.SharedInformerFactory, log *zap.SugaredLogger) *internalresource.Workload {
+	pods, err := getter.ListPodsWithCache(labels.SelectorFromValidatedSet(ds.Spec.Selector.MatchLabels), informer)
+	if err != nil {
+		log.Warnf("Failed to get pods, err: %s", err)
+	}
+
+	return wrapper.Daemenset(ds).WorkloadResource(pods)
+}
+
+func getJobWorkloadResourceWithCache(job *batchv1.Job, informer informers.SharedInformerFactory, log *zap.SugaredLogger) *internalresource.Workload {
+	pods, err := getter.ListPodsWithCache(labels.SelectorFromValidatedSet(job.Spec.Selector.MatchLabels), informer)
+	if err != nil {
+		log.Warnf("Failed to get pods, err: %s", err)
+	}
+
+	return wrapper.Job(job).WorkloadResource(pods)
+}
+
+// getCronJobWorkloadResource returns the workload of the CronJob, only the pods of the active jobs are included.
+func getCronJobWorkloadResource(namespace, name string, clientset *kubernetes.Clientset, kubeClient client.Client, informer informers.SharedInformerFactory, log *zap.SugaredLogger) (*internalresource.Workload, error) {
+	version, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	listActivePods := func(active []corev1.ObjectReference) []*corev1.Pod {
+		pods := make([]*corev1.Pod, 0)
+		for _, ref := range active {
+			jobPods, err := getter.ListPodsWithCache(labels.SelectorFromValidatedSet(labels.Set{"job-name": ref.Name}), informer)
+			if err != nil {
+				log.Warnf("Failed to get pods, err: %s", err)
+				continue
+			}
+			pods = append(pods, jobPods...)
+		}
+		return pods
+	}
+
+	if VersionLessThan121(version) {
+		cj, found, err := getter.GetCronJobV1Beta(namespace, name, kubeClient)
+		if err != nil || !found {
+			return nil, fmt.Errorf("cronJob: %s does not exist", name)
+		}
+		return wrapper.CronJobV1Beta(cj).WorkloadResource(listActivePods(cj.Status.Active)), nil
+	}
+	cj, found, err := getter.GetCronJob(namespace, name, kubeClient)
+	if err != nil || !found {
+		return nil, fmt.Errorf("cronJob: %s does not exist", name)
+	}
+	return wrapper.CronJob(cj).WorkloadResource(listActivePods(cj.Status.Active)), nil
+}
+
+func onlyCronJobs(scales []*internalresource.Workload) bool {
+	for _, scale := range scales {
+		if scale.Type != setting.CronJob {
+			return false
+		}
+	}
+	return true
+}
+
 func getStatefulSetWorkloadResource(sts *appsv1.StatefulSet, informer informers.SharedInformerFactory, log *zap.SugaredLogger) *internalresource.Workload {
 	pods, err := getter.ListPodsWithCache(labels.SelectorFromValidatedSet(sts.Spec.Selector.MatchLabels), informer)
 	if err != nil {
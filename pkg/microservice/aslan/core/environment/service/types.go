@@ -87,6 +87,27 @@ type ScaleArgs struct {
 	Number      int    `json:"number"`
 }
 
+type SuspendCronJobArgs struct {
+	ProductName string `json:"product_name"`
+	EnvName     string `json:"env_name"`
+	ServiceName string `json:"service_name"`
+	Name        string `json:"name"`
+	Suspend     bool   `json:"suspend"`
+}
+
+// CronJobHistory is a job created by the CronJob
+type CronJobHistory struct {
+	Name           string `json:"name"`
+	Status         string `json:"status"`
+	CreateTime     int64  `json:"create_time"`
+	StartTime      int64  `json:"start_time,omitempty"`
+	CompletionTime int64  `json:"completion_time,omitempty"`
+	Duration       string `json:"duration"`
+	Active         int32  `json:"active"`
+	Succeeded      int32  `json:"succeeded"`
+	Failed         int32  `json:"failed"`
+}
+
 // SvcResp struct 产品-服务详情页面Response
 type SvcResp struct {
 	ServiceName string                       `json:"service_name"`
@@ -200,3 +221,125 @@ type MatchedEnv struct {
 	EnvName   string
 	Namespace string
 }
+
+// The OpenAPI types below are part of the public contract of the /openapi/v1/environments endpoints,
+// fields must not be renamed or removed, new fields should be optional.
+
+type OpenAPIEnvBrief struct {
+	ProjectName string `json:"project_name"`
+	EnvName     string `json:"env_name"`
+	Alias       string `json:"alias"`
+	Production  bool   `json:"production"`
+	ClusterID   string `json:"cluster_id"`
+	ClusterName string `json:"cluster_name"`
+	Namespace   string `json:"namespace"`
+	RegistryID  string `json:"registry_id"`
+	Source      string `json:"source"`
+	Status      string `json:"status"`
+	Error       string `json:"error"`
+	UpdateBy    string `json:"update_by"`
+	UpdateTime  int64  `json:"update_time"`
+}
+
+type OpenAPIEnvDetail struct {
+	*OpenAPIEnvBrief
+	Services []*OpenAPIEnvService `json:"services"`
+}
+
+type OpenAPIEnvService struct {
+	ServiceName string              `json:"service_name"`
+	Type        string              `json:"type"`
+	Revision    int64               `json:"revision"`
+	Containers  []*OpenAPIContainer `json:"containers"`
+}
+
+type OpenAPIContainer struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+type OpenAPICreateEnvReq struct {
+	// ProjectName is read from the query so that it is the same project as the authorized one
+	ProjectName string `json:"-"`
+	EnvName     string `json:"env_name"`
+	Alias       string `json:"alias"`
+	Production  bool   `json:"production"`
+	ClusterID   string `json:"cluster_id"`
+	Namespace   string `json:"namespace"`
+	RegistryID  string `json:"registry_id"`
+	// BaseEnvName copies the services and variables of an existing environment if set,
+	// otherwise the environment is created from the latest service templates.
+	BaseEnvName string `json:"base_env_name"`
+	// Services limits the services deployed into the environment, all services are deployed if it is empty.
+	Services      []string `json:"services"`
+	DefaultValues string   `json:"default_values"`
+}
+
+func (req *OpenAPICreateEnvReq) Validate() (bool, error) {
+	if req.ProjectName == "" {
+		return false, fmt.Errorf("project name cannot be empty")
+	}
+	if req.EnvName == "" {
+		return false, fmt.Errorf("env name cannot be empty")
+	}
+	if req.ClusterID == "" {
+		return false, fmt.Errorf("cluster id cannot be empty")
+	}
+	return true, nil
+}
+
+type OpenAPIUpdateServiceImagesReq struct {
+	ProjectName string                 `json:"-"`
+	UpdateBy    string                 `json:"-"`
+	Images      []*OpenAPIServiceImage `json:"images"`
+}
+
+type OpenAPIServiceImage struct {
+	ServiceName   string `json:"service_name"`
+	ContainerName string `json:"container_name"`
+	Image         string `json:"image"`
+}
+
+func (req *OpenAPIUpdateServiceImagesReq) Validate() (bool, error) {
+	if req.ProjectName == "" {
+		return false, fmt.Errorf("project name cannot be empty")
+	}
+	if len(req.Images) == 0 {
+		return false, fmt.Errorf("images must have at least one item in it")
+	}
+	for _, image := range req.Images {
+		if image.ServiceName == "" || image.ContainerName == "" || image.Image == "" {
+			return false, fmt.Errorf("service name, container name and image of each item cannot be empty")
+		}
+	}
+	return true, nil
+}
+
+type OpenAPIEnvVariables struct {
+	DefaultValues string                    `json:"default_values"`
+	Services      []*OpenAPIServiceVariable `json:"services"`
+}
+
+type OpenAPIServiceVariable struct {
+	ServiceName string `json:"service_name"`
+	// VariableYaml is the variable yaml of k8s yaml services, or the override values yaml of helm services.
+	VariableYaml string `json:"variable_yaml"`
+	// OverrideValues is only used by helm services, json-encoded key value pairs.
+	OverrideValues string `json:"override_values,omitempty"`
+}
+
+type OpenAPIServiceStatus struct {
+	ServiceName string             `json:"service_name"`
+	EnvName     string             `json:"env_name"`
+	Status      string             `json:"status"`
+	Ready       string             `json:"ready"`
+	Workloads   []*OpenAPIWorkload `json:"workloads"`
+}
+
+type OpenAPIWorkload struct {
+	Name          string              `json:"name"`
+	Type          string              `json:"type"`
+	Replicas      int32               `json:"replicas"`
+	ReadyReplicas int32               `json:"ready_replicas"`
+	Images        []*OpenAPIContainer `json:"images"`
+}
@@ -26,4 +26,68 @@ CREATE TABLE IF NOT EXISTS `user`(
     `updated_at` int(11) unsigned NOT NULL COMMENT '修改时间',
     UNIQUE KEY `account` (`account`,`identity_type`),
     PRIMARY KEY (`uid`)
-) ENGINE = InnoDB AUTO_INCREMENT = 59 CHARACTER SET = utf8 COLLATE = utf8_general_ci COMMENT = '用户信息表' ROW_FORMAT = Compact;
\ No newline at end of file
+) ENGINE = InnoDB AUTO_INCREMENT = 59 CHARACTER SET = utf8 COLLATE = utf8_general_ci COMMENT = '用户信息表' ROW_FORMAT = Compact;
+
+CREATE TABLE IF NOT EXISTS `user_group`(
+    `group_id` varchar(64) NOT NULL COMMENT '用户组ID',
+    `name` varchar(64) NOT NULL DEFAULT '' COMMENT '用户组名称',
+    `description` varchar(255) NOT NULL DEFAULT '' COMMENT '描述',
+    `identity_type` varchar(32) NOT NULL DEFAULT 'system' COMMENT '用户组来源',
+    `created_at` int(11) unsigned NOT NULL COMMENT '创建时间',
+    `updated_at` int(11) unsigned NOT NULL COMMENT '修改时间',
+    UNIQUE KEY `name` (`name`,`identity_type`),
+    PRIMARY KEY (`group_id`)
+) ENGINE = InnoDB CHARACTER SET = utf8 COLLATE = utf8_general_ci COMMENT = '用户组表' ROW_FORMAT = Compact;
+
+CREATE TABLE IF NOT EXISTS `group_binding`(
+    `id` bigint(20) NOT NULL AUTO_INCREMENT,
+    `group_id` varchar(64) NOT NULL COMMENT '用户组ID',
+    `uid` varchar(64) NOT NULL COMMENT '用户ID',
+    `created_at` int(11) unsigned NOT NULL COMMENT '创建时间',
+    `updated_at` int(11) unsigned NOT NULL COMMENT '修改时间',
+    UNIQUE KEY `binding` (`group_id`,`uid`),
+    PRIMARY KEY (`id`),
+    KEY `idx_uid` (`uid`) USING BTREE
+) ENGINE = InnoDB CHARACTER SET = utf8 COLLATE = utf8_general_ci COMMENT = '用户组成员表' ROW_FORMAT = Compact;
+
+CREATE TABLE IF NOT EXISTS `api_token`(
+    `token_id` varchar(64) NOT NULL COMMENT 'Token ID',
+    `uid` varchar(64) NOT NULL COMMENT '用户ID',
+    `name` varchar(64) NOT NULL DEFAULT '' COMMENT 'Token名称',
+    `scopes` text COMMENT 'Token权限范围',
+    `token_hash` varchar(64) NOT NULL COMMENT 'Token的sha256值',
+    `expires_at` int(11) unsigned NOT NULL COMMENT '过期时间',
+    `last_used_at` int(11) unsigned NOT NULL DEFAULT 0 COMMENT '最后使用时间',
+    `revoked` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否已吊销',
+    `created_at` int(11) unsigned NOT NULL COMMENT '创建时间',
+    `updated_at` int(11) unsigned NOT NULL COMMENT '修改时间',
+    UNIQUE KEY `name` (`uid`,`name`),
+    PRIMARY KEY (`token_id`)
+) ENGINE = InnoDB CHARACTER SET = utf8 COLLATE = utf8_general_ci COMMENT = 'API Token表' ROW_FORMAT = Compact;
+
+CREATE TABLE IF NOT EXISTS `user_mfa`(
+    `uid` varchar(64) NOT NULL COMMENT '用户ID',
+    `secret` varchar(255) NOT NULL COMMENT '加密后的TOTP密钥',
+    `enabled` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否已启用',
+    `recovery_codes` text COMMENT '恢复码的sha256值',
+    `created_at` int(11) unsigned NOT NULL COMMENT '创建时间',
+    `updated_at` int(11) unsigned NOT NULL COMMENT '修改时间',
+    PRIMARY KEY (`uid`)
+) ENGINE = InnoDB CHARACTER SET = utf8 COLLATE = utf8_general_ci COMMENT = '用户MFA表' ROW_FORMAT = Compact;
+
+CREATE TABLE IF NOT EXISTS `user_mfa_attempt`(
+    `uid` varchar(64) NOT NULL COMMENT '用户ID',
+    `failed_count` int(11) NOT NULL DEFAULT 0 COMMENT '连续失败次数',
+    `locked_until` int(11) unsigned NOT NULL DEFAULT 0 COMMENT '锁定截止时间',
+    `last_step` bigint(20) NOT NULL DEFAULT 0 COMMENT '最后一次通过校验的TOTP时间步',
+    `created_at` int(11) unsigned NOT NULL COMMENT '创建时间',
+    `updated_at` int(11) unsigned NOT NULL COMMENT '修改时间',
+    PRIMARY KEY (`uid`)
+) ENGINE = InnoDB CHARACTER SET = utf8 COLLATE = utf8_general_ci COMMENT = '用户MFA校验记录表' ROW_FORMAT = Compact;
+
+CREATE TABLE IF NOT EXISTS `mfa_enforced_role`(
+    `role` varchar(64) NOT NULL COMMENT '系统角色名称',
+    `created_at` int(11) unsigned NOT NULL COMMENT '创建时间',
+    `updated_at` int(11) unsigned NOT NULL COMMENT '修改时间',
+    PRIMARY KEY (`role`)
+) ENGINE = InnoDB CHARACTER SET = utf8 COLLATE = utf8_general_ci COMMENT = '强制MFA的角色表' ROW_FORMAT = Compact;
@@ -288,3 +288,41 @@ func CreateOrUpdateMatchRules(c *gin.Context) {
 
 	ctx.Err = projectservice.UpdateCustomMatchRules(c.Param("name"), ctx.UserName, ctx.RequestID, args.Rules)
 }
+
+func GetPreviewEnvSetting(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if c.Param("name") == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("productName can not be null!")
+		return
+	}
+
+	ctx.Resp, ctx.Err = projectservice.GetPreviewEnvSetting(c.Param("name"), ctx.Logger)
+}
+
+func UpdatePreviewEnvSetting(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	if c.Param("name") == "" {
+		ctx.Err = e.ErrInvalidParam.AddDesc("productName can not be null!")
+		return
+	}
+
+	args := new(template.PreviewEnvSetting)
+	data, err := c.GetRawData()
+	if err != nil {
+		log.Errorf("UpdatePreviewEnvSetting c.GetRawData() err : %v", err)
+		ctx.Err = e.ErrInvalidParam
+		return
+	}
+	if err = json.Unmarshal(data, args); err != nil {
+		log.Errorf("UpdatePreviewEnvSetting json.Unmarshal err : %v", err)
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, c.Param("name"), "更新", "项目管理-预览环境", c.Param("name"), string(data), ctx.Logger)
+
+	ctx.Err = projectservice.UpdatePreviewEnvSetting(c.Param("name"), ctx.UserName, args, ctx.Logger)
+}
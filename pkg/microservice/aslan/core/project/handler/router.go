@@ -39,6 +39,8 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		product.GET("/:name/services", GetProductTemplateServices)
 		product.GET("/:name/searching-rules", GetCustomMatchRules)
 		product.PUT("/:name/searching-rules", CreateOrUpdateMatchRules)
+		product.GET("/:name/preview-env", GetPreviewEnvSetting)
+		product.PUT("/:name/preview-env", UpdatePreviewEnvSetting)
 		product.POST("", CreateProductTemplate)
 		product.PUT("/:name", UpdateProductTemplate)
 		product.PUT("/:name/:status", UpdateProductTmplStatus)
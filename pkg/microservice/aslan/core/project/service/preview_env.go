@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/template"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	templaterepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+func GetPreviewEnvSetting(productName string, log *zap.SugaredLogger) (*template.PreviewEnvSetting, error) {
+	productInfo, err := templaterepo.NewProductColl().Find(productName)
+	if err != nil {
+		log.Errorf("query product:%s fail, err:%s", productName, err.Error())
+		return nil, e.ErrGetProduct.AddDesc(fmt.Sprintf("failed to find product %s", productName))
+	}
+
+	if productInfo.PreviewEnv == nil {
+		return &template.PreviewEnvSetting{Repos: []*template.PreviewEnvRepo{}, Services: []string{}}, nil
+	}
+	return productInfo.PreviewEnv, nil
+}
+
+func UpdatePreviewEnvSetting(productName, userName string, args *template.PreviewEnvSetting, log *zap.SugaredLogger) error {
+	if _, err := templaterepo.NewProductColl().Find(productName); err != nil {
+		log.Errorf("query product:%s fail, err:%s", productName, err.Error())
+		return e.ErrUpdateProduct.AddDesc(fmt.Sprintf("failed to find product %s", productName))
+	}
+
+	if args.Enabled {
+		if err := validatePreviewEnvSetting(productName, args); err != nil {
+			return e.ErrUpdateProduct.AddErr(err)
+		}
+	}
+
+	if err := templaterepo.NewProductColl().UpdatePreviewEnv(productName, args, userName); err != nil {
+		log.Errorf("failed to update product:%s, err:%s", productName, err.Error())
+		return e.ErrUpdateProduct.AddDesc("failed to store preview environment setting")
+	}
+	return nil
+}
+
+func validatePreviewEnvSetting(productName string, args *template.PreviewEnvSetting) error {
+	productInfo, err := templaterepo.NewProductColl().Find(productName)
+	if err != nil {
+		return err
+	}
+	if !productInfo.IsK8sYamlProduct() {
+		return fmt.Errorf("preview environment is only supported in k8s yaml projects")
+	}
+	if len(args.Repos) == 0 {
+		return fmt.Errorf("at least one repo should be specified")
+	}
+	if args.TTL < 0 {
+		return fmt.Errorf("invalid ttl: %d", args.TTL)
+	}
+
+	baseEnv, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: args.BaseEnv})
+	if err != nil {
+		return fmt.Errorf("failed to find base environment %s, err: %s", args.BaseEnv, err)
+	}
+	if !baseEnv.ShareEnv.Enable || !baseEnv.ShareEnv.IsBase {
+		return fmt.Errorf("environment %s is not a base environment", args.BaseEnv)
+	}
+
+	workflow, err := commonrepo.NewWorkflowV4Coll().Find(args.WorkflowName)
+	if err != nil {
+		return fmt.Errorf("failed to find workflow %s, err: %s", args.WorkflowName, err)
+	}
+	if workflow.Project != productName {
+		return fmt.Errorf("workflow %s does not belong to project %s", args.WorkflowName, productName)
+	}
+	return nil
+}
@@ -35,6 +35,7 @@ import (
 	modeMongodb "github.com/koderover/zadig/pkg/microservice/aslan/core/collaboration/repository/mongodb"
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/cloudevent"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/kube"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/nsq"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/webhook"
@@ -48,6 +49,7 @@ import (
 	templateservice "github.com/koderover/zadig/pkg/microservice/aslan/core/templatestore/service"
 	workflowservice "github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow"
 	policydb "github.com/koderover/zadig/pkg/microservice/policy/core/repository/mongodb"
+	policycoreservice "github.com/koderover/zadig/pkg/microservice/policy/core/service"
 	policybundle "github.com/koderover/zadig/pkg/microservice/policy/core/service/bundle"
 	configmongodb "github.com/koderover/zadig/pkg/microservice/systemconfig/core/email/repository/mongodb"
 	configservice "github.com/koderover/zadig/pkg/microservice/systemconfig/core/features/service"
@@ -65,6 +67,8 @@ import (
 const (
 	webhookController = iota
 	bundleController
+	auditLogController
+	accessSweeperController
 )
 
 type policyGetter interface {
@@ -77,12 +81,16 @@ type Controller interface {
 
 func StartControllers(stopCh <-chan struct{}) {
 	controllerWorkers := map[int]int{
-		webhookController: 1,
-		bundleController:  1,
+		webhookController:       1,
+		bundleController:        1,
+		auditLogController:      1,
+		accessSweeperController: 1,
 	}
 	controllers := map[int]Controller{
-		webhookController: webhook.NewWebhookController(),
-		bundleController:  policybundle.NewBundleController(),
+		webhookController:       webhook.NewWebhookController(),
+		bundleController:        policybundle.NewBundleController(),
+		auditLogController:      systemservice.NewAuditLogController(),
+		accessSweeperController: policycoreservice.NewAccessSweeper(),
 	}
 
 	var wg sync.WaitGroup
@@ -189,6 +197,9 @@ func initService() {
 	if err := workflowservice.SubScribeNSQ(); err != nil {
 		errors = multierror.Append(errors, err)
 	}
+	if err := cloudevent.SubscribeNSQ(); err != nil {
+		errors = multierror.Append(errors, err)
+	}
 }
 
 func initDinD() {
@@ -285,6 +296,12 @@ func initDatabase() {
 		commonrepo.NewWorkLoadsStatColl(),
 		commonrepo.NewServicesInExternalEnvColl(),
 		commonrepo.NewExternalLinkColl(),
+		commonrepo.NewChangeFreezeWindowColl(),
+		commonrepo.NewChangeFreezeOverrideColl(),
+		commonrepo.NewSecretProviderColl(),
+		commonrepo.NewEventSubscriptionColl(),
+		commonrepo.NewEventDeliveryColl(),
+		commonrepo.NewTerminalSessionColl(),
 		commonrepo.NewChartColl(),
 		commonrepo.NewDockerfileTemplateColl(),
 		commonrepo.NewProjectClusterRelationColl(),
@@ -302,6 +319,8 @@ func initDatabase() {
 
 		systemrepo.NewAnnouncementColl(),
 		systemrepo.NewOperationLogColl(),
+		systemrepo.NewAuditLogConfigColl(),
+		systemrepo.NewAuditChainAnchorColl(),
 		labelMongodb.NewLabelColl(),
 		labelMongodb.NewLabelBindingColl(),
 		modeMongodb.NewCollaborationModeColl(),
@@ -313,6 +332,7 @@ func initDatabase() {
 		// policy related db index
 		policydb.NewRoleColl(),
 		policydb.NewRoleBindingColl(),
+		policydb.NewAccessRequestColl(),
 		policydb.NewPolicyMetaColl(),
 
 		// user related db index
@@ -1337,6 +1337,7 @@ func geneCreationDetail(args *helmServiceCreationArgs) interface{} {
 		setting.SourceFromGitee,
 		setting.SourceFromGiteeEE,
 		setting.SourceFromCodeHub,
+		setting.SourceFromGitea,
 		// FIXME this is a temporary solution, remove when possible
 		setting.SourceFromGitRepo:
 		return &models.CreateFromRepo{
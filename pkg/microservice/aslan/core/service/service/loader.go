@@ -32,9 +32,11 @@ import (
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/config"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	bitbucketservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/bitbucket"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/command"
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
+	"github.com/koderover/zadig/pkg/tool/bitbucket"
 	"github.com/koderover/zadig/pkg/tool/codehub"
 	e "github.com/koderover/zadig/pkg/tool/errors"
 	"github.com/koderover/zadig/pkg/tool/gerrit"
@@ -60,13 +62,14 @@ func PreloadServiceFromCodeHost(codehostID int, repoOwner, repoName, repoUUID, b
 		return nil, e.ErrPreloadServiceTemplate.AddDesc(err.Error())
 	}
 	switch ch.Type {
-	case setting.SourceFromGithub, setting.SourceFromGitlab:
+	case setting.SourceFromGithub, setting.SourceFromGitlab, setting.SourceFromGitea:
 		ret, err = preloadService(ch, repoOwner, repoName, branchName, path, isDir, log)
 	case setting.SourceFromGerrit:
 		ret, err = preloadGerritService(ch, repoName, branchName, remoteName, path, isDir)
 	case setting.SourceFromCodeHub:
 		ret, err = preloadCodehubService(ch, repoName, repoUUID, branchName, path, isDir)
-	case setting.SourceFromGitee, setting.SourceFromGiteeEE:
+	case setting.SourceFromGitee, setting.SourceFromGiteeEE, setting.SourceFromBitbucket:
+		// both are loaded from a local clone of the repo
 		ret, err = preloadGiteeService(ch, repoOwner, repoName, branchName, remoteName, path, isDir)
 	default:
 		return nil, e.ErrPreloadServiceTemplate.AddDesc("Not supported code source")
@@ -83,7 +86,7 @@ func LoadServiceFromCodeHost(username string, codehostID int, repoOwner, namespa
 		return e.ErrLoadServiceTemplate.AddDesc(err.Error())
 	}
 	switch ch.Type {
-	case setting.SourceFromGithub, setting.SourceFromGitlab:
+	case setting.SourceFromGithub, setting.SourceFromGitlab, setting.SourceFromGitea:
 		return loadService(username, ch, repoOwner, namespace, repoName, branchName, args, force, log)
 	case setting.SourceFromGerrit:
 		return loadGerritService(username, ch, repoOwner, repoName, branchName, remoteName, args, force, log)
@@ -91,6 +94,8 @@ func LoadServiceFromCodeHost(username string, codehostID int, repoOwner, namespa
 		return loadCodehubService(username, ch, repoOwner, repoName, repoUUID, branchName, args, force, log)
 	case setting.SourceFromGitee, setting.SourceFromGiteeEE:
 		return loadGiteeService(username, ch, repoOwner, repoName, branchName, remoteName, args, force, log)
+	case setting.SourceFromBitbucket:
+		return loadBitbucketService(username, ch, repoOwner, repoName, branchName, remoteName, args, force, log)
 	default:
 		return e.ErrLoadServiceTemplate.AddDesc("unsupported code source")
 	}
@@ -108,11 +113,13 @@ func ValidateServiceUpdate(codehostID int, serviceName, repoOwner, repoName, rep
 		return validateServiceUpdateGithub(detail, serviceName, repoOwner, repoName, branchName, path, isDir)
 	case setting.SourceFromGitlab:
 		return validateServiceUpdateGitlab(detail, serviceName, repoOwner, repoName, branchName, path, isDir)
+	case setting.SourceFromGitea:
+		return validateServiceUpdateGitea(detail, serviceName, repoOwner, repoName, branchName, path, isDir)
 	case setting.SourceFromGerrit:
 		return validateServiceUpdateGerrit(detail, serviceName, repoName, branchName, remoteName, path, isDir)
 	case setting.SourceFromCodeHub:
 		return validateServiceUpdateCodehub(detail, serviceName, repoName, repoUUID, branchName, path, isDir)
-	case setting.SourceFromGitee, setting.SourceFromGiteeEE:
+	case setting.SourceFromGitee, setting.SourceFromGiteeEE, setting.SourceFromBitbucket:
 		return validateServiceUpdateGitee(detail, serviceName, repoOwner, repoName, branchName, remoteName, path, isDir)
 	default:
 		return e.ErrValidateServiceUpdate.AddDesc("Not supported code source")
@@ -712,6 +719,132 @@ func loadServiceFromGitee(tree []os.FileInfo, ch *systemconfig.CodeHost, usernam
 	return err
 }
 
+// Load services from bitbucket based on repo information
+func loadBitbucketService(username string, ch *systemconfig.CodeHost, repoOwner, repoName, branchName, remoteName string, args *LoadServiceReq, force bool, log *zap.SugaredLogger) error {
+	if remoteName == "" {
+		remoteName = "origin"
+	}
+	base := path.Join(config.S3StoragePath(), repoName)
+	if _, err := os.Stat(base); os.IsNotExist(err) {
+		err = command.RunGitCmds(ch, repoOwner, repoOwner, repoName, branchName, remoteName)
+		if err != nil {
+			return e.ErrLoadServiceTemplate.AddDesc(err.Error())
+		}
+	}
+
+	bitbucketCli, err := bitbucketservice.NewClient(ch.ID, ch.Address, ch.Username, ch.AccessToken, config.ProxyHTTPSAddr(), ch.EnableProxy)
+	if err != nil {
+		log.Errorf("Failed to create bitbucket client, the error is: %s", err)
+		return e.ErrLoadServiceTemplate.AddDesc(err.Error())
+	}
+	branch, err := bitbucketCli.GetBranch(repoOwner, repoName, branchName)
+	if err != nil {
+		log.Errorf("Failed to get latest commit info from repo: %s, the error is: %s", repoName, err)
+		return e.ErrLoadServiceTemplate.AddDesc(err.Error())
+	}
+	commitInfo := &models.Commit{
+		SHA:     branch.LatestCommit,
+		Message: branch.Message,
+	}
+
+	filePath := path.Join(base, args.LoadPath)
+	if !args.LoadFromDir {
+		contentBytes, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			log.Errorf("Failed to read file of path: %s, the error is: %s", args.LoadPath, err)
+			return e.ErrLoadServiceTemplate.AddDesc(err.Error())
+		}
+		pathSegments := strings.Split(args.LoadPath, "/")
+		fileName := pathSegments[len(pathSegments)-1]
+		createSvcArgs := &models.Service{
+			CodehostID:  ch.ID,
+			RepoName:    repoName,
+			RepoOwner:   repoOwner,
+			BranchName:  branchName,
+			SrcPath:     bitbucket.BrowseURL(ch.Address, repoOwner, repoName, branchName, args.LoadPath),
+			LoadPath:    args.LoadPath,
+			LoadFromDir: args.LoadFromDir,
+			KubeYamls:   SplitYaml(string(contentBytes)),
+			CreateBy:    username,
+			ServiceName: getFileName(fileName),
+			Type:        args.Type,
+			ProductName: args.ProductName,
+			Source:      setting.SourceFromBitbucket,
+			Yaml:        string(contentBytes),
+			Commit:      commitInfo,
+			Visibility:  args.Visibility,
+		}
+		return createBitbucketServiceTemplate(username, createSvcArgs, force, log)
+	}
+	fileInfos, err := ioutil.ReadDir(filePath)
+	if err != nil {
+		log.Errorf("Failed to read directory info of path: %s, the error is: %s", filePath, err)
+		return e.ErrLoadServiceTemplate.AddDesc(err.Error())
+	}
+	if isValidServiceDir(fileInfos) {
+		return loadServiceFromBitbucket(fileInfos, ch, username, branchName, args.LoadPath, filePath, repoOwner, repoName, args, commitInfo, force, log)
+	}
+	for _, entry := range fileInfos {
+		subtreeLoadPath := fmt.Sprintf("%s/%s", args.LoadPath, entry.Name())
+		subtreePath := fmt.Sprintf("%s/%s", filePath, entry.Name())
+		subtreeInfo, err := ioutil.ReadDir(subtreePath)
+		if err != nil {
+			log.Errorf("Failed to read subdir info from bitbucket package of path: %s, the error is: %s", subtreePath, err)
+			return e.ErrLoadServiceTemplate.AddDesc(err.Error())
+		}
+		if isValidServiceDir(subtreeInfo) {
+			if err := loadServiceFromBitbucket(subtreeInfo, ch, username, branchName, subtreeLoadPath, subtreePath, repoOwner, repoName, args, commitInfo, force, log); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func loadServiceFromBitbucket(tree []os.FileInfo, ch *systemconfig.CodeHost, username, branchName, loadPath, path, repoOwner, repoName string, args *LoadServiceReq, commit *models.Commit, force bool, log *zap.SugaredLogger) error {
+	pathList := strings.Split(path, "/")
+	var splittedYaml []string
+	yamlList, err := extractYamls(path, tree)
+	if err != nil {
+		log.Errorf("Failed to extract yamls from bitbucket package, the error is: %+v", err)
+		return err
+	}
+	for _, yamlEntry := range yamlList {
+		splittedYaml = append(splittedYaml, SplitYaml(yamlEntry)...)
+	}
+	createSvcArgs := &models.Service{
+		CodehostID:  ch.ID,
+		BranchName:  branchName,
+		RepoName:    repoName,
+		RepoOwner:   repoOwner,
+		LoadPath:    loadPath,
+		SrcPath:     bitbucket.BrowseURL(ch.Address, repoOwner, repoName, branchName, loadPath),
+		LoadFromDir: args.LoadFromDir,
+		KubeYamls:   splittedYaml,
+		CreateBy:    username,
+		ServiceName: getFileName(pathList[len(pathList)-1]),
+		Type:        args.Type,
+		ProductName: args.ProductName,
+		Source:      setting.SourceFromBitbucket,
+		Yaml:        joinYamls(yamlList),
+		Commit:      commit,
+		Visibility:  args.Visibility,
+	}
+	return createBitbucketServiceTemplate(username, createSvcArgs, force, log)
+}
+
+func createBitbucketServiceTemplate(username string, args *models.Service, force bool, log *zap.SugaredLogger) error {
+	_, err := CreateServiceTemplate(username, args, force, log)
+	if err != nil {
+		_, messageMap := e.ErrorMessage(err)
+		if description, ok := messageMap["description"]; ok {
+			return e.ErrLoadServiceTemplate.AddDesc(description.(string))
+		}
+		return e.ErrLoadServiceTemplate.AddDesc("Load Service Error for unknown reason")
+	}
+	return nil
+}
+
 // 根据github repo决定服务是否可以更新这个repo地址
 func validateServiceUpdateGithub(detail *systemconfig.CodeHost, serviceName, repoOwner, repoName, branchName, path string, isDir bool) error {
 	ctx := context.Background()
@@ -953,6 +1086,46 @@ func validateServiceUpdateGitee(detail *systemconfig.CodeHost, serviceName, repo
 	return e.ErrValidateServiceUpdate.AddDesc("所选路径中没有yaml，请重新选择")
 }
 
+// 根据gitea repo决定服务是否可以更新这个repo地址
+func validateServiceUpdateGitea(detail *systemconfig.CodeHost, serviceName, repoOwner, repoName, branchName, path string, isDir bool) error {
+	if !isDir {
+		if !isYaml(path) {
+			log.Errorf("trying to preload a non-yaml file, ending ...")
+			return e.ErrValidateServiceUpdate.AddDesc("Non-yaml service loading is not supported")
+		}
+		loadedName := getFileName(path)
+		if loadedName != serviceName {
+			log.Errorf("The loaded file name [%s] is the same as the service to be updated: [%s]", loadedName, serviceName)
+			return e.ErrValidateServiceUpdate.AddDesc("文件名称和服务名称不一致")
+		}
+		return nil
+	}
+
+	loader, err := getLoader(detail)
+	if err != nil {
+		return e.ErrValidateServiceUpdate.AddDesc(err.Error())
+	}
+	treeNodes, err := loader.GetTree(repoOwner, repoName, path, branchName)
+	if err != nil {
+		log.Errorf("Failed to get dir content from gitea with path: %s, the error is: %+v", path, err)
+		return e.ErrValidateServiceUpdate.AddDesc(err.Error())
+	}
+	if hasYAMLFiles(treeNodes) {
+		svcName := path
+		if path == "" {
+			svcName = repoName
+		}
+		pathList := strings.Split(svcName, "/")
+		folderName := pathList[len(pathList)-1]
+		if folderName != serviceName {
+			log.Errorf("The loaded file name [%s] is the same as the service to be updated: [%s]", folderName, serviceName)
+			return e.ErrValidateServiceUpdate.AddDesc("文件夹名称和服务名称不一致")
+		}
+		return nil
+	}
+	return e.ErrValidateServiceUpdate.AddDesc("所选路径中没有yaml，请重新选择")
+}
+
 func isValidGithubServiceDir(child []*github.RepositoryContent) bool {
 	for _, entry := range child {
 		if entry.GetType() == "file" && isYaml(entry.GetName()) {
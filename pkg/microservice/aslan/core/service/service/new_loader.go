@@ -27,11 +27,13 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	templaterepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb/template"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/git"
+	giteaservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/gitea"
 	githubservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/github"
 	gitlabservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/gitlab"
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
 	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/tool/gitea"
 	"github.com/koderover/zadig/pkg/tool/log"
 	"github.com/koderover/zadig/pkg/util"
 )
@@ -175,6 +177,10 @@ func loadService(username string, ch *systemconfig.CodeHost, owner, namespace, r
 		if !info.isDir {
 			pathType = "blob"
 		}
+		srcPath := fmt.Sprintf("%s/%s/%s/%s/%s/%s", ch.Address, namespace, repo, pathType, branch, info.path)
+		if ch.Type == setting.SourceFromGitea {
+			srcPath = gitea.BrowseURL(ch.Address, namespace, repo, branch, info.path)
+		}
 		createSvcArgs := &models.Service{
 			CodehostID:    ch.ID,
 			RepoName:      repo,
@@ -184,7 +190,7 @@ func loadService(username string, ch *systemconfig.CodeHost, owner, namespace, r
 			LoadPath:      info.path,
 			LoadFromDir:   info.isDir,
 			KubeYamls:     info.yamls,
-			SrcPath:       fmt.Sprintf("%s/%s/%s/%s/%s/%s", ch.Address, namespace, repo, pathType, branch, info.path),
+			SrcPath:       srcPath,
 			CreateBy:      username,
 			ServiceName:   serviceName,
 			Type:          args.Type,
@@ -243,6 +249,8 @@ func getLoader(ch *systemconfig.CodeHost) (yamlLoader, error) {
 		return githubservice.NewClient(ch.AccessToken, config.ProxyHTTPSAddr(), ch.EnableProxy), nil
 	case setting.SourceFromGitlab:
 		return gitlabservice.NewClient(ch.ID, ch.Address, ch.AccessToken, config.ProxyHTTPSAddr(), ch.EnableProxy)
+	case setting.SourceFromGitea:
+		return giteaservice.NewClient(ch.ID, ch.Address, ch.AccessToken, config.ProxyHTTPSAddr(), ch.EnableProxy)
 	default:
 		// should not have happened here
 		log.DPanicf("invalid source: %s", ch.Type)
@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/system/service"
+	"github.com/koderover/zadig/pkg/setting"
+	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+func ListChangeFreezeWindows(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.ListChangeFreezeWindows(c.Query("projectName"), ctx.Logger)
+}
+
+func ListActiveChangeFreezeWindows(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.ListActiveChangeFreezeWindows(c.Query("projectName"), ctx.Logger)
+}
+
+func CreateChangeFreezeWindow(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(commonmodels.ChangeFreezeWindow)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	args.UpdateBy = ctx.UserName
+
+	projectName := c.Query("projectName")
+	bs, _ := json.Marshal(args)
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectName, setting.OperationSceneSystem, "新增", "变更冻结窗口", args.Name, string(bs), ctx.Logger)
+
+	ctx.Err = service.CreateChangeFreezeWindow(projectName, args, ctx.Logger)
+}
+
+func UpdateChangeFreezeWindow(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(commonmodels.ChangeFreezeWindow)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	args.UpdateBy = ctx.UserName
+
+	projectName := c.Query("projectName")
+	bs, _ := json.Marshal(args)
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectName, setting.OperationSceneSystem, "更新", "变更冻结窗口", args.Name, string(bs), ctx.Logger)
+
+	ctx.Err = service.UpdateChangeFreezeWindow(c.Param("id"), projectName, args, ctx.Logger)
+}
+
+func DeleteChangeFreezeWindow(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	projectName := c.Query("projectName")
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectName, setting.OperationSceneSystem, "删除", "变更冻结窗口", c.Param("id"), "", ctx.Logger)
+
+	ctx.Err = service.DeleteChangeFreezeWindow(c.Param("id"), projectName, ctx.Logger)
+}
+
+func ListChangeFreezeOverrides(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.ListChangeFreezeOverrides(&commonrepo.ListChangeFreezeOverrideOption{
+		ProjectName:  c.Query("projectName"),
+		WorkflowName: c.Query("workflowName"),
+		Status:       c.Query("status"),
+	}, ctx.Logger)
+}
+
+func CreateChangeFreezeOverride(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(service.ChangeFreezeOverrideArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	bs, _ := json.Marshal(args)
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, args.ProjectName, setting.OperationSceneWorkflow, "申请", "变更冻结紧急豁免", args.WorkflowName, string(bs), ctx.Logger, args.WorkflowName)
+
+	ctx.Resp, ctx.Err = service.CreateChangeFreezeOverride(args, ctx.UserName, ctx.UserID, ctx.Logger)
+}
+
+func ReviewChangeFreezeOverride(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(service.ReviewChangeFreezeOverrideArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	method := "拒绝"
+	if args.Approve {
+		method = "批准"
+	}
+	bs, _ := json.Marshal(args)
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, c.Query("projectName"), setting.OperationSceneWorkflow, method, "变更冻结紧急豁免", fmt.Sprintf("id:%s", c.Param("id")), string(bs), ctx.Logger)
+
+	ctx.Err = service.ReviewChangeFreezeOverride(c.Param("id"), args, ctx.UserName, ctx.UserID, ctx.Logger)
+}
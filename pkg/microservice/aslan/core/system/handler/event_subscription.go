@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/system/service"
+	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+type listEventDeliveriesQuery struct {
+	SubscriptionID string `form:"subscriptionID"`
+	EventType      string `form:"eventType"`
+	ProjectName    string `form:"projectName"`
+	Status         string `form:"status"`
+	PageSize       int64  `form:"page_size,default=20"`
+	PageNum        int64  `form:"page_num,default=1"`
+}
+
+func ListEventTypes(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp = service.ListEventTypes()
+}
+
+func ListEventSubscriptions(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.ListEventSubscriptions(ctx.Logger)
+}
+
+func CreateEventSubscription(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(commonmodels.EventSubscription)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, "", "新增", "系统配置-事件订阅", fmt.Sprintf("name:%s url:%s events:%s", args.Name, args.URL, strings.Join(args.EventTypes, ",")), "", ctx.Logger)
+
+	ctx.Err = service.CreateEventSubscription(ctx.UserName, args, ctx.Logger)
+}
+
+func UpdateEventSubscription(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(commonmodels.EventSubscription)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, "", "更新", "系统配置-事件订阅", fmt.Sprintf("name:%s url:%s events:%s", args.Name, args.URL, strings.Join(args.EventTypes, ",")), "", ctx.Logger)
+
+	ctx.Err = service.UpdateEventSubscription(c.Param("id"), ctx.UserName, args, ctx.Logger)
+}
+
+func DeleteEventSubscription(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, "", "删除", "系统配置-事件订阅", c.Param("id"), "", ctx.Logger)
+
+	ctx.Err = service.DeleteEventSubscription(c.Param("id"), ctx.Logger)
+}
+
+func ListEventDeliveries(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(listEventDeliveriesQuery)
+	if err := c.ShouldBindQuery(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	ctx.Resp, ctx.Err = service.ListEventDeliveries(&commonrepo.EventDeliveryListOption{
+		SubscriptionID: args.SubscriptionID,
+		EventType:      args.EventType,
+		ProjectName:    args.ProjectName,
+		Status:         args.Status,
+		PageNum:        args.PageNum,
+		PageSize:       args.PageSize,
+	}, ctx.Logger)
+}
+
+func GetEventDelivery(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.GetEventDelivery(c.Param("id"), ctx.Logger)
+}
+
+func RedeliverEvent(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	internalhandler.InsertOperationLog(c, ctx.UserName, "", "重新投递", "系统配置-事件订阅", c.Param("id"), "", ctx.Logger)
+
+	ctx.Err = service.RedeliverEvent(c.Param("id"), ctx.Logger)
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/lark"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/system/service"
 	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
 )
 
@@ -57,9 +58,18 @@ func LarkEventHandler(c *gin.Context) {
 		ctx.Err = err
 		return
 	}
-	ctx.Resp, ctx.Err = lark.EventHandler(
+	resp, message, err := lark.EventHandler(
 		c.Param("id"),
 		c.GetHeader("X-Lark-Signature"),
 		c.GetHeader("X-Lark-Request-Timestamp"),
 		c.GetHeader("X-Lark-Request-Nonce"), string(body))
+	if err != nil {
+		ctx.Err = err
+		return
+	}
+	// lark requires the event to be acknowledged in 3 seconds, the command is replied in another message
+	if message != nil {
+		go service.HandleLarkChatOpsMessage(message, ctx.Logger)
+	}
+	ctx.Resp = resp
 }
@@ -101,3 +101,47 @@ func UpdateOperationLog(c *gin.Context) {
 	}
 	ctx.Err = service.UpdateOperation(c.Param("id"), args.Status, ctx.Logger)
 }
+
+func GetOperationLogDiff(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.GetOperationLogDiff(c.Param("id"), ctx.Logger)
+}
+
+// VerifyOperationLogs verifies the chain, head_seq and head_hash are the head recorded by an export sink,
+// they are checked to detect the records removed from the end of the chain.
+func VerifyOperationLogs(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	var head *service.AuditChainHead
+	if c.Query("head_seq") != "" {
+		seq, err := strconv.ParseInt(c.Query("head_seq"), 10, 64)
+		if err != nil || seq <= 0 || c.Query("head_hash") == "" {
+			ctx.Err = e.ErrInvalidParam.AddDesc("head_seq must be a positive number and head_hash is required")
+			return
+		}
+		head = &service.AuditChainHead{Seq: seq, Hash: c.Query("head_hash")}
+	}
+	ctx.Resp, ctx.Err = service.VerifyOperationLogChain(head, ctx.Logger)
+}
+
+func GetAuditLogConfig(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.GetAuditLogConfig(ctx.Logger)
+}
+
+func UpdateAuditLogConfig(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(models2.AuditLogConfig)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	ctx.Err = service.UpdateAuditLogConfig(args, ctx.UserName, ctx.Logger)
+}
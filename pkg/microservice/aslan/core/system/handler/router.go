@@ -208,6 +208,62 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		operation.GET("", GetOperationLogs)
 		operation.POST("", AddSystemOperationLog)
 		operation.PUT("/:id", UpdateOperationLog)
+		operation.GET("/:id/diff", GetOperationLogDiff)
+		operation.GET("/verify", VerifyOperationLogs)
+		operation.GET("/config", GetAuditLogConfig)
+		operation.PUT("/config", UpdateAuditLogConfig)
+	}
+
+	// ---------------------------------------------------------------------------------------
+	// external secret provider
+	// ---------------------------------------------------------------------------------------
+	secretProvider := router.Group("secretProvider")
+	{
+		secretProvider.GET("", GetSecretProvider)
+		secretProvider.PUT("", UpdateSecretProvider)
+		secretProvider.DELETE("", DeleteSecretProvider)
+	}
+
+	// ---------------------------------------------------------------------------------------
+	// change freeze windows and emergency overrides
+	// ---------------------------------------------------------------------------------------
+	changeFreeze := router.Group("changeFreeze")
+	{
+		changeFreeze.GET("", ListChangeFreezeWindows)
+		changeFreeze.GET("/active", ListActiveChangeFreezeWindows)
+		changeFreeze.POST("", CreateChangeFreezeWindow)
+		changeFreeze.PUT("/:id", UpdateChangeFreezeWindow)
+		changeFreeze.DELETE("/:id", DeleteChangeFreezeWindow)
+		changeFreeze.GET("/overrides", ListChangeFreezeOverrides)
+		changeFreeze.POST("/overrides", CreateChangeFreezeOverride)
+		changeFreeze.POST("/overrides/:id/review", ReviewChangeFreezeOverride)
+	}
+
+	// ---------------------------------------------------------------------------------------
+	// terminal session recording
+	// ---------------------------------------------------------------------------------------
+	terminal := router.Group("terminal")
+	{
+		terminal.GET("/setting", GetTerminalSetting)
+		terminal.PUT("/setting", UpdateTerminalSetting)
+		terminal.GET("/sessions", ListTerminalSessions)
+		terminal.GET("/sessions/:id", GetTerminalSession)
+		terminal.GET("/sessions/:id/recording", GetTerminalSessionRecording)
+	}
+
+	// ---------------------------------------------------------------------------------------
+	// outbound event subscription
+	// ---------------------------------------------------------------------------------------
+	events := router.Group("events")
+	{
+		events.GET("/types", ListEventTypes)
+		events.GET("/subscriptions", ListEventSubscriptions)
+		events.POST("/subscriptions", CreateEventSubscription)
+		events.PUT("/subscriptions/:id", UpdateEventSubscription)
+		events.DELETE("/subscriptions/:id", DeleteEventSubscription)
+		events.GET("/deliveries", ListEventDeliveries)
+		events.GET("/deliveries/:id", GetEventDelivery)
+		events.POST("/deliveries/:id/redeliver", RedeliverEvent)
 	}
 
 	// ---------------------------------------------------------------------------------------
@@ -276,6 +332,11 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		lark.POST("/:id/webhook", LarkEventHandler)
 	}
 
+	slack := router.Group("slack")
+	{
+		slack.POST("/:id/command", SlackCommandHandler)
+	}
+
 	pm := router.Group("project_management")
 	{
 		pm.GET("", ListProjectManagement)
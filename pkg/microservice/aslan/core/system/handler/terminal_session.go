@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/system/service"
+	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+type listTerminalSessionsQuery struct {
+	ProjectName string `form:"projectName"`
+	EnvName     string `form:"envName"`
+	UserName    string `form:"userName"`
+	Type        string `form:"type"`
+	StartTime   int64  `form:"startTime"`
+	EndTime     int64  `form:"endTime"`
+	PageSize    int64  `form:"page_size,default=20"`
+	PageNum     int64  `form:"page_num,default=1"`
+}
+
+func GetTerminalSetting(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.GetTerminalSetting(ctx.Logger)
+}
+
+func UpdateTerminalSetting(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(service.TerminalSetting)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	internalhandler.InsertOperationLog(c, ctx.UserName, "", "更新", "系统配置-终端管控", fmt.Sprintf("restrict_production_exec:%t", args.RestrictProductionExec), "", ctx.Logger)
+
+	ctx.Err = service.UpdateTerminalSetting(args, ctx.Logger)
+}
+
+func ListTerminalSessions(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(listTerminalSessionsQuery)
+	if err := c.ShouldBindQuery(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	ctx.Resp, ctx.Err = service.ListTerminalSessions(&commonrepo.TerminalSessionListOption{
+		ProjectName: args.ProjectName,
+		EnvName:     args.EnvName,
+		UserName:    args.UserName,
+		Type:        args.Type,
+		StartTime:   args.StartTime,
+		EndTime:     args.EndTime,
+		PageNum:     args.PageNum,
+		PageSize:    args.PageSize,
+	}, ctx.Logger)
+}
+
+func GetTerminalSession(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.GetTerminalSession(c.Param("id"), ctx.Logger)
+}
+
+func GetTerminalSessionRecording(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	id := c.Param("id")
+	recording, err := service.GetTerminalSessionRecording(id, ctx.Logger)
+	if err != nil {
+		ctx.Err = err
+		return
+	}
+	c.Writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.cast"`, id))
+	c.Data(http.StatusOK, "application/x-asciicast", recording)
+}
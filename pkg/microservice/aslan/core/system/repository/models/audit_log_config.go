@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuditLogConfig struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	// RetentionDays is the number of days operation logs are kept, 0 means forever
+	RetentionDays int                 `bson:"retention_days"        json:"retention_days"`
+	Syslog        *AuditSyslogSink    `bson:"syslog,omitempty"      json:"syslog,omitempty"`
+	Webhook       *AuditWebhookSink   `bson:"webhook,omitempty"     json:"webhook,omitempty"`
+	ObjectStorage *AuditObjectStorage `bson:"object_storage,omitempty" json:"object_storage,omitempty"`
+	UpdateBy      string              `bson:"update_by"             json:"update_by"`
+	UpdateTime    int64               `bson:"update_time"           json:"update_time"`
+}
+
+type AuditSyslogSink struct {
+	Enabled bool `bson:"enabled"  json:"enabled"`
+	// Network is either udp or tcp
+	Network  string `bson:"network"  json:"network"`
+	Address  string `bson:"address"  json:"address"`
+	AppName  string `bson:"app_name" json:"app_name"`
+	Facility int    `bson:"facility" json:"facility"`
+	// Checkpoint is the seq of the last exported record
+	Checkpoint int64 `bson:"checkpoint" json:"checkpoint"`
+}
+
+type AuditWebhookSink struct {
+	Enabled bool   `bson:"enabled"    json:"enabled"`
+	Address string `bson:"address"    json:"address"`
+	// Token is encrypted in the database and masked in the response
+	Token      string `bson:"token"      json:"token"`
+	Checkpoint int64  `bson:"checkpoint" json:"checkpoint"`
+}
+
+type AuditObjectStorage struct {
+	Enabled    bool   `bson:"enabled"    json:"enabled"`
+	StorageID  string `bson:"storage_id" json:"storage_id"`
+	Prefix     string `bson:"prefix"     json:"prefix"`
+	Checkpoint int64  `bson:"checkpoint" json:"checkpoint"`
+}
+
+// AuditChainAnchor is the last record removed by the retention policy, chain verification starts from
+// the latest anchor. Anchors are only appended and never updated.
+type AuditChainAnchor struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Seq       int64              `bson:"seq"           json:"seq"`
+	Hash      string             `bson:"hash"          json:"hash"`
+	CreatedAt int64              `bson:"created_at"    json:"created_at"`
+	// Signature is a HMAC of the anchor with the key of the chain, a forged anchor can't skip records
+	Signature string `bson:"signature"     json:"signature"`
+}
+
+func (a *AuditChainAnchor) ComputeSignature(key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(fmt.Sprintf("%d.%s.%d", a.Seq, a.Hash, a.CreatedAt)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (AuditLogConfig) TableName() string {
+	return "audit_log_config"
+}
+
+func (AuditChainAnchor) TableName() string {
+	return "audit_chain_anchor"
+}
@@ -16,7 +16,14 @@ limitations under the License.
 
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type OperationLog struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty"               json:"id,omitempty"`
@@ -30,8 +37,50 @@ type OperationLog struct {
 	RequestBody string             `bson:"request_body"                json:"request_body"`
 	Status      int                `bson:"status"                      json:"status"`
 	CreatedAt   int64              `bson:"created_at"                  json:"created_at"`
+	// Seq, PrevHash and Hash chain every record to its predecessor so that tampering can be detected
+	Seq      int64  `bson:"seq,omitempty"               json:"seq,omitempty"`
+	PrevHash string `bson:"prev_hash,omitempty"         json:"prev_hash,omitempty"`
+	Hash     string `bson:"hash,omitempty"              json:"hash,omitempty"`
 }
 
 func (OperationLog) TableName() string {
 	return "operation_log"
 }
+
+// ComputeHash returns the chain hash of the record, it covers every persisted field except the hash itself.
+// A record is chained only after its status is set, so it is not changed afterwards.
+// The hash is a HMAC with a key which is not stored in mongodb, so the chain can't be rebuilt from the database alone.
+func (l *OperationLog) ComputeHash(key []byte) string {
+	content, _ := json.Marshal(struct {
+		ID          string   `json:"id"`
+		Seq         int64    `json:"seq"`
+		PrevHash    string   `json:"prev_hash"`
+		Username    string   `json:"username"`
+		ProductName string   `json:"product_name"`
+		Method      string   `json:"method"`
+		Function    string   `json:"function"`
+		Scene       string   `json:"scene"`
+		Targets     []string `json:"targets"`
+		Name        string   `json:"name"`
+		RequestBody string   `json:"request_body"`
+		Status      int      `json:"status"`
+		CreatedAt   int64    `json:"created_at"`
+	}{
+		ID:          l.ID.Hex(),
+		Seq:         l.Seq,
+		PrevHash:    l.PrevHash,
+		Username:    l.Username,
+		ProductName: l.ProductName,
+		Method:      l.Method,
+		Function:    l.Function,
+		Scene:       l.Scene,
+		Targets:     l.Targets,
+		Name:        l.Name,
+		RequestBody: l.RequestBody,
+		Status:      l.Status,
+		CreatedAt:   l.CreatedAt,
+	})
+	mac := hmac.New(sha256.New, key)
+	mac.Write(content)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func mustObjectID(t *testing.T, hex string) primitive.ObjectID {
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestOperationLogComputeHash(t *testing.T) {
+	newLog := func() *OperationLog {
+		return &OperationLog{
+			ID:          mustObjectID(t, "6554d6800000000000000001"),
+			Username:    "admin",
+			ProductName: "demo",
+			Method:      "更新",
+			Function:    "环境",
+			Scene:       "env",
+			Targets:     []string{"dev"},
+			Name:        "dev",
+			RequestBody: `{"replicas":1}`,
+			Status:      200,
+			CreatedAt:   1700000000,
+			Seq:         2,
+			PrevHash:    "prev",
+		}
+	}
+	key := []byte("key")
+	base := newLog().ComputeHash(key)
+	if got := newLog().ComputeHash(key); got != base {
+		t.Fatalf("hash is not stable, got %s, want %s", got, base)
+	}
+	if newLog().ComputeHash([]byte("other key")) == base {
+		t.Fatalf("hash doesn't change with the key")
+	}
+
+	tests := []struct {
+		name   string
+		modify func(l *OperationLog)
+	}{
+		{name: "id", modify: func(l *OperationLog) { l.ID = mustObjectID(t, "6554d6800000000000000002") }},
+		{name: "username", modify: func(l *OperationLog) { l.Username = "guest" }},
+		{name: "product name", modify: func(l *OperationLog) { l.ProductName = "other" }},
+		{name: "method", modify: func(l *OperationLog) { l.Method = "删除" }},
+		{name: "function", modify: func(l *OperationLog) { l.Function = "服务" }},
+		{name: "scene", modify: func(l *OperationLog) { l.Scene = "" }},
+		{name: "targets", modify: func(l *OperationLog) { l.Targets = []string{"prod"} }},
+		{name: "name", modify: func(l *OperationLog) { l.Name = "prod" }},
+		{name: "request body", modify: func(l *OperationLog) { l.RequestBody = `{"replicas":0}` }},
+		{name: "status", modify: func(l *OperationLog) { l.Status = 500 }},
+		{name: "created at", modify: func(l *OperationLog) { l.CreatedAt++ }},
+		{name: "seq", modify: func(l *OperationLog) { l.Seq++ }},
+		{name: "prev hash", modify: func(l *OperationLog) { l.PrevHash = "other" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLog()
+			tt.modify(l)
+			if l.ComputeHash(key) == base {
+				t.Errorf("hash doesn't change when %s is modified", tt.name)
+			}
+		})
+	}
+}
+
+func TestAuditChainAnchorComputeSignature(t *testing.T) {
+	key := []byte("key")
+	anchor := &AuditChainAnchor{Seq: 10, Hash: "hash", CreatedAt: 1700000000}
+	base := anchor.ComputeSignature(key)
+
+	tests := []struct {
+		name   string
+		anchor *AuditChainAnchor
+		key    []byte
+	}{
+		{name: "seq", anchor: &AuditChainAnchor{Seq: 11, Hash: "hash", CreatedAt: 1700000000}, key: key},
+		{name: "hash", anchor: &AuditChainAnchor{Seq: 10, Hash: "other", CreatedAt: 1700000000}, key: key},
+		{name: "created at", anchor: &AuditChainAnchor{Seq: 10, Hash: "hash", CreatedAt: 1700000001}, key: key},
+		{name: "key", anchor: &AuditChainAnchor{Seq: 10, Hash: "hash", CreatedAt: 1700000000}, key: []byte("other key")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.anchor.ComputeSignature(tt.key) == base {
+				t.Errorf("signature doesn't change when %s is modified", tt.name)
+			}
+		})
+	}
+}
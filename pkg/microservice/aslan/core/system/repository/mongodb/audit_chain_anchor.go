@@ -0,0 +1,74 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	models2 "github.com/koderover/zadig/pkg/microservice/aslan/core/system/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type AuditChainAnchorColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewAuditChainAnchorColl() *AuditChainAnchorColl {
+	name := models2.AuditChainAnchor{}.TableName()
+	return &AuditChainAnchorColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *AuditChainAnchorColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *AuditChainAnchorColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys:    bson.D{bson.E{Key: "seq", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
+}
+
+func (c *AuditChainAnchorColl) Create(args *models2.AuditChainAnchor) error {
+	_, err := c.InsertOne(context.TODO(), args)
+	return err
+}
+
+// FindLatest returns the anchor with the largest seq, nil is returned if no record has been removed yet.
+func (c *AuditChainAnchorColl) FindLatest() (*models2.AuditChainAnchor, error) {
+	res := &models2.AuditChainAnchor{}
+	opts := options.FindOne().SetSort(bson.D{bson.E{Key: "seq", Value: -1}})
+	err := c.FindOne(context.TODO(), bson.M{}, opts).Decode(res)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
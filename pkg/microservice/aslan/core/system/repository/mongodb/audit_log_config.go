@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	models2 "github.com/koderover/zadig/pkg/microservice/aslan/core/system/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type AuditLogConfigColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewAuditLogConfigColl() *AuditLogConfigColl {
+	name := models2.AuditLogConfig{}.TableName()
+	return &AuditLogConfigColl{Collection: mongotool.Database(config.MongoDatabase()).Collection(name), coll: name}
+}
+
+func (c *AuditLogConfigColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *AuditLogConfigColl) EnsureIndex(_ context.Context) error {
+	return nil
+}
+
+// Get returns the global audit log config, nil is returned if it is not configured yet.
+func (c *AuditLogConfigColl) Get() (*models2.AuditLogConfig, error) {
+	res := &models2.AuditLogConfig{}
+	err := c.FindOne(context.TODO(), bson.M{}).Decode(res)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Upsert replaces the settings of the config while keeping the export checkpoints.
+func (c *AuditLogConfigColl) Upsert(args *models2.AuditLogConfig) error {
+	change := bson.M{
+		"retention_days": args.RetentionDays,
+		"update_by":      args.UpdateBy,
+		"update_time":    args.UpdateTime,
+	}
+	if args.Syslog != nil {
+		change["syslog.enabled"] = args.Syslog.Enabled
+		change["syslog.network"] = args.Syslog.Network
+		change["syslog.address"] = args.Syslog.Address
+		change["syslog.app_name"] = args.Syslog.AppName
+		change["syslog.facility"] = args.Syslog.Facility
+	}
+	if args.Webhook != nil {
+		change["webhook.enabled"] = args.Webhook.Enabled
+		change["webhook.address"] = args.Webhook.Address
+		change["webhook.token"] = args.Webhook.Token
+	}
+	if args.ObjectStorage != nil {
+		change["object_storage.enabled"] = args.ObjectStorage.Enabled
+		change["object_storage.storage_id"] = args.ObjectStorage.StorageID
+		change["object_storage.prefix"] = args.ObjectStorage.Prefix
+	}
+	_, err := c.UpdateOne(context.TODO(), bson.M{}, bson.M{"$set": change}, options.Update().SetUpsert(true))
+	return err
+}
+
+// UpdateCheckpoint records the seq of the last record exported to the given sink, sink is one of
+// syslog, webhook and object_storage.
+func (c *AuditLogConfigColl) UpdateCheckpoint(sink string, seq int64) error {
+	_, err := c.UpdateOne(context.TODO(), bson.M{}, bson.M{"$set": bson.M{sink + ".checkpoint": seq}})
+	return err
+}
@@ -61,15 +61,30 @@ func (c *OperationLogColl) GetCollectionName() string {
 	return c.coll
 }
 
-func (c *OperationLogColl) EnsureIndex(_ context.Context) error {
-	return nil
+func (c *OperationLogColl) EnsureIndex(ctx context.Context) error {
+	mod := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{Key: "seq", Value: 1},
+		},
+		// records created before hash chaining was introduced have no seq
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"seq": bson.M{"$exists": true}}),
+	}
+
+	_, err := c.Indexes().CreateOne(ctx, mod)
+
+	return err
 }
 
+// chainRetries bounds the number of attempts when another instance takes the same seq concurrently
+const chainRetries = 10
+
+// Insert saves the log as pending, it is appended to the hash chain once its status is updated.
 func (c *OperationLogColl) Insert(args *models2.OperationLog) error {
 	if args == nil {
 		return errors.New("nil operation_log args")
 	}
 
+	args.Seq, args.PrevHash, args.Hash = 0, "", ""
 	res, err := c.InsertOne(context.TODO(), args)
 	if err != nil || res == nil {
 		return err
@@ -82,6 +97,96 @@ func (c *OperationLogColl) Insert(args *models2.OperationLog) error {
 	return nil
 }
 
+// FindLatestChained returns the chained log with the largest seq, nil is returned if there is none.
+func (c *OperationLogColl) FindLatestChained() (*models2.OperationLog, error) {
+	res := &models2.OperationLog{}
+	query := bson.M{"seq": bson.M{"$exists": true}}
+	opts := options.FindOne().SetSort(bson.D{{"seq", -1}})
+	err := c.FindOne(context.TODO(), query, opts).Decode(res)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *OperationLogColl) FindByID(id string) (*models2.OperationLog, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &models2.OperationLog{}
+	err = c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(res)
+	return res, err
+}
+
+// FindPreviousByTarget returns the latest log with a request body for the same target which is created before the
+// given log, nil is returned if there is none.
+func (c *OperationLogColl) FindPreviousByTarget(l *models2.OperationLog) (*models2.OperationLog, error) {
+	res := &models2.OperationLog{}
+	query := bson.M{
+		"_id":          bson.M{"$lt": l.ID},
+		"product_name": l.ProductName,
+		"function":     l.Function,
+		"name":         l.Name,
+		"request_body": bson.M{"$ne": ""},
+	}
+	opts := options.FindOne().SetSort(bson.D{{"_id", -1}})
+	err := c.FindOne(context.TODO(), query, opts).Decode(res)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ListBySeq returns at most limit chained logs whose seq is larger than afterSeq, in ascending order.
+func (c *OperationLogColl) ListBySeq(afterSeq int64, limit int64) ([]*models2.OperationLog, error) {
+	var res []*models2.OperationLog
+	query := bson.M{"seq": bson.M{"$gt": afterSeq}}
+	opts := options.Find().SetSort(bson.D{{"seq", 1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := c.Collection.Find(context.TODO(), query, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = cursor.All(context.TODO(), &res)
+	return res, err
+}
+
+// FindEarliestCreatedSince returns the chained log with the smallest seq which is created at or after the given time.
+func (c *OperationLogColl) FindEarliestCreatedSince(createdAt int64) (*models2.OperationLog, error) {
+	res := &models2.OperationLog{}
+	query := bson.M{"seq": bson.M{"$exists": true}, "created_at": bson.M{"$gte": createdAt}}
+	opts := options.FindOne().SetSort(bson.D{{"seq", 1}})
+	err := c.FindOne(context.TODO(), query, opts).Decode(res)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// DeleteUntilSeq deletes all chained logs whose seq is not larger than seq, together with the unchained legacy logs
+// created before the given time.
+func (c *OperationLogColl) DeleteUntilSeq(seq int64, createdBefore int64) error {
+	query := bson.M{"$or": bson.A{
+		bson.M{"seq": bson.M{"$lte": seq}},
+		bson.M{"seq": bson.M{"$exists": false}, "created_at": bson.M{"$lt": createdBefore}},
+	}}
+	_, err := c.DeleteMany(context.TODO(), query)
+	return err
+}
+
 func (c *OperationLogColl) Update(id string, status int) error {
 	if id == "" {
 		return errors.New("nil operation_log args")
@@ -92,10 +197,53 @@ func (c *OperationLogColl) Update(id string, status int) error {
 		return err
 	}
 
-	change := bson.M{"$set": bson.M{
-		"status": status,
-	}}
-	_, err = c.UpdateByID(context.TODO(), oid, change, options.Update().SetUpsert(true))
+	res := &models2.OperationLog{}
+	if err = c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(res); err != nil {
+		return err
+	}
+	if res.Seq != 0 {
+		return errors.New("operation log has been finalized")
+	}
+	res.Status = status
+
+	return c.appendToChain(res)
+}
+
+// appendToChain sets the seq, prev_hash and hash of the pending log and saves it together with the status,
+// so that the hash covers every persisted field.
+func (c *OperationLogColl) appendToChain(args *models2.OperationLog) error {
+	var err error
+	for i := 0; i < chainRetries; i++ {
+		args.Seq, args.PrevHash = 1, ""
+		last, findErr := c.FindLatestChained()
+		if findErr != nil {
+			return findErr
+		}
+		if last != nil {
+			args.Seq, args.PrevHash = last.Seq+1, last.Hash
+		}
+		args.Hash = args.ComputeHash(config.AuditLogHashKey())
+
+		query := bson.M{"_id": args.ID, "seq": bson.M{"$exists": false}}
+		change := bson.M{"$set": bson.M{
+			"status":    args.Status,
+			"seq":       args.Seq,
+			"prev_hash": args.PrevHash,
+			"hash":      args.Hash,
+		}}
+		var res *mongo.UpdateResult
+		res, err = c.UpdateOne(context.TODO(), query, change)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount == 0 {
+			return errors.New("operation log has been finalized")
+		}
+		return nil
+	}
 
 	return err
 }
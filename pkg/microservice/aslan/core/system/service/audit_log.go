@@ -0,0 +1,518 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	s3service "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/s3"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/system/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/system/repository/mongodb"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/crypto"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+	"github.com/koderover/zadig/pkg/tool/log"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+	s3tool "github.com/koderover/zadig/pkg/tool/s3"
+)
+
+const (
+	auditSinkSyslog        = "syslog"
+	auditSinkWebhook       = "webhook"
+	auditSinkObjectStorage = "object_storage"
+
+	auditExportBatchSize   = 500
+	auditExportInterval    = 30 * time.Second
+	auditRetentionInterval = time.Hour
+	// the replica running the export renews the lease every round, others take over after it expires
+	auditLeaseTTL = 5 * time.Minute
+
+	// syslog severity "informational"
+	syslogSeverityInfo = 6
+)
+
+func GetAuditLogConfig(log *zap.SugaredLogger) (*models.AuditLogConfig, error) {
+	resp, err := mongodb.NewAuditLogConfigColl().Get()
+	if err != nil {
+		log.Errorf("get audit log config error: %v", err)
+		return nil, e.ErrInternalError.AddErr(err)
+	}
+	if resp == nil {
+		resp = &models.AuditLogConfig{}
+	}
+	if resp.Webhook != nil && resp.Webhook.Token != "" {
+		resp.Webhook.Token = setting.MaskValue
+	}
+	return resp, nil
+}
+
+func UpdateAuditLogConfig(args *models.AuditLogConfig, username string, log *zap.SugaredLogger) error {
+	if err := validateAuditLogConfig(args); err != nil {
+		return e.ErrInvalidParam.AddErr(err)
+	}
+
+	if args.Webhook != nil && args.Webhook.Token != "" {
+		if args.Webhook.Token == setting.MaskValue {
+			// the masked token is sent back as it is, keep the one in the database
+			old, err := mongodb.NewAuditLogConfigColl().Get()
+			if err != nil {
+				log.Errorf("get audit log config error: %v", err)
+				return e.ErrInternalError.AddErr(err)
+			}
+			args.Webhook.Token = ""
+			if old != nil && old.Webhook != nil {
+				args.Webhook.Token = old.Webhook.Token
+			}
+		} else {
+			token, err := crypto.AesEncrypt(args.Webhook.Token)
+			if err != nil {
+				log.Errorf("encrypt audit webhook token error: %v", err)
+				return e.ErrInternalError.AddErr(err)
+			}
+			args.Webhook.Token = token
+		}
+	}
+
+	args.UpdateBy = username
+	args.UpdateTime = time.Now().Unix()
+	if err := mongodb.NewAuditLogConfigColl().Upsert(args); err != nil {
+		log.Errorf("update audit log config error: %v", err)
+		return e.ErrInternalError.AddErr(err)
+	}
+	return nil
+}
+
+func validateAuditLogConfig(args *models.AuditLogConfig) error {
+	if args.RetentionDays < 0 {
+		return fmt.Errorf("retention_days can't be negative")
+	}
+	if sink := args.Syslog; sink != nil && sink.Enabled {
+		if sink.Network != "udp" && sink.Network != "tcp" {
+			return fmt.Errorf("syslog network must be udp or tcp")
+		}
+		if _, _, err := net.SplitHostPort(sink.Address); err != nil {
+			return fmt.Errorf("invalid syslog address: %s", err)
+		}
+		if sink.Facility < 0 || sink.Facility > 23 {
+			return fmt.Errorf("syslog facility must be between 0 and 23")
+		}
+	}
+	if sink := args.Webhook; sink != nil && sink.Enabled {
+		u, err := url.Parse(sink.Address)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook address: %s", sink.Address)
+		}
+	}
+	if sink := args.ObjectStorage; sink != nil && sink.Enabled && sink.StorageID != "" {
+		if _, err := s3service.FindS3ById(sink.StorageID); err != nil {
+			return fmt.Errorf("object storage %s not found", sink.StorageID)
+		}
+	}
+	return nil
+}
+
+// AuditChainHead is the head of the chain recorded outside of the database, e.g. the last record received by
+// an export sink.
+type AuditChainHead struct {
+	Seq  int64
+	Hash string
+}
+
+type AuditChainVerifyResult struct {
+	Valid   bool  `json:"valid"`
+	Checked int64 `json:"checked"`
+	// FirstSeq and LastSeq are the range of the records verified
+	FirstSeq int64 `json:"first_seq"`
+	LastSeq  int64 `json:"last_seq"`
+	// BrokenSeq is the seq of the first record failing the verification
+	BrokenSeq int64  `json:"broken_seq,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// VerifyOperationLogChain walks through the chained operation logs from the retention anchor and checks that
+// every record is contiguous, points to the hash of its predecessor and has not been modified.
+// The hashes and the anchor are signed with a key which is not stored in mongodb. If the head recorded outside
+// of the database is given, the chain must contain it, so that the records removed from the end are detected too.
+func VerifyOperationLogChain(head *AuditChainHead, log *zap.SugaredLogger) (*AuditChainVerifyResult, error) {
+	anchor, err := mongodb.NewAuditChainAnchorColl().FindLatest()
+	if err != nil {
+		log.Errorf("get audit chain anchor error: %v", err)
+		return nil, e.ErrInternalError.AddErr(err)
+	}
+
+	coll := mongodb.NewOperationLogColl()
+	resp, err := verifyOperationLogChain(config.AuditLogHashKey(), anchor, head, func(afterSeq int64) ([]*models.OperationLog, error) {
+		return coll.ListBySeq(afterSeq, auditExportBatchSize)
+	})
+	if err != nil {
+		log.Errorf("list operation logs error: %v", err)
+		return nil, e.ErrFindOperationLog.AddErr(err)
+	}
+	return resp, nil
+}
+
+func verifyOperationLogChain(key []byte, anchor *models.AuditChainAnchor, head *AuditChainHead, list func(afterSeq int64) ([]*models.OperationLog, error)) (*AuditChainVerifyResult, error) {
+	resp := &AuditChainVerifyResult{Valid: true}
+	invalid := func(seq int64, reason string) (*AuditChainVerifyResult, error) {
+		resp.Valid, resp.BrokenSeq, resp.Reason = false, seq, reason
+		return resp, nil
+	}
+
+	var seq int64
+	var prevHash string
+	if anchor != nil {
+		if anchor.ComputeSignature(key) != anchor.Signature {
+			return invalid(anchor.Seq, "anchor signature mismatch, anchor has been modified")
+		}
+		seq, prevHash = anchor.Seq, anchor.Hash
+	}
+	if head != nil && head.Seq <= seq {
+		if head.Seq == seq && head.Hash != prevHash {
+			return invalid(head.Seq, "head hash mismatch")
+		}
+		// the head has been removed by the retention policy, it is covered by the anchor
+		head = nil
+	}
+
+	for {
+		logs, err := list(seq)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range logs {
+			switch {
+			case l.Seq != seq+1:
+				return invalid(l.Seq, fmt.Sprintf("record %d is missing", seq+1))
+			case l.PrevHash != prevHash:
+				return invalid(l.Seq, "previous hash mismatch")
+			case l.ComputeHash(key) != l.Hash:
+				return invalid(l.Seq, "hash mismatch, record has been modified")
+			case head != nil && l.Seq == head.Seq && l.Hash != head.Hash:
+				return invalid(l.Seq, "head hash mismatch")
+			}
+
+			if resp.FirstSeq == 0 {
+				resp.FirstSeq = l.Seq
+			}
+			resp.LastSeq = l.Seq
+			resp.Checked++
+			seq, prevHash = l.Seq, l.Hash
+		}
+		if len(logs) < auditExportBatchSize {
+			break
+		}
+	}
+
+	if head != nil && head.Seq > seq {
+		return invalid(seq+1, fmt.Sprintf("records from %d to %d are missing", seq+1, head.Seq))
+	}
+	return resp, nil
+}
+
+func NewAuditLogController() *auditLogController {
+	return &auditLogController{
+		logger: log.SugaredLogger(),
+		lease:  mongotool.NewLease(mongotool.Database(config.MongoDatabase()).Collection("lease"), "audit-log", auditLeaseTTL),
+	}
+}
+
+type auditLogController struct {
+	logger *zap.SugaredLogger
+	lease  *mongotool.Lease
+}
+
+// Run starts the audit log exporter and the retention worker, it blocks until receiving signal from stopCh.
+func (c *auditLogController) Run(workers int, stopCh <-chan struct{}) {
+	c.logger.Info("Starting audit log controller")
+	defer c.logger.Info("Shutting down audit log controller")
+
+	go wait.Until(c.export, auditExportInterval, stopCh)
+	go wait.Until(c.applyRetention, auditRetentionInterval, stopCh)
+
+	<-stopCh
+}
+
+// isLeader returns true if this replica holds the lease, the export and the retention run in one replica only
+// so that records are neither sent twice nor removed while being exported.
+func (c *auditLogController) isLeader() bool {
+	ok, err := c.lease.Acquire(context.TODO())
+	if err != nil {
+		c.logger.Errorf("failed to acquire audit log lease: %s", err)
+		return false
+	}
+	return ok
+}
+
+func (c *auditLogController) export() {
+	if !c.isLeader() {
+		return
+	}
+	conf, err := mongodb.NewAuditLogConfigColl().Get()
+	if err != nil {
+		c.logger.Errorf("failed to get audit log config: %s", err)
+		return
+	}
+	if conf == nil {
+		return
+	}
+
+	if conf.Syslog != nil && conf.Syslog.Enabled {
+		c.exportTo(auditSinkSyslog, conf.Syslog.Checkpoint, func(logs []*models.OperationLog) error {
+			return exportToSyslog(conf.Syslog, logs)
+		})
+	}
+	if conf.Webhook != nil && conf.Webhook.Enabled {
+		c.exportTo(auditSinkWebhook, conf.Webhook.Checkpoint, func(logs []*models.OperationLog) error {
+			return exportToWebhook(conf.Webhook, logs)
+		})
+	}
+	if conf.ObjectStorage != nil && conf.ObjectStorage.Enabled {
+		c.exportTo(auditSinkObjectStorage, conf.ObjectStorage.Checkpoint, func(logs []*models.OperationLog) error {
+			return exportToObjectStorage(conf.ObjectStorage, logs)
+		})
+	}
+}
+
+// exportTo sends all records after the checkpoint to the sink batch by batch, the checkpoint is moved forward
+// after each successful batch so that a failed batch is retried in the next round.
+func (c *auditLogController) exportTo(sink string, checkpoint int64, send func([]*models.OperationLog) error) {
+	for {
+		logs, err := mongodb.NewOperationLogColl().ListBySeq(checkpoint, auditExportBatchSize)
+		if err != nil {
+			c.logger.Errorf("failed to list operation logs: %s", err)
+			return
+		}
+		if len(logs) == 0 {
+			return
+		}
+		if err := send(logs); err != nil {
+			c.logger.Errorf("failed to export operation logs to %s: %s", sink, err)
+			return
+		}
+
+		checkpoint = logs[len(logs)-1].Seq
+		if err := mongodb.NewAuditLogConfigColl().UpdateCheckpoint(sink, checkpoint); err != nil {
+			c.logger.Errorf("failed to update %s checkpoint: %s", sink, err)
+			return
+		}
+		if len(logs) < auditExportBatchSize {
+			return
+		}
+	}
+}
+
+// applyRetention removes the records older than the retention days. The latest record is always kept since
+// it is the head of the chain, and the last removed record is saved as the anchor for verification.
+func (c *auditLogController) applyRetention() {
+	if !c.isLeader() {
+		return
+	}
+	conf, err := mongodb.NewAuditLogConfigColl().Get()
+	if err != nil {
+		c.logger.Errorf("failed to get audit log config: %s", err)
+		return
+	}
+	if conf == nil || conf.RetentionDays <= 0 {
+		return
+	}
+
+	coll := mongodb.NewOperationLogColl()
+	cutoff := time.Now().AddDate(0, 0, -conf.RetentionDays).Unix()
+	head, err := coll.FindLatestChained()
+	if err != nil || head == nil {
+		return
+	}
+	firstKept, err := coll.FindEarliestCreatedSince(cutoff)
+	if err != nil {
+		return
+	}
+	seq := retentionSeq(conf, head.Seq, firstKept)
+	if seq <= 0 {
+		return
+	}
+	logs, err := coll.ListBySeq(seq-1, 1)
+	if err != nil || len(logs) == 0 || logs[0].Seq != seq {
+		return
+	}
+	last := logs[0]
+
+	// the anchor is saved first, so an interrupted deletion leaves records which can still be skipped safely
+	anchor := &models.AuditChainAnchor{Seq: last.Seq, Hash: last.Hash, CreatedAt: time.Now().Unix()}
+	anchor.Signature = anchor.ComputeSignature(config.AuditLogHashKey())
+	if err := mongodb.NewAuditChainAnchorColl().Create(anchor); err != nil {
+		c.logger.Errorf("failed to create audit chain anchor: %s", err)
+		return
+	}
+	if err := coll.DeleteUntilSeq(last.Seq, cutoff); err != nil {
+		c.logger.Errorf("failed to delete expired operation logs: %s", err)
+	}
+}
+
+// retentionSeq returns the seq of the last record which can be removed, 0 means nothing can be removed.
+// A record is kept if it is the head of the chain, if any record before it is not expired yet or if it
+// has not been exported to all enabled sinks.
+func retentionSeq(conf *models.AuditLogConfig, headSeq int64, firstKept *models.OperationLog) int64 {
+	seq := headSeq - 1
+	if firstKept != nil && firstKept.Seq-1 < seq {
+		seq = firstKept.Seq - 1
+	}
+	if sink := conf.Syslog; sink != nil && sink.Enabled && sink.Checkpoint < seq {
+		seq = sink.Checkpoint
+	}
+	if sink := conf.Webhook; sink != nil && sink.Enabled && sink.Checkpoint < seq {
+		seq = sink.Checkpoint
+	}
+	if sink := conf.ObjectStorage; sink != nil && sink.Enabled && sink.Checkpoint < seq {
+		seq = sink.Checkpoint
+	}
+	if seq < 0 {
+		return 0
+	}
+	return seq
+}
+
+// formatSyslogMessage formats the log as a RFC 5424 message, the MSG part is the JSON encoded record.
+func formatSyslogMessage(sink *models.AuditSyslogSink, hostname string, l *models.OperationLog) ([]byte, error) {
+	content, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	appName := sink.AppName
+	if appName == "" {
+		appName = "zadig"
+	}
+	msgID := l.Scene
+	if msgID == "" {
+		msgID = "-"
+	}
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	header := fmt.Sprintf("<%d>1 %s %s %s - %s - ",
+		sink.Facility*8+syslogSeverityInfo,
+		time.Unix(l.CreatedAt, 0).UTC().Format(time.RFC3339),
+		hostname, appName, msgID,
+	)
+	return append([]byte(header), content...), nil
+}
+
+func exportToSyslog(sink *models.AuditSyslogSink, logs []*models.OperationLog) error {
+	conn, err := net.DialTimeout(sink.Network, sink.Address, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	for _, l := range logs {
+		msg, err := formatSyslogMessage(sink, hostname, l)
+		if err != nil {
+			return err
+		}
+		// TCP transport uses octet counting framing as described in RFC 6587
+		if sink.Network == "tcp" {
+			msg = append([]byte(fmt.Sprintf("%d ", len(msg))), msg...)
+		}
+		if err := conn.SetWriteDeadline(time.Now().Add(10 * time.Second)); err != nil {
+			return err
+		}
+		if _, err := conn.Write(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportToWebhook(sink *models.AuditWebhookSink, logs []*models.OperationLog) error {
+	rfs := []httpclient.RequestFunc{httpclient.SetBody(logs)}
+	if sink.Token != "" {
+		token, err := crypto.AesDecrypt(sink.Token)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt webhook token: %s", err)
+		}
+		rfs = append(rfs, httpclient.SetHeader("Authorization", "Bearer "+token))
+	}
+	_, err := httpclient.Post(sink.Address, rfs...)
+	return err
+}
+
+func exportToObjectStorage(sink *models.AuditObjectStorage, logs []*models.OperationLog) error {
+	var storage *s3service.S3
+	var err error
+	if sink.StorageID != "" {
+		storage, err = s3service.FindS3ById(sink.StorageID)
+	} else {
+		storage, err = s3service.FindDefaultS3()
+	}
+	if err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	for _, l := range logs {
+		if err := encoder.Encode(l); err != nil {
+			return err
+		}
+	}
+
+	tmpFile, err := ioutil.TempFile("", "audit-*.jsonl")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(buf.Bytes()); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	forcedPathStyle := true
+	if storage.Provider == setting.ProviderSourceAli {
+		forcedPathStyle = false
+	}
+	client, err := s3tool.NewClient(storage.Endpoint, storage.Ak, storage.Sk, storage.Region, storage.Insecure, forcedPathStyle)
+	if err != nil {
+		return err
+	}
+
+	prefix := strings.Trim(sink.Prefix, "/")
+	if prefix == "" {
+		prefix = "audit-logs"
+	}
+	first, last := logs[0], logs[len(logs)-1]
+	key := path.Join(prefix, time.Unix(first.CreatedAt, 0).UTC().Format("2006/01/02"), fmt.Sprintf("%d-%d.jsonl", first.Seq, last.Seq))
+	return client.Upload(storage.Bucket, tmpFile.Name(), storage.GetObjectPath(key))
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"testing"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/system/repository/models"
+)
+
+func TestRetentionSeq(t *testing.T) {
+	tests := []struct {
+		name      string
+		conf      *models.AuditLogConfig
+		headSeq   int64
+		firstKept *models.OperationLog
+		want      int64
+	}{
+		{
+			name:    "all expired, the head is kept",
+			conf:    &models.AuditLogConfig{},
+			headSeq: 10,
+			want:    9,
+		},
+		{
+			name:      "stops before the first record in retention",
+			conf:      &models.AuditLogConfig{},
+			headSeq:   10,
+			firstKept: &models.OperationLog{Seq: 6},
+			want:      5,
+		},
+		{
+			name:      "nothing expired",
+			conf:      &models.AuditLogConfig{},
+			headSeq:   10,
+			firstKept: &models.OperationLog{Seq: 1},
+			want:      0,
+		},
+		{
+			name: "stops at the slowest enabled sink",
+			conf: &models.AuditLogConfig{
+				Syslog:        &models.AuditSyslogSink{Enabled: true, Checkpoint: 7},
+				Webhook:       &models.AuditWebhookSink{Enabled: true, Checkpoint: 3},
+				ObjectStorage: &models.AuditObjectStorage{Enabled: true, Checkpoint: 8},
+			},
+			headSeq: 10,
+			want:    3,
+		},
+		{
+			name: "disabled sinks are ignored",
+			conf: &models.AuditLogConfig{
+				Webhook: &models.AuditWebhookSink{Enabled: false, Checkpoint: 1},
+			},
+			headSeq:   10,
+			firstKept: &models.OperationLog{Seq: 6},
+			want:      5,
+		},
+		{
+			name: "nothing exported yet",
+			conf: &models.AuditLogConfig{
+				ObjectStorage: &models.AuditObjectStorage{Enabled: true},
+			},
+			headSeq: 10,
+			want:    0,
+		},
+		{
+			name:    "only the head exists",
+			conf:    &models.AuditLogConfig{},
+			headSeq: 1,
+			want:    0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retentionSeq(tt.conf, tt.headSeq, tt.firstKept); got != tt.want {
+				t.Errorf("retentionSeq() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func newTestChain(key []byte, from, to int64, prevHash string) []*models.OperationLog {
+	var logs []*models.OperationLog
+	for seq := from; seq <= to; seq++ {
+		l := &models.OperationLog{Username: "admin", Seq: seq, PrevHash: prevHash, CreatedAt: 1700000000 + seq}
+		l.Hash = l.ComputeHash(key)
+		prevHash = l.Hash
+		logs = append(logs, l)
+	}
+	return logs
+}
+
+func TestVerifyOperationLogChain(t *testing.T) {
+	key := []byte("key")
+	chain := newTestChain(key, 1, 5, "")
+	anchor := &models.AuditChainAnchor{Seq: 2, Hash: chain[1].Hash, CreatedAt: 1700000100}
+	anchor.Signature = anchor.ComputeSignature(key)
+
+	tests := []struct {
+		name      string
+		key       []byte
+		anchor    *models.AuditChainAnchor
+		head      *AuditChainHead
+		logs      func() []*models.OperationLog
+		wantValid bool
+		wantSeq   int64
+	}{
+		{
+			name:      "whole chain",
+			key:       key,
+			logs:      func() []*models.OperationLog { return chain },
+			wantValid: true,
+		},
+		{
+			name:      "chain after a signed anchor",
+			key:       key,
+			anchor:    anchor,
+			logs:      func() []*models.OperationLog { return chain[2:] },
+			wantValid: true,
+		},
+		{
+			name:    "anchor moved forward to skip records",
+			key:     key,
+			anchor:  &models.AuditChainAnchor{Seq: 3, Hash: chain[2].Hash, CreatedAt: 1700000100, Signature: anchor.Signature},
+			logs:    func() []*models.OperationLog { return chain[3:] },
+			wantSeq: 3,
+		},
+		{
+			name: "record rehashed without the key",
+			key:  key,
+			logs: func() []*models.OperationLog {
+				logs := newTestChain(key, 1, 5, "")
+				logs[4].Username = "guest"
+				logs[4].Hash = logs[4].ComputeHash([]byte("guessed key"))
+				return logs
+			},
+			wantSeq: 5,
+		},
+		{
+			name: "chain verified with another key",
+			key:  []byte("other key"),
+			logs: func() []*models.OperationLog { return chain },
+		},
+		{
+			name:      "exported head matches",
+			key:       key,
+			head:      &AuditChainHead{Seq: 4, Hash: chain[3].Hash},
+			logs:      func() []*models.OperationLog { return chain },
+			wantValid: true,
+		},
+		{
+			name:    "records removed from the end",
+			key:     key,
+			head:    &AuditChainHead{Seq: 5, Hash: chain[4].Hash},
+			logs:    func() []*models.OperationLog { return chain[:3] },
+			wantSeq: 4,
+		},
+		{
+			name:    "exported head replaced",
+			key:     key,
+			head:    &AuditChainHead{Seq: 4, Hash: "other"},
+			logs:    func() []*models.OperationLog { return chain },
+			wantSeq: 4,
+		},
+		{
+			name:      "exported head removed by the retention",
+			key:       key,
+			anchor:    anchor,
+			head:      &AuditChainHead{Seq: 2, Hash: chain[1].Hash},
+			logs:      func() []*models.OperationLog { return chain[2:] },
+			wantValid: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := tt.logs()
+			list := func(afterSeq int64) ([]*models.OperationLog, error) {
+				var res []*models.OperationLog
+				for _, l := range logs {
+					if l.Seq > afterSeq {
+						res = append(res, l)
+					}
+				}
+				return res, nil
+			}
+			got, err := verifyOperationLogChain(tt.key, tt.anchor, tt.head, list)
+			if err != nil {
+				t.Fatalf("verifyOperationLogChain() error = %v", err)
+			}
+			if got.Valid != tt.wantValid {
+				t.Fatalf("verifyOperationLogChain() valid = %v, want %v, reason: %s", got.Valid, tt.wantValid, got.Reason)
+			}
+			if !tt.wantValid && tt.wantSeq != 0 && got.BrokenSeq != tt.wantSeq {
+				t.Errorf("verifyOperationLogChain() broken seq = %d, want %d, reason: %s", got.BrokenSeq, tt.wantSeq, got.Reason)
+			}
+		})
+	}
+}
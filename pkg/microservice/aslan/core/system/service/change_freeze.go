@@ -0,0 +1,263 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/changefreeze"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+const (
+	defaultOverrideDuration = 60
+	maxOverrideDuration     = 24 * 60
+)
+
+func ListChangeFreezeWindows(projectName string, log *zap.SugaredLogger) ([]*commonmodels.ChangeFreezeWindow, error) {
+	resp, err := commonrepo.NewChangeFreezeWindowColl().List(projectName, true)
+	if err != nil {
+		log.Errorf("list change freeze windows error: %v", err)
+		return nil, e.ErrInternalError.AddErr(err)
+	}
+	return resp, nil
+}
+
+func ListActiveChangeFreezeWindows(projectName string, log *zap.SugaredLogger) ([]*commonmodels.ChangeFreezeWindow, error) {
+	resp, err := changefreeze.ActiveWindows(projectName, time.Now())
+	if err != nil {
+		log.Errorf("list active change freeze windows error: %v", err)
+		return nil, e.ErrInternalError.AddErr(err)
+	}
+	return resp, nil
+}
+
+func CreateChangeFreezeWindow(projectName string, args *commonmodels.ChangeFreezeWindow, log *zap.SugaredLogger) error {
+	args.ProjectName = projectName
+	if err := changefreeze.Validate(args); err != nil {
+		return e.ErrInvalidParam.AddErr(err)
+	}
+	args.CreatedBy = args.UpdateBy
+
+	if err := commonrepo.NewChangeFreezeWindowColl().Create(args); err != nil {
+		log.Errorf("create change freeze window error: %v", err)
+		return e.ErrInternalError.AddErr(err)
+	}
+	return nil
+}
+
+func UpdateChangeFreezeWindow(id, projectName string, args *commonmodels.ChangeFreezeWindow, log *zap.SugaredLogger) error {
+	if _, err := findChangeFreezeWindow(id, projectName); err != nil {
+		return err
+	}
+	args.ProjectName = projectName
+	if err := changefreeze.Validate(args); err != nil {
+		return e.ErrInvalidParam.AddErr(err)
+	}
+
+	if err := commonrepo.NewChangeFreezeWindowColl().Update(id, args); err != nil {
+		log.Errorf("update change freeze window error: %v", err)
+		return e.ErrInternalError.AddErr(err)
+	}
+	return nil
+}
+
+func DeleteChangeFreezeWindow(id, projectName string, log *zap.SugaredLogger) error {
+	if _, err := findChangeFreezeWindow(id, projectName); err != nil {
+		return err
+	}
+
+	if err := commonrepo.NewChangeFreezeWindowColl().Delete(id); err != nil {
+		log.Errorf("delete change freeze window error: %v", err)
+		return e.ErrInternalError.AddErr(err)
+	}
+	return nil
+}
+
+// findChangeFreezeWindow makes sure that project windows can't be used to modify org-wide windows and vice versa.
+func findChangeFreezeWindow(id, projectName string) (*commonmodels.ChangeFreezeWindow, error) {
+	window, err := commonrepo.NewChangeFreezeWindowColl().Find(id)
+	if err != nil {
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("change freeze window %s not found", id))
+	}
+	if window.ProjectName != projectName {
+		return nil, e.ErrForbidden.AddDesc("change freeze window doesn't belong to the project")
+	}
+	return window, nil
+}
+
+type ChangeFreezeOverrideArgs struct {
+	ProjectName  string `json:"project_name"`
+	WorkflowName string `json:"workflow_name"`
+	Reason       string `json:"reason"`
+	// Duration is the number of minutes the override is valid after it is approved
+	Duration int64 `json:"duration"`
+}
+
+func ListChangeFreezeOverrides(opt *commonrepo.ListChangeFreezeOverrideOption, log *zap.SugaredLogger) ([]*commonmodels.ChangeFreezeOverride, error) {
+	resp, err := commonrepo.NewChangeFreezeOverrideColl().List(opt)
+	if err != nil {
+		log.Errorf("list change freeze overrides error: %v", err)
+		return nil, e.ErrInternalError.AddErr(err)
+	}
+	return resp, nil
+}
+
+// CreateChangeFreezeOverride requests an emergency override, it takes effect after every active freeze window
+// is approved by one of its approvers.
+func CreateChangeFreezeOverride(args *ChangeFreezeOverrideArgs, username, uid string, log *zap.SugaredLogger) (*commonmodels.ChangeFreezeOverride, error) {
+	if args.ProjectName == "" || args.WorkflowName == "" {
+		return nil, e.ErrInvalidParam.AddDesc("project_name and workflow_name are required")
+	}
+	if args.Reason == "" {
+		return nil, e.ErrInvalidParam.AddDesc("reason is required")
+	}
+	if args.Duration <= 0 {
+		args.Duration = defaultOverrideDuration
+	}
+	if args.Duration > maxOverrideDuration {
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("duration can't be longer than %d minutes", maxOverrideDuration))
+	}
+
+	windows, err := changefreeze.ActiveWindows(args.ProjectName, time.Now())
+	if err != nil {
+		log.Errorf("list active change freeze windows error: %v", err)
+		return nil, e.ErrInternalError.AddErr(err)
+	}
+	if len(windows) == 0 {
+		return nil, e.ErrInvalidParam.AddDesc("no active change freeze window")
+	}
+	for _, w := range windows {
+		if !hasOtherApprover(w, uid) {
+			return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("change freeze window %s has no designated approver other than the requester", w.Name))
+		}
+	}
+
+	override := &commonmodels.ChangeFreezeOverride{
+		ProjectName:  args.ProjectName,
+		WorkflowName: args.WorkflowName,
+		Reason:       args.Reason,
+		Duration:     args.Duration,
+		Status:       commonmodels.ChangeFreezeOverridePending,
+		RequestedBy:  username,
+		RequestedUID: uid,
+		Approvals:    make([]*commonmodels.ChangeFreezeApproval, 0),
+	}
+	if err := commonrepo.NewChangeFreezeOverrideColl().Create(override); err != nil {
+		log.Errorf("create change freeze override error: %v", err)
+		return nil, e.ErrInternalError.AddErr(err)
+	}
+	return override, nil
+}
+
+type ReviewChangeFreezeOverrideArgs struct {
+	Approve bool   `json:"approve"`
+	Comment string `json:"comment"`
+}
+
+func ReviewChangeFreezeOverride(id string, args *ReviewChangeFreezeOverrideArgs, username, uid string, log *zap.SugaredLogger) error {
+	override, err := commonrepo.NewChangeFreezeOverrideColl().Find(id)
+	if err != nil {
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("change freeze override %s not found", id))
+	}
+	if override.Status != commonmodels.ChangeFreezeOverridePending {
+		return e.ErrInvalidParam.AddDesc("change freeze override has been reviewed")
+	}
+
+	if override.RequestedUID == uid {
+		return e.ErrForbidden.AddDesc("the requester can't review the override")
+	}
+
+	now := time.Now()
+	windows, err := changefreeze.ActiveWindows(override.ProjectName, now)
+	if err != nil {
+		log.Errorf("list active change freeze windows error: %v", err)
+		return e.ErrInternalError.AddErr(err)
+	}
+	approvals := approveWindows(windows, override.Approvals, username, uid, now.Unix())
+	if approvals == nil {
+		return e.ErrForbidden.AddDesc("only the designated approvers of the active change freeze windows can review the override")
+	}
+
+	override.ReviewedBy = username
+	override.ReviewedUID = uid
+	override.ReviewComment = args.Comment
+	override.ReviewTime = now.Unix()
+	override.Status = commonmodels.ChangeFreezeOverrideRejected
+	if args.Approve {
+		// the override stays pending until every active window is approved
+		override.Approvals = approvals
+		override.Status = commonmodels.ChangeFreezeOverridePending
+		if len(changefreeze.UnapprovedWindows(windows, approvals)) == 0 {
+			override.Status = commonmodels.ChangeFreezeOverrideApproved
+			override.ExpireAt = now.Add(time.Duration(override.Duration) * time.Minute).Unix()
+		}
+	}
+	if err := commonrepo.NewChangeFreezeOverrideColl().Review(id, override); err != nil {
+		log.Errorf("review change freeze override error: %v", err)
+		return e.ErrInvalidParam.AddErr(err)
+	}
+	return nil
+}
+
+// approveWindows adds the approvals of the user to the windows the user is an approver of,
+// nil is returned if the user is not an approver of any of the windows.
+func approveWindows(windows []*commonmodels.ChangeFreezeWindow, approvals []*commonmodels.ChangeFreezeApproval, username, uid string, now int64) []*commonmodels.ChangeFreezeApproval {
+	approved := make(map[string]bool)
+	resp := make([]*commonmodels.ChangeFreezeApproval, 0, len(approvals))
+	for _, a := range approvals {
+		approved[a.WindowID] = true
+		resp = append(resp, a)
+	}
+
+	isApprover := false
+	for _, w := range windows {
+		if !sets.NewString(w.Approvers...).Has(uid) {
+			continue
+		}
+		isApprover = true
+		if approved[w.ID.Hex()] {
+			continue
+		}
+		resp = append(resp, &commonmodels.ChangeFreezeApproval{
+			WindowID:    w.ID.Hex(),
+			WindowName:  w.Name,
+			ApprovedBy:  username,
+			ApprovedUID: uid,
+			ApproveTime: now,
+		})
+	}
+	if !isApprover {
+		return nil
+	}
+	return resp
+}
+
+func hasOtherApprover(w *commonmodels.ChangeFreezeWindow, uid string) bool {
+	for _, approver := range w.Approvers {
+		if approver != uid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+func TestApproveWindows(t *testing.T) {
+	org := &commonmodels.ChangeFreezeWindow{ID: primitive.NewObjectID(), Name: "org", Approvers: []string{"ops"}}
+	project := &commonmodels.ChangeFreezeWindow{ID: primitive.NewObjectID(), Name: "project", ProjectName: "demo", Approvers: []string{"lead", "ops"}}
+	windows := []*commonmodels.ChangeFreezeWindow{org, project}
+
+	if got := approveWindows(windows, nil, "dev", "dev", 1); got != nil {
+		t.Fatalf("expected a user who is not an approver to be rejected, got %v", got)
+	}
+
+	got := approveWindows(windows, nil, "lead", "lead", 1)
+	if len(got) != 1 || got[0].WindowID != project.ID.Hex() || got[0].ApprovedUID != "lead" {
+		t.Fatalf("expected the project window to be approved by lead, got %v", got)
+	}
+
+	// the project window is approved already, only the org-wide window is added
+	got = approveWindows(windows, got, "ops", "ops", 2)
+	if len(got) != 2 || got[1].WindowID != org.ID.Hex() || got[1].ApprovedUID != "ops" {
+		t.Fatalf("expected the org-wide window to be approved by ops, got %v", got)
+	}
+	if got[0].ApprovedUID != "lead" {
+		t.Errorf("expected the existing approval to be kept, got %v", got[0])
+	}
+}
+
+func TestHasOtherApprover(t *testing.T) {
+	w := &commonmodels.ChangeFreezeWindow{Approvers: []string{"lead"}}
+	if hasOtherApprover(w, "lead") {
+		t.Error("expected the requester not to count as an approver")
+	}
+	if !hasOtherApprover(w, "dev") {
+		t.Error("expected lead to be an approver other than dev")
+	}
+}
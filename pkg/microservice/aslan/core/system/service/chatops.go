@@ -0,0 +1,490 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	larkservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/lark"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow"
+	"github.com/koderover/zadig/pkg/microservice/picket/client/opa"
+	"github.com/koderover/zadig/pkg/microservice/user/core"
+	usermodels "github.com/koderover/zadig/pkg/microservice/user/core/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/user/core/repository/orm"
+	"github.com/koderover/zadig/pkg/microservice/user/core/service/login"
+	"github.com/koderover/zadig/pkg/setting"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/tool/slack"
+)
+
+const (
+	chatOpsCommandPrefix = "/zadig"
+	// chatOpsTokenTTL is the lifetime of the token issued to evaluate the policy for an im user
+	chatOpsTokenTTL = 5 * time.Minute
+	// chatOpsStatusTaskCount is the number of tasks replied by the status command
+	chatOpsStatusTaskCount = 5
+)
+
+const (
+	ChatOpsActionRun     = "run"
+	ChatOpsActionApprove = "approve"
+	ChatOpsActionReject  = "reject"
+	ChatOpsActionStatus  = "status"
+	ChatOpsActionHelp    = "help"
+)
+
+const chatOpsUsage = `Usage:
+/zadig run <workflow> [<param>=<value> ...]
+/zadig approve <workflow>#<task id> [stage=<stage>] [comment=<comment>]
+/zadig reject <workflow>#<task id> [stage=<stage>] [comment=<comment>]
+/zadig status <workflow>`
+
+// ChatOpsCommand is a command sent to the bot of an im app, e.g. "/zadig run my-workflow env=dev tag=v1.2".
+type ChatOpsCommand struct {
+	Action   string
+	Workflow string
+	TaskID   int64
+	Args     map[string]string
+	// ArgNames keeps the order of the args in the command
+	ArgNames []string
+}
+
+// ParseChatOpsCommand parses the text of a message, the leading mentions and the "/zadig" prefix are optional.
+// Values with spaces can be quoted, e.g. comment="looks good".
+func ParseChatOpsCommand(text string) (*ChatOpsCommand, error) {
+	fields, err := splitChatOpsFields(text)
+	if err != nil {
+		return nil, err
+	}
+	// mentions of the bot in group chats, e.g. "@_user_1" in lark
+	for len(fields) > 0 && strings.HasPrefix(fields[0], "@") {
+		fields = fields[1:]
+	}
+	if len(fields) > 0 && fields[0] == chatOpsCommandPrefix {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return &ChatOpsCommand{Action: ChatOpsActionHelp}, nil
+	}
+
+	cmd := &ChatOpsCommand{Action: strings.ToLower(fields[0]), Args: make(map[string]string)}
+	var positional []string
+	for _, field := range fields[1:] {
+		if k, v, ok := strings.Cut(field, "="); ok && k != "" {
+			if _, exists := cmd.Args[k]; !exists {
+				cmd.ArgNames = append(cmd.ArgNames, k)
+			}
+			cmd.Args[k] = v
+			continue
+		}
+		positional = append(positional, field)
+	}
+
+	switch cmd.Action {
+	case ChatOpsActionHelp:
+		return cmd, nil
+	case ChatOpsActionRun, ChatOpsActionStatus:
+		if len(positional) != 1 {
+			return nil, fmt.Errorf("%s requires exactly one workflow name", cmd.Action)
+		}
+		cmd.Workflow = positional[0]
+	case ChatOpsActionApprove, ChatOpsActionReject:
+		// both "<workflow>#<task id>" and "<workflow> <task id>" are accepted
+		if len(positional) == 1 {
+			positional = strings.SplitN(positional[0], "#", 2)
+		}
+		if len(positional) != 2 {
+			return nil, fmt.Errorf("%s requires a task in the form of <workflow>#<task id>", cmd.Action)
+		}
+		taskID, err := strconv.ParseInt(strings.TrimPrefix(positional[1], "#"), 10, 64)
+		if err != nil || taskID <= 0 {
+			return nil, fmt.Errorf("invalid task id %q", positional[1])
+		}
+		cmd.Workflow, cmd.TaskID = positional[0], taskID
+	default:
+		return nil, fmt.Errorf("unknown command %q", cmd.Action)
+	}
+	return cmd, nil
+}
+
+func splitChatOpsFields(text string) ([]string, error) {
+	var (
+		fields  []string
+		current strings.Builder
+		inQuote bool
+		started bool
+	)
+	for _, r := range strings.TrimSpace(text) {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			started = true
+		case !inQuote && unicode.IsSpace(r):
+			if started {
+				fields = append(fields, current.String())
+				current.Reset()
+				started = false
+			}
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+	if inQuote {
+		return nil, errors.New("unclosed quote")
+	}
+	if started {
+		fields = append(fields, current.String())
+	}
+	return fields, nil
+}
+
+// ChatOpsIdentity is the identity of the sender in the im app, it is mapped to a zadig user by email or mobile.
+// Mobile must only be set if it is managed by the organization, e.g. in lark, since users can edit it freely in slack.
+type ChatOpsIdentity struct {
+	Email  string
+	Mobile string
+}
+
+func getChatOpsUser(identity *ChatOpsIdentity) (*usermodels.User, error) {
+	if identity.Email != "" {
+		user, err := orm.GetUserByEmail(identity.Email, core.DB)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			return user, nil
+		}
+	}
+	if identity.Mobile != "" {
+		// the mobile in im apps has a country code, while it is usually omitted in zadig for mainland numbers
+		for _, mobile := range []string{identity.Mobile, strings.TrimPrefix(identity.Mobile, "+86")} {
+			user, err := orm.GetUserByPhone(mobile, core.DB)
+			if err != nil {
+				return nil, err
+			}
+			if user != nil {
+				return user, nil
+			}
+		}
+	}
+	return nil, errors.New("no zadig user is bound to your email or mobile")
+}
+
+// RunChatOpsCommand executes the command for the im user and returns the text to reply.
+func RunChatOpsCommand(identity *ChatOpsIdentity, text string, log *zap.SugaredLogger) string {
+	cmd, err := ParseChatOpsCommand(text)
+	if err != nil {
+		return fmt.Sprintf("%s\n%s", err, chatOpsUsage)
+	}
+	if cmd.Action == ChatOpsActionHelp {
+		return chatOpsUsage
+	}
+
+	user, err := getChatOpsUser(identity)
+	if err != nil {
+		log.Warnf("failed to map im user %+v to a zadig user: %s", identity, err)
+		return fmt.Sprintf("failed to find your zadig account: %s", err)
+	}
+
+	var reply string
+	switch cmd.Action {
+	case ChatOpsActionRun:
+		reply, err = chatOpsRunWorkflow(user, cmd, log)
+	case ChatOpsActionApprove, ChatOpsActionReject:
+		reply, err = chatOpsApproveTask(user, cmd, log)
+	case ChatOpsActionStatus:
+		reply, err = chatOpsWorkflowStatus(user, cmd, log)
+	}
+	if err != nil {
+		log.Errorf("failed to run chatops command %q for user %s: %s", text, user.Account, err)
+		return fmt.Sprintf("failed to %s: %s", cmd.Action, err)
+	}
+	return reply
+}
+
+func chatOpsRunWorkflow(user *usermodels.User, cmd *ChatOpsCommand, log *zap.SugaredLogger) (string, error) {
+	wf, err := commonrepo.NewWorkflowV4Coll().Find(cmd.Workflow)
+	if err != nil {
+		return "", fmt.Errorf("workflow %s not found", cmd.Workflow)
+	}
+	if err := authorizeChatOpsAction(user, wf.Project, http.MethodPost, "/api/aslan/workflow/v4/workflowtask"); err != nil {
+		return "", err
+	}
+	if err := setChatOpsWorkflowParams(wf, cmd); err != nil {
+		return "", err
+	}
+
+	resp, err := workflow.CreateWorkflowTaskV4(&workflow.CreateWorkflowTaskV4Args{
+		Name:   user.Name,
+		UserID: user.UID,
+	}, wf, log)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("task #%d of workflow %s is created: %s", resp.TaskID, getChatOpsWorkflowName(wf), getChatOpsTaskURL(wf, resp.TaskID)), nil
+}
+
+func setChatOpsWorkflowParams(wf *commonmodels.WorkflowV4, cmd *ChatOpsCommand) error {
+	params := make(map[string]*commonmodels.Param, len(wf.Params))
+	names := make([]string, 0, len(wf.Params))
+	for _, param := range wf.Params {
+		params[param.Name] = param
+		names = append(names, param.Name)
+	}
+	for _, name := range cmd.ArgNames {
+		param, ok := params[name]
+		if !ok {
+			return fmt.Errorf("unknown param %s, the params of the workflow are: %s", name, strings.Join(names, ", "))
+		}
+		value := cmd.Args[name]
+		if param.ParamsType == "choice" && !sets.NewString(param.ChoiceOption...).Has(value) {
+			return fmt.Errorf("invalid value %s of param %s, the options are: %s", value, name, strings.Join(param.ChoiceOption, ", "))
+		}
+		param.Value = value
+	}
+	return nil
+}
+
+func chatOpsApproveTask(user *usermodels.User, cmd *ChatOpsCommand, log *zap.SugaredLogger) (string, error) {
+	task, err := commonrepo.NewworkflowTaskv4Coll().Find(cmd.Workflow, cmd.TaskID)
+	if err != nil {
+		return "", fmt.Errorf("task #%d of workflow %s not found", cmd.TaskID, cmd.Workflow)
+	}
+	if err := authorizeChatOpsAction(user, task.ProjectName, http.MethodPost, "/api/aslan/workflow/v4/workflowtask/approve"); err != nil {
+		return "", err
+	}
+	if task.Status != config.StatusWaitingApprove {
+		return "", fmt.Errorf("task #%d is %s, not waiting for approval", task.TaskID, task.Status)
+	}
+
+	stage := getChatOpsApprovalStage(task, cmd.Args["stage"])
+	if stage == nil {
+		return "", errors.New("no stage is waiting for approval")
+	}
+	if stage.Approval.Type != config.NativeApproval {
+		return "", fmt.Errorf("stage %s should be approved in %s", stage.Name, stage.Approval.Type)
+	}
+
+	approve := cmd.Action == ChatOpsActionApprove
+	if err := workflow.ApproveStage(task.WorkflowName, stage.Name, user.Name, user.UID, cmd.Args["comment"], task.TaskID, approve, log); err != nil {
+		return "", err
+	}
+	action := "approved"
+	if !approve {
+		action = "rejected"
+	}
+	return fmt.Sprintf("stage %s of task #%d is %s by %s", stage.Name, task.TaskID, action, user.Name), nil
+}
+
+// getChatOpsApprovalStage returns the stage with the given name, or the running stage if the name is empty.
+func getChatOpsApprovalStage(task *commonmodels.WorkflowTask, name string) *commonmodels.StageTask {
+	for _, stage := range task.Stages {
+		if stage.Approval == nil || !stage.Approval.Enabled {
+			continue
+		}
+		if name != "" && stage.Name == name {
+			return stage
+		}
+		if name == "" && stage.Status == config.StatusRunning {
+			return stage
+		}
+	}
+	return nil
+}
+
+func chatOpsWorkflowStatus(user *usermodels.User, cmd *ChatOpsCommand, log *zap.SugaredLogger) (string, error) {
+	wf, err := commonrepo.NewWorkflowV4Coll().Find(cmd.Workflow)
+	if err != nil {
+		return "", fmt.Errorf("workflow %s not found", cmd.Workflow)
+	}
+	if err := authorizeChatOpsAction(user, wf.Project, http.MethodGet, "/api/aslan/workflow/v4/workflowtask"); err != nil {
+		return "", err
+	}
+
+	tasks, _, err := workflow.ListWorkflowTaskV4(wf.Name, 1, chatOpsStatusTaskCount, log)
+	if err != nil {
+		return "", err
+	}
+	if len(tasks) == 0 {
+		return fmt.Sprintf("workflow %s has no tasks yet", getChatOpsWorkflowName(wf)), nil
+	}
+
+	lines := []string{fmt.Sprintf("recent tasks of workflow %s:", getChatOpsWorkflowName(wf))}
+	for _, task := range tasks {
+		lines = append(lines, fmt.Sprintf("#%d %s, created by %s at %s, %s",
+			task.TaskID, task.Status, task.TaskCreator, time.Unix(task.CreateTime, 0).Format("2006-01-02 15:04:05"), getChatOpsTaskURL(wf, task.TaskID)))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// authorizeChatOpsAction evaluates the same policy as the web api for the request the action would send, with a
+// short-lived token issued for the user.
+// The bot can't challenge the user for MFA, so the token never carries the MFA claim, the users who must use MFA are
+// denied on the MFA required actions (run and approve) and have to perform them in Zadig.
+func authorizeChatOpsAction(user *usermodels.User, projectName, method, endpoint string) error {
+	token, err := login.CreateToken(&login.Claims{
+		Name:              user.Name,
+		Email:             user.Email,
+		UID:               user.UID,
+		PreferredUsername: user.Account,
+		FederatedClaims: login.FederatedClaims{
+			ConnectorId: user.IdentityType,
+			UserId:      user.Account,
+		},
+		StandardClaims: jwt.StandardClaims{
+			Audience:  setting.ProductName,
+			ExpiresAt: time.Now().Add(chatOpsTokenTTL).Unix(),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "create token")
+	}
+
+	inputGenerator := func() (*opa.Input, error) {
+		return &opa.Input{
+			ParsedQuery: &opa.ParseQuery{
+				ProjectName: []string{projectName},
+			},
+			ParsedPath: strings.Split(strings.Trim(endpoint, "/"), "/"),
+			Attributes: &opa.Attributes{
+				Request: &opa.Request{HTTP: &opa.HTTPSpec{
+					Method: method,
+					Headers: map[string]string{
+						strings.ToLower(setting.AuthorizationHeader): "Bearer " + token,
+					},
+				}},
+			},
+		}, nil
+	}
+
+	allowed := &struct {
+		Result bool `json:"result"`
+	}{}
+	if err := opa.NewDefault().Evaluate("rbac.allow", allowed, inputGenerator); err != nil {
+		return errors.Wrap(err, "evaluate policy")
+	}
+	if allowed.Result {
+		return nil
+	}
+
+	mfaSatisfied := &struct {
+		Result bool `json:"result"`
+	}{}
+	if err := opa.NewDefault().Evaluate("rbac.mfa_is_satisfied", mfaSatisfied, inputGenerator); err != nil {
+		return errors.Wrap(err, "evaluate policy")
+	}
+	if !mfaSatisfied.Result {
+		return errors.New("permission denied, the action requires an MFA login, please perform it in Zadig")
+	}
+	return errors.New("permission denied")
+}
+
+func getChatOpsWorkflowName(wf *commonmodels.WorkflowV4) string {
+	if wf.DisplayName != "" {
+		return wf.DisplayName
+	}
+	return wf.Name
+}
+
+func getChatOpsTaskURL(wf *commonmodels.WorkflowV4, taskID int64) string {
+	return fmt.Sprintf("%s/v1/projects/detail/%s/pipelines/custom/%s/%d?display_name=%s",
+		configbase.SystemAddress(), wf.Project, wf.Name, taskID, url.QueryEscape(getChatOpsWorkflowName(wf)))
+}
+
+// HandleLarkChatOpsMessage runs the command in the message sent to the lark bot and replies the result to the message.
+func HandleLarkChatOpsMessage(event *larkservice.MessageEvent, log *zap.SugaredLogger) {
+	client, err := larkservice.GetLarkClientByIMAppID(event.IMAppID)
+	if err != nil {
+		log.Errorf("failed to get lark client of im app %s: %s", event.IMAppID, err)
+		return
+	}
+
+	var reply string
+	info, err := client.GetUserContactInfoByID(event.OpenID)
+	if err != nil {
+		log.Errorf("failed to get lark user %s: %s", event.OpenID, err)
+		reply = "failed to get your lark contact info, please make sure the app has the contact permissions"
+	} else {
+		reply = RunChatOpsCommand(&ChatOpsIdentity{Email: info.Email, Mobile: info.Mobile}, event.Text, log)
+	}
+
+	if err := client.ReplyTextMessage(event.MessageID, reply); err != nil {
+		log.Errorf("failed to reply lark message %s: %s", event.MessageID, err)
+	}
+}
+
+// HandleSlackChatOpsCommand verifies the slash command sent by slack and runs it in the background, since slack
+// requires the request to be acknowledged in 3 seconds, the result is sent to the response url of the command.
+func HandleSlackChatOpsCommand(id, timestamp, signature string, body []byte, log *zap.SugaredLogger) (*slack.CommandResponse, error) {
+	app, err := commonrepo.NewIMAppColl().GetByID(context.Background(), id)
+	if err != nil {
+		return nil, e.ErrInvalidParam.AddDesc("im app not found")
+	}
+	if app.Type != setting.IMSlack {
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("unexpected im app type %s", app.Type))
+	}
+	if err := slack.VerifySignature(app.SlackSigningSecret, timestamp, signature, body); err != nil {
+		log.Warnf("failed to verify the slack command of im app %s: %s", id, err)
+		return nil, e.ErrForbidden.AddErr(err)
+	}
+
+	cmd, err := slack.ParseSlashCommand(body)
+	if err != nil {
+		return nil, e.ErrInvalidParam.AddErr(err)
+	}
+
+	go func() {
+		var reply string
+		info, err := slack.NewClient(app.SlackBotToken).GetUserInfo(cmd.UserID)
+		if err != nil {
+			log.Errorf("failed to get slack user %s: %s", cmd.UserID, err)
+			reply = "failed to get your slack profile, please make sure the app has the users:read and users:read.email scopes"
+		} else {
+			// the phone in the slack profile is free text, the user is only mapped by the verified email
+			reply = RunChatOpsCommand(&ChatOpsIdentity{Email: info.Profile.Email}, cmd.Text, log)
+		}
+
+		if err := slack.Respond(cmd.ResponseURL, &slack.CommandResponse{
+			ResponseType: slack.ResponseTypeInChannel,
+			Text:         fmt.Sprintf("> %s %s\n%s", cmd.Command, cmd.Text, reply),
+		}); err != nil {
+			log.Errorf("failed to respond slack command: %s", err)
+		}
+	}()
+
+	return &slack.CommandResponse{
+		ResponseType: slack.ResponseTypeEphemeral,
+		Text:         fmt.Sprintf("running `%s %s`", cmd.Command, cmd.Text),
+	}, nil
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/cloudevent"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/crypto"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+type ListEventDeliveriesResp struct {
+	Deliveries []*commonmodels.EventDelivery `json:"deliveries"`
+	Total      int64                         `json:"total"`
+}
+
+func ListEventTypes() []string {
+	return cloudevent.EventTypes
+}
+
+func ListEventSubscriptions(logger *zap.SugaredLogger) ([]*commonmodels.EventSubscription, error) {
+	subscriptions, err := commonrepo.NewEventSubscriptionColl().List()
+	if err != nil {
+		logger.Errorf("failed to list event subscriptions, error: %s", err)
+		return nil, e.ErrInternalError.AddErr(err)
+	}
+	for _, subscription := range subscriptions {
+		if subscription.Secret != "" {
+			subscription.Secret = setting.MaskValue
+		}
+	}
+	return subscriptions, nil
+}
+
+func CreateEventSubscription(userName string, args *commonmodels.EventSubscription, logger *zap.SugaredLogger) error {
+	if err := validateEventSubscription(args); err != nil {
+		return e.ErrInvalidParam.AddErr(err)
+	}
+	if err := encryptSubscriptionSecret(args, nil); err != nil {
+		logger.Errorf("failed to encrypt the secret of event subscription %s, error: %s", args.Name, err)
+		return e.ErrInternalError.AddErr(err)
+	}
+	args.CreatedBy = userName
+	args.CreateTime = time.Now().Unix()
+	args.UpdateBy = userName
+	args.UpdateTime = args.CreateTime
+	if err := commonrepo.NewEventSubscriptionColl().Create(args); err != nil {
+		logger.Errorf("failed to create event subscription %s, error: %s", args.Name, err)
+		return e.ErrInternalError.AddErr(err)
+	}
+	return nil
+}
+
+func UpdateEventSubscription(id, userName string, args *commonmodels.EventSubscription, logger *zap.SugaredLogger) error {
+	if err := validateEventSubscription(args); err != nil {
+		return e.ErrInvalidParam.AddErr(err)
+	}
+	origin, err := commonrepo.NewEventSubscriptionColl().Find(id)
+	if err != nil {
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("event subscription %s not found", id))
+	}
+	if err := encryptSubscriptionSecret(args, origin); err != nil {
+		logger.Errorf("failed to encrypt the secret of event subscription %s, error: %s", id, err)
+		return e.ErrInternalError.AddErr(err)
+	}
+	args.UpdateBy = userName
+	args.UpdateTime = time.Now().Unix()
+	if err := commonrepo.NewEventSubscriptionColl().Update(id, args); err != nil {
+		logger.Errorf("failed to update event subscription %s, error: %s", id, err)
+		return e.ErrInternalError.AddErr(err)
+	}
+	return nil
+}
+
+func DeleteEventSubscription(id string, logger *zap.SugaredLogger) error {
+	if err := commonrepo.NewEventSubscriptionColl().Delete(id); err != nil {
+		logger.Errorf("failed to delete event subscription %s, error: %s", id, err)
+		return e.ErrInternalError.AddErr(err)
+	}
+	return nil
+}
+
+func ListEventDeliveries(opt *commonrepo.EventDeliveryListOption, logger *zap.SugaredLogger) (*ListEventDeliveriesResp, error) {
+	deliveries, total, err := commonrepo.NewEventDeliveryColl().List(opt)
+	if err != nil {
+		logger.Errorf("failed to list event deliveries, error: %s", err)
+		return nil, e.ErrInternalError.AddErr(err)
+	}
+	return &ListEventDeliveriesResp{Deliveries: deliveries, Total: total}, nil
+}
+
+func GetEventDelivery(id string, logger *zap.SugaredLogger) (*commonmodels.EventDelivery, error) {
+	delivery, err := commonrepo.NewEventDeliveryColl().Find(id)
+	if err != nil {
+		logger.Errorf("failed to find event delivery %s, error: %s", id, err)
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("event delivery %s not found", id))
+	}
+	return delivery, nil
+}
+
+func RedeliverEvent(id string, logger *zap.SugaredLogger) error {
+	delivery, err := GetEventDelivery(id, logger)
+	if err != nil {
+		return err
+	}
+	if err := cloudevent.Redeliver(delivery); err != nil {
+		if err == cloudevent.ErrDeliveryInProgress {
+			return e.ErrInvalidParam.AddErr(err)
+		}
+		logger.Errorf("failed to redeliver event delivery %s, error: %s", id, err)
+		return e.ErrInternalError.AddErr(err)
+	}
+	return nil
+}
+
+// encryptSubscriptionSecret encrypts the secret before it's saved, a masked secret is replaced by the saved one.
+func encryptSubscriptionSecret(args, origin *commonmodels.EventSubscription) error {
+	if args.Secret == setting.MaskValue {
+		if origin == nil {
+			return fmt.Errorf("secret should not be masked")
+		}
+		args.Secret = origin.Secret
+		return nil
+	}
+	if args.Secret == "" {
+		return nil
+	}
+	secret, err := crypto.AesEncrypt(args.Secret)
+	if err != nil {
+		return err
+	}
+	args.Secret = secret
+	return nil
+}
+
+func validateEventSubscription(args *commonmodels.EventSubscription) error {
+	if args.Name == "" {
+		return fmt.Errorf("name should not be empty")
+	}
+	u, err := url.Parse(args.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url: %s", args.URL)
+	}
+	eventTypes := sets.NewString(cloudevent.EventTypes...)
+	for _, eventType := range args.EventTypes {
+		if !eventTypes.Has(eventType) {
+			return fmt.Errorf("unsupported event type: %s", eventType)
+		}
+	}
+	return nil
+}
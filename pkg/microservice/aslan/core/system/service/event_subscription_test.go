@@ -0,0 +1,43 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"testing"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+func TestEncryptSubscriptionSecret(t *testing.T) {
+	origin := &commonmodels.EventSubscription{Secret: "encrypted"}
+
+	args := &commonmodels.EventSubscription{Secret: setting.MaskValue}
+	if err := encryptSubscriptionSecret(args, origin); err != nil || args.Secret != "encrypted" {
+		t.Fatalf("expected a masked secret to be replaced by the saved one, got (%s, %v)", args.Secret, err)
+	}
+
+	args = &commonmodels.EventSubscription{Secret: setting.MaskValue}
+	if err := encryptSubscriptionSecret(args, nil); err == nil {
+		t.Fatalf("expected a masked secret to be rejected when creating a subscription")
+	}
+
+	args = &commonmodels.EventSubscription{}
+	if err := encryptSubscriptionSecret(args, origin); err != nil || args.Secret != "" {
+		t.Fatalf("expected an empty secret to remove the secret, got (%s, %v)", args.Secret, err)
+	}
+}
@@ -24,9 +24,13 @@ import (
 
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	dingtalkservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/dingtalk"
 	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/dingtalk"
 	e "github.com/koderover/zadig/pkg/tool/errors"
 	"github.com/koderover/zadig/pkg/tool/lark"
+	"github.com/koderover/zadig/pkg/tool/slack"
+	"github.com/koderover/zadig/pkg/tool/wecom"
 )
 
 func ListIMApp(_type string, log *zap.SugaredLogger) ([]*commonmodels.IMApp, error) {
@@ -46,20 +50,9 @@ func CreateIMApp(args *commonmodels.IMApp, log *zap.SugaredLogger) (string, erro
 		return "", e.ErrCreateIMApp.AddErr(err)
 	}
 
-	client := lark.NewClient(args.AppID, args.AppSecret)
-
-	approvalCode, err := createLarkDefaultApprovalDefinition(client)
-	if err != nil {
-		return "", e.ErrCreateIMApp.AddErr(errors.Wrap(err, "create definition"))
-	}
-	err = client.SubscribeApprovalDefinition(&lark.SubscribeApprovalDefinitionArgs{
-		ApprovalID: approvalCode,
-	})
-	if err != nil {
-		return "", e.ErrCreateIMApp.AddErr(errors.Wrap(err, "subscribe"))
+	if err := prepareIMAppApproval(args); err != nil {
+		return "", e.ErrCreateIMApp.AddErr(err)
 	}
-
-	args.LarkDefaultApprovalCode = approvalCode
 	err = mongodb.NewIMAppColl().Update(context.Background(), oid, args)
 	if err != nil {
 		return "", errors.Wrap(err, "update approval with approval code")
@@ -68,24 +61,21 @@ func CreateIMApp(args *commonmodels.IMApp, log *zap.SugaredLogger) (string, erro
 }
 
 func UpdateIMApp(id string, args *commonmodels.IMApp, log *zap.SugaredLogger) error {
-	if err := lark.Validate(args.AppID, args.AppSecret); err != nil {
+	if err := ValidateIMApp(args, log); err != nil {
 		return e.ErrUpdateIMApp.AddErr(errors.Wrap(err, "validate"))
 	}
 
-	client := lark.NewClient(args.AppID, args.AppSecret)
-	approvalCode, err := createLarkDefaultApprovalDefinition(client)
-	if err != nil {
-		return e.ErrUpdateIMApp.AddErr(errors.Wrap(err, "create definition"))
+	// the form created before is updated instead of creating a new one every time
+	if args.Type == setting.IMDingding {
+		if old, err := mongodb.NewIMAppColl().GetByID(context.Background(), id); err == nil {
+			args.DingTalkDefaultApprovalFormCode = old.DingTalkDefaultApprovalFormCode
+		}
 	}
-	err = client.SubscribeApprovalDefinition(&lark.SubscribeApprovalDefinitionArgs{
-		ApprovalID: approvalCode,
-	})
-	if err != nil {
-		return e.ErrUpdateIMApp.AddErr(errors.Wrap(err, "subscribe"))
+	if err := prepareIMAppApproval(args); err != nil {
+		return e.ErrUpdateIMApp.AddErr(err)
 	}
-	args.LarkDefaultApprovalCode = approvalCode
 
-	err = mongodb.NewIMAppColl().Update(context.Background(), id, args)
+	err := mongodb.NewIMAppColl().Update(context.Background(), id, args)
 	if err != nil {
 		log.Errorf("update external approval error: %v", err)
 		return e.ErrUpdateIMApp.AddErr(err)
@@ -93,6 +83,37 @@ func UpdateIMApp(id string, args *commonmodels.IMApp, log *zap.SugaredLogger) er
 	return nil
 }
 
+// prepareIMAppApproval creates the approval definition used by workflows in the im app.
+func prepareIMAppApproval(args *commonmodels.IMApp) error {
+	switch args.Type {
+	case setting.IMLark:
+		client := lark.NewClient(args.AppID, args.AppSecret)
+		approvalCode, err := createLarkDefaultApprovalDefinition(client)
+		if err != nil {
+			return errors.Wrap(err, "create definition")
+		}
+		err = client.SubscribeApprovalDefinition(&lark.SubscribeApprovalDefinitionArgs{
+			ApprovalID: approvalCode,
+		})
+		if err != nil {
+			return errors.Wrap(err, "subscribe")
+		}
+		args.LarkDefaultApprovalCode = approvalCode
+	case setting.IMDingding:
+		formCode, err := dingtalkservice.CreateDefaultApprovalForm(args)
+		if err != nil {
+			return errors.Wrap(err, "create form")
+		}
+		args.DingTalkDefaultApprovalFormCode = formCode
+	case setting.IMWeCom:
+		// wecom approval templates can only be created by admins, the template given must contain a text control
+		if _, _, err := wecom.NewClient(args.WeComCorpID, args.WeComSecret).GetTemplateTextControl(args.WeComApprovalTemplateID); err != nil {
+			return errors.Wrap(err, "check approval template")
+		}
+	}
+	return nil
+}
+
 func DeleteIMApp(id string, log *zap.SugaredLogger) error {
 	err := mongodb.NewIMAppColl().DeleteByID(context.Background(), id)
 	if err != nil {
@@ -107,10 +128,14 @@ func ValidateIMApp(approval *commonmodels.IMApp, log *zap.SugaredLogger) error {
 	case setting.IMLark:
 		return lark.Validate(approval.AppID, approval.AppSecret)
 	case setting.IMDingding:
+		return dingtalk.Validate(approval.DingTalkAppKey, approval.DingTalkAppSecret)
+	case setting.IMWeCom:
+		return wecom.Validate(approval.WeComCorpID, approval.WeComSecret)
+	case setting.IMSlack:
+		return slack.Validate(approval.SlackBotToken)
 	default:
 		return e.ErrValidateIMApp.AddDesc("invalid type")
 	}
-	return nil
 }
 
 func createLarkDefaultApprovalDefinition(client *lark.Client) (string, error) {
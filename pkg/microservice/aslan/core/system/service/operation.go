@@ -17,6 +17,9 @@ limitations under the License.
 package service
 
 import (
+	"encoding/json"
+
+	jsonpatch "github.com/evanphx/json-patch"
 	"go.uber.org/zap"
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/system/repository/models"
@@ -81,3 +84,54 @@ func UpdateOperation(id string, status int, log *zap.SugaredLogger) error {
 	}
 	return nil
 }
+
+type OperationLogDiff struct {
+	// Diff is a JSON merge patch from the previous request body of the same target to the one of the log
+	Diff string `json:"diff"`
+	// PreviousID is the id of the log the diff is computed against, it is empty if the log is the first one
+	PreviousID string `json:"previous_id,omitempty"`
+}
+
+// GetOperationLogDiff computes the diff of the request body when it is viewed, so that no extra query is needed
+// when the operation log is inserted.
+func GetOperationLogDiff(id string, log *zap.SugaredLogger) (*OperationLogDiff, error) {
+	coll := mongodb.NewOperationLogColl()
+	current, err := coll.FindByID(id)
+	if err != nil {
+		log.Errorf("find operation log %s error: %v", id, err)
+		return nil, e.ErrFindOperationLog.AddErr(err)
+	}
+
+	resp := &OperationLogDiff{}
+	previousBody := ""
+	previous, err := coll.FindPreviousByTarget(current)
+	if err != nil {
+		log.Errorf("find previous operation log of %s error: %v", id, err)
+		return nil, e.ErrFindOperationLog.AddErr(err)
+	}
+	if previous != nil {
+		resp.PreviousID = previous.ID.Hex()
+		previousBody = previous.RequestBody
+	}
+	resp.Diff = operationDiff(previousBody, current.RequestBody)
+	return resp, nil
+}
+
+// operationDiff returns the JSON merge patch from the previous request body to the current one,
+// an empty string is returned if the current request body is not a JSON object.
+func operationDiff(previous, current string) string {
+	obj := make(map[string]interface{})
+	if current == "" || json.Unmarshal([]byte(current), &obj) != nil {
+		return ""
+	}
+
+	original := []byte("{}")
+	if json.Valid([]byte(previous)) {
+		original = []byte(previous)
+	}
+	diff, err := jsonpatch.CreateMergePatch(original, []byte(current))
+	if err != nil {
+		diff, _ = jsonpatch.CreateMergePatch([]byte("{}"), []byte(current))
+	}
+	return string(diff)
+}
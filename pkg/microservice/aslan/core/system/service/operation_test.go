@@ -0,0 +1,44 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import "testing"
+
+func TestOperationDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous string
+		current  string
+		want     string
+	}{
+		{name: "first record", current: `{"name":"dev","replicas":1}`, want: `{"name":"dev","replicas":1}`},
+		{name: "changed field", previous: `{"name":"dev","replicas":1}`, current: `{"name":"dev","replicas":2}`, want: `{"replicas":2}`},
+		{name: "removed field", previous: `{"name":"dev","replicas":1}`, current: `{"name":"dev"}`, want: `{"replicas":null}`},
+		{name: "unchanged", previous: `{"name":"dev"}`, current: `{"name":"dev"}`, want: `{}`},
+		{name: "previous is not json", previous: "dev", current: `{"name":"dev"}`, want: `{"name":"dev"}`},
+		{name: "previous is an array", previous: `["dev"]`, current: `{"name":"dev"}`, want: `{"name":"dev"}`},
+		{name: "current is not an object", previous: `{"name":"dev"}`, current: `["dev"]`},
+		{name: "empty body", previous: `{"name":"dev"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := operationDiff(tt.previous, tt.current); got != tt.want {
+				t.Errorf("operationDiff() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
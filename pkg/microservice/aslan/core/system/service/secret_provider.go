@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"net/url"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/tool/crypto"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+// GetSecretProvider returns the secret provider config without the vault token.
+func GetSecretProvider(log *zap.SugaredLogger) (*commonmodels.SecretProvider, error) {
+	resp, err := commonrepo.NewSecretProviderColl().Get()
+	if err != nil {
+		log.Errorf("get secret provider error: %v", err)
+		return nil, e.ErrInternalError.AddErr(err)
+	}
+	if resp == nil {
+		return &commonmodels.SecretProvider{}, nil
+	}
+	if resp.Vault != nil {
+		resp.Vault.Token = ""
+	}
+	return resp, nil
+}
+
+// UpdateSecretProvider saves the secret provider config, the vault token is kept unchanged if it is empty.
+func UpdateSecretProvider(args *commonmodels.SecretProvider, log *zap.SugaredLogger) error {
+	switch args.Type {
+	case commonmodels.SecretProviderVault:
+		if args.Vault == nil {
+			return e.ErrInvalidParam.AddDesc("vault config is missing")
+		}
+		u, err := url.Parse(args.Vault.Address)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return e.ErrInvalidParam.AddDesc(fmt.Sprintf("invalid vault address: %s", args.Vault.Address))
+		}
+		args.Kubernetes = nil
+	case commonmodels.SecretProviderKubernetes:
+		if args.Kubernetes == nil || args.Kubernetes.Namespace == "" {
+			return e.ErrInvalidParam.AddDesc("kubernetes namespace is missing")
+		}
+		args.Vault = nil
+	default:
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("unsupported secret provider type %s", args.Type))
+	}
+
+	if args.Vault != nil {
+		if args.Vault.Token == "" {
+			current, err := commonrepo.NewSecretProviderColl().Get()
+			if err != nil {
+				log.Errorf("get secret provider error: %v", err)
+				return e.ErrInternalError.AddErr(err)
+			}
+			if current == nil || current.Vault == nil || current.Vault.Token == "" {
+				return e.ErrInvalidParam.AddDesc("vault token is missing")
+			}
+			args.Vault.Token = current.Vault.Token
+		} else {
+			token, err := crypto.AesEncrypt(args.Vault.Token)
+			if err != nil {
+				log.Errorf("encrypt vault token error: %v", err)
+				return e.ErrInternalError.AddErr(err)
+			}
+			args.Vault.Token = token
+		}
+	}
+
+	if err := commonrepo.NewSecretProviderColl().Upsert(args); err != nil {
+		log.Errorf("update secret provider error: %v", err)
+		return e.ErrInternalError.AddErr(err)
+	}
+	return nil
+}
+
+func DeleteSecretProvider(log *zap.SugaredLogger) error {
+	if err := commonrepo.NewSecretProviderColl().Delete(); err != nil {
+		log.Errorf("delete secret provider error: %v", err)
+		return e.ErrInternalError.AddErr(err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	s3service "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/s3"
+	"github.com/koderover/zadig/pkg/setting"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	s3tool "github.com/koderover/zadig/pkg/tool/s3"
+)
+
+type TerminalSetting struct {
+	RestrictProductionExec bool `json:"restrict_production_exec"`
+}
+
+type ListTerminalSessionsResp struct {
+	Sessions []*commonmodels.TerminalSession `json:"sessions"`
+	Total    int64                           `json:"total"`
+}
+
+func GetTerminalSetting(logger *zap.SugaredLogger) (*TerminalSetting, error) {
+	systemSetting, err := commonrepo.NewSystemSettingColl().Get()
+	if err != nil {
+		logger.Errorf("failed to get system setting, error: %s", err)
+		return nil, e.ErrInternalError.AddErr(err)
+	}
+	return &TerminalSetting{RestrictProductionExec: systemSetting.RestrictProductionExec}, nil
+}
+
+func UpdateTerminalSetting(args *TerminalSetting, logger *zap.SugaredLogger) error {
+	if err := commonrepo.NewSystemSettingColl().UpdateTerminalSetting(args.RestrictProductionExec); err != nil {
+		logger.Errorf("failed to update terminal setting, error: %s", err)
+		return e.ErrInternalError.AddErr(err)
+	}
+	return nil
+}
+
+func ListTerminalSessions(opt *commonrepo.TerminalSessionListOption, logger *zap.SugaredLogger) (*ListTerminalSessionsResp, error) {
+	sessions, total, err := commonrepo.NewTerminalSessionColl().List(opt)
+	if err != nil {
+		logger.Errorf("failed to list terminal sessions, error: %s", err)
+		return nil, e.ErrInternalError.AddErr(err)
+	}
+	return &ListTerminalSessionsResp{Sessions: sessions, Total: total}, nil
+}
+
+func GetTerminalSession(id string, logger *zap.SugaredLogger) (*commonmodels.TerminalSession, error) {
+	session, err := commonrepo.NewTerminalSessionColl().Find(id)
+	if err != nil {
+		logger.Errorf("failed to find terminal session %s, error: %s", id, err)
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("terminal session %s not found", id))
+	}
+	return session, nil
+}
+
+// GetTerminalSessionRecording returns the asciicast recording of the session which can be played by asciinema-player.
+func GetTerminalSessionRecording(id string, logger *zap.SugaredLogger) ([]byte, error) {
+	session, err := GetTerminalSession(id, logger)
+	if err != nil {
+		return nil, err
+	}
+	if session.ObjectKey == "" {
+		if session.Status == commonmodels.TerminalSessionStatusActive {
+			return nil, e.ErrInvalidParam.AddDesc("the session is still active")
+		}
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("the session has no recording: %s", session.Error))
+	}
+
+	storage, err := s3service.FindS3ById(session.StorageID)
+	if err != nil {
+		logger.Errorf("failed to find object storage %s, error: %s", session.StorageID, err)
+		return nil, e.ErrInternalError.AddErr(err)
+	}
+	forcedPathStyle := true
+	if storage.Provider == setting.ProviderSourceAli {
+		forcedPathStyle = false
+	}
+	client, err := s3tool.NewClient(storage.Endpoint, storage.Ak, storage.Sk, storage.Region, storage.Insecure, forcedPathStyle)
+	if err != nil {
+		logger.Errorf("failed to create s3 client, error: %s", err)
+		return nil, e.ErrInternalError.AddErr(err)
+	}
+	object, err := client.GetFile(storage.Bucket, session.ObjectKey, &s3tool.DownloadOption{RetryNum: 2})
+	if err != nil {
+		logger.Errorf("failed to get recording %s, error: %s", session.ObjectKey, err)
+		return nil, e.ErrInternalError.AddErr(err)
+	}
+	defer object.Body.Close()
+
+	return ioutil.ReadAll(object.Body)
+}
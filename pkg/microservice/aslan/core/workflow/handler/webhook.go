@@ -27,7 +27,9 @@ import (
 
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/webhook"
 	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+	"github.com/koderover/zadig/pkg/tool/bitbucket"
 	"github.com/koderover/zadig/pkg/tool/codehub"
+	"github.com/koderover/zadig/pkg/tool/gitea"
 	"github.com/koderover/zadig/pkg/tool/gitee"
 )
 
@@ -45,7 +47,10 @@ func ProcessWebHook(c *gin.Context) {
 		ctx.Err = err
 		return
 	}
-	if github.WebHookType(c.Request) != "" {
+	// gitea sends github compatible headers as well, so it must be checked first
+	if gitea.HookEventType(c.Request) != "" {
+		ctx.Err = webhook.ProcessGiteaHook(payload, c.Request, ctx.RequestID, ctx.Logger)
+	} else if github.WebHookType(c.Request) != "" {
 		ctx.Err = processGithub(payload, c.Request, ctx.RequestID, ctx.Logger)
 	} else if gitlab.HookEventType(c.Request) != "" {
 		ctx.Err = webhook.ProcessGitlabHook(payload, c.Request, ctx.RequestID, ctx.Logger)
@@ -53,6 +58,8 @@ func ProcessWebHook(c *gin.Context) {
 		ctx.Err = webhook.ProcessCodehubHook(payload, c.Request, ctx.RequestID, ctx.Logger)
 	} else if gitee.HookEventType(c.Request) != "" {
 		ctx.Err = webhook.ProcessGiteeHook(payload, c.Request, ctx.RequestID, ctx.Logger)
+	} else if bitbucket.HookEventType(c.Request) != "" {
+		ctx.Err = webhook.ProcessBitbucketHook(payload, c.Request, ctx.RequestID, ctx.Logger)
 	} else {
 		ctx.Err = webhook.ProcessGerritHook(payload, c.Request, ctx.RequestID, ctx.Logger)
 	}
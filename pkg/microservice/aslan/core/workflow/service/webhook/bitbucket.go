@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/config"
+	microserviceConfig "github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	bitbucketservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/bitbucket"
+	gitservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/git"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
+	"github.com/koderover/zadig/pkg/tool/bitbucket"
+)
+
+func ProcessBitbucketHook(payload []byte, req *http.Request, requestID string, log *zap.SugaredLogger) error {
+	if err := bitbucket.ValidateSignature(req, payload, gitservice.GetHookSecret()); err != nil {
+		return err
+	}
+
+	eventType := bitbucket.HookEventType(req)
+	// Bitbucket Server sends a ping event when testing the connection of a webhook
+	if eventType == bitbucket.EventTypeDiagnosticsPing {
+		return nil
+	}
+	event, err := bitbucket.ParseHook(eventType, payload)
+	if err != nil {
+		return err
+	}
+
+	baseURI := config.SystemAddress()
+	var errorList = &multierror.Error{}
+	var wg sync.WaitGroup
+
+	switch event := event.(type) {
+	case *bitbucket.PushEvent:
+		// Bitbucket may put several ref changes into one push, each of them triggers workflows on its own
+		for _, change := range event.Changes {
+			if change.Deleted {
+				continue
+			}
+			if change.Author != "" {
+				webhookUser := &commonmodels.WebHookUser{
+					Domain:    req.Header.Get("X-Forwarded-Host"),
+					UserName:  change.Author,
+					Source:    setting.SourceFromBitbucket,
+					CreatedAt: time.Now().Unix(),
+				}
+				commonrepo.NewWebHookUserColl().Upsert(webhookUser)
+			}
+
+			ev := &bitbucket.PushEvent{
+				Namespace: event.Namespace,
+				Repo:      event.Repo,
+				Actor:     event.Actor,
+				Changes:   []*bitbucket.RefChange{change},
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := TriggerWorkflowV4ByBitbucketEvent(ev, baseURI, requestID, log); err != nil {
+					errorList = multierror.Append(errorList, err)
+				}
+			}()
+		}
+	case *bitbucket.PullRequestEvent:
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := TriggerWorkflowV4ByBitbucketEvent(event, baseURI, requestID, log); err != nil {
+				errorList = multierror.Append(errorList, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+	return errorList.ErrorOrNil()
+}
+
+func newBitbucketClient(codehostID int) (*bitbucketservice.Client, error) {
+	detail, err := systemconfig.New().GetCodeHost(codehostID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find codehost %d: %v", codehostID, err)
+	}
+	return bitbucketservice.NewClient(detail.ID, detail.Address, detail.Username, detail.AccessToken, microserviceConfig.ProxyHTTPSAddr(), detail.EnableProxy)
+}
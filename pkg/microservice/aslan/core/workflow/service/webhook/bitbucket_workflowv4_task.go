@@ -0,0 +1,382 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/scmnotify"
+	workflowservice "github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow/job"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/bitbucket"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+type bitbucketEventMatcherForWorkflowV4 interface {
+	Match(*commonmodels.MainHookRepo) (bool, error)
+	GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository
+}
+
+// bitbucketRepoMatch compares case-insensitively since project keys of Bitbucket Server are upper case.
+func bitbucketRepoMatch(hookRepo *commonmodels.MainHookRepo, namespace, repo string) bool {
+	return strings.EqualFold(hookRepo.GetRepoNamespace()+"/"+hookRepo.RepoName, namespace+"/"+repo)
+}
+
+func bitbucketBranchMatch(hookRepo *commonmodels.MainHookRepo, branch string) bool {
+	if !hookRepo.IsRegular {
+		return hookRepo.Branch == branch
+	}
+	// Do not use regexp.MustCompile to avoid panic
+	matched, err := regexp.MatchString(hookRepo.Branch, branch)
+	return err == nil && matched
+}
+
+type bitbucketPushEventMatcherForWorkflowV4 struct {
+	log      *zap.SugaredLogger
+	workflow *commonmodels.WorkflowV4
+	event    *bitbucket.PushEvent
+	change   *bitbucket.RefChange
+}
+
+func (bpem *bitbucketPushEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
+	ev := bpem.event
+	if !bitbucketRepoMatch(hookRepo, ev.Namespace, ev.Repo) {
+		return false, nil
+	}
+	if !EventConfigured(hookRepo, config.HookEventPush) {
+		return false, nil
+	}
+	if !bitbucketBranchMatch(hookRepo, bpem.change.Name) {
+		return false, nil
+	}
+	hookRepo.Branch = bpem.change.Name
+	hookRepo.Committer = ev.Actor
+
+	// a newly created branch has nothing to compare with, it is treated as all files changed
+	var changedFiles []string
+	if bpem.change.Before != "" {
+		cli, err := newBitbucketClient(hookRepo.CodehostID)
+		if err != nil {
+			return false, err
+		}
+		changedFiles, err = cli.ListChangedFiles(ev.Namespace, ev.Repo, bpem.change.Before, bpem.change.After)
+		if err != nil {
+			bpem.log.Warnf("failed to get changes of event %v", ev)
+			return false, err
+		}
+	}
+	return MatchChanges(hookRepo, changedFiles), nil
+}
+
+func (bpem *bitbucketPushEventMatcherForWorkflowV4) GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository {
+	return &types.Repository{
+		CodehostID:    hookRepo.CodehostID,
+		RepoName:      hookRepo.RepoName,
+		RepoNamespace: hookRepo.GetRepoNamespace(),
+		RepoOwner:     hookRepo.RepoOwner,
+		Branch:        hookRepo.Branch,
+		Source:        hookRepo.Source,
+	}
+}
+
+type bitbucketMergeEventMatcherForWorkflowV4 struct {
+	log      *zap.SugaredLogger
+	workflow *commonmodels.WorkflowV4
+	event    *bitbucket.PullRequestEvent
+}
+
+func (bmem *bitbucketMergeEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
+	ev := bmem.event
+	if !bitbucketRepoMatch(hookRepo, ev.Namespace, ev.Repo) {
+		return false, nil
+	}
+	if !EventConfigured(hookRepo, config.HookEventPr) {
+		return false, nil
+	}
+	if !bitbucketBranchMatch(hookRepo, ev.PullRequest.TargetBranch) {
+		return false, nil
+	}
+	// the source branch of a fork can't be checked out from the base repository on Bitbucket Cloud
+	if isBitbucketCloudPullRequest(ev.EventType) && ev.PullRequest.FromFork() {
+		bmem.log.Infof("skip pull request %d of %s/%s from fork %s", ev.PullRequest.ID, ev.Namespace, ev.Repo, ev.PullRequest.SourceRepo)
+		return false, nil
+	}
+	hookRepo.Branch = ev.PullRequest.TargetBranch
+	hookRepo.Committer = ev.PullRequest.Author
+
+	cli, err := newBitbucketClient(hookRepo.CodehostID)
+	if err != nil {
+		return false, err
+	}
+	changedFiles, err := cli.ListPullRequestChangedFiles(ev.Namespace, ev.Repo, ev.PullRequest.ID)
+	if err != nil {
+		bmem.log.Warnf("failed to get changes of event %v", ev)
+		return false, err
+	}
+	bmem.log.Debugf("succeed to get %d changes in merge event", len(changedFiles))
+
+	return MatchChanges(hookRepo, changedFiles), nil
+}
+
+func (bmem *bitbucketMergeEventMatcherForWorkflowV4) GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository {
+	repo := &types.Repository{
+		CodehostID:    hookRepo.CodehostID,
+		RepoName:      hookRepo.RepoName,
+		RepoOwner:     hookRepo.RepoOwner,
+		RepoNamespace: hookRepo.GetRepoNamespace(),
+		Branch:        hookRepo.Branch,
+		PR:            bmem.event.PullRequest.ID,
+		Source:        hookRepo.Source,
+	}
+	// Bitbucket Cloud does not expose pull request refs, the source branch is checked out instead,
+	// so pull requests from forks are skipped in Match.
+	if isBitbucketCloudPullRequest(bmem.event.EventType) {
+		repo.CheckoutRef = "refs/heads/" + bmem.event.PullRequest.SourceBranch
+	}
+	return repo
+}
+
+func isBitbucketCloudPullRequest(eventType bitbucket.EventType) bool {
+	return eventType == bitbucket.EventTypePullRequestCreated || eventType == bitbucket.EventTypePullRequestUpdated
+}
+
+type bitbucketTagEventMatcherForWorkflowV4 struct {
+	log      *zap.SugaredLogger
+	workflow *commonmodels.WorkflowV4
+	event    *bitbucket.PushEvent
+	change   *bitbucket.RefChange
+}
+
+func (btem *bitbucketTagEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
+	ev := btem.event
+	if !bitbucketRepoMatch(hookRepo, ev.Namespace, ev.Repo) {
+		return false, nil
+	}
+	if !EventConfigured(hookRepo, config.HookEventTag) {
+		return false, nil
+	}
+
+	cli, err := newBitbucketClient(hookRepo.CodehostID)
+	if err != nil {
+		return false, err
+	}
+	defaultBranch, err := cli.GetDefaultBranch(ev.Namespace, ev.Repo)
+	if err != nil {
+		return false, err
+	}
+	if !bitbucketBranchMatch(hookRepo, defaultBranch) {
+		return false, nil
+	}
+	hookRepo.Tag = btem.change.Name
+	hookRepo.Committer = ev.Actor
+
+	return true, nil
+}
+
+func (btem *bitbucketTagEventMatcherForWorkflowV4) GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository {
+	return &types.Repository{
+		CodehostID:    hookRepo.CodehostID,
+		RepoName:      hookRepo.RepoName,
+		RepoOwner:     hookRepo.RepoOwner,
+		RepoNamespace: hookRepo.GetRepoNamespace(),
+		Branch:        hookRepo.Branch,
+		Tag:           hookRepo.Tag,
+		Source:        hookRepo.Source,
+	}
+}
+
+func createBitbucketEventMatcherForWorkflowV4(
+	event interface{}, workflow *commonmodels.WorkflowV4, log *zap.SugaredLogger,
+) bitbucketEventMatcherForWorkflowV4 {
+	switch evt := event.(type) {
+	case *bitbucket.PushEvent:
+		if len(evt.Changes) == 0 {
+			return nil
+		}
+		if evt.Changes[0].Type == bitbucket.RefTypeTag {
+			return &bitbucketTagEventMatcherForWorkflowV4{
+				workflow: workflow,
+				log:      log,
+				event:    evt,
+				change:   evt.Changes[0],
+			}
+		}
+		return &bitbucketPushEventMatcherForWorkflowV4{
+			workflow: workflow,
+			log:      log,
+			event:    evt,
+			change:   evt.Changes[0],
+		}
+	case *bitbucket.PullRequestEvent:
+		return &bitbucketMergeEventMatcherForWorkflowV4{
+			workflow: workflow,
+			log:      log,
+			event:    evt,
+		}
+	}
+
+	return nil
+}
+
+// TriggerWorkflowV4ByBitbucketEvent triggers workflows by a pull request event or a push event holding a single ref change.
+func TriggerWorkflowV4ByBitbucketEvent(event interface{}, baseURI, requestID string, log *zap.SugaredLogger) error {
+	workflows, _, err := commonrepo.NewWorkflowV4Coll().List(&commonrepo.ListWorkflowV4Option{}, 0, 0)
+	if err != nil {
+		errMsg := fmt.Sprintf("list workflow v4 error: %v", err)
+		log.Error(errMsg)
+		return fmt.Errorf(errMsg)
+	}
+
+	mErr := &multierror.Error{}
+	var hookPayload *commonmodels.HookPayload
+	var notification *commonmodels.Notification
+
+	for _, workflow := range workflows {
+		if workflow.HookCtls == nil {
+			continue
+		}
+		for _, item := range workflow.HookCtls {
+			if !item.Enabled {
+				continue
+			}
+			matcher := createBitbucketEventMatcherForWorkflowV4(event, workflow, log)
+			if matcher == nil {
+				continue
+			}
+			matches, err := matcher.Match(item.MainRepo)
+			if err != nil {
+				mErr = multierror.Append(mErr, err)
+			}
+			if !matches {
+				continue
+			}
+
+			log.Infof("event match hook %v of %s", item.MainRepo, workflow.Name)
+			eventRepo := matcher.GetHookRepo(item.MainRepo)
+
+			autoCancelOpt := &AutoCancelOpt{
+				TaskType:     config.WorkflowType,
+				MainRepo:     item.MainRepo,
+				AutoCancel:   item.AutoCancel,
+				WorkflowName: workflow.Name,
+			}
+			var mergeRequestID, commitID, ref, eventType string
+			var prID int
+			switch ev := event.(type) {
+			case *bitbucket.PullRequestEvent:
+				eventType = EventTypePR
+				mergeRequestID = strconv.Itoa(ev.PullRequest.ID)
+				commitID = ev.PullRequest.SourceCommit
+				prID = ev.PullRequest.ID
+				autoCancelOpt.Type = eventType
+				autoCancelOpt.CommitID = commitID
+				autoCancelOpt.MergeRequestID = mergeRequestID
+				hookPayload = &commonmodels.HookPayload{
+					Owner:          eventRepo.RepoOwner,
+					Repo:           eventRepo.RepoName,
+					CodehostID:     item.MainRepo.CodehostID,
+					Branch:         eventRepo.Branch,
+					IsPr:           true,
+					MergeRequestID: mergeRequestID,
+					CommitID:       commitID,
+					EventType:      eventType,
+				}
+			case *bitbucket.PushEvent:
+				change := ev.Changes[0]
+				if change.Type == bitbucket.RefTypeTag {
+					eventType = EventTypeTag
+					break
+				}
+				eventType = EventTypePush
+				ref = change.Ref
+				commitID = change.After
+				autoCancelOpt.Type = eventType
+				autoCancelOpt.CommitID = commitID
+				autoCancelOpt.Ref = ref
+				hookPayload = &commonmodels.HookPayload{
+					Owner:      eventRepo.RepoOwner,
+					Repo:       eventRepo.RepoName,
+					CodehostID: item.MainRepo.CodehostID,
+					Branch:     eventRepo.Branch,
+					Ref:        ref,
+					IsPr:       false,
+					CommitID:   commitID,
+					EventType:  eventType,
+				}
+			}
+			if autoCancelOpt.Type != "" {
+				err := AutoCancelWorkflowV4Task(autoCancelOpt, log)
+				if err != nil {
+					log.Errorf("failed to auto cancel workflowV4 task when receive event %v due to %v ", event, err)
+					mErr = multierror.Append(mErr, err)
+				}
+
+				if autoCancelOpt.Type == EventTypePR && notification == nil {
+					notification, err = scmnotify.NewService().SendInitWebhookComment(
+						item.MainRepo, prID, baseURI, false, false, false, true, log,
+					)
+					if err != nil {
+						log.Errorf("failed to init webhook comment due to %s", err)
+						mErr = multierror.Append(mErr, err)
+					}
+				}
+			}
+			if err := job.MergeArgs(workflow, item.WorkflowArg); err != nil {
+				errMsg := fmt.Sprintf("merge workflow args error: %v", err)
+				log.Error(errMsg)
+				mErr = multierror.Append(mErr, fmt.Errorf(errMsg))
+				continue
+			}
+			if err := job.MergeWebhookRepo(workflow, eventRepo); err != nil {
+				errMsg := fmt.Sprintf("merge webhook repo info to workflowargs error: %v", err)
+				log.Error(errMsg)
+				mErr = multierror.Append(mErr, fmt.Errorf(errMsg))
+				continue
+			}
+			if notification != nil {
+				workflow.NotificationID = notification.ID.Hex()
+			}
+			workflow.HookPayload = hookPayload
+			if resp, err := workflowservice.CreateWorkflowTaskV4(&workflowservice.CreateWorkflowTaskV4Args{
+				Name: setting.WebhookTaskCreator,
+			}, workflow, log); err != nil {
+				errMsg := fmt.Sprintf("failed to create workflow task when receive push event due to %v ", err)
+				log.Error(errMsg)
+				mErr = multierror.Append(mErr, fmt.Errorf(errMsg))
+			} else {
+				if workflow.HookPayload != nil {
+					// Reporting the build status to bitbucket, this will not cause the function to return error if this function call fails
+					if err := scmnotify.NewService().CreateGitCheckForWorkflowV4(workflow, resp.TaskID, log); err != nil {
+						log.Warnf("Failed to create bitbucket build status for custom workflow %s, taskID: %d the error is: %s", workflow.Name, resp.TaskID, err)
+					}
+				}
+				log.Infof("succeed to create task %v", resp)
+			}
+		}
+	}
+	return mErr.ErrorOrNil()
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/config"
+	microserviceConfig "github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	gitservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/git"
+	giteaservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/gitea"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
+	"github.com/koderover/zadig/pkg/tool/gitea"
+)
+
+func ProcessGiteaHook(payload []byte, req *http.Request, requestID string, log *zap.SugaredLogger) error {
+	if err := gitea.ValidateSignature(req, payload, gitservice.GetHookSecret()); err != nil {
+		return err
+	}
+
+	event, err := gitea.ParseHook(gitea.HookEventType(req), payload)
+	if err != nil {
+		return err
+	}
+
+	switch event := event.(type) {
+	case *gitea.PushEvent:
+		if event.IsDelete() {
+			return nil
+		}
+		if event.Pusher != nil && event.Pusher.Login != "" {
+			webhookUser := &commonmodels.WebHookUser{
+				Domain:    req.Header.Get("X-Forwarded-Host"),
+				UserName:  event.Pusher.Login,
+				Email:     event.Pusher.Email,
+				Source:    setting.SourceFromGitea,
+				CreatedAt: time.Now().Unix(),
+			}
+			commonrepo.NewWebHookUserColl().Upsert(webhookUser)
+		}
+	case *gitea.PullRequestEvent:
+		if event.Action != gitea.PullRequestActionOpened && event.Action != gitea.PullRequestActionSynchronized && event.Action != gitea.PullRequestActionReopened {
+			return nil
+		}
+	}
+
+	baseURI := config.SystemAddress()
+	var errorList = &multierror.Error{}
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := TriggerWorkflowV4ByGiteaEvent(event, baseURI, requestID, log); err != nil {
+			errorList = multierror.Append(errorList, err)
+		}
+	}()
+
+	wg.Wait()
+	return errorList.ErrorOrNil()
+}
+
+func newGiteaClient(codehostID int) (*giteaservice.Client, error) {
+	detail, err := systemconfig.New().GetCodeHost(codehostID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find codehost %d: %v", codehostID, err)
+	}
+	return giteaservice.NewClient(detail.ID, detail.Address, detail.AccessToken, microserviceConfig.ProxyHTTPSAddr(), detail.EnableProxy)
+}
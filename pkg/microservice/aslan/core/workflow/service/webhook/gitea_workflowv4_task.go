@@ -0,0 +1,354 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/config"
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/scmnotify"
+	workflowservice "github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow/job"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/gitea"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+type giteaEventMatcherForWorkflowV4 interface {
+	Match(*commonmodels.MainHookRepo) (bool, error)
+	GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository
+}
+
+// giteaRepoMatch compares case-insensitively since owner and repository names of Gitea are case-insensitive.
+func giteaRepoMatch(hookRepo *commonmodels.MainHookRepo, repo *gitea.Repository) bool {
+	return repo != nil && strings.EqualFold(hookRepo.GetRepoNamespace()+"/"+hookRepo.RepoName, repo.FullName)
+}
+
+func giteaBranchMatch(hookRepo *commonmodels.MainHookRepo, branch string) bool {
+	if !hookRepo.IsRegular {
+		return hookRepo.Branch == branch
+	}
+	// Do not use regexp.MustCompile to avoid panic
+	matched, err := regexp.MatchString(hookRepo.Branch, branch)
+	return err == nil && matched
+}
+
+func giteaUserName(user *gitea.User) string {
+	if user == nil {
+		return ""
+	}
+	return user.Login
+}
+
+type giteaPushEventMatcherForWorkflowV4 struct {
+	log      *zap.SugaredLogger
+	workflow *commonmodels.WorkflowV4
+	event    *gitea.PushEvent
+}
+
+func (gpem *giteaPushEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
+	ev := gpem.event
+	if !giteaRepoMatch(hookRepo, ev.Repo) {
+		return false, nil
+	}
+	if !EventConfigured(hookRepo, config.HookEventPush) {
+		return false, nil
+	}
+	branch := strings.TrimPrefix(ev.Ref, "refs/heads/")
+	if !giteaBranchMatch(hookRepo, branch) {
+		return false, nil
+	}
+	hookRepo.Branch = branch
+	hookRepo.Committer = giteaUserName(ev.Pusher)
+
+	// the payload only carries part of the commits of a large push, it is treated as all files changed then
+	changedFiles, ok := ev.ChangedFiles()
+	if !ok {
+		gpem.log.Debugf("commits of push event to %s are truncated, all files are considered changed", ev.Ref)
+	}
+	return MatchChanges(hookRepo, changedFiles), nil
+}
+
+func (gpem *giteaPushEventMatcherForWorkflowV4) GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository {
+	return &types.Repository{
+		CodehostID:    hookRepo.CodehostID,
+		RepoName:      hookRepo.RepoName,
+		RepoNamespace: hookRepo.GetRepoNamespace(),
+		RepoOwner:     hookRepo.RepoOwner,
+		Branch:        hookRepo.Branch,
+		Source:        hookRepo.Source,
+	}
+}
+
+type giteaMergeEventMatcherForWorkflowV4 struct {
+	log      *zap.SugaredLogger
+	workflow *commonmodels.WorkflowV4
+	event    *gitea.PullRequestEvent
+}
+
+func (gmem *giteaMergeEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
+	ev := gmem.event
+	if ev.PullRequest == nil || ev.PullRequest.Base == nil {
+		return false, nil
+	}
+	if !giteaRepoMatch(hookRepo, ev.Repo) {
+		return false, nil
+	}
+	if !EventConfigured(hookRepo, config.HookEventPr) {
+		return false, nil
+	}
+	if !giteaBranchMatch(hookRepo, ev.PullRequest.Base.Ref) {
+		return false, nil
+	}
+	hookRepo.Branch = ev.PullRequest.Base.Ref
+	hookRepo.Committer = giteaUserName(ev.PullRequest.User)
+
+	cli, err := newGiteaClient(hookRepo.CodehostID)
+	if err != nil {
+		return false, err
+	}
+	changedFiles, err := cli.ListPullRequestChangedFiles(hookRepo.GetRepoNamespace(), hookRepo.RepoName, ev.Number)
+	if err != nil {
+		gmem.log.Warnf("failed to get changes of event %v", ev)
+		return false, err
+	}
+	gmem.log.Debugf("succeed to get %d changes in merge event", len(changedFiles))
+
+	return MatchChanges(hookRepo, changedFiles), nil
+}
+
+func (gmem *giteaMergeEventMatcherForWorkflowV4) GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository {
+	return &types.Repository{
+		CodehostID:    hookRepo.CodehostID,
+		RepoName:      hookRepo.RepoName,
+		RepoOwner:     hookRepo.RepoOwner,
+		RepoNamespace: hookRepo.GetRepoNamespace(),
+		Branch:        hookRepo.Branch,
+		PR:            gmem.event.Number,
+		Source:        hookRepo.Source,
+	}
+}
+
+type giteaTagEventMatcherForWorkflowV4 struct {
+	log      *zap.SugaredLogger
+	workflow *commonmodels.WorkflowV4
+	event    *gitea.PushEvent
+}
+
+func (gtem *giteaTagEventMatcherForWorkflowV4) Match(hookRepo *commonmodels.MainHookRepo) (bool, error) {
+	ev := gtem.event
+	if !giteaRepoMatch(hookRepo, ev.Repo) {
+		return false, nil
+	}
+	if !EventConfigured(hookRepo, config.HookEventTag) {
+		return false, nil
+	}
+	if !giteaBranchMatch(hookRepo, ev.Repo.DefaultBranch) {
+		return false, nil
+	}
+	hookRepo.Tag = strings.TrimPrefix(ev.Ref, "refs/tags/")
+	hookRepo.Committer = giteaUserName(ev.Pusher)
+
+	return true, nil
+}
+
+func (gtem *giteaTagEventMatcherForWorkflowV4) GetHookRepo(hookRepo *commonmodels.MainHookRepo) *types.Repository {
+	return &types.Repository{
+		CodehostID:    hookRepo.CodehostID,
+		RepoName:      hookRepo.RepoName,
+		RepoOwner:     hookRepo.RepoOwner,
+		RepoNamespace: hookRepo.GetRepoNamespace(),
+		Branch:        hookRepo.Branch,
+		Tag:           hookRepo.Tag,
+		Source:        hookRepo.Source,
+	}
+}
+
+func createGiteaEventMatcherForWorkflowV4(
+	event interface{}, workflow *commonmodels.WorkflowV4, log *zap.SugaredLogger,
+) giteaEventMatcherForWorkflowV4 {
+	switch evt := event.(type) {
+	case *gitea.PushEvent:
+		if evt.IsTag() {
+			return &giteaTagEventMatcherForWorkflowV4{
+				workflow: workflow,
+				log:      log,
+				event:    evt,
+			}
+		}
+		return &giteaPushEventMatcherForWorkflowV4{
+			workflow: workflow,
+			log:      log,
+			event:    evt,
+		}
+	case *gitea.PullRequestEvent:
+		return &giteaMergeEventMatcherForWorkflowV4{
+			workflow: workflow,
+			log:      log,
+			event:    evt,
+		}
+	}
+
+	return nil
+}
+
+func TriggerWorkflowV4ByGiteaEvent(event interface{}, baseURI, requestID string, log *zap.SugaredLogger) error {
+	workflows, _, err := commonrepo.NewWorkflowV4Coll().List(&commonrepo.ListWorkflowV4Option{}, 0, 0)
+	if err != nil {
+		errMsg := fmt.Sprintf("list workflow v4 error: %v", err)
+		log.Error(errMsg)
+		return fmt.Errorf(errMsg)
+	}
+
+	mErr := &multierror.Error{}
+	var hookPayload *commonmodels.HookPayload
+	var notification *commonmodels.Notification
+
+	for _, workflow := range workflows {
+		if workflow.HookCtls == nil {
+			continue
+		}
+		for _, item := range workflow.HookCtls {
+			if !item.Enabled {
+				continue
+			}
+			matcher := createGiteaEventMatcherForWorkflowV4(event, workflow, log)
+			if matcher == nil {
+				continue
+			}
+			matches, err := matcher.Match(item.MainRepo)
+			if err != nil {
+				mErr = multierror.Append(mErr, err)
+			}
+			if !matches {
+				continue
+			}
+
+			log.Infof("event match hook %v of %s", item.MainRepo, workflow.Name)
+			eventRepo := matcher.GetHookRepo(item.MainRepo)
+
+			autoCancelOpt := &AutoCancelOpt{
+				TaskType:     config.WorkflowType,
+				MainRepo:     item.MainRepo,
+				AutoCancel:   item.AutoCancel,
+				WorkflowName: workflow.Name,
+			}
+			var mergeRequestID, commitID, ref, eventType string
+			var prID int
+			switch ev := event.(type) {
+			case *gitea.PullRequestEvent:
+				eventType = EventTypePR
+				mergeRequestID = strconv.Itoa(ev.Number)
+				if ev.PullRequest.Head != nil {
+					commitID = ev.PullRequest.Head.Sha
+				}
+				prID = ev.Number
+				autoCancelOpt.Type = eventType
+				autoCancelOpt.CommitID = commitID
+				autoCancelOpt.MergeRequestID = mergeRequestID
+				hookPayload = &commonmodels.HookPayload{
+					Owner:          eventRepo.RepoOwner,
+					Repo:           eventRepo.RepoName,
+					CodehostID:     item.MainRepo.CodehostID,
+					Branch:         eventRepo.Branch,
+					IsPr:           true,
+					MergeRequestID: mergeRequestID,
+					CommitID:       commitID,
+					EventType:      eventType,
+				}
+			case *gitea.PushEvent:
+				if ev.IsTag() {
+					eventType = EventTypeTag
+					break
+				}
+				eventType = EventTypePush
+				ref = ev.Ref
+				commitID = ev.After
+				autoCancelOpt.Type = eventType
+				autoCancelOpt.CommitID = commitID
+				autoCancelOpt.Ref = ref
+				hookPayload = &commonmodels.HookPayload{
+					Owner:      eventRepo.RepoOwner,
+					Repo:       eventRepo.RepoName,
+					CodehostID: item.MainRepo.CodehostID,
+					Branch:     eventRepo.Branch,
+					Ref:        ref,
+					IsPr:       false,
+					CommitID:   commitID,
+					EventType:  eventType,
+				}
+			}
+			if autoCancelOpt.Type != "" {
+				err := AutoCancelWorkflowV4Task(autoCancelOpt, log)
+				if err != nil {
+					log.Errorf("failed to auto cancel workflowV4 task when receive event %v due to %v ", event, err)
+					mErr = multierror.Append(mErr, err)
+				}
+
+				if autoCancelOpt.Type == EventTypePR && notification == nil {
+					notification, err = scmnotify.NewService().SendInitWebhookComment(
+						item.MainRepo, prID, baseURI, false, false, false, true, log,
+					)
+					if err != nil {
+						log.Errorf("failed to init webhook comment due to %s", err)
+						mErr = multierror.Append(mErr, err)
+					}
+				}
+			}
+			if err := job.MergeArgs(workflow, item.WorkflowArg); err != nil {
+				errMsg := fmt.Sprintf("merge workflow args error: %v", err)
+				log.Error(errMsg)
+				mErr = multierror.Append(mErr, fmt.Errorf(errMsg))
+				continue
+			}
+			if err := job.MergeWebhookRepo(workflow, eventRepo); err != nil {
+				errMsg := fmt.Sprintf("merge webhook repo info to workflowargs error: %v", err)
+				log.Error(errMsg)
+				mErr = multierror.Append(mErr, fmt.Errorf(errMsg))
+				continue
+			}
+			if notification != nil {
+				workflow.NotificationID = notification.ID.Hex()
+			}
+			workflow.HookPayload = hookPayload
+			if resp, err := workflowservice.CreateWorkflowTaskV4(&workflowservice.CreateWorkflowTaskV4Args{
+				Name: setting.WebhookTaskCreator,
+			}, workflow, log); err != nil {
+				errMsg := fmt.Sprintf("failed to create workflow task when receive push event due to %v ", err)
+				log.Error(errMsg)
+				mErr = multierror.Append(mErr, fmt.Errorf(errMsg))
+			} else {
+				if workflow.HookPayload != nil {
+					// Reporting the commit status to gitea, this will not cause the function to return error if this function call fails
+					if err := scmnotify.NewService().CreateGitCheckForWorkflowV4(workflow, resp.TaskID, log); err != nil {
+						log.Warnf("Failed to create gitea commit status for custom workflow %s, taskID: %d the error is: %s", workflow.Name, resp.TaskID, err)
+					}
+				}
+				log.Infof("succeed to create task %v", resp)
+			}
+		}
+	}
+	return mErr.ErrorOrNil()
+}
@@ -537,6 +537,10 @@ func ProcessGithubWebHookForWorkflowV4(payload []byte, req *http.Request, reques
 
 	switch et := event.(type) {
 	case *github.PullRequestEvent:
+		// preview environments are also destroyed when the pull request is closed
+		if err := TriggerPreviewEnvByGithubEvent(et, baseURI, deliveryID, requestID, log); err != nil {
+			log.Errorf("prEventToPreviewEnv error: %v", err)
+		}
 		if *et.Action != "opened" && *et.Action != "synchronize" {
 			return nil
 		}
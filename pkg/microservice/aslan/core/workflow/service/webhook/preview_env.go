@@ -0,0 +1,278 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v35/github"
+	"github.com/hashicorp/go-multierror"
+	"github.com/xanzy/go-gitlab"
+	"go.uber.org/zap"
+
+	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	templatemodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/template"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	templaterepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb/template"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/scmnotify"
+	environmentservice "github.com/koderover/zadig/pkg/microservice/aslan/core/environment/service"
+	workflowservice "github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/workflow/service/workflow/job"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/client/systemconfig"
+	"github.com/koderover/zadig/pkg/types"
+)
+
+// previewEnvEvent is the codehost independent part of a pull request event used by preview environments
+type previewEnvEvent struct {
+	source            string
+	pathWithNamespace string
+	targetBranch      string
+	pr                int
+	commitID          string
+	commitMessage     string
+	closed            bool
+	deliveryID        string
+}
+
+// TriggerPreviewEnvByGithubEvent creates, updates or deletes the preview environments according to the pull request event
+func TriggerPreviewEnvByGithubEvent(ev *github.PullRequestEvent, baseURI, deliveryID, requestID string, log *zap.SugaredLogger) error {
+	previewEv := newGithubPreviewEnvEvent(ev, deliveryID)
+	if previewEv == nil {
+		return nil
+	}
+	return triggerPreviewEnv(previewEv, baseURI, requestID, log)
+}
+
+// TriggerPreviewEnvByGitlabEvent creates, updates or deletes the preview environments according to the merge request event
+func TriggerPreviewEnvByGitlabEvent(ev *gitlab.MergeEvent, baseURI, requestID string, log *zap.SugaredLogger) error {
+	previewEv := newGitlabPreviewEnvEvent(ev)
+	if previewEv == nil {
+		return nil
+	}
+	return triggerPreviewEnv(previewEv, baseURI, requestID, log)
+}
+
+// newGithubPreviewEnvEvent returns nil if the action of the pull request event does not affect preview environments
+func newGithubPreviewEnvEvent(ev *github.PullRequestEvent, deliveryID string) *previewEnvEvent {
+	var closed bool
+	switch ev.GetAction() {
+	case "opened", "reopened", "synchronize":
+	case "closed":
+		closed = true
+	default:
+		return nil
+	}
+
+	return &previewEnvEvent{
+		source:            setting.SourceFromGithub,
+		pathWithNamespace: ev.GetPullRequest().GetBase().GetRepo().GetFullName(),
+		targetBranch:      ev.GetPullRequest().GetBase().GetRef(),
+		pr:                ev.GetPullRequest().GetNumber(),
+		commitID:          ev.GetPullRequest().GetHead().GetSHA(),
+		commitMessage:     ev.GetPullRequest().GetTitle(),
+		closed:            closed,
+		deliveryID:        deliveryID,
+	}
+}
+
+// newGitlabPreviewEnvEvent returns nil if the action of the merge request event does not affect preview environments
+func newGitlabPreviewEnvEvent(ev *gitlab.MergeEvent) *previewEnvEvent {
+	var closed bool
+	switch ev.ObjectAttributes.Action {
+	case "open", "reopen", "update":
+	case "close", "merge":
+		closed = true
+	default:
+		return nil
+	}
+
+	return &previewEnvEvent{
+		source:            setting.SourceFromGitlab,
+		pathWithNamespace: ev.ObjectAttributes.Target.PathWithNamespace,
+		targetBranch:      ev.ObjectAttributes.TargetBranch,
+		pr:                ev.ObjectAttributes.IID,
+		commitID:          ev.ObjectAttributes.LastCommit.ID,
+		commitMessage:     ev.ObjectAttributes.Title,
+		closed:            closed,
+	}
+}
+
+func triggerPreviewEnv(ev *previewEnvEvent, baseURI, requestID string, log *zap.SugaredLogger) error {
+	projects, err := templaterepo.NewProductColl().ListWithOption(&templaterepo.ProductListOpt{PreviewEnvEnabled: true})
+	if err != nil {
+		return fmt.Errorf("failed to list projects with preview environment enabled, err: %s", err)
+	}
+
+	mErr := &multierror.Error{}
+	for _, project := range projects {
+		for _, repo := range project.PreviewEnv.Repos {
+			if !matchPreviewEnvRepo(repo, ev) {
+				continue
+			}
+			eventRepo := previewEnvEventRepo(repo, ev)
+
+			if ev.closed {
+				if err := destroyPreviewEnv(project, eventRepo, baseURI, requestID, log); err != nil {
+					mErr = multierror.Append(mErr, err)
+				}
+				continue
+			}
+			if err := deployPreviewEnv(project, eventRepo, ev.deliveryID, baseURI, requestID, log); err != nil {
+				mErr = multierror.Append(mErr, err)
+			}
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+func previewEnvEventRepo(repo *templatemodels.PreviewEnvRepo, ev *previewEnvEvent) *types.Repository {
+	return &types.Repository{
+		Source:        ev.source,
+		CodehostID:    repo.CodehostID,
+		RepoOwner:     repo.RepoOwner,
+		RepoNamespace: getPreviewEnvRepoNamespace(repo),
+		RepoName:      repo.RepoName,
+		Branch:        ev.targetBranch,
+		PR:            ev.pr,
+		CommitID:      ev.commitID,
+		CommitMessage: ev.commitMessage,
+	}
+}
+
+func getPreviewEnvRepoNamespace(repo *templatemodels.PreviewEnvRepo) string {
+	if repo.RepoNamespace != "" {
+		return repo.RepoNamespace
+	}
+	return repo.RepoOwner
+}
+
+func matchPreviewEnvRepo(repo *templatemodels.PreviewEnvRepo, ev *previewEnvEvent) bool {
+	if getPreviewEnvRepoNamespace(repo)+"/"+repo.RepoName != ev.pathWithNamespace {
+		return false
+	}
+	if repo.Branch != "" && repo.Branch != ev.targetBranch {
+		return false
+	}
+	codehost, err := systemconfig.New().GetCodeHost(repo.CodehostID)
+	if err != nil {
+		return false
+	}
+	return strings.ToLower(codehost.Type) == ev.source
+}
+
+func deployPreviewEnv(project *templatemodels.Product, repo *types.Repository, deliveryID, baseURI, requestID string, log *zap.SugaredLogger) error {
+	env, created, err := environmentservice.EnsurePreviewEnv(project.ProductName, requestID, &commonmodels.ProductPreviewEnv{
+		CodehostID:    repo.CodehostID,
+		RepoOwner:     repo.RepoOwner,
+		RepoNamespace: repo.RepoNamespace,
+		RepoName:      repo.RepoName,
+		PR:            repo.PR,
+		CommitID:      repo.CommitID,
+	}, log)
+	if err != nil {
+		log.Errorf("failed to ensure preview environment of %s#%d in project %s, err: %s", repo.RepoName, repo.PR, project.ProductName, err)
+		return err
+	}
+
+	if created {
+		// Commenting the pull request will not cause the function to return error if this function call fails
+		if err := scmnotify.NewService().SendPreviewEnvComment(repo, baseURI, &commonmodels.PreviewEnvInfo{
+			ProductName: project.ProductName,
+			EnvName:     env.EnvName,
+			BaseEnv:     env.ShareEnv.BaseEnv,
+			URL:         project.PreviewEnv.URL,
+		}, log); err != nil {
+			log.Warnf("failed to comment preview environment %s to %s#%d, err: %s", env.EnvName, repo.RepoName, repo.PR, err)
+		}
+
+		// services of the new environment are deployed asynchronously, the workflow is triggered once they are ready
+		go func() {
+			if err := environmentservice.WaitPreviewEnvReady(project.ProductName, env.EnvName); err != nil {
+				log.Errorf("preview environment %s is not ready, workflow is not triggered, err: %s", env.EnvName, err)
+				return
+			}
+			if err := createPreviewEnvWorkflowTask(project, env, repo, deliveryID, log); err != nil {
+				log.Errorf("failed to trigger workflow of preview environment %s, err: %s", env.EnvName, err)
+			}
+		}()
+		return nil
+	}
+
+	return createPreviewEnvWorkflowTask(project, env, repo, deliveryID, log)
+}
+
+func createPreviewEnvWorkflowTask(project *templatemodels.Product, env *commonmodels.Product, repo *types.Repository, deliveryID string, log *zap.SugaredLogger) error {
+	workflow, err := commonrepo.NewWorkflowV4Coll().Find(project.PreviewEnv.WorkflowName)
+	if err != nil {
+		return fmt.Errorf("failed to find workflow %s of preview environment, err: %s", project.PreviewEnv.WorkflowName, err)
+	}
+	if err := job.MergeDeployEnv(workflow, env.EnvName); err != nil {
+		return fmt.Errorf("merge preview environment to workflow %s error: %s", workflow.Name, err)
+	}
+	if err := job.MergeWebhookRepo(workflow, repo); err != nil {
+		return fmt.Errorf("merge webhook repo info to workflow %s error: %s", workflow.Name, err)
+	}
+	workflow.HookPayload = &commonmodels.HookPayload{
+		Owner:          repo.RepoOwner,
+		Repo:           repo.RepoName,
+		Branch:         repo.Branch,
+		Ref:            repo.CommitID,
+		IsPr:           true,
+		CodehostID:     repo.CodehostID,
+		DeliveryID:     deliveryID,
+		MergeRequestID: strconv.Itoa(repo.PR),
+		CommitID:       repo.CommitID,
+		EventType:      EventTypePR,
+	}
+
+	resp, err := workflowservice.CreateWorkflowTaskV4(&workflowservice.CreateWorkflowTaskV4Args{
+		Name: setting.WebhookTaskCreator,
+	}, workflow, log)
+	if err != nil {
+		return fmt.Errorf("failed to create workflow task for preview environment %s, err: %s", env.EnvName, err)
+	}
+	if repo.Source == setting.SourceFromGithub {
+		if err := scmnotify.NewService().CreateGitCheckForWorkflowV4(workflow, resp.TaskID, log); err != nil {
+			log.Warnf("Failed to create github check status for custom workflow %s, taskID: %d the error is: %s", workflow.Name, resp.TaskID, err)
+		}
+	}
+	log.Infof("succeed to create task %v for preview environment %s", resp, env.EnvName)
+	return nil
+}
+
+func destroyPreviewEnv(project *templatemodels.Product, repo *types.Repository, baseURI, requestID string, log *zap.SugaredLogger) error {
+	envName, deleted, err := environmentservice.DeletePreviewEnv(project.ProductName, repo.RepoNamespace, repo.RepoName, repo.PR, requestID, log)
+	if err != nil {
+		log.Errorf("failed to delete preview environment of %s#%d in project %s, err: %s", repo.RepoName, repo.PR, project.ProductName, err)
+		return err
+	}
+	if !deleted {
+		return nil
+	}
+
+	if err := scmnotify.NewService().SendPreviewEnvComment(repo, baseURI, &commonmodels.PreviewEnvInfo{
+		ProductName: project.ProductName,
+		EnvName:     envName,
+		Deleted:     true,
+	}, log); err != nil {
+		log.Warnf("failed to comment preview environment %s to %s#%d, err: %s", envName, repo.RepoName, repo.PR, err)
+	}
+	return nil
+}
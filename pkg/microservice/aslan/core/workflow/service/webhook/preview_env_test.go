@@ -0,0 +1,135 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+
+	"github.com/google/go-github/v35/github"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+
+	templatemodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models/template"
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+func newTestGithubPullRequestEvent(action string) *github.PullRequestEvent {
+	return &github.PullRequestEvent{
+		Action: github.String(action),
+		PullRequest: &github.PullRequest{
+			Number: github.Int(7),
+			Title:  github.String("ZADIG-1 add preview env"),
+			Base: &github.PullRequestBranch{
+				Ref:  github.String("main"),
+				Repo: &github.Repository{FullName: github.String("koderover/zadig")},
+			},
+			Head: &github.PullRequestBranch{SHA: github.String("abc123")},
+		},
+	}
+}
+
+func newTestGitlabMergeEvent(action string) *gitlab.MergeEvent {
+	ev := &gitlab.MergeEvent{}
+	Expect(json.Unmarshal([]byte(`{"object_attributes": {
+		"iid": 7,
+		"title": "ZADIG-1 add preview env",
+		"target_branch": "main",
+		"action": "`+action+`",
+		"target": {"path_with_namespace": "group/zadig"},
+		"last_commit": {"id": "abc123"}
+	}}`), ev)).To(Succeed())
+	return ev
+}
+
+var _ = Describe("Testing preview env", func() {
+
+	Describe("test newGithubPreviewEnvEvent", func() {
+
+		It("should create or update the env for opened and updated pull requests", func() {
+			for _, action := range []string{"opened", "reopened", "synchronize"} {
+				ev := newGithubPreviewEnvEvent(newTestGithubPullRequestEvent(action), "delivery")
+				Expect(ev).NotTo(BeNil())
+				Expect(ev.closed).To(BeFalse())
+				Expect(ev.source).To(Equal(setting.SourceFromGithub))
+				Expect(ev.pathWithNamespace).To(Equal("koderover/zadig"))
+				Expect(ev.targetBranch).To(Equal("main"))
+				Expect(ev.pr).To(Equal(7))
+				Expect(ev.commitID).To(Equal("abc123"))
+				Expect(ev.deliveryID).To(Equal("delivery"))
+			}
+		})
+
+		It("should clean up the env for closed pull requests", func() {
+			ev := newGithubPreviewEnvEvent(newTestGithubPullRequestEvent("closed"), "delivery")
+			Expect(ev).NotTo(BeNil())
+			Expect(ev.closed).To(BeTrue())
+		})
+
+		It("should ignore other actions", func() {
+			Expect(newGithubPreviewEnvEvent(newTestGithubPullRequestEvent("labeled"), "delivery")).To(BeNil())
+		})
+	})
+
+	Describe("test newGitlabPreviewEnvEvent", func() {
+
+		It("should create or update the env for opened and updated merge requests", func() {
+			for _, action := range []string{"open", "reopen", "update"} {
+				ev := newGitlabPreviewEnvEvent(newTestGitlabMergeEvent(action))
+				Expect(ev).NotTo(BeNil())
+				Expect(ev.closed).To(BeFalse())
+				Expect(ev.source).To(Equal(setting.SourceFromGitlab))
+				Expect(ev.pathWithNamespace).To(Equal("group/zadig"))
+				Expect(ev.pr).To(Equal(7))
+				Expect(ev.commitID).To(Equal("abc123"))
+			}
+		})
+
+		It("should clean up the env for closed and merged merge requests", func() {
+			for _, action := range []string{"close", "merge"} {
+				ev := newGitlabPreviewEnvEvent(newTestGitlabMergeEvent(action))
+				Expect(ev).NotTo(BeNil())
+				Expect(ev.closed).To(BeTrue())
+			}
+		})
+
+		It("should ignore other actions", func() {
+			Expect(newGitlabPreviewEnvEvent(newTestGitlabMergeEvent("approved"))).To(BeNil())
+		})
+	})
+
+	Describe("test previewEnvEventRepo", func() {
+
+		It("should use the repo owner as namespace if it is not set", func() {
+			ev := newGithubPreviewEnvEvent(newTestGithubPullRequestEvent("opened"), "delivery")
+			repo := previewEnvEventRepo(&templatemodels.PreviewEnvRepo{CodehostID: 1, RepoOwner: "koderover", RepoName: "zadig"}, ev)
+			Expect(repo.RepoNamespace).To(Equal("koderover"))
+			Expect(repo.CodehostID).To(Equal(1))
+			Expect(repo.Branch).To(Equal("main"))
+			Expect(repo.PR).To(Equal(7))
+			Expect(repo.CommitID).To(Equal("abc123"))
+			Expect(repo.CommitMessage).To(Equal("ZADIG-1 add preview env"))
+		})
+
+		It("should keep the configured namespace", func() {
+			ev := newGitlabPreviewEnvEvent(newTestGitlabMergeEvent("open"))
+			repo := previewEnvEventRepo(&templatemodels.PreviewEnvRepo{RepoOwner: "owner", RepoNamespace: "group", RepoName: "zadig"}, ev)
+			Expect(repo.RepoNamespace).To(Equal("group"))
+			Expect(repo.Source).To(Equal(setting.SourceFromGitlab))
+		})
+	})
+})
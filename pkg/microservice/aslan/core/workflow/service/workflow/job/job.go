@@ -179,6 +179,22 @@ func MergeWebhookRepo(workflow *commonmodels.WorkflowV4, repo *types.Repository)
 	return nil
 }
 
+// MergeDeployEnv replaces the env of all the deploy jobs in the workflow
+func MergeDeployEnv(workflow *commonmodels.WorkflowV4, envName string) error {
+	for _, stage := range workflow.Stages {
+		for _, job := range stage.Jobs {
+			if job.JobType != config.JobZadigDeploy {
+				continue
+			}
+			jobCtl := &DeployJob{job: job, workflow: workflow}
+			if err := jobCtl.MergeDeployEnv(envName); err != nil {
+				return warpJobError(job.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
 func GetWorkflowOutputs(workflow *commonmodels.WorkflowV4, currentJobName string, log *zap.SugaredLogger) []string {
 	resp := []string{}
 	jobRankMap := getJobRankMap(workflow.Stages)
@@ -531,6 +531,9 @@ func mergeRepos(templateRepos []*types.Repository, customRepos []*types.Reposito
 			repo.Tag = cv.Tag
 			repo.PR = cv.PR
 			repo.PRs = cv.PRs
+			if cv.CheckoutRef != "" {
+				repo.CheckoutRef = cv.CheckoutRef
+			}
 		}
 	}
 	return templateRepos
@@ -83,6 +83,17 @@ func (j *DeployJob) MergeArgs(args *commonmodels.Job) error {
 	return nil
 }
 
+// MergeDeployEnv sets the env of the deploy job, it is used to deploy services into the preview environment
+func (j *DeployJob) MergeDeployEnv(envName string) error {
+	j.spec = &commonmodels.ZadigDeployJobSpec{}
+	if err := commonmodels.IToi(j.job.Spec, j.spec); err != nil {
+		return err
+	}
+	j.spec.Env = envName
+	j.job.Spec = j.spec
+	return nil
+}
+
 func (j *DeployJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
 	resp := []*commonmodels.JobTask{}
 
@@ -179,6 +190,7 @@ func (j *DeployJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
 				ServiceModule:      deploy.ServiceModule,
 				ClusterID:          product.ClusterID,
 				Image:              deploy.Image,
+				Verification:       j.spec.Verification,
 			}
 			jobTask := &commonmodels.JobTask{
 				Name:    jobNameFormat(deploy.ServiceName + "-" + deploy.ServiceModule + "-" + j.job.Name),
@@ -217,6 +229,7 @@ func (j *DeployJob) ToJobs(taskID int64) ([]*commonmodels.JobTask, error) {
 				ServiceType:        setting.HelmDeployType,
 				ClusterID:          product.ClusterID,
 				ReleaseName:        releaseName,
+				Verification:       j.spec.Verification,
 			}
 			for _, deploy := range deploys {
 				if err := checkServiceExsistsInEnv(productServiceMap, serviceName, j.spec.Env); err != nil {
@@ -253,6 +266,9 @@ func (j *DeployJob) LintJob() error {
 	if err := commonmodels.IToiYaml(j.job.Spec, j.spec); err != nil {
 		return err
 	}
+	if err := lintDeployVerification(j.spec.Verification); err != nil {
+		return fmt.Errorf("invalid verification of job %s: %v", j.job.Name, err)
+	}
 	if j.spec.Source != config.SourceFromJob {
 		return nil
 	}
@@ -263,3 +279,33 @@ func (j *DeployJob) LintJob() error {
 	}
 	return nil
 }
+
+func lintDeployVerification(verification *commonmodels.DeployVerification) error {
+	if verification == nil || !verification.Enabled {
+		return nil
+	}
+	if verification.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	for _, p := range verification.Probes {
+		switch p.Protocol {
+		case setting.ProtocolHTTP, setting.ProtocolHTTPS, setting.ProtocolTCP:
+		default:
+			return fmt.Errorf("unsupported probe protocol: %s", p.Protocol)
+		}
+		if p.Address == "" {
+			return fmt.Errorf("probe address can't be empty")
+		}
+	}
+	for _, m := range verification.Metrics {
+		switch m.Operator {
+		case ">", ">=", "<", "<=":
+		default:
+			return fmt.Errorf("unsupported operator %s of metric %s", m.Operator, m.Name)
+		}
+		if m.Endpoint == "" || m.Query == "" {
+			return fmt.Errorf("endpoint and query of metric %s can't be empty", m.Name)
+		}
+	}
+	return nil
+}
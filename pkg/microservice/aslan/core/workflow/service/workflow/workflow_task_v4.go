@@ -30,6 +30,7 @@ import (
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
 	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/changefreeze"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/instantmessage"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/lark"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/s3"
@@ -118,9 +119,10 @@ type ZadigScanningJobSpec struct {
 }
 
 type ZadigDeployJobSpec struct {
-	Env                string             `bson:"env"                          json:"env"`
-	SkipCheckRunStatus bool               `bson:"skip_check_run_status"        json:"skip_check_run_status"`
-	ServiceAndImages   []*ServiceAndImage `bson:"service_and_images"           json:"service_and_images"`
+	Env                string                           `bson:"env"                          json:"env"`
+	SkipCheckRunStatus bool                             `bson:"skip_check_run_status"        json:"skip_check_run_status"`
+	ServiceAndImages   []*ServiceAndImage               `bson:"service_and_images"           json:"service_and_images"`
+	Verification       *commonmodels.DeployVerification `bson:"verification"                 json:"verification,omitempty"`
 }
 
 type CustomDeployJobSpec struct {
@@ -360,6 +362,10 @@ func CreateWorkflowTaskV4(args *CreateWorkflowTaskV4Args, workflow *commonmodels
 	if err := workflowTaskLint(workflowTask, log); err != nil {
 		return resp, err
 	}
+	if err := changefreeze.CheckWorkflowTask(workflowTask); err != nil {
+		log.Errorf("workflow %s is blocked by change freeze: %v", workflow.Name, err)
+		return resp, e.ErrCreateTask.AddDesc(err.Error())
+	}
 
 	workflowTask.WorkflowArgs = workflow
 	workflowTask.Status = config.StatusCreated
@@ -389,6 +395,9 @@ func CloneWorkflowTaskV4(workflowName string, taskID int64, logger *zap.SugaredL
 		logger.Errorf("find workflowTaskV4 error: %s", err)
 		return nil, e.ErrGetTask.AddErr(err)
 	}
+	if task.OriginWorkflowArgs != nil {
+		instantmessage.MaskWebhookSecrets(task.OriginWorkflowArgs.NotifyCtls)
+	}
 	return task.OriginWorkflowArgs, nil
 }
 
@@ -442,6 +451,23 @@ func CancelWorkflowTaskV4(userName, workflowName string, taskID int64, logger *z
 	return nil
 }
 
+func PreviewWorkflowTaskV4Notification(workflowName string, taskID int64, notify *commonmodels.NotifyCtl, logger *zap.SugaredLogger) (*instantmessage.NotificationPreview, error) {
+	if err := instantmessage.ValidateNotificationTemplate(notify); err != nil {
+		return nil, e.ErrInvalidParam.AddErr(err)
+	}
+	task, err := commonrepo.NewworkflowTaskv4Coll().Find(workflowName, taskID)
+	if err != nil {
+		logger.Errorf("find workflowTaskV4 error: %s", err)
+		return nil, e.ErrInvalidParam.AddErr(err)
+	}
+	preview, err := instantmessage.NewWeChatClient().PreviewWorkflowTaskNotification(notify, task)
+	if err != nil {
+		logger.Errorf("failed to preview notification of workflow %s task %d, error: %s", workflowName, taskID, err)
+		return nil, e.ErrInvalidParam.AddErr(err)
+	}
+	return preview, nil
+}
+
 func GetWorkflowTaskV4(workflowName string, taskID int64, logger *zap.SugaredLogger) (*WorkflowTaskPreview, error) {
 	task, err := commonrepo.NewworkflowTaskv4Coll().Find(workflowName, taskID)
 	if err != nil {
@@ -624,6 +650,7 @@ func jobsToJobPreviews(jobs []*commonmodels.JobTask, context map[string]string)
 			}
 			spec.Env = taskJobSpec.Env
 			spec.SkipCheckRunStatus = taskJobSpec.SkipCheckRunStatus
+			spec.Verification = taskJobSpec.Verification
 			spec.ServiceAndImages = append(spec.ServiceAndImages, &ServiceAndImage{
 				ServiceName:   taskJobSpec.ServiceName,
 				ServiceModule: taskJobSpec.ServiceModule,
@@ -640,6 +667,7 @@ func jobsToJobPreviews(jobs []*commonmodels.JobTask, context map[string]string)
 			}
 			spec.Env = taskJobSpec.Env
 			spec.SkipCheckRunStatus = taskJobSpec.SkipCheckRunStatus
+			spec.Verification = taskJobSpec.Verification
 			for _, imageAndmodule := range taskJobSpec.ImageAndModules {
 				spec.ServiceAndImages = append(spec.ServiceAndImages, &ServiceAndImage{
 					ServiceName:   taskJobSpec.ServiceName,
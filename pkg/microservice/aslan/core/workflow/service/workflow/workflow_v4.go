@@ -37,6 +37,7 @@ import (
 	templaterepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb/template"
 	commonservice "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/collaboration"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/instantmessage"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/nsq"
 	commomtemplate "github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/template"
 	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/webhook"
@@ -61,6 +62,10 @@ func CreateWorkflowV4(user string, workflow *commonmodels.WorkflowV4, logger *za
 	if err := LintWorkflowV4(workflow, logger); err != nil {
 		return err
 	}
+	if err := instantmessage.EncryptWebhookSecrets(workflow.NotifyCtls, nil); err != nil {
+		logger.Errorf("Failed to encrypt webhook secrets, error: %s", err)
+		return e.ErrUpsertWorkflow.AddErr(err)
+	}
 
 	workflow.CreatedBy = user
 	workflow.UpdatedBy = user
@@ -99,6 +104,10 @@ func UpdateWorkflowV4(name, user string, inputWorkflow *commonmodels.WorkflowV4,
 	if err := LintWorkflowV4(inputWorkflow, logger); err != nil {
 		return err
 	}
+	if err := instantmessage.EncryptWebhookSecrets(inputWorkflow.NotifyCtls, workflow.NotifyCtls); err != nil {
+		logger.Errorf("Failed to encrypt webhook secrets, error: %s", err)
+		return e.ErrUpsertWorkflow.AddErr(err)
+	}
 
 	inputWorkflow.UpdatedBy = user
 	inputWorkflow.UpdateTime = time.Now().Unix()
@@ -360,6 +369,7 @@ func getRecentTaskV4Info(workflow *Workflow, tasks []*commonmodels.WorkflowTask)
 }
 
 func ensureWorkflowV4Resp(encryptedKey string, workflow *commonmodels.WorkflowV4, logger *zap.SugaredLogger) error {
+	instantmessage.MaskWebhookSecrets(workflow.NotifyCtls)
 	for _, stage := range workflow.Stages {
 		for _, job := range stage.Jobs {
 			if job.JobType == config.JobZadigBuild {
@@ -465,6 +475,17 @@ func LintWorkflowV4(workflow *commonmodels.WorkflowV4, logger *zap.SugaredLogger
 			return e.ErrUpsertWorkflow.AddDesc("common workflow only support k8s and helm project")
 		}
 	}
+	for _, notify := range workflow.NotifyCtls {
+		if err := instantmessage.ValidateNotificationTemplate(notify); err != nil {
+			logger.Errorf("lint notification failed: %v", err)
+			return e.ErrUpsertWorkflow.AddErr(err)
+		}
+	}
+	if err := lintJiraIntegration(workflow.JiraIntegration); err != nil {
+		logger.Errorf("lint jira integration failed: %v", err)
+		return e.ErrUpsertWorkflow.AddErr(err)
+	}
+
 	stageNameMap := make(map[string]bool)
 	jobNameMap := make(map[string]string)
 
@@ -504,6 +525,30 @@ func LintWorkflowV4(workflow *commonmodels.WorkflowV4, logger *zap.SugaredLogger
 	return nil
 }
 
+func lintJiraIntegration(integration *commonmodels.JiraIntegration) error {
+	if integration == nil {
+		return nil
+	}
+	if issue := integration.FailureIssue; issue != nil && issue.Enabled {
+		if issue.ProjectKey == "" || issue.IssueType == "" {
+			return fmt.Errorf("jira project and issue type are required to create issues on failure")
+		}
+		for _, branch := range issue.ProtectedBranches {
+			if _, err := regexp.Compile(branch); err != nil {
+				return fmt.Errorf("invalid protected branch %s: %v", branch, err)
+			}
+		}
+	}
+	if link := integration.DeploymentLink; link != nil && link.Enabled {
+		switch link.Mode {
+		case "", config.JiraLinkModeComment, config.JiraLinkModeRemoteLink:
+		default:
+			return fmt.Errorf("invalid jira deployment link mode: %s", link.Mode)
+		}
+	}
+	return nil
+}
+
 func lintApprovals(approval *commonmodels.Approval) error {
 	if approval == nil {
 		return nil
@@ -526,6 +571,26 @@ func lintApprovals(approval *commonmodels.Approval) error {
 		if len(approval.LarkApproval.ApproveUsers) == 0 {
 			return errors.New("num of approver is 0")
 		}
+	case config.DingTalkApproval:
+		if approval.DingTalkApproval == nil {
+			return errors.New("approval not found")
+		}
+		if approval.DingTalkApproval.ApprovalID == "" {
+			return errors.New("dingtalk app not selected")
+		}
+		if len(approval.DingTalkApproval.ApproveUsers) == 0 {
+			return errors.New("num of approver is 0")
+		}
+	case config.WeComApproval:
+		if approval.WeComApproval == nil {
+			return errors.New("approval not found")
+		}
+		if approval.WeComApproval.ApprovalID == "" {
+			return errors.New("wecom app not selected")
+		}
+		if len(approval.WeComApproval.ApproveUsers) == 0 {
+			return errors.New("num of approver is 0")
+		}
 	default:
 		return errors.New("invalid approval type")
 	}
@@ -67,6 +67,17 @@ func (c *Client) TriggerCleanProducts(log *zap.SugaredLogger) error {
 	return err
 }
 
+// TriggerCleanPreviewEnvs deletes the idle preview environments of pull requests
+func (c *Client) TriggerCleanPreviewEnvs(log *zap.SugaredLogger) error {
+	url := fmt.Sprintf("%s/environment/cron/cleanpreviewenv", c.APIBase)
+	log.Info("start clean preview environments..")
+	err := c.sendRequest(url)
+	if err != nil {
+		log.Errorf("trigger clean preview environments error :%v", err)
+	}
+	return err
+}
+
 // TriggerCleanCIResources trigger clean CollaborationInstance Resources
 func (c *Client) TriggerCleanCIResources(log *zap.SugaredLogger) error {
 	url := fmt.Sprintf("%s/collaboration/collaborations/cron/clean", c.APIBase)
@@ -17,12 +17,7 @@ limitations under the License.
 package scheduler
 
 import (
-	"crypto/tls"
-	"errors"
 	"fmt"
-	"io/ioutil"
-	"net"
-	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -34,6 +29,7 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/cron/core/service"
 	"github.com/koderover/zadig/pkg/microservice/cron/core/service/client"
 	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/probe"
 	"github.com/koderover/zadig/pkg/types"
 )
 
@@ -207,12 +203,12 @@ func runProbe(healthCheck *service.PmHealthCheck, address string, log *zap.Sugar
 	timeout := time.Duration(healthCheck.TimeOut) * time.Second
 	switch healthCheck.Protocol {
 	case setting.ProtocolHTTP, setting.ProtocolHTTPS:
-		if message, err = doHTTPProbe(healthCheck.Protocol, address, healthCheck.Path, healthCheck.Port, []*types.HTTPHeader{}, timeout, "", log); err != nil {
+		if message, err = probe.DoHTTPProbe(healthCheck.Protocol, address, healthCheck.Path, healthCheck.Port, []*types.HTTPHeader{}, timeout, "", log); err != nil {
 			log.Errorf("doHttpProbe err:%v", err)
 			return Failure, err
 		}
 	case setting.ProtocolTCP:
-		if message, err = doTCPProbe(address, healthCheck.Port, timeout, log); err != nil {
+		if message, err = probe.DoTCPProbe(address, healthCheck.Port, timeout, log); err != nil {
 			log.Errorf("doTCPProbe err:%v", err)
 			return Failure, err
 		}
@@ -220,114 +216,6 @@ func runProbe(healthCheck *service.PmHealthCheck, address string, log *zap.Sugar
 
 	return message, nil
 }
-func doTCPProbe(addr string, port int, timeout time.Duration, log *zap.SugaredLogger) (string, error) {
-	var (
-		conn net.Conn
-		err  error
-	)
-	if port == 0 {
-		conn, err = net.DialTimeout("tcp", addr, timeout)
-	} else {
-		conn, err = net.DialTimeout("tcp", fmt.Sprintf("%s:%d", addr, port), timeout)
-	}
-
-	if err != nil {
-		return Failure, err
-	}
-	err = conn.Close()
-	if err != nil {
-		log.Errorf("Unexpected error closing TCP socket: %v (%#v)", err, err)
-		return Failure, err
-	}
-	return Success, nil
-}
-
-func doHTTPProbe(protocol, address, path string, port int, headerList []*types.HTTPHeader, timeout time.Duration, responseSuccessFlag string, log *zap.SugaredLogger) (string, error) {
-	tlsConfig := &tls.Config{InsecureSkipVerify: true}
-	transport := &http.Transport{
-		TLSClientConfig:   tlsConfig,
-		DisableKeepAlives: true,
-		Proxy:             http.ProxyURL(nil),
-	}
-	client := &http.Client{
-		Timeout:       timeout,
-		Transport:     transport,
-		CheckRedirect: redirectChecker(false),
-	}
-	url, err := formatURL(protocol, address, path, port)
-	if err != nil {
-		return Failure, err
-	}
-	headers := buildHeader(headerList)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return Failure, err
-	}
-	req.Header = headers
-	req.Host = headers.Get("Host")
-
-	res, err := client.Do(req)
-	if err != nil {
-		return Failure, err
-	}
-	defer res.Body.Close()
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return Failure, err
-	}
-
-	if res.StatusCode >= http.StatusOK && res.StatusCode < http.StatusBadRequest {
-		if responseSuccessFlag != "" && !strings.Contains(string(body), responseSuccessFlag) {
-			return Failure, fmt.Errorf("HTTP probe failed with response success flag: %s", responseSuccessFlag)
-		}
-		log.Infof("Probe succeeded for %s, Response: %v", url, *res)
-		return Success, nil
-	}
-	log.Warnf("Probe failed for %s, response body: %v", url, string(body))
-	return Failure, fmt.Errorf("HTTP probe failed with statuscode: %d", res.StatusCode)
-}
-
-func redirectChecker(followNonLocalRedirects bool) func(*http.Request, []*http.Request) error {
-	if followNonLocalRedirects {
-		return nil // Use the default http client checker.
-	}
-
-	return func(req *http.Request, via []*http.Request) error {
-		if req.URL.Hostname() != via[0].URL.Hostname() {
-			return http.ErrUseLastResponse
-		}
-		// Default behavior: stop after 10 redirects.
-		if len(via) >= 10 {
-			return errors.New("stopped after 10 redirects")
-		}
-		return nil
-	}
-}
-
-func formatURL(protocol, address, path string, port int) (string, error) {
-	if len(strings.Split(address, ":")) > 2 {
-		return "", fmt.Errorf("illegal address")
-	}
-	if path == "" && port == 0 {
-		return fmt.Sprintf("%s://%s", protocol, address), nil
-	}
-
-	path = strings.TrimPrefix(path, "/")
-
-	if port == 0 {
-		return fmt.Sprintf("%s://%s/%s", protocol, address, path), nil
-	}
-	return fmt.Sprintf("%s://%s:%d/%s", protocol, address, port, path), nil
-}
-
-func buildHeader(headerList []*types.HTTPHeader) http.Header {
-	headers := make(http.Header)
-	for _, header := range headerList {
-		headers[header.Name] = append(headers[header.Name], header.Value)
-	}
-	return headers
-}
-
 func buildEnvNameKey(productRevision *service.ProductRevision) string {
 	return "helm-values-sync-" + productRevision.ProductName + "-" + productRevision.EnvName
 }
@@ -508,9 +396,9 @@ func (c *CronClient) comparePMProductRevision(currentProductRevisions []*service
 
 const (
 	// Success Result
-	Success string = "success"
+	Success = probe.Success
 	// Failure Result
-	Failure string = "failure"
+	Failure = probe.Failure
 	// maxProbeRetries
 	MaxProbeRetries = 3
 )
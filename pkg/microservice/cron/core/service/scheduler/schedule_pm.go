@@ -21,6 +21,7 @@ import (
 
 	"github.com/koderover/zadig/pkg/microservice/cron/core/service"
 	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/tool/probe"
 	"github.com/koderover/zadig/pkg/types"
 	"go.uber.org/zap"
 )
@@ -50,12 +51,12 @@ func (c *CronClient) UpdatePmHostStatusScheduler(log *zap.SugaredLogger) {
 				if hostPm.Probe.HttpProbe == nil {
 					break
 				}
-				msg, err = doHTTPProbe(string(hostPm.Probe.ProbeScheme), hostPm.IP, hostPm.Probe.HttpProbe.Path, hostPm.Probe.HttpProbe.Port, hostPm.Probe.HttpProbe.HTTPHeaders, time.Duration(hostPm.Probe.HttpProbe.TimeOutSecond)*time.Second, hostPm.Probe.HttpProbe.ResponseSuccessFlag, log)
+				msg, err = probe.DoHTTPProbe(string(hostPm.Probe.ProbeScheme), hostPm.IP, hostPm.Probe.HttpProbe.Path, hostPm.Probe.HttpProbe.Port, hostPm.Probe.HttpProbe.HTTPHeaders, time.Duration(hostPm.Probe.HttpProbe.TimeOutSecond)*time.Second, hostPm.Probe.HttpProbe.ResponseSuccessFlag, log)
 				if err != nil {
 					log.Warnf("doHttpProbe err:%s", err)
 				}
 			case setting.ProtocolTCP:
-				msg, err = doTCPProbe(hostPm.IP, int(hostPm.Port), 3*time.Second, log)
+				msg, err = probe.DoTCPProbe(hostPm.IP, int(hostPm.Port), 3*time.Second, log)
 				if err != nil {
 					log.Warnf("doTCPProbe TCP %s:%d err: %s)", hostPm.IP, hostPm.Port, err)
 				}
@@ -117,6 +117,8 @@ const (
 
 	CleanCIResourcesScheduler = "CleanCIResourcesScheduler"
 
+	CleanPreviewEnvScheduler = "CleanPreviewEnvScheduler"
+
 	InitStatScheduler = "InitStatScheduler"
 
 	InitOperationStatScheduler = "InitOperationStatScheduler"
@@ -194,6 +196,8 @@ func (c *CronClient) Init() {
 	c.InitCleanProductScheduler()
 	// clean collaboration instance resource every 5 minutes
 	c.InitCleanCIResourcesScheduler()
+	// clean idle preview environments of pull requests every 10 minutes
+	c.InitCleanPreviewEnvScheduler()
 	// 定时初始化构建数据
 	c.InitBuildStatScheduler()
 	// 定时器初始化话运营统计数据
@@ -237,6 +241,15 @@ func (c *CronClient) InitCleanCIResourcesScheduler() {
 	c.Schedulers[CleanCIResourcesScheduler].Start()
 }
 
+func (c *CronClient) InitCleanPreviewEnvScheduler() {
+
+	c.Schedulers[CleanPreviewEnvScheduler] = gocron.NewScheduler()
+
+	c.Schedulers[CleanPreviewEnvScheduler].Every(10).Minutes().Do(c.AslanCli.TriggerCleanPreviewEnvs, c.log)
+
+	c.Schedulers[CleanPreviewEnvScheduler].Start()
+}
+
 func (c *CronClient) InitJobScheduler() {
 
 	c.Schedulers[UpsertWorkflowScheduler] = gocron.NewScheduler()
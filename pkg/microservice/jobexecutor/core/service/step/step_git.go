@@ -34,6 +34,8 @@ import (
 
 	"github.com/koderover/zadig/pkg/microservice/jobexecutor/config"
 	c "github.com/koderover/zadig/pkg/microservice/jobexecutor/core/service/cmd"
+	"github.com/koderover/zadig/pkg/tool/bitbucket"
+	"github.com/koderover/zadig/pkg/tool/gitea"
 	"github.com/koderover/zadig/pkg/tool/log"
 	"github.com/koderover/zadig/pkg/types"
 	"github.com/koderover/zadig/pkg/types/step"
@@ -254,6 +256,10 @@ func (s *GitStep) buildGitCommands(repo *types.Repository, hostNames sets.String
 		})
 	} else if repo.Source == types.ProviderGitee || repo.Source == types.ProviderGiteeEE {
 		cmds = append(cmds, &c.Command{Cmd: c.RemoteAdd(repo.RemoteName, HTTPSCloneURL(repo.Source, repo.OauthToken, repo.RepoOwner, repo.RepoName, repo.Address)), DisableTrace: true})
+	} else if repo.Source == types.ProviderBitbucket {
+		cmds = append(cmds, &c.Command{Cmd: c.RemoteAdd(repo.RemoteName, bitbucket.CloneURL(repo.Address, repo.Username, repo.OauthToken, owner, repo.RepoName)), DisableTrace: true})
+	} else if repo.Source == types.ProviderGitea {
+		cmds = append(cmds, &c.Command{Cmd: c.RemoteAdd(repo.RemoteName, gitea.CloneURL(repo.Address, repo.OauthToken, owner, repo.RepoName)), DisableTrace: true})
 	} else if repo.Source == types.ProviderOther {
 		if repo.AuthType == types.SSHAuthType {
 			host := getHost(repo.Address)
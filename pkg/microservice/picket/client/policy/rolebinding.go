@@ -28,6 +28,7 @@ import (
 type RoleBinding struct {
 	Name   string               `json:"name"`
 	UID    string               `json:"uid"`
+	GID    string               `json:"gid,omitempty"`
 	Role   string               `json:"role"`
 	Preset bool                 `json:"preset"`
 	Type   setting.ResourceType `json:"type"`
@@ -36,6 +37,7 @@ type RoleBinding struct {
 type PolicyBinding struct {
 	Name   string               `json:"name"`
 	UID    string               `json:"uid"`
+	GID    string               `json:"gid,omitempty"`
 	Policy string               `json:"policy"`
 	Preset bool                 `json:"preset"`
 	Type   setting.ResourceType `json:"type"`
@@ -56,6 +56,8 @@ type Binding struct {
 	IdentityType string           `json:"identity_type"`
 	Phone        string           `json:"phone"`
 	Uid          string           `json:"uid"`
+	// GID is set if the bindings are for a user group, UserName is the name of the group then
+	GID string `json:"gid,omitempty"`
 }
 
 func ListBindings(header http.Header, qs url.Values, logger *zap.SugaredLogger) ([]*Binding, error) {
@@ -67,7 +69,12 @@ func ListBindings(header http.Header, qs url.Values, logger *zap.SugaredLogger)
 
 	uidSets := sets.String{}
 	uidToRoleBinding := make(map[string][]*roleBinding)
+	gidToRoleBinding := make(map[string][]*roleBinding)
 	for _, rb := range rbs {
+		if rb.GID != "" {
+			gidToRoleBinding[rb.GID] = append(gidToRoleBinding[rb.GID], &roleBinding{RoleBinding: rb})
+			continue
+		}
 		if rb.UID != ALLUsers {
 			uidSets.Insert(rb.UID)
 		}
@@ -81,12 +88,22 @@ func ListBindings(header http.Header, qs url.Values, logger *zap.SugaredLogger)
 	}
 
 	uidToPolicyBindings := make(map[string][]*policyBinding)
+	gidToPolicyBindings := make(map[string][]*policyBinding)
 	for _, pb := range pbs {
+		if pb.GID != "" {
+			gidToPolicyBindings[pb.GID] = append(gidToPolicyBindings[pb.GID], &policyBinding{PolicyBinding: pb})
+			continue
+		}
 		if pb.UID != ALLUsers {
 			uidSets.Insert(pb.UID)
 		}
 		uidToPolicyBindings[pb.UID] = append(uidToPolicyBindings[pb.UID], &policyBinding{PolicyBinding: pb})
 	}
+	groupBindings, err := listGroupBindings(gidToRoleBinding, gidToPolicyBindings)
+	if err != nil {
+		logger.Errorf("Failed to list group bindings, err: %s", err)
+		return nil, err
+	}
 	if uidSets.Len() == 0 {
 		if len(uidToRoleBinding[ALLUsers]) != 0 || len(uidToPolicyBindings[ALLUsers]) != 0 {
 			// add all 'ALLUsers' roles
@@ -97,9 +114,9 @@ func ListBindings(header http.Header, qs url.Values, logger *zap.SugaredLogger)
 				Email:    "",
 				Uid:      "*",
 			}
-			return []*Binding{AllUserBinding}, nil
+			return append(groupBindings, AllUserBinding), nil
 		}
-		return []*Binding{}, nil
+		return append([]*Binding{}, groupBindings...), nil
 	}
 	users, err := user.New().ListUsers(&user.SearchArgs{UIDs: uidSets.List()})
 	if err != nil {
@@ -107,7 +124,7 @@ func ListBindings(header http.Header, qs url.Values, logger *zap.SugaredLogger)
 		return nil, err
 	}
 
-	var res []*Binding
+	res := groupBindings
 
 	for _, u := range users {
 		var policyBindings []*policyBinding
@@ -163,3 +180,37 @@ func ListBindings(header http.Header, qs url.Values, logger *zap.SugaredLogger)
 
 	return res, nil
 }
+
+func listGroupBindings(gidToRoleBinding map[string][]*roleBinding, gidToPolicyBindings map[string][]*policyBinding) ([]*Binding, error) {
+	if len(gidToRoleBinding) == 0 && len(gidToPolicyBindings) == 0 {
+		return nil, nil
+	}
+	groups, err := user.New().ListUserGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*Binding
+	for _, g := range groups {
+		rbs, pbs := gidToRoleBinding[g.GroupID], gidToPolicyBindings[g.GroupID]
+		if len(rbs) == 0 && len(pbs) == 0 {
+			continue
+		}
+		for _, rb := range rbs {
+			rb.Username = g.Name
+			rb.IdentityType = g.IdentityType
+		}
+		for _, pb := range pbs {
+			pb.Username = g.Name
+			pb.IdentityType = g.IdentityType
+		}
+		res = append(res, &Binding{
+			Roles:        rbs,
+			Policies:     pbs,
+			UserName:     g.Name,
+			IdentityType: g.IdentityType,
+			GID:          g.GroupID,
+		})
+	}
+	return res, nil
+}
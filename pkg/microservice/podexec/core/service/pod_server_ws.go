@@ -20,6 +20,11 @@ import (
 	"fmt"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	commonrepo "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/terminalrecord"
+	"github.com/koderover/zadig/pkg/setting"
 	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
 	e "github.com/koderover/zadig/pkg/tool/errors"
 	"github.com/koderover/zadig/pkg/tool/log"
@@ -33,6 +38,8 @@ func ServeWs(c *gin.Context) {
 	podName := c.Param("podName")
 	containerName := c.Param("containerName")
 	clusterID := c.Query("clusterId")
+	productName := c.Param("productName")
+	envName := c.Param("envName")
 
 	if namespace == "" || podName == "" || containerName == "" {
 		ctx.Err = e.ErrInvalidParam.AddDesc("namespace,podName,containerName can't be empty,please check!")
@@ -40,6 +47,26 @@ func ServeWs(c *gin.Context) {
 	}
 	log.Infof("exec containerName: %s, pod: %s, namespace: %s", containerName, podName, namespace)
 
+	env, err := commonrepo.NewProductColl().Find(&commonrepo.ProductFindOptions{Name: productName, EnvName: envName})
+	if err != nil {
+		ctx.Err = e.ErrInvalidParam.AddDesc(fmt.Sprintf("failed to find env %s of project %s: %v", envName, productName, err))
+		return
+	}
+	if err := terminalrecord.CheckExecPermission(ctx.UserID, env); err != nil {
+		if err == terminalrecord.ErrProductionExecForbidden {
+			ctx.Err = e.ErrForbidden.AddErr(err)
+		} else {
+			ctx.Err = e.ErrInternalError.AddErr(err)
+		}
+		return
+	}
+	// the permission is checked against the env, so the pod must be looked up in the namespace and cluster of the env
+	if err := checkExecTarget(env, namespace, clusterID); err != nil {
+		ctx.Err = e.ErrForbidden.AddErr(err)
+		return
+	}
+	namespace, clusterID = env.Namespace, env.ClusterID
+
 	pty, err := NewTerminalSession(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Errorf("get pty failed: %v", err)
@@ -73,6 +100,30 @@ func ServeWs(c *gin.Context) {
 		return
 	}
 
+	recording, err := terminalrecord.Start(&models.TerminalSession{
+		Type:          models.TerminalSessionTypePod,
+		UserName:      ctx.UserName,
+		UID:           ctx.UserID,
+		ProjectName:   productName,
+		EnvName:       envName,
+		Production:    env.Production,
+		ClusterID:     clusterID,
+		Namespace:     namespace,
+		PodName:       podName,
+		ContainerName: containerName,
+	}, 80, 24)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to start session recording! err: %v", err)
+		log.Errorf(msg)
+		_, _ = pty.Write([]byte(msg))
+		pty.Done()
+
+		ctx.Err = e.ErrInternalError.AddDesc(msg)
+		return
+	}
+	defer recording.Close()
+	pty.SetRecorder(recording)
+
 	err = ExecPod(kubeCli, cfg, []string{"/bin/sh"}, pty, namespace, podName, containerName)
 	if err != nil {
 		msg := fmt.Sprintf("Exec to pod error! err: %v", err)
@@ -84,3 +135,22 @@ func ServeWs(c *gin.Context) {
 		return
 	}
 }
+
+// checkExecTarget rejects the requests whose namespace or cluster doesn't match the env of the project
+func checkExecTarget(env *models.Product, namespace, clusterID string) error {
+	if namespace != env.Namespace {
+		return fmt.Errorf("namespace %s doesn't belong to env %s of project %s", namespace, env.EnvName, env.ProductName)
+	}
+	if normalizeClusterID(clusterID) != normalizeClusterID(env.ClusterID) {
+		return fmt.Errorf("cluster %s doesn't belong to env %s of project %s", clusterID, env.EnvName, env.ProductName)
+	}
+	return nil
+}
+
+// normalizeClusterID treats the empty cluster id as the local cluster
+func normalizeClusterID(clusterID string) string {
+	if clusterID == "" {
+		return setting.LocalClusterID
+	}
+	return clusterID
+}
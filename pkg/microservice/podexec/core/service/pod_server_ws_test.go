@@ -0,0 +1,53 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"testing"
+
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+func TestCheckExecTarget(t *testing.T) {
+	env := &models.Product{ProductName: "proj", EnvName: "prod", Namespace: "proj-env-prod", ClusterID: "cluster-a"}
+	localEnv := &models.Product{ProductName: "proj", EnvName: "dev", Namespace: "proj-env-dev"}
+
+	tests := []struct {
+		name      string
+		env       *models.Product
+		namespace string
+		clusterID string
+		wantErr   bool
+	}{
+		{name: "matched", env: env, namespace: "proj-env-prod", clusterID: "cluster-a"},
+		{name: "namespace of another env", env: env, namespace: "kube-system", clusterID: "cluster-a", wantErr: true},
+		{name: "another cluster", env: env, namespace: "proj-env-prod", clusterID: "cluster-b", wantErr: true},
+		{name: "cluster omitted for a remote env", env: env, namespace: "proj-env-prod", wantErr: true},
+		{name: "local cluster omitted", env: localEnv, namespace: "proj-env-dev"},
+		{name: "local cluster id", env: localEnv, namespace: "proj-env-dev", clusterID: setting.LocalClusterID},
+		{name: "remote cluster for a local env", env: localEnv, namespace: "proj-env-dev", clusterID: "cluster-a", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkExecTarget(tt.env, tt.namespace, tt.clusterID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkExecTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
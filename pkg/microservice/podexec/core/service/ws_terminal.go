@@ -35,6 +35,7 @@ import (
 	conf "github.com/koderover/zadig/pkg/microservice/podexec/config"
 	"github.com/koderover/zadig/pkg/shared/kube/client"
 	"github.com/koderover/zadig/pkg/shared/kube/wrapper"
+	"github.com/koderover/zadig/pkg/tool/asciicast"
 	"github.com/koderover/zadig/pkg/tool/log"
 )
 
@@ -71,6 +72,7 @@ type TerminalSession struct {
 	wsConn   *websocket.Conn
 	sizeChan chan remotecommand.TerminalSize
 	doneChan chan struct{}
+	recorder asciicast.Recorder
 }
 
 func NewTerminalSession(w http.ResponseWriter, r *http.Request, responseHeader http.Header) (*TerminalSession, error) {
@@ -86,6 +88,11 @@ func NewTerminalSession(w http.ResponseWriter, r *http.Request, responseHeader h
 	return session, nil
 }
 
+// SetRecorder records the traffic of the session with r
+func (t *TerminalSession) SetRecorder(r asciicast.Recorder) {
+	t.recorder = r
+}
+
 // Done done
 func (t *TerminalSession) Done() chan struct{} {
 	return t.doneChan
@@ -115,8 +122,14 @@ func (t *TerminalSession) Read(p []byte) (int, error) {
 	}
 	switch msg.Operation {
 	case "stdin":
+		if t.recorder != nil {
+			t.recorder.Input([]byte(msg.Data))
+		}
 		return copy(p, msg.Data), nil
 	case "resize":
+		if t.recorder != nil {
+			t.recorder.Resize(int(msg.Cols), int(msg.Rows))
+		}
 		t.sizeChan <- remotecommand.TerminalSize{Width: msg.Cols, Height: msg.Rows}
 		return 0, nil
 	default:
@@ -139,6 +152,9 @@ func (t *TerminalSession) Write(p []byte) (int, error) {
 		log.Errorf("write message err: %v", err)
 		return 0, err
 	}
+	if t.recorder != nil {
+		t.recorder.Output(p)
+	}
 	return len(p), nil
 }
 
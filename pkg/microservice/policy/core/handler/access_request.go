@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handler
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/pkg/microservice/policy/core/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/policy/core/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/policy/core/service"
+	"github.com/koderover/zadig/pkg/setting"
+	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+func ListAccessRequests(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	opt := &mongodb.ListAccessRequestOption{
+		Namespace: c.Query("namespace"),
+		Status:    c.Query("status"),
+	}
+	if c.Query("mine") == "true" {
+		opt.UID = ctx.UserID
+	}
+	ctx.Resp, ctx.Err = service.ListAccessRequests(opt, ctx.UserID, ctx.Logger)
+}
+
+func CreateAccessRequest(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(service.AccessRequestArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	bs, _ := json.Marshal(args)
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, args.Namespace, setting.OperationScenePolicy, "申请", "临时权限", args.Role+args.Policy, string(bs), ctx.Logger)
+
+	ctx.Resp, ctx.Err = service.CreateAccessRequest(args, ctx.UserID, ctx.UserName, ctx.Logger)
+}
+
+func ReviewAccessRequest(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(service.ReviewAccessRequestArgs)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+
+	bs, _ := json.Marshal(args)
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, "", setting.OperationScenePolicy, "审批", "临时权限", c.Param("id"), string(bs), ctx.Logger)
+
+	ctx.Err = service.ReviewAccessRequest(c.Param("id"), args, ctx.UserID, ctx.UserName, ctx.Logger)
+}
+
+func RevokeAccessRequest(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, "", setting.OperationScenePolicy, "撤销", "临时权限", c.Param("id"), "", ctx.Logger)
+
+	ctx.Err = service.RevokeAccessRequest(c.Param("id"), ctx.UserID, ctx.Logger)
+}
+
+func GetAccessRequestConfig(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	ctx.Resp, ctx.Err = service.GetAccessRequestConfig(ctx.Logger)
+}
+
+func UpdateAccessRequestConfig(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	args := new(models.AccessRequestConfig)
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = e.ErrInvalidParam.AddErr(err)
+		return
+	}
+	args.UpdateBy = ctx.UserName
+	internalhandler.InsertOperationLog(c, ctx.UserName, "", "更新", "系统配置-临时权限", "", "", ctx.Logger)
+
+	ctx.Err = service.UpdateAccessRequestConfig(args, ctx.Logger)
+}
@@ -17,9 +17,13 @@ limitations under the License.
 package handler
 
 import (
+	"encoding/json"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 
 	"github.com/koderover/zadig/pkg/microservice/policy/core/service"
+	"github.com/koderover/zadig/pkg/setting"
 	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
 	e "github.com/koderover/zadig/pkg/tool/errors"
 )
@@ -46,6 +50,13 @@ func CreatePolicies(c *gin.Context) {
 		ctx.Err = e.ErrInvalidParam.AddDesc("projectName is empty")
 		return
 	}
+	names := make([]string, 0, len(args.Policies))
+	for _, policy := range args.Policies {
+		names = append(names, policy.Name)
+	}
+	bs, _ := json.Marshal(args)
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectName, setting.OperationScenePolicy, "新增", "权限策略", strings.Join(names, ","), string(bs), ctx.Logger)
+
 	ctx.Err = service.CreatePolicies(projectName, args.Policies, ctx.Logger)
 }
 
@@ -67,6 +78,9 @@ func UpdatePolicy(c *gin.Context) {
 	name := c.Param("name")
 	args.Name = name
 
+	bs, _ := json.Marshal(args)
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectName, setting.OperationScenePolicy, "更新", "权限策略", args.Name, string(bs), ctx.Logger)
+
 	ctx.Err = service.UpdatePolicy(projectName, args, ctx.Logger)
 }
 
@@ -87,6 +101,9 @@ func UpdateOrCreatePolicy(c *gin.Context) {
 	}
 	args.Name = c.Param("name")
 
+	bs, _ := json.Marshal(args)
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectName, setting.OperationScenePolicy, "更新", "权限策略", args.Name, string(bs), ctx.Logger)
+
 	ctx.Err = service.UpdateOrCreatePolicy(projectName, args, ctx.Logger)
 }
 
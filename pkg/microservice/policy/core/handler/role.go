@@ -17,6 +17,8 @@ limitations under the License.
 package handler
 
 import (
+	"encoding/json"
+
 	"github.com/gin-gonic/gin"
 
 	"github.com/koderover/zadig/pkg/microservice/policy/core/service"
@@ -45,6 +47,9 @@ func CreateRole(c *gin.Context) {
 		return
 	}
 
+	bs, _ := json.Marshal(args)
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectName, setting.OperationScenePolicy, "新增", "角色", args.Name, string(bs), ctx.Logger)
+
 	ctx.Err = service.CreateRole(projectName, args, ctx.Logger)
 }
 
@@ -66,6 +71,9 @@ func UpdateRole(c *gin.Context) {
 	name := c.Param("name")
 	args.Name = name
 
+	bs, _ := json.Marshal(args)
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectName, setting.OperationScenePolicy, "更新", "角色", args.Name, string(bs), ctx.Logger)
+
 	ctx.Err = service.UpdateRole(projectName, args, ctx.Logger)
 }
 
@@ -86,6 +94,9 @@ func UpdateOrCreateRole(c *gin.Context) {
 	}
 	args.Name = c.Param("name")
 
+	bs, _ := json.Marshal(args)
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, projectName, setting.OperationScenePolicy, "更新", "角色", args.Name, string(bs), ctx.Logger)
+
 	ctx.Err = service.UpdateOrCreateRole(projectName, args, ctx.Logger)
 }
 
@@ -221,6 +232,9 @@ func CreateSystemRole(c *gin.Context) {
 		ctx.Err = err
 		return
 	}
+	bs, _ := json.Marshal(args)
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, "", setting.OperationScenePolicy, "新增", "系统角色", args.Name, string(bs), ctx.Logger)
+
 	ctx.Err = service.CreateRole(service.SystemScope, args, ctx.Logger)
 }
 
@@ -235,6 +249,9 @@ func UpdateOrCreateSystemRole(c *gin.Context) {
 	}
 	name := c.Param("name")
 	args.Name = name
+	bs, _ := json.Marshal(args)
+	internalhandler.InsertDetailedOperationLog(c, ctx.UserName, "", setting.OperationScenePolicy, "更新", "系统角色", args.Name, string(bs), ctx.Logger)
+
 	ctx.Err = service.UpdateOrCreateRole(service.SystemScope, args, ctx.Logger)
 }
 
@@ -83,6 +83,16 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		policyBindings.POST("/bulk-delete", DeletePolicyBindings)
 	}
 
+	accessRequests := router.Group("access-requests")
+	{
+		accessRequests.GET("", ListAccessRequests)
+		accessRequests.POST("", CreateAccessRequest)
+		accessRequests.POST("/:id/review", ReviewAccessRequest)
+		accessRequests.POST("/:id/revoke", RevokeAccessRequest)
+		accessRequests.GET("/config", GetAccessRequestConfig)
+		accessRequests.PUT("/config", UpdateAccessRequestConfig)
+	}
+
 	systemRoleBindings := router.Group("system-rolebindings")
 	{
 		systemRoleBindings.POST("", CreateSystemRoleBinding)
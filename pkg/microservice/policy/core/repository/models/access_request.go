@@ -0,0 +1,73 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	aslanmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
+)
+
+const (
+	AccessRequestPending  = "pending"
+	AccessRequestApproved = "approved"
+	AccessRequestRejected = "rejected"
+	AccessRequestExpired  = "expired"
+	AccessRequestRevoked  = "revoked"
+)
+
+// AccessRequest asks for a role or a policy in a namespace for a limited duration. Once approved, a binding
+// which expires at ExpireAt is created and it is revoked by the access sweeper afterwards.
+type AccessRequest struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"  json:"id,omitempty"`
+	Namespace string             `bson:"namespace"      json:"namespace"`
+	UID       string             `bson:"uid"            json:"uid"`
+	UserName  string             `bson:"user_name"      json:"user_name"`
+	// exactly one of Role and Policy is set
+	Role   string `bson:"role,omitempty"   json:"role,omitempty"`
+	Policy string `bson:"policy,omitempty" json:"policy,omitempty"`
+	Preset bool   `bson:"preset"           json:"preset"`
+	Reason string `bson:"reason"           json:"reason"`
+	// Duration is in minutes
+	Duration      int64  `bson:"duration"       json:"duration"`
+	Status        string `bson:"status"         json:"status"`
+	ReviewedBy    string `bson:"reviewed_by"    json:"reviewed_by"`
+	ReviewedUID   string `bson:"reviewed_uid"   json:"reviewed_uid"`
+	ReviewComment string `bson:"review_comment" json:"review_comment"`
+	BindingName   string `bson:"binding_name"   json:"binding_name"`
+	CreateTime    int64  `bson:"create_time"    json:"create_time"`
+	ReviewTime    int64  `bson:"review_time"    json:"review_time"`
+	ExpireAt      int64  `bson:"expire_at"      json:"expire_at"`
+}
+
+func (AccessRequest) TableName() string {
+	return "access_request"
+}
+
+// AccessRequestConfig is the system wide configuration of access requests.
+type AccessRequestConfig struct {
+	// MaxDuration is the longest duration in minutes that can be requested
+	MaxDuration int64 `bson:"max_duration" json:"max_duration"`
+	// NotifyCtls are the IM webhooks that approvers are notified through
+	NotifyCtls []*aslanmodels.NotifyCtl `bson:"notify_ctls"  json:"notify_ctls"`
+	UpdateBy   string                   `bson:"update_by"    json:"update_by"`
+	UpdateTime int64                    `bson:"update_time"  json:"update_time"`
+}
+
+func (AccessRequestConfig) TableName() string {
+	return "access_request_config"
+}
@@ -33,6 +33,9 @@ type PolicyBinding struct {
 	// PolicyRef can reference a namespaced or cluster scoped Policy.
 	PolicyRef *PolicyRef           `bson:"policy_ref"  json:"policy_ref"`
 	Type      setting.ResourceType `bson:"type"        json:"type"`
+
+	// ExpireAt is the unix time after which the binding is revoked, 0 means the binding never expires.
+	ExpireAt int64 `bson:"expire_at,omitempty" json:"expire_at,omitempty"`
 }
 
 // PolicyRef contains information that points to the policy being used
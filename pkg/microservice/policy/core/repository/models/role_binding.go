@@ -28,6 +28,9 @@ type RoleBinding struct {
 
 	// RoleRef can reference a namespaced or cluster scoped Role.
 	RoleRef *RoleRef `bson:"role_ref" json:"roleRef"`
+
+	// ExpireAt is the unix time after which the binding is revoked, 0 means the binding never expires.
+	ExpireAt int64 `bson:"expire_at,omitempty" json:"expire_at,omitempty"`
 }
 
 // RoleRef contains information that points to the role being used
@@ -0,0 +1,215 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/policy/core/repository/models"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+type ListAccessRequestOption struct {
+	Namespace string
+	UID       string
+	Status    string
+}
+
+type AccessRequestColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewAccessRequestColl() *AccessRequestColl {
+	name := models.AccessRequest{}.TableName()
+	return &AccessRequestColl{
+		Collection: mongotool.Database(config.PolicyDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *AccessRequestColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *AccessRequestColl) EnsureIndex(ctx context.Context) error {
+	mod := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				bson.E{Key: "namespace", Value: 1},
+				bson.E{Key: "status", Value: 1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+		{
+			Keys: bson.D{
+				bson.E{Key: "binding_name", Value: 1},
+			},
+			Options: options.Index().SetUnique(false),
+		},
+	}
+
+	_, err := c.Indexes().CreateMany(ctx, mod)
+	return err
+}
+
+func (c *AccessRequestColl) List(opt *ListAccessRequestOption) ([]*models.AccessRequest, error) {
+	query := bson.M{}
+	if opt != nil {
+		if opt.Namespace != "" {
+			query["namespace"] = opt.Namespace
+		}
+		if opt.UID != "" {
+			query["uid"] = opt.UID
+		}
+		if opt.Status != "" {
+			query["status"] = opt.Status
+		}
+	}
+	resp := make([]*models.AccessRequest, 0)
+	ctx := context.Background()
+
+	opts := options.Find().SetSort(bson.D{{"create_time", -1}})
+	cursor, err := c.Collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (c *AccessRequestColl) Find(id string) (*models.AccessRequest, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := new(models.AccessRequest)
+	err = c.FindOne(context.TODO(), bson.M{"_id": oid}).Decode(resp)
+	return resp, err
+}
+
+func (c *AccessRequestColl) Create(args *models.AccessRequest) error {
+	if args == nil {
+		return errors.New("nil access request")
+	}
+
+	args.Status = models.AccessRequestPending
+	args.CreateTime = time.Now().Unix()
+
+	res, err := c.InsertOne(context.TODO(), args)
+	if err != nil {
+		return err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		args.ID = oid
+	}
+	return nil
+}
+
+// Review records the review result of a pending request, mongo.ErrNoDocuments is returned if the request
+// has been reviewed already.
+func (c *AccessRequestColl) Review(args *models.AccessRequest) error {
+	if args == nil {
+		return errors.New("nil access request")
+	}
+
+	query := bson.M{"_id": args.ID, "status": models.AccessRequestPending}
+	change := bson.M{"$set": bson.M{
+		"status":         args.Status,
+		"reviewed_by":    args.ReviewedBy,
+		"reviewed_uid":   args.ReviewedUID,
+		"review_comment": args.ReviewComment,
+		"review_time":    args.ReviewTime,
+		"binding_name":   args.BindingName,
+		"expire_at":      args.ExpireAt,
+	}}
+
+	res, err := c.UpdateOne(context.TODO(), query, change)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Close moves an approved request whose binding is created under bindingName to the given status.
+func (c *AccessRequestColl) Close(namespace, bindingName, status string) error {
+	query := bson.M{"namespace": namespace, "binding_name": bindingName, "status": models.AccessRequestApproved}
+	change := bson.M{"$set": bson.M{"status": status}}
+
+	_, err := c.UpdateMany(context.TODO(), query, change)
+	return err
+}
+
+type AccessRequestConfigColl struct {
+	*mongo.Collection
+
+	coll string
+}
+
+func NewAccessRequestConfigColl() *AccessRequestConfigColl {
+	name := models.AccessRequestConfig{}.TableName()
+	return &AccessRequestConfigColl{
+		Collection: mongotool.Database(config.PolicyDatabase()).Collection(name),
+		coll:       name,
+	}
+}
+
+func (c *AccessRequestConfigColl) GetCollectionName() string {
+	return c.coll
+}
+
+func (c *AccessRequestConfigColl) EnsureIndex(ctx context.Context) error {
+	return nil
+}
+
+// Get returns the config, an empty config is returned if it is not set yet.
+func (c *AccessRequestConfigColl) Get() (*models.AccessRequestConfig, error) {
+	resp := new(models.AccessRequestConfig)
+	err := c.FindOne(context.TODO(), bson.M{}).Decode(resp)
+	if err == mongo.ErrNoDocuments {
+		return resp, nil
+	}
+	return resp, err
+}
+
+func (c *AccessRequestConfigColl) Upsert(args *models.AccessRequestConfig) error {
+	if args == nil {
+		return errors.New("nil access request config")
+	}
+
+	args.UpdateTime = time.Now().Unix()
+	_, err := c.UpdateOne(context.TODO(), bson.M{}, bson.M{"$set": args}, options.Update().SetUpsert(true))
+	return err
+}
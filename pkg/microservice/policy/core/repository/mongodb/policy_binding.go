@@ -138,6 +138,26 @@ func (c *PolicyBindingColl) ListByUser(uid string) ([]*models.PolicyBinding, err
 	return res, nil
 }
 
+// ListExpired lists the bindings which expire at or before the given time.
+func (c *PolicyBindingColl) ListExpired(before int64) ([]*models.PolicyBinding, error) {
+	var res []*models.PolicyBinding
+
+	ctx := context.Background()
+	query := bson.M{"expire_at": bson.M{"$gt": 0, "$lte": before}}
+
+	cursor, err := c.Collection.Find(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
 func (c *PolicyBindingColl) Delete(name string, projectName string) error {
 	query := bson.M{"name": name, "namespace": projectName}
 	_, err := c.DeleteOne(context.TODO(), query)
@@ -186,6 +206,14 @@ func (c *PolicyBindingColl) DeleteByPolicies(policyNames []string, projectName s
 	return err
 }
 
+// DeleteByGroup deletes the bindings whose subject is the given user group
+func (c *PolicyBindingColl) DeleteByGroup(gid string) error {
+	query := bson.M{"subjects.kind": models.GroupKind, "subjects.uid": gid}
+	_, err := c.Collection.DeleteMany(context.TODO(), query)
+
+	return err
+}
+
 func (c *PolicyBindingColl) Create(obj *models.PolicyBinding) error {
 	if obj == nil {
 		return fmt.Errorf("nil object")
@@ -159,6 +159,26 @@ func (c *RoleBindingColl) ListSystemRoleBindingsByUIDs(uids []string) ([]*models
 	return res, nil
 }
 
+// ListExpired lists the bindings which expire at or before the given time.
+func (c *RoleBindingColl) ListExpired(before int64) ([]*models.RoleBinding, error) {
+	var res []*models.RoleBinding
+
+	ctx := context.Background()
+	query := bson.M{"expire_at": bson.M{"$gt": 0, "$lte": before}}
+
+	cursor, err := c.Collection.Find(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
 func (c *RoleBindingColl) Delete(name string, projectName string) error {
 	query := bson.M{"name": name, "namespace": projectName}
 	_, err := c.DeleteOne(context.TODO(), query)
@@ -207,6 +227,14 @@ func (c *RoleBindingColl) DeleteByRoles(roleNames []string, projectName string)
 	return err
 }
 
+// DeleteByGroup deletes the bindings whose subject is the given user group
+func (c *RoleBindingColl) DeleteByGroup(gid string) error {
+	query := bson.M{"subjects.kind": models.GroupKind, "subjects.uid": gid}
+	_, err := c.Collection.DeleteMany(context.TODO(), query)
+
+	return err
+}
+
 func (c *RoleBindingColl) Create(obj *models.RoleBinding) error {
 	if obj == nil {
 		return fmt.Errorf("nil object")
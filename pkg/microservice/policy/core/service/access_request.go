@@ -0,0 +1,433 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/aslan/core/common/service/instantmessage"
+	"github.com/koderover/zadig/pkg/microservice/policy/core/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/policy/core/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/policy/core/service/bundle"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/client/user"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/tool/log"
+	mongotool "github.com/koderover/zadig/pkg/tool/mongo"
+)
+
+// defaultMaxAccessDuration is used if the max duration is not configured, in minutes
+const defaultMaxAccessDuration = 8 * 60
+
+type AccessRequestArgs struct {
+	Namespace string `json:"namespace"`
+	Role      string `json:"role"`
+	Policy    string `json:"policy"`
+	Preset    bool   `json:"preset"`
+	Reason    string `json:"reason"`
+	// Duration is in minutes
+	Duration int64 `json:"duration"`
+}
+
+type ReviewAccessRequestArgs struct {
+	Approve bool   `json:"approve"`
+	Comment string `json:"comment"`
+}
+
+func GetAccessRequestConfig(_ *zap.SugaredLogger) (*models.AccessRequestConfig, error) {
+	return mongodb.NewAccessRequestConfigColl().Get()
+}
+
+func UpdateAccessRequestConfig(args *models.AccessRequestConfig, _ *zap.SugaredLogger) error {
+	if args.MaxDuration < 0 {
+		return e.ErrInvalidParam.AddDesc("max_duration can't be negative")
+	}
+	return mongodb.NewAccessRequestConfigColl().Upsert(args)
+}
+
+// ListAccessRequests lists the requests the user is able to review, users who are not approvers of the
+// namespace can only see their own requests. Requests of all namespaces are visible to system admins only.
+func ListAccessRequests(opt *mongodb.ListAccessRequestOption, uid string, _ *zap.SugaredLogger) ([]*models.AccessRequest, error) {
+	namespace := opt.Namespace
+	if namespace == "" {
+		namespace = SystemScope
+	}
+	ok, err := isAccessApprover(uid, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		opt.UID = uid
+	}
+	return mongodb.NewAccessRequestColl().List(opt)
+}
+
+func CreateAccessRequest(args *AccessRequestArgs, uid, userName string, logger *zap.SugaredLogger) (*models.AccessRequest, error) {
+	if args.Namespace == "" {
+		return nil, e.ErrInvalidParam.AddDesc("namespace is empty")
+	}
+	if (args.Role == "") == (args.Policy == "") {
+		return nil, e.ErrInvalidParam.AddDesc("exactly one of role and policy should be set")
+	}
+	if strings.TrimSpace(args.Reason) == "" {
+		return nil, e.ErrInvalidParam.AddDesc("reason is empty")
+	}
+	cfg, err := mongodb.NewAccessRequestConfigColl().Get()
+	if err != nil {
+		logger.Errorf("Failed to get access request config, err: %s", err)
+		return nil, err
+	}
+	maxDuration := cfg.MaxDuration
+	if maxDuration == 0 {
+		maxDuration = defaultMaxAccessDuration
+	}
+	if args.Duration <= 0 || args.Duration > maxDuration {
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("duration should be between 1 and %d minutes", maxDuration))
+	}
+
+	// make sure the requested role or policy exists
+	ns := args.Namespace
+	if args.Preset {
+		ns = ""
+	}
+	var found bool
+	if args.Role != "" {
+		_, found, err = mongodb.NewRoleColl().Get(ns, args.Role)
+	} else {
+		_, found, err = mongodb.NewPolicyColl().Get(ns, args.Policy)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("role or policy %s%s not found", args.Role, args.Policy))
+	}
+
+	request := &models.AccessRequest{
+		Namespace: args.Namespace,
+		UID:       uid,
+		UserName:  userName,
+		Role:      args.Role,
+		Policy:    args.Policy,
+		Preset:    args.Preset,
+		Reason:    args.Reason,
+		Duration:  args.Duration,
+	}
+	if err := mongodb.NewAccessRequestColl().Create(request); err != nil {
+		logger.Errorf("Failed to create access request, err: %s", err)
+		return nil, err
+	}
+
+	go notifyAccessRequest(request, cfg, "权限申请等待审批")
+	return request, nil
+}
+
+// ReviewAccessRequest approves or rejects a pending request, the reviewer should be a system admin or an admin of
+// the requested project who gets the role permanently.
+func ReviewAccessRequest(id string, args *ReviewAccessRequestArgs, uid, userName string, logger *zap.SugaredLogger) error {
+	request, err := mongodb.NewAccessRequestColl().Find(id)
+	if err != nil {
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("access request %s not found", id))
+	}
+	if request.Status != models.AccessRequestPending {
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("access request is %s already", request.Status))
+	}
+	if request.UID == uid {
+		return e.ErrForbidden.AddDesc("users can't review their own access requests")
+	}
+	ok, err := isAccessApprover(uid, request.Namespace)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return e.ErrForbidden.AddDesc("only system admins and project admins can review access requests")
+	}
+
+	now := time.Now()
+	request.ReviewedBy = userName
+	request.ReviewedUID = uid
+	request.ReviewComment = args.Comment
+	request.ReviewTime = now.Unix()
+	request.Status = models.AccessRequestRejected
+	if args.Approve {
+		request.Status = models.AccessRequestApproved
+		request.BindingName = "jit-" + request.ID.Hex()
+		request.ExpireAt = now.Add(time.Duration(request.Duration) * time.Minute).Unix()
+	}
+
+	if err := mongodb.NewAccessRequestColl().Review(request); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return e.ErrInvalidParam.AddDesc("access request has been reviewed already")
+		}
+		return err
+	}
+
+	if args.Approve {
+		if err := createAccessBinding(request, logger); err != nil {
+			logger.Errorf("Failed to create binding for access request %s, err: %s", id, err)
+			_ = mongodb.NewAccessRequestColl().Close(request.Namespace, request.BindingName, models.AccessRequestRevoked)
+			return err
+		}
+		bundle.RefreshOPABundle()
+	}
+
+	cfg, err := mongodb.NewAccessRequestConfigColl().Get()
+	if err != nil {
+		logger.Warnf("Failed to get access request config, err: %s", err)
+		return nil
+	}
+	if args.Approve {
+		go notifyAccessRequest(request, cfg, "权限申请已通过")
+	} else {
+		go notifyAccessRequest(request, cfg, "权限申请已拒绝")
+	}
+	return nil
+}
+
+// RevokeAccessRequest revokes the binding of an approved request before it expires.
+func RevokeAccessRequest(id, uid string, logger *zap.SugaredLogger) error {
+	request, err := mongodb.NewAccessRequestColl().Find(id)
+	if err != nil {
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("access request %s not found", id))
+	}
+	if request.Status != models.AccessRequestApproved {
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("access request is %s", request.Status))
+	}
+	if request.UID != uid {
+		ok, err := isAccessApprover(uid, request.Namespace)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return e.ErrForbidden.AddDesc("only the requester and admins can revoke access")
+		}
+	}
+
+	if err := revokeAccessBinding(request.Role != "", request.Namespace, request.BindingName, models.AccessRequestRevoked); err != nil {
+		logger.Errorf("Failed to revoke access request %s, err: %s", id, err)
+		return err
+	}
+	bundle.RefreshOPABundle()
+	return nil
+}
+
+func createAccessBinding(request *models.AccessRequest, logger *zap.SugaredLogger) error {
+	if request.Role != "" {
+		obj, err := createRoleBindingObject(request.Namespace, &RoleBinding{
+			Name:     request.BindingName,
+			UID:      request.UID,
+			Role:     request.Role,
+			Preset:   request.Preset,
+			ExpireAt: request.ExpireAt,
+		}, logger)
+		if err != nil {
+			return err
+		}
+		return mongodb.NewRoleBindingColl().Create(obj)
+	}
+
+	obj, err := createPolicyBindingObject(request.Namespace, &PolicyBinding{
+		Name:     request.BindingName,
+		UID:      request.UID,
+		Policy:   request.Policy,
+		Preset:   request.Preset,
+		ExpireAt: request.ExpireAt,
+	}, logger)
+	if err != nil {
+		return err
+	}
+	return mongodb.NewPolicyBindingColl().Create(obj)
+}
+
+// revokeAccessBinding deletes the role binding, or the policy binding if isRole is false, and closes the
+// access request it is created for.
+func revokeAccessBinding(isRole bool, namespace, bindingName, status string) error {
+	var err error
+	if isRole {
+		err = mongodb.NewRoleBindingColl().Delete(bindingName, namespace)
+	} else {
+		err = mongodb.NewPolicyBindingColl().Delete(bindingName, namespace)
+	}
+	if err != nil {
+		return err
+	}
+	return mongodb.NewAccessRequestColl().Close(namespace, bindingName, status)
+}
+
+// isAccessApprover checks whether the user is a system admin, or an admin of the project, by permanent bindings
+// of the user or of the groups the user belongs to.
+func isAccessApprover(uid, namespace string) (bool, error) {
+	groups, err := user.New().ListUserGroups()
+	if err != nil {
+		return false, err
+	}
+	gids := sets.NewString()
+	for _, g := range groups {
+		if sets.NewString(g.UIDs...).Has(uid) {
+			gids.Insert(g.GroupID)
+		}
+	}
+
+	rbs, err := mongodb.NewRoleBindingColl().ListRoleBindingsByUIDs(append(gids.List(), uid, "*"))
+	if err != nil {
+		return false, err
+	}
+	for _, rb := range rbs {
+		if isApproverBinding(rb, uid, gids, namespace) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isApproverBinding checks whether the binding is a permanent system admin binding, or a permanent project admin
+// binding of the namespace, which applies to the user or to one of the groups.
+func isApproverBinding(rb *models.RoleBinding, uid string, gids sets.String, namespace string) bool {
+	if rb.ExpireAt > 0 || rb.RoleRef == nil {
+		return false
+	}
+	switch {
+	case rb.Namespace == SystemScope && rb.RoleRef.Name == string(setting.SystemAdmin):
+	case rb.Namespace != SystemScope && rb.Namespace == namespace &&
+		(rb.RoleRef.Name == string(setting.SystemAdmin) || rb.RoleRef.Name == string(setting.ProjectAdmin)):
+	default:
+		return false
+	}
+	for _, s := range rb.Subjects {
+		switch s.Kind {
+		case models.UserKind:
+			if s.UID == uid || s.UID == "*" {
+				return true
+			}
+		case models.GroupKind:
+			if gids.Has(s.UID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func notifyAccessRequest(request *models.AccessRequest, cfg *models.AccessRequestConfig, title string) {
+	target := request.Role
+	if target == "" {
+		target = request.Policy
+	}
+	project := request.Namespace
+	if project == SystemScope {
+		project = "系统"
+	}
+	fields := []string{
+		fmt.Sprintf("**申请人**：%s", request.UserName),
+		fmt.Sprintf("**项目**：%s", project),
+		fmt.Sprintf("**角色**：%s", target),
+		fmt.Sprintf("**时长**：%d 分钟", request.Duration),
+		fmt.Sprintf("**原因**：%s", request.Reason),
+	}
+	if request.ReviewedBy != "" {
+		fields = append(fields, fmt.Sprintf("**审批人**：%s", request.ReviewedBy))
+	}
+	if request.ExpireAt > 0 {
+		fields = append(fields, fmt.Sprintf("**过期时间**：%s", time.Unix(request.ExpireAt, 0).Format("2006-01-02 15:04:05")))
+	}
+
+	client := instantmessage.NewWeChatClient()
+	for _, notify := range cfg.NotifyCtls {
+		if !notify.Enabled {
+			continue
+		}
+		if err := client.SendTextNotification(notify, title, fields, "点击查看", configbase.SystemAddress()); err != nil {
+			log.Errorf("Failed to send notification of access request %s, err: %s", request.ID.Hex(), err)
+		}
+	}
+}
+
+// NewAccessSweeper returns a controller which revokes expired role bindings and policy bindings.
+// The sweeper runs in the replica holding the lease only.
+func NewAccessSweeper() *accessSweeper {
+	return &accessSweeper{
+		interval: 30 * time.Second,
+		lease:    mongotool.NewLease(mongotool.Database(configbase.PolicyDatabase()).Collection("lease"), "access-sweeper", 5*time.Minute),
+	}
+}
+
+type accessSweeper struct {
+	interval time.Duration
+	lease    *mongotool.Lease
+}
+
+// Run starts the sweeper and blocks until receiving signal from stopCh.
+func (s *accessSweeper) Run(_ int, stopCh <-chan struct{}) {
+	log.Info("Starting access sweeper")
+	defer log.Info("Shutting down access sweeper")
+
+	go wait.Until(s.sweep, s.interval, stopCh)
+
+	<-stopCh
+}
+
+func (s *accessSweeper) sweep() {
+	ok, err := s.lease.Acquire(context.TODO())
+	if err != nil {
+		log.Errorf("Failed to acquire access sweeper lease, err: %s", err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	now := time.Now().Unix()
+	revoked := 0
+
+	rbs, err := mongodb.NewRoleBindingColl().ListExpired(now)
+	if err != nil {
+		log.Errorf("Failed to list expired role bindings, err: %s", err)
+	}
+	for _, rb := range rbs {
+		if err := revokeAccessBinding(true, rb.Namespace, rb.Name, models.AccessRequestExpired); err != nil {
+			log.Errorf("Failed to revoke role binding %s in %s, err: %s", rb.Name, rb.Namespace, err)
+			continue
+		}
+		revoked++
+	}
+
+	pbs, err := mongodb.NewPolicyBindingColl().ListExpired(now)
+	if err != nil {
+		log.Errorf("Failed to list expired policy bindings, err: %s", err)
+	}
+	for _, pb := range pbs {
+		if err := revokeAccessBinding(false, pb.Namespace, pb.Name, models.AccessRequestExpired); err != nil {
+			log.Errorf("Failed to revoke policy binding %s in %s, err: %s", pb.Name, pb.Namespace, err)
+			continue
+		}
+		revoked++
+	}
+
+	if revoked > 0 {
+		log.Infof("%d expired bindings are revoked", revoked)
+		bundle.RefreshOPABundle()
+	}
+}
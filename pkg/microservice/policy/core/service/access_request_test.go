@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/pkg/microservice/policy/core/repository/models"
+	"github.com/koderover/zadig/pkg/setting"
+)
+
+func TestIsApproverBinding(t *testing.T) {
+	binding := func(namespace string, role setting.RoleType, expireAt int64, subjects ...*models.Subject) *models.RoleBinding {
+		return &models.RoleBinding{
+			Namespace: namespace,
+			Subjects:  subjects,
+			RoleRef:   &models.RoleRef{Name: string(role)},
+			ExpireAt:  expireAt,
+		}
+	}
+	alice := &models.Subject{Kind: models.UserKind, UID: "alice"}
+	ops := &models.Subject{Kind: models.GroupKind, UID: "ops"}
+	gids := sets.NewString("ops")
+
+	tests := []struct {
+		name      string
+		rb        *models.RoleBinding
+		namespace string
+		want      bool
+	}{
+		{name: "system admin", rb: binding(SystemScope, setting.SystemAdmin, 0, alice), namespace: "demo", want: true},
+		{name: "project admin", rb: binding("demo", setting.ProjectAdmin, 0, alice), namespace: "demo", want: true},
+		{name: "project admin by group", rb: binding("demo", setting.ProjectAdmin, 0, ops), namespace: "demo", want: true},
+		{name: "system admin by group", rb: binding(SystemScope, setting.SystemAdmin, 0, ops), namespace: SystemScope, want: true},
+		{name: "project admin of another project", rb: binding("other", setting.ProjectAdmin, 0, alice), namespace: "demo"},
+		{name: "project admin for system requests", rb: binding("demo", setting.ProjectAdmin, 0, alice), namespace: SystemScope},
+		{name: "temporary binding", rb: binding("demo", setting.ProjectAdmin, 1700000000, alice), namespace: "demo"},
+		{name: "other group", rb: binding("demo", setting.ProjectAdmin, 0, &models.Subject{Kind: models.GroupKind, UID: "dev"}), namespace: "demo"},
+		{name: "other user", rb: binding("demo", setting.ProjectAdmin, 0, &models.Subject{Kind: models.UserKind, UID: "bob"}), namespace: "demo"},
+		{name: "all users", rb: binding("demo", setting.ProjectAdmin, 0, &models.Subject{Kind: models.UserKind, UID: "*"}), namespace: "demo", want: true},
+		{name: "group id as user", rb: binding("demo", setting.ProjectAdmin, 0, &models.Subject{Kind: models.UserKind, UID: "ops"}), namespace: "demo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isApproverBinding(tt.rb, "alice", gids, tt.namespace); got != tt.want {
+				t.Errorf("isApproverBinding() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
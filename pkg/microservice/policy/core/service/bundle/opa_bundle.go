@@ -19,6 +19,8 @@ package bundle
 import (
 	"net/http"
 	"sort"
+	"sync"
+	"time"
 
 	"k8s.io/apimachinery/pkg/util/sets"
 
@@ -26,6 +28,7 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/policy/core/repository/models"
 	"github.com/koderover/zadig/pkg/microservice/policy/core/repository/mongodb"
 	"github.com/koderover/zadig/pkg/microservice/policy/core/yamlconfig"
+	"github.com/koderover/zadig/pkg/shared/client/user"
 	"github.com/koderover/zadig/pkg/tool/log"
 	"github.com/koderover/zadig/pkg/tool/opa"
 )
@@ -36,6 +39,8 @@ const (
 	rolesPath      = "roles/data.json"
 	policiesPath   = "policies/data.json"
 	bindingsPath   = "bindings/data.json"
+	tokensPath     = "tokens/data.json"
+	mfaPath        = "mfa/data.json"
 
 	exemptionsPath = "exemptions/data.json"
 	resourcesPath  = "resources/data.json"
@@ -46,6 +51,8 @@ const (
 	exemptionsRoot   = "exemptions"
 	resourcesRoot    = "resources"
 	policiesRoot     = "policies"
+	tokensRoot       = "tokens"
+	mfaRoot          = "mfa"
 )
 
 type expressionOperator string
@@ -69,6 +76,12 @@ var AllMethods = []string{MethodGet, MethodPost, MethodPut, MethodPatch, MethodD
 
 var revision string
 
+// expiryTimer refreshes the bundle once the next binding expires, it is reset by every bundle generation
+var (
+	expiryTimer     *time.Timer
+	expiryTimerLock sync.Mutex
+)
+
 type opaRoles struct {
 	Roles roles `json:"roles"`
 }
@@ -80,6 +93,34 @@ type opaPolicies struct {
 type opaRoleBindings struct {
 	RoleBindings   roleBindings   `json:"role_bindings"`
 	PolicyBindings policyBindings `json:"policy_bindings"`
+	// UserGroups maps the uid of a user to the ids of the groups the user belongs to
+	UserGroups map[string][]string `json:"user_groups"`
+}
+
+// opaTokens holds the active api tokens indexed by token id
+type opaTokens struct {
+	Tokens map[string]*apiToken `json:"tokens"`
+}
+
+// opaMFA tells which users must log in with mfa before visiting the mfa required urls
+type opaMFA struct {
+	// Users holds the uids of the users who activated mfa
+	Users map[string]bool `json:"users"`
+	// EnforcedRoles are the system roles whose local members must use mfa
+	EnforcedRoles []string `json:"enforced_roles"`
+}
+
+type apiToken struct {
+	UID       string        `json:"uid"`
+	TokenHash string        `json:"token_hash"`
+	ExpiresAt int64         `json:"expires_at"`
+	Scopes    []*tokenScope `json:"scopes"`
+}
+
+// tokenScope holds the rules which are granted to a token in the namespace, "*" means system scope
+type tokenScope struct {
+	Namespace string `json:"namespace"`
+	Rules     Rules  `json:"rules"`
 }
 
 type role struct {
@@ -149,13 +190,16 @@ type expression struct {
 	Operator expressionOperator `json:"operator"`
 }
 
+// roleBinding holds the bindings of a user, or of a user group if GID is set
 type roleBinding struct {
 	UID      string   `json:"uid"`
+	GID      string   `json:"gid,omitempty"`
 	Bindings bindings `json:"bindings"`
 }
 
 type policyBinding struct {
 	UID      string         `json:"uid"`
+	GID      string         `json:"gid,omitempty"`
 	Bindings bindingPolicys `json:"bindings"`
 }
 
@@ -231,6 +275,9 @@ type roleBindings []*roleBinding
 func (o roleBindings) Len() int      { return len(o) }
 func (o roleBindings) Swap(i, j int) { o[i], o[j] = o[j], o[i] }
 func (o roleBindings) Less(i, j int) bool {
+	if o[i].UID == o[j].UID {
+		return o[i].GID < o[j].GID
+	}
 	return o[i].UID < o[j].UID
 }
 
@@ -239,6 +286,9 @@ type policyBindings []*policyBinding
 func (o policyBindings) Len() int      { return len(o) }
 func (o policyBindings) Swap(i, j int) { o[i], o[j] = o[j], o[i] }
 func (o policyBindings) Less(i, j int) bool {
+	if o[i].UID == o[j].UID {
+		return o[i].GID < o[j].GID
+	}
 	return o[i].UID < o[j].UID
 }
 
@@ -370,66 +420,170 @@ func generateOPAPolicies(policies []*models.Policy, policyMetas []*models.Policy
 	return data
 }
 
-func generateOPABindings(rbs []*models.RoleBinding, pbs []*models.PolicyBinding) *opaRoleBindings {
-	data := &opaRoleBindings{}
+// generateOPABindings generates the bindings which are in effect at now, the expired ones are left out
+// even if they are not revoked by the access sweeper yet.
+func generateOPABindings(rbs []*models.RoleBinding, pbs []*models.PolicyBinding, groups []*user.UserGroup, now int64) *opaRoleBindings {
+	data := &opaRoleBindings{UserGroups: make(map[string][]string)}
 
 	userRoleMap := make(map[string]map[string][]*roleRef)
+	groupRoleMap := make(map[string]map[string][]*roleRef)
 
 	for _, rb := range rbs {
+		if isExpired(rb.ExpireAt, now) {
+			continue
+		}
 		for _, s := range rb.Subjects {
-			if s.Kind == models.UserKind {
-				if _, ok := userRoleMap[s.UID]; !ok {
-					userRoleMap[s.UID] = make(map[string][]*roleRef)
-				}
-				userRoleMap[s.UID][rb.Namespace] = append(userRoleMap[s.UID][rb.Namespace], &roleRef{Name: rb.RoleRef.Name, Namespace: rb.RoleRef.Namespace})
+			subjectRoleMap := userRoleMap
+			switch s.Kind {
+			case models.UserKind:
+			case models.GroupKind:
+				subjectRoleMap = groupRoleMap
+			default:
+				continue
+			}
+			if _, ok := subjectRoleMap[s.UID]; !ok {
+				subjectRoleMap[s.UID] = make(map[string][]*roleRef)
 			}
+			subjectRoleMap[s.UID][rb.Namespace] = append(subjectRoleMap[s.UID][rb.Namespace], &roleRef{Name: rb.RoleRef.Name, Namespace: rb.RoleRef.Namespace})
 		}
 	}
 
 	for u, nb := range userRoleMap {
-		var bindingsData []*binding
-		for n, b := range nb {
-			sort.Sort(roleRefs(b))
-			bindingsData = append(bindingsData, &binding{Namespace: n, RoleRefs: b})
-		}
-		sort.Sort(bindings(bindingsData))
-		data.RoleBindings = append(data.RoleBindings, &roleBinding{UID: u, Bindings: bindingsData})
+		data.RoleBindings = append(data.RoleBindings, &roleBinding{UID: u, Bindings: toOPABindings(nb)})
+	}
+	for g, nb := range groupRoleMap {
+		data.RoleBindings = append(data.RoleBindings, &roleBinding{GID: g, Bindings: toOPABindings(nb)})
 	}
 
 	sort.Sort(data.RoleBindings)
 
 	userPolicyMap := make(map[string]map[string][]*roleRef)
+	groupPolicyMap := make(map[string]map[string][]*roleRef)
 
 	for _, rb := range pbs {
+		if isExpired(rb.ExpireAt, now) {
+			continue
+		}
 		for _, s := range rb.Subjects {
-			if s.Kind == models.UserKind {
-				if _, ok := userPolicyMap[s.UID]; !ok {
-					userPolicyMap[s.UID] = make(map[string][]*roleRef)
-				}
-				userPolicyMap[s.UID][rb.Namespace] = append(userPolicyMap[s.UID][rb.Namespace], &roleRef{Name: rb.PolicyRef.Name, Namespace: rb.PolicyRef.Namespace})
+			subjectPolicyMap := userPolicyMap
+			switch s.Kind {
+			case models.UserKind:
+			case models.GroupKind:
+				subjectPolicyMap = groupPolicyMap
+			default:
+				continue
+			}
+			if _, ok := subjectPolicyMap[s.UID]; !ok {
+				subjectPolicyMap[s.UID] = make(map[string][]*roleRef)
 			}
+			subjectPolicyMap[s.UID][rb.Namespace] = append(subjectPolicyMap[s.UID][rb.Namespace], &roleRef{Name: rb.PolicyRef.Name, Namespace: rb.PolicyRef.Namespace})
 		}
 	}
 
 	for u, nb := range userPolicyMap {
-		var bindingsData []*bindingPolicy
-		for n, b := range nb {
-			sort.Sort(roleRefs(b))
-			bindingsData = append(bindingsData, &bindingPolicy{Namespace: n, RoleRefs: b})
-		}
-		sort.Sort(bindingPolicys(bindingsData))
-		data.PolicyBindings = append(data.PolicyBindings, &policyBinding{UID: u, Bindings: bindingsData})
+		data.PolicyBindings = append(data.PolicyBindings, &policyBinding{UID: u, Bindings: toOPABindingPolicys(nb)})
+	}
+	for g, nb := range groupPolicyMap {
+		data.PolicyBindings = append(data.PolicyBindings, &policyBinding{GID: g, Bindings: toOPABindingPolicys(nb)})
 	}
 
 	sort.Sort(data.PolicyBindings)
 
+	for _, group := range groups {
+		for _, uid := range group.UIDs {
+			data.UserGroups[uid] = append(data.UserGroups[uid], group.GroupID)
+		}
+	}
+	for _, gids := range data.UserGroups {
+		sort.Strings(gids)
+	}
+
+	return data
+}
+
+func isExpired(expireAt, now int64) bool {
+	return expireAt > 0 && expireAt <= now
+}
+
+// nextExpiry returns the earliest expiry of the bindings which are in effect at now, 0 is returned if there is none.
+func nextExpiry(rbs []*models.RoleBinding, pbs []*models.PolicyBinding, now int64) int64 {
+	var next int64
+	check := func(expireAt int64) {
+		if expireAt > now && (next == 0 || expireAt < next) {
+			next = expireAt
+		}
+	}
+	for _, rb := range rbs {
+		check(rb.ExpireAt)
+	}
+	for _, pb := range pbs {
+		check(pb.ExpireAt)
+	}
+	return next
+}
+
+func toOPABindings(nb map[string][]*roleRef) bindings {
+	var bindingsData []*binding
+	for n, b := range nb {
+		sort.Sort(roleRefs(b))
+		bindingsData = append(bindingsData, &binding{Namespace: n, RoleRefs: b})
+	}
+	sort.Sort(bindings(bindingsData))
+	return bindingsData
+}
+
+func toOPABindingPolicys(nb map[string][]*roleRef) bindingPolicys {
+	var bindingsData []*bindingPolicy
+	for n, b := range nb {
+		sort.Sort(roleRefs(b))
+		bindingsData = append(bindingsData, &bindingPolicy{Namespace: n, RoleRefs: b})
+	}
+	sort.Sort(bindingPolicys(bindingsData))
+	return bindingsData
+}
+
+// generateOPATokens maps the scopes of api tokens onto the rules of the policy metas,
+// the scopes don't grant any permission by themselves, they only narrow down the permissions of the token owner
+func generateOPATokens(tokens []*user.APIToken, policyMetas []*models.PolicyMeta) *opaTokens {
+	data := &opaTokens{Tokens: make(map[string]*apiToken)}
+	resourceMappings := getResourceActionMappings(true, policyMetas)
+
+	for _, t := range tokens {
+		token := &apiToken{UID: t.UID, TokenHash: t.TokenHash, ExpiresAt: t.ExpiresAt}
+		namespaceRules := make(map[string]Rules)
+		for _, s := range t.Scopes {
+			verbs := s.Verbs
+			if sets.NewString(verbs...).Has("*") {
+				verbs = []string{models.MethodAll}
+			}
+			namespaceRules[s.Project] = append(namespaceRules[s.Project], resourceMappings.GetRules(s.Resource, verbs)...)
+		}
+		for namespace, rules := range namespaceRules {
+			sort.Sort(rules)
+			token.Scopes = append(token.Scopes, &tokenScope{Namespace: namespace, Rules: rules})
+		}
+		sort.Slice(token.Scopes, func(i, j int) bool {
+			return token.Scopes[i].Namespace < token.Scopes[j].Namespace
+		})
+		data.Tokens[t.TokenID] = token
+	}
+
+	return data
+}
+
+func generateOPAMFA(uids, enforcedRoles []string) *opaMFA {
+	data := &opaMFA{Users: make(map[string]bool), EnforcedRoles: sets.NewString(enforcedRoles...).List()}
+	for _, uid := range uids {
+		data.Users[uid] = true
+	}
 	return data
 }
 
 type ExemptionURLs struct {
-	Public     Rules `json:"public"`     // public urls are not controlled by AuthN and AuthZ
-	Privileged Rules `json:"privileged"` // privileged urls can only be visited by system admins
-	Registered Rules `json:"registered"` // registered urls are the entire list of urls which are controlled by AuthZ, which means that if an url is not in this list, it is not controlled by AuthZ
+	Public      Rules `json:"public"`       // public urls are not controlled by AuthN and AuthZ
+	Privileged  Rules `json:"privileged"`   // privileged urls can only be visited by system admins
+	Registered  Rules `json:"registered"`   // registered urls are the entire list of urls which are controlled by AuthZ, which means that if an url is not in this list, it is not controlled by AuthZ
+	MFARequired Rules `json:"mfa_required"` // mfa required urls can only be visited by users who logged in with MFA
 }
 
 func generateOPAExemptionURLs(policies []*models.PolicyMeta) *ExemptionURLs {
@@ -456,6 +610,16 @@ func generateOPAExemptionURLs(policies []*models.PolicyMeta) *ExemptionURLs {
 	}
 	sort.Sort(data.Privileged)
 
+	for _, r := range yamlconfig.GetExemptionsUrls().MFARequired {
+		if len(r.Methods) == 1 && r.Methods[0] == models.MethodAll {
+			r.Methods = AllMethods
+		}
+		for _, method := range r.Methods {
+			data.MFARequired = append(data.MFARequired, &Rule{Method: method, Endpoint: r.Endpoint})
+		}
+	}
+	sort.Sort(data.MFARequired)
+
 	resourceMappings := getResourceActionMappings(false, policies)
 	for _, resourceMappings := range resourceMappings {
 		for _, rs := range resourceMappings {
@@ -486,6 +650,7 @@ func generateOPAPolicyRego() []byte {
 }
 
 func GenerateOPABundle() error {
+	now := time.Now().Unix()
 	rs, err := mongodb.NewRoleColl().List()
 	if err != nil {
 		log.Errorf("Failed to list roles, err: %s", err)
@@ -508,17 +673,35 @@ func GenerateOPABundle() error {
 	if err != nil {
 		log.Errorf("Failed to list policies, err: %s", err)
 	}
+	groups, err := user.New().ListUserGroups()
+	if err != nil {
+		log.Errorf("Failed to list user groups, err: %s", err)
+	}
+	tokens, err := user.New().ListActiveAPITokens()
+	if err != nil {
+		log.Errorf("Failed to list api tokens, err: %s", err)
+	}
+	mfaUsers, err := user.New().ListMFAUsers()
+	if err != nil {
+		log.Errorf("Failed to list mfa users, err: %s", err)
+	}
+	mfaEnforcedRoles, err := user.New().ListMFAEnforcedRoles()
+	if err != nil {
+		log.Errorf("Failed to list mfa enforced roles, err: %s", err)
+	}
 
 	bundle := &opa.Bundle{
 		Data: []*opa.DataSpec{
 			{Data: generateOPAPolicyRego(), Path: policyRegoPath},
 			{Data: generateOPARoles(rs, pms), Path: rolesPath},
 			{Data: generateOPAPolicies(policies, pms), Path: policiesPath},
-			{Data: generateOPABindings(bs, pbs), Path: bindingsPath},
+			{Data: generateOPABindings(bs, pbs, groups, now), Path: bindingsPath},
+			{Data: generateOPATokens(tokens, pms), Path: tokensPath},
+			{Data: generateOPAMFA(mfaUsers, mfaEnforcedRoles), Path: mfaPath},
 			{Data: generateOPAExemptionURLs(pms), Path: exemptionsPath},
 			{Data: generateResourceBundle(), Path: resourcesPath},
 		},
-		Roots: []string{policyRoot, rolesRoot, rolebindingsRoot, exemptionsRoot, resourcesRoot, policiesRoot},
+		Roots: []string{policyRoot, rolesRoot, rolebindingsRoot, exemptionsRoot, resourcesRoot, policiesRoot, tokensRoot, mfaRoot},
 	}
 
 	hash, err := bundle.Rehash()
@@ -528,7 +711,25 @@ func GenerateOPABundle() error {
 	}
 	revision = hash
 
-	return bundle.Save(config.DataPath())
+	if err := bundle.Save(config.DataPath()); err != nil {
+		return err
+	}
+	// the bundle is generated again once the next binding expires, so that the access ends on time
+	resetExpiryTimer(nextExpiry(bs, pbs, now), now)
+	return nil
+}
+
+func resetExpiryTimer(next, now int64) {
+	expiryTimerLock.Lock()
+	defer expiryTimerLock.Unlock()
+
+	if expiryTimer != nil {
+		expiryTimer.Stop()
+		expiryTimer = nil
+	}
+	if next > 0 {
+		expiryTimer = time.AfterFunc(time.Duration(next-now)*time.Second, RefreshOPABundle)
+	}
 }
 
 func GetRevision() string {
@@ -19,11 +19,14 @@ package bundle
 import (
 	"encoding/json"
 	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	"github.com/koderover/zadig/pkg/microservice/policy/core/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/policy/core/yamlconfig"
+	"github.com/koderover/zadig/pkg/shared/client/user"
 	_ "github.com/koderover/zadig/pkg/util/testing"
 )
 
@@ -225,4 +228,79 @@ var _ = Describe("Testing generate OPA roles and bindings", func() {
 		})
 
 	})
+
+	Context("generateOPATokens", func() {
+
+		var policyMetas []*models.PolicyMeta
+
+		BeforeEach(func() {
+			bs, err := json.Marshal(yamlconfig.DefaultPolicyMetas())
+			Expect(err).ShouldNot(HaveOccurred())
+			err = json.Unmarshal(bs, &policyMetas)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("should grant the openapi environment routes to the scoped token", func() {
+			tokens := []*user.APIToken{{
+				TokenID: "t1",
+				UID:     "u1",
+				Scopes: []*user.APITokenScope{
+					{Project: "proj", Resource: "Environment", Verbs: []string{"get_environment", "manage_environment"}},
+				},
+			}}
+			data := generateOPATokens(tokens, policyMetas)
+			Expect(data.Tokens).To(HaveKey("t1"))
+			Expect(data.Tokens["t1"].Scopes).To(HaveLen(1))
+
+			scope := data.Tokens["t1"].Scopes[0]
+			Expect(scope.Namespace).To(Equal("proj"))
+			var endpoints []string
+			for _, r := range scope.Rules {
+				endpoints = append(endpoints, r.Method+" "+r.Endpoint)
+			}
+			Expect(endpoints).To(ContainElements(
+				"GET /openapi/v1/environments",
+				"GET /openapi/v1/environments/?*",
+				"POST /openapi/v1/environments/?*/images",
+				"POST /openapi/v1/environments/?*/services/?*/restart",
+			))
+			Expect(endpoints).NotTo(ContainElement("DELETE /openapi/v1/environments/?*"))
+		})
+
+	})
+
+	Context("resetExpiryTimer", func() {
+
+		It("should keep a single timer for the next expiry", func() {
+			now := time.Now().Unix()
+			resetExpiryTimer(now+3600, now)
+			first := expiryTimer
+			Expect(first).NotTo(BeNil())
+
+			resetExpiryTimer(now+1800, now)
+			Expect(expiryTimer).NotTo(BeIdenticalTo(first))
+			Expect(first.Stop()).To(BeFalse())
+
+			resetExpiryTimer(0, now)
+			Expect(expiryTimer).To(BeNil())
+		})
+
+	})
+
+	Context("generateOPAMFA", func() {
+
+		It("should index the mfa users and dedupe the enforced roles", func() {
+			data := generateOPAMFA([]string{"u1", "u2"}, []string{"ops", "admin", "ops"})
+			Expect(data.Users).To(Equal(map[string]bool{"u1": true, "u2": true}))
+			Expect(data.EnforcedRoles).To(Equal([]string{"admin", "ops"}))
+		})
+
+		It("should work without any mfa data", func() {
+			data := generateOPAMFA(nil, nil)
+			actual, err := json.Marshal(data)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(string(actual)).To(Equal(`{"users":{},"enforced_roles":[]}`))
+		})
+
+	})
 })
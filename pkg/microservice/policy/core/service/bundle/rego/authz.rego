@@ -39,6 +39,7 @@ response = r {
 response = r {
     is_authenticated
     not allow
+    access_is_in_token_scope
     rule_is_matched_for_filtering
     roles := all_roles
     role_resource := user_role_allowed_resources
@@ -68,6 +69,77 @@ allow {
 allow {
     is_authenticated
     access_is_granted
+    access_is_in_token_scope
+    mfa_is_satisfied
+}
+
+mfa_is_satisfied {
+    not url_requires_mfa
+}
+
+# MFA is required only from the users who must log in with it, TOTP is checked by the local login only,
+# so the users from LDAP, OIDC and other SSO connectors are never asked for it.
+mfa_is_satisfied {
+    not claims.token_id
+    not user_must_use_mfa
+}
+
+# the claim is set if the user logged in with MFA, it is never trusted in api tokens since they outlive the session
+mfa_is_satisfied {
+    claims.mfa == true
+    not claims.token_id
+}
+
+# api tokens can't carry an MFA login, they are exempted for the actions which are explicitly granted by their scopes,
+# an MFA login is required to create a token if the owner must use MFA.
+mfa_is_satisfied {
+    claims.token_id
+    access_is_in_token_scope
+}
+
+user_is_local {
+    claims.federated_claims.connector_id == "system"
+}
+
+# users who activated MFA
+user_must_use_mfa {
+    user_is_local
+    data.mfa.users[claims.uid]
+}
+
+# users who are bound to a system role which enforces MFA
+user_must_use_mfa {
+    some role
+    user_is_local
+    allowed_system_roles[role]
+    role.name == data.mfa.enforced_roles[_]
+}
+
+# Requests with a normal login token are not narrowed down.
+access_is_in_token_scope {
+    not claims.token_id
+}
+
+# Requests with an api token are allowed only if the action is also granted by the scopes of the token,
+# so the permissions of the token are the intersection of its scopes and the owner's roles.
+access_is_in_token_scope {
+    some rule
+
+    token_scope_rules[rule]
+    rule.method == http_request.method
+    glob.match(trim(rule.endpoint, "/"), ["/"], concat("/", input.parsed_path))
+}
+
+token_scope_rules[rule] {
+    scope := data.tokens.tokens[claims.token_id].scopes[_]
+    scope.namespace == "*"
+    rule := scope.rules[_]
+}
+
+token_scope_rules[rule] {
+    scope := data.tokens.tokens[claims.token_id].scopes[_]
+    scope.namespace == project_name
+    rule := scope.rules[_]
 }
 
 # Allow all valid users to visit exempted urls.
@@ -281,6 +353,13 @@ url_is_registered {
     glob.match(trim(data.exemptions.registered[i].endpoint, "/"), ["/"], concat("/", input.parsed_path))
 }
 
+# mfa required urls are visible for users who logged in with MFA only
+url_requires_mfa {
+    some i
+    data.exemptions.mfa_required[i].method == http_request.method
+    glob.match(trim(data.exemptions.mfa_required[i].endpoint, "/"), ["/"], concat("/", input.parsed_path))
+}
+
 # privileged urls are visible for system admins only
 url_is_privileged {
     some i
@@ -292,16 +371,30 @@ project_name := pn {
     pn := input.parsed_query.projectName[0]
 }
 
+# the binding is for the current user
+binding_is_for_user(b) {
+    b.uid == claims.uid
+}
+
+# the binding is for a group which the current user belongs to
+binding_is_for_user(b) {
+    b.gid == user_groups[_]
+}
+
+user_groups[gid] {
+    gid := data.bindings.user_groups[claims.uid][_]
+}
+
 # get all projects which are visible by current user
 user_projects[project] {
     some i
-    data.bindings.role_bindings[i].uid == claims.uid
+    binding_is_for_user(data.bindings.role_bindings[i])
     project := data.bindings.role_bindings[i].bindings[_].namespace
     project !="*"
 }
 user_projects[project] {
     some i
-    data.bindings.policy_bindings[i].uid == claims.uid
+    binding_is_for_user(data.bindings.policy_bindings[i])
     project := data.bindings.policy_bindings[i].bindings[_].namespace
     project !="*"
 }
@@ -348,7 +441,7 @@ user_visible_projects[project] {
 
 all_roles[role_ref] {
     some i
-    data.bindings.role_bindings[i].uid == claims.uid
+    binding_is_for_user(data.bindings.role_bindings[i])
     role_ref := data.bindings.role_bindings[i].bindings[j].role_refs[_]
 }
 
@@ -357,7 +450,7 @@ all_roles[role_ref] {
 allowed_roles[role_ref] {
     some i
     some j
-    data.bindings.role_bindings[i].uid == claims.uid
+    binding_is_for_user(data.bindings.role_bindings[i])
     data.bindings.role_bindings[i].bindings[j].namespace == project_name
     role_ref := data.bindings.role_bindings[i].bindings[j].role_refs[_]
 }
@@ -365,7 +458,7 @@ allowed_roles[role_ref] {
 allowed_system_roles[role_ref]{
     some i
     some j
-    data.bindings.role_bindings[i].uid == claims.uid
+    binding_is_for_user(data.bindings.role_bindings[i])
     data.bindings.role_bindings[i].bindings[j].namespace == "*"
     role_ref := data.bindings.role_bindings[i].bindings[j].role_refs[_]
 }
@@ -382,7 +475,7 @@ allowed_roles[role_ref] {
 allowed_policies[policy_ref] {
     some i
     some j
-    data.bindings.policy_bindings[i].uid == claims.uid
+    binding_is_for_user(data.bindings.policy_bindings[i])
     data.bindings.policy_bindings[i].bindings[j].namespace == project_name
     policy_ref := data.bindings.policy_bindings[i].bindings[j].policy_refs[_]
 }
@@ -509,6 +602,19 @@ is_authenticated {
     claims
     claims.uid != ""
     claims.exp > time.now_ns()/1000000000
+    api_token_is_valid
+}
+
+api_token_is_valid {
+    not claims.token_id
+}
+
+# api tokens which are revoked or expired are not in the data
+api_token_is_valid {
+    token := data.tokens.tokens[claims.token_id]
+    token.uid == claims.uid
+    token.token_hash == crypto.sha256(bearer_token)
+    token.expires_at > time.now_ns()/1000000000
 }
 
 envs := env {
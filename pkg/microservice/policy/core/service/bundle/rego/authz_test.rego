@@ -1 +1,316 @@
-package rbac
\ No newline at end of file
+package rbac
+
+mfa_test_exemptions := {
+    "public": [],
+    "privileged": [],
+    "registered": [
+        {"method": "PUT", "endpoint": "api/aslan/environment/environments"},
+        {"method": "GET", "endpoint": "api/aslan/environment/environments"}
+    ],
+    "mfa_required": [
+        {"method": "PUT", "endpoint": "api/aslan/environment/environments"}
+    ]
+}
+
+mfa_test_roles := {"roles": [
+    {
+        "name": "deployer",
+        "namespace": "proj",
+        "rules": [
+            {"method": "PUT", "endpoint": "api/aslan/environment/environments"},
+            {"method": "GET", "endpoint": "api/aslan/environment/environments"}
+        ]
+    },
+    {
+        "name": "ops",
+        "namespace": "*",
+        "rules": []
+    }
+]}
+
+mfa_test_bindings := {
+    "role_bindings": [
+        {"uid": "u1", "bindings": [
+            {"namespace": "proj", "role_refs": [{"name": "deployer", "namespace": "proj"}]},
+            {"namespace": "*", "role_refs": [{"name": "ops", "namespace": "*"}]}
+        ]}
+    ],
+    "policy_bindings": [],
+    "user_groups": {}
+}
+
+mfa_test_tokens := {"tokens": {"t1": {
+    "uid": "u1",
+    "scopes": [{"namespace": "proj", "rules": [
+        {"method": "PUT", "endpoint": "api/aslan/environment/environments"}
+    ]}]
+}}}
+
+mfa_test_put_input := {
+    "parsed_path": ["api", "aslan", "environment", "environments"],
+    "parsed_query": {"projectName": ["proj"]},
+    "attributes": {"request": {"http": {"method": "PUT"}}}
+}
+
+mfa_test_get_input := {
+    "parsed_path": ["api", "aslan", "environment", "environments"],
+    "parsed_query": {"projectName": ["proj"]},
+    "attributes": {"request": {"http": {"method": "GET"}}}
+}
+
+local_claims := {"uid": "u1", "federated_claims": {"connector_id": "system"}}
+
+local_mfa_claims := {"uid": "u1", "mfa": true, "federated_claims": {"connector_id": "system"}}
+
+sso_claims := {"uid": "u1", "federated_claims": {"connector_id": "ldap"}}
+
+token_claims := {"uid": "u1", "token_id": "t1", "federated_claims": {"connector_id": "system"}}
+
+no_mfa_users := {"users": {}, "enforced_roles": []}
+
+enrolled_mfa_users := {"users": {"u1": true}, "enforced_roles": []}
+
+enforced_mfa_roles := {"users": {}, "enforced_roles": ["ops"]}
+
+allow_with(c, m, i) {
+    allow with data.rbac.is_authenticated as true
+        with data.rbac.claims as c
+        with data.mfa as m
+        with input as i
+        with data.exemptions as mfa_test_exemptions
+        with data.roles as mfa_test_roles
+        with data.bindings as mfa_test_bindings
+        with data.tokens as mfa_test_tokens
+}
+
+test_local_user_without_mfa_is_allowed {
+    allow_with(local_claims, no_mfa_users, mfa_test_put_input)
+}
+
+test_enrolled_local_user_is_denied_without_mfa_login {
+    not allow_with(local_claims, enrolled_mfa_users, mfa_test_put_input)
+}
+
+test_enrolled_local_user_is_allowed_with_mfa_login {
+    allow_with(local_mfa_claims, enrolled_mfa_users, mfa_test_put_input)
+}
+
+test_enrolled_local_user_is_not_asked_for_mfa_on_other_urls {
+    allow_with(local_claims, enrolled_mfa_users, mfa_test_get_input)
+}
+
+test_local_user_with_enforced_role_is_denied_without_mfa_login {
+    not allow_with(local_claims, enforced_mfa_roles, mfa_test_put_input)
+}
+
+test_sso_user_is_not_asked_for_mfa {
+    allow_with(sso_claims, enrolled_mfa_users, mfa_test_put_input)
+    allow_with(sso_claims, enforced_mfa_roles, mfa_test_put_input)
+}
+
+test_api_token_is_exempted_in_its_scope {
+    allow_with(token_claims, enrolled_mfa_users, mfa_test_put_input)
+    allow_with(token_claims, enforced_mfa_roles, mfa_test_put_input)
+}
+
+test_api_token_is_denied_out_of_its_scope {
+    not allow_with(token_claims, no_mfa_users, mfa_test_get_input)
+}
+
+openapi_test_exemptions := {
+    "public": [],
+    "privileged": [],
+    "registered": [
+        {"method": "GET", "endpoint": "/openapi/v1/environments"},
+        {"method": "POST", "endpoint": "/openapi/v1/environments/?*/images"}
+    ],
+    "mfa_required": [
+        {"method": "POST", "endpoint": "openapi/v1/environments/?*/images"}
+    ]
+}
+
+openapi_test_roles := {"roles": [{
+    "name": "deployer",
+    "namespace": "proj",
+    "rules": [
+        {"method": "GET", "endpoint": "/openapi/v1/environments"},
+        {"method": "POST", "endpoint": "/openapi/v1/environments/?*/images"}
+    ]
+}]}
+
+openapi_test_bindings := {
+    "role_bindings": [
+        {"uid": "u1", "bindings": [{"namespace": "proj", "role_refs": [{"name": "deployer", "namespace": "proj"}]}]}
+    ],
+    "policy_bindings": [],
+    "user_groups": {}
+}
+
+openapi_test_tokens := {"tokens": {
+    "t1": {"uid": "u1", "scopes": [{"namespace": "proj", "rules": [
+        {"method": "GET", "endpoint": "/openapi/v1/environments"},
+        {"method": "POST", "endpoint": "/openapi/v1/environments/?*/images"}
+    ]}]},
+    "t2": {"uid": "u2", "scopes": [{"namespace": "proj", "rules": [
+        {"method": "GET", "endpoint": "/openapi/v1/environments"}
+    ]}]}
+}}
+
+openapi_list_envs_input := {
+    "parsed_path": ["openapi", "v1", "environments"],
+    "parsed_query": {"projectName": ["proj"]},
+    "attributes": {"request": {"http": {"method": "GET"}}}
+}
+
+openapi_update_images_input := {
+    "parsed_path": ["openapi", "v1", "environments", "dev", "images"],
+    "parsed_query": {"projectName": ["proj"]},
+    "attributes": {"request": {"http": {"method": "POST"}}}
+}
+
+openapi_allow_with(c, i) {
+    allow with data.rbac.is_authenticated as true
+        with data.rbac.claims as c
+        with data.mfa as enrolled_mfa_users
+        with input as i
+        with data.exemptions as openapi_test_exemptions
+        with data.roles as openapi_test_roles
+        with data.bindings as openapi_test_bindings
+        with data.tokens as openapi_test_tokens
+}
+
+test_openapi_env_is_allowed_with_project_access {
+    openapi_allow_with(sso_claims, openapi_list_envs_input)
+}
+
+test_openapi_env_is_denied_without_project_access {
+    not openapi_allow_with({"uid": "u2", "federated_claims": {"connector_id": "ldap"}}, openapi_list_envs_input)
+}
+
+test_scoped_token_calls_openapi_env_route {
+    openapi_allow_with(token_claims, openapi_list_envs_input)
+    openapi_allow_with(token_claims, openapi_update_images_input)
+}
+
+test_scoped_token_is_denied_without_owner_project_access {
+    not openapi_allow_with({"uid": "u2", "token_id": "t2", "federated_claims": {"connector_id": "system"}}, openapi_list_envs_input)
+}
+
+chatops_test_exemptions := {
+    "public": [],
+    "privileged": [],
+    "registered": [
+        {"method": "POST", "endpoint": "/api/aslan/workflow/v4/workflowtask"},
+        {"method": "POST", "endpoint": "/api/aslan/workflow/v4/workflowtask/approve"}
+    ],
+    "mfa_required": [
+        {"method": "POST", "endpoint": "api/aslan/workflow/v4/workflowtask"},
+        {"method": "POST", "endpoint": "api/aslan/workflow/v4/workflowtask/approve"}
+    ]
+}
+
+chatops_test_roles := {"roles": [{
+    "name": "runner",
+    "namespace": "proj",
+    "rules": [
+        {"method": "POST", "endpoint": "/api/aslan/workflow/v4/workflowtask"},
+        {"method": "POST", "endpoint": "/api/aslan/workflow/v4/workflowtask/approve"}
+    ]
+}]}
+
+chatops_test_bindings := {
+    "role_bindings": [
+        {"uid": "u1", "bindings": [{"namespace": "proj", "role_refs": [{"name": "runner", "namespace": "proj"}]}]}
+    ],
+    "policy_bindings": [],
+    "user_groups": {}
+}
+
+chatops_run_input := {
+    "parsed_path": ["api", "aslan", "workflow", "v4", "workflowtask"],
+    "parsed_query": {"projectName": ["proj"]},
+    "attributes": {"request": {"http": {"method": "POST"}}}
+}
+
+chatops_approve_input := {
+    "parsed_path": ["api", "aslan", "workflow", "v4", "workflowtask", "approve"],
+    "parsed_query": {"projectName": ["proj"]},
+    "attributes": {"request": {"http": {"method": "POST"}}}
+}
+
+chatops_allow_with(c, m, i) {
+    allow with data.rbac.is_authenticated as true
+        with data.rbac.claims as c
+        with data.mfa as m
+        with input as i
+        with data.exemptions as chatops_test_exemptions
+        with data.roles as chatops_test_roles
+        with data.bindings as chatops_test_bindings
+}
+
+# the tokens minted for chatops actions never carry the mfa claim
+test_chatops_run_and_approve_are_allowed_for_users_without_mfa {
+    chatops_allow_with(local_claims, no_mfa_users, chatops_run_input)
+    chatops_allow_with(local_claims, no_mfa_users, chatops_approve_input)
+    chatops_allow_with(sso_claims, enrolled_mfa_users, chatops_run_input)
+    chatops_allow_with(sso_claims, enrolled_mfa_users, chatops_approve_input)
+}
+
+test_chatops_run_and_approve_are_denied_for_users_who_must_use_mfa {
+    not chatops_allow_with(local_claims, enrolled_mfa_users, chatops_run_input)
+    not chatops_allow_with(local_claims, enrolled_mfa_users, chatops_approve_input)
+    not mfa_is_satisfied with data.rbac.claims as local_claims
+        with data.mfa as enrolled_mfa_users
+        with input as chatops_approve_input
+        with data.exemptions as chatops_test_exemptions
+        with data.roles as chatops_test_roles
+        with data.bindings as chatops_test_bindings
+}
+
+test_chatops_run_is_denied_without_project_access {
+    not chatops_allow_with({"uid": "u2", "federated_claims": {"connector_id": "system"}}, no_mfa_users, chatops_run_input)
+}
+
+group_test_bindings := {
+    "role_bindings": [
+        {"gid": "g1", "bindings": [{"namespace": "proj", "role_refs": [{"name": "deployer", "namespace": "proj"}]}]}
+    ],
+    "policy_bindings": [],
+    "user_groups": {"u1": ["g1"], "u2": ["g2"]}
+}
+
+# the bindings of a removed group are deleted together with the group, and the group is gone from user_groups
+removed_group_test_bindings := {
+    "role_bindings": [],
+    "policy_bindings": [],
+    "user_groups": {"u1": []}
+}
+
+group_allow_with(c, b, i) {
+    allow with data.rbac.is_authenticated as true
+        with data.rbac.claims as c
+        with data.mfa as no_mfa_users
+        with input as i
+        with data.exemptions as openapi_test_exemptions
+        with data.roles as openapi_test_roles
+        with data.bindings as b
+        with data.tokens as openapi_test_tokens
+}
+
+test_group_member_gets_the_roles_of_the_group {
+    group_allow_with(sso_claims, group_test_bindings, openapi_list_envs_input)
+    group_allow_with(sso_claims, group_test_bindings, openapi_update_images_input)
+}
+
+test_group_member_sees_the_projects_of_the_group {
+    user_projects["proj"] with data.rbac.claims as sso_claims
+        with data.bindings as group_test_bindings
+}
+
+test_user_out_of_the_group_is_denied {
+    not group_allow_with({"uid": "u2", "federated_claims": {"connector_id": "ldap"}}, group_test_bindings, openapi_list_envs_input)
+}
+
+test_removed_group_grants_nothing {
+    not group_allow_with(sso_claims, removed_group_test_bindings, openapi_list_envs_input)
+}
@@ -30,3 +30,19 @@ type Rule struct {
 
 const SystemScope = "*"
 const PresetScope = ""
+
+// newSubject returns a group subject if gid is set, otherwise a user subject.
+func newSubject(uid, gid string) *models.Subject {
+	if gid != "" {
+		return &models.Subject{Kind: models.GroupKind, UID: gid}
+	}
+	return &models.Subject{Kind: models.UserKind, UID: uid}
+}
+
+// subjectIDs returns the uid or the gid of the subject according to its kind.
+func subjectIDs(subject *models.Subject) (uid, gid string) {
+	if subject.Kind == models.GroupKind {
+		return "", subject.UID
+	}
+	return subject.UID, ""
+}
@@ -26,12 +26,16 @@ import (
 	"github.com/koderover/zadig/pkg/setting"
 )
 
+// PolicyBinding binds a policy to a user, or to a user group if GID is set
 type PolicyBinding struct {
 	Name   string               `json:"name"`
 	UID    string               `json:"uid"`
+	GID    string               `json:"gid,omitempty"`
 	Policy string               `json:"policy"`
 	Preset bool                 `json:"preset"`
 	Type   setting.ResourceType `json:"type"`
+	// ExpireAt is the unix time after which the binding is revoked, 0 means the binding never expires
+	ExpireAt int64 `json:"expire_at,omitempty"`
 }
 
 func CreatePolicyBindings(ns string, rbs []*PolicyBinding, logger *zap.SugaredLogger) error {
@@ -72,12 +76,15 @@ func ListPolicyBindings(ns, uid string, _ *zap.SugaredLogger) ([]*PolicyBinding,
 	}
 
 	for _, v := range modelPolicyBindings {
+		uid, gid := subjectIDs(v.Subjects[0])
 		policyBindings = append(policyBindings, &PolicyBinding{
-			Name:   v.Name,
-			Policy: v.PolicyRef.Name,
-			UID:    v.Subjects[0].UID,
-			Preset: v.PolicyRef.Namespace == "",
-			Type:   v.Type,
+			Name:     v.Name,
+			Policy:   v.PolicyRef.Name,
+			UID:      uid,
+			GID:      gid,
+			Preset:   v.PolicyRef.Namespace == "",
+			Type:     v.Type,
+			ExpireAt: v.ExpireAt,
 		})
 	}
 
@@ -97,11 +104,14 @@ func ListPolicyBindingsByPolicy(ns, policyName string, publicPolicy bool, _ *zap
 	}
 
 	for _, v := range modelPolicyBindings {
+		uid, gid := subjectIDs(v.Subjects[0])
 		policyBindings = append(policyBindings, &PolicyBinding{
-			Name:   v.Name,
-			Policy: v.PolicyRef.Name,
-			UID:    v.Subjects[0].UID,
-			Preset: v.PolicyRef.Namespace == "",
+			Name:     v.Name,
+			Policy:   v.PolicyRef.Name,
+			UID:      uid,
+			GID:      gid,
+			Preset:   v.PolicyRef.Namespace == "",
+			ExpireAt: v.ExpireAt,
 		})
 	}
 
@@ -138,11 +148,12 @@ func createPolicyBindingObject(ns string, rb *PolicyBinding, logger *zap.Sugared
 	return &models.PolicyBinding{
 		Name:      rb.Name,
 		Namespace: ns,
-		Subjects:  []*models.Subject{{Kind: models.UserKind, UID: rb.UID}},
+		Subjects:  []*models.Subject{newSubject(rb.UID, rb.GID)},
 		PolicyRef: &models.PolicyRef{
 			Name:      policy.Name,
 			Namespace: policy.Namespace,
 		},
-		Type: setting.ResourceTypeSystem,
+		Type:     setting.ResourceTypeSystem,
+		ExpireAt: rb.ExpireAt,
 	}, nil
 }
@@ -24,15 +24,20 @@ import (
 	"github.com/koderover/zadig/pkg/config"
 	"github.com/koderover/zadig/pkg/microservice/policy/core/repository/models"
 	"github.com/koderover/zadig/pkg/microservice/policy/core/repository/mongodb"
+	"github.com/koderover/zadig/pkg/microservice/policy/core/service/bundle"
 	"github.com/koderover/zadig/pkg/setting"
 )
 
+// RoleBinding binds a role to a user, or to a user group if GID is set
 type RoleBinding struct {
 	Name   string               `json:"name"`
 	UID    string               `json:"uid"`
+	GID    string               `json:"gid,omitempty"`
 	Role   string               `json:"role"`
 	Preset bool                 `json:"preset"`
 	Type   setting.ResourceType `json:"type"`
+	// ExpireAt is the unix time after which the binding is revoked, 0 means the binding never expires
+	ExpireAt int64 `json:"expire_at,omitempty"`
 }
 
 func CreateRoleBindings(ns string, rbs []*RoleBinding, logger *zap.SugaredLogger) error {
@@ -88,11 +93,14 @@ func ListRoleBindings(ns, uid string, _ *zap.SugaredLogger) ([]*RoleBinding, err
 	}
 
 	for _, v := range modelRoleBindings {
+		uid, gid := subjectIDs(v.Subjects[0])
 		roleBindings = append(roleBindings, &RoleBinding{
-			Name:   v.Name,
-			Role:   v.RoleRef.Name,
-			UID:    v.Subjects[0].UID,
-			Preset: v.RoleRef.Namespace == "",
+			Name:     v.Name,
+			Role:     v.RoleRef.Name,
+			UID:      uid,
+			GID:      gid,
+			Preset:   v.RoleRef.Namespace == "",
+			ExpireAt: v.ExpireAt,
 		})
 	}
 
@@ -107,11 +115,14 @@ func SearchSystemRoleBindings(uids []string, _ *zap.SugaredLogger) (map[string][
 	}
 
 	for _, v := range modelRoleBindings {
+		uid, gid := subjectIDs(v.Subjects[0])
 		roleBindings = append(roleBindings, &RoleBinding{
-			Name:   v.Name,
-			Role:   v.RoleRef.Name,
-			UID:    v.Subjects[0].UID,
-			Preset: v.RoleRef.Namespace == "",
+			Name:     v.Name,
+			Role:     v.RoleRef.Name,
+			UID:      uid,
+			GID:      gid,
+			Preset:   v.RoleRef.Namespace == "",
+			ExpireAt: v.ExpireAt,
 		})
 	}
 	resMap := make(map[string][]*RoleBinding)
@@ -135,6 +146,24 @@ func DeleteRoleBindings(names []string, projectName string, userID string, _ *za
 	return mongodb.NewRoleBindingColl().DeleteMany(names, projectName, userID)
 }
 
+// DeleteGroupBindings deletes the role bindings and the policy bindings of the user group in all projects,
+// it is called when the group is removed.
+func DeleteGroupBindings(gid string, logger *zap.SugaredLogger) error {
+	if gid == "" {
+		return fmt.Errorf("group id is empty")
+	}
+	if err := mongodb.NewRoleBindingColl().DeleteByGroup(gid); err != nil {
+		logger.Errorf("Failed to delete role bindings of group %s, err: %s", gid, err)
+		return err
+	}
+	if err := mongodb.NewPolicyBindingColl().DeleteByGroup(gid); err != nil {
+		logger.Errorf("Failed to delete policy bindings of group %s, err: %s", gid, err)
+		return err
+	}
+	bundle.RefreshOPABundle()
+	return nil
+}
+
 func createRoleBindingObject(ns string, rb *RoleBinding, logger *zap.SugaredLogger) (*models.RoleBinding, error) {
 	nsRole := ns
 	if rb.Preset {
@@ -154,11 +183,12 @@ func createRoleBindingObject(ns string, rb *RoleBinding, logger *zap.SugaredLogg
 	return &models.RoleBinding{
 		Name:      rb.Name,
 		Namespace: ns,
-		Subjects:  []*models.Subject{{Kind: models.UserKind, UID: rb.UID}},
+		Subjects:  []*models.Subject{newSubject(rb.UID, rb.GID)},
 		RoleRef: &models.RoleRef{
 			Name:      role.Name,
 			Namespace: role.Namespace,
 		},
+		ExpireAt: rb.ExpireAt,
 	}, nil
 }
 
@@ -172,7 +202,11 @@ func ensureRoleBindingName(ns string, rb *RoleBinding) {
 		nsRole = ""
 	}
 
-	rb.Name = config.RoleBindingNameFromUIDAndRole(rb.UID, setting.RoleType(rb.Role), nsRole)
+	subjectID := rb.UID
+	if rb.GID != "" {
+		subjectID = rb.GID
+	}
+	rb.Name = config.RoleBindingNameFromUIDAndRole(subjectID, setting.RoleType(rb.Role), nsRole)
 }
 
 func ListUserAllRoleBindings(projectName, uid string) ([]*models.RoleBinding, error) {
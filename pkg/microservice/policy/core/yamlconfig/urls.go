@@ -40,6 +40,7 @@ type ExemptionURLs struct {
 	Public       []*types.PolicyRule `json:"public"`
 	SystemAdmin  []*types.PolicyRule `json:"system_admin"`
 	ProjectAdmin []*types.PolicyRule `json:"project_admin"`
+	MFARequired  []*types.PolicyRule `json:"mfa_required"`
 }
 
 func init() {
@@ -34,6 +34,12 @@ const (
 	// ProviderGiteeEE
 	ProviderGiteeEE = "gitee-enterprise"
 
+	// ProviderBitbucket
+	ProviderBitbucket = "bitbucket"
+
+	// ProviderGitea
+	ProviderGitea = "gitea"
+
 	// ProviderOther
 	ProviderOther = "other"
 
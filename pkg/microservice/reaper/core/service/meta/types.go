@@ -297,7 +297,7 @@ func (r *Repo) PRRef() string {
 	} else if strings.ToLower(r.Source) == ProviderGerrit {
 		return r.CheckoutRef
 	}
-	return fmt.Sprintf("refs/pull/%d/head", r.PR)
+	return r.PRRefByPRID(r.PR)
 }
 
 func (r *Repo) PRRefByPRID(pr int) string {
@@ -305,6 +305,11 @@ func (r *Repo) PRRefByPRID(pr int) string {
 		return fmt.Sprintf("merge-requests/%d/head", pr)
 	} else if strings.ToLower(r.Source) == ProviderGerrit {
 		return r.CheckoutRef
+	} else if strings.ToLower(r.Source) == ProviderBitbucket {
+		if r.CheckoutRef != "" {
+			return r.CheckoutRef
+		}
+		return fmt.Sprintf("refs/pull-requests/%d/from", pr)
 	}
 	return fmt.Sprintf("refs/pull/%d/head", pr)
 }
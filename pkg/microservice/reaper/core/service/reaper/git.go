@@ -32,6 +32,8 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/reaper/config"
 	c "github.com/koderover/zadig/pkg/microservice/reaper/core/service/cmd"
 	"github.com/koderover/zadig/pkg/microservice/reaper/core/service/meta"
+	"github.com/koderover/zadig/pkg/tool/bitbucket"
+	"github.com/koderover/zadig/pkg/tool/gitea"
 	"github.com/koderover/zadig/pkg/tool/log"
 	"github.com/koderover/zadig/pkg/types"
 )
@@ -225,6 +227,10 @@ func (r *Reaper) buildGitCommands(repo *meta.Repo, hostNames sets.String) []*c.C
 		})
 	} else if repo.Source == meta.ProviderGitee || repo.Source == meta.ProviderGiteeEE {
 		cmds = append(cmds, &c.Command{Cmd: c.RemoteAdd(repo.RemoteName, r.Ctx.Git.HTTPSCloneURL(repo.Source, repo.OauthToken, repo.Owner, repo.Name, repo.Address)), DisableTrace: true})
+	} else if repo.Source == meta.ProviderBitbucket {
+		cmds = append(cmds, &c.Command{Cmd: c.RemoteAdd(repo.RemoteName, bitbucket.CloneURL(repo.Address, repo.User, repo.OauthToken, owner, repo.Name)), DisableTrace: true})
+	} else if repo.Source == meta.ProviderGitea {
+		cmds = append(cmds, &c.Command{Cmd: c.RemoteAdd(repo.RemoteName, gitea.CloneURL(repo.Address, repo.OauthToken, owner, repo.Name)), DisableTrace: true})
 	} else if repo.Source == meta.ProviderOther {
 		if repo.AuthType == types.SSHAuthType {
 			host := getHost(repo.Address)
@@ -172,7 +172,8 @@ func (c *CodehostColl) UpdateCodeHost(host *models.CodeHost) (*models.CodeHost,
 	}
 	if host.Type == setting.SourceFromGerrit {
 		modifyValue["access_token"] = host.AccessToken
-	} else if host.Type == setting.SourceFromGitee || host.Type == setting.SourceFromGitlab || host.Type == setting.SourceFromGiteeEE {
+	} else if host.Type == setting.SourceFromGitee || host.Type == setting.SourceFromGitlab || host.Type == setting.SourceFromGiteeEE ||
+		host.Type == setting.SourceFromBitbucket || host.Type == setting.SourceFromGitea {
 		modifyValue["access_token"] = host.AccessToken
 		modifyValue["refresh_token"] = host.RefreshToken
 		modifyValue["updated_at"] = host.UpdatedAt
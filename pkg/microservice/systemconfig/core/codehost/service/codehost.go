@@ -34,7 +34,9 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/systemconfig/core/codehost/repository/mongodb"
 	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/shared/client/aslan"
+	"github.com/koderover/zadig/pkg/tool/bitbucket"
 	"github.com/koderover/zadig/pkg/tool/crypto"
+	"github.com/koderover/zadig/pkg/tool/gitea"
 )
 
 const callback = "/api/directory/codehosts/callback"
@@ -47,6 +49,11 @@ func CreateCodeHost(codehost *models.CodeHost, _ *zap.SugaredLogger) (*models.Co
 		codehost.IsReady = "2"
 		codehost.AccessToken = base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", codehost.Username, codehost.Password)))
 	}
+	// bitbucket app passwords and http access tokens are used directly, only oauth needs authorization
+	if codehost.Type == setting.SourceFromBitbucket && codehost.Password != "" {
+		codehost.IsReady = "2"
+		codehost.AccessToken = codehost.Password
+	}
 
 	if codehost.Alias != "" {
 		if _, err := mongodb.NewCodehostColl().GetCodeHostByAlias(codehost.Alias); err == nil {
@@ -137,6 +144,9 @@ func UpdateCodeHost(host *models.CodeHost, _ *zap.SugaredLogger) (*models.CodeHo
 	if host.Type == setting.SourceFromGerrit {
 		host.AccessToken = base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", host.Username, host.Password)))
 	}
+	if host.Type == setting.SourceFromBitbucket && host.Password != "" {
+		host.AccessToken = host.Password
+	}
 
 	var oldAlias string
 	oldCodeHost, err := mongodb.NewCodehostColl().GetCodeHostByID(host.ID, false)
@@ -261,6 +271,24 @@ func newOAuth(provider, callbackURL, clientID, clientSecret, address string) (*o
 			AuthURL:  address + "/oauth/authorize",
 			TokenURL: address + "/oauth/token",
 		}), nil
+	case setting.SourceFromBitbucket:
+		// scopes of bitbucket cloud are configured on the oauth consumer
+		var scopes []string
+		if !bitbucket.IsCloud(address) {
+			scopes = []string{"REPO_ADMIN"}
+		}
+		authURL, tokenURL := bitbucket.OAuthEndpoint(address)
+		return oauth.New(callbackURL, clientID, clientSecret, scopes, oauth2.Endpoint{
+			AuthURL:  authURL,
+			TokenURL: tokenURL,
+		}), nil
+	case setting.SourceFromGitea:
+		// gitea oauth2 applications are granted full access of the authorizing user
+		authURL, tokenURL := gitea.OAuthEndpoint(address)
+		return oauth.New(callbackURL, clientID, clientSecret, nil, oauth2.Endpoint{
+			AuthURL:  authURL,
+			TokenURL: tokenURL,
+		}), nil
 	}
 	return nil, errors.New("illegal provider")
 }
@@ -17,6 +17,8 @@ limitations under the License.
 package handler
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 
 	"github.com/koderover/zadig/pkg/microservice/systemconfig/core/connector/service"
@@ -83,3 +85,16 @@ func UpdateConnector(c *gin.Context) {
 
 	ctx.Err = service.UpdateConnector(args, ctx.Logger)
 }
+
+func GetSAMLMetadata(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+
+	metadata, err := service.GetSAMLMetadata(c.Param("id"), ctx.Logger)
+	if err != nil {
+		ctx.Err = err
+		return
+	}
+
+	c.Data(http.StatusOK, "application/samlmetadata+xml", metadata)
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/koderover/zadig/pkg/microservice/systemconfig/core/repository/orm"
 	"github.com/koderover/zadig/pkg/shared/client/aslan"
 	"github.com/koderover/zadig/pkg/tool/crypto"
+	e "github.com/koderover/zadig/pkg/tool/errors"
 )
 
 func ListConnectorsInternal(logger *zap.SugaredLogger) ([]*Connector, error) {
@@ -142,6 +143,10 @@ func DeleteConnector(id string, _ *zap.SugaredLogger) error {
 }
 
 func CreateConnector(ct *Connector, logger *zap.SugaredLogger) error {
+	if err := validateConnectorConfig(ct); err != nil {
+		return e.ErrInvalidParam.AddDesc(err.Error())
+	}
+
 	cf, err := json.Marshal(ct.Config)
 	if err != nil {
 		logger.Errorf("Failed to marshal config, err: %s", err)
@@ -159,6 +164,10 @@ func CreateConnector(ct *Connector, logger *zap.SugaredLogger) error {
 }
 
 func UpdateConnector(ct *Connector, logger *zap.SugaredLogger) error {
+	if err := validateConnectorConfig(ct); err != nil {
+		return e.ErrInvalidParam.AddDesc(err.Error())
+	}
+
 	cf, err := json.Marshal(ct.Config)
 	if err != nil {
 		logger.Errorf("Failed to marshal config, err: %s", err)
@@ -174,3 +183,11 @@ func UpdateConnector(ct *Connector, logger *zap.SugaredLogger) error {
 
 	return orm.NewConnectorColl().Update(obj)
 }
+
+func validateConnectorConfig(ct *Connector) error {
+	switch cf := ct.Config.(type) {
+	case *SAMLConfig:
+		return cf.Validate()
+	}
+	return nil
+}
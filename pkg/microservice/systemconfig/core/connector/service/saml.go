@@ -0,0 +1,205 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/config"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+const (
+	samlBindingPOST         = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	samlProtocol            = "urn:oasis:names:tc:SAML:2.0:protocol"
+	samlMetadataNamespace   = "urn:oasis:names:tc:SAML:2.0:metadata"
+	samlNameIDFormatPrefix  = "urn:oasis:names:tc:SAML:"
+	samlNameIDFormatDefault = "urn:oasis:names:tc:SAML:2.0:nameid-format:persistent"
+)
+
+// SAMLConfig is the config of the dex saml connector, the json keys must be kept the same as dex.
+// The assertions are validated by dex with the CA, so the signature validation can't be skipped. The CA is
+// accepted as caData only, the ca field of dex is left out since it reads a file on the host of dex.
+type SAMLConfig struct {
+	EntityIssuer string `json:"entityIssuer"`
+	SSOIssuer    string `json:"ssoIssuer"`
+	SSOURL       string `json:"ssoURL"`
+
+	CAData []byte `json:"caData"`
+
+	InsecureSkipSignatureValidation bool `json:"insecureSkipSignatureValidation"`
+
+	UsernameAttr  string   `json:"usernameAttr"`
+	EmailAttr     string   `json:"emailAttr"`
+	GroupsAttr    string   `json:"groupsAttr"`
+	GroupsDelim   string   `json:"groupsDelim"`
+	AllowedGroups []string `json:"allowedGroups"`
+	FilterGroups  bool     `json:"filterGroups"`
+	RedirectURI   string   `json:"redirectURI"`
+
+	NameIDPolicyFormat string `json:"nameIDPolicyFormat"`
+}
+
+// Validate checks the required fields and the CA, and fills the SP urls with the defaults of this system
+func (c *SAMLConfig) Validate() error {
+	if c.SSOURL == "" {
+		return fmt.Errorf("ssoURL can not be empty")
+	}
+	if c.UsernameAttr == "" || c.EmailAttr == "" {
+		return fmt.Errorf("usernameAttr and emailAttr can not be empty")
+	}
+	if c.InsecureSkipSignatureValidation {
+		return fmt.Errorf("the signature of the assertions must be validated")
+	}
+
+	if len(c.CAData) == 0 {
+		return fmt.Errorf("caData is required to validate the signature of the assertions")
+	}
+	if err := validateCertificates(c.CAData); err != nil {
+		return err
+	}
+
+	if c.RedirectURI == "" {
+		c.RedirectURI = config.SystemAddress() + "/dex/callback"
+	}
+	if c.EntityIssuer == "" {
+		c.EntityIssuer = c.RedirectURI
+	}
+	return nil
+}
+
+type spEntityDescriptor struct {
+	XMLName         xml.Name        `xml:"md:EntityDescriptor"`
+	Namespace       string          `xml:"xmlns:md,attr"`
+	EntityID        string          `xml:"entityID,attr"`
+	SPSSODescriptor spSSODescriptor `xml:"md:SPSSODescriptor"`
+}
+
+type spSSODescriptor struct {
+	AuthnRequestsSigned        bool                       `xml:"AuthnRequestsSigned,attr"`
+	WantAssertionsSigned       bool                       `xml:"WantAssertionsSigned,attr"`
+	ProtocolSupportEnumeration string                     `xml:"protocolSupportEnumeration,attr"`
+	NameIDFormat               string                     `xml:"md:NameIDFormat"`
+	AssertionConsumerService   spAssertionConsumerService `xml:"md:AssertionConsumerService"`
+}
+
+type spAssertionConsumerService struct {
+	Binding  string `xml:"Binding,attr"`
+	Location string `xml:"Location,attr"`
+	Index    int    `xml:"index,attr"`
+}
+
+// GetSAMLMetadata generates the service provider metadata of the saml connector which can be imported into the IdP
+func GetSAMLMetadata(id string, logger *zap.SugaredLogger) ([]byte, error) {
+	c, err := GetConnector(id, logger)
+	if err != nil {
+		return nil, err
+	}
+	if c.Type != TypeSAML {
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("connector %s is not a saml connector", id))
+	}
+	cf := &SAMLConfig{}
+	if err = convertConfig(c.Config, cf); err != nil {
+		logger.Errorf("Failed to convert saml config of connector %s, err: %s", id, err)
+		return nil, err
+	}
+	if cf.RedirectURI == "" {
+		cf.RedirectURI = config.SystemAddress() + "/dex/callback"
+	}
+	if cf.EntityIssuer == "" {
+		cf.EntityIssuer = cf.RedirectURI
+	}
+
+	metadata := &spEntityDescriptor{
+		Namespace: samlMetadataNamespace,
+		EntityID:  cf.EntityIssuer,
+		SPSSODescriptor: spSSODescriptor{
+			WantAssertionsSigned:       true,
+			ProtocolSupportEnumeration: samlProtocol,
+			NameIDFormat:               nameIDFormat(cf.NameIDPolicyFormat),
+			AssertionConsumerService: spAssertionConsumerService{
+				Binding:  samlBindingPOST,
+				Location: cf.RedirectURI,
+			},
+		},
+	}
+	b, err := xml.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+// nameIDFormat resolves the abbreviated format in the same way as dex, e.g. emailAddress
+func nameIDFormat(format string) string {
+	if format == "" {
+		return samlNameIDFormatDefault
+	}
+	for _, f := range []string{
+		"urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress",
+		"urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified",
+		"urn:oasis:names:tc:SAML:1.1:nameid-format:X509SubjectName",
+		"urn:oasis:names:tc:SAML:1.1:nameid-format:WindowsDomainQualifiedName",
+		"urn:oasis:names:tc:SAML:2.0:nameid-format:encrypted",
+		"urn:oasis:names:tc:SAML:2.0:nameid-format:entity",
+		"urn:oasis:names:tc:SAML:2.0:nameid-format:kerberos",
+		"urn:oasis:names:tc:SAML:2.0:nameid-format:persistent",
+		"urn:oasis:names:tc:SAML:2.0:nameid-format:transient",
+	} {
+		if f == format || strings.HasSuffix(f, ":"+format) {
+			return f
+		}
+	}
+	if strings.HasPrefix(format, samlNameIDFormatPrefix) {
+		return format
+	}
+	return samlNameIDFormatDefault
+}
+
+func convertConfig(in interface{}, out interface{}) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+func validateCertificates(data []byte) error {
+	found := false
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("failed to parse ca: %s", err)
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("no certificate found in ca")
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func newTestCertificate(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %s", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestNameIDFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{format: "", want: samlNameIDFormatDefault},
+		{format: "emailAddress", want: "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"},
+		{format: "transient", want: "urn:oasis:names:tc:SAML:2.0:nameid-format:transient"},
+		{format: "persistent", want: "urn:oasis:names:tc:SAML:2.0:nameid-format:persistent"},
+		{format: "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified", want: "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"},
+		{format: "urn:oasis:names:tc:SAML:2.0:nameid-format:custom", want: "urn:oasis:names:tc:SAML:2.0:nameid-format:custom"},
+		{format: "unknown", want: samlNameIDFormatDefault},
+		{format: "nameid-format:emailAddress", want: "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"},
+	}
+	for _, tt := range tests {
+		if got := nameIDFormat(tt.format); got != tt.want {
+			t.Errorf("nameIDFormat(%q) = %s, want %s", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestValidateCertificates(t *testing.T) {
+	cert := newTestCertificate(t)
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{name: "one certificate", data: cert},
+		{name: "certificate chain", data: append(append([]byte{}, cert...), newTestCertificate(t)...)},
+		{name: "empty", data: nil, wantErr: true},
+		{name: "not pem", data: []byte("not a certificate"), wantErr: true},
+		{name: "invalid certificate", data: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("invalid")}), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateCertificates(tt.data); (err != nil) != tt.wantErr {
+				t.Errorf("validateCertificates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSAMLConfigValidate(t *testing.T) {
+	cert := newTestCertificate(t)
+	valid := func() *SAMLConfig {
+		return &SAMLConfig{SSOURL: "https://idp/sso", UsernameAttr: "name", EmailAttr: "email", CAData: cert}
+	}
+
+	c := valid()
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if c.EntityIssuer != c.RedirectURI {
+		t.Errorf("entityIssuer should default to the redirect uri %s, got %s", c.RedirectURI, c.EntityIssuer)
+	}
+
+	c = valid()
+	c.CAData = nil
+	if err := c.Validate(); err == nil {
+		t.Errorf("Validate() should require caData")
+	}
+
+	c = valid()
+	c.InsecureSkipSignatureValidation = true
+	if err := c.Validate(); err == nil {
+		t.Errorf("Validate() should not skip the signature validation")
+	}
+
+	// the ca file is not accepted, it's dropped when the config is decoded
+	c = &SAMLConfig{}
+	if err := json.Unmarshal([]byte(`{"ssoURL":"https://idp/sso","usernameAttr":"name","emailAttr":"email","ca":"/etc/passwd"}`), c); err != nil {
+		t.Fatalf("failed to decode config: %s", err)
+	}
+	if err := c.Validate(); err == nil {
+		t.Errorf("Validate() should not read the ca file")
+	}
+	b, _ := json.Marshal(c)
+	var saved map[string]interface{}
+	_ = json.Unmarshal(b, &saved)
+	if _, ok := saved["ca"]; ok {
+		t.Errorf("the ca file should not be saved, got %s", b)
+	}
+}
@@ -40,6 +40,7 @@ const (
 	TypeGoogle    ConnectorType = "google"
 	TypeLinkedIn  ConnectorType = "linkedin"
 	TypeMicrosoft ConnectorType = "microsoft"
+	TypeSAML      ConnectorType = "saml"
 )
 
 type Connector struct {
@@ -77,6 +78,8 @@ func (c *Connector) UnmarshalJSON(data []byte) error {
 		c.Config = &linkedin.Config{}
 	case TypeMicrosoft:
 		c.Config = &microsoft.Config{}
+	case TypeSAML:
+		c.Config = &SAMLConfig{}
 	}
 
 	type tmp Connector
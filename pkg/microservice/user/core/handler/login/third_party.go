@@ -151,7 +151,7 @@ func Callback(c *gin.Context) {
 		return
 	}
 
-	user, err := user.SyncUser(&user.SyncUserInfo{
+	syncedUser, err := user.SyncUser(&user.SyncUserInfo{
 		Account:      claims.PreferredUsername,
 		Name:         claims.Name,
 		Email:        claims.Email,
@@ -161,7 +161,15 @@ func Callback(c *gin.Context) {
 		ctx.Err = err
 		return
 	}
-	claims.UID = user.UID
+	// groups claim is only present when the connector supports it and the "groups" scope is requested
+	if claims.Groups != nil {
+		if err := user.SyncUserGroupsOfUser(syncedUser.UID, claims.FederatedClaims.ConnectorId, claims.Groups, ctx.Logger); err != nil {
+			ctx.Logger.Errorf("failed to sync groups of user %s, err: %s", syncedUser.UID, err)
+		}
+		// memberships are kept in the user service, no need to carry them in the token
+		claims.Groups = nil
+	}
+	claims.UID = syncedUser.UID
 	claims.StandardClaims.ExpiresAt = time.Now().Add(time.Duration(config.TokenExpiresAt()) * time.Minute).Unix()
 	userToken, err := login.CreateToken(claims)
 	if err != nil {
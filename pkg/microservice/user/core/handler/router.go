@@ -47,6 +47,10 @@ func (*Router) Inject(router *gin.RouterGroup) {
 		users.GET("/users/:uid/personal", user.GetPersonalUser)
 
 		users.GET("/users/:uid/setting", user.GetUserSetting)
+		users.GET("/users/:uid/groups", user.ListGroupsOfUser)
+		users.GET("/users/:uid/api-tokens", user.ListUserAPITokens)
+		users.DELETE("/users/:uid/api-tokens/:id", user.RevokeUserAPIToken)
+		users.DELETE("/users/:uid/mfa", login.ResetUserMFA)
 
 		users.POST("/users/search", user.ListUsers)
 
@@ -54,12 +58,39 @@ func (*Router) Inject(router *gin.RouterGroup) {
 
 		users.GET("/user/count", user.CountSystemUsers)
 
+		users.POST("/user-groups", user.CreateUserGroup)
+		users.GET("/user-groups", user.ListUserGroups)
+		users.GET("/user-groups/:id", user.GetUserGroup)
+		users.PUT("/user-groups/:id", user.UpdateUserGroup)
+		users.DELETE("/user-groups/:id", user.DeleteUserGroup)
+		users.POST("/user-groups/:id/members", user.AddUserGroupMembers)
+		users.POST("/user-groups/:id/members/bulk-delete", user.RemoveUserGroupMembers)
+
+		users.POST("/api-tokens", user.CreateAPIToken)
+		users.GET("/api-tokens", user.ListAPITokens)
+		users.GET("/api-tokens/active", user.ListActiveAPITokens)
+		users.POST("/api-tokens/:id/usage", user.RecordAPITokenUsage)
+		users.DELETE("/api-tokens/:id", user.RevokeAPIToken)
+
+		users.GET("/mfa", login.GetMFAStatus)
+		users.POST("/mfa/enroll", login.EnrollMFA)
+		users.POST("/mfa/activate", login.ActivateMFA)
+		users.POST("/mfa/recovery-codes", login.RegenerateRecoveryCodes)
+		users.DELETE("/mfa", login.DisableMFA)
+		users.GET("/mfa/users", login.ListMFAUsers)
+		users.GET("/mfa/enforced-roles", login.ListMFAEnforcedRoles)
+		users.PUT("/mfa/enforced-roles", login.UpdateMFAEnforcedRoles)
+
 		router.GET("login", login.Login)
 
 		router.GET("login-enabled", login.ThirdPartyLoginEnabled)
 
 		router.POST("login", login.LocalLogin)
 
+		router.POST("login/mfa", login.LocalLoginMFA)
+
+		router.POST("login/mfa/enroll", login.EnrollLoginMFA)
+
 		router.POST("signup", user.SignUp)
 
 		router.GET("retrieve", user.Retrieve)
@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/pkg/microservice/user/core/service/user"
+	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+)
+
+func CreateUserGroup(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	args := &user.UserGroupArgs{}
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = err
+		return
+	}
+	ctx.Resp, ctx.Err = user.CreateUserGroup(args, ctx.Logger)
+}
+
+func ListUserGroups(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	ctx.Resp, ctx.Err = user.ListUserGroups(c.Query("name"), ctx.Logger)
+}
+
+func GetUserGroup(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	ctx.Resp, ctx.Err = user.GetUserGroup(c.Param("id"), ctx.Logger)
+}
+
+func UpdateUserGroup(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	args := &user.UserGroupArgs{}
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = err
+		return
+	}
+	ctx.Err = user.UpdateUserGroup(c.Param("id"), args, ctx.Logger)
+}
+
+func DeleteUserGroup(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	ctx.Err = user.DeleteUserGroup(c.Param("id"), ctx.Logger)
+}
+
+func AddUserGroupMembers(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	args := &user.GroupMembersArgs{}
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = err
+		return
+	}
+	ctx.Err = user.AddUserGroupMembers(c.Param("id"), args.UIDs, ctx.Logger)
+}
+
+func RemoveUserGroupMembers(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	args := &user.GroupMembersArgs{}
+	if err := c.ShouldBindJSON(args); err != nil {
+		ctx.Err = err
+		return
+	}
+	ctx.Err = user.RemoveUserGroupMembers(c.Param("id"), args.UIDs, ctx.Logger)
+}
+
+func ListGroupsOfUser(c *gin.Context) {
+	ctx := internalhandler.NewContext(c)
+	defer func() { internalhandler.JSONResponse(c, ctx) }()
+	ctx.Resp, ctx.Err = user.ListUserGroupsByUID(c.Param("uid"), ctx.Logger)
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+// APIToken is a scoped credential of a user for calling the OpenAPI, only the sha256 hash of the token is stored
+type APIToken struct {
+	Model
+	TokenID    string `json:"token_id"`
+	UID        string `json:"uid"`
+	Name       string `json:"name"`
+	Scopes     string `json:"scopes"`
+	TokenHash  string `json:"-"`
+	ExpiresAt  int64  `json:"expires_at"`
+	LastUsedAt int64  `json:"last_used_at"`
+	Revoked    bool   `json:"revoked"`
+}
+
+// TableName sets the insert table name for this struct type
+func (APIToken) TableName() string {
+	return "api_token"
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+// UserMFA is the TOTP enrollment of a user, the secret is encrypted and the recovery codes are hashed
+type UserMFA struct {
+	Model
+	UID           string `json:"uid"`
+	Secret        string `json:"-"`
+	Enabled       bool   `json:"enabled"`
+	RecoveryCodes string `json:"-"`
+}
+
+// TableName sets the insert table name for this struct type
+func (UserMFA) TableName() string {
+	return "user_mfa"
+}
+
+// UserMFAAttempt tracks the failed mfa attempts and the last accepted totp time step of a user
+type UserMFAAttempt struct {
+	Model
+	UID         string `json:"uid"`
+	FailedCount int    `json:"failed_count"`
+	LockedUntil int64  `json:"locked_until"`
+	LastStep    int64  `json:"last_step"`
+}
+
+// TableName sets the insert table name for this struct type
+func (UserMFAAttempt) TableName() string {
+	return "user_mfa_attempt"
+}
+
+// MFAEnforcedRole is a system role whose members must log in with MFA
+type MFAEnforcedRole struct {
+	Model
+	Role string `json:"role"`
+}
+
+// TableName sets the insert table name for this struct type
+func (MFAEnforcedRole) TableName() string {
+	return "mfa_enforced_role"
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orm
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/koderover/zadig/pkg/microservice/user/core/repository/models"
+)
+
+// CreateAPIToken create an api token
+func CreateAPIToken(token *models.APIToken, db *gorm.DB) error {
+	if err := db.Create(token).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetAPIToken Get an api token based on tokenID
+func GetAPIToken(tokenID string, db *gorm.DB) (*models.APIToken, error) {
+	var token models.APIToken
+	err := db.Where("token_id = ?", tokenID).First(&token).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+// GetAPITokenByName Get an api token based on uid and name
+func GetAPITokenByName(uid, name string, db *gorm.DB) (*models.APIToken, error) {
+	var token models.APIToken
+	err := db.Where("uid = ? and name = ?", uid, name).First(&token).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+// ListAPITokensByUID gets the api tokens of the user
+func ListAPITokensByUID(uid string, db *gorm.DB) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	err := db.Where("uid = ?", uid).Order("created_at DESC").Find(&tokens).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// ListActiveAPITokens gets all the api tokens which are neither revoked nor expired
+func ListActiveAPITokens(now int64, db *gorm.DB) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	err := db.Where("revoked = ? and expires_at > ?", false, now).Find(&tokens).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// RevokeAPIToken marks the api token as revoked
+func RevokeAPIToken(tokenID string, db *gorm.DB) error {
+	if err := db.Model(&models.APIToken{}).Where("token_id = ?", tokenID).Update("revoked", true).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateAPITokenLastUsedAt updates the last used time of the api token
+func UpdateAPITokenLastUsedAt(tokenID string, lastUsedAt int64, db *gorm.DB) error {
+	if err := db.Model(&models.APIToken{}).Where("token_id = ?", tokenID).UpdateColumn("last_used_at", lastUsedAt).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteAPITokensByUID Delete all the api tokens of the user
+func DeleteAPITokensByUID(uid string, db *gorm.DB) error {
+	var token models.APIToken
+	if err := db.Where("uid = ?", uid).Delete(&token).Error; err != nil {
+		return err
+	}
+	return nil
+}
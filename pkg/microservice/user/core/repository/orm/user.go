@@ -17,6 +17,8 @@ limitations under the License.
 package orm
 
 import (
+	"fmt"
+
 	"gorm.io/gorm"
 
 	"github.com/koderover/zadig/pkg/microservice/user/core"
@@ -58,6 +60,33 @@ func GetUserByUid(uid string, db *gorm.DB) (*models.User, error) {
 	return &user, nil
 }
 
+// GetUserByEmail Get a user based on email
+// the email is not unique, an error is returned if more than one user has it
+func GetUserByEmail(email string, db *gorm.DB) (*models.User, error) {
+	return getUniqueUser("email", email, db)
+}
+
+// GetUserByPhone Get a user based on phone
+// the phone is not unique, an error is returned if more than one user has it
+func GetUserByPhone(phone string, db *gorm.DB) (*models.User, error) {
+	return getUniqueUser("phone", phone, db)
+}
+
+func getUniqueUser(column, value string, db *gorm.DB) (*models.User, error) {
+	var users []models.User
+	if err := db.Where(column+" = ?", value).Limit(2).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	switch len(users) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &users[0], nil
+	default:
+		return nil, fmt.Errorf("more than one user has the %s %s", column, value)
+	}
+}
+
 // ListUsers gets a list of users based on paging constraints
 func ListUsers(page int, perPage int, name string, db *gorm.DB) ([]models.User, error) {
 	var (
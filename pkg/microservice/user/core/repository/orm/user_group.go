@@ -0,0 +1,151 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orm
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/koderover/zadig/pkg/microservice/user/core/repository/models"
+)
+
+// CreateUserGroup create a user group
+func CreateUserGroup(group *models.UserGroup, db *gorm.DB) error {
+	if err := db.Create(group).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetUserGroup Get a user group based on groupID
+func GetUserGroup(groupID string, db *gorm.DB) (*models.UserGroup, error) {
+	var group models.UserGroup
+	err := db.Where("group_id = ?", groupID).First(&group).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &group, nil
+}
+
+// GetUserGroupByName Get a user group based on name and identityType
+func GetUserGroupByName(name, identityType string, db *gorm.DB) (*models.UserGroup, error) {
+	var group models.UserGroup
+	err := db.Where("name = ? and identity_type = ?", name, identityType).First(&group).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &group, nil
+}
+
+// ListUserGroups gets a list of user groups whose name contains the given name
+func ListUserGroups(name string, db *gorm.DB) ([]models.UserGroup, error) {
+	var groups []models.UserGroup
+	err := db.Where("name LIKE ?", "%"+name+"%").Order("name ASC").Find(&groups).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// ListUserGroupsByIdentityType gets a list of user groups based on identityType
+func ListUserGroupsByIdentityType(identityType string, db *gorm.DB) ([]models.UserGroup, error) {
+	var groups []models.UserGroup
+	err := db.Find(&groups, "identity_type = ?", identityType).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// UpdateUserGroup update user group info
+func UpdateUserGroup(groupID string, group *models.UserGroup, db *gorm.DB) error {
+	if err := db.Model(&models.UserGroup{}).Where("group_id = ?", groupID).Updates(group).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteUserGroup Delete a user group based on groupID
+func DeleteUserGroup(groupID string, db *gorm.DB) error {
+	var group models.UserGroup
+	if err := db.Where("group_id = ?", groupID).Delete(&group).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// CreateGroupBindings add users to user groups
+func CreateGroupBindings(bindings []*models.GroupBinding, db *gorm.DB) error {
+	if len(bindings) == 0 {
+		return nil
+	}
+	if err := db.Create(&bindings).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListGroupBindings gets the members of the given user groups, all the memberships are returned if groupIDs is empty
+func ListGroupBindings(groupIDs []string, db *gorm.DB) ([]models.GroupBinding, error) {
+	var bindings []models.GroupBinding
+	query := db
+	if len(groupIDs) > 0 {
+		query = query.Where("group_id in ?", groupIDs)
+	}
+	err := query.Find(&bindings).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return bindings, nil
+}
+
+// ListGroupBindingsByUID gets the user groups which the user belongs to
+func ListGroupBindingsByUID(uid string, db *gorm.DB) ([]models.GroupBinding, error) {
+	var bindings []models.GroupBinding
+	err := db.Find(&bindings, "uid = ?", uid).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return bindings, nil
+}
+
+// DeleteGroupBindings removes users from the user group, all the members are removed if uids is empty
+func DeleteGroupBindings(groupID string, uids []string, db *gorm.DB) error {
+	var binding models.GroupBinding
+	query := db.Where("group_id = ?", groupID)
+	if len(uids) > 0 {
+		query = query.Where("uid in ?", uids)
+	}
+	if err := query.Delete(&binding).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteGroupBindingsByUID removes the user from all the user groups
+func DeleteGroupBindingsByUID(uid string, db *gorm.DB) error {
+	var binding models.GroupBinding
+	if err := db.Where("uid = ?", uid).Delete(&binding).Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/koderover/zadig/pkg/microservice/user/core/repository/models"
+)
+
+// sqlRecorder records the statements which are built by gorm in the dry run mode.
+type sqlRecorder struct {
+	logger.Interface
+	statements []string
+}
+
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+func newDryRunDB(t *testing.T) (*gorm.DB, *sqlRecorder) {
+	recorder := &sqlRecorder{Interface: logger.Discard}
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:password@tcp(127.0.0.1:3306)/user",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 recorder,
+	})
+	if err != nil {
+		t.Fatalf("failed to open the dry run db: %s", err)
+	}
+	return db, recorder
+}
+
+func TestUserGroupStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(db *gorm.DB) error
+		want string
+	}{
+		{
+			name: "get a user group",
+			run: func(db *gorm.DB) error {
+				_, err := GetUserGroup("g1", db)
+				return err
+			},
+			want: "SELECT * FROM `user_group` WHERE group_id = 'g1' ORDER BY `user_group`.`created_at` LIMIT 1",
+		},
+		{
+			name: "list the user groups of an identity provider",
+			run: func(db *gorm.DB) error {
+				_, err := ListUserGroupsByIdentityType("oidc", db)
+				return err
+			},
+			want: "SELECT * FROM `user_group` WHERE identity_type = 'oidc'",
+		},
+		{
+			name: "delete a user group",
+			run: func(db *gorm.DB) error {
+				return DeleteUserGroup("g1", db)
+			},
+			want: "DELETE FROM `user_group` WHERE group_id = 'g1'",
+		},
+		{
+			name: "list the members of the given user groups",
+			run: func(db *gorm.DB) error {
+				_, err := ListGroupBindings([]string{"g1", "g2"}, db)
+				return err
+			},
+			want: "SELECT * FROM `group_binding` WHERE group_id in ('g1','g2')",
+		},
+		{
+			name: "list the members of all the user groups",
+			run: func(db *gorm.DB) error {
+				_, err := ListGroupBindings(nil, db)
+				return err
+			},
+			want: "SELECT * FROM `group_binding`",
+		},
+		{
+			name: "remove the given members from a user group",
+			run: func(db *gorm.DB) error {
+				return DeleteGroupBindings("g1", []string{"u1", "u2"}, db)
+			},
+			want: "DELETE FROM `group_binding` WHERE group_id = 'g1' AND uid in ('u1','u2')",
+		},
+		{
+			name: "remove all the members from a user group",
+			run: func(db *gorm.DB) error {
+				return DeleteGroupBindings("g1", nil, db)
+			},
+			want: "DELETE FROM `group_binding` WHERE group_id = 'g1'",
+		},
+		{
+			name: "remove a user from all the user groups",
+			run: func(db *gorm.DB) error {
+				return DeleteGroupBindingsByUID("u1", db)
+			},
+			want: "DELETE FROM `group_binding` WHERE uid = 'u1'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, recorder := newDryRunDB(t)
+			assert.NoError(t, tt.run(db))
+			assert.Equal(t, []string{tt.want}, recorder.statements)
+		})
+	}
+}
+
+func TestCreateGroupBindings(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+	assert.NoError(t, CreateGroupBindings(nil, db))
+	assert.Empty(t, recorder.statements)
+
+	bindings := []*models.GroupBinding{{GroupID: "g1", UID: "u1"}, {GroupID: "g1", UID: "u2"}}
+	assert.NoError(t, CreateGroupBindings(bindings, db))
+	assert.Len(t, recorder.statements, 1)
+	assert.Contains(t, recorder.statements[0], "INSERT INTO `group_binding`")
+	assert.Contains(t, recorder.statements[0], "'g1','u1'")
+	assert.Contains(t, recorder.statements[0], "'g1','u2'")
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orm
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/koderover/zadig/pkg/microservice/user/core/repository/models"
+)
+
+// GetUserMFA Get the mfa enrollment of the user
+func GetUserMFA(uid string, db *gorm.DB) (*models.UserMFA, error) {
+	var mfa models.UserMFA
+	err := db.Where("uid = ?", uid).First(&mfa).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &mfa, nil
+}
+
+// ListEnabledUserMFAs gets the mfa enrollments which are activated
+func ListEnabledUserMFAs(db *gorm.DB) ([]models.UserMFA, error) {
+	var mfas []models.UserMFA
+	err := db.Where("enabled = ?", true).Find(&mfas).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return mfas, nil
+}
+
+// UpsertUserMFA creates or replaces the mfa enrollment of the user
+func UpsertUserMFA(mfa *models.UserMFA, db *gorm.DB) error {
+	if err := DeleteUserMFA(mfa.UID, db); err != nil {
+		return err
+	}
+	if err := db.Create(mfa).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateUserMFA update the mfa enrollment of the user
+func UpdateUserMFA(uid string, mfa *models.UserMFA, db *gorm.DB) error {
+	if err := db.Model(&models.UserMFA{}).Where("uid = ?", uid).Select("enabled", "recovery_codes").Updates(mfa).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteUserMFA Delete the mfa enrollment of the user
+func DeleteUserMFA(uid string, db *gorm.DB) error {
+	var mfa models.UserMFA
+	if err := db.Where("uid = ?", uid).Delete(&mfa).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetUserMFAAttempt Get the mfa attempts of the user
+func GetUserMFAAttempt(uid string, db *gorm.DB) (*models.UserMFAAttempt, error) {
+	var attempt models.UserMFAAttempt
+	err := db.Where("uid = ?", uid).First(&attempt).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &attempt, nil
+}
+
+func ensureUserMFAAttempt(uid string, db *gorm.DB) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserMFAAttempt{UID: uid}).Error
+}
+
+// IncreaseUserMFAFailure counts a failed attempt, the user is locked until lockedUntil once the failures reach maxFailures
+func IncreaseUserMFAFailure(uid string, maxFailures int, lockedUntil int64, db *gorm.DB) error {
+	if err := ensureUserMFAAttempt(uid, db); err != nil {
+		return err
+	}
+	if err := db.Model(&models.UserMFAAttempt{}).Where("uid = ?", uid).
+		Update("failed_count", gorm.Expr("failed_count + 1")).Error; err != nil {
+		return err
+	}
+	return db.Model(&models.UserMFAAttempt{}).Where("uid = ? AND failed_count >= ?", uid, maxFailures).
+		Updates(map[string]interface{}{"failed_count": 0, "locked_until": lockedUntil}).Error
+}
+
+// ClaimUserMFAStep records the accepted totp time step and resets the failures, it returns false if
+// the step is not later than the last accepted one, which means the code has been used
+func ClaimUserMFAStep(uid string, step int64, db *gorm.DB) (bool, error) {
+	if err := ensureUserMFAAttempt(uid, db); err != nil {
+		return false, err
+	}
+	res := db.Model(&models.UserMFAAttempt{}).Where("uid = ? AND last_step < ?", uid, step).
+		Updates(map[string]interface{}{"last_step": step, "failed_count": 0})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// ResetUserMFAFailure resets the failures of the user
+func ResetUserMFAFailure(uid string, db *gorm.DB) error {
+	return db.Model(&models.UserMFAAttempt{}).Where("uid = ?", uid).Update("failed_count", 0).Error
+}
+
+// DeleteUserMFAAttempt Delete the mfa attempts of the user
+func DeleteUserMFAAttempt(uid string, db *gorm.DB) error {
+	var attempt models.UserMFAAttempt
+	if err := db.Where("uid = ?", uid).Delete(&attempt).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListMFAEnforcedRoles gets the system roles which must log in with mfa
+func ListMFAEnforcedRoles(db *gorm.DB) ([]models.MFAEnforcedRole, error) {
+	var roles []models.MFAEnforcedRole
+	err := db.Order("role ASC").Find(&roles).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// ReplaceMFAEnforcedRoles replaces all the system roles which must log in with mfa
+func ReplaceMFAEnforcedRoles(roles []*models.MFAEnforcedRole, db *gorm.DB) error {
+	if err := db.Where("1 = 1").Delete(&models.MFAEnforcedRole{}).Error; err != nil {
+		return err
+	}
+	if len(roles) == 0 {
+		return nil
+	}
+	if err := db.Create(&roles).Error; err != nil {
+		return err
+	}
+	return nil
+}
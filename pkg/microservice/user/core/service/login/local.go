@@ -18,16 +18,13 @@ package login
 
 import (
 	"fmt"
-	"time"
 
-	"github.com/golang-jwt/jwt"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/koderover/zadig/pkg/microservice/user/config"
 	"github.com/koderover/zadig/pkg/microservice/user/core"
 	"github.com/koderover/zadig/pkg/microservice/user/core/repository/orm"
-	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/shared/client/plutusvendor"
 )
 
@@ -44,6 +41,11 @@ type User struct {
 	Name         string `json:"name"`
 	Account      string `json:"account"`
 	IdentityType string `json:"identityType"`
+	// MFARequired means the login is not finished, MFAToken must be sent to the second step with the totp code
+	MFARequired       bool     `json:"mfa_required,omitempty"`
+	MFAEnrollRequired bool     `json:"mfa_enroll_required,omitempty"`
+	MFAToken          string   `json:"mfa_token,omitempty"`
+	RecoveryCodes     []string `json:"recovery_codes,omitempty"`
 }
 
 type CheckSignatureRes struct {
@@ -109,38 +111,35 @@ func LocalLogin(args *LoginArgs, logger *zap.SugaredLogger) (*User, error) {
 	if err != nil {
 		return nil, err
 	}
-	userLogin.LastLoginTime = time.Now().Unix()
-	err = orm.UpdateUserLogin(userLogin.UID, userLogin, core.DB)
+
+	mfa, err := orm.GetUserMFA(user.UID, core.DB)
 	if err != nil {
-		logger.Errorf("LocalLogin user:%s update user login password error, error msg:%s", args.Account, err.Error())
+		logger.Errorf("LocalLogin user:%s get mfa error, error msg:%s", args.Account, err)
 		return nil, err
 	}
-	token, err := CreateToken(&Claims{
-		Name:              user.Name,
-		UID:               user.UID,
-		Email:             user.Email,
-		PreferredUsername: user.Account,
-		StandardClaims: jwt.StandardClaims{
-			Audience:  setting.ProductName,
-			ExpiresAt: time.Now().Add(24 * time.Hour).Unix(),
-		},
-		FederatedClaims: FederatedClaims{
-			ConnectorId: user.IdentityType,
-			UserId:      user.Account,
-		},
-	})
-	if err != nil {
-		logger.Errorf("LocalLogin user:%s create token error, error msg:%s", args.Account, err.Error())
-		return nil, err
+	mfaEnabled := mfa != nil && mfa.Enabled
+	mfaEnforced := false
+	if !mfaEnabled {
+		if mfaEnforced, err = mfaIsEnforced(user.UID, logger); err != nil {
+			return nil, err
+		}
+	}
+	// the password is checked, but the user has to pass the second step with the totp code to get the token
+	if mfaEnabled || mfaEnforced {
+		mfaToken, err := createMFAToken(user.UID)
+		if err != nil {
+			logger.Errorf("LocalLogin user:%s create mfa token error, error msg:%s", args.Account, err)
+			return nil, err
+		}
+		return &User{
+			Uid:               user.UID,
+			Account:           user.Account,
+			IdentityType:      user.IdentityType,
+			MFARequired:       true,
+			MFAEnrollRequired: !mfaEnabled,
+			MFAToken:          mfaToken,
+		}, nil
 	}
 
-	return &User{
-		Uid:          user.UID,
-		Token:        token,
-		Email:        user.Email,
-		Phone:        user.Phone,
-		Name:         user.Name,
-		Account:      user.Account,
-		IdentityType: user.IdentityType,
-	}, nil
+	return issueLoginToken(user, false, logger)
 }
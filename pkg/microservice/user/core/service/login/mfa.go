@@ -0,0 +1,562 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package login
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	configbase "github.com/koderover/zadig/pkg/config"
+	"github.com/koderover/zadig/pkg/microservice/user/config"
+	"github.com/koderover/zadig/pkg/microservice/user/core"
+	"github.com/koderover/zadig/pkg/microservice/user/core/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/user/core/repository/orm"
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/client/policy"
+	"github.com/koderover/zadig/pkg/tool/crypto"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+	"github.com/koderover/zadig/pkg/tool/totp"
+)
+
+const (
+	// mfaTokenAudience is the audience of the short-lived token which is issued after the password is checked,
+	// it carries no uid so it can't be used to call any api other than the second login step
+	mfaTokenAudience = "mfa"
+	mfaTokenTTL      = 5 * time.Minute
+
+	recoveryCodeCount = 10
+	recoveryCodeSize  = 5
+
+	// the user is locked for mfaLockDuration after maxMFAFailures wrong codes in a row
+	maxMFAFailures  = 5
+	mfaLockDuration = 15 * time.Minute
+)
+
+type MFALoginArgs struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type MFAEnrollArgs struct {
+	MFAToken string `json:"mfa_token"`
+}
+
+type MFACodeArgs struct {
+	Code string `json:"code"`
+}
+
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type MFAStatus struct {
+	Enabled  bool `json:"enabled"`
+	Enforced bool `json:"enforced"`
+}
+
+type MFARecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MFAEnforcedRolesArgs struct {
+	Roles []string `json:"roles"`
+}
+
+type MFAUsers struct {
+	UIDs []string `json:"uids"`
+}
+
+// LocalLoginMFA is the second login step, the user is logged in if the totp code or a recovery code is valid.
+// If the user has not activated mfa yet, a valid totp code activates it and the recovery codes are returned.
+func LocalLoginMFA(args *MFALoginArgs, logger *zap.SugaredLogger) (*User, error) {
+	uid, err := parseMFAToken(args.MFAToken)
+	if err != nil {
+		return nil, e.ErrUnauthorized.AddDesc(err.Error())
+	}
+	user, err := orm.GetUserByUid(uid, core.DB)
+	if err != nil {
+		logger.Errorf("LocalLoginMFA GetUserByUid:%s error, error msg:%s", uid, err)
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user not exist")
+	}
+	mfa, err := orm.GetUserMFA(uid, core.DB)
+	if err != nil {
+		logger.Errorf("LocalLoginMFA GetUserMFA:%s error, error msg:%s", uid, err)
+		return nil, err
+	}
+	if mfa == nil {
+		return nil, e.ErrInvalidParam.AddDesc("mfa is not enrolled")
+	}
+
+	var recoveryCodes []string
+	switch {
+	case args.RecoveryCode != "" && mfa.Enabled:
+		if err = useRecoveryCode(mfa, args.RecoveryCode, logger); err != nil {
+			return nil, err
+		}
+	case mfa.Enabled:
+		if err = validateMFACode(mfa, args.Code, logger); err != nil {
+			return nil, err
+		}
+	default:
+		if recoveryCodes, err = activateMFA(mfa, args.Code, logger); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := issueLoginToken(user, true, logger)
+	if err != nil {
+		return nil, err
+	}
+	resp.RecoveryCodes = recoveryCodes
+	return resp, nil
+}
+
+// EnrollLoginMFA generates a totp secret for the user who must enroll mfa before logging in
+func EnrollLoginMFA(args *MFAEnrollArgs, logger *zap.SugaredLogger) (*MFAEnrollment, error) {
+	uid, err := parseMFAToken(args.MFAToken)
+	if err != nil {
+		return nil, e.ErrUnauthorized.AddDesc(err.Error())
+	}
+	return EnrollMFA(uid, logger)
+}
+
+func GetMFAStatus(uid string, logger *zap.SugaredLogger) (*MFAStatus, error) {
+	mfa, err := orm.GetUserMFA(uid, core.DB)
+	if err != nil {
+		logger.Errorf("GetMFAStatus GetUserMFA:%s error, error msg:%s", uid, err)
+		return nil, err
+	}
+	enforced, err := mfaIsEnforced(uid, logger)
+	if err != nil {
+		return nil, err
+	}
+	return &MFAStatus{Enabled: mfa != nil && mfa.Enabled, Enforced: enforced}, nil
+}
+
+// EnrollMFA generates a new totp secret for the user, it takes effect after it is activated by a valid code
+func EnrollMFA(uid string, logger *zap.SugaredLogger) (*MFAEnrollment, error) {
+	user, err := orm.GetUserByUid(uid, core.DB)
+	if err != nil {
+		logger.Errorf("EnrollMFA GetUserByUid:%s error, error msg:%s", uid, err)
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user not exist")
+	}
+	mfa, err := orm.GetUserMFA(uid, core.DB)
+	if err != nil {
+		logger.Errorf("EnrollMFA GetUserMFA:%s error, error msg:%s", uid, err)
+		return nil, err
+	}
+	if mfa != nil && mfa.Enabled {
+		return nil, e.ErrInvalidParam.AddDesc("mfa is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := crypto.AesEncrypt(secret)
+	if err != nil {
+		logger.Errorf("EnrollMFA encrypt secret of user:%s error, error msg:%s", uid, err)
+		return nil, err
+	}
+	if err = orm.UpsertUserMFA(&models.UserMFA{UID: uid, Secret: encrypted}, core.DB); err != nil {
+		logger.Errorf("EnrollMFA UpsertUserMFA:%s error, error msg:%s", uid, err)
+		return nil, err
+	}
+
+	return &MFAEnrollment{Secret: secret, URI: totp.URI(setting.ProductName, user.Account, secret)}, nil
+}
+
+// ActivateMFA enables the enrolled totp secret of the user and returns the recovery codes
+func ActivateMFA(uid, code string, logger *zap.SugaredLogger) (*MFARecoveryCodes, error) {
+	mfa, err := orm.GetUserMFA(uid, core.DB)
+	if err != nil {
+		logger.Errorf("ActivateMFA GetUserMFA:%s error, error msg:%s", uid, err)
+		return nil, err
+	}
+	if mfa == nil {
+		return nil, e.ErrInvalidParam.AddDesc("mfa is not enrolled")
+	}
+	if mfa.Enabled {
+		return nil, e.ErrInvalidParam.AddDesc("mfa is already enabled")
+	}
+	codes, err := activateMFA(mfa, code, logger)
+	if err != nil {
+		return nil, err
+	}
+	return &MFARecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user, the old ones can no longer be used
+func RegenerateRecoveryCodes(uid, code string, logger *zap.SugaredLogger) (*MFARecoveryCodes, error) {
+	mfa, err := getEnabledMFA(uid, logger)
+	if err != nil {
+		return nil, err
+	}
+	if err = validateMFACode(mfa, code, logger); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	mfa.RecoveryCodes = hashes
+	if err = orm.UpdateUserMFA(uid, mfa, core.DB); err != nil {
+		logger.Errorf("RegenerateRecoveryCodes UpdateUserMFA:%s error, error msg:%s", uid, err)
+		return nil, err
+	}
+	return &MFARecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+// DisableMFA disables mfa of the user with a valid code, it is not allowed if mfa is enforced for the user
+func DisableMFA(uid, code string, logger *zap.SugaredLogger) error {
+	mfa, err := getEnabledMFA(uid, logger)
+	if err != nil {
+		return err
+	}
+	if err = validateMFACode(mfa, code, logger); err != nil {
+		return err
+	}
+	enforced, err := mfaIsEnforced(uid, logger)
+	if err != nil {
+		return err
+	}
+	if enforced {
+		return e.ErrForbidden.AddDesc("mfa is enforced for the user")
+	}
+	return ResetMFA(uid, logger)
+}
+
+// ResetMFA removes the mfa enrollment of the user, it is used by admins when a user lost the device and the recovery codes
+func ResetMFA(uid string, logger *zap.SugaredLogger) error {
+	if err := orm.DeleteUserMFA(uid, core.DB); err != nil {
+		logger.Errorf("ResetMFA DeleteUserMFA:%s error, error msg:%s", uid, err)
+		return err
+	}
+	if err := orm.DeleteUserMFAAttempt(uid, core.DB); err != nil {
+		logger.Errorf("ResetMFA DeleteUserMFAAttempt:%s error, error msg:%s", uid, err)
+		return err
+	}
+	return nil
+}
+
+// ListMFAUsers lists the users who activated mfa, the policy requires them to log in with mfa for the sensitive actions
+func ListMFAUsers(logger *zap.SugaredLogger) (*MFAUsers, error) {
+	mfas, err := orm.ListEnabledUserMFAs(core.DB)
+	if err != nil {
+		logger.Errorf("ListMFAUsers ListEnabledUserMFAs error, error msg:%s", err)
+		return nil, err
+	}
+	res := &MFAUsers{UIDs: make([]string, 0, len(mfas))}
+	for _, mfa := range mfas {
+		res.UIDs = append(res.UIDs, mfa.UID)
+	}
+	return res, nil
+}
+
+func ListMFAEnforcedRoles(logger *zap.SugaredLogger) (*MFAEnforcedRolesArgs, error) {
+	roles, err := orm.ListMFAEnforcedRoles(core.DB)
+	if err != nil {
+		logger.Errorf("ListMFAEnforcedRoles error, error msg:%s", err)
+		return nil, err
+	}
+	res := &MFAEnforcedRolesArgs{Roles: make([]string, 0, len(roles))}
+	for _, role := range roles {
+		res.Roles = append(res.Roles, role.Role)
+	}
+	return res, nil
+}
+
+func UpdateMFAEnforcedRoles(args *MFAEnforcedRolesArgs, logger *zap.SugaredLogger) error {
+	var roles []*models.MFAEnforcedRole
+	for _, role := range sets.NewString(args.Roles...).List() {
+		roles = append(roles, &models.MFAEnforcedRole{Role: role})
+	}
+	tx := core.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := orm.ReplaceMFAEnforcedRoles(roles, tx); err != nil {
+		tx.Rollback()
+		logger.Errorf("UpdateMFAEnforcedRoles ReplaceMFAEnforcedRoles error, error msg:%s", err)
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// mfaIsEnforced checks if the user is bound to an enforced system role, directly or by a user group
+func mfaIsEnforced(uid string, logger *zap.SugaredLogger) (bool, error) {
+	roles, err := orm.ListMFAEnforcedRoles(core.DB)
+	if err != nil {
+		logger.Errorf("mfaIsEnforced ListMFAEnforcedRoles error, error msg:%s", err)
+		return false, err
+	}
+	if len(roles) == 0 {
+		return false, nil
+	}
+	enforcedRoles := sets.NewString()
+	for _, role := range roles {
+		enforcedRoles.Insert(role.Role)
+	}
+
+	groupBindings, err := orm.ListGroupBindingsByUID(uid, core.DB)
+	if err != nil {
+		logger.Errorf("mfaIsEnforced ListGroupBindingsByUID:%s error, error msg:%s", uid, err)
+		return false, err
+	}
+	groups := sets.NewString()
+	for _, binding := range groupBindings {
+		groups.Insert(binding.GroupID)
+	}
+
+	roleBindings, err := policy.NewDefault().ListRoleBindings("*")
+	if err != nil {
+		logger.Errorf("mfaIsEnforced ListRoleBindings error, error msg:%s", err)
+		return false, err
+	}
+	for _, rb := range roleBindings {
+		if !enforcedRoles.Has(rb.Role) {
+			continue
+		}
+		if rb.UID == uid || (rb.GID != "" && groups.Has(rb.GID)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func issueLoginToken(user *models.User, mfa bool, logger *zap.SugaredLogger) (*User, error) {
+	userLogin, err := orm.GetUserLogin(user.UID, user.Account, config.AccountLoginType, core.DB)
+	if err != nil {
+		logger.Errorf("issueLoginToken get user:%s user login error, error msg:%s", user.Account, err)
+		return nil, err
+	}
+	if userLogin != nil {
+		userLogin.LastLoginTime = time.Now().Unix()
+		if err = orm.UpdateUserLogin(userLogin.UID, userLogin, core.DB); err != nil {
+			logger.Errorf("issueLoginToken user:%s update user login error, error msg:%s", user.Account, err)
+			return nil, err
+		}
+	}
+	token, err := CreateToken(&Claims{
+		Name:              user.Name,
+		UID:               user.UID,
+		Email:             user.Email,
+		PreferredUsername: user.Account,
+		MFA:               mfa,
+		StandardClaims: jwt.StandardClaims{
+			Audience:  setting.ProductName,
+			ExpiresAt: time.Now().Add(24 * time.Hour).Unix(),
+		},
+		FederatedClaims: FederatedClaims{
+			ConnectorId: user.IdentityType,
+			UserId:      user.Account,
+		},
+	})
+	if err != nil {
+		logger.Errorf("issueLoginToken user:%s create token error, error msg:%s", user.Account, err)
+		return nil, err
+	}
+
+	return &User{
+		Uid:          user.UID,
+		Token:        token,
+		Email:        user.Email,
+		Phone:        user.Phone,
+		Name:         user.Name,
+		Account:      user.Account,
+		IdentityType: user.IdentityType,
+	}, nil
+}
+
+func createMFAToken(uid string) (string, error) {
+	return CreateToken(&Claims{
+		StandardClaims: jwt.StandardClaims{
+			Subject:   uid,
+			Audience:  mfaTokenAudience,
+			ExpiresAt: time.Now().Add(mfaTokenTTL).Unix(),
+		},
+	})
+}
+
+func parseMFAToken(token string) (string, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(configbase.SecretKey()), nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("invalid mfa token: %s", err)
+	}
+	if claims.Audience != mfaTokenAudience || claims.Subject == "" {
+		return "", fmt.Errorf("invalid mfa token")
+	}
+	return claims.Subject, nil
+}
+
+func getEnabledMFA(uid string, logger *zap.SugaredLogger) (*models.UserMFA, error) {
+	mfa, err := orm.GetUserMFA(uid, core.DB)
+	if err != nil {
+		logger.Errorf("getEnabledMFA GetUserMFA:%s error, error msg:%s", uid, err)
+		return nil, err
+	}
+	if mfa == nil || !mfa.Enabled {
+		return nil, e.ErrInvalidParam.AddDesc("mfa is not enabled")
+	}
+	return mfa, nil
+}
+
+// validateMFACode checks the totp code, wrong codes are counted to lock the user and
+// each code is accepted only once
+func validateMFACode(mfa *models.UserMFA, code string, logger *zap.SugaredLogger) error {
+	if err := checkMFALocked(mfa.UID, logger); err != nil {
+		return err
+	}
+	secret, err := crypto.AesDecrypt(mfa.Secret)
+	if err != nil {
+		return err
+	}
+	step, ok := totp.ValidateStep(secret, code, time.Now())
+	if !ok {
+		recordMFAFailure(mfa.UID, logger)
+		return e.ErrUnauthorized.AddDesc("mfa code is wrong")
+	}
+	claimed, err := orm.ClaimUserMFAStep(mfa.UID, step, core.DB)
+	if err != nil {
+		logger.Errorf("validateMFACode ClaimUserMFAStep:%s error, error msg:%s", mfa.UID, err)
+		return err
+	}
+	if !claimed {
+		return e.ErrUnauthorized.AddDesc("mfa code has been used")
+	}
+	return nil
+}
+
+func checkMFALocked(uid string, logger *zap.SugaredLogger) error {
+	attempt, err := orm.GetUserMFAAttempt(uid, core.DB)
+	if err != nil {
+		logger.Errorf("checkMFALocked GetUserMFAAttempt:%s error, error msg:%s", uid, err)
+		return err
+	}
+	if attempt != nil && attempt.LockedUntil > time.Now().Unix() {
+		return e.ErrForbidden.AddDesc("too many failed mfa attempts, please try again later")
+	}
+	return nil
+}
+
+func recordMFAFailure(uid string, logger *zap.SugaredLogger) {
+	lockedUntil := time.Now().Add(mfaLockDuration).Unix()
+	if err := orm.IncreaseUserMFAFailure(uid, maxMFAFailures, lockedUntil, core.DB); err != nil {
+		logger.Errorf("recordMFAFailure IncreaseUserMFAFailure:%s error, error msg:%s", uid, err)
+	}
+}
+
+func activateMFA(mfa *models.UserMFA, code string, logger *zap.SugaredLogger) ([]string, error) {
+	if err := validateMFACode(mfa, code, logger); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	mfa.Enabled = true
+	mfa.RecoveryCodes = hashes
+	if err = orm.UpdateUserMFA(mfa.UID, mfa, core.DB); err != nil {
+		logger.Errorf("activateMFA UpdateUserMFA:%s error, error msg:%s", mfa.UID, err)
+		return nil, err
+	}
+	return codes, nil
+}
+
+// useRecoveryCode checks the recovery code and removes it, each recovery code can be used only once
+func useRecoveryCode(mfa *models.UserMFA, code string, logger *zap.SugaredLogger) error {
+	if err := checkMFALocked(mfa.UID, logger); err != nil {
+		return err
+	}
+	var hashes []string
+	if mfa.RecoveryCodes != "" {
+		if err := json.Unmarshal([]byte(mfa.RecoveryCodes), &hashes); err != nil {
+			return err
+		}
+	}
+	hash := hashRecoveryCode(code)
+	remaining := make([]string, 0, len(hashes))
+	for _, h := range hashes {
+		if h != hash {
+			remaining = append(remaining, h)
+		}
+	}
+	if len(remaining) == len(hashes) {
+		recordMFAFailure(mfa.UID, logger)
+		return e.ErrUnauthorized.AddDesc("recovery code is wrong")
+	}
+
+	b, err := json.Marshal(remaining)
+	if err != nil {
+		return err
+	}
+	mfa.RecoveryCodes = string(b)
+	if err = orm.UpdateUserMFA(mfa.UID, mfa, core.DB); err != nil {
+		logger.Errorf("useRecoveryCode UpdateUserMFA:%s error, error msg:%s", mfa.UID, err)
+		return err
+	}
+	if err = orm.ResetUserMFAFailure(mfa.UID, core.DB); err != nil {
+		logger.Errorf("useRecoveryCode ResetUserMFAFailure:%s error, error msg:%s", mfa.UID, err)
+	}
+	return nil
+}
+
+func generateRecoveryCodes() ([]string, string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, recoveryCodeSize)
+		if _, err := rand.Read(b); err != nil {
+			return nil, "", err
+		}
+		code := hex.EncodeToString(b)
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	b, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, "", err
+	}
+	return codes, string(b), nil
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
+}
@@ -28,6 +28,9 @@ type Claims struct {
 	UID               string          `json:"uid"`
 	PreferredUsername string          `json:"preferred_username"`
 	FederatedClaims   FederatedClaims `json:"federated_claims"`
+	Groups            []string        `json:"groups,omitempty"`
+	TokenID           string          `json:"token_id,omitempty"`
+	MFA               bool            `json:"mfa,omitempty"`
 	jwt.StandardClaims
 }
 
@@ -0,0 +1,237 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package user
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/microservice/user/core"
+	"github.com/koderover/zadig/pkg/microservice/user/core/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/user/core/repository/orm"
+	"github.com/koderover/zadig/pkg/microservice/user/core/service/login"
+	"github.com/koderover/zadig/pkg/setting"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+// APITokenScope grants the verbs of a resource in a project to the token, the project "*" means system scope.
+// The verbs are the actions defined in the policy metas, e.g. get_environment, "*" means all actions of the resource.
+type APITokenScope struct {
+	Project  string   `json:"project"`
+	Resource string   `json:"resource"`
+	Verbs    []string `json:"verbs"`
+}
+
+type APITokenArgs struct {
+	Name      string           `json:"name"`
+	Scopes    []*APITokenScope `json:"scopes"`
+	ExpiresAt int64            `json:"expires_at"`
+}
+
+type APIToken struct {
+	TokenID    string           `json:"token_id"`
+	UID        string           `json:"uid"`
+	Name       string           `json:"name"`
+	Scopes     []*APITokenScope `json:"scopes"`
+	ExpiresAt  int64            `json:"expires_at"`
+	LastUsedAt int64            `json:"last_used_at"`
+	Revoked    bool             `json:"revoked"`
+	CreatedAt  int64            `json:"created_at"`
+}
+
+// APITokenWithSecret is returned only once when the token is created, the token itself is never stored
+type APITokenWithSecret struct {
+	*APIToken
+	Token string `json:"token"`
+}
+
+// ActiveAPIToken is an api token which can still be used, it is consumed by the policy service to enforce the scopes
+type ActiveAPIToken struct {
+	TokenID   string           `json:"token_id"`
+	UID       string           `json:"uid"`
+	TokenHash string           `json:"token_hash"`
+	Scopes    []*APITokenScope `json:"scopes"`
+	ExpiresAt int64            `json:"expires_at"`
+}
+
+// CreateAPIToken issues a token for the user, api tokens never carry the mfa claim since they outlive the session
+// which creates them, so they can not be used for the urls which require mfa
+func CreateAPIToken(uid string, args *APITokenArgs, logger *zap.SugaredLogger) (*APITokenWithSecret, error) {
+	if args.Name == "" {
+		return nil, e.ErrInvalidParam.AddDesc("name can not be empty")
+	}
+	if args.ExpiresAt <= time.Now().Unix() {
+		return nil, e.ErrInvalidParam.AddDesc("expires_at must be a time in the future")
+	}
+	if len(args.Scopes) == 0 {
+		return nil, e.ErrInvalidParam.AddDesc("scopes can not be empty")
+	}
+	for _, scope := range args.Scopes {
+		if scope.Project == "" || scope.Resource == "" || len(scope.Verbs) == 0 {
+			return nil, e.ErrInvalidParam.AddDesc("project, resource and verbs of a scope can not be empty")
+		}
+	}
+
+	user, err := orm.GetUserByUid(uid, core.DB)
+	if err != nil {
+		logger.Errorf("CreateAPIToken GetUserByUid:%s error, error msg:%s", uid, err)
+		return nil, err
+	}
+	if user == nil {
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("user %s not found", uid))
+	}
+	existed, err := orm.GetAPITokenByName(uid, args.Name, core.DB)
+	if err != nil {
+		logger.Errorf("CreateAPIToken GetAPITokenByName:%s error, error msg:%s", args.Name, err)
+		return nil, err
+	}
+	if existed != nil {
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("api token %s already exists", args.Name))
+	}
+
+	tokenID, _ := uuid.NewUUID()
+	token, err := login.CreateToken(&login.Claims{
+		Name:              user.Name,
+		UID:               user.UID,
+		Email:             user.Email,
+		PreferredUsername: user.Account,
+		TokenID:           tokenID.String(),
+		StandardClaims: jwt.StandardClaims{
+			Audience:  setting.ProductName,
+			ExpiresAt: args.ExpiresAt,
+		},
+		FederatedClaims: login.FederatedClaims{
+			ConnectorId: user.IdentityType,
+			UserId:      user.Account,
+		},
+	})
+	if err != nil {
+		logger.Errorf("CreateAPIToken user:%s create token error, error msg:%s", user.Account, err)
+		return nil, err
+	}
+
+	scopes, err := json.Marshal(args.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	apiToken := &models.APIToken{
+		TokenID:   tokenID.String(),
+		UID:       uid,
+		Name:      args.Name,
+		Scopes:    string(scopes),
+		TokenHash: hashAPIToken(token),
+		ExpiresAt: args.ExpiresAt,
+	}
+	if err = orm.CreateAPIToken(apiToken, core.DB); err != nil {
+		logger.Errorf("CreateAPIToken CreateAPIToken:%s error, error msg:%s", args.Name, err)
+		return nil, err
+	}
+
+	return &APITokenWithSecret{APIToken: toAPIToken(apiToken), Token: token}, nil
+}
+
+func ListAPITokens(uid string, logger *zap.SugaredLogger) ([]*APIToken, error) {
+	tokens, err := orm.ListAPITokensByUID(uid, core.DB)
+	if err != nil {
+		logger.Errorf("ListAPITokens ListAPITokensByUID:%s error, error msg:%s", uid, err)
+		return nil, err
+	}
+	res := make([]*APIToken, 0, len(tokens))
+	for i := range tokens {
+		res = append(res, toAPIToken(&tokens[i]))
+	}
+	return res, nil
+}
+
+// RevokeAPIToken revokes the token of the user, the token is rejected by the policy service as soon as the bundle is refreshed
+func RevokeAPIToken(uid, tokenID string, logger *zap.SugaredLogger) error {
+	token, err := orm.GetAPIToken(tokenID, core.DB)
+	if err != nil {
+		logger.Errorf("RevokeAPIToken GetAPIToken:%s error, error msg:%s", tokenID, err)
+		return err
+	}
+	if token == nil || token.UID != uid {
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("api token %s not found", tokenID))
+	}
+	if err = orm.RevokeAPIToken(tokenID, core.DB); err != nil {
+		logger.Errorf("RevokeAPIToken RevokeAPIToken:%s error, error msg:%s", tokenID, err)
+		return err
+	}
+	return nil
+}
+
+func ListActiveAPITokens(logger *zap.SugaredLogger) ([]*ActiveAPIToken, error) {
+	tokens, err := orm.ListActiveAPITokens(time.Now().Unix(), core.DB)
+	if err != nil {
+		logger.Errorf("ListActiveAPITokens error, error msg:%s", err)
+		return nil, err
+	}
+	res := make([]*ActiveAPIToken, 0, len(tokens))
+	for _, token := range tokens {
+		res = append(res, &ActiveAPIToken{
+			TokenID:   token.TokenID,
+			UID:       token.UID,
+			TokenHash: token.TokenHash,
+			Scopes:    unmarshalAPITokenScopes(token.Scopes),
+			ExpiresAt: token.ExpiresAt,
+		})
+	}
+	return res, nil
+}
+
+// RecordAPITokenUsage updates the last used time of the token
+func RecordAPITokenUsage(tokenID string, logger *zap.SugaredLogger) error {
+	if err := orm.UpdateAPITokenLastUsedAt(tokenID, time.Now().Unix(), core.DB); err != nil {
+		logger.Errorf("RecordAPITokenUsage UpdateAPITokenLastUsedAt:%s error, error msg:%s", tokenID, err)
+		return err
+	}
+	return nil
+}
+
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func unmarshalAPITokenScopes(scopes string) []*APITokenScope {
+	res := make([]*APITokenScope, 0)
+	if scopes == "" {
+		return res
+	}
+	_ = json.Unmarshal([]byte(scopes), &res)
+	return res
+}
+
+func toAPIToken(token *models.APIToken) *APIToken {
+	return &APIToken{
+		TokenID:    token.TokenID,
+		UID:        token.UID,
+		Name:       token.Name,
+		Scopes:     unmarshalAPITokenScopes(token.Scopes),
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		Revoked:    token.Revoked,
+		CreatedAt:  token.CreatedAt,
+	}
+}
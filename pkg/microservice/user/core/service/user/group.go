@@ -0,0 +1,437 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package user
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/koderover/zadig/pkg/microservice/user/config"
+	"github.com/koderover/zadig/pkg/microservice/user/core"
+	"github.com/koderover/zadig/pkg/microservice/user/core/repository/models"
+	"github.com/koderover/zadig/pkg/microservice/user/core/repository/orm"
+	"github.com/koderover/zadig/pkg/shared/client/policy"
+	e "github.com/koderover/zadig/pkg/tool/errors"
+)
+
+type UserGroup struct {
+	GroupID      string   `json:"group_id"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	IdentityType string   `json:"identity_type"`
+	UIDs         []string `json:"uids"`
+	CreatedAt    int64    `json:"created_at"`
+	UpdatedAt    int64    `json:"updated_at"`
+}
+
+type UserGroupArgs struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	UIDs        []string `json:"uids"`
+}
+
+type GroupMembersArgs struct {
+	UIDs []string `json:"uids"`
+}
+
+func CreateUserGroup(args *UserGroupArgs, logger *zap.SugaredLogger) (*UserGroup, error) {
+	if args.Name == "" {
+		return nil, e.ErrInvalidParam.AddDesc("name can not be empty")
+	}
+	existed, err := orm.GetUserGroupByName(args.Name, config.SystemIdentityType, core.DB)
+	if err != nil {
+		logger.Errorf("CreateUserGroup GetUserGroupByName:%s error, error msg:%s", args.Name, err)
+		return nil, err
+	}
+	if existed != nil {
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("user group %s already exists", args.Name))
+	}
+
+	uid, _ := uuid.NewUUID()
+	group := &models.UserGroup{
+		GroupID:      uid.String(),
+		Name:         args.Name,
+		Description:  args.Description,
+		IdentityType: config.SystemIdentityType,
+	}
+	tx := core.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = orm.CreateUserGroup(group, tx); err != nil {
+		tx.Rollback()
+		logger.Errorf("CreateUserGroup CreateUserGroup:%s error, error msg:%s", args.Name, err)
+		return nil, err
+	}
+	if err = orm.CreateGroupBindings(newGroupBindings(group.GroupID, sets.NewString(args.UIDs...).List()), tx); err != nil {
+		tx.Rollback()
+		logger.Errorf("CreateUserGroup CreateGroupBindings:%s error, error msg:%s", args.Name, err)
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		logger.Errorf("CreateUserGroup tx commit error, error msg:%s", err)
+		return nil, err
+	}
+
+	return toUserGroup(group, sets.NewString(args.UIDs...).List()), nil
+}
+
+func ListUserGroups(name string, logger *zap.SugaredLogger) ([]*UserGroup, error) {
+	groups, err := orm.ListUserGroups(name, core.DB)
+	if err != nil {
+		logger.Errorf("ListUserGroups error, error msg:%s", err)
+		return nil, err
+	}
+	var groupIDs []string
+	for _, group := range groups {
+		groupIDs = append(groupIDs, group.GroupID)
+	}
+	members := make(map[string][]string)
+	if len(groupIDs) > 0 {
+		bindings, err := orm.ListGroupBindings(groupIDs, core.DB)
+		if err != nil {
+			logger.Errorf("ListUserGroups ListGroupBindings error, error msg:%s", err)
+			return nil, err
+		}
+		for _, binding := range bindings {
+			members[binding.GroupID] = append(members[binding.GroupID], binding.UID)
+		}
+	}
+
+	res := make([]*UserGroup, 0, len(groups))
+	for i := range groups {
+		res = append(res, toUserGroup(&groups[i], members[groups[i].GroupID]))
+	}
+	return res, nil
+}
+
+func GetUserGroup(groupID string, logger *zap.SugaredLogger) (*UserGroup, error) {
+	group, err := getUserGroup(groupID, logger)
+	if err != nil {
+		return nil, err
+	}
+	bindings, err := orm.ListGroupBindings([]string{groupID}, core.DB)
+	if err != nil {
+		logger.Errorf("GetUserGroup ListGroupBindings:%s error, error msg:%s", groupID, err)
+		return nil, err
+	}
+	var uids []string
+	for _, binding := range bindings {
+		uids = append(uids, binding.UID)
+	}
+	return toUserGroup(group, uids), nil
+}
+
+// ListUserGroupsByUID returns the user groups which the user belongs to.
+func ListUserGroupsByUID(uid string, logger *zap.SugaredLogger) ([]*UserGroup, error) {
+	bindings, err := orm.ListGroupBindingsByUID(uid, core.DB)
+	if err != nil {
+		logger.Errorf("ListUserGroupsByUID ListGroupBindingsByUID:%s error, error msg:%s", uid, err)
+		return nil, err
+	}
+	res := make([]*UserGroup, 0, len(bindings))
+	for _, binding := range bindings {
+		group, err := orm.GetUserGroup(binding.GroupID, core.DB)
+		if err != nil {
+			logger.Errorf("ListUserGroupsByUID GetUserGroup:%s error, error msg:%s", binding.GroupID, err)
+			return nil, err
+		}
+		if group == nil {
+			continue
+		}
+		res = append(res, toUserGroup(group, nil))
+	}
+	return res, nil
+}
+
+func UpdateUserGroup(groupID string, args *UserGroupArgs, logger *zap.SugaredLogger) error {
+	group, err := getUserGroup(groupID, logger)
+	if err != nil {
+		return err
+	}
+	// the name of synced groups is managed by the identity provider
+	if group.IdentityType != config.SystemIdentityType && args.Name != "" && args.Name != group.Name {
+		return e.ErrInvalidParam.AddDesc(fmt.Sprintf("user group %s is synced from %s and can not be renamed", group.Name, group.IdentityType))
+	}
+	if args.Name != "" && args.Name != group.Name {
+		existed, err := orm.GetUserGroupByName(args.Name, group.IdentityType, core.DB)
+		if err != nil {
+			logger.Errorf("UpdateUserGroup GetUserGroupByName:%s error, error msg:%s", args.Name, err)
+			return err
+		}
+		if existed != nil {
+			return e.ErrInvalidParam.AddDesc(fmt.Sprintf("user group %s already exists", args.Name))
+		}
+	}
+
+	return orm.UpdateUserGroup(groupID, &models.UserGroup{Name: args.Name, Description: args.Description}, core.DB)
+}
+
+func DeleteUserGroup(groupID string, logger *zap.SugaredLogger) error {
+	tx := core.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := orm.DeleteUserGroup(groupID, tx); err != nil {
+		tx.Rollback()
+		logger.Errorf("DeleteUserGroup DeleteUserGroup:%s error, error msg:%s", groupID, err)
+		return err
+	}
+	if err := orm.DeleteGroupBindings(groupID, nil, tx); err != nil {
+		tx.Rollback()
+		logger.Errorf("DeleteUserGroup DeleteGroupBindings:%s error, error msg:%s", groupID, err)
+		return err
+	}
+	// the role bindings and policy bindings of the group would grant permissions to a new group with the same id otherwise
+	if err := policy.NewDefault().DeleteGroupBindings(groupID); err != nil {
+		tx.Rollback()
+		logger.Errorf("DeleteUserGroup DeleteGroupBindings of policy:%s error, error msg:%s", groupID, err)
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func AddUserGroupMembers(groupID string, uids []string, logger *zap.SugaredLogger) error {
+	group, err := getLocalUserGroup(groupID, logger)
+	if err != nil {
+		return err
+	}
+	bindings, err := orm.ListGroupBindings([]string{group.GroupID}, core.DB)
+	if err != nil {
+		logger.Errorf("AddUserGroupMembers ListGroupBindings:%s error, error msg:%s", groupID, err)
+		return err
+	}
+	newMembers := sets.NewString(uids...)
+	for _, binding := range bindings {
+		newMembers.Delete(binding.UID)
+	}
+	return orm.CreateGroupBindings(newGroupBindings(group.GroupID, newMembers.List()), core.DB)
+}
+
+func RemoveUserGroupMembers(groupID string, uids []string, logger *zap.SugaredLogger) error {
+	if len(uids) == 0 {
+		return nil
+	}
+	group, err := getLocalUserGroup(groupID, logger)
+	if err != nil {
+		return err
+	}
+	return orm.DeleteGroupBindings(group.GroupID, uids, core.DB)
+}
+
+// SyncUserGroupsOfUser makes the user a member of exactly the given groups of the identity provider,
+// it is used to sync the groups claim of the OIDC connectors when the user logs in.
+func SyncUserGroupsOfUser(uid, identityType string, groupNames []string, logger *zap.SugaredLogger) error {
+	groups, err := orm.ListUserGroupsByIdentityType(identityType, core.DB)
+	if err != nil {
+		logger.Errorf("SyncUserGroupsOfUser ListUserGroupsByIdentityType:%s error, error msg:%s", identityType, err)
+		return err
+	}
+	groupMap := make(map[string]*models.UserGroup)
+	for i := range groups {
+		groupMap[groups[i].Name] = &groups[i]
+	}
+
+	tx := core.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+	for _, group := range groups {
+		if err = orm.DeleteGroupBindings(group.GroupID, []string{uid}, tx); err != nil {
+			tx.Rollback()
+			logger.Errorf("SyncUserGroupsOfUser DeleteGroupBindings:%s error, error msg:%s", group.GroupID, err)
+			return err
+		}
+	}
+	for _, name := range sets.NewString(groupNames...).List() {
+		group, err := ensureUserGroup(groupMap, name, identityType, tx)
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("SyncUserGroupsOfUser ensureUserGroup:%s error, error msg:%s", name, err)
+			return err
+		}
+		if err = orm.CreateGroupBindings(newGroupBindings(group.GroupID, []string{uid}), tx); err != nil {
+			tx.Rollback()
+			logger.Errorf("SyncUserGroupsOfUser CreateGroupBindings:%s error, error msg:%s", group.GroupID, err)
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+// syncUserGroups replaces all the groups of the identity provider with the given ones, which are
+// group names mapped to the accounts of the members.
+func syncUserGroups(identityType string, members map[string][]string, logger *zap.SugaredLogger) error {
+	users, err := orm.ListUsersByIdentityType(identityType, core.DB)
+	if err != nil {
+		logger.Errorf("syncUserGroups ListUsersByIdentityType:%s error, error msg:%s", identityType, err)
+		return err
+	}
+	accountUIDs := make(map[string]string)
+	for _, user := range users {
+		accountUIDs[user.Account] = user.UID
+	}
+	groups, err := orm.ListUserGroupsByIdentityType(identityType, core.DB)
+	if err != nil {
+		logger.Errorf("syncUserGroups ListUserGroupsByIdentityType:%s error, error msg:%s", identityType, err)
+		return err
+	}
+	groupMap := make(map[string]*models.UserGroup)
+	for i := range groups {
+		groupMap[groups[i].Name] = &groups[i]
+	}
+
+	tx := core.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+	for _, group := range removedUserGroups(groups, members) {
+		if err = orm.DeleteUserGroup(group.GroupID, tx); err != nil {
+			tx.Rollback()
+			logger.Errorf("syncUserGroups DeleteUserGroup:%s error, error msg:%s", group.Name, err)
+			return err
+		}
+		if err = orm.DeleteGroupBindings(group.GroupID, nil, tx); err != nil {
+			tx.Rollback()
+			logger.Errorf("syncUserGroups DeleteGroupBindings:%s error, error msg:%s", group.Name, err)
+			return err
+		}
+		if err = policy.NewDefault().DeleteGroupBindings(group.GroupID); err != nil {
+			tx.Rollback()
+			logger.Errorf("syncUserGroups DeleteGroupBindings of policy:%s error, error msg:%s", group.Name, err)
+			return err
+		}
+	}
+	for name, accounts := range members {
+		group, err := ensureUserGroup(groupMap, name, identityType, tx)
+		if err != nil {
+			tx.Rollback()
+			logger.Errorf("syncUserGroups ensureUserGroup:%s error, error msg:%s", name, err)
+			return err
+		}
+		uids := memberUIDs(accounts, accountUIDs)
+		if err = orm.DeleteGroupBindings(group.GroupID, nil, tx); err != nil {
+			tx.Rollback()
+			logger.Errorf("syncUserGroups DeleteGroupBindings:%s error, error msg:%s", name, err)
+			return err
+		}
+		if err = orm.CreateGroupBindings(newGroupBindings(group.GroupID, uids), tx); err != nil {
+			tx.Rollback()
+			logger.Errorf("syncUserGroups CreateGroupBindings:%s error, error msg:%s", name, err)
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+// removedUserGroups returns the groups which are removed from the identity provider.
+func removedUserGroups(groups []models.UserGroup, members map[string][]string) []models.UserGroup {
+	var removed []models.UserGroup
+	for _, group := range groups {
+		if _, ok := members[group.Name]; !ok {
+			removed = append(removed, group)
+		}
+	}
+	return removed
+}
+
+// memberUIDs returns the sorted uids of the given accounts, the accounts which have never logged in are ignored.
+func memberUIDs(accounts []string, accountUIDs map[string]string) []string {
+	uids := sets.NewString()
+	for _, account := range accounts {
+		if uid, ok := accountUIDs[account]; ok {
+			uids.Insert(uid)
+		}
+	}
+	return uids.List()
+}
+
+func ensureUserGroup(groupMap map[string]*models.UserGroup, name, identityType string, db *gorm.DB) (*models.UserGroup, error) {
+	if group, ok := groupMap[name]; ok {
+		return group, nil
+	}
+	uid, _ := uuid.NewUUID()
+	group := &models.UserGroup{
+		GroupID:      uid.String(),
+		Name:         name,
+		IdentityType: identityType,
+	}
+	if err := orm.CreateUserGroup(group, db); err != nil {
+		return nil, err
+	}
+	groupMap[name] = group
+	return group, nil
+}
+
+func getUserGroup(groupID string, logger *zap.SugaredLogger) (*models.UserGroup, error) {
+	group, err := orm.GetUserGroup(groupID, core.DB)
+	if err != nil {
+		logger.Errorf("GetUserGroup:%s error, error msg:%s", groupID, err)
+		return nil, err
+	}
+	if group == nil {
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("user group %s not found", groupID))
+	}
+	return group, nil
+}
+
+// getLocalUserGroup returns the group whose members are managed in zadig rather than synced from an identity provider.
+func getLocalUserGroup(groupID string, logger *zap.SugaredLogger) (*models.UserGroup, error) {
+	group, err := getUserGroup(groupID, logger)
+	if err != nil {
+		return nil, err
+	}
+	if group.IdentityType != config.SystemIdentityType {
+		return nil, e.ErrInvalidParam.AddDesc(fmt.Sprintf("members of user group %s are synced from %s", group.Name, group.IdentityType))
+	}
+	return group, nil
+}
+
+func newGroupBindings(groupID string, uids []string) []*models.GroupBinding {
+	var bindings []*models.GroupBinding
+	for _, uid := range uids {
+		bindings = append(bindings, &models.GroupBinding{GroupID: groupID, UID: uid})
+	}
+	return bindings
+}
+
+func toUserGroup(group *models.UserGroup, uids []string) *UserGroup {
+	if uids == nil {
+		uids = []string{}
+	}
+	return &UserGroup{
+		GroupID:      group.GroupID,
+		Name:         group.Name,
+		Description:  group.Description,
+		IdentityType: group.IdentityType,
+		UIDs:         uids,
+		CreatedAt:    group.CreatedAt,
+		UpdatedAt:    group.UpdatedAt,
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/koderover/zadig/pkg/microservice/user/core/repository/models"
+)
+
+func TestRemovedUserGroups(t *testing.T) {
+	groups := []models.UserGroup{
+		{GroupID: "g1", Name: "dev"},
+		{GroupID: "g2", Name: "ops"},
+		{GroupID: "g3", Name: "qa"},
+	}
+
+	tests := []struct {
+		name    string
+		members map[string][]string
+		want    []string
+	}{
+		{
+			name:    "all groups are kept",
+			members: map[string][]string{"dev": nil, "ops": {"a"}, "qa": {"b"}},
+		},
+		{
+			name:    "groups missing in the identity provider are removed",
+			members: map[string][]string{"ops": {"a"}},
+			want:    []string{"g1", "g3"},
+		},
+		{
+			name:    "a group without members is kept",
+			members: map[string][]string{"dev": {}, "ops": nil, "qa": nil},
+		},
+		{
+			name: "all groups are removed",
+			want: []string{"g1", "g2", "g3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, group := range removedUserGroups(groups, tt.members) {
+				got = append(got, group.GroupID)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMemberUIDs(t *testing.T) {
+	accountUIDs := map[string]string{"alice": "u1", "bob": "u2", "carol": "u3"}
+
+	tests := []struct {
+		name     string
+		accounts []string
+		want     []string
+	}{
+		{
+			name:     "accounts are mapped to sorted uids",
+			accounts: []string{"carol", "alice"},
+			want:     []string{"u1", "u3"},
+		},
+		{
+			name:     "unknown accounts are ignored",
+			accounts: []string{"alice", "dave"},
+			want:     []string{"u1"},
+		},
+		{
+			name:     "duplicated accounts are merged",
+			accounts: []string{"bob", "bob"},
+			want:     []string{"u2"},
+		},
+		{
+			name: "no accounts",
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, memberUIDs(tt.accounts, accountUIDs))
+		})
+	}
+}
+
+func TestToUserGroup(t *testing.T) {
+	group := &models.UserGroup{GroupID: "g1", Name: "dev", Description: "developers", IdentityType: "system"}
+
+	got := toUserGroup(group, nil)
+	assert.Equal(t, []string{}, got.UIDs)
+	assert.Equal(t, "g1", got.GroupID)
+	assert.Equal(t, "developers", got.Description)
+
+	got = toUserGroup(group, []string{"u1"})
+	assert.Equal(t, []string{"u1"}, got.UIDs)
+	assert.Equal(t, []*models.GroupBinding{{GroupID: "g1", UID: "u1"}}, newGroupBindings("g1", []string{"u1"}))
+}
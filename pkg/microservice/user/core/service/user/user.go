@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/dexidp/dex/connector/ldap"
@@ -30,6 +31,7 @@ import (
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
+	"k8s.io/apimachinery/pkg/util/sets"
 
 	configbase "github.com/koderover/zadig/pkg/config"
 	commonmodels "github.com/koderover/zadig/pkg/microservice/aslan/core/common/repository/models"
@@ -158,7 +160,101 @@ func SearchAndSyncUser(ldapId string, logger *zap.SugaredLogger) error {
 			return err
 		}
 	}
-	return nil
+	return searchAndSyncGroup(l, config, si.ID, logger)
+}
+
+// searchAndSyncGroup syncs the groups found by the group search of the ldap connector, members of the groups
+// are matched to the synced users by the user matchers.
+func searchAndSyncGroup(l *ldapv3.Conn, config *ldap.Config, identityType string, logger *zap.SugaredLogger) error {
+	matchers := config.GroupSearch.UserMatchers
+	if len(matchers) == 0 && config.GroupSearch.UserAttr != "" && config.GroupSearch.GroupAttr != "" {
+		matchers = []ldap.UserMatcher{{UserAttr: config.GroupSearch.UserAttr, GroupAttr: config.GroupSearch.GroupAttr}}
+	}
+	if config.GroupSearch.NameAttr == "" || len(matchers) == 0 {
+		return nil
+	}
+
+	userBaseDN, userFilter := config.UserSearch.BaseDN, config.UserSearch.Filter
+	if userBaseDN == "" {
+		userBaseDN, userFilter = config.GroupSearch.BaseDN, config.GroupSearch.Filter
+	}
+	if userFilter == "" {
+		userFilter = "(objectClass=*)"
+	}
+	userAttrs := []string{config.UserSearch.PreferredUsernameAttrAttr}
+	for _, matcher := range matchers {
+		userAttrs = append(userAttrs, matcher.UserAttr)
+	}
+	sr, err := l.Search(ldapv3.NewSearchRequest(
+		userBaseDN,
+		ldapv3.ScopeWholeSubtree, ldapv3.NeverDerefAliases, 0, 0, false,
+		userFilter,
+		userAttrs,
+		nil,
+	))
+	if err != nil {
+		logger.Errorf("ldap search users host:%s error, error msg:%s", config.Host, err)
+		return err
+	}
+	// accounts of users indexed by the value of the user attributes in the matchers
+	accounts := make(map[string]map[string]string)
+	for _, entry := range sr.Entries {
+		account := entry.GetAttributeValue(config.UserSearch.PreferredUsernameAttrAttr)
+		if account == "" {
+			continue
+		}
+		for _, matcher := range matchers {
+			if _, ok := accounts[matcher.UserAttr]; !ok {
+				accounts[matcher.UserAttr] = make(map[string]string)
+			}
+			if strings.EqualFold(matcher.UserAttr, "DN") {
+				accounts[matcher.UserAttr][entry.DN] = account
+				continue
+			}
+			for _, value := range entry.GetAttributeValues(matcher.UserAttr) {
+				accounts[matcher.UserAttr][value] = account
+			}
+		}
+	}
+
+	groupFilter := fmt.Sprintf("(%s=*)", config.GroupSearch.NameAttr)
+	if config.GroupSearch.Filter != "" {
+		groupFilter = fmt.Sprintf("(&%s%s)", config.GroupSearch.Filter, groupFilter)
+	}
+	groupAttrs := []string{config.GroupSearch.NameAttr}
+	for _, matcher := range matchers {
+		groupAttrs = append(groupAttrs, matcher.GroupAttr)
+	}
+	sr, err = l.Search(ldapv3.NewSearchRequest(
+		config.GroupSearch.BaseDN,
+		ldapv3.ScopeWholeSubtree, ldapv3.NeverDerefAliases, 0, 0, false,
+		groupFilter,
+		groupAttrs,
+		nil,
+	))
+	if err != nil {
+		logger.Errorf("ldap search groups host:%s error, error msg:%s", config.Host, err)
+		return err
+	}
+	members := make(map[string][]string)
+	for _, entry := range sr.Entries {
+		groupAccounts := sets.NewString()
+		for _, matcher := range matchers {
+			for _, value := range entry.GetAttributeValues(matcher.GroupAttr) {
+				if account, ok := accounts[matcher.UserAttr][value]; ok {
+					groupAccounts.Insert(account)
+				}
+			}
+		}
+		// entries without members are not groups, the base dn of groups may be shared with users
+		if groupAccounts.Len() == 0 {
+			continue
+		}
+		name := entry.GetAttributeValue(config.GroupSearch.NameAttr)
+		members[name] = append(members[name], groupAccounts.List()...)
+	}
+
+	return syncUserGroups(identityType, members, logger)
 }
 
 func GetUser(uid string, logger *zap.SugaredLogger) (*types.UserInfo, error) {
@@ -365,6 +461,18 @@ func DeleteUserByUID(uid string, logger *zap.SugaredLogger) error {
 		logger.Errorf("DeleteUserByUID DeleteUserLoginByUid:%s error, error msg:%s", uid, err.Error())
 		return err
 	}
+	err = orm.DeleteGroupBindingsByUID(uid, tx)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("DeleteUserByUID DeleteGroupBindingsByUID:%s error, error msg:%s", uid, err.Error())
+		return err
+	}
+	err = orm.DeleteAPITokensByUID(uid, tx)
+	if err != nil {
+		tx.Rollback()
+		logger.Errorf("DeleteUserByUID DeleteAPITokensByUID:%s error, error msg:%s", uid, err.Error())
+		return err
+	}
 	err = mongodb.NewUserSettingColl().DeleteUserSettingByUid(uid)
 	if err != nil {
 		tx.Rollback()
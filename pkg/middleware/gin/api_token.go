@@ -0,0 +1,52 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gin
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/koderover/zadig/pkg/shared/client/user"
+	internalhandler "github.com/koderover/zadig/pkg/shared/handler"
+)
+
+// apiTokenUsageInterval is the minimum interval between two updates of the last used time of a token
+const apiTokenUsageInterval = time.Minute
+
+var apiTokenUsage sync.Map
+
+// APITokenUsage records the last used time of the api token which the request is authenticated by,
+// at most once per apiTokenUsageInterval for each token
+func APITokenUsage() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := internalhandler.NewContext(c)
+		if ctx.APITokenID != "" {
+			now := time.Now()
+			if last, ok := apiTokenUsage.Load(ctx.APITokenID); !ok || now.Sub(last.(time.Time)) >= apiTokenUsageInterval {
+				apiTokenUsage.Store(ctx.APITokenID, now)
+				go func(tokenID string) {
+					if err := user.New().RecordAPITokenUsage(tokenID); err != nil {
+						ctx.Logger.Warnf("failed to record the usage of api token %s, err: %s", tokenID, err)
+					}
+				}(ctx.APITokenID)
+			}
+		}
+		c.Next()
+	}
+}
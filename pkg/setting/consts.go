@@ -48,6 +48,9 @@ const (
 	ENVAslanRegSecretKey    = "DEFAULT_REGISTRY_SK"
 	ENVAslanRegNamespace    = "DEFAULT_REGISTRY_NAMESPACE"
 
+	// comma separated Prometheus endpoints which the metrics of deploy verifications are allowed to query
+	ENVVerificationMetricEndpoints = "VERIFICATION_METRIC_ENDPOINTS"
+
 	ENVGithubSSHKey    = "GITHUB_SSH_KEY"
 	ENVGithubKnownHost = "GITHUB_KNOWN_HOST"
 
@@ -139,6 +142,7 @@ const (
 	Service               = "Service"
 	Deployment            = "Deployment"
 	StatefulSet           = "StatefulSet"
+	DaemonSet             = "DaemonSet"
 	Pod                   = "Pod"
 	ReplicaSet            = "ReplicaSet"
 	Job                   = "Job"
@@ -258,6 +262,10 @@ const (
 	SourceFromGitee = "gitee"
 	// SourceFromGiteeEE Configure the source as gitee-enterprise
 	SourceFromGiteeEE = "gitee-enterprise"
+	// SourceFromBitbucket Configure the source as bitbucket, including Bitbucket Cloud and Bitbucket Server/Data Center
+	SourceFromBitbucket = "bitbucket"
+	// SourceFromGitea Configure the source as gitea, Forgejo is supported as well
+	SourceFromGitea = "gitea"
 	// SourceFromOther Configure the source as other
 	SourceFromOther = "other"
 	// SourceFromChartTemplate The configuration source is helmTemplate
@@ -418,6 +426,7 @@ const (
 	OperationSceneScanning = "scanning"
 	OperationSceneVersion  = "version"
 	OperationSceneSystem   = "system"
+	OperationScenePolicy   = "policy"
 )
 
 // Service Related
@@ -463,10 +472,20 @@ const (
 	GeneralHookTaskCreator = "general_hook"
 	// CronTaskCreator ...
 	CronTaskCreator = "timer"
+	// PreviewEnvCreator ...
+	PreviewEnvCreator = "preview_env"
 	// DefaultTaskRevoker ...
 	DefaultTaskRevoker = "system" // default task revoker
 )
 
+// sync status of the environments in gitops mode
+const (
+	GitOpsStatusSynced    = "synced"
+	GitOpsStatusPending   = "pending"
+	GitOpsStatusOutOfSync = "outofsync"
+	GitOpsStatusFailed    = "failed"
+)
+
 const (
 	// DefaultMaxFailures ...
 	DefaultMaxFailures = 10
@@ -543,6 +562,8 @@ const (
 	TopicItReport     = "task.it.report"
 	TopicNotification = "task.notification"
 	TopicCronjob      = "cronjob"
+
+	TopicEventDelivery = "event.delivery"
 )
 
 // S3 related constants
@@ -750,6 +771,8 @@ const (
 const (
 	IMLark     = "lark"
 	IMDingding = "dingding"
+	IMWeCom    = "wecom"
+	IMSlack    = "slack"
 )
 
 // lark app
@@ -35,6 +35,7 @@ type DeletePoliciesArgs struct {
 type PolicyBinding struct {
 	Name   string               `json:"name"`
 	UID    string               `json:"uid"`
+	GID    string               `json:"gid,omitempty"`
 	Policy string               `json:"policy"`
 	Preset bool                 `json:"preset"`
 	Type   setting.ResourceType `json:"type"`
@@ -175,3 +176,7 @@ func (c *Client) GetPolicies(names string) ([]*types.Policy, error) {
 	}
 	return res, nil
 }
+
+func (c *Client) GetUserRulesByProject(uid, projectName string) (*policyservice.GetUserRulesByProjectResp, error) {
+	return policyservice.GetUserRulesByProject(uid, projectName, log.SugaredLogger())
+}
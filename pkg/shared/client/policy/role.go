@@ -30,6 +30,7 @@ import (
 type RoleBinding struct {
 	Name   string               `json:"name"`
 	UID    string               `json:"uid"`
+	GID    string               `json:"gid,omitempty"`
 	Role   string               `json:"role"`
 	Preset bool                 `json:"preset"`
 	Type   setting.ResourceType `json:"type"`
@@ -43,6 +44,7 @@ func (c *Client) ListRoleBindings(projectName string) ([]*RoleBinding, error) {
 		res = append(res, &RoleBinding{
 			Name:   rb.Name,
 			UID:    rb.UID,
+			GID:    rb.GID,
 			Role:   rb.Role,
 			Preset: rb.Preset,
 			Type:   rb.Type,
@@ -83,6 +85,11 @@ func (c *Client) DeleteRoleBindings(names []string, projectName string) error {
 	return policyservice.DeleteRoleBindings(names, projectName, "", log.SugaredLogger())
 }
 
+// DeleteGroupBindings deletes all role bindings and policy bindings of the user group
+func (c *Client) DeleteGroupBindings(gid string) error {
+	return policyservice.DeleteGroupBindings(gid, log.SugaredLogger())
+}
+
 func (c *Client) DeleteRoles(names []string, projectName string) error {
 	return policyservice.DeleteRoles(names, projectName, log.SugaredLogger())
 }
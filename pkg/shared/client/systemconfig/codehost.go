@@ -26,13 +26,15 @@ import (
 )
 
 const (
-	GitLabProvider  = "gitlab"
-	GitHubProvider  = "github"
-	GerritProvider  = "gerrit"
-	CodeHubProvider = "codehub"
-	GiteeProvider   = "gitee"
-	GiteeEEProvider = "gitee-enterprise"
-	OtherProvider   = "other"
+	GitLabProvider    = "gitlab"
+	GitHubProvider    = "github"
+	GerritProvider    = "gerrit"
+	CodeHubProvider   = "codehub"
+	GiteeProvider     = "gitee"
+	GiteeEEProvider   = "gitee-enterprise"
+	BitbucketProvider = "bitbucket"
+	GiteaProvider     = "gitea"
+	OtherProvider     = "other"
 )
 
 type CodeHost struct {
@@ -17,6 +17,8 @@ limitations under the License.
 package user
 
 import (
+	"fmt"
+
 	"github.com/koderover/zadig/pkg/tool/httpclient"
 )
 
@@ -86,6 +88,71 @@ func (c *Client) SearchUser(args *SearchUserArgs) (*SearchUserResp, error) {
 	return resp, err
 }
 
+type UserGroup struct {
+	GroupID      string   `json:"group_id"`
+	Name         string   `json:"name"`
+	IdentityType string   `json:"identity_type"`
+	UIDs         []string `json:"uids"`
+}
+
+func (c *Client) ListUserGroups() ([]*UserGroup, error) {
+	url := "/user-groups"
+	res := make([]*UserGroup, 0)
+	_, err := c.Get(url, httpclient.SetResult(&res))
+	return res, err
+}
+
+type APITokenScope struct {
+	Project  string   `json:"project"`
+	Resource string   `json:"resource"`
+	Verbs    []string `json:"verbs"`
+}
+
+type APIToken struct {
+	TokenID   string           `json:"token_id"`
+	UID       string           `json:"uid"`
+	TokenHash string           `json:"token_hash"`
+	Scopes    []*APITokenScope `json:"scopes"`
+	ExpiresAt int64            `json:"expires_at"`
+}
+
+func (c *Client) ListActiveAPITokens() ([]*APIToken, error) {
+	url := "/api-tokens/active"
+	res := make([]*APIToken, 0)
+	_, err := c.Get(url, httpclient.SetResult(&res))
+	return res, err
+}
+
+func (c *Client) RecordAPITokenUsage(tokenID string) error {
+	url := fmt.Sprintf("/api-tokens/%s/usage", tokenID)
+	_, err := c.Post(url)
+	return err
+}
+
+type mfaUsers struct {
+	UIDs []string `json:"uids"`
+}
+
+// ListMFAUsers lists the uids of the users who activated mfa
+func (c *Client) ListMFAUsers() ([]string, error) {
+	url := "/mfa/users"
+	res := &mfaUsers{}
+	_, err := c.Get(url, httpclient.SetResult(res))
+	return res.UIDs, err
+}
+
+type mfaEnforcedRoles struct {
+	Roles []string `json:"roles"`
+}
+
+// ListMFAEnforcedRoles lists the system roles whose local members must log in with mfa
+func (c *Client) ListMFAEnforcedRoles() ([]string, error) {
+	url := "/mfa/enforced-roles"
+	res := &mfaEnforcedRoles{}
+	_, err := c.Get(url, httpclient.SetResult(res))
+	return res.Roles, err
+}
+
 func (c *Client) Healthz() error {
 	url := "/healthz"
 	_, err := c.Get(url)
@@ -44,6 +44,10 @@ type Context struct {
 	UserID       string
 	IdentityType string
 	RequestID    string
+	// APITokenID is set if the request is authenticated by an api token
+	APITokenID string
+	// MFA is true if the user logged in with MFA
+	MFA bool
 }
 
 type jwtClaims struct {
@@ -52,6 +56,8 @@ type jwtClaims struct {
 	UID             string          `json:"uid"`
 	Account         string          `json:"preferred_username"`
 	FederatedClaims FederatedClaims `json:"federated_claims"`
+	TokenID         string          `json:"token_id"`
+	MFA             bool            `json:"mfa"`
 	jwt.StandardClaims
 }
 
@@ -83,6 +89,8 @@ func NewContext(c *gin.Context) *Context {
 		IdentityType: claims.FederatedClaims.ConnectorId,
 		Logger:       ginzap.WithContext(c).Sugar(),
 		RequestID:    c.GetString(setting.RequestID),
+		APITokenID:   claims.TokenID,
+		MFA:          claims.MFA,
 	}
 }
 
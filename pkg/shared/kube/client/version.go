@@ -17,22 +17,75 @@ limitations under the License.
 package client
 
 import (
+	"sync"
+	"time"
+
 	"k8s.io/apimachinery/pkg/util/version"
 	k8sversion "k8s.io/apimachinery/pkg/version"
 )
 
+var KubernetesVersion121 *version.Version
 var KubernetesVersion122 *version.Version
 
+// clusterVersionCacheTTL bounds how long a detected server version is trusted, so that cluster upgrades are picked up
+const clusterVersionCacheTTL = 10 * time.Minute
+
+// clusterVersions caches the server version of each cluster, clusterID => *clusterVersion
+var clusterVersions sync.Map
+
+type clusterVersion struct {
+	info      *k8sversion.Info
+	expiresAt time.Time
+}
+
 func init() {
 	// as of zadig v1.11.0. Only kubernetes version 1.17+ is supported
 	// There is only 1 major api version change from v1.17+
 	// More versions of kubernetes should be added if there are more API changes
 	// in future kubernetes version
+	v121, _ := version.ParseGeneric("v1.21.0")
+	KubernetesVersion121 = v121
 	v122, _ := version.ParseGeneric("v1.22.0")
 	KubernetesVersion122 = v122
 }
 
+// VersionLessThan121 reports whether batch/v1 CronJob is unavailable in the cluster.
+func VersionLessThan121(ver *k8sversion.Info) bool {
+	currVersion, _ := version.ParseGeneric(ver.String())
+	return currVersion.LessThan(KubernetesVersion121)
+}
+
 func VersionLessThan122(ver *k8sversion.Info) bool {
 	currVersion, _ := version.ParseGeneric(ver.String())
 	return currVersion.LessThan(KubernetesVersion122)
 }
+
+// GetClusterVersion returns the server version of the cluster, the version is cached per cluster to avoid
+// querying the discovery api on every call.
+func GetClusterVersion(hubServerAddr, clusterID string) (*k8sversion.Info, error) {
+	if cached, ok := clusterVersions.Load(clusterID); ok {
+		if v := cached.(*clusterVersion); time.Now().Before(v.expiresAt) {
+			return v.info, nil
+		}
+	}
+
+	clientset, err := GetKubeClientSet(hubServerAddr, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	info, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+	clusterVersions.Store(clusterID, &clusterVersion{info: info, expiresAt: time.Now().Add(clusterVersionCacheTTL)})
+	return info, nil
+}
+
+// ClusterVersionLessThan121 reports whether batch/v1 CronJob is unavailable in the cluster.
+func ClusterVersionLessThan121(hubServerAddr, clusterID string) (bool, error) {
+	info, err := GetClusterVersion(hubServerAddr, clusterID)
+	if err != nil {
+		return false, err
+	}
+	return VersionLessThan121(info), nil
+}
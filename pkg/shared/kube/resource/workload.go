@@ -22,6 +22,8 @@ type Workload struct {
 	Images   []ContainerImage `json:"images"`
 	Pods     []*Pod           `json:"pods"`
 	Replicas int32            `json:"replicas"`
+	// Suspend is only used by CronJob.
+	Suspend bool `json:"suspend,omitempty"`
 }
 
 type ContainerImage struct {
@@ -20,7 +20,11 @@ import (
 	"time"
 
 	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/duration"
+
+	"github.com/koderover/zadig/pkg/setting"
+	"github.com/koderover/zadig/pkg/shared/kube/resource"
 )
 
 type cronJobV1Beta struct {
@@ -46,3 +50,25 @@ func (cj *cronJobV1Beta) ImageInfos() (images []string) {
 func (cj *cronJobV1Beta) GetAge() string {
 	return duration.HumanDuration(time.Now().Sub(cj.CreationTimestamp.Time))
 }
+
+// WorkloadResource returns the workload of the CronJob, the pods are those of its active jobs.
+func (cj *cronJobV1Beta) WorkloadResource(pods []*corev1.Pod) *resource.Workload {
+	wl := &resource.Workload{
+		Name:     cj.Name,
+		Type:     setting.CronJob,
+		Replicas: int32(len(cj.Status.Active)),
+		Pods:     make([]*resource.Pod, 0, len(pods)),
+	}
+	if cj.Spec.Suspend != nil {
+		wl.Suspend = *cj.Spec.Suspend
+	}
+
+	for _, c := range cj.Spec.JobTemplate.Spec.Template.Spec.Containers {
+		wl.Images = append(wl.Images, resource.ContainerImage{Name: c.Name, Image: c.Image})
+	}
+
+	for _, p := range pods {
+		wl.Pods = append(wl.Pods, Pod(p).Resource())
+	}
+	return wl
+}
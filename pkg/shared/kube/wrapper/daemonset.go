@@ -20,6 +20,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 
+	"github.com/koderover/zadig/pkg/setting"
 	"github.com/koderover/zadig/pkg/shared/kube/resource"
 )
 
@@ -50,10 +51,17 @@ func (d *daemonset) ImageInfos() (images []string) {
 	return
 }
 
+// Ready reports whether the latest revision has been rolled out to all the scheduled nodes.
+func (d *daemonset) Ready() bool {
+	return d.Status.ObservedGeneration >= d.Generation &&
+		d.Status.UpdatedNumberScheduled == d.Status.DesiredNumberScheduled &&
+		d.Status.NumberAvailable == d.Status.DesiredNumberScheduled
+}
+
 func (d *daemonset) WorkloadResource(pods []*corev1.Pod) *resource.Workload {
 	wl := &resource.Workload{
 		Name:     d.Name,
-		Type:     "DaemonSet",
+		Type:     setting.DaemonSet,
 		Replicas: d.Status.NumberReady,
 		Pods:     make([]*resource.Pod, 0, len(pods)),
 	}
@@ -0,0 +1,122 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package asciicast
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const Version = 2
+
+const (
+	EventOutput = "o"
+	EventInput  = "i"
+	EventResize = "r"
+)
+
+// Recorder receives the traffic of an interactive terminal.
+type Recorder interface {
+	Output(data []byte)
+	Input(data []byte)
+	Resize(cols, rows int)
+}
+
+// Header is the first line of an asciicast v2 file.
+// See https://github.com/asciinema/asciinema/blob/develop/doc/asciicast-v2.md
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Writer writes terminal events to w in asciicast v2 format. It is safe for concurrent use,
+// write errors are kept and returned by Err so that a broken recording never interrupts a session.
+type Writer struct {
+	mu    sync.Mutex
+	w     io.Writer
+	start time.Time
+	err   error
+	now   func() time.Time
+}
+
+func NewWriter(w io.Writer, header *Header) (*Writer, error) {
+	return newWriter(w, header, time.Now)
+}
+
+func newWriter(w io.Writer, header *Header, now func() time.Time) (*Writer, error) {
+	start := now()
+	header.Version = Version
+	if header.Timestamp == 0 {
+		header.Timestamp = start.Unix()
+	}
+	line, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(append(line, '\n')); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w, start: start, now: now}, nil
+}
+
+func (w *Writer) Output(data []byte) {
+	w.event(EventOutput, string(data))
+}
+
+func (w *Writer) Input(data []byte) {
+	w.event(EventInput, string(data))
+}
+
+func (w *Writer) Resize(cols, rows int) {
+	if cols <= 0 || rows <= 0 {
+		return
+	}
+	w.event(EventResize, fmt.Sprintf("%dx%d", cols, rows))
+}
+
+// Err returns the first error met while writing events.
+func (w *Writer) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+func (w *Writer) event(code, data string) {
+	if data == "" {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return
+	}
+	elapsed := float64(w.now().Sub(w.start).Microseconds()) / 1e6
+	line, err := json.Marshal([]interface{}{elapsed, code, data})
+	if err != nil {
+		w.err = err
+		return
+	}
+	_, w.err = w.w.Write(append(line, '\n'))
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package asciicast
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriter(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	clock := start
+	buf := &bytes.Buffer{}
+
+	w, err := newWriter(buf, &Header{Width: 80, Height: 24, Env: map[string]string{"SHELL": "/bin/sh"}}, func() time.Time { return clock })
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock = start.Add(1500 * time.Millisecond)
+	w.Input([]byte("ls\r"))
+	w.Output([]byte("a.txt\r\n"))
+	w.Output(nil)
+	w.Resize(120, 40)
+	w.Resize(0, 40)
+	if err := w.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines, got %d: %q", len(lines), buf.String())
+	}
+
+	header := &Header{}
+	if err := json.Unmarshal([]byte(lines[0]), header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Version != 2 || header.Width != 80 || header.Height != 24 || header.Timestamp != start.Unix() {
+		t.Errorf("unexpected header: %+v", header)
+	}
+
+	expected := []struct {
+		code string
+		data string
+	}{
+		{EventInput, "ls\r"},
+		{EventOutput, "a.txt\r\n"},
+		{EventResize, "120x40"},
+	}
+	for i, e := range expected {
+		var event []interface{}
+		if err := json.Unmarshal([]byte(lines[i+1]), &event); err != nil {
+			t.Fatal(err)
+		}
+		if event[0].(float64) != 1.5 || event[1] != e.code || event[2] != e.data {
+			t.Errorf("event %d: expected [1.5 %s %q], got %v", i, e.code, e.data, event)
+		}
+	}
+}
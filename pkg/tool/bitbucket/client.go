@@ -0,0 +1,201 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitbucket
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+const (
+	// CloudAddress is the address of Bitbucket Cloud, any other address is treated as Bitbucket Server/Data Center.
+	CloudAddress = "https://bitbucket.org"
+
+	cloudAPIAddress    = "https://api.bitbucket.org/2.0"
+	serverAPIPrefix    = "/rest/api/1.0"
+	serverStatusPrefix = "/rest/build-status/1.0"
+
+	defaultPerPage = 100
+	// maxPages limits the number of pages fetched when listing all items of a resource
+	maxPages = 20
+)
+
+// Client talks to both Bitbucket Cloud (REST API 2.0) and Bitbucket Server/Data Center (REST API 1.0).
+// In Bitbucket Cloud the namespace of a repository is the workspace slug,
+// in Bitbucket Server it is the project key, personal projects start with a "~".
+type Client struct {
+	*httpclient.Client
+
+	Address  string
+	Username string
+	IsCloud  bool
+}
+
+// IsCloud reports whether the given address points to Bitbucket Cloud.
+func IsCloud(address string) bool {
+	u, err := url.Parse(address)
+	if err != nil {
+		return false
+	}
+	return u.Host == "bitbucket.org" || u.Host == "api.bitbucket.org"
+}
+
+// NewClient creates a Bitbucket client. If username is not empty, token is used as an app password (Cloud)
+// or password/personal access token (Server) in basic auth, otherwise it is sent as a bearer token,
+// which is what the OAuth flow of Bitbucket Cloud and the HTTP access tokens of Bitbucket Server expect.
+func NewClient(address, username, token, proxyAddr string, enableProxy bool) *Client {
+	address = strings.TrimSuffix(address, "/")
+	isCloud := IsCloud(address)
+
+	host := address
+	if isCloud {
+		host = cloudAPIAddress
+	}
+	cfs := []httpclient.ClientFunc{httpclient.SetHostURL(host)}
+	if username != "" {
+		cfs = append(cfs, httpclient.SetBasicAuth(username, token))
+	} else if token != "" {
+		cfs = append(cfs, httpclient.SetAuthToken(token))
+	}
+	if enableProxy && proxyAddr != "" {
+		cfs = append(cfs, httpclient.SetProxy(proxyAddr))
+	}
+
+	return &Client{
+		Client:   httpclient.New(cfs...),
+		Address:  address,
+		Username: username,
+		IsCloud:  isCloud,
+	}
+}
+
+func (c *Client) api(format string, a ...interface{}) string {
+	for i, v := range a {
+		if s, ok := v.(string); ok {
+			a[i] = url.PathEscape(s)
+		}
+	}
+	p := fmt.Sprintf(format, a...)
+	if c.IsCloud {
+		return p
+	}
+	return serverAPIPrefix + p
+}
+
+// repoPath returns the api path of a repository.
+func (c *Client) repoPath(namespace, repo string) string {
+	if c.IsCloud {
+		return c.api("/repositories/%s/%s", namespace, repo)
+	}
+	return c.api("/projects/%s/repos/%s", namespace, repo)
+}
+
+type cloudPage struct {
+	Values []json.RawMessage `json:"values"`
+	Next   string            `json:"next"`
+}
+
+type serverPage struct {
+	Values        []json.RawMessage `json:"values"`
+	IsLastPage    bool              `json:"isLastPage"`
+	NextPageStart int               `json:"nextPageStart"`
+}
+
+// listPage fetches a single page of a paged resource, page starts from 1.
+func (c *Client) listPage(path string, params map[string]string, page, perPage int) ([]json.RawMessage, bool, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if perPage <= 0 {
+		perPage = defaultPerPage
+	}
+	qs := make(map[string]string, len(params)+2)
+	for k, v := range params {
+		qs[k] = v
+	}
+
+	if c.IsCloud {
+		qs["page"] = strconv.Itoa(page)
+		qs["pagelen"] = strconv.Itoa(perPage)
+		res := &cloudPage{}
+		if _, err := c.Get(path, httpclient.SetQueryParams(qs), httpclient.SetResult(res)); err != nil {
+			return nil, false, err
+		}
+		return res.Values, res.Next != "", nil
+	}
+
+	qs["start"] = strconv.Itoa((page - 1) * perPage)
+	qs["limit"] = strconv.Itoa(perPage)
+	res := &serverPage{}
+	if _, err := c.Get(path, httpclient.SetQueryParams(qs), httpclient.SetResult(res)); err != nil {
+		return nil, false, err
+	}
+	return res.Values, !res.IsLastPage, nil
+}
+
+// listAll fetches all pages of a paged resource and decodes every item with decode.
+func (c *Client) listAll(path string, params map[string]string, decode func(json.RawMessage) error) error {
+	for page := 1; page <= maxPages; page++ {
+		values, hasNext, err := c.listPage(path, params, page, defaultPerPage)
+		if err != nil {
+			return err
+		}
+		for _, v := range values {
+			if err := decode(v); err != nil {
+				return err
+			}
+		}
+		if !hasNext {
+			break
+		}
+	}
+	return nil
+}
+
+// CloneURL returns the https clone url of a repository with the credentials embedded.
+// OAuth and HTTP access tokens are used with the reserved user "x-token-auth".
+func CloneURL(address, username, token, namespace, repo string) string {
+	u, err := url.Parse(strings.TrimSuffix(address, "/"))
+	if err != nil {
+		return ""
+	}
+	if username == "" {
+		username = "x-token-auth"
+	}
+	u.User = url.UserPassword(username, token)
+	if IsCloud(address) {
+		u.Path = fmt.Sprintf("/%s/%s.git", namespace, repo)
+	} else {
+		u.Path = fmt.Sprintf("%s/scm/%s/%s.git", u.Path, strings.ToLower(namespace), repo)
+	}
+	return u.String()
+}
+
+// BrowseURL returns the web url of a file or directory of a repository on branch.
+func BrowseURL(address, namespace, repo, branch, filePath string) string {
+	address = strings.TrimSuffix(address, "/")
+	filePath = strings.TrimPrefix(filePath, "/")
+	if IsCloud(address) {
+		return fmt.Sprintf("%s/%s/%s/src/%s/%s", address, namespace, repo, branch, filePath)
+	}
+	return fmt.Sprintf("%s/projects/%s/repos/%s/browse/%s?at=%s", address, namespace, repo, filePath, url.QueryEscape("refs/heads/"+branch))
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitbucket
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// EventType represents a bitbucket event type.
+type EventType string
+
+// List of available event types.
+const (
+	// Bitbucket Cloud
+	EventTypeRepoPush           EventType = "repo:push"
+	EventTypePullRequestCreated EventType = "pullrequest:created"
+	EventTypePullRequestUpdated EventType = "pullrequest:updated"
+
+	// Bitbucket Server/Data Center
+	EventTypeRefsChanged      EventType = "repo:refs_changed"
+	EventTypePROpened         EventType = "pr:opened"
+	EventTypePRFromRefUpdated EventType = "pr:from_ref_updated"
+	EventTypeDiagnosticsPing  EventType = "diagnostics:ping"
+)
+
+const (
+	eventTypeHeader = "X-Event-Key"
+	signatureHeader = "X-Hub-Signature"
+
+	RefTypeBranch = "branch"
+	RefTypeTag    = "tag"
+)
+
+// HookEventType returns the event type for the given request.
+func HookEventType(r *http.Request) EventType {
+	return EventType(r.Header.Get(eventTypeHeader))
+}
+
+// ValidateSignature checks the HMAC-SHA256 signature Bitbucket adds to hooks configured with a secret.
+func ValidateSignature(r *http.Request, payload []byte, secret string) error {
+	if secret == "" {
+		return nil
+	}
+	signature := strings.TrimPrefix(r.Header.Get(signatureHeader), "sha256=")
+	if signature == "" {
+		return errors.New("missing signature")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	if !hmac.Equal([]byte(signature), []byte(hex.EncodeToString(mac.Sum(nil)))) {
+		return errors.New("signature is illegal")
+	}
+	return nil
+}
+
+// PushEvent is a push to branches or tags, Bitbucket may put several ref changes into one event.
+type PushEvent struct {
+	Namespace string
+	Repo      string
+	Actor     string
+	Changes   []*RefChange
+}
+
+type RefChange struct {
+	// Ref is the full name of the reference, e.g. refs/heads/main
+	Ref     string
+	Name    string
+	Type    string
+	Before  string
+	After   string
+	Deleted bool
+	Message string
+	Author  string
+}
+
+type PullRequestEvent struct {
+	EventType   EventType
+	Namespace   string
+	Repo        string
+	Actor       string
+	PullRequest *PullRequest
+}
+
+// ParseHook parses the payload of a Bitbucket Cloud or Bitbucket Server event into
+// a *PushEvent or *PullRequestEvent.
+func ParseHook(eventType EventType, payload []byte) (interface{}, error) {
+	switch eventType {
+	case EventTypeRepoPush:
+		ev := &cloudPushEvent{}
+		if err := json.Unmarshal(payload, ev); err != nil {
+			return nil, err
+		}
+		return ev.toPushEvent(), nil
+	case EventTypePullRequestCreated, EventTypePullRequestUpdated:
+		ev := &cloudPullRequestEvent{}
+		if err := json.Unmarshal(payload, ev); err != nil {
+			return nil, err
+		}
+		namespace, repo := splitFullName(ev.Repository.FullName)
+		return &PullRequestEvent{
+			EventType:   eventType,
+			Namespace:   namespace,
+			Repo:        repo,
+			Actor:       ev.Actor.name(),
+			PullRequest: ev.PullRequest.toPullRequest(),
+		}, nil
+	case EventTypeRefsChanged:
+		ev := &serverRefsChangedEvent{}
+		if err := json.Unmarshal(payload, ev); err != nil {
+			return nil, err
+		}
+		return ev.toPushEvent(), nil
+	case EventTypePROpened, EventTypePRFromRefUpdated:
+		ev := &serverPullRequestEvent{}
+		if err := json.Unmarshal(payload, ev); err != nil {
+			return nil, err
+		}
+		return &PullRequestEvent{
+			EventType:   eventType,
+			Namespace:   ev.PullRequest.ToRef.Repository.Project.Key,
+			Repo:        ev.PullRequest.ToRef.Repository.Slug,
+			Actor:       ev.Actor.Name,
+			PullRequest: ev.PullRequest.toPullRequest(),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected event type: %s", eventType)
+}
+
+func splitFullName(fullName string) (string, string) {
+	i := strings.Index(fullName, "/")
+	if i < 0 {
+		return "", fullName
+	}
+	return fullName[:i], fullName[i+1:]
+}
+
+type cloudActor struct {
+	DisplayName string `json:"display_name"`
+	Nickname    string `json:"nickname"`
+}
+
+func (a cloudActor) name() string {
+	if a.Nickname != "" {
+		return a.Nickname
+	}
+	return a.DisplayName
+}
+
+type cloudEventRepository struct {
+	FullName string `json:"full_name"`
+}
+
+type cloudPushRef struct {
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Target struct {
+		Hash    string `json:"hash"`
+		Message string `json:"message"`
+		Author  struct {
+			Raw string `json:"raw"`
+		} `json:"author"`
+	} `json:"target"`
+}
+
+type cloudPushEvent struct {
+	Actor      cloudActor           `json:"actor"`
+	Repository cloudEventRepository `json:"repository"`
+	Push       struct {
+		Changes []struct {
+			New *cloudPushRef `json:"new"`
+			Old *cloudPushRef `json:"old"`
+		} `json:"changes"`
+	} `json:"push"`
+}
+
+func (ev *cloudPushEvent) toPushEvent() *PushEvent {
+	namespace, repo := splitFullName(ev.Repository.FullName)
+	res := &PushEvent{Namespace: namespace, Repo: repo, Actor: ev.Actor.name()}
+	for _, change := range ev.Push.Changes {
+		rc := &RefChange{}
+		ref := change.New
+		if ref == nil {
+			ref = change.Old
+			rc.Deleted = true
+		} else {
+			rc.After = ref.Target.Hash
+			rc.Message = ref.Target.Message
+			rc.Author = ref.Target.Author.Raw
+		}
+		if ref == nil {
+			continue
+		}
+		if change.Old != nil {
+			rc.Before = change.Old.Target.Hash
+		}
+		rc.Name = ref.Name
+		rc.Type = ref.Type
+		if rc.Type == RefTypeTag {
+			rc.Ref = "refs/tags/" + ref.Name
+		} else {
+			rc.Type = RefTypeBranch
+			rc.Ref = "refs/heads/" + ref.Name
+		}
+		res.Changes = append(res.Changes, rc)
+	}
+	return res
+}
+
+type cloudPullRequestEvent struct {
+	Actor       cloudActor           `json:"actor"`
+	Repository  cloudEventRepository `json:"repository"`
+	PullRequest cloudPullRequest     `json:"pullrequest"`
+}
+
+type serverRefsChangedEvent struct {
+	Actor      serverUser       `json:"actor"`
+	Repository serverRepository `json:"repository"`
+	Changes    []struct {
+		Ref struct {
+			ID        string `json:"id"`
+			DisplayID string `json:"displayId"`
+			Type      string `json:"type"`
+		} `json:"ref"`
+		FromHash string `json:"fromHash"`
+		ToHash   string `json:"toHash"`
+		Type     string `json:"type"`
+	} `json:"changes"`
+}
+
+func (ev *serverRefsChangedEvent) toPushEvent() *PushEvent {
+	res := &PushEvent{Namespace: ev.Repository.Project.Key, Repo: ev.Repository.Slug, Actor: ev.Actor.Name}
+	for _, change := range ev.Changes {
+		rc := &RefChange{
+			Ref:     change.Ref.ID,
+			Name:    change.Ref.DisplayID,
+			Type:    strings.ToLower(change.Ref.Type),
+			Deleted: change.Type == "DELETE",
+			Author:  ev.Actor.Name,
+		}
+		if change.Type != "ADD" {
+			rc.Before = change.FromHash
+		}
+		if !rc.Deleted {
+			rc.After = change.ToHash
+		}
+		res.Changes = append(res.Changes, rc)
+	}
+	return res
+}
+
+type serverPullRequestEvent struct {
+	Actor       serverUser        `json:"actor"`
+	PullRequest serverPullRequest `json:"pullRequest"`
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitbucket
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseCloudPushEvent(t *testing.T) {
+	payload := []byte(`{
+  "actor": {"display_name": "Jane Doe", "nickname": "jane"},
+  "repository": {"full_name": "acme/api"},
+  "push": {"changes": [
+    {"new": {"type": "branch", "name": "main", "target": {"hash": "b2", "message": "fix", "author": {"raw": "Jane <jane@acme.io>"}}},
+     "old": {"type": "branch", "name": "main", "target": {"hash": "b1"}}},
+    {"new": {"type": "tag", "name": "v1.0.0", "target": {"hash": "b2"}}, "old": null},
+    {"new": null, "old": {"type": "branch", "name": "feature", "target": {"hash": "f1"}}}
+  ]}
+}`)
+	event, err := ParseHook(EventTypeRepoPush, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev, ok := event.(*PushEvent)
+	if !ok {
+		t.Fatalf("unexpected event %T", event)
+	}
+	if ev.Namespace != "acme" || ev.Repo != "api" || ev.Actor != "jane" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+	if len(ev.Changes) != 3 {
+		t.Fatalf("expected 3 changes, got %d", len(ev.Changes))
+	}
+	if c := ev.Changes[0]; c.Ref != "refs/heads/main" || c.Before != "b1" || c.After != "b2" || c.Deleted {
+		t.Errorf("unexpected branch change %+v", c)
+	}
+	if c := ev.Changes[1]; c.Ref != "refs/tags/v1.0.0" || c.Type != RefTypeTag || c.Before != "" {
+		t.Errorf("unexpected tag change %+v", c)
+	}
+	if c := ev.Changes[2]; !c.Deleted || c.After != "" || c.Ref != "refs/heads/feature" {
+		t.Errorf("unexpected deleted change %+v", c)
+	}
+}
+
+func TestParseCloudPullRequestEvent(t *testing.T) {
+	payload := []byte(`{
+  "actor": {"nickname": "jane"},
+  "repository": {"full_name": "acme/api"},
+  "pullrequest": {"id": 3, "title": "feat", "state": "OPEN", "author": {"nickname": "jane"},
+    "source": {"branch": {"name": "main"}, "commit": {"hash": "c1"}, "repository": {"full_name": "jane/api"}},
+    "destination": {"branch": {"name": "main"}, "commit": {"hash": "b2"}, "repository": {"full_name": "acme/api"}}}
+}`)
+	event, err := ParseHook(EventTypePullRequestCreated, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := event.(*PullRequestEvent)
+	if ev.Namespace != "acme" || ev.Repo != "api" || ev.PullRequest.ID != 3 || ev.PullRequest.SourceBranch != "main" {
+		t.Fatalf("unexpected event %+v %+v", ev, ev.PullRequest)
+	}
+	if !ev.PullRequest.FromFork() {
+		t.Errorf("pull request from jane/api should be from a fork")
+	}
+}
+
+func TestParseServerEvents(t *testing.T) {
+	push := []byte(`{
+  "eventKey": "repo:refs_changed",
+  "actor": {"name": "jane"},
+  "repository": {"slug": "api", "project": {"key": "ACME"}},
+  "changes": [{"ref": {"id": "refs/heads/main", "displayId": "main", "type": "BRANCH"}, "fromHash": "0000000000000000000000000000000000000000", "toHash": "b2", "type": "ADD"}]
+}`)
+	event, err := ParseHook(EventTypeRefsChanged, push)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := event.(*PushEvent)
+	if ev.Namespace != "ACME" || ev.Repo != "api" || len(ev.Changes) != 1 {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+	if c := ev.Changes[0]; c.Type != RefTypeBranch || c.Before != "" || c.After != "b2" {
+		t.Errorf("unexpected change %+v", c)
+	}
+
+	pr := []byte(`{
+  "eventKey": "pr:opened",
+  "actor": {"name": "jane"},
+  "pullRequest": {"id": 7, "title": "feat", "state": "OPEN", "author": {"user": {"name": "jane"}},
+    "fromRef": {"displayId": "feature", "latestCommit": "c1", "repository": {"slug": "api", "project": {"key": "ACME"}}},
+    "toRef": {"displayId": "main", "latestCommit": "b2", "repository": {"slug": "api", "project": {"key": "ACME"}}}}
+}`)
+	event, err = ParseHook(EventTypePROpened, pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prEv := event.(*PullRequestEvent)
+	if prEv.Namespace != "ACME" || prEv.Repo != "api" || prEv.PullRequest.ID != 7 ||
+		prEv.PullRequest.SourceCommit != "c1" || prEv.PullRequest.TargetBranch != "main" {
+		t.Fatalf("unexpected event %+v %+v", prEv, prEv.PullRequest)
+	}
+	if prEv.PullRequest.FromFork() {
+		t.Errorf("pull request inside ACME/api should not be from a fork")
+	}
+}
+
+func TestValidateSignature(t *testing.T) {
+	payload := []byte(`{"a":1}`)
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+	if err := ValidateSignature(r, payload, "s3cr3t"); err == nil {
+		t.Error("expected an error when the signature is missing")
+	}
+	// echo -n '{"a":1}' | openssl dgst -sha256 -hmac s3cr3t
+	r.Header.Set(signatureHeader, "sha256=d42927434049e0b8c73ce887062238cc1c6bb6644bfe66e66d8dd0f30b85679e")
+	if err := ValidateSignature(r, payload, "s3cr3t"); err != nil {
+		t.Errorf("expected a valid signature, got %v", err)
+	}
+	if err := ValidateSignature(r, payload, "other"); err == nil {
+		t.Error("expected an error for a wrong secret")
+	}
+	if err := ValidateSignature(r, payload, ""); err != nil {
+		t.Errorf("empty secret should skip validation, got %v", err)
+	}
+}
@@ -0,0 +1,295 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitbucket
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+type PullRequest struct {
+	ID           int    `json:"id"`
+	Title        string `json:"title"`
+	State        string `json:"state"`
+	Author       string `json:"author"`
+	SourceBranch string `json:"source_branch"`
+	SourceCommit string `json:"source_commit"`
+	TargetBranch string `json:"target_branch"`
+	// SourceRepo and TargetRepo are the full names of the repositories, e.g. namespace/repo
+	SourceRepo string `json:"source_repo"`
+	TargetRepo string `json:"target_repo"`
+	CreatedAt  int64  `json:"created_at"`
+	UpdatedAt  int64  `json:"updated_at"`
+}
+
+// FromFork reports whether the source branch of the pull request lives in another repository.
+func (pr *PullRequest) FromFork() bool {
+	return pr.SourceRepo != "" && !strings.EqualFold(pr.SourceRepo, pr.TargetRepo)
+}
+
+type cloudPullRequest struct {
+	ID     int    `json:"id"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	Author struct {
+		DisplayName string `json:"display_name"`
+		Nickname    string `json:"nickname"`
+	} `json:"author"`
+	Source      cloudPullRequestRef `json:"source"`
+	Destination cloudPullRequestRef `json:"destination"`
+	CreatedOn   time.Time           `json:"created_on"`
+	UpdatedOn   time.Time           `json:"updated_on"`
+}
+
+type cloudPullRequestRef struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+	Commit struct {
+		Hash string `json:"hash"`
+	} `json:"commit"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+func (pr *cloudPullRequest) toPullRequest() *PullRequest {
+	author := pr.Author.Nickname
+	if author == "" {
+		author = pr.Author.DisplayName
+	}
+	return &PullRequest{
+		ID:           pr.ID,
+		Title:        pr.Title,
+		State:        pr.State,
+		Author:       author,
+		SourceBranch: pr.Source.Branch.Name,
+		SourceCommit: pr.Source.Commit.Hash,
+		TargetBranch: pr.Destination.Branch.Name,
+		SourceRepo:   pr.Source.Repository.FullName,
+		TargetRepo:   pr.Destination.Repository.FullName,
+		CreatedAt:    pr.CreatedOn.Unix(),
+		UpdatedAt:    pr.UpdatedOn.Unix(),
+	}
+}
+
+type serverPullRequest struct {
+	ID     int    `json:"id"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	Author struct {
+		User serverUser `json:"user"`
+	} `json:"author"`
+	FromRef     serverPullRequestRef `json:"fromRef"`
+	ToRef       serverPullRequestRef `json:"toRef"`
+	CreatedDate int64                `json:"createdDate"`
+	UpdatedDate int64                `json:"updatedDate"`
+}
+
+type serverUser struct {
+	Name         string `json:"name"`
+	DisplayName  string `json:"displayName"`
+	EmailAddress string `json:"emailAddress"`
+}
+
+type serverPullRequestRef struct {
+	ID           string           `json:"id"`
+	DisplayID    string           `json:"displayId"`
+	LatestCommit string           `json:"latestCommit"`
+	Repository   serverRepository `json:"repository"`
+}
+
+func (pr *serverPullRequest) toPullRequest() *PullRequest {
+	return &PullRequest{
+		ID:           pr.ID,
+		Title:        pr.Title,
+		State:        pr.State,
+		Author:       pr.Author.User.Name,
+		SourceBranch: pr.FromRef.DisplayID,
+		SourceCommit: pr.FromRef.LatestCommit,
+		TargetBranch: pr.ToRef.DisplayID,
+		SourceRepo:   pr.FromRef.Repository.Project.Key + "/" + pr.FromRef.Repository.Slug,
+		TargetRepo:   pr.ToRef.Repository.Project.Key + "/" + pr.ToRef.Repository.Slug,
+		CreatedAt:    pr.CreatedDate / 1000,
+		UpdatedAt:    pr.UpdatedDate / 1000,
+	}
+}
+
+// ListOpenPullRequests returns the open pull requests of a repository, targetBranch is optional.
+func (c *Client) ListOpenPullRequests(namespace, repo, targetBranch string) ([]*PullRequest, error) {
+	var res []*PullRequest
+	if c.IsCloud {
+		params := map[string]string{"state": "OPEN"}
+		if targetBranch != "" {
+			params["q"] = fmt.Sprintf("destination.branch.name = %q", targetBranch)
+		}
+		err := c.listAll(c.repoPath(namespace, repo)+"/pullrequests", params, func(raw json.RawMessage) error {
+			pr := &cloudPullRequest{}
+			if err := json.Unmarshal(raw, pr); err != nil {
+				return err
+			}
+			res = append(res, pr.toPullRequest())
+			return nil
+		})
+		return res, err
+	}
+
+	params := map[string]string{"state": "OPEN"}
+	if targetBranch != "" {
+		params["at"] = "refs/heads/" + targetBranch
+		params["direction"] = "INCOMING"
+	}
+	err := c.listAll(c.repoPath(namespace, repo)+"/pull-requests", params, func(raw json.RawMessage) error {
+		pr := &serverPullRequest{}
+		if err := json.Unmarshal(raw, pr); err != nil {
+			return err
+		}
+		res = append(res, pr.toPullRequest())
+		return nil
+	})
+	return res, err
+}
+
+type cloudDiffStat struct {
+	Old *struct {
+		Path string `json:"path"`
+	} `json:"old"`
+	New *struct {
+		Path string `json:"path"`
+	} `json:"new"`
+}
+
+type serverChange struct {
+	Path struct {
+		ToString string `json:"toString"`
+	} `json:"path"`
+	SrcPath *struct {
+		ToString string `json:"toString"`
+	} `json:"srcPath"`
+}
+
+func (c *Client) listChanges(path string, params map[string]string) ([]string, error) {
+	var files []string
+	if c.IsCloud {
+		err := c.listAll(path, params, func(raw json.RawMessage) error {
+			d := &cloudDiffStat{}
+			if err := json.Unmarshal(raw, d); err != nil {
+				return err
+			}
+			if d.New != nil {
+				files = append(files, d.New.Path)
+			}
+			if d.Old != nil && (d.New == nil || d.Old.Path != d.New.Path) {
+				files = append(files, d.Old.Path)
+			}
+			return nil
+		})
+		return files, err
+	}
+
+	err := c.listAll(path, params, func(raw json.RawMessage) error {
+		ch := &serverChange{}
+		if err := json.Unmarshal(raw, ch); err != nil {
+			return err
+		}
+		files = append(files, ch.Path.ToString)
+		if ch.SrcPath != nil && ch.SrcPath.ToString != ch.Path.ToString {
+			files = append(files, ch.SrcPath.ToString)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// ListChangedFiles returns the files changed between two commits.
+func (c *Client) ListChangedFiles(namespace, repo, from, to string) ([]string, error) {
+	if c.IsCloud {
+		// the spec of diffstat is "<new>..<old>"
+		return c.listChanges(c.repoPath(namespace, repo)+"/diffstat/"+to+".."+from, nil)
+	}
+	return c.listChanges(c.repoPath(namespace, repo)+"/changes", map[string]string{"since": from, "until": to})
+}
+
+// ListPullRequestChangedFiles returns the files changed in a pull request.
+func (c *Client) ListPullRequestChangedFiles(namespace, repo string, id int) ([]string, error) {
+	if c.IsCloud {
+		return c.listChanges(c.repoPath(namespace, repo)+"/pullrequests/"+strconv.Itoa(id)+"/diffstat", nil)
+	}
+	return c.listChanges(c.repoPath(namespace, repo)+"/pull-requests/"+strconv.Itoa(id)+"/changes", nil)
+}
+
+type Comment struct {
+	ID      int `json:"id"`
+	Version int `json:"version"`
+}
+
+type cloudComment struct {
+	Content struct {
+		Raw string `json:"raw"`
+	} `json:"content"`
+}
+
+type serverComment struct {
+	Text    string `json:"text"`
+	Version int    `json:"version,omitempty"`
+}
+
+func (c *Client) commentPath(namespace, repo string, prID int) string {
+	if c.IsCloud {
+		return fmt.Sprintf("%s/pullrequests/%d/comments", c.repoPath(namespace, repo), prID)
+	}
+	return fmt.Sprintf("%s/pull-requests/%d/comments", c.repoPath(namespace, repo), prID)
+}
+
+func (c *Client) commentBody(body string, version int) interface{} {
+	if c.IsCloud {
+		cc := &cloudComment{}
+		cc.Content.Raw = body
+		return cc
+	}
+	return &serverComment{Text: body, Version: version}
+}
+
+// CreatePullRequestComment adds a markdown comment to a pull request and returns its id.
+func (c *Client) CreatePullRequestComment(namespace, repo string, prID int, body string) (int, error) {
+	res := &Comment{}
+	if _, err := c.Post(c.commentPath(namespace, repo, prID), httpclient.SetBody(c.commentBody(body, 0)), httpclient.SetResult(res)); err != nil {
+		return 0, err
+	}
+	return res.ID, nil
+}
+
+// UpdatePullRequestComment replaces the content of a pull request comment.
+// Bitbucket Server uses optimistic locking on comments, so the current version is fetched before updating.
+func (c *Client) UpdatePullRequestComment(namespace, repo string, prID, commentID int, body string) error {
+	path := fmt.Sprintf("%s/%d", c.commentPath(namespace, repo, prID), commentID)
+	version := 0
+	if !c.IsCloud {
+		current := &Comment{}
+		if _, err := c.Get(path, httpclient.SetResult(current)); err != nil {
+			return err
+		}
+		version = current.Version
+	}
+	_, err := c.Put(path, httpclient.SetBody(c.commentBody(body, version)))
+	return err
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitbucket
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+type Branch struct {
+	Name         string `json:"name"`
+	LatestCommit string `json:"latest_commit"`
+	Message      string `json:"message"`
+}
+
+type Tag struct {
+	Name    string `json:"name"`
+	Commit  string `json:"commit"`
+	Message string `json:"message"`
+}
+
+type cloudRef struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Target  struct {
+		Hash    string `json:"hash"`
+		Message string `json:"message"`
+	} `json:"target"`
+}
+
+func (c *Client) ListBranches(namespace, repo string) ([]*Branch, error) {
+	var res []*Branch
+	if c.IsCloud {
+		err := c.listAll(c.repoPath(namespace, repo)+"/refs/branches", nil, func(raw json.RawMessage) error {
+			ref := &cloudRef{}
+			if err := json.Unmarshal(raw, ref); err != nil {
+				return err
+			}
+			res = append(res, &Branch{Name: ref.Name, LatestCommit: ref.Target.Hash, Message: ref.Target.Message})
+			return nil
+		})
+		return res, err
+	}
+
+	err := c.listAll(c.repoPath(namespace, repo)+"/branches", nil, func(raw json.RawMessage) error {
+		ref := &serverRef{}
+		if err := json.Unmarshal(raw, ref); err != nil {
+			return err
+		}
+		res = append(res, &Branch{Name: ref.DisplayID, LatestCommit: ref.LatestCommit})
+		return nil
+	})
+	return res, err
+}
+
+// GetBranch returns the branch with its latest commit.
+func (c *Client) GetBranch(namespace, repo, branch string) (*Branch, error) {
+	if c.IsCloud {
+		ref := &cloudRef{}
+		if _, err := c.Get(c.repoPath(namespace, repo)+"/refs/branches/"+branch, httpclient.SetResult(ref)); err != nil {
+			return nil, err
+		}
+		return &Branch{Name: ref.Name, LatestCommit: ref.Target.Hash, Message: ref.Target.Message}, nil
+	}
+
+	values, _, err := c.listPage(c.repoPath(namespace, repo)+"/branches", map[string]string{"filterText": branch}, 1, defaultPerPage)
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range values {
+		ref := &serverRef{}
+		if err := json.Unmarshal(raw, ref); err != nil {
+			return nil, err
+		}
+		if ref.DisplayID != branch {
+			continue
+		}
+		commit := &struct {
+			Message string `json:"message"`
+		}{}
+		if _, err := c.Get(c.repoPath(namespace, repo)+"/commits/"+ref.LatestCommit, httpclient.SetResult(commit)); err != nil {
+			return nil, err
+		}
+		return &Branch{Name: ref.DisplayID, LatestCommit: ref.LatestCommit, Message: commit.Message}, nil
+	}
+	return nil, fmt.Errorf("branch %s not found in %s/%s", branch, namespace, repo)
+}
+
+func (c *Client) ListTags(namespace, repo string) ([]*Tag, error) {
+	var res []*Tag
+	if c.IsCloud {
+		err := c.listAll(c.repoPath(namespace, repo)+"/refs/tags", map[string]string{"sort": "-target.date"}, func(raw json.RawMessage) error {
+			ref := &cloudRef{}
+			if err := json.Unmarshal(raw, ref); err != nil {
+				return err
+			}
+			res = append(res, &Tag{Name: ref.Name, Commit: ref.Target.Hash, Message: ref.Message})
+			return nil
+		})
+		return res, err
+	}
+
+	err := c.listAll(c.repoPath(namespace, repo)+"/tags", map[string]string{"orderBy": "MODIFICATION"}, func(raw json.RawMessage) error {
+		ref := &serverRef{}
+		if err := json.Unmarshal(raw, ref); err != nil {
+			return err
+		}
+		res = append(res, &Tag{Name: ref.DisplayID, Commit: ref.LatestCommit})
+		return nil
+	})
+	return res, err
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitbucket
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+// Namespace is a workspace in Bitbucket Cloud or a project in Bitbucket Server.
+type Namespace struct {
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	Personal bool   `json:"personal"`
+}
+
+type Repository struct {
+	ID            int    `json:"id"`
+	Slug          string `json:"slug"`
+	Name          string `json:"name"`
+	Namespace     string `json:"namespace"`
+	Description   string `json:"description"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+type cloudWorkspacePermission struct {
+	Workspace struct {
+		Slug string `json:"slug"`
+		Name string `json:"name"`
+	} `json:"workspace"`
+}
+
+type cloudRepository struct {
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	FullName    string `json:"full_name"`
+	Description string `json:"description"`
+	MainBranch  *struct {
+		Name string `json:"name"`
+	} `json:"mainbranch"`
+}
+
+type serverProject struct {
+	ID   int    `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+type serverRepository struct {
+	ID      int           `json:"id"`
+	Slug    string        `json:"slug"`
+	Name    string        `json:"name"`
+	Project serverProject `json:"project"`
+}
+
+type serverRef struct {
+	ID           string `json:"id"`
+	DisplayID    string `json:"displayId"`
+	LatestCommit string `json:"latestCommit"`
+	IsDefault    bool   `json:"isDefault"`
+}
+
+// ListNamespaces returns the workspaces (Cloud) or projects (Server) the user can access.
+func (c *Client) ListNamespaces() ([]*Namespace, error) {
+	var res []*Namespace
+	if c.IsCloud {
+		err := c.listAll(c.api("/user/permissions/workspaces"), nil, func(raw json.RawMessage) error {
+			p := &cloudWorkspacePermission{}
+			if err := json.Unmarshal(raw, p); err != nil {
+				return err
+			}
+			res = append(res, &Namespace{Key: p.Workspace.Slug, Name: p.Workspace.Name})
+			return nil
+		})
+		return res, err
+	}
+
+	if c.Username != "" {
+		res = append(res, &Namespace{Key: "~" + c.Username, Name: c.Username, Personal: true})
+	}
+	err := c.listAll(c.api("/projects"), nil, func(raw json.RawMessage) error {
+		p := &serverProject{}
+		if err := json.Unmarshal(raw, p); err != nil {
+			return err
+		}
+		res = append(res, &Namespace{Key: p.Key, Name: p.Name})
+		return nil
+	})
+	return res, err
+}
+
+// ListRepositories returns one page of the repositories under namespace whose name contains keyword.
+func (c *Client) ListRepositories(namespace, keyword string, page, perPage int) ([]*Repository, error) {
+	var res []*Repository
+	if c.IsCloud {
+		params := map[string]string{}
+		if keyword != "" {
+			params["q"] = fmt.Sprintf("name ~ %q", keyword)
+		}
+		values, _, err := c.listPage(c.api("/repositories/%s", namespace), params, page, perPage)
+		if err != nil {
+			return nil, err
+		}
+		for _, raw := range values {
+			r := &cloudRepository{}
+			if err := json.Unmarshal(raw, r); err != nil {
+				return nil, err
+			}
+			repo := &Repository{Slug: r.Slug, Name: r.Name, Namespace: namespace, Description: r.Description}
+			if r.MainBranch != nil {
+				repo.DefaultBranch = r.MainBranch.Name
+			}
+			res = append(res, repo)
+		}
+		return res, nil
+	}
+
+	params := map[string]string{"projectkey": namespace}
+	if keyword != "" {
+		params["name"] = keyword
+	}
+	values, _, err := c.listPage(c.api("/repos"), params, page, perPage)
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range values {
+		r := &serverRepository{}
+		if err := json.Unmarshal(raw, r); err != nil {
+			return nil, err
+		}
+		res = append(res, &Repository{ID: r.ID, Slug: r.Slug, Name: r.Name, Namespace: r.Project.Key})
+	}
+	return res, nil
+}
+
+// GetDefaultBranch returns the name of the default branch of a repository.
+func (c *Client) GetDefaultBranch(namespace, repo string) (string, error) {
+	if c.IsCloud {
+		r := &cloudRepository{}
+		if _, err := c.Get(c.repoPath(namespace, repo), httpclient.SetResult(r)); err != nil {
+			return "", err
+		}
+		if r.MainBranch == nil {
+			return "", nil
+		}
+		return r.MainBranch.Name, nil
+	}
+
+	ref := &serverRef{}
+	if _, err := c.Get(c.repoPath(namespace, repo)+"/branches/default", httpclient.SetResult(ref)); err != nil {
+		return "", err
+	}
+	return ref.DisplayID, nil
+}
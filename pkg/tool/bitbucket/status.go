@@ -0,0 +1,55 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitbucket
+
+import (
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+type BuildState string
+
+const (
+	BuildStateInProgress BuildState = "INPROGRESS"
+	BuildStateSuccessful BuildState = "SUCCESSFUL"
+	BuildStateFailed     BuildState = "FAILED"
+	// BuildStateStopped is only supported by Bitbucket Cloud, it is reported as FAILED to Bitbucket Server.
+	BuildStateStopped BuildState = "STOPPED"
+)
+
+type BuildStatus struct {
+	// Key identifies the build, statuses with the same key on a commit overwrite each other.
+	Key         string     `json:"key"`
+	State       BuildState `json:"state"`
+	Name        string     `json:"name"`
+	URL         string     `json:"url"`
+	Description string     `json:"description"`
+}
+
+// SetBuildStatus creates or updates the build status of a commit.
+func (c *Client) SetBuildStatus(namespace, repo, commit string, status *BuildStatus) error {
+	if c.IsCloud {
+		_, err := c.Post(c.repoPath(namespace, repo)+"/commit/"+commit+"/statuses/build", httpclient.SetBody(status))
+		return err
+	}
+
+	s := *status
+	if s.State == BuildStateStopped {
+		s.State = BuildStateFailed
+	}
+	_, err := c.Post(serverStatusPrefix+"/commits/"+commit, httpclient.SetBody(&s))
+	return err
+}
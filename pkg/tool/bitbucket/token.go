@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitbucket
+
+import (
+	"strings"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+const (
+	cloudTokenURL  = "https://bitbucket.org/site/oauth2/access_token"
+	serverTokenURI = "/rest/oauth2/latest/token"
+)
+
+type AccessToken struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scopes       string `json:"scopes"`
+}
+
+// OAuthEndpoint returns the authorize and token urls of the OAuth 2.0 flow.
+func OAuthEndpoint(address string) (string, string) {
+	if IsCloud(address) {
+		return CloudAddress + "/site/oauth2/authorize", cloudTokenURL
+	}
+	address = strings.TrimSuffix(address, "/")
+	return address + "/rest/oauth2/latest/authorize", address + serverTokenURI
+}
+
+// RefreshAccessToken exchanges a refresh token for a new access token.
+func RefreshAccessToken(address, clientID, clientSecret, refreshToken string) (*AccessToken, error) {
+	_, tokenURL := OAuthEndpoint(address)
+	token := &AccessToken{}
+	_, err := httpclient.Post(tokenURL,
+		httpclient.SetHeader("Content-Type", "application/x-www-form-urlencoded"),
+		httpclient.SetFormData(map[string]string{
+			"grant_type":    "refresh_token",
+			"refresh_token": refreshToken,
+			"client_id":     clientID,
+			"client_secret": clientSecret,
+		}),
+		httpclient.SetResult(token),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitbucket
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+// Events a webhook created by zadig subscribes to.
+var (
+	cloudHookEvents  = []string{string(EventTypeRepoPush), string(EventTypePullRequestCreated), string(EventTypePullRequestUpdated)}
+	serverHookEvents = []string{string(EventTypeRefsChanged), string(EventTypePROpened), string(EventTypePRFromRefUpdated)}
+)
+
+type cloudHook struct {
+	UUID        string   `json:"uuid,omitempty"`
+	Description string   `json:"description"`
+	URL         string   `json:"url"`
+	Active      bool     `json:"active"`
+	Secret      string   `json:"secret,omitempty"`
+	Events      []string `json:"events"`
+}
+
+type serverHook struct {
+	ID            int               `json:"id,omitempty"`
+	Name          string            `json:"name"`
+	URL           string            `json:"url"`
+	Active        bool              `json:"active"`
+	Events        []string          `json:"events"`
+	Configuration map[string]string `json:"configuration,omitempty"`
+}
+
+// CreateHook creates a webhook for push and pull request events signed with secret, and returns the hook id.
+func (c *Client) CreateHook(namespace, repo, hookURL, secret string) (string, error) {
+	if c.IsCloud {
+		res := &cloudHook{}
+		_, err := c.Post(c.repoPath(namespace, repo)+"/hooks", httpclient.SetBody(&cloudHook{
+			Description: "zadig",
+			URL:         hookURL,
+			Active:      true,
+			Secret:      secret,
+			Events:      cloudHookEvents,
+		}), httpclient.SetResult(res))
+		if err != nil {
+			return "", err
+		}
+		return res.UUID, nil
+	}
+
+	res := &serverHook{}
+	_, err := c.Post(c.repoPath(namespace, repo)+"/webhooks", httpclient.SetBody(&serverHook{
+		Name:          "zadig",
+		URL:           hookURL,
+		Active:        true,
+		Events:        serverHookEvents,
+		Configuration: map[string]string{"secret": secret},
+	}), httpclient.SetResult(res))
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(res.ID), nil
+}
+
+func (c *Client) DeleteHook(namespace, repo, hookID string) error {
+	if c.IsCloud {
+		_, err := c.Delete(c.repoPath(namespace, repo) + "/hooks/" + url.PathEscape(hookID))
+		return err
+	}
+	_, err := c.Delete(c.repoPath(namespace, repo) + "/webhooks/" + url.PathEscape(hookID))
+	return err
+}
@@ -51,20 +51,18 @@ func getAESKey() string {
 	return aesKey
 }
 
+// GetAesKey returns the primary key, it is handed to the components which decrypt the data by themselves.
 func GetAesKey() string {
-	return getAESKey()
+	key, err := getKeyring().key(getKeyring().Primary)
+	if err != nil {
+		panic(err)
+	}
+	return key
 }
 
+// AesEncrypt encrypts the data with the primary key in the keyring.
 func AesEncrypt(src string) (string, error) {
-	client, err := NewAes(getAESKey())
-	if err != nil {
-		return "", err
-	}
-	dest, err := client.Encrypt(src)
-	if err != nil {
-		return "", err
-	}
-	return dest, nil
+	return getKeyring().Encrypt(src)
 }
 
 func AesEncryptByKey(src, aesKey string) (string, error) {
@@ -79,20 +77,21 @@ func AesEncryptByKey(src, aesKey string) (string, error) {
 	return dest, nil
 }
 
+// AesDecrypt decrypts the data with the key of the version recorded in the data, or with the given key.
 func AesDecrypt(src string, aesKey ...string) (string, error) {
-	var (
-		err    error
-		client *Aes
-	)
-	if len(aesKey) > 0 {
-		client, err = NewAes(aesKey[0])
-	} else {
-		client, err = NewAes(getAESKey())
+	if len(aesKey) == 0 {
+		return getKeyring().Decrypt(src)
+	}
+
+	_, payload, err := splitVersion(src)
+	if err != nil {
+		return "", err
 	}
+	client, err := NewAes(aesKey[0])
 	if err != nil {
 		return "", err
 	}
-	dest, err := client.Decrypt(src)
+	dest, err := client.Decrypt(payload)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,179 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypto
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+
+	fsutil "github.com/koderover/zadig/pkg/util/fs"
+)
+
+// keyringFile keeps all versions of the AES keys, e.g.
+//
+//	primary: 2
+//	keys:
+//	  "1": <key of version 1>
+//	  "2": <key of version 2>
+//
+// Version 0 is the legacy key in aesKeyFile, ciphertexts encrypted by it have no version prefix.
+const keyringFile = "etc/encryption/keyring"
+
+const versionPrefix = "v"
+
+// Keyring encrypts with the primary key and decrypts with the key of the version recorded in the ciphertext,
+// so that the primary key can be rotated without breaking the data encrypted by the old ones.
+type Keyring struct {
+	Primary int               `json:"primary"`
+	Keys    map[string]string `json:"keys"`
+}
+
+var (
+	keyring     *Keyring
+	keyringOnce sync.Once
+)
+
+func getKeyring() *Keyring {
+	keyringOnce.Do(func() {
+		data, err := fs.ReadFile(fsutil.Root(), keyringFile)
+		if errors.Is(err, fs.ErrNotExist) {
+			keyring = &Keyring{}
+			return
+		}
+		if err != nil {
+			panic(fmt.Sprintf("Failed to read aes keyring: %s", err))
+		}
+		keyring, err = ParseKeyring(data)
+		if err != nil {
+			panic(fmt.Sprintf("Failed to parse aes keyring: %s", err))
+		}
+	})
+
+	return keyring
+}
+
+// GetKeyring returns the keyring loaded from the keyring file, only the legacy key is used if the file doesn't exist.
+func GetKeyring() *Keyring {
+	return getKeyring()
+}
+
+func ParseKeyring(data []byte) (*Keyring, error) {
+	k := &Keyring{}
+	if err := yaml.Unmarshal(data, k); err != nil {
+		return nil, err
+	}
+	for v, key := range k.Keys {
+		if _, err := strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid key version %s", v)
+		}
+		if _, err := NewAes(strings.TrimSpace(key)); err != nil {
+			return nil, fmt.Errorf("invalid key of version %s: %s", v, err)
+		}
+	}
+	if _, err := k.key(k.Primary); err != nil && k.Primary != 0 {
+		return nil, err
+	}
+	return k, nil
+}
+
+func (k *Keyring) key(version int) (string, error) {
+	if key, ok := k.Keys[strconv.Itoa(version)]; ok {
+		return strings.TrimSpace(key), nil
+	}
+	if version == 0 {
+		return getAESKey(), nil
+	}
+	return "", fmt.Errorf("aes key of version %d not found", version)
+}
+
+// Encrypt encrypts the plaintext with the primary key, the key version is recorded as a prefix of the ciphertext.
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	key, err := k.key(k.Primary)
+	if err != nil {
+		return "", err
+	}
+	dest, err := AesEncryptByKey(plaintext, key)
+	if err != nil {
+		return "", err
+	}
+	if k.Primary == 0 {
+		return dest, nil
+	}
+	return fmt.Sprintf("%s%d:%s", versionPrefix, k.Primary, dest), nil
+}
+
+func (k *Keyring) Decrypt(ciphertext string) (string, error) {
+	version, payload, err := splitVersion(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	key, err := k.key(version)
+	if err != nil {
+		return "", err
+	}
+	client, err := NewAes(key)
+	if err != nil {
+		return "", err
+	}
+	return client.Decrypt(payload)
+}
+
+// IsPrimary returns whether the ciphertext is encrypted by the primary key.
+func (k *Keyring) IsPrimary(ciphertext string) bool {
+	version, _, err := splitVersion(ciphertext)
+	return err == nil && version == k.Primary
+}
+
+// ReEncrypt encrypts the ciphertext again with the primary key, the ciphertext is returned as is if it is
+// already encrypted by the primary key.
+func (k *Keyring) ReEncrypt(ciphertext string) (string, bool, error) {
+	if k.IsPrimary(ciphertext) {
+		return ciphertext, false, nil
+	}
+	plaintext, err := k.Decrypt(ciphertext)
+	if err != nil {
+		return "", false, err
+	}
+	dest, err := k.Encrypt(plaintext)
+	if err != nil {
+		return "", false, err
+	}
+	return dest, true, nil
+}
+
+// splitVersion splits a ciphertext in the form of v<version>:<hex>, the version is 0 if there is no prefix.
+// The prefix is unambiguous since hex encoded data never contains v or :.
+func splitVersion(ciphertext string) (int, string, error) {
+	if !strings.HasPrefix(ciphertext, versionPrefix) {
+		return 0, ciphertext, nil
+	}
+	idx := strings.Index(ciphertext, ":")
+	if idx < 0 {
+		return 0, "", fmt.Errorf("invalid ciphertext")
+	}
+	version, err := strconv.Atoi(ciphertext[len(versionPrefix):idx])
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid key version in ciphertext: %s", err)
+	}
+	return version, ciphertext[idx+1:], nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypto
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	testKeyV1 = "0123456789abcdef0123456789abcdef"
+	testKeyV2 = "fedcba9876543210fedcba9876543210"
+)
+
+func TestKeyringRotation(t *testing.T) {
+	old, err := ParseKeyring([]byte("primary: 1\nkeys:\n  \"1\": " + testKeyV1 + "\n"))
+	if err != nil {
+		t.Fatalf("ParseKeyring() error: %v", err)
+	}
+	encrypted, err := old.Encrypt("hello")
+	if err != nil {
+		t.Fatalf("Encrypt() error: %v", err)
+	}
+	if !strings.HasPrefix(encrypted, "v1:") {
+		t.Errorf("ciphertext %s should be prefixed with the key version", encrypted)
+	}
+
+	rotated := &Keyring{Primary: 2, Keys: map[string]string{"1": testKeyV1, "2": testKeyV2}}
+	if rotated.IsPrimary(encrypted) {
+		t.Errorf("ciphertext of version 1 should not be primary")
+	}
+	decrypted, err := rotated.Decrypt(encrypted)
+	if err != nil || decrypted != "hello" {
+		t.Errorf("Decrypt() = %q, %v", decrypted, err)
+	}
+
+	reEncrypted, changed, err := rotated.ReEncrypt(encrypted)
+	if err != nil || !changed || !strings.HasPrefix(reEncrypted, "v2:") {
+		t.Fatalf("ReEncrypt() = %q, %v, %v", reEncrypted, changed, err)
+	}
+	if _, changed, _ = rotated.ReEncrypt(reEncrypted); changed {
+		t.Errorf("ReEncrypt() should skip ciphertexts of the primary version")
+	}
+	if decrypted, err = AesDecrypt(reEncrypted, testKeyV2); err != nil || decrypted != "hello" {
+		t.Errorf("AesDecrypt() with explicit key = %q, %v", decrypted, err)
+	}
+
+	if _, err := (&Keyring{Primary: 2, Keys: map[string]string{"2": testKeyV2}}).Decrypt(encrypted); err == nil {
+		t.Errorf("Decrypt() should fail if the key version is missing")
+	}
+}
+
+func TestParseKeyring(t *testing.T) {
+	if _, err := ParseKeyring([]byte("primary: 3\nkeys:\n  \"1\": " + testKeyV1 + "\n")); err == nil {
+		t.Errorf("primary key missing in the keyring should be rejected")
+	}
+	if _, err := ParseKeyring([]byte("primary: 1\nkeys:\n  \"1\": short\n")); err == nil {
+		t.Errorf("invalid aes key should be rejected")
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dingtalk
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// ApprovalInstance statuses
+const (
+	ApprovalStatusNew        = "NEW"
+	ApprovalStatusRunning    = "RUNNING"
+	ApprovalStatusTerminated = "TERMINATED"
+	ApprovalStatusCompleted  = "COMPLETED"
+	ApprovalStatusCanceled   = "CANCELED"
+)
+
+// Results of a completed approval instance
+const (
+	ApprovalResultAgree  = "agree"
+	ApprovalResultRefuse = "refuse"
+)
+
+// Results of an operation record
+const (
+	OperationResultAgree  = "AGREE"
+	OperationResultRefuse = "REFUSE"
+)
+
+const (
+	ActionTypeAnd = "AND"
+	ActionTypeOr  = "OR"
+
+	// DefaultFormComponentName is the label of the only component in the form created by CreateApprovalForm
+	DefaultFormComponentName = "详情"
+)
+
+type formComponent struct {
+	ComponentType string             `json:"componentType"`
+	Props         formComponentProps `json:"props"`
+}
+
+type formComponentProps struct {
+	ComponentID string `json:"componentId"`
+	Label       string `json:"label"`
+	Required    bool   `json:"required"`
+}
+
+type createFormResp struct {
+	Result struct {
+		ProcessCode string `json:"processCode"`
+	} `json:"result"`
+}
+
+// CreateApprovalForm creates an approval form holding a single text area and returns its process code,
+// the form of the given process code is updated if it is not empty.
+func (c *Client) CreateApprovalForm(name, description, processCode string) (string, error) {
+	body := map[string]interface{}{
+		"name":        name,
+		"description": description,
+		"formComponents": []*formComponent{{
+			ComponentType: "TextareaField",
+			Props: formComponentProps{
+				ComponentID: "TextareaField-zadig",
+				Label:       DefaultFormComponentName,
+				Required:    true,
+			},
+		}},
+	}
+	if processCode != "" {
+		body["processCode"] = processCode
+	}
+	resp := &createFormResp{}
+	if err := c.apiRequest(http.MethodPost, "/v1.0/workflow/forms", body, resp); err != nil {
+		return "", err
+	}
+	return resp.Result.ProcessCode, nil
+}
+
+type Approver struct {
+	ActionType string   `json:"actionType"`
+	UserIDs    []string `json:"userIds"`
+}
+
+type FormComponentValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type CreateApprovalInstanceArgs struct {
+	ProcessCode         string                `json:"processCode"`
+	OriginatorUserID    string                `json:"originatorUserId"`
+	Approvers           []*Approver           `json:"approvers"`
+	FormComponentValues []*FormComponentValue `json:"formComponentValues"`
+}
+
+type createInstanceResp struct {
+	InstanceID string `json:"instanceId"`
+}
+
+func (c *Client) CreateApprovalInstance(args *CreateApprovalInstanceArgs) (string, error) {
+	resp := &createInstanceResp{}
+	if err := c.apiRequest(http.MethodPost, "/v1.0/workflow/processInstances", args, resp); err != nil {
+		return "", err
+	}
+	return resp.InstanceID, nil
+}
+
+type OperationRecord struct {
+	UserID string `json:"userId"`
+	Date   string `json:"date"`
+	Type   string `json:"type"`
+	Result string `json:"result"`
+	Remark string `json:"remark"`
+}
+
+type ApprovalInstance struct {
+	Status           string             `json:"status"`
+	Result           string             `json:"result"`
+	OperationRecords []*OperationRecord `json:"operationRecords"`
+}
+
+type getInstanceResp struct {
+	Result *ApprovalInstance `json:"result"`
+}
+
+func (c *Client) GetApprovalInstance(instanceID string) (*ApprovalInstance, error) {
+	resp := &getInstanceResp{}
+	if err := c.apiRequest(http.MethodGet, "/v1.0/workflow/processInstances?processInstanceId="+url.QueryEscape(instanceID), nil, resp); err != nil {
+		return nil, err
+	}
+	return resp.Result, nil
+}
+
+func (c *Client) TerminateApprovalInstance(instanceID, remark string) error {
+	return c.apiRequest(http.MethodPost, "/v1.0/workflow/processInstances/terminate", map[string]interface{}{
+		"processInstanceId": instanceID,
+		"isSystem":          true,
+		"remark":            remark,
+	}, nil)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dingtalk
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+const (
+	defaultAPIAddress  = "https://api.dingtalk.com"
+	defaultOAPIAddress = "https://oapi.dingtalk.com"
+
+	accessTokenHeader = "x-acs-dingtalk-access-token"
+)
+
+// Client talks to both the new api.dingtalk.com APIs and the legacy oapi.dingtalk.com APIs,
+// users are only able to be looked up by the latter.
+type Client struct {
+	api  *httpclient.Client
+	oapi *httpclient.Client
+
+	appKey    string
+	appSecret string
+
+	mu       sync.Mutex
+	token    string
+	expireAt time.Time
+}
+
+func NewClient(appKey, appSecret string) *Client {
+	return &Client{
+		api:       httpclient.New(httpclient.SetHostURL(defaultAPIAddress)),
+		oapi:      httpclient.New(httpclient.SetHostURL(defaultOAPIAddress)),
+		appKey:    appKey,
+		appSecret: appSecret,
+	}
+}
+
+type accessTokenResp struct {
+	AccessToken string `json:"accessToken"`
+	ExpireIn    int64  `json:"expireIn"`
+}
+
+// getAccessToken returns the cached access token of the app, it is refreshed 5 minutes before it expires.
+func (c *Client) getAccessToken() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.expireAt) {
+		return c.token, nil
+	}
+	resp := &accessTokenResp{}
+	_, err := c.api.Post("/v1.0/oauth2/accessToken", httpclient.SetBody(map[string]string{
+		"appKey":    c.appKey,
+		"appSecret": c.appSecret,
+	}), httpclient.SetResult(resp))
+	if err != nil {
+		return "", err
+	}
+	c.token = resp.AccessToken
+	c.expireAt = time.Now().Add(time.Duration(resp.ExpireIn-300) * time.Second)
+	return c.token, nil
+}
+
+func (c *Client) apiRequest(method, url string, body, result interface{}) error {
+	token, err := c.getAccessToken()
+	if err != nil {
+		return err
+	}
+	rfs := []httpclient.RequestFunc{httpclient.SetHeader(accessTokenHeader, token)}
+	if body != nil {
+		rfs = append(rfs, httpclient.SetBody(body))
+	}
+	if result != nil {
+		rfs = append(rfs, httpclient.SetResult(result))
+	}
+	_, err = c.api.Request(method, url, rfs...)
+	return err
+}
+
+type oapiResp struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func (r *oapiResp) err() error {
+	if r.ErrCode != 0 {
+		return fmt.Errorf("dingtalk error, code: %d, message: %s", r.ErrCode, r.ErrMsg)
+	}
+	return nil
+}
+
+// Validate checks whether the app key and secret are able to get an access token.
+func Validate(appKey, appSecret string) error {
+	_, err := NewClient(appKey, appSecret).getAccessToken()
+	return err
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dingtalk
+
+import (
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+type UserInfo struct {
+	UserID string `json:"userid"`
+	Name   string `json:"name"`
+	Mobile string `json:"mobile"`
+	Email  string `json:"email"`
+}
+
+type userIDResp struct {
+	oapiResp
+	Result struct {
+		UserID string `json:"userid"`
+	} `json:"result"`
+}
+
+type userInfoResp struct {
+	oapiResp
+	Result *UserInfo `json:"result"`
+}
+
+func (c *Client) oapiPost(url string, body interface{}, result interface{ err() error }) error {
+	token, err := c.getAccessToken()
+	if err != nil {
+		return err
+	}
+	_, err = c.oapi.Post(url, httpclient.SetQueryParam("access_token", token), httpclient.SetBody(body), httpclient.SetResult(result))
+	if err != nil {
+		return err
+	}
+	return result.err()
+}
+
+// GetUserIDByMobile returns the dingtalk user id of the mobile, dingtalk does not support looking up users by email.
+func (c *Client) GetUserIDByMobile(mobile string) (string, error) {
+	resp := &userIDResp{}
+	if err := c.oapiPost("/topapi/v2/user/getbymobile", map[string]string{"mobile": mobile}, resp); err != nil {
+		return "", err
+	}
+	return resp.Result.UserID, nil
+}
+
+func (c *Client) GetUserInfo(userID string) (*UserInfo, error) {
+	resp := &userInfoResp{}
+	if err := c.oapiPost("/topapi/v2/user/get", map[string]string{"userid": userID}, resp); err != nil {
+		return nil, err
+	}
+	return resp.Result, nil
+}
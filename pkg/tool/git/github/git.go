@@ -15,6 +15,7 @@ package github
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/google/go-github/v35/github"
 )
@@ -27,3 +28,79 @@ func (c *Client) GetTree(ctx context.Context, owner string, repo string, sha str
 
 	return nil, err
 }
+
+// CreateBranch creates the branch from the head of fromBranch.
+func (c *Client) CreateBranch(ctx context.Context, owner, repo, branch, fromBranch string) error {
+	ref, err := wrap(c.Git.GetRef(ctx, owner, repo, "heads/"+fromBranch))
+	if err != nil {
+		return err
+	}
+	from, ok := ref.(*github.Reference)
+	if !ok {
+		return fmt.Errorf("branch %s not found", fromBranch)
+	}
+
+	_, err = wrap(c.Git.CreateRef(ctx, owner, repo, &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: from.Object.SHA},
+	}))
+	return err
+}
+
+// CommitFiles creates a single commit on top of the branch which writes the files and removes the deleted paths.
+func (c *Client) CommitFiles(ctx context.Context, owner, repo, branch, message string, files map[string]string, deleted []string) (*github.Commit, error) {
+	ref, err := wrap(c.Git.GetRef(ctx, owner, repo, "heads/"+branch))
+	if err != nil {
+		return nil, err
+	}
+	head, ok := ref.(*github.Reference)
+	if !ok {
+		return nil, fmt.Errorf("branch %s not found", branch)
+	}
+	parent, err := wrap(c.Git.GetCommit(ctx, owner, repo, head.Object.GetSHA()))
+	if err != nil {
+		return nil, err
+	}
+	parentCommit, ok := parent.(*github.Commit)
+	if !ok {
+		return nil, fmt.Errorf("commit %s not found", head.Object.GetSHA())
+	}
+
+	entries := make([]*github.TreeEntry, 0, len(files)+len(deleted))
+	for path, content := range files {
+		entries = append(entries, &github.TreeEntry{
+			Path:    github.String(path),
+			Mode:    github.String("100644"),
+			Type:    github.String("blob"),
+			Content: github.String(content),
+		})
+	}
+	// an entry without sha and content removes the file
+	for _, path := range deleted {
+		entries = append(entries, &github.TreeEntry{
+			Path: github.String(path),
+			Mode: github.String("100644"),
+			Type: github.String("blob"),
+		})
+	}
+	tree, err := wrap(c.Git.CreateTree(ctx, owner, repo, parentCommit.Tree.GetSHA(), entries))
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := wrap(c.Git.CreateCommit(ctx, owner, repo, &github.Commit{
+		Message: github.String(message),
+		Tree:    tree.(*github.Tree),
+		Parents: []*github.Commit{{SHA: parentCommit.SHA}},
+	}))
+	if err != nil {
+		return nil, err
+	}
+	newCommit := commit.(*github.Commit)
+
+	head.Object.SHA = newCommit.SHA
+	if _, err = wrap(c.Git.UpdateRef(ctx, owner, repo, head, false)); err != nil {
+		return nil, err
+	}
+	return newCommit, nil
+}
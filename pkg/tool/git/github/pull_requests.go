@@ -91,3 +91,16 @@ func (c *Client) ListFiles(ctx context.Context, owner string, repo string, numbe
 
 	return res, err
 }
+
+func (c *Client) CreatePullRequest(ctx context.Context, owner, repo, title, head, base string) (*github.PullRequest, error) {
+	pr, err := wrap(c.PullRequests.Create(ctx, owner, repo, &github.NewPullRequest{
+		Title: github.String(title),
+		Head:  github.String(head),
+		Base:  github.String(base),
+	}))
+	if p, ok := pr.(*github.PullRequest); ok {
+		return p, err
+	}
+
+	return nil, err
+}
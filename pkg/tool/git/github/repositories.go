@@ -186,6 +186,19 @@ func (c *Client) ListRepositoryCommits(ctx context.Context, owner, repo, path, b
 	return res, err
 }
 
+// CompareCommits returns the commits reachable from head but not from base, github returns at most 250 commits.
+func (c *Client) CompareCommits(ctx context.Context, owner, repo, base, head string) ([]*github.RepositoryCommit, error) {
+	comparison, err := wrap(c.Repositories.CompareCommits(ctx, owner, repo, base, head))
+	if err != nil {
+		return nil, err
+	}
+	if cc, ok := comparison.(*github.CommitsComparison); ok {
+		return cc.Commits, nil
+	}
+
+	return nil, err
+}
+
 func (c *Client) GetLatestRepositoryCommit(ctx context.Context, owner, repo, path, branch string) (*github.RepositoryCommit, error) {
 	cs, err := c.ListRepositoryCommits(ctx, owner, repo, path, branch, &ListOptions{PerPage: 1, NoPaginated: true})
 	if err != nil || len(cs) == 0 {
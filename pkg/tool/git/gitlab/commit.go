@@ -53,3 +53,30 @@ func (c *Client) GetSingleCommitOfProject(owner, repo, commitSha string) (*gitla
 
 	return nil, err
 }
+
+// CommitFiles creates a commit with the actions in the branch, the branch is created from startBranch if it is set.
+func (c *Client) CommitFiles(owner, repo, branch, startBranch, message string, actions []*gitlab.CommitActionOptions) (*gitlab.Commit, error) {
+	opts := &gitlab.CreateCommitOptions{
+		Branch:        &branch,
+		CommitMessage: &message,
+		Actions:       actions,
+	}
+	if startBranch != "" && startBranch != branch {
+		opts.StartBranch = &startBranch
+	}
+	commit, err := wrap(c.Commits.CreateCommit(generateProjectName(owner, repo), opts))
+	if err != nil {
+		return nil, err
+	}
+	if ct, ok := commit.(*gitlab.Commit); ok {
+		return ct, nil
+	}
+
+	return nil, err
+}
+
+// SetCommitStatus creates or updates the status of the given name on the commit.
+func (c *Client) SetCommitStatus(owner, repo, sha string, opts *gitlab.SetCommitStatusOptions) error {
+	_, err := wrap(c.Commits.SetCommitStatus(generateProjectName(owner, repo), sha, opts))
+	return err
+}
@@ -54,6 +54,18 @@ func (c *Client) ListOpenedProjectMergeRequests(owner, repo, targetBranch, key s
 	return res, err
 }
 
+func (c *Client) GetMergeRequest(owner, repo string, iid int) (*gitlab.MergeRequest, error) {
+	mr, err := wrap(c.MergeRequests.GetMergeRequest(generateProjectName(owner, repo), iid, nil))
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := mr.(*gitlab.MergeRequest); ok {
+		return m, nil
+	}
+
+	return nil, err
+}
+
 func (c *Client) ListChangedFiles(event *gitlab.MergeEvent) ([]string, error) {
 	files := make([]string, 0)
 	mergeRequest, err := wrap(c.MergeRequests.GetMergeRequestChanges(event.ObjectAttributes.TargetProjectID, event.ObjectAttributes.IID, nil))
@@ -77,3 +89,20 @@ func (c *Client) ListChangedFiles(event *gitlab.MergeEvent) ([]string, error) {
 //	_, err := wrap(c.Discussions.CreateCommitDiscussion(generateProjectName(owner, repo), commitHash, args))
 //	return err
 //}
+
+func (c *Client) CreateMergeRequest(owner, repo, sourceBranch, targetBranch, title string) (*gitlab.MergeRequest, error) {
+	opts := &gitlab.CreateMergeRequestOptions{
+		Title:        &title,
+		SourceBranch: &sourceBranch,
+		TargetBranch: &targetBranch,
+	}
+	mergeRequest, err := wrap(c.MergeRequests.CreateMergeRequest(generateProjectName(owner, repo), opts))
+	if err != nil {
+		return nil, err
+	}
+	if mr, ok := mergeRequest.(*gitlab.MergeRequest); ok {
+		return mr, nil
+	}
+
+	return nil, err
+}
@@ -114,6 +114,24 @@ func (c *Client) Compare(projectID int, from, to string) ([]*gitlab.Diff, error)
 	return nil, err
 }
 
+// CompareCommits returns the commits between from and to.
+func (c *Client) CompareCommits(owner, repo, from, to string) ([]*gitlab.Commit, error) {
+	opts := &gitlab.CompareOptions{
+		From: &from,
+		To:   &to,
+	}
+
+	compare, err := wrap(c.Repositories.Compare(generateProjectName(owner, repo), opts))
+	if err != nil {
+		return nil, err
+	}
+	if cp, ok := compare.(*gitlab.Compare); ok {
+		return cp.Commits, nil
+	}
+
+	return nil, err
+}
+
 // GetYAMLContents recursively gets all yaml contents under the given path. if split is true, manifests in the same file
 // will be split to separated ones.
 func (c *Client) GetYAMLContents(owner, repo, path, branch string, isDir, split bool) ([]string, error) {
@@ -0,0 +1,132 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitea
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+const (
+	apiPrefix = "/api/v1"
+
+	defaultPerPage = 50
+	// maxPages limits the number of pages fetched when listing all items of a resource
+	maxPages = 20
+)
+
+// Client talks to the REST API v1 of Gitea, which is also served by Forgejo.
+type Client struct {
+	*httpclient.Client
+
+	Address string
+}
+
+// NewClient creates a Gitea client, token can be a personal access token or an OAuth2 access token.
+func NewClient(address, token, proxyAddr string, enableProxy bool) *Client {
+	address = strings.TrimSuffix(address, "/")
+
+	cfs := []httpclient.ClientFunc{httpclient.SetHostURL(address + apiPrefix)}
+	if token != "" {
+		cfs = append(cfs, httpclient.SetAuthToken(token))
+	}
+	if enableProxy && proxyAddr != "" {
+		cfs = append(cfs, httpclient.SetProxy(proxyAddr))
+	}
+
+	return &Client{
+		Client:  httpclient.New(cfs...),
+		Address: address,
+	}
+}
+
+func api(format string, a ...interface{}) string {
+	for i, v := range a {
+		if s, ok := v.(string); ok {
+			a[i] = url.PathEscape(s)
+		}
+	}
+	return fmt.Sprintf(format, a...)
+}
+
+// repoPath returns the api path of a repository.
+func repoPath(owner, repo string) string {
+	return api("/repos/%s/%s", owner, repo)
+}
+
+// escapePath escapes every segment of a file path in a repository.
+func escapePath(p string) string {
+	segments := strings.Split(strings.Trim(p, "/"), "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// listPage fetches a single page of a paged resource into result, page starts from 1.
+func (c *Client) listPage(path string, params map[string]string, page, perPage int, result interface{}) error {
+	if page <= 0 {
+		page = 1
+	}
+	if perPage <= 0 {
+		perPage = defaultPerPage
+	}
+	qs := make(map[string]string, len(params)+2)
+	for k, v := range params {
+		qs[k] = v
+	}
+	qs["page"] = strconv.Itoa(page)
+	qs["limit"] = strconv.Itoa(perPage)
+
+	_, err := c.Get(path, httpclient.SetQueryParams(qs), httpclient.SetResult(result))
+	return err
+}
+
+// listAll fetches all pages of a paged resource, fetch decodes a page and returns the number of items in it.
+func (c *Client) listAll(fetch func(page int) (int, error)) error {
+	for page := 1; page <= maxPages; page++ {
+		n, err := fetch(page)
+		if err != nil {
+			return err
+		}
+		if n < defaultPerPage {
+			break
+		}
+	}
+	return nil
+}
+
+// CloneURL returns the https clone url of a repository with the token embedded,
+// Gitea ignores the user name when the password is a token.
+func CloneURL(address, token, owner, repo string) string {
+	u, err := url.Parse(strings.TrimSuffix(address, "/"))
+	if err != nil {
+		return ""
+	}
+	u.User = url.UserPassword("oauth2", token)
+	u.Path = fmt.Sprintf("%s/%s/%s.git", u.Path, owner, repo)
+	return u.String()
+}
+
+// BrowseURL returns the web url of a file or directory of a repository on branch.
+func BrowseURL(address, owner, repo, branch, filePath string) string {
+	return fmt.Sprintf("%s/%s/%s/src/branch/%s/%s", strings.TrimSuffix(address, "/"), owner, repo, branch, strings.TrimPrefix(filePath, "/"))
+}
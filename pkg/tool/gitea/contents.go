@@ -0,0 +1,171 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitea
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/27149chen/afero"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+	"github.com/koderover/zadig/pkg/util"
+	fsutil "github.com/koderover/zadig/pkg/util/fs"
+)
+
+const (
+	ContentTypeFile = "file"
+	ContentTypeDir  = "dir"
+)
+
+// ContentsResponse is a file or an entry of a directory in a repository,
+// Content is only set when a single file is requested.
+type ContentsResponse struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	SHA      string `json:"sha"`
+	Type     string `json:"type"`
+	Size     int64  `json:"size"`
+	Encoding string `json:"encoding"`
+	Content  string `json:"content"`
+}
+
+// GetContent returns the decoded content of a file.
+func (r *ContentsResponse) GetContent() (string, error) {
+	if r.Encoding != "base64" {
+		return r.Content, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(r.Content)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// GetContents returns either the metadata and content of a file or the entries of a directory on ref.
+func (c *Client) GetContents(owner, repo, path, ref string) (*ContentsResponse, []*ContentsResponse, error) {
+	res, err := c.Get(repoPath(owner, repo)+"/contents/"+escapePath(path), httpclient.SetQueryParam("ref", ref))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body := bytes.TrimSpace(res.Body())
+	if len(body) > 0 && body[0] == '[' {
+		var dir []*ContentsResponse
+		if err := json.Unmarshal(body, &dir); err != nil {
+			return nil, nil, err
+		}
+		return nil, dir, nil
+	}
+	file := &ContentsResponse{}
+	if err := json.Unmarshal(body, file); err != nil {
+		return nil, nil, err
+	}
+	return file, nil, nil
+}
+
+// GetRawFile returns the raw content of a file on ref.
+func (c *Client) GetRawFile(owner, repo, path, ref string) ([]byte, error) {
+	res, err := c.Get(repoPath(owner, repo)+"/raw/"+escapePath(path), httpclient.SetQueryParam("ref", ref))
+	if err != nil {
+		return nil, err
+	}
+	return res.Body(), nil
+}
+
+func isYAML(path string) bool {
+	return strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")
+}
+
+// GetYAMLContents returns the contents of all yaml files under path on branch, recursively.
+func (c *Client) GetYAMLContents(owner, repo, path, branch string, split bool) ([]string, error) {
+	fileContent, directoryContent, err := c.GetContents(owner, repo, path, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	if fileContent != nil {
+		if isYAML(fileContent.Path) {
+			content, err := fileContent.GetContent()
+			if err != nil {
+				return nil, err
+			}
+			if split {
+				res = util.SplitManifests(content)
+			} else {
+				res = []string{content}
+			}
+		}
+		return res, nil
+	}
+
+	for _, fileOrDir := range directoryContent {
+		if fileOrDir.Type == ContentTypeFile && !isYAML(fileOrDir.Path) {
+			continue
+		}
+		if fileOrDir.Type != ContentTypeFile && fileOrDir.Type != ContentTypeDir {
+			continue
+		}
+		r, err := c.GetYAMLContents(owner, repo, fileOrDir.Path, branch, split)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, r...)
+	}
+
+	return res, nil
+}
+
+// GetTreeContents downloads all files under path on branch into an in-memory file system,
+// the files are placed under the base name of path.
+func (c *Client) GetTreeContents(owner, repo, path, branch string) (afero.Fs, error) {
+	fs := afero.NewMemMapFs()
+	if err := c.getTreeContents(owner, repo, branch, path, path, fs); err != nil {
+		return nil, err
+	}
+
+	return fs, nil
+}
+
+func (c *Client) getTreeContents(owner, repo, branch, base, path string, fs afero.Fs) error {
+	fileContent, directoryContent, err := c.GetContents(owner, repo, path, branch)
+	if err != nil {
+		return err
+	}
+
+	if fileContent != nil {
+		content, err := fileContent.GetContent()
+		if err != nil {
+			return err
+		}
+		return afero.WriteFile(fs, fsutil.ShortenFileBase(base, path), []byte(content), 0644)
+	}
+
+	for _, fileOrDir := range directoryContent {
+		if fileOrDir.Type != ContentTypeFile && fileOrDir.Type != ContentTypeDir {
+			continue
+		}
+		if err = c.getTreeContents(owner, repo, branch, base, fileOrDir.Path, fs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
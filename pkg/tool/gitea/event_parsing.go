@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitea
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// EventType represents a gitea event type.
+type EventType string
+
+// List of available event types.
+const (
+	EventTypePush        EventType = "push"
+	EventTypePullRequest EventType = "pull_request"
+)
+
+const (
+	// Forgejo sends the same header besides its own X-Forgejo-Event
+	eventTypeHeader = "X-Gitea-Event"
+	signatureHeader = "X-Gitea-Signature"
+
+	PullRequestActionOpened       = "opened"
+	PullRequestActionReopened     = "reopened"
+	PullRequestActionSynchronized = "synchronized"
+
+	tagRefPrefix = "refs/tags/"
+)
+
+// HookEventType returns the event type for the given request.
+func HookEventType(r *http.Request) EventType {
+	return EventType(r.Header.Get(eventTypeHeader))
+}
+
+// ValidateSignature checks the HMAC-SHA256 signature Gitea adds to hooks configured with a secret.
+func ValidateSignature(r *http.Request, payload []byte, secret string) error {
+	if secret == "" {
+		return nil
+	}
+	signature := r.Header.Get(signatureHeader)
+	if signature == "" {
+		return errors.New("missing signature")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	if !hmac.Equal([]byte(signature), []byte(hex.EncodeToString(mac.Sum(nil)))) {
+		return errors.New("signature is illegal")
+	}
+	return nil
+}
+
+// PushEvent is a push to a branch or a tag.
+type PushEvent struct {
+	Ref          string           `json:"ref"`
+	Before       string           `json:"before"`
+	After        string           `json:"after"`
+	Commits      []*PayloadCommit `json:"commits"`
+	TotalCommits int              `json:"total_commits"`
+	HeadCommit   *PayloadCommit   `json:"head_commit"`
+	Repo         *Repository      `json:"repository"`
+	Pusher       *User            `json:"pusher"`
+	Sender       *User            `json:"sender"`
+}
+
+// IsTag reports whether a tag is pushed.
+func (ev *PushEvent) IsTag() bool {
+	return strings.HasPrefix(ev.Ref, tagRefPrefix)
+}
+
+// IsDelete reports whether the ref is deleted by the push.
+func (ev *PushEvent) IsDelete() bool {
+	return strings.Trim(ev.After, "0") == ""
+}
+
+// ChangedFiles returns the files changed by the pushed commits. Gitea puts a limited number of commits
+// into the payload, ok is false when some of them are missing and the changes are unknown.
+func (ev *PushEvent) ChangedFiles() (files []string, ok bool) {
+	if ev.TotalCommits > len(ev.Commits) {
+		return nil, false
+	}
+	for _, commit := range ev.Commits {
+		files = append(files, commit.Added...)
+		files = append(files, commit.Removed...)
+		files = append(files, commit.Modified...)
+	}
+	return files, true
+}
+
+type PullRequestEvent struct {
+	Action      string       `json:"action"`
+	Number      int          `json:"number"`
+	PullRequest *PullRequest `json:"pull_request"`
+	Repo        *Repository  `json:"repository"`
+	Sender      *User        `json:"sender"`
+}
+
+// ParseHook parses the payload of a Gitea event into a *PushEvent or *PullRequestEvent.
+func ParseHook(eventType EventType, payload []byte) (interface{}, error) {
+	var event interface{}
+	switch eventType {
+	case EventTypePush:
+		event = &PushEvent{}
+	case EventTypePullRequest:
+		event = &PullRequestEvent{}
+	default:
+		return nil, fmt.Errorf("unexpected event type: %s", eventType)
+	}
+
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
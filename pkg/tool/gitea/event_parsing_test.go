@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitea
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParsePushEvent(t *testing.T) {
+	payload := []byte(`{
+		"ref": "refs/heads/main",
+		"before": "1111111111111111111111111111111111111111",
+		"after": "2222222222222222222222222222222222222222",
+		"total_commits": 2,
+		"commits": [
+			{"id": "a", "added": ["a.yaml"], "removed": [], "modified": ["b.yaml"]},
+			{"id": "b", "added": [], "removed": ["c.yaml"], "modified": []}
+		],
+		"repository": {"name": "zadig", "full_name": "koderover/zadig", "owner": {"login": "koderover"}},
+		"pusher": {"login": "alice"}
+	}`)
+
+	event, err := ParseHook(EventTypePush, payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	ev, ok := event.(*PushEvent)
+	if !ok {
+		t.Fatalf("expected *PushEvent, got %T", event)
+	}
+	if ev.IsTag() || ev.IsDelete() {
+		t.Errorf("expected a branch push")
+	}
+	if ev.Repo.Owner.Login != "koderover" || ev.Pusher.Login != "alice" {
+		t.Errorf("unexpected repository or pusher: %+v %+v", ev.Repo, ev.Pusher)
+	}
+	files, ok := ev.ChangedFiles()
+	if !ok || len(files) != 3 {
+		t.Errorf("expected 3 changed files, got %v (%v)", files, ok)
+	}
+
+	ev.TotalCommits = 10
+	if _, ok := ev.ChangedFiles(); ok {
+		t.Errorf("changes should be unknown when commits are truncated")
+	}
+
+	ev.Ref = "refs/tags/v1.0.0"
+	ev.After = "0000000000000000000000000000000000000000"
+	if !ev.IsTag() || !ev.IsDelete() {
+		t.Errorf("expected a deleted tag")
+	}
+}
+
+func TestParsePullRequestEvent(t *testing.T) {
+	payload := []byte(`{
+		"action": "synchronized",
+		"number": 7,
+		"pull_request": {
+			"number": 7,
+			"state": "open",
+			"user": {"login": "bob"},
+			"head": {"ref": "feature", "sha": "3333333333333333333333333333333333333333"},
+			"base": {"ref": "main", "sha": "4444444444444444444444444444444444444444"}
+		},
+		"repository": {"name": "zadig", "owner": {"login": "koderover"}}
+	}`)
+
+	event, err := ParseHook(EventTypePullRequest, payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	ev, ok := event.(*PullRequestEvent)
+	if !ok {
+		t.Fatalf("expected *PullRequestEvent, got %T", event)
+	}
+	if ev.Action != PullRequestActionSynchronized || ev.PullRequest.Base.Ref != "main" || ev.PullRequest.Head.Ref != "feature" {
+		t.Errorf("unexpected event: %+v", ev.PullRequest)
+	}
+
+	if _, err := ParseHook("issues", payload); err == nil {
+		t.Errorf("expected an error for unsupported events")
+	}
+}
+
+func TestValidateSignature(t *testing.T) {
+	payload := []byte(`{"a":1}`)
+	r, _ := http.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set(signatureHeader, "d42927434049e0b8c73ce887062238cc1c6bb6644bfe66e66d8dd0f30b85679e")
+
+	if err := ValidateSignature(r, payload, "s3cr3t"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if err := ValidateSignature(r, payload, "wrong"); err == nil {
+		t.Errorf("expected an error for a wrong secret")
+	}
+	if err := ValidateSignature(r, payload, ""); err != nil {
+		t.Errorf("signature should not be checked without a secret: %s", err)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitea
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+type PRBranchInfo struct {
+	Name   string      `json:"label"`
+	Ref    string      `json:"ref"`
+	Sha    string      `json:"sha"`
+	RepoID int64       `json:"repo_id"`
+	Repo   *Repository `json:"repo"`
+}
+
+type PullRequest struct {
+	ID        int64         `json:"id"`
+	Number    int           `json:"number"`
+	Title     string        `json:"title"`
+	State     string        `json:"state"`
+	User      *User         `json:"user"`
+	HTMLURL   string        `json:"html_url"`
+	Merged    bool          `json:"merged"`
+	Head      *PRBranchInfo `json:"head"`
+	Base      *PRBranchInfo `json:"base"`
+	CreatedAt *time.Time    `json:"created_at"`
+	UpdatedAt *time.Time    `json:"updated_at"`
+}
+
+type ChangedFile struct {
+	Filename         string `json:"filename"`
+	PreviousFilename string `json:"previous_filename"`
+	Status           string `json:"status"`
+}
+
+type Comment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// ListOpenPullRequests returns the open pull requests of a repository, targetBranch filters them by base branch if set.
+func (c *Client) ListOpenPullRequests(owner, repo, targetBranch string) ([]*PullRequest, error) {
+	var res []*PullRequest
+	err := c.listAll(func(page int) (int, error) {
+		var prs []*PullRequest
+		if err := c.listPage(repoPath(owner, repo)+"/pulls", map[string]string{"state": "open"}, page, defaultPerPage, &prs); err != nil {
+			return 0, err
+		}
+		for _, pr := range prs {
+			if targetBranch != "" && (pr.Base == nil || pr.Base.Ref != targetBranch) {
+				continue
+			}
+			res = append(res, pr)
+		}
+		return len(prs), nil
+	})
+	return res, err
+}
+
+// ListPullRequestChangedFiles returns the paths of files changed in a pull request, renamed files count twice.
+func (c *Client) ListPullRequestChangedFiles(owner, repo string, number int) ([]string, error) {
+	var res []string
+	err := c.listAll(func(page int) (int, error) {
+		var files []*ChangedFile
+		if err := c.listPage(fmt.Sprintf("%s/pulls/%d/files", repoPath(owner, repo), number), nil, page, defaultPerPage, &files); err != nil {
+			return 0, err
+		}
+		for _, f := range files {
+			res = append(res, f.Filename)
+			if f.PreviousFilename != "" && f.PreviousFilename != f.Filename {
+				res = append(res, f.PreviousFilename)
+			}
+		}
+		return len(files), nil
+	})
+	return res, err
+}
+
+// CreateIssueComment comments on an issue or a pull request.
+func (c *Client) CreateIssueComment(owner, repo string, number int, body string) (*Comment, error) {
+	res := &Comment{}
+	_, err := c.Post(fmt.Sprintf("%s/issues/%d/comments", repoPath(owner, repo), number),
+		httpclient.SetBody(map[string]string{"body": body}),
+		httpclient.SetResult(res),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) EditIssueComment(owner, repo string, commentID int64, body string) error {
+	_, err := c.Patch(fmt.Sprintf("%s/issues/comments/%d", repoPath(owner, repo), commentID),
+		httpclient.SetBody(map[string]string{"body": body}),
+	)
+	return err
+}
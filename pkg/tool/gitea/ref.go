@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitea
+
+import (
+	"fmt"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+type PayloadUser struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	UserName string `json:"username"`
+}
+
+// PayloadCommit is the commit in branches and push events, the file lists are only set in push events.
+type PayloadCommit struct {
+	ID       string       `json:"id"`
+	Message  string       `json:"message"`
+	URL      string       `json:"url"`
+	Author   *PayloadUser `json:"author"`
+	Added    []string     `json:"added"`
+	Removed  []string     `json:"removed"`
+	Modified []string     `json:"modified"`
+}
+
+type Branch struct {
+	Name   string         `json:"name"`
+	Commit *PayloadCommit `json:"commit"`
+}
+
+type Tag struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Commit  *struct {
+		SHA string `json:"sha"`
+	} `json:"commit"`
+}
+
+type Commit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Message string `json:"message"`
+	} `json:"commit"`
+}
+
+func (c *Client) ListBranches(owner, repo string) ([]*Branch, error) {
+	var res []*Branch
+	err := c.listAll(func(page int) (int, error) {
+		var branches []*Branch
+		if err := c.listPage(repoPath(owner, repo)+"/branches", nil, page, defaultPerPage, &branches); err != nil {
+			return 0, err
+		}
+		res = append(res, branches...)
+		return len(branches), nil
+	})
+	return res, err
+}
+
+func (c *Client) GetBranch(owner, repo, branch string) (*Branch, error) {
+	res := &Branch{}
+	if _, err := c.Get(repoPath(owner, repo)+"/branches/"+escapePath(branch), httpclient.SetResult(res)); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) ListTags(owner, repo string) ([]*Tag, error) {
+	var res []*Tag
+	err := c.listAll(func(page int) (int, error) {
+		var tags []*Tag
+		if err := c.listPage(repoPath(owner, repo)+"/tags", nil, page, defaultPerPage, &tags); err != nil {
+			return 0, err
+		}
+		res = append(res, tags...)
+		return len(tags), nil
+	})
+	return res, err
+}
+
+// GetLatestCommit returns the latest commit on branch which touches path, an empty path means the whole repository.
+func (c *Client) GetLatestCommit(owner, repo, path, branch string) (*Commit, error) {
+	params := map[string]string{
+		"sha":   branch,
+		"stat":  "false",
+		"files": "false",
+	}
+	if path != "" {
+		params["path"] = path
+	}
+	var commits []*Commit
+	if err := c.listPage(repoPath(owner, repo)+"/commits", params, 1, 1, &commits); err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commit found on branch %s of %s/%s", branch, owner, repo)
+	}
+	return commits[0], nil
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitea
+
+import (
+	"strconv"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+const (
+	NamespaceKindUser = "user"
+	NamespaceKindOrg  = "org"
+)
+
+type User struct {
+	ID       int64  `json:"id"`
+	Login    string `json:"login"`
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+}
+
+// Namespace is a user or an organization owning repositories.
+type Namespace struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+type Repository struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Owner         *User  `json:"owner"`
+	Description   string `json:"description"`
+	Private       bool   `json:"private"`
+	DefaultBranch string `json:"default_branch"`
+	HTMLURL       string `json:"html_url"`
+	CloneURL      string `json:"clone_url"`
+}
+
+type organization struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+type searchResult struct {
+	OK   bool          `json:"ok"`
+	Data []*Repository `json:"data"`
+}
+
+// GetCurrentUser returns the user the token belongs to.
+func (c *Client) GetCurrentUser() (*User, error) {
+	user := &User{}
+	if _, err := c.Get("/user", httpclient.SetResult(user)); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ListNamespaces returns the current user and the organizations the user belongs to.
+func (c *Client) ListNamespaces() ([]*Namespace, error) {
+	user, err := c.GetCurrentUser()
+	if err != nil {
+		return nil, err
+	}
+	res := []*Namespace{{Name: user.Login, Kind: NamespaceKindUser}}
+
+	err = c.listAll(func(page int) (int, error) {
+		var orgs []*organization
+		if err := c.listPage("/user/orgs", nil, page, defaultPerPage, &orgs); err != nil {
+			return 0, err
+		}
+		for _, o := range orgs {
+			res = append(res, &Namespace{Name: o.Username, Kind: NamespaceKindOrg})
+		}
+		return len(orgs), nil
+	})
+	return res, err
+}
+
+// ListRepositories returns a page of the repositories owned by owner whose names contain keyword.
+func (c *Client) ListRepositories(owner, keyword string, page, perPage int) ([]*Repository, error) {
+	u := &User{}
+	if _, err := c.Get(api("/users/%s", owner), httpclient.SetResult(u)); err != nil {
+		return nil, err
+	}
+
+	res := &searchResult{}
+	params := map[string]string{
+		"uid":       strconv.FormatInt(u.ID, 10),
+		"exclusive": "true",
+	}
+	if keyword != "" {
+		params["q"] = keyword
+	}
+	if err := c.listPage("/repos/search", params, page, perPage, res); err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
+
+func (c *Client) GetRepository(owner, repo string) (*Repository, error) {
+	res := &Repository{}
+	if _, err := c.Get(repoPath(owner, repo), httpclient.SetResult(res)); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
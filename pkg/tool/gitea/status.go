@@ -0,0 +1,45 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitea
+
+import (
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+type StatusState string
+
+const (
+	StatusPending StatusState = "pending"
+	StatusSuccess StatusState = "success"
+	StatusError   StatusState = "error"
+	StatusFailure StatusState = "failure"
+	StatusWarning StatusState = "warning"
+)
+
+type CommitStatus struct {
+	State       StatusState `json:"state"`
+	TargetURL   string      `json:"target_url"`
+	Description string      `json:"description"`
+	// Context identifies the status, statuses with the same context on a commit overwrite each other.
+	Context string `json:"context"`
+}
+
+// CreateCommitStatus creates or updates the status of a commit.
+func (c *Client) CreateCommitStatus(owner, repo, sha string, status *CommitStatus) error {
+	_, err := c.Post(repoPath(owner, repo)+"/statuses/"+sha, httpclient.SetBody(status))
+	return err
+}
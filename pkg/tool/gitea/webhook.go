@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitea
+
+import (
+	"fmt"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+type hookConfig struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Secret      string `json:"secret,omitempty"`
+}
+
+type createHookOption struct {
+	Type   string     `json:"type"`
+	Config hookConfig `json:"config"`
+	Events []string   `json:"events"`
+	Active bool       `json:"active"`
+}
+
+type Hook struct {
+	ID     int64    `json:"id"`
+	Type   string   `json:"type"`
+	Events []string `json:"events"`
+	Active bool     `json:"active"`
+}
+
+// CreateHook registers a webhook for push and pull request events, tag pushes are delivered as push events.
+func (c *Client) CreateHook(owner, repo, hookURL, secret string) (*Hook, error) {
+	res := &Hook{}
+	_, err := c.Post(repoPath(owner, repo)+"/hooks",
+		httpclient.SetBody(&createHookOption{
+			Type: "gitea",
+			Config: hookConfig{
+				URL:         hookURL,
+				ContentType: "json",
+				Secret:      secret,
+			},
+			Events: []string{"push", "pull_request"},
+			Active: true,
+		}),
+		httpclient.SetResult(res),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) DeleteHook(owner, repo string, id int64) error {
+	_, err := c.Delete(fmt.Sprintf("%s/hooks/%d", repoPath(owner, repo), id))
+	return err
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitee
+
+import (
+	"fmt"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+const (
+	CheckRunStatusQueued     = "queued"
+	CheckRunStatusInProgress = "in_progress"
+	CheckRunStatusCompleted  = "completed"
+
+	CheckRunConclusionSuccess   = "success"
+	CheckRunConclusionFailure   = "failure"
+	CheckRunConclusionCancelled = "cancelled"
+	CheckRunConclusionTimedOut  = "timed_out"
+)
+
+type CheckRun struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	HeadSha    string `json:"head_sha"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	DetailsURL string `json:"details_url"`
+}
+
+type CheckRunOutput struct {
+	Title   string `json:"title"`
+	Summary string `json:"summary"`
+}
+
+type CheckRunOptions struct {
+	Name       string          `json:"name"`
+	HeadSha    string          `json:"head_sha,omitempty"`
+	Status     string          `json:"status"`
+	Conclusion string          `json:"conclusion,omitempty"`
+	DetailsURL string          `json:"details_url,omitempty"`
+	Output     *CheckRunOutput `json:"output,omitempty"`
+}
+
+type checkRunList struct {
+	TotalCount int         `json:"total_count"`
+	CheckRuns  []*CheckRun `json:"check_runs"`
+}
+
+// ListCheckRunsForRef lists the check runs of the given name on a commit.
+func (c *Client) ListCheckRunsForRef(hostURL, accessToken, owner, repo, ref, name string) ([]*CheckRun, error) {
+	apiHost := fmt.Sprintf("%s/%s", hostURL, "api")
+	httpClient := httpclient.New(
+		httpclient.SetHostURL(apiHost),
+	)
+	url := fmt.Sprintf("/v5/repos/%s/%s/commits/%s/check-runs", owner, repo, ref)
+	result := &checkRunList{}
+	_, err := httpClient.Get(url, httpclient.SetQueryParams(map[string]string{
+		"access_token": accessToken,
+		"check_name":   name,
+	}), httpclient.SetResult(result))
+	if err != nil {
+		return nil, err
+	}
+	return result.CheckRuns, nil
+}
+
+func (c *Client) CreateCheckRun(hostURL, accessToken, owner, repo string, opts *CheckRunOptions) (*CheckRun, error) {
+	apiHost := fmt.Sprintf("%s/%s", hostURL, "api")
+	httpClient := httpclient.New(
+		httpclient.SetHostURL(apiHost),
+	)
+	url := fmt.Sprintf("/v5/repos/%s/%s/check-runs", owner, repo)
+	checkRun := &CheckRun{}
+	_, err := httpClient.Post(url, httpclient.SetQueryParam("access_token", accessToken), httpclient.SetBody(opts), httpclient.SetResult(checkRun))
+	if err != nil {
+		return nil, err
+	}
+	return checkRun, nil
+}
+
+func (c *Client) UpdateCheckRun(hostURL, accessToken, owner, repo string, id int64, opts *CheckRunOptions) error {
+	apiHost := fmt.Sprintf("%s/%s", hostURL, "api")
+	httpClient := httpclient.New(
+		httpclient.SetHostURL(apiHost),
+	)
+	url := fmt.Sprintf("/v5/repos/%s/%s/check-runs/%d", owner, repo, id)
+	_, err := httpClient.Patch(url, httpclient.SetQueryParam("access_token", accessToken), httpclient.SetBody(opts))
+	return err
+}
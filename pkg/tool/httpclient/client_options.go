@@ -19,6 +19,8 @@ package httpclient
 import (
 	"crypto/tls"
 	"time"
+
+	"github.com/go-resty/resty/v2"
 )
 
 type ClientFunc func(*Client)
@@ -60,12 +62,25 @@ func SetProxy(proxyURL string) ClientFunc {
 	}
 }
 
+func SetTimeout(timeout time.Duration) ClientFunc {
+	return func(c *Client) {
+		c.Client.SetTimeout(timeout)
+	}
+}
+
 func UnsetTimeout() ClientFunc {
 	return func(c *Client) {
 		c.Client.SetTimeout(0)
 	}
 }
 
+// DisableRedirect makes the client return the redirect response instead of following it.
+func DisableRedirect() ClientFunc {
+	return func(c *Client) {
+		c.Client.SetRedirectPolicy(resty.NoRedirectPolicy())
+	}
+}
+
 func SetIgnoreCodes(codes ...int) ClientFunc {
 	return func(c *Client) {
 		c.IgnoreCodes.Insert(codes...)
@@ -19,7 +19,9 @@ package jira
 import (
 	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -52,6 +54,13 @@ const commentTmpl = `{"body": {
   ]
 }}`
 
+var issueKeyRegexp = regexp.MustCompile(`\b[A-Z][A-Z0-9_]+-[0-9]+\b`)
+
+// FindIssueKeys returns the issue keys like "ZADIG-123" mentioned in the text
+func FindIssueKeys(text string) []string {
+	return issueKeyRegexp.FindAllString(text, -1)
+}
+
 // IssueService ...
 type IssueService struct {
 	client *Client
@@ -77,6 +86,51 @@ func (s *IssueService) GetByKeyOrID(keyOrID, fields string) (*Issue, error) {
 	return issue, nil
 }
 
+type CreateIssueArgs struct {
+	ProjectKey  string
+	IssueType   string
+	Summary     string
+	Description string
+	Labels      []string
+	// Assignee is the account id for jira cloud and the username for jira server
+	Assignee string
+}
+
+// Create https://developer.atlassian.com/cloud/jira/platform/rest/v2/api-group-issues/#api-rest-api-2-issue-post
+func (s *IssueService) Create(args *CreateIssueArgs) (string, error) {
+	url := s.client.Host + "/rest/api/2/issue"
+
+	fields := map[string]interface{}{
+		"project":     map[string]string{"key": args.ProjectKey},
+		"issuetype":   map[string]string{"name": args.IssueType},
+		"summary":     args.Summary,
+		"description": args.Description,
+	}
+	if len(args.Labels) > 0 {
+		fields["labels"] = args.Labels
+	}
+	if args.Assignee != "" {
+		if strings.HasSuffix(strings.TrimSuffix(s.client.Host, "/"), ".atlassian.net") {
+			fields["assignee"] = map[string]string{"accountId": args.Assignee}
+		} else {
+			fields["assignee"] = map[string]string{"name": args.Assignee}
+		}
+	}
+
+	resp, err := s.client.R().SetBodyJsonMarshal(map[string]interface{}{"fields": fields}).Post(url)
+	if err != nil {
+		return "", err
+	}
+	if resp.GetStatusCode()/100 != 2 {
+		return "", errors.Errorf("unexpected status code %d, body: %s", resp.GetStatusCode(), resp.String())
+	}
+	created := &Issue{}
+	if err = resp.UnmarshalJson(created); err != nil {
+		return "", errors.Wrap(err, "unmarshal")
+	}
+	return created.Key, nil
+}
+
 type IssueTypeDefinition struct {
 	Self     string `json:"self"`
 	ID       string `json:"id"`
@@ -255,6 +309,28 @@ func (s *IssueService) AddCommentV3(key, comment, link, linkTitle string) error
 	return nil
 }
 
+// AddRemoteLink creates or updates the remote link identified by globalID
+// https://developer.atlassian.com/cloud/jira/platform/rest/v2/api-group-issue-remote-links/#api-rest-api-2-issue-issueidorkey-remotelink-post
+func (s *IssueService) AddRemoteLink(key, globalID, link, title, summary string) error {
+	url := s.client.Host + "/rest/api/2/issue/" + key + "/remotelink"
+
+	resp, err := s.client.R().SetBodyJsonMarshal(map[string]interface{}{
+		"globalId": globalID,
+		"object": map[string]string{
+			"url":     link,
+			"title":   title,
+			"summary": summary,
+		},
+	}).Post(url)
+	if err != nil {
+		return err
+	}
+	if resp.GetStatusCode()/100 != 2 {
+		return errors.Errorf("get unexpected status code %d, body: %s", resp.GetStatusCode(), resp.String())
+	}
+	return nil
+}
+
 func (s *IssueService) AddCommentV2(key, comment string) error {
 	url := s.client.Host + "/rest/api/2/issue/" + key + "/comment"
 
@@ -0,0 +1,45 @@
+/*
+Copyright 2021 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jira
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindIssueKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "no key", text: "fix typo", want: nil},
+		{name: "single key", text: "ZADIG-123 fix typo", want: []string{"ZADIG-123"}},
+		{name: "multiple keys", text: "[ZADIG-1] fix OPS_2-45, see ZADIG-1", want: []string{"ZADIG-1", "OPS_2-45", "ZADIG-1"}},
+		{name: "lower case", text: "zadig-123 fix", want: nil},
+		{name: "single letter project", text: "Z-123 fix", want: nil},
+		{name: "inside word", text: "fooZADIG-123 ZADIG-123bar", want: nil},
+		{name: "multiline", text: "fix login\n\nCloses AUTH-7", want: []string{"AUTH-7"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindIssueKeys(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindIssueKeys(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -58,6 +58,24 @@ func ListCronJobsV1Beta(ns string, selector labels.Selector, cl client.Client) (
 	return res, err
 }
 
+func GetCronJob(ns, name string, cl client.Client) (*batchv1.CronJob, bool, error) {
+	cj := &batchv1.CronJob{}
+	found, err := GetResourceInCache(ns, name, cj, cl)
+	if err != nil || !found {
+		cj = nil
+	}
+	return cj, found, err
+}
+
+func GetCronJobV1Beta(ns, name string, cl client.Client) (*batchv1beta1.CronJob, bool, error) {
+	cj := &batchv1beta1.CronJob{}
+	found, err := GetResourceInCache(ns, name, cj, cl)
+	if err != nil || !found {
+		cj = nil
+	}
+	return cj, found, err
+}
+
 func GetCronJobYaml(ns, name string, cl client.Client, versionLessThan121 bool) ([]byte, bool, error) {
 	gvk := CronJobGVK
 	bytes, existed, err := GetResourceYamlInCache(ns, name, gvk, cl)
@@ -18,7 +18,9 @@ package updater
 
 import (
 	"context"
+	"fmt"
 
+	batchv1 "k8s.io/api/batch/v1"
 	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -46,3 +48,27 @@ func DeleteCronJobs(namespace string, selector labels.Selector, clientset *kuber
 func CreateOrPatchCronJob(cj *batchv1beta1.CronJob, cl client.Client) error {
 	return createOrPatchObject(cj, cl)
 }
+
+// PatchCronJob patches the batch/v1 CronJob, or the batch/v1beta1 one if the cluster is older than 1.21.
+func PatchCronJob(ns, name string, patchBytes []byte, cl client.Client, versionLessThan121 bool) error {
+	meta := metav1.ObjectMeta{
+		Namespace: ns,
+		Name:      name,
+	}
+	if versionLessThan121 {
+		return patchObject(&batchv1beta1.CronJob{ObjectMeta: meta}, patchBytes, cl)
+	}
+	return patchObject(&batchv1.CronJob{ObjectMeta: meta}, patchBytes, cl)
+}
+
+func UpdateCronJobImage(ns, name, container, image string, cl client.Client, versionLessThan121 bool) error {
+	patchBytes := []byte(fmt.Sprintf(`{"spec":{"jobTemplate":{"spec":{"template":{"spec":{"containers":[{"name":"%s","image":"%s"}]}}}}}}`, container, image))
+
+	return PatchCronJob(ns, name, patchBytes, cl, versionLessThan121)
+}
+
+// SuspendCronJob suspends or resumes the scheduling of the CronJob, the running jobs are not affected.
+func SuspendCronJob(ns, name string, suspend bool, cl client.Client, versionLessThan121 bool) error {
+	patchBytes := []byte(fmt.Sprintf(`{"spec":{"suspend":%t}}`, suspend))
+	return PatchCronJob(ns, name, patchBytes, cl, versionLessThan121)
+}
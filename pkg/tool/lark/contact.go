@@ -221,6 +221,30 @@ func (client *Client) GetUserInfoByID(id string) (*UserInfo, error) {
 	}, nil
 }
 
+// GetUserContactInfoByID returns the email and mobile of the user, which requires the contact permissions of the app
+func (client *Client) GetUserContactInfoByID(id string) (*UserContactInfo, error) {
+	req := larkcontact.NewGetUserReqBuilder().
+		UserId(id).
+		UserIdType(setting.LarkUserOpenID).
+		Build()
+
+	resp, err := client.Contact.User.Get(context.Background(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	if !resp.Success() {
+		return nil, resp.CodeError
+	}
+
+	return &UserContactInfo{
+		ID:     id,
+		Name:   getStringFromPointer(resp.Data.User.Name),
+		Email:  getStringFromPointer(resp.Data.User.Email),
+		Mobile: getStringFromPointer(resp.Data.User.Mobile),
+	}, nil
+}
+
 func (client *Client) GetDepartmentInfoByID(id string) (*DepartmentInfo, error) {
 	req := larkcontact.NewGetDepartmentReqBuilder().
 		DepartmentId(id).
@@ -22,6 +22,13 @@ type UserInfo struct {
 	Avatar string `json:"avatar" yaml:"avatar" bson:"avatar"`
 }
 
+type UserContactInfo struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Mobile string `json:"mobile"`
+}
+
 type DepartmentInfo struct {
 	ID   string `json:"id" yaml:"id" bson:"id"`
 	Name string `json:"name" yaml:"name" bson:"name"`
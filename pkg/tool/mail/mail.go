@@ -19,12 +19,14 @@ package mail
 import (
 	"bytes"
 	"html/template"
+	"strings"
 
 	"gopkg.in/gomail.v2"
 )
 
 type EmailParams struct {
-	From     string
+	From string
+	// To can contain multiple receivers separated by commas
 	To       string
 	Subject  string
 	Body     string
@@ -37,7 +39,7 @@ type EmailParams struct {
 func SendEmail(param *EmailParams) error {
 	m := gomail.NewMessage()
 	m.SetHeader("From", param.From)
-	m.SetHeader("To", param.To)
+	m.SetHeader("To", strings.Split(param.To, ",")...)
 	m.SetHeader("Subject", param.Subject)
 	m.SetBody("text/html", param.Body)
 
@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongo
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Lease makes sure a background job runs in only one replica at a time. The replica which holds
+// the lease keeps it by acquiring it again before it expires, other replicas take it over after
+// the holder stops renewing it.
+type Lease struct {
+	coll   *mongo.Collection
+	name   string
+	holder string
+	ttl    time.Duration
+}
+
+func NewLease(coll *mongo.Collection, name string, ttl time.Duration) *Lease {
+	holder, err := os.Hostname()
+	if err != nil || holder == "" {
+		holder = name
+	}
+	return &Lease{coll: coll, name: name, holder: holder, ttl: ttl}
+}
+
+// Acquire takes or renews the lease, false is returned if it is held by another replica.
+func (l *Lease) Acquire(ctx context.Context) (bool, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id": l.name,
+		"$or": bson.A{
+			bson.M{"holder": l.holder},
+			bson.M{"expire_at": bson.M{"$lt": now.Unix()}},
+		},
+	}
+	change := bson.M{"$set": bson.M{
+		"holder":    l.holder,
+		"expire_at": now.Add(l.ttl).Unix(),
+	}}
+	// the upsert fails with a duplicate key error if the lease exists and doesn't match the filter
+	_, err := l.coll.UpdateOne(ctx, filter, change, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
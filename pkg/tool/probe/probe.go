@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package probe
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/koderover/zadig/pkg/types"
+)
+
+const (
+	// Success Result
+	Success string = "success"
+	// Failure Result
+	Failure string = "failure"
+)
+
+// DoTCPProbe checks whether a TCP connection can be established to the address.
+func DoTCPProbe(addr string, port int, timeout time.Duration, log *zap.SugaredLogger) (string, error) {
+	var (
+		conn net.Conn
+		err  error
+	)
+	if port == 0 {
+		conn, err = net.DialTimeout("tcp", addr, timeout)
+	} else {
+		conn, err = net.DialTimeout("tcp", fmt.Sprintf("%s:%d", addr, port), timeout)
+	}
+
+	if err != nil {
+		return Failure, err
+	}
+	err = conn.Close()
+	if err != nil {
+		log.Errorf("Unexpected error closing TCP socket: %v (%#v)", err, err)
+		return Failure, err
+	}
+	return Success, nil
+}
+
+// DoHTTPProbe sends a GET request to the address and checks the status code and the response body.
+func DoHTTPProbe(protocol, address, path string, port int, headerList []*types.HTTPHeader, timeout time.Duration, responseSuccessFlag string, log *zap.SugaredLogger) (string, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	transport := &http.Transport{
+		TLSClientConfig:   tlsConfig,
+		DisableKeepAlives: true,
+		Proxy:             http.ProxyURL(nil),
+	}
+	client := &http.Client{
+		Timeout:       timeout,
+		Transport:     transport,
+		CheckRedirect: redirectChecker(false),
+	}
+	url, err := formatURL(protocol, address, path, port)
+	if err != nil {
+		return Failure, err
+	}
+	headers := buildHeader(headerList)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return Failure, err
+	}
+	req.Header = headers
+	req.Host = headers.Get("Host")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return Failure, err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return Failure, err
+	}
+
+	if res.StatusCode >= http.StatusOK && res.StatusCode < http.StatusBadRequest {
+		if responseSuccessFlag != "" && !strings.Contains(string(body), responseSuccessFlag) {
+			return Failure, fmt.Errorf("HTTP probe failed with response success flag: %s", responseSuccessFlag)
+		}
+		log.Infof("Probe succeeded for %s, Response: %v", url, *res)
+		return Success, nil
+	}
+	log.Warnf("Probe failed for %s, response body: %v", url, string(body))
+	return Failure, fmt.Errorf("HTTP probe failed with statuscode: %d", res.StatusCode)
+}
+
+func redirectChecker(followNonLocalRedirects bool) func(*http.Request, []*http.Request) error {
+	if followNonLocalRedirects {
+		return nil // Use the default http client checker.
+	}
+
+	return func(req *http.Request, via []*http.Request) error {
+		if req.URL.Hostname() != via[0].URL.Hostname() {
+			return http.ErrUseLastResponse
+		}
+		// Default behavior: stop after 10 redirects.
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+}
+
+func formatURL(protocol, address, path string, port int) (string, error) {
+	if len(strings.Split(address, ":")) > 2 {
+		return "", fmt.Errorf("illegal address")
+	}
+	if path == "" && port == 0 {
+		return fmt.Sprintf("%s://%s", protocol, address), nil
+	}
+
+	path = strings.TrimPrefix(path, "/")
+
+	if port == 0 {
+		return fmt.Sprintf("%s://%s/%s", protocol, address, path), nil
+	}
+	return fmt.Sprintf("%s://%s:%d/%s", protocol, address, port, path), nil
+}
+
+func buildHeader(headerList []*types.HTTPHeader) http.Header {
+	headers := make(http.Header)
+	for _, header := range headerList {
+		headers[header.Name] = append(headers[header.Name], header.Value)
+	}
+	return headers
+}
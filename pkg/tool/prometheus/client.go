@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+// Client queries the http api of Prometheus, or any service compatible with it such as Thanos and VictoriaMetrics.
+type Client struct {
+	*httpclient.Client
+}
+
+func NewClient(host, token string) *Client {
+	// redirects are not followed so that the requests do not leave the configured host
+	cfs := []httpclient.ClientFunc{httpclient.SetHostURL(host), httpclient.DisableRedirect()}
+	if token != "" {
+		cfs = append(cfs, httpclient.SetAuthScheme("Bearer"), httpclient.SetAuthToken(token))
+	}
+
+	return &Client{
+		Client: httpclient.New(cfs...),
+	}
+}
+
+type queryResponse struct {
+	Status    string    `json:"status"`
+	Data      queryData `json:"data"`
+	ErrorType string    `json:"errorType"`
+	Error     string    `json:"error"`
+}
+
+type queryData struct {
+	ResultType string          `json:"resultType"`
+	Result     json.RawMessage `json:"result"`
+}
+
+type vectorSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  []interface{}     `json:"value"`
+}
+
+// QueryScalar runs an instant query and returns its value, the query must return a scalar or a vector with exactly one sample.
+func (c *Client) QueryScalar(query string) (float64, error) {
+	resp := &queryResponse{}
+	if _, err := c.Get("/api/v1/query", httpclient.SetQueryParam("query", query), httpclient.SetResult(resp)); err != nil {
+		return 0, err
+	}
+	if resp.Status != "success" {
+		return 0, fmt.Errorf("query %s failed, %s: %s", query, resp.ErrorType, resp.Error)
+	}
+
+	switch resp.Data.ResultType {
+	case "scalar":
+		value := make([]interface{}, 0)
+		if err := json.Unmarshal(resp.Data.Result, &value); err != nil {
+			return 0, err
+		}
+		return parseSampleValue(value)
+	case "vector":
+		samples := make([]*vectorSample, 0)
+		if err := json.Unmarshal(resp.Data.Result, &samples); err != nil {
+			return 0, err
+		}
+		if len(samples) != 1 {
+			return 0, fmt.Errorf("query %s returns %d samples, exactly one is expected", query, len(samples))
+		}
+		return parseSampleValue(samples[0].Value)
+	default:
+		return 0, fmt.Errorf("unsupported result type %s of query %s", resp.Data.ResultType, query)
+	}
+}
+
+// parseSampleValue parses the value of a sample, which is in the form of [<unix_time>, "<value>"].
+func parseSampleValue(value []interface{}) (float64, error) {
+	if len(value) != 2 {
+		return 0, fmt.Errorf("invalid sample value: %v", value)
+	}
+	s, ok := value[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("invalid sample value: %v", value)
+	}
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secret
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ProjectsAnnotation lists the projects which are allowed to read the Secret, separated by commas,
+// a single * allows all projects.
+const ProjectsAnnotation = "secret.zadig.koderover.com/projects"
+
+// KubernetesProvider reads secrets from Kubernetes Secrets in the configured namespace only. The path is the
+// name of the Secret, and the Secret must allow the project in the ProjectsAnnotation annotation.
+type KubernetesProvider struct {
+	clientset kubernetes.Interface
+	namespace string
+}
+
+func NewKubernetesProvider(clientset kubernetes.Interface, namespace string) *KubernetesProvider {
+	return &KubernetesProvider{clientset: clientset, namespace: namespace}
+}
+
+func (p *KubernetesProvider) GetSecret(ctx context.Context, project, path string) (map[string]string, error) {
+	if strings.Contains(path, "/") {
+		return nil, fmt.Errorf("secret name %s can't contain a namespace, secrets are read from namespace %s", path, p.namespace)
+	}
+
+	secret, err := p.clientset.CoreV1().Secrets(p.namespace).Get(ctx, path, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if !projectAllowed(secret.Annotations[ProjectsAnnotation], project) {
+		return nil, fmt.Errorf("secret %s is not allowed to be used in project %s", path, project)
+	}
+
+	data := make(map[string]string, len(secret.Data)+len(secret.StringData))
+	for k, v := range secret.Data {
+		data[k] = string(v)
+	}
+	for k, v := range secret.StringData {
+		data[k] = v
+	}
+	return data, nil
+}
+
+func projectAllowed(allowList, project string) bool {
+	for _, p := range strings.Split(allowList, ",") {
+		p = strings.TrimSpace(p)
+		if p == "*" || (p != "" && p == project) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package secret resolves references to secrets kept in external secret stores.
+// A reference has the form of secret://path#key, the path is interpreted by the provider
+// and is always scoped to the project the reference is used in.
+package secret
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const Scheme = "secret://"
+
+// Provider reads a secret of the project from a secret store, all the key value pairs under the path are returned.
+// The provider must make sure that a project can't read the secrets of other projects.
+type Provider interface {
+	GetSecret(ctx context.Context, project, path string) (map[string]string, error)
+}
+
+type Reference struct {
+	Path string
+	Key  string
+}
+
+func (r *Reference) String() string {
+	return fmt.Sprintf("%s%s#%s", Scheme, r.Path, r.Key)
+}
+
+// IsReference returns whether the value refers to an external secret.
+func IsReference(value string) bool {
+	return strings.HasPrefix(strings.TrimSpace(value), Scheme)
+}
+
+// ParseReference parses the value in the form of secret://path#key.
+func ParseReference(value string) (*Reference, error) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, Scheme) {
+		return nil, fmt.Errorf("%s is not a secret reference", value)
+	}
+
+	ref := strings.TrimPrefix(value, Scheme)
+	idx := strings.LastIndex(ref, "#")
+	if idx < 0 {
+		return nil, fmt.Errorf("key is missing in secret reference %s", value)
+	}
+	path, key := strings.Trim(ref[:idx], "/"), ref[idx+1:]
+	if path == "" || key == "" {
+		return nil, fmt.Errorf("invalid secret reference %s", value)
+	}
+	return &Reference{Path: path, Key: key}, nil
+}
+
+// Resolve returns the secret of the project the value refers to, the value is returned as is if it is not a reference.
+func Resolve(ctx context.Context, p Provider, project, value string) (string, error) {
+	if !IsReference(value) {
+		return value, nil
+	}
+	if p == nil {
+		return "", fmt.Errorf("no secret provider is configured")
+	}
+
+	ref, err := ParseReference(value)
+	if err != nil {
+		return "", err
+	}
+	if project == "" {
+		return "", fmt.Errorf("project is required to resolve secret %s", ref.Path)
+	}
+	data, err := p.GetSecret(ctx, project, ref.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s: %s", ref.Path, err)
+	}
+	v, ok := data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in secret %s", ref.Key, ref.Path)
+	}
+	return v, nil
+}
+
+// FakeProvider keeps secrets in memory, it is meant to be used in tests.
+// The secrets are keyed by project/path.
+type FakeProvider struct {
+	Secrets map[string]map[string]string
+}
+
+func NewFakeProvider(secrets map[string]map[string]string) *FakeProvider {
+	return &FakeProvider{Secrets: secrets}
+}
+
+func (p *FakeProvider) GetSecret(_ context.Context, project, path string) (map[string]string, error) {
+	data, ok := p.Secrets[project+"/"+path]
+	if !ok {
+		return nil, fmt.Errorf("secret %s not found", path)
+	}
+	return data, nil
+}
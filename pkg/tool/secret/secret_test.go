@@ -0,0 +1,167 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secret
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    *Reference
+		wantErr bool
+	}{
+		{value: "secret://ci/docker#password", want: &Reference{Path: "ci/docker", Key: "password"}},
+		{value: " secret:///ci/docker/#password ", want: &Reference{Path: "ci/docker", Key: "password"}},
+		{value: "secret://ci/docker", wantErr: true},
+		{value: "secret://#password", wantErr: true},
+		{value: "plain", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseReference(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseReference(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if err == nil && *got != *tt.want {
+			t.Errorf("ParseReference(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	p := NewFakeProvider(map[string]map[string]string{
+		"demo/ci/docker": {"password": "s3cr3t"},
+	})
+
+	got, err := Resolve(context.TODO(), p, "demo", "secret://ci/docker#password")
+	if err != nil || got != "s3cr3t" {
+		t.Errorf("Resolve() = %q, %v", got, err)
+	}
+	if got, err = Resolve(context.TODO(), p, "demo", "plain"); err != nil || got != "plain" {
+		t.Errorf("Resolve() of plain value = %q, %v", got, err)
+	}
+	if _, err = Resolve(context.TODO(), p, "demo", "secret://ci/docker#user"); err == nil {
+		t.Errorf("Resolve() of a missing key should fail")
+	}
+	if _, err = Resolve(context.TODO(), p, "other", "secret://ci/docker#password"); err == nil {
+		t.Errorf("Resolve() of a secret of another project should fail")
+	}
+	if _, err = Resolve(context.TODO(), p, "", "secret://ci/docker#password"); err == nil {
+		t.Errorf("Resolve() without project should fail")
+	}
+	if _, err = Resolve(context.TODO(), nil, "demo", "secret://ci/docker#password"); err == nil {
+		t.Errorf("Resolve() without provider should fail")
+	}
+}
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		if r.URL.Path != "/v1/kv/data/demo/ci/docker" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"password":"s3cr3t","port":5000},"metadata":{"version":1}}}`))
+	}))
+	defer server.Close()
+
+	p := NewVaultProvider(server.URL, "token", "kv", "")
+	data, err := p.GetSecret(context.TODO(), "demo", "ci/docker")
+	if err != nil {
+		t.Fatalf("GetSecret() error: %v", err)
+	}
+	if data["password"] != "s3cr3t" || data["port"] != "5000" {
+		t.Errorf("GetSecret() = %v", data)
+	}
+
+	if _, err := NewVaultProvider(server.URL, "invalid", "kv", "").GetSecret(context.TODO(), "demo", "ci/docker"); err == nil {
+		t.Errorf("GetSecret() with invalid token should fail")
+	}
+	for _, tt := range []struct{ project, path string }{
+		{"other", "ci/docker"},
+		{"other", "../demo/ci/docker"},
+		{"demo", "ci/./docker"},
+		{"demo", "ci//docker"},
+		{"demo", "ci/docker%2F.."},
+		{"", "demo/ci/docker"},
+		{"..", "demo/ci/docker"},
+	} {
+		if _, err := p.GetSecret(context.TODO(), tt.project, tt.path); err == nil {
+			t.Errorf("GetSecret(%q, %q) should fail", tt.project, tt.path)
+		}
+	}
+}
+
+func TestKubernetesProvider(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "docker", Namespace: "zadig", Annotations: map[string]string{ProjectsAnnotation: "demo, test"}},
+			Data:       map[string][]byte{"password": []byte("s3cr3t")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "zadig", Annotations: map[string]string{ProjectsAnnotation: "*"}},
+			Data:       map[string][]byte{"token": []byte("t0ken")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "internal", Namespace: "zadig"},
+			Data:       map[string][]byte{"password": []byte("internal")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "docker", Namespace: "kube-system", Annotations: map[string]string{ProjectsAnnotation: "*"}},
+			Data:       map[string][]byte{"password": []byte("system")},
+		},
+	)
+	p := NewKubernetesProvider(clientset, "zadig")
+
+	tests := []struct {
+		project string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{project: "demo", value: "secret://docker#password", want: "s3cr3t"},
+		{project: "test", value: "secret://docker#password", want: "s3cr3t"},
+		{project: "other", value: "secret://shared#token", want: "t0ken"},
+		{project: "other", value: "secret://docker#password", wantErr: true},
+		{project: "demo", value: "secret://internal#password", wantErr: true},
+		{project: "demo", value: "secret://kube-system/docker#password", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Resolve(context.TODO(), p, tt.project, tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Resolve(%q, %q) error = %v, wantErr %v", tt.project, tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Resolve(%q, %q) = %q, want %q", tt.project, tt.value, got, tt.want)
+		}
+	}
+}
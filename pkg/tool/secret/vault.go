@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VaultProvider reads secrets from a Vault compatible KV version 2 secrets engine.
+// The secrets of a project are kept under the path named after the project, e.g. the
+// path ci/docker of project demo is read from <mount>/data/demo/ci/docker.
+type VaultProvider struct {
+	Address string
+	Token   string
+	// Mount is the path the KV engine is mounted at, secret is used if it is empty
+	Mount string
+	// Namespace is the Vault Enterprise namespace, optional
+	Namespace string
+
+	client *http.Client
+}
+
+func NewVaultProvider(address, token, mount, namespace string) *VaultProvider {
+	if mount == "" {
+		mount = "secret"
+	}
+	return &VaultProvider{
+		Address:   strings.TrimRight(address, "/"),
+		Token:     token,
+		Mount:     strings.Trim(mount, "/"),
+		Namespace: namespace,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type vaultKVResponse struct {
+	Data struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+func (p *VaultProvider) GetSecret(ctx context.Context, project, path string) (map[string]string, error) {
+	if err := validatePath(project); err != nil {
+		return nil, fmt.Errorf("invalid project %s: %s", project, err)
+	}
+	if err := validatePath(path); err != nil {
+		return nil, fmt.Errorf("invalid path %s: %s", path, err)
+	}
+	url := fmt.Sprintf("%s/v1/%s/data/%s/%s", p.Address, p.Mount, project, strings.Trim(path, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", p.Token)
+	if p.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.Namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	res := &vaultKVResponse{}
+	if err := json.Unmarshal(body, res); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("invalid response from vault: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault responded with status %d: %s", resp.StatusCode, strings.Join(res.Errors, ","))
+	}
+
+	data := make(map[string]string, len(res.Data.Data))
+	for k, v := range res.Data.Data {
+		if s, ok := v.(string); ok {
+			data[k] = s
+			continue
+		}
+		bs, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		data[k] = string(bs)
+	}
+	return data, nil
+}
+
+// validatePath makes sure the path can't escape the directory of the project.
+func validatePath(path string) error {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return fmt.Errorf("path can't be empty")
+	}
+	for _, seg := range strings.Split(path, "/") {
+		if seg == "" || seg == "." || seg == ".." || strings.ContainsAny(seg, "?#%\\") {
+			return fmt.Errorf("invalid path segment %q", seg)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"fmt"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+const defaultAddress = "https://slack.com/api"
+
+type Client struct {
+	*httpclient.Client
+}
+
+// NewClient creates a client with the bot user OAuth token of a slack app.
+func NewClient(botToken string) *Client {
+	return &Client{
+		Client: httpclient.New(
+			httpclient.SetHostURL(defaultAddress),
+			httpclient.SetAuthScheme("Bearer"),
+			httpclient.SetAuthToken(botToken),
+		),
+	}
+}
+
+type response struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+func (r *response) err() error {
+	if !r.OK {
+		return fmt.Errorf("slack error: %s", r.Error)
+	}
+	return nil
+}
+
+// Validate checks whether the bot token is valid.
+func Validate(botToken string) error {
+	resp := &response{}
+	if _, err := NewClient(botToken).Post("/auth.test", httpclient.SetResult(resp)); err != nil {
+		return err
+	}
+	return resp.err()
+}
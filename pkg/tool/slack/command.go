@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+// maxRequestAge is the max age of a request from slack, older requests are rejected to prevent replay attacks
+const maxRequestAge = 5 * time.Minute
+
+const (
+	ResponseTypeInChannel = "in_channel"
+	ResponseTypeEphemeral = "ephemeral"
+)
+
+// SlashCommand is the payload slack sends to the request url of a slash command
+type SlashCommand struct {
+	TeamID      string
+	ChannelID   string
+	UserID      string
+	UserName    string
+	Command     string
+	Text        string
+	ResponseURL string
+}
+
+type CommandResponse struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+func ParseSlashCommand(body []byte) (*SlashCommand, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	return &SlashCommand{
+		TeamID:      values.Get("team_id"),
+		ChannelID:   values.Get("channel_id"),
+		UserID:      values.Get("user_id"),
+		UserName:    values.Get("user_name"),
+		Command:     values.Get("command"),
+		Text:        values.Get("text"),
+		ResponseURL: values.Get("response_url"),
+	}, nil
+}
+
+// VerifySignature checks the X-Slack-Signature header of a request with the signing secret of the app.
+func VerifySignature(signingSecret, timestamp, signature string, body []byte) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+	if math.Abs(time.Since(time.Unix(ts, 0)).Seconds()) > maxRequestAge.Seconds() {
+		return fmt.Errorf("request timestamp %s is out of range", timestamp)
+	}
+
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// Respond sends a delayed response of a slash command to the response url.
+func Respond(responseURL string, resp *CommandResponse) error {
+	_, err := httpclient.Post(responseURL, httpclient.SetBody(resp))
+	return err
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func sign(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + ts + ":"))
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte("command=%2Fzadig&text=status+demo&user_id=U123")
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+
+	if err := VerifySignature("s3cr3t", now, sign("s3cr3t", now, body), body); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if err := VerifySignature("wrong", now, sign("s3cr3t", now, body), body); err == nil {
+		t.Errorf("expected an error for a wrong secret")
+	}
+
+	old := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+	if err := VerifySignature("s3cr3t", old, sign("s3cr3t", old, body), body); err == nil {
+		t.Errorf("expected an error for an expired request")
+	}
+}
+
+func TestParseSlashCommand(t *testing.T) {
+	cmd, err := ParseSlashCommand([]byte("command=%2Fzadig&text=run+demo+env%3Ddev&user_id=U123&response_url=https%3A%2F%2Fhooks.slack.com%2Fx"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if cmd.Command != "/zadig" || cmd.Text != "run demo env=dev" || cmd.UserID != "U123" || cmd.ResponseURL != "https://hooks.slack.com/x" {
+		t.Errorf("unexpected command: %+v", cmd)
+	}
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+type UserInfo struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Profile struct {
+		Email string `json:"email"`
+		Phone string `json:"phone"`
+	} `json:"profile"`
+}
+
+type userInfoResp struct {
+	response
+	User *UserInfo `json:"user"`
+}
+
+// GetUserInfo returns the user with the profile, the email is returned only if the app has the users:read.email scope.
+func (c *Client) GetUserInfo(userID string) (*UserInfo, error) {
+	resp := &userInfoResp{}
+	if _, err := c.Get("/users.info", httpclient.SetQueryParam("user", userID), httpclient.SetResult(resp)); err != nil {
+		return nil, err
+	}
+	if err := resp.err(); err != nil {
+		return nil, err
+	}
+	return resp.User, nil
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// the test vectors of RFC 6238 with SHA1, truncated to 6 digits
+func TestCode(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	cases := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for ts, expected := range cases {
+		code, err := Code(secret, time.Unix(ts, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != expected {
+			t.Errorf("time %d: expected %s, got %s", ts, expected, code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	code, err := Code(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !Validate(secret, code, now) {
+		t.Error("the code of the current period should be valid")
+	}
+	if !Validate(secret, code, now.Add(period*time.Second)) {
+		t.Error("the code of the previous period should be valid")
+	}
+	if Validate(secret, code, now.Add(3*period*time.Second)) {
+		t.Error("the code of an earlier period should be invalid")
+	}
+	if Validate(secret, "12345", now) {
+		t.Error("a code with wrong length should be invalid")
+	}
+}
+
+func TestValidateStep(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1700000010, 0)
+	code, err := Code(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step, ok := ValidateStep(secret, code, now)
+	if !ok || step != now.Unix()/period {
+		t.Errorf("expected step %d, got %d, valid %v", now.Unix()/period, step, ok)
+	}
+	// the step is the one the code is generated for, not the one it is validated at
+	step, ok = ValidateStep(secret, code, now.Add(period*time.Second))
+	if !ok || step != now.Unix()/period {
+		t.Errorf("expected step %d of the previous period, got %d, valid %v", now.Unix()/period, step, ok)
+	}
+	if _, ok = ValidateStep(secret, "000000x", now); ok {
+		t.Error("a code with wrong length should be invalid")
+	}
+}
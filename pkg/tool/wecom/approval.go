@@ -0,0 +1,167 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wecom
+
+import (
+	"errors"
+)
+
+// Statuses of an approval
+const (
+	ApprovalStatusPending  = 1
+	ApprovalStatusApproved = 2
+	ApprovalStatusRejected = 3
+	ApprovalStatusRevoked  = 4
+	ApprovalStatusDeleted  = 7
+)
+
+// Statuses of an approver in an approval node
+const (
+	ApproverStatusPending  = 1
+	ApproverStatusApproved = 2
+	ApproverStatusRejected = 3
+)
+
+const (
+	ApproverAttrAnd = 1
+	ApproverAttrOr  = 2
+
+	controlTextarea = "Textarea"
+	controlText     = "Text"
+)
+
+type templateControl struct {
+	Property struct {
+		Control string `json:"control"`
+		ID      string `json:"id"`
+	} `json:"property"`
+}
+
+type templateDetailResp struct {
+	response
+	TemplateContent struct {
+		Controls []*templateControl `json:"controls"`
+	} `json:"template_content"`
+}
+
+// GetTemplateTextControl returns the control and id of the first text control of the template,
+// the approval details are filled into it.
+func (c *Client) GetTemplateTextControl(templateID string) (string, string, error) {
+	resp := &templateDetailResp{}
+	if err := c.post("/cgi-bin/oa/gettemplatedetail", map[string]string{"template_id": templateID}, resp); err != nil {
+		return "", "", err
+	}
+	for _, control := range resp.TemplateContent.Controls {
+		if control.Property.Control == controlTextarea || control.Property.Control == controlText {
+			return control.Property.Control, control.Property.ID, nil
+		}
+	}
+	return "", "", errors.New("no text control found in the approval template")
+}
+
+type Approver struct {
+	Attr    int      `json:"attr"`
+	UserIDs []string `json:"userid"`
+}
+
+type CreateApprovalArgs struct {
+	CreatorUserID string
+	TemplateID    string
+	Approvers     []*Approver
+	Control       string
+	ControlID     string
+	Content       string
+	Summary       string
+}
+
+type createApprovalResp struct {
+	response
+	SpNo string `json:"sp_no"`
+}
+
+type textValue struct {
+	Text string `json:"text"`
+}
+
+type applyContent struct {
+	Control string    `json:"control"`
+	ID      string    `json:"id"`
+	Value   textValue `json:"value"`
+}
+
+type summaryInfo struct {
+	Text string `json:"text"`
+	Lang string `json:"lang"`
+}
+
+// CreateApproval creates an approval with the approvers given instead of the ones of the template, the number of it is returned.
+func (c *Client) CreateApproval(args *CreateApprovalArgs) (string, error) {
+	body := map[string]interface{}{
+		"creator_userid":        args.CreatorUserID,
+		"template_id":           args.TemplateID,
+		"use_template_approver": 0,
+		"approver":              args.Approvers,
+		"apply_data": map[string]interface{}{
+			"contents": []*applyContent{{
+				Control: args.Control,
+				ID:      args.ControlID,
+				Value:   textValue{Text: args.Content},
+			}},
+		},
+		"summary_list": []map[string]interface{}{{
+			"summary_info": []*summaryInfo{{Text: args.Summary, Lang: "zh_CN"}},
+		}},
+	}
+	resp := &createApprovalResp{}
+	if err := c.post("/cgi-bin/oa/applyevent", body, resp); err != nil {
+		return "", err
+	}
+	return resp.SpNo, nil
+}
+
+type ApproverDetail struct {
+	Approver struct {
+		UserID string `json:"userid"`
+	} `json:"approver"`
+	Speech   string `json:"speech"`
+	SpStatus int    `json:"sp_status"`
+	SpTime   int64  `json:"sptime"`
+}
+
+type ApprovalRecord struct {
+	SpStatus int               `json:"sp_status"`
+	Details  []*ApproverDetail `json:"details"`
+}
+
+type ApprovalDetail struct {
+	SpNo     string            `json:"sp_no"`
+	SpStatus int               `json:"sp_status"`
+	SpRecord []*ApprovalRecord `json:"sp_record"`
+}
+
+type approvalDetailResp struct {
+	response
+	Info *ApprovalDetail `json:"info"`
+}
+
+func (c *Client) GetApprovalDetail(spNo string) (*ApprovalDetail, error) {
+	resp := &approvalDetailResp{}
+	if err := c.post("/cgi-bin/oa/getapprovaldetail", map[string]string{"sp_no": spNo}, resp); err != nil {
+		return nil, err
+	}
+	return resp.Info, nil
+}
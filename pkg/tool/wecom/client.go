@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wecom
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/koderover/zadig/pkg/tool/httpclient"
+)
+
+const defaultAddress = "https://qyapi.weixin.qq.com"
+
+type Client struct {
+	*httpclient.Client
+
+	corpID string
+	secret string
+
+	mu       sync.Mutex
+	token    string
+	expireAt time.Time
+}
+
+// NewClient creates a client of a wecom application, the secret must be the one of the approval application
+// or of a self-built application which is granted the approval permission.
+func NewClient(corpID, secret string) *Client {
+	return &Client{
+		Client: httpclient.New(httpclient.SetHostURL(defaultAddress)),
+		corpID: corpID,
+		secret: secret,
+	}
+}
+
+type response struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func (r *response) err() error {
+	if r.ErrCode != 0 {
+		return fmt.Errorf("wecom error, code: %d, message: %s", r.ErrCode, r.ErrMsg)
+	}
+	return nil
+}
+
+type accessTokenResp struct {
+	response
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// getAccessToken returns the cached access token of the application, it is refreshed 5 minutes before it expires.
+func (c *Client) getAccessToken() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.expireAt) {
+		return c.token, nil
+	}
+	resp := &accessTokenResp{}
+	_, err := c.Get("/cgi-bin/gettoken", httpclient.SetQueryParams(map[string]string{
+		"corpid":     c.corpID,
+		"corpsecret": c.secret,
+	}), httpclient.SetResult(resp))
+	if err != nil {
+		return "", err
+	}
+	if err := resp.err(); err != nil {
+		return "", err
+	}
+	c.token = resp.AccessToken
+	c.expireAt = time.Now().Add(time.Duration(resp.ExpiresIn-300) * time.Second)
+	return c.token, nil
+}
+
+func (c *Client) post(url string, body interface{}, result interface{ err() error }) error {
+	token, err := c.getAccessToken()
+	if err != nil {
+		return err
+	}
+	_, err = c.Post(url, httpclient.SetQueryParam("access_token", token), httpclient.SetBody(body), httpclient.SetResult(result))
+	if err != nil {
+		return err
+	}
+	return result.err()
+}
+
+// Validate checks whether the corp id and secret are able to get an access token.
+func Validate(corpID, secret string) error {
+	_, err := NewClient(corpID, secret).getAccessToken()
+	return err
+}
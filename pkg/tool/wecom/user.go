@@ -0,0 +1,39 @@
+/*
+Copyright 2022 The KodeRover Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wecom
+
+type userIDResp struct {
+	response
+	UserID string `json:"userid"`
+}
+
+func (c *Client) GetUserIDByMobile(mobile string) (string, error) {
+	resp := &userIDResp{}
+	if err := c.post("/cgi-bin/user/getuserid", map[string]string{"mobile": mobile}, resp); err != nil {
+		return "", err
+	}
+	return resp.UserID, nil
+}
+
+// GetUserIDByEmail looks up the user by the corporate email.
+func (c *Client) GetUserIDByEmail(email string) (string, error) {
+	resp := &userIDResp{}
+	if err := c.post("/cgi-bin/user/get_userid_by_email", map[string]interface{}{"email": email, "email_type": 1}, resp); err != nil {
+		return "", err
+	}
+	return resp.UserID, nil
+}
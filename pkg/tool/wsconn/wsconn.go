@@ -26,6 +26,7 @@ import (
 	"github.com/gorilla/websocket"
 	"golang.org/x/crypto/ssh"
 
+	"github.com/koderover/zadig/pkg/tool/asciicast"
 	"github.com/koderover/zadig/pkg/tool/log"
 )
 
@@ -45,13 +46,17 @@ type wsMessage struct {
 }
 
 type wsBufferWriter struct {
-	buffer bytes.Buffer
-	mu     sync.Mutex
+	buffer   bytes.Buffer
+	mu       sync.Mutex
+	recorder asciicast.Recorder
 }
 
 func (w *wsBufferWriter) Write(p []byte) (int, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	if w.recorder != nil {
+		w.recorder.Output(p)
+	}
 	return w.buffer.Write(p)
 }
 
@@ -59,6 +64,7 @@ type SshConn struct {
 	Stdin      io.WriteCloser
 	WsWriter   *wsBufferWriter
 	SshSession *ssh.Session
+	recorder   asciicast.Recorder
 }
 
 func NewSshConn(cols, rows int, sshClient *ssh.Client) (*SshConn, error) {
@@ -91,6 +97,18 @@ func NewSshConn(cols, rows int, sshClient *ssh.Client) (*SshConn, error) {
 	return &SshConn{Stdin: stdin, WsWriter: wsWriter, SshSession: sshSession}, nil
 }
 
+// SetRecorder records the traffic of the session with r, it should be called before the session is served.
+func (ssConn *SshConn) SetRecorder(r asciicast.Recorder) {
+	ssConn.recorder = r
+	ssConn.WsWriter.mu.Lock()
+	// output that has not been flushed yet, e.g. the login banner
+	if ssConn.WsWriter.buffer.Len() > 0 {
+		r.Output(ssConn.WsWriter.buffer.Bytes())
+	}
+	ssConn.WsWriter.recorder = r
+	ssConn.WsWriter.mu.Unlock()
+}
+
 func (ssConn *SshConn) ReadWsMessage(wsConn *websocket.Conn, stopCh chan bool) {
 	defer setStop(stopCh)
 	for {
@@ -112,12 +130,18 @@ func (ssConn *SshConn) ReadWsMessage(wsConn *websocket.Conn, stopCh chan bool) {
 			switch wsMsgObj.Operation {
 			case wsMsgResize:
 				if wsMsgObj.Cols > 0 && wsMsgObj.Rows > 0 {
+					if ssConn.recorder != nil {
+						ssConn.recorder.Resize(wsMsgObj.Cols, wsMsgObj.Rows)
+					}
 					if err := ssConn.SshSession.WindowChange(wsMsgObj.Rows, wsMsgObj.Cols); err != nil {
 						log.Error("resize windows err:", err)
 					}
 				}
 			case wsMsgStdin:
 				decodeBytes := []byte(wsMsgObj.Data)
+				if ssConn.recorder != nil {
+					ssConn.recorder.Input(decodeBytes)
+				}
 				if _, err := ssConn.Stdin.Write(decodeBytes); err != nil {
 					log.Error("ws stdin write to ssh.stdin err:", err)
 					setStop(stopCh)
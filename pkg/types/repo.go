@@ -140,6 +140,12 @@ const (
 	// ProviderGiteeEE
 	ProviderGiteeEE = "gitee-enterprise"
 
+	// ProviderBitbucket
+	ProviderBitbucket = "bitbucket"
+
+	// ProviderGitea
+	ProviderGitea = "gitea"
+
 	// ProviderOther
 	ProviderOther = "other"
 )
@@ -149,13 +155,9 @@ const (
 //
 // e.g. github returns refs/pull/1/head
 // e.g. gitlab returns merge-requests/1/head
+// e.g. bitbucket returns refs/pull-requests/1/from
 func (r *Repository) PRRef() string {
-	if strings.ToLower(r.Source) == ProviderGitlab || strings.ToLower(r.Source) == ProviderCodehub {
-		return fmt.Sprintf("merge-requests/%d/head", r.PR)
-	} else if strings.ToLower(r.Source) == ProviderGerrit {
-		return r.CheckoutRef
-	}
-	return fmt.Sprintf("refs/pull/%d/head", r.PR)
+	return r.PRRefByPRID(r.PR)
 }
 
 func (r *Repository) PRRefByPRID(pr int) string {
@@ -163,6 +165,12 @@ func (r *Repository) PRRefByPRID(pr int) string {
 		return fmt.Sprintf("merge-requests/%d/head", pr)
 	} else if strings.ToLower(r.Source) == ProviderGerrit {
 		return r.CheckoutRef
+	} else if strings.ToLower(r.Source) == ProviderBitbucket {
+		// Bitbucket Cloud does not expose pull request refs, the source branch is set as checkout ref instead
+		if r.CheckoutRef != "" {
+			return r.CheckoutRef
+		}
+		return fmt.Sprintf("refs/pull-requests/%d/from", pr)
 	}
 	return fmt.Sprintf("refs/pull/%d/head", pr)
 }